@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// lockChainHighWait is the longest wait at which a lock chain becomes high
+// severity.
+const lockChainHighWait = time.Minute
+
+// detectIdleInTransaction flags sessions that have sat idle inside an open
+// transaction for longer than threshold. They hold their locks and their
+// snapshot keeps vacuum from removing dead tuples everywhere.
+func detectIdleInTransaction(sessions []postgres.SessionInfo, threshold time.Duration) []Finding {
+	blocking := blockedCounts(sessions)
+
+	var findings []Finding
+	for _, s := range sessions {
+		if !strings.HasPrefix(s.State, "idle in transaction") {
+			continue
+		}
+		idle := seconds(s.StateSeconds)
+		if idle < threshold {
+			continue
+		}
+		f := Finding{
+			Type:     FindingIdleInTransaction,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("session %d (%s) has been idle in transaction for %s", s.PID, sessionOwner(s), idle),
+			Detail:   sessionDetail(s),
+		}
+		f.Detail["idle_for"] = idle.String()
+		f.Detail["transaction_age"] = seconds(s.XactSeconds).String()
+		if n := blocking[s.PID]; n > 0 {
+			f.Severity = SeverityHigh
+			f.Message += fmt.Sprintf(" and is blocking %d sessions", n)
+			f.Detail["blocking"] = strconv.Itoa(n)
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// detectLockChains reports each session at the head of a lock wait chain:
+// it blocks others but is not waiting on anyone itself. The finding lists
+// every session waiting on it, directly or through another waiter.
+func detectLockChains(sessions []postgres.SessionInfo) []Finding {
+	byPID := make(map[int32]postgres.SessionInfo, len(sessions))
+	waiters := make(map[int32][]int32)
+	for _, s := range sessions {
+		byPID[s.PID] = s
+		for _, b := range s.BlockedBy {
+			waiters[b] = append(waiters[b], s.PID)
+		}
+	}
+
+	var findings []Finding
+	for _, root := range sessions {
+		if len(root.BlockedBy) > 0 || len(waiters[root.PID]) == 0 {
+			continue
+		}
+		seen := map[int32]bool{root.PID: true}
+		queue := append([]int32(nil), waiters[root.PID]...)
+		var blocked []string
+		var longest time.Duration
+		schema, table := "", ""
+		for len(queue) > 0 {
+			pid := queue[0]
+			queue = queue[1:]
+			if seen[pid] {
+				continue
+			}
+			seen[pid] = true
+			blocked = append(blocked, strconv.Itoa(int(pid)))
+			w := byPID[pid]
+			if d := seconds(w.StateSeconds); d > longest {
+				longest = d
+			}
+			if table == "" && w.WaitTable != "" {
+				schema, table = w.WaitSchema, w.WaitTable
+			}
+			queue = append(queue, waiters[pid]...)
+		}
+
+		sev := SeverityMedium
+		if longest >= lockChainHighWait {
+			sev = SeverityHigh
+		}
+		detail := sessionDetail(root)
+		detail["blocked_pids"] = strings.Join(blocked, ", ")
+		detail["longest_wait"] = longest.String()
+		findings = append(findings, Finding{
+			Type:     FindingLockChain,
+			Severity: sev,
+			Schema:   schema,
+			Table:    table,
+			Message: fmt.Sprintf("session %d (%s, %s) is blocking %d sessions; longest wait %s",
+				root.PID, sessionOwner(root), root.State, len(blocked), longest),
+			Detail: detail,
+		})
+	}
+	return findings
+}
+
+// blockedCounts counts the sessions directly waiting on each pid.
+func blockedCounts(sessions []postgres.SessionInfo) map[int32]int {
+	counts := make(map[int32]int)
+	for _, s := range sessions {
+		for _, b := range s.BlockedBy {
+			counts[b]++
+		}
+	}
+	return counts
+}
+
+func sessionOwner(s postgres.SessionInfo) string {
+	if s.Application != "" {
+		return s.User + "/" + s.Application
+	}
+	return s.User
+}
+
+func sessionDetail(s postgres.SessionInfo) map[string]string {
+	detail := map[string]string{
+		"pid":   strconv.Itoa(int(s.PID)),
+		"user":  s.User,
+		"state": s.State,
+		"query": truncateSQL(strings.Join(strings.Fields(s.Query), " ")),
+	}
+	if s.Application != "" {
+		detail["application"] = s.Application
+	}
+	if s.ClientAddr != "" {
+		detail["client_addr"] = s.ClientAddr
+	}
+	return detail
+}
+
+// seconds converts a duration in seconds, rounded to the second.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Second)
+}
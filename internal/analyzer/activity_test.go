@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func activitySessions() []postgres.SessionInfo {
+	return []postgres.SessionInfo{
+		// Idle in transaction for 10 minutes, holding a lock two sessions wait on.
+		{PID: 100, User: "app", Application: "api", State: "idle in transaction", XactSeconds: 900, StateSeconds: 600, Query: "UPDATE orders SET status = $1 WHERE id = $2"},
+		{PID: 101, User: "app", State: "active", StateSeconds: 90, WaitEventType: "Lock", BlockedBy: []int32{100}, WaitSchema: "public", WaitTable: "orders"},
+		{PID: 102, User: "app", State: "active", StateSeconds: 20, WaitEventType: "Lock", BlockedBy: []int32{101}},
+		// Briefly idle in transaction; under the threshold.
+		{PID: 200, User: "worker", State: "idle in transaction", XactSeconds: 5, StateSeconds: 2},
+		// Long idle in an aborted transaction, blocking nobody.
+		{PID: 300, User: "report", State: "idle in transaction (aborted)", XactSeconds: 4000, StateSeconds: 3600},
+		{PID: 400, User: "app", State: "idle", StateSeconds: 7200},
+	}
+}
+
+func TestDetectIdleInTransaction(t *testing.T) {
+	findings := detectIdleInTransaction(activitySessions(), 5*time.Minute)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Detail["pid"] != "100" || f.Severity != SeverityHigh || f.Detail["blocking"] != "1" {
+		t.Errorf("pid 100: %+v", f)
+	}
+	if f.Detail["idle_for"] != "10m0s" || f.Detail["transaction_age"] != "15m0s" || f.Detail["application"] != "api" {
+		t.Errorf("pid 100 detail: %v", f.Detail)
+	}
+	if f := findings[1]; f.Detail["pid"] != "300" || f.Severity != SeverityMedium {
+		t.Errorf("pid 300: %+v", f)
+	}
+}
+
+func TestDetectLockChains(t *testing.T) {
+	findings := detectLockChains(activitySessions())
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Detail["pid"] != "100" || f.Detail["blocked_pids"] != "101, 102" || f.Detail["longest_wait"] != "1m30s" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Severity != SeverityHigh || f.Table != "orders" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	if got := detectLockChains(nil); got != nil {
+		t.Errorf("without activity: got %+v", got)
+	}
+}
@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -14,8 +15,8 @@ func tableKey(schema, table string) string {
 	return schema + "." + table
 }
 
-// Audit analyzes a catalog snapshot and returns findings.
-func Audit(snap *postgres.Snapshot, opts AuditOptions) []Finding {
+// withDefaults fills unset thresholds from DefaultAuditOptions.
+func (opts AuditOptions) withDefaults() AuditOptions {
 	defaults := DefaultAuditOptions()
 	if opts.VacuumDays <= 0 {
 		opts.VacuumDays = defaults.VacuumDays
@@ -26,6 +27,42 @@ func Audit(snap *postgres.Snapshot, opts AuditOptions) []Finding {
 	if opts.BloatMinBytes <= 0 {
 		opts.BloatMinBytes = defaults.BloatMinBytes
 	}
+	if opts.SequenceExhaustionPct <= 0 {
+		opts.SequenceExhaustionPct = defaults.SequenceExhaustionPct
+	}
+	if opts.SeqScanMinBytes <= 0 {
+		opts.SeqScanMinBytes = defaults.SeqScanMinBytes
+	}
+	if opts.SeqScanRatio <= 0 {
+		opts.SeqScanRatio = defaults.SeqScanRatio
+	}
+	if opts.CacheHitRatio <= 0 {
+		opts.CacheHitRatio = defaults.CacheHitRatio
+	}
+	if opts.HotTableBlocks <= 0 {
+		opts.HotTableBlocks = defaults.HotTableBlocks
+	}
+	if opts.RowWidthBytes <= 0 {
+		opts.RowWidthBytes = defaults.RowWidthBytes
+	}
+	if opts.WideTableColumns <= 0 {
+		opts.WideTableColumns = defaults.WideTableColumns
+	}
+	if opts.IdleTransactionSeconds <= 0 {
+		opts.IdleTransactionSeconds = defaults.IdleTransactionSeconds
+	}
+	if opts.MinStatsWindowDays <= 0 {
+		opts.MinStatsWindowDays = defaults.MinStatsWindowDays
+	}
+	if len(opts.FKColumnPatterns) == 0 {
+		opts.FKColumnPatterns = defaults.FKColumnPatterns
+	}
+	return opts
+}
+
+// Audit analyzes a catalog snapshot and returns findings.
+func Audit(snap *postgres.Snapshot, opts AuditOptions) []Finding {
+	opts = opts.withDefaults()
 
 	excludeTable := make(map[string]bool, len(opts.ExcludeTables))
 	for _, t := range opts.ExcludeTables {
@@ -48,48 +85,227 @@ func Audit(snap *postgres.Snapshot, opts AuditOptions) []Finding {
 	}
 
 	tableSizeMap := make(map[string]int64, len(snap.Tables))
+	tableRowsMap := make(map[string]int64, len(snap.Tables))
+	tableOptions := make(map[string][]string)
 	for _, t := range snap.Tables {
+		if len(t.Options) > 0 {
+			tableOptions[tableKey(t.Schema, t.Name)] = t.Options
+		}
 		if t.SizeBytes > 0 {
 			tableSizeMap[tableKey(t.Schema, t.Name)] = t.SizeBytes
 		}
+		if t.EstimatedRows > 0 {
+			tableRowsMap[tableKey(t.Schema, t.Name)] = t.EstimatedRows
+		}
+	}
+	colStats := buildColumnStatsMap(snap.ColumnStats)
+
+	var filteredMatViews []postgres.MatViewInfo
+	matviewSet := make(map[string]bool, len(snap.MatViews))
+	for _, mv := range snap.MatViews {
+		matviewSet[tableKey(mv.Schema, mv.Name)] = true
+		if excludeTable[strings.ToLower(mv.Name)] || excludeSchema[strings.ToLower(mv.Schema)] {
+			continue
+		}
+		filteredMatViews = append(filteredMatViews, mv)
 	}
 
-	// Filter stats and tables by exclusions
-	var filteredStats []postgres.TableStats
+	var filteredTriggers []postgres.TriggerInfo
+	for _, tr := range snap.Triggers {
+		if excludeTable[strings.ToLower(tr.Table)] || excludeSchema[strings.ToLower(tr.Schema)] {
+			continue
+		}
+		filteredTriggers = append(filteredTriggers, tr)
+	}
+
+	var filteredViews []postgres.ViewInfo
+	for _, v := range snap.Views {
+		if excludeTable[strings.ToLower(v.Name)] || excludeSchema[strings.ToLower(v.Schema)] {
+			continue
+		}
+		filteredViews = append(filteredViews, v)
+	}
+
+	// Partitions share their parent's definition, so definition checks run
+	// on the parent only, while storage and activity checks run per
+	// partition. Partitioned tables themselves hold no rows.
+	partitions := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+	var filteredPartitioned []postgres.PartitionedTableInfo
+	for _, pt := range snap.PartitionedTables {
+		if excludeTable[strings.ToLower(pt.Name)] || excludeSchema[strings.ToLower(pt.Schema)] {
+			continue
+		}
+		filteredPartitioned = append(filteredPartitioned, pt)
+	}
+
+	// Filter stats and tables by exclusions. pg_stat_user_tables includes
+	// materialized views, which detectStaleMatViews covers.
+	var filteredStats, unpartitionedStats []postgres.TableStats
 	for i := range snap.Stats {
 		s := &snap.Stats[i]
 		if excludeTable[strings.ToLower(s.Name)] || excludeSchema[strings.ToLower(s.Schema)] {
 			continue
 		}
+		key := tableKey(s.Schema, s.Name)
+		if matviewSet[key] || partitions.partitioned[key] {
+			continue
+		}
 		filteredStats = append(filteredStats, *s)
+		if !partitions.isPartition(key) {
+			unpartitionedStats = append(unpartitionedStats, *s)
+		}
 	}
 
-	var filteredTables []postgres.TableInfo
+	var filteredTables, definitionTables []postgres.TableInfo
 	for _, t := range snap.Tables {
 		if excludeTable[strings.ToLower(t.Name)] || excludeSchema[strings.ToLower(t.Schema)] {
 			continue
 		}
 		filteredTables = append(filteredTables, t)
+		if !partitions.isPartition(tableKey(t.Schema, t.Name)) {
+			definitionTables = append(definitionTables, t)
+		}
 	}
 
-	var filteredIndexes []postgres.IndexInfo
+	var filteredIndexes, definitionIndexes []postgres.IndexInfo
 	for _, idx := range snap.Indexes {
 		if excludeTable[strings.ToLower(idx.Table)] || excludeSchema[strings.ToLower(idx.Schema)] {
 			continue
 		}
 		filteredIndexes = append(filteredIndexes, idx)
+		if !partitions.isPartition(tableKey(idx.Schema, idx.Table)) {
+			definitionIndexes = append(definitionIndexes, idx)
+		}
+	}
+
+	var filteredSequences []postgres.SequenceInfo
+	for _, seq := range snap.Sequences {
+		if excludeTable[strings.ToLower(seq.Table)] || excludeSchema[strings.ToLower(seq.Schema)] {
+			continue
+		}
+		filteredSequences = append(filteredSequences, seq)
 	}
 
+	var filteredColumns []postgres.ColumnInfo
+	for _, c := range snap.Columns {
+		if excludeTable[strings.ToLower(c.Table)] || excludeSchema[strings.ToLower(c.Schema)] {
+			continue
+		}
+		if partitions.isPartition(tableKey(c.Schema, c.Table)) {
+			continue
+		}
+		filteredColumns = append(filteredColumns, c)
+	}
+
+	var filteredGrants []postgres.GrantInfo
+	for _, g := range snap.Grants {
+		if excludeTable[strings.ToLower(g.Table)] || excludeSchema[strings.ToLower(g.Schema)] {
+			continue
+		}
+		filteredGrants = append(filteredGrants, g)
+	}
+
+	var filteredConstraints []postgres.ConstraintInfo
+	for _, c := range snap.Constraints {
+		if excludeTable[strings.ToLower(c.Table)] || excludeSchema[strings.ToLower(c.Schema)] {
+			continue
+		}
+		if partitions.isPartition(tableKey(c.Schema, c.Table)) {
+			continue
+		}
+		filteredConstraints = append(filteredConstraints, c)
+	}
+
+	var filteredColumnStats []postgres.ColumnStats
+	for _, cs := range snap.ColumnStats {
+		if excludeTable[strings.ToLower(cs.Table)] || excludeSchema[strings.ToLower(cs.Schema)] {
+			continue
+		}
+		filteredColumnStats = append(filteredColumnStats, cs)
+	}
+
+	checks := newCheckSet(opts.Checks)
+	allStats := statsByKey(snap.Stats)
+
 	var findings []Finding
 
-	findings = append(findings, detectUnusedTables(filteredStats)...)
+	findings = append(findings, detectUnusedTables(unpartitionedStats)...)
+	findings = append(findings, detectUnusedPartitionedTables(filteredPartitioned, partitions, allStats)...)
+	findings = append(findings, detectHotDefaultPartitions(filteredPartitioned, partitions, allStats)...)
+	findings = append(findings, detectMissingPartitionKeyIndexes(filteredPartitioned, snap.Indexes)...)
+	findings = append(findings, detectInconsistentPartitionIndexes(filteredPartitioned, partitions, filteredIndexes)...)
 	findings = append(findings, detectUnusedIndexes(filteredIndexes, unusedIndexMin)...)
 	findings = append(findings, detectBloatedIndexes(filteredIndexes, tableSizeMap, bloatMin)...)
 	findings = append(findings, detectMissingVacuum(filteredStats, time.Now(), vacuumThreshold)...)
-	findings = append(findings, detectNoPrimaryKey(filteredTables, pkSet)...)
+	findings = append(findings, detectAutovacuumDisabled(filteredTables, allStats)...)
+	wide := wideColumns(snap.ColumnStats)
+	findings = append(findings, detectToastBloat(filteredTables, wide)...)
+	findings = append(findings, detectOversizedRows(filteredTables, wide, opts.RowWidthBytes)...)
+	findings = append(findings, detectNoPrimaryKey(definitionTables, pkSet)...)
+	findings = append(findings, detectUnloggedTables(filteredTables)...)
+	findings = append(findings, detectStaleMatViews(filteredMatViews)...)
+	findings = append(findings, detectUnusedViews(filteredViews, snap.Views, snap.Stats)...)
+	findings = append(findings, detectBrokenViews(filteredViews)...)
+	findings = append(findings, detectDisabledTriggers(filteredTriggers)...)
+	findings = append(findings, detectBrokenTriggers(filteredTriggers, snap, opts.PartialCatalog)...)
 	findings = append(findings, detectDuplicateIndexes(filteredIndexes)...)
+	findings = append(findings, detectInvalidIndexes(filteredIndexes)...)
+	findings = append(findings, detectSequenceExhaustion(filteredSequences, opts.SequenceExhaustionPct)...)
+	findings = append(findings, detectLowCacheHits(filteredStats, tableSizeMap, opts.CacheHitRatio)...)
+	findings = append(findings, detectLowCacheHitRatio(filteredStats, tableSizeMap, opts.CacheHitRatio, opts.HotTableBlocks)...)
+	instance := SummarizeInstance(snap.Instance, snap.IO)
+	findings = append(findings, detectFrequentCheckpoints(snap.Instance, instance)...)
+	findings = append(findings, detectBackendBufferWrites(instance)...)
+	findings = append(findings, detectLowHOTRatios(filteredStats, tableOptions)...)
+	findings = append(findings, detectHighSeqScans(filteredStats, tableSizeMap, opts.SeqScanMinBytes, opts.SeqScanRatio)...)
+	findings = append(findings, detectSeqScanQueries(snap.Statements, filteredTables, snap.Indexes)...)
+	findings = append(findings, detectSuggestedIndexes(snap.Statements, filteredTables, snap.Columns, snap.Indexes)...)
+	findings = append(findings, detectQuerySpills(snap.Statements, snap.Database, snap.Tables)...)
+	findings = append(findings, detectLowSelectivityIndexes(filteredIndexes, colStats, tableRowsMap)...)
+	findings = append(findings, detectAlwaysNullColumns(filteredColumnStats, tableRowsMap)...)
+	findings = append(findings, detectProblematicIdentifiers(definitionTables, filteredColumns)...)
+	findings = append(findings, detectNamingViolations(definitionTables, filteredColumns, definitionIndexes, filteredConstraints, opts.Naming)...)
+	findings = append(findings, detectWideTables(definitionTables, filteredColumns, colStats, opts.WideTableColumns)...)
+	findings = append(findings, detectNullableFKColumns(filteredConstraints, filteredColumns, colStats)...)
+	findings = append(findings, detectMissingForeignKeys(filteredColumns, definitionTables, filteredConstraints, opts.FKColumnPatterns)...)
+	findings = append(findings, detectFKTypeMismatches(filteredConstraints, snap.Columns)...)
+	backfills := detectIncompleteBackfills(filteredConstraints, filteredColumns, colStats)
+	pendingBackfill := make(map[string]bool, len(backfills))
+	for _, f := range backfills {
+		pendingBackfill[columnKey(f.Schema, f.Table, f.Detail["constraint"])] = true
+	}
+	findings = append(findings, backfills...)
+	findings = append(findings, detectNotValidatedConstraints(filteredConstraints, pendingBackfill)...)
 
-	return findings
+	if checks.enabled(FindingSensitiveColumn) {
+		findings = append(findings, detectSensitiveColumns(filteredColumns)...)
+	}
+	if checks.enabled(FindingRiskyExtension) {
+		findings = append(findings, detectRiskyExtensions(snap.Extensions)...)
+	}
+	findings = append(findings, detectIdleInTransaction(snap.Activity, time.Duration(opts.IdleTransactionSeconds)*time.Second)...)
+	findings = append(findings, detectLockChains(snap.Activity)...)
+	findings = append(findings, detectInactiveSlots(snap.Cluster)...)
+	findings = append(findings, detectReplicationLag(snap.Cluster)...)
+	findings = append(findings, detectArchiverFailures(snap.Cluster)...)
+	findings = append(findings, detectSettings(snap.Settings, snap.Database)...)
+	findings = append(findings, detectMissingRLS(definitionTables, snap.Policies, opts.TenantTables)...)
+	if checks.enabled(FindingPublicGrant) {
+		findings = append(findings, detectPublicGrants(filteredGrants)...)
+	}
+	if checks.enabled(FindingSuperuserAppRole) {
+		findings = append(findings, detectSuperuserAppRoles(snap.Roles)...)
+	}
+	if checks.enabled(FindingAuditTableWrite) {
+		findings = append(findings, detectAuditTableWrites(filteredGrants)...)
+	}
+
+	annotateTriggerCounts(findings, snap.Triggers)
+	annotateStatementWorkload(findings, snap.Statements, snap.Tables)
+	annotateStatsWindow(findings, snap.Database, opts.MinStatsWindowDays)
+	annotateConfidence(findings, evidence{db: snap.Database, minStatsDays: opts.MinStatsWindowDays})
+	findings = applyVersionRules(findings, opts.ServerMajor)
+	return checks.filter(findings)
 }
 
 func detectUnusedTables(stats []postgres.TableStats) []Finding {
@@ -124,6 +340,14 @@ func detectUnusedIndexes(indexes []postgres.IndexInfo, minSizeBytes int64) []Fin
 	var findings []Finding
 	for _, idx := range indexes {
 		if idx.IndexScans == 0 && idx.SizeBytes > minSizeBytes {
+			detail := map[string]string{
+				"size_bytes": strconv.FormatInt(idx.SizeBytes, 10),
+				"size":       formatBytes(idx.SizeBytes),
+				"idx_scan":   strconv.FormatInt(idx.IndexScans, 10),
+			}
+			if idx.Constraint != "" {
+				detail["constraint"] = idx.Constraint
+			}
 			findings = append(findings, Finding{
 				Type:     FindingUnusedIndex,
 				Severity: SeverityMedium,
@@ -131,11 +355,7 @@ func detectUnusedIndexes(indexes []postgres.IndexInfo, minSizeBytes int64) []Fin
 				Table:    idx.Table,
 				Index:    idx.Name,
 				Message:  fmt.Sprintf("index %q has never been used (%s)", idx.Name, formatBytes(idx.SizeBytes)),
-				Detail: map[string]string{
-					"size_bytes": strconv.FormatInt(idx.SizeBytes, 10),
-					"size":       formatBytes(idx.SizeBytes),
-					"idx_scan":   strconv.FormatInt(idx.IndexScans, 10),
-				},
+				Detail:   detail,
 			})
 		}
 	}
@@ -232,6 +452,34 @@ func detectNoPrimaryKey(tables []postgres.TableInfo, pkSet map[string]bool) []Fi
 	return findings
 }
 
+// unloggedMinBytes is the size above which an unlogged table is considered
+// to carry data worth losing.
+const unloggedMinBytes = 1024 * 1024 // 1 MB
+
+// detectUnloggedTables flags unlogged tables holding real data. They are
+// truncated after a crash and not replicated, and are often leftovers from
+// bulk loads that were never switched back with SET LOGGED.
+func detectUnloggedTables(tables []postgres.TableInfo) []Finding {
+	var findings []Finding
+	for _, t := range tables {
+		if t.Persistence != "u" || t.SizeBytes < unloggedMinBytes {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUnloggedTable,
+			Severity: SeverityMedium,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("unlogged table holds %s that will be lost on crash and is not replicated", formatBytes(t.SizeBytes)),
+			Detail: map[string]string{
+				"size":           formatBytes(t.SizeBytes),
+				"estimated_rows": strconv.FormatInt(t.EstimatedRows, 10),
+			},
+		})
+	}
+	return findings
+}
+
 func detectDuplicateIndexes(indexes []postgres.IndexInfo) []Finding {
 	// Group indexes by table
 	byTable := make(map[string][]postgres.IndexInfo)
@@ -245,17 +493,284 @@ func detectDuplicateIndexes(indexes []postgres.IndexInfo) []Finding {
 		for i := 0; i < len(group); i++ {
 			for j := i + 1; j < len(group); j++ {
 				if normalizeDef(group[i].Definition) == normalizeDef(group[j].Definition) {
-					findings = append(findings, Finding{
+					keep, drop := group[i], group[j]
+					if keepPriority(drop) > keepPriority(keep) {
+						keep, drop = drop, keep
+					}
+					f := Finding{
 						Type:     FindingDuplicateIndex,
 						Severity: SeverityLow,
-						Schema:   group[i].Schema,
-						Table:    group[i].Table,
-						Index:    group[j].Name,
-						Message:  fmt.Sprintf("index %q has the same definition as %q", group[j].Name, group[i].Name),
-					})
+						Schema:   keep.Schema,
+						Table:    keep.Table,
+						Index:    drop.Name,
+						Message:  fmt.Sprintf("index %q has the same definition as %q", drop.Name, keep.Name),
+					}
+					if drop.Constraint != "" {
+						f.Detail = map[string]string{"constraint": drop.Constraint}
+					}
+					findings = append(findings, f)
 				}
 			}
 		}
+		findings = append(findings, detectOverlappingPartialIndexes(group)...)
+	}
+	return findings
+}
+
+// keepPriority ranks which of two duplicate indexes to keep: one backing a
+// constraint over a unique one, and a unique one over a plain one, since
+// dropping it would lose what it enforces.
+func keepPriority(idx postgres.IndexInfo) int {
+	switch {
+	case idx.Constraint != "":
+		return 2
+	case idx.IsUnique || isUniqueIndex(idx.Definition):
+		return 1
+	}
+	return 0
+}
+
+// detectInvalidIndexes flags indexes marked invalid in pg_index, typically left
+// behind by a failed CREATE INDEX CONCURRENTLY. They are maintained on every
+// write but never used by the planner.
+func detectInvalidIndexes(indexes []postgres.IndexInfo) []Finding {
+	var findings []Finding
+	for _, idx := range indexes {
+		if idx.IsValid {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingInvalidIndex,
+			Severity: SeverityHigh,
+			Schema:   idx.Schema,
+			Table:    idx.Table,
+			Index:    idx.Name,
+			Message:  fmt.Sprintf("index %q is invalid (%s); drop and recreate it", idx.Name, formatBytes(idx.SizeBytes)),
+			Detail: map[string]string{
+				"size_bytes": strconv.FormatInt(idx.SizeBytes, 10),
+				"size":       formatBytes(idx.SizeBytes),
+			},
+		})
+	}
+	return findings
+}
+
+// detectHighSeqScans flags large tables whose sequential scans outnumber index
+// scans by at least ratio, which usually means a hot query path lacks an index.
+func detectHighSeqScans(stats []postgres.TableStats, tableSizeMap map[string]int64, minBytes int64, ratio float64) []Finding {
+	var findings []Finding
+	for i := range stats {
+		s := &stats[i]
+		size := tableSizeMap[tableKey(s.Schema, s.Name)]
+		if size <= minBytes || s.SeqScan == 0 {
+			continue
+		}
+		// Treat zero index scans as one so the ratio stays finite.
+		idxScans := s.IdxScan
+		if idxScans < 1 {
+			idxScans = 1
+		}
+		if float64(s.SeqScan) < ratio*float64(idxScans) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingHighSeqScan,
+			Severity: SeverityMedium,
+			Schema:   s.Schema,
+			Table:    s.Name,
+			Message:  fmt.Sprintf("table (%s) is read mostly by sequential scans (%d seq vs %d idx)", formatBytes(size), s.SeqScan, s.IdxScan),
+			Detail: map[string]string{
+				"seq_scan":         strconv.FormatInt(s.SeqScan, 10),
+				"seq_tup_read":     strconv.FormatInt(s.SeqTupRead, 10),
+				"idx_scan":         strconv.FormatInt(s.IdxScan, 10),
+				"table_size_bytes": strconv.FormatInt(size, 10),
+				"table_size":       formatBytes(size),
+			},
+		})
+	}
+	return findings
+}
+
+// A table is judged for cache hit ratio once it exceeds cacheHitMinBytes and
+// has had at least cacheHitMinBlocks heap blocks requested.
+const (
+	cacheHitMinBytes  = 100 * 1024 * 1024 // 100 MB
+	cacheHitMinBlocks = 10000
+)
+
+// hitRatio returns the share of block requests served from shared buffers.
+// ok is false when there are too few requests to judge.
+func hitRatio(read, hit int64) (ratio float64, ok bool) {
+	total := read + hit
+	if total < cacheHitMinBlocks {
+		return 0, false
+	}
+	return float64(hit) / float64(total), true
+}
+
+// detectLowCacheHits flags large, frequently read tables whose heap or
+// index blocks are found in shared buffers less often than ratio of the time.
+func detectLowCacheHits(stats []postgres.TableStats, tableSizeMap map[string]int64, ratio float64) []Finding {
+	var findings []Finding
+	for i := range stats {
+		s := &stats[i]
+		size := tableSizeMap[tableKey(s.Schema, s.Name)]
+		if size <= cacheHitMinBytes {
+			continue
+		}
+		heap, heapOK := hitRatio(s.HeapBlksRead, s.HeapBlksHit)
+		idx, idxOK := hitRatio(s.IdxBlksRead, s.IdxBlksHit)
+		heapLow, idxLow := heapOK && heap < ratio, idxOK && idx < ratio
+		if !heapLow && !idxLow {
+			continue
+		}
+		detail := map[string]string{
+			"threshold":  strconv.FormatFloat(ratio, 'f', -1, 64),
+			"table_size": formatBytes(size),
+		}
+		var parts []string
+		if heapOK {
+			detail["heap_blks_read"] = strconv.FormatInt(s.HeapBlksRead, 10)
+			detail["heap_blks_hit"] = strconv.FormatInt(s.HeapBlksHit, 10)
+			detail["hit_ratio"] = fmt.Sprintf("%.3f", heap)
+			if heapLow {
+				parts = append(parts, fmt.Sprintf("a %.1f%% heap", heap*100))
+			}
+		}
+		if idxOK {
+			detail["idx_blks_read"] = strconv.FormatInt(s.IdxBlksRead, 10)
+			detail["idx_blks_hit"] = strconv.FormatInt(s.IdxBlksHit, 10)
+			detail["idx_hit_ratio"] = fmt.Sprintf("%.3f", idx)
+			if idxLow {
+				parts = append(parts, fmt.Sprintf("a %.1f%% index", idx*100))
+			}
+		}
+		findings = append(findings, Finding{
+			Type:     FindingLowCacheHit,
+			Severity: SeverityMedium,
+			Schema:   s.Schema,
+			Table:    s.Name,
+			Message:  fmt.Sprintf("table (%s) has %s buffer cache hit ratio", formatBytes(size), strings.Join(parts, " and ")),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// detectLowCacheHitRatio flags hot tables, those with at least hotBlocks
+// heap and index block requests, whose blocks are found in shared buffers
+// less often than ratio of the time. Tables over cacheHitMinBytes are left to
+// LOW_CACHE_HIT, so this catches small tables that are read constantly.
+func detectLowCacheHitRatio(stats []postgres.TableStats, tableSizeMap map[string]int64, ratio float64, hotBlocks int64) []Finding {
+	var findings []Finding
+	for i := range stats {
+		s := &stats[i]
+		size := tableSizeMap[tableKey(s.Schema, s.Name)]
+		if size > cacheHitMinBytes {
+			continue
+		}
+		read := s.HeapBlksRead + s.IdxBlksRead
+		hit := s.HeapBlksHit + s.IdxBlksHit
+		if read+hit < hotBlocks {
+			continue
+		}
+		r, ok := hitRatio(read, hit)
+		if !ok || r >= ratio {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingLowCacheHitRatio,
+			Severity: SeverityMedium,
+			Schema:   s.Schema,
+			Table:    s.Name,
+			Message:  fmt.Sprintf("hot table (%d block requests) has a %.1f%% buffer cache hit ratio", read+hit, r*100),
+			Detail: map[string]string{
+				"blks_read":  strconv.FormatInt(read, 10),
+				"blks_hit":   strconv.FormatInt(hit, 10),
+				"hit_ratio":  fmt.Sprintf("%.3f", r),
+				"threshold":  strconv.FormatFloat(ratio, 'f', -1, 64),
+				"hot_blocks": strconv.FormatInt(hotBlocks, 10),
+				"table_size": formatBytes(size),
+			},
+		})
+	}
+	return findings
+}
+
+// integerTypeMax maps integer type names to their largest value.
+var integerTypeMax = map[string]int64{
+	"smallint": math.MaxInt16,
+	"integer":  math.MaxInt32,
+	"bigint":   math.MaxInt64,
+}
+
+// sequenceCriticalPct is the usage above which exhaustion becomes high severity.
+const sequenceCriticalPct = 90
+
+// detectSequenceExhaustion flags sequences that have consumed more than
+// thresholdPct of their usable range. The range is capped by the owning
+// column type, so a bigint sequence feeding an integer column is measured
+// against the integer limit. A descending sequence is measured from
+// MAXVALUE toward MINVALUE; a CYCLE sequence wraps instead of failing and
+// is skipped.
+func detectSequenceExhaustion(sequences []postgres.SequenceInfo, thresholdPct int) []Finding {
+	var findings []Finding
+	for _, seq := range sequences {
+		if seq.Cycle || seq.LastValue == 0 {
+			continue
+		}
+		colMax, capped := integerTypeMax[seq.ColumnType]
+		// The range starts at MINVALUE (MAXVALUE when descending), which
+		// need not be 1.
+		start, limit, dir := seq.MinValue, seq.MaxValue, 1.0
+		if seq.Increment < 0 {
+			start, limit, dir = seq.MaxValue, seq.MinValue, -1.0
+			if colMin := -colMax - 1; capped && colMin > limit {
+				limit = colMin
+			}
+		} else {
+			if capped && (limit <= 0 || colMax < limit) {
+				limit = colMax
+			}
+			if limit <= 0 {
+				continue
+			}
+		}
+		span := (float64(limit) - float64(start)) * dir
+		used := (float64(seq.LastValue) - float64(start)) * dir
+		if span <= 0 || used <= 0 {
+			continue
+		}
+
+		usedPct := used / span * 100
+		if usedPct < float64(thresholdPct) {
+			continue
+		}
+
+		severity := SeverityMedium
+		if usedPct >= sequenceCriticalPct {
+			severity = SeverityHigh
+		}
+
+		detail := map[string]string{
+			"sequence":   seq.Name,
+			"data_type":  seq.DataType,
+			"last_value": strconv.FormatInt(seq.LastValue, 10),
+			"limit":      strconv.FormatInt(limit, 10),
+			"used_pct":   fmt.Sprintf("%.1f", usedPct),
+		}
+		if seq.ColumnType != "" {
+			detail["column_type"] = seq.ColumnType
+		}
+		findings = append(findings, Finding{
+			Type:     FindingSequenceExhaustion,
+			Severity: severity,
+			Schema:   seq.Schema,
+			Table:    seq.Table, // empty for a sequence no column owns
+			Column:   seq.Column,
+			Message:  fmt.Sprintf("sequence %q has used %.1f%% of its range", seq.Name, usedPct),
+			Detail:   detail,
+		})
 	}
 	return findings
 }
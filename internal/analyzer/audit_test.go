@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -24,6 +25,7 @@ func makeIndex(schema, table, name, def string, size, scans int64) postgres.Inde
 		Definition: def,
 		SizeBytes:  size,
 		IndexScans: scans,
+		IsValid:    true,
 	}
 }
 
@@ -244,6 +246,36 @@ func TestDetectNoPrimaryKey(t *testing.T) {
 	}
 }
 
+func TestDetectUnloggedTables(t *testing.T) {
+	tests := []struct {
+		name  string
+		table postgres.TableInfo
+		want  int
+	}{
+		{"unlogged with data", postgres.TableInfo{Schema: "public", Name: "staging", Persistence: "u", SizeBytes: 50 * 1024 * 1024, EstimatedRows: 200000}, 1},
+		{"unlogged but tiny", postgres.TableInfo{Schema: "public", Name: "scratch", Persistence: "u", SizeBytes: 16384}, 0},
+		{"permanent", postgres.TableInfo{Schema: "public", Name: "users", Persistence: "p", SizeBytes: 50 * 1024 * 1024}, 0},
+		{"unknown persistence", postgres.TableInfo{Schema: "public", Name: "old", SizeBytes: 50 * 1024 * 1024}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectUnloggedTables([]postgres.TableInfo{tt.table})
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingUnloggedTable || f.Severity != SeverityMedium {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+				if f.Detail["estimated_rows"] != "200000" {
+					t.Errorf("estimated_rows = %q", f.Detail["estimated_rows"])
+				}
+			}
+		})
+	}
+}
+
 func TestDetectDuplicateIndexes(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -275,6 +307,317 @@ func TestDetectDuplicateIndexes(t *testing.T) {
 	}
 }
 
+func TestDetectDuplicateIndexes_KeepsConstraintIndex(t *testing.T) {
+	pkey := makeIndex("public", "users", "users_pkey", "CREATE UNIQUE INDEX users_pkey ON users (id)", 8192, 10)
+	pkey.IsUnique, pkey.Constraint = true, "users_pkey"
+	plain := makeIndex("public", "users", "idx_id", "CREATE INDEX idx_id ON users (id)", 8192, 0)
+	unique := makeIndex("public", "users", "users_id_key", "CREATE UNIQUE INDEX users_id_key ON users (id)", 8192, 0)
+	unique.IsUnique = true
+	uniqueCon := unique
+	uniqueCon.Constraint = "users_id_key"
+
+	tests := []struct {
+		name       string
+		indexes    []postgres.IndexInfo
+		drop       string
+		constraint string
+	}{
+		{"constraint listed first", []postgres.IndexInfo{pkey, plain}, "idx_id", ""},
+		{"constraint listed second", []postgres.IndexInfo{plain, pkey}, "idx_id", ""},
+		{"unique over plain", []postgres.IndexInfo{unique, plain}, "idx_id", ""},
+		{"constraint over unique", []postgres.IndexInfo{unique, pkey}, "users_id_key", ""},
+		{"both back constraints", []postgres.IndexInfo{pkey, uniqueCon}, "users_id_key", "users_id_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectDuplicateIndexes(tt.indexes)
+			if len(findings) != 1 {
+				t.Fatalf("got %d findings, want 1", len(findings))
+			}
+			f := findings[0]
+			if f.Index != tt.drop || f.Detail["constraint"] != tt.constraint {
+				t.Errorf("drop %q (constraint %q), want %q (%q)", f.Index, f.Detail["constraint"], tt.drop, tt.constraint)
+			}
+			if _, ok := Remediate(f); ok != (tt.constraint == "") {
+				t.Errorf("remediation = %v, want %v", ok, tt.constraint == "")
+			}
+		})
+	}
+}
+
+func TestDetectInvalidIndexes(t *testing.T) {
+	invalid := makeIndex("public", "users", "idx_broken", "CREATE INDEX idx_broken ON users (email)", 16384, 0)
+	invalid.IsValid = false
+
+	tests := []struct {
+		name    string
+		indexes []postgres.IndexInfo
+		want    int
+	}{
+		{"no indexes", nil, 0},
+		{"valid index", []postgres.IndexInfo{makeIndex("public", "users", "idx_ok", "CREATE ...", 8192, 10)}, 0},
+		{"invalid index", []postgres.IndexInfo{invalid}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectInvalidIndexes(tt.indexes)
+			if len(findings) != tt.want {
+				t.Errorf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingInvalidIndex {
+					t.Errorf("expected type INVALID_INDEX, got %s", f.Type)
+				}
+				if f.Severity != SeverityHigh {
+					t.Errorf("expected severity high, got %s", f.Severity)
+				}
+				if f.Index != "idx_broken" {
+					t.Errorf("index = %q, want idx_broken", f.Index)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectSequenceExhaustion(t *testing.T) {
+	tests := []struct {
+		name         string
+		seq          postgres.SequenceInfo
+		want         int
+		wantSeverity Severity
+	}{
+		{"unused sequence", postgres.SequenceInfo{
+			Schema: "public", Name: "users_id_seq", DataType: "integer", MaxValue: math.MaxInt32,
+			Table: "users", Column: "id", ColumnType: "integer",
+		}, 0, ""},
+		{"low usage", postgres.SequenceInfo{
+			Schema: "public", Name: "users_id_seq", DataType: "integer", LastValue: 1000, MaxValue: math.MaxInt32,
+			Table: "users", Column: "id", ColumnType: "integer",
+		}, 0, ""},
+		{"above threshold", postgres.SequenceInfo{
+			Schema: "public", Name: "users_id_seq", DataType: "integer", LastValue: 1_600_000_000, MaxValue: math.MaxInt32,
+			Table: "users", Column: "id", ColumnType: "integer",
+		}, 1, SeverityMedium},
+		{"critical", postgres.SequenceInfo{
+			Schema: "public", Name: "users_id_seq", DataType: "integer", LastValue: 2_100_000_000, MaxValue: math.MaxInt32,
+			Table: "users", Column: "id", ColumnType: "integer",
+		}, 1, SeverityHigh},
+		{"bigint sequence on integer column", postgres.SequenceInfo{
+			Schema: "public", Name: "orders_id_seq", DataType: "bigint", LastValue: 1_600_000_000, MaxValue: math.MaxInt64,
+			Table: "orders", Column: "id", ColumnType: "integer",
+		}, 1, SeverityMedium},
+		{"bigint column far from limit", postgres.SequenceInfo{
+			Schema: "public", Name: "events_id_seq", DataType: "bigint", LastValue: 1_600_000_000, MaxValue: math.MaxInt64,
+			Table: "events", Column: "id", ColumnType: "bigint",
+		}, 0, ""},
+		{"starts at minvalue", postgres.SequenceInfo{
+			Schema: "public", Name: "tickets_id_seq", DataType: "integer", LastValue: 1_600_000_000, MinValue: 1_000_000_000, MaxValue: math.MaxInt32,
+			Table: "tickets", Column: "id", ColumnType: "integer",
+		}, 0, ""},
+		{"negative minvalue", postgres.SequenceInfo{
+			Schema: "public", Name: "events_id_seq", DataType: "integer", LastValue: 1_000_000_000, MinValue: math.MinInt32, MaxValue: math.MaxInt32,
+			Table: "events", Column: "id", ColumnType: "integer",
+		}, 1, SeverityMedium},
+		{"standalone sequence", postgres.SequenceInfo{
+			Schema: "public", Name: "invoice_no", DataType: "smallint", LastValue: 30000, MaxValue: math.MaxInt16,
+		}, 1, SeverityHigh},
+		{"cycle sequence", postgres.SequenceInfo{
+			Schema: "public", Name: "ticket_no", DataType: "smallint", LastValue: 32000, MinValue: 1, MaxValue: math.MaxInt16, Increment: 1, Cycle: true,
+		}, 0, ""},
+		{"descending near minvalue", postgres.SequenceInfo{
+			Schema: "public", Name: "refunds_id_seq", DataType: "integer", LastValue: -1_600_000_000, MinValue: math.MinInt32, MaxValue: -1, Increment: -1,
+			Table: "refunds", Column: "id", ColumnType: "integer",
+		}, 1, SeverityMedium},
+		{"descending far from minvalue", postgres.SequenceInfo{
+			Schema: "public", Name: "refunds_id_seq", DataType: "integer", LastValue: -1000, MinValue: math.MinInt32, MaxValue: -1, Increment: -1,
+			Table: "refunds", Column: "id", ColumnType: "integer",
+		}, 0, ""},
+		{"descending bigint sequence on integer column", postgres.SequenceInfo{
+			Schema: "public", Name: "credits_id_seq", DataType: "bigint", LastValue: -2_100_000_000, MinValue: math.MinInt64, MaxValue: -1, Increment: -1,
+			Table: "credits", Column: "id", ColumnType: "integer",
+		}, 1, SeverityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectSequenceExhaustion([]postgres.SequenceInfo{tt.seq}, 70)
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingSequenceExhaustion {
+					t.Errorf("expected type SEQUENCE_EXHAUSTION, got %s", f.Type)
+				}
+				if f.Severity != tt.wantSeverity {
+					t.Errorf("severity = %s, want %s", f.Severity, tt.wantSeverity)
+				}
+				if f.Detail["sequence"] != tt.seq.Name {
+					t.Errorf("sequence detail = %q, want %q", f.Detail["sequence"], tt.seq.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectSequenceExhaustion_StandaloneHasNoTable(t *testing.T) {
+	seqs := []postgres.SequenceInfo{
+		{Schema: "public", Name: "invoice_no", DataType: "integer", LastValue: 2_000_000_000, MinValue: 1, MaxValue: math.MaxInt32},
+	}
+	findings := detectSequenceExhaustion(seqs, 70)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Table != "" || findings[0].Detail["sequence"] != "invoice_no" {
+		t.Errorf("table = %q, sequence = %q; want no table and invoice_no", findings[0].Table, findings[0].Detail["sequence"])
+	}
+	if findings[0].Detail["limit"] != "2147483647" {
+		t.Errorf("limit = %q, want 2147483647", findings[0].Detail["limit"])
+	}
+}
+
+func TestDetectHighSeqScans(t *testing.T) {
+	const mb = 1024 * 1024
+	tableSizeMap := map[string]int64{
+		"public.events": 500 * mb,
+		"public.small":  1 * mb,
+	}
+
+	tests := []struct {
+		name  string
+		stats []postgres.TableStats
+		want  int
+	}{
+		{"no stats", nil, 0},
+		{"index dominated", []postgres.TableStats{makeStats("public", "events", 50, 10000)}, 0},
+		{"seq dominated", []postgres.TableStats{makeStats("public", "events", 5000, 10)}, 1},
+		{"ratio boundary", []postgres.TableStats{makeStats("public", "events", 100, 10)}, 1},
+		{"below ratio", []postgres.TableStats{makeStats("public", "events", 99, 10)}, 0},
+		{"no index scans", []postgres.TableStats{makeStats("public", "events", 20, 0)}, 1},
+		{"small table ignored", []postgres.TableStats{makeStats("public", "small", 5000, 0)}, 0},
+		{"unused table ignored", []postgres.TableStats{makeStats("public", "events", 0, 0)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectHighSeqScans(tt.stats, tableSizeMap, 100*mb, 10)
+			if len(findings) != tt.want {
+				t.Errorf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingHighSeqScan {
+					t.Errorf("expected type HIGH_SEQ_SCAN, got %s", f.Type)
+				}
+				if f.Detail["table_size"] != "500.0 MB" {
+					t.Errorf("table_size = %q, want 500.0 MB", f.Detail["table_size"])
+				}
+				if _, ok := f.Detail["seq_tup_read"]; !ok {
+					t.Error("expected seq_tup_read in detail")
+				}
+			}
+		})
+	}
+}
+
+func TestDetectLowCacheHits(t *testing.T) {
+	const mb = 1024 * 1024
+	tableSizeMap := map[string]int64{
+		"public.events": 500 * mb,
+		"public.small":  1 * mb,
+	}
+	statio := func(name string, read, hit int64) postgres.TableStats {
+		return postgres.TableStats{Schema: "public", Name: name, HeapBlksRead: read, HeapBlksHit: hit}
+	}
+
+	tests := []struct {
+		name  string
+		stats []postgres.TableStats
+		want  int
+	}{
+		{"mostly cached", []postgres.TableStats{statio("events", 1000, 99000)}, 0},
+		{"mostly disk", []postgres.TableStats{statio("events", 60000, 40000)}, 1},
+		{"at threshold", []postgres.TableStats{statio("events", 1000, 9000)}, 0},
+		{"rarely read", []postgres.TableStats{statio("events", 900, 100)}, 0},
+		{"small table ignored", []postgres.TableStats{statio("small", 60000, 40000)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectLowCacheHits(tt.stats, tableSizeMap, 0.9)
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingLowCacheHit || f.Detail["hit_ratio"] != "0.400" || f.Detail["threshold"] != "0.9" {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectLowCacheHits_Indexes(t *testing.T) {
+	tableSizeMap := map[string]int64{"public.events": 500 * 1024 * 1024}
+	stats := []postgres.TableStats{{
+		Schema: "public", Name: "events",
+		HeapBlksRead: 1000, HeapBlksHit: 99000,
+		IdxBlksRead: 30000, IdxBlksHit: 70000,
+	}}
+
+	findings := detectLowCacheHits(stats, tableSizeMap, 0.9)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Detail["idx_hit_ratio"] != "0.700" || f.Detail["hit_ratio"] != "0.990" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+	if want := "table (500.0 MB) has a 70.0% index buffer cache hit ratio"; f.Message != want {
+		t.Errorf("message = %q, want %q", f.Message, want)
+	}
+}
+
+func TestDetectLowCacheHitRatio(t *testing.T) {
+	const mb = 1024 * 1024
+	tableSizeMap := map[string]int64{
+		"public.sessions": 8 * mb,
+		"public.events":   500 * mb,
+	}
+	statio := func(name string, heapRead, heapHit, idxRead, idxHit int64) postgres.TableStats {
+		return postgres.TableStats{
+			Schema: "public", Name: name,
+			HeapBlksRead: heapRead, HeapBlksHit: heapHit,
+			IdxBlksRead: idxRead, IdxBlksHit: idxHit,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		stats []postgres.TableStats
+		want  int
+	}{
+		{"hot and missing", []postgres.TableStats{statio("sessions", 400000, 400000, 200000, 200000)}, 1},
+		{"hot and cached", []postgres.TableStats{statio("sessions", 10000, 990000, 0, 500000)}, 0},
+		{"cold", []postgres.TableStats{statio("sessions", 50000, 50000, 0, 0)}, 0},
+		{"large table left to LOW_CACHE_HIT", []postgres.TableStats{statio("events", 600000, 600000, 0, 0)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectLowCacheHitRatio(tt.stats, tableSizeMap, 0.9, 1000000)
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingLowCacheHitRatio || f.Detail["hit_ratio"] != "0.500" || f.Detail["blks_read"] != "600000" {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+				if want := "hot table (1200000 block requests) has a 50.0% buffer cache hit ratio"; f.Message != want {
+					t.Errorf("message = %q, want %q", f.Message, want)
+				}
+			}
+		})
+	}
+}
+
 func TestAudit_Integration(t *testing.T) {
 	snap := &postgres.Snapshot{
 		Tables: []postgres.TableInfo{
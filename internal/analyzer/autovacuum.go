@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// Dead-tuple levels at which per-table autovacuum settings are judged: at
+// least autovacuumMinDead dead rows making up more than
+// autovacuumDeadRatio of the live rows.
+const (
+	autovacuumMinDead   = 10000
+	autovacuumDeadRatio = 0.2
+)
+
+// Per-table thresholds at or above which autovacuum rarely triggers. The
+// server defaults are 0.2 and 50.
+const (
+	laxVacuumScaleFactor = 0.5
+	laxVacuumThreshold   = 100000
+)
+
+// parseRelOptions splits pg_class.reloptions entries ("key=value") into a
+// map. TOAST options carry a "toast." prefix.
+func parseRelOptions(options []string) map[string]string {
+	m := make(map[string]string, len(options))
+	for _, o := range options {
+		k, v, ok := strings.Cut(o, "=")
+		if !ok {
+			continue
+		}
+		m[strings.ToLower(k)] = v
+	}
+	return m
+}
+
+// relOptionFalse reports whether a boolean storage parameter is off.
+func relOptionFalse(v string) bool {
+	switch strings.ToLower(v) {
+	case "false", "off", "no", "0", "f", "n":
+		return true
+	}
+	return false
+}
+
+func deadTuplesHigh(s postgres.TableStats) bool {
+	return s.DeadTuples >= autovacuumMinDead && float64(s.DeadTuples) > float64(s.LiveTuples)*autovacuumDeadRatio
+}
+
+// detectAutovacuumDisabled flags tables whose reloptions turn autovacuum off,
+// or raise its thresholds so far that it no longer keeps up with dead tuples.
+func detectAutovacuumDisabled(tables []postgres.TableInfo, stats map[string]postgres.TableStats) []Finding {
+	var findings []Finding
+	for _, t := range tables {
+		if len(t.Options) == 0 {
+			continue
+		}
+		opts := parseRelOptions(t.Options)
+		s := stats[tableKey(t.Schema, t.Name)]
+		detail := map[string]string{
+			"dead_tuples": strconv.FormatInt(s.DeadTuples, 10),
+			"live_tuples": strconv.FormatInt(s.LiveTuples, 10),
+		}
+
+		var disabled []string
+		for _, k := range []string{"autovacuum_enabled", "toast.autovacuum_enabled"} {
+			if v, ok := opts[k]; ok && relOptionFalse(v) {
+				disabled = append(disabled, k+"="+v)
+			}
+		}
+		if len(disabled) > 0 {
+			sev := SeverityMedium
+			if deadTuplesHigh(s) {
+				sev = SeverityHigh
+			}
+			detail["reason"] = "disabled"
+			detail["setting"] = strings.Join(disabled, ", ")
+			findings = append(findings, Finding{
+				Type:     FindingAutovacuumDisabled,
+				Severity: sev,
+				Schema:   t.Schema,
+				Table:    t.Name,
+				Message:  fmt.Sprintf("autovacuum is disabled for this table (%s)", detail["setting"]),
+				Detail:   detail,
+			})
+			continue
+		}
+
+		if !deadTuplesHigh(s) {
+			continue
+		}
+		var lax []string
+		for _, k := range []string{"autovacuum_vacuum_scale_factor", "toast.autovacuum_vacuum_scale_factor"} {
+			if f, err := strconv.ParseFloat(opts[k], 64); err == nil && f >= laxVacuumScaleFactor {
+				lax = append(lax, k+"="+opts[k])
+			}
+		}
+		for _, k := range []string{"autovacuum_vacuum_threshold", "toast.autovacuum_vacuum_threshold"} {
+			if n, err := strconv.ParseInt(opts[k], 10, 64); err == nil && n >= laxVacuumThreshold {
+				lax = append(lax, k+"="+opts[k])
+			}
+		}
+		if len(lax) == 0 {
+			continue
+		}
+		detail["reason"] = "lax_threshold"
+		detail["setting"] = strings.Join(lax, ", ")
+		findings = append(findings, Finding{
+			Type:     FindingAutovacuumDisabled,
+			Severity: SeverityLow,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("per-table autovacuum thresholds (%s) let %d dead tuples accumulate", detail["setting"], s.DeadTuples),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectAutovacuumDisabled(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "off_quiet", Options: []string{"autovacuum_enabled=false"}},
+		{Schema: "public", Name: "off_dead", Options: []string{"fillfactor=90", "autovacuum_enabled=off"}},
+		{Schema: "public", Name: "toast_off", Options: []string{"toast.autovacuum_enabled=false"}},
+		{Schema: "public", Name: "lax_dead", Options: []string{"autovacuum_vacuum_scale_factor=0.8"}},
+		{Schema: "public", Name: "lax_clean", Options: []string{"autovacuum_vacuum_threshold=500000"}},
+		{Schema: "public", Name: "tuned", Options: []string{"autovacuum_vacuum_scale_factor=0.01"}},
+		{Schema: "public", Name: "plain"},
+	}
+	stats := statsByKey([]postgres.TableStats{
+		{Schema: "public", Name: "off_dead", LiveTuples: 10000, DeadTuples: 50000},
+		{Schema: "public", Name: "lax_dead", LiveTuples: 100000, DeadTuples: 40000},
+		{Schema: "public", Name: "lax_clean", LiveTuples: 100000, DeadTuples: 100},
+		{Schema: "public", Name: "tuned", LiveTuples: 1000, DeadTuples: 90000},
+		{Schema: "public", Name: "plain", LiveTuples: 1000, DeadTuples: 90000},
+	})
+
+	findings := detectAutovacuumDisabled(tables, stats)
+	got := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Type != FindingAutovacuumDisabled {
+			t.Errorf("unexpected type %s", f.Type)
+		}
+		got[f.Table] = f
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d findings, want 4: %+v", len(findings), findings)
+	}
+
+	cases := []struct {
+		table, reason, setting string
+		sev                    Severity
+	}{
+		{"off_quiet", "disabled", "autovacuum_enabled=false", SeverityMedium},
+		{"off_dead", "disabled", "autovacuum_enabled=off", SeverityHigh},
+		{"toast_off", "disabled", "toast.autovacuum_enabled=false", SeverityMedium},
+		{"lax_dead", "lax_threshold", "autovacuum_vacuum_scale_factor=0.8", SeverityLow},
+	}
+	for _, tc := range cases {
+		f, ok := got[tc.table]
+		if !ok {
+			t.Errorf("missing finding for %s", tc.table)
+			continue
+		}
+		if f.Severity != tc.sev || f.Detail["reason"] != tc.reason || f.Detail["setting"] != tc.setting {
+			t.Errorf("%s: severity=%s detail=%v", tc.table, f.Severity, f.Detail)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// notNullCheckRe matches the definition of a CHECK (col IS NOT NULL)
+// constraint as printed by pg_get_constraintdef.
+var notNullCheckRe = regexp.MustCompile(`(?i)^CHECK \(+\s*("(?:[^"]|"")+"|[\w$]+)\s+IS\s+NOT\s+NULL\s*\)+`)
+
+// BackfillColumn is a column with a NOT VALID CHECK (col IS NOT NULL)
+// constraint: the expand step of adding a NOT NULL column, waiting on a
+// backfill before the constraint is validated.
+type BackfillColumn struct {
+	Schema     string
+	Table      string
+	Column     string
+	Constraint string
+}
+
+// BackfillColumns returns the columns with a pending NOT NULL check.
+func BackfillColumns(constraints []postgres.ConstraintInfo) []BackfillColumn {
+	var out []BackfillColumn
+	for _, c := range constraints {
+		if c.Type != "c" || c.Validated {
+			continue
+		}
+		m := notNullCheckRe.FindStringSubmatch(c.Definition)
+		if m == nil {
+			continue
+		}
+		column := m[1]
+		if strings.HasPrefix(column, `"`) {
+			column = strings.ReplaceAll(column[1:len(column)-1], `""`, `"`)
+		}
+		out = append(out, BackfillColumn{Schema: c.Schema, Table: c.Table, Column: column, Constraint: c.Name})
+	}
+	return out
+}
+
+// detectIncompleteBackfills flags nullable columns with a pending NOT NULL
+// check that still contain NULLs, so validating the constraint (and the
+// contract step after it) would fail.
+func detectIncompleteBackfills(constraints []postgres.ConstraintInfo, columns []postgres.ColumnInfo, colStats map[string]postgres.ColumnStats) []Finding {
+	nullable := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		nullable[columnKey(c.Schema, c.Table, c.Name)] = c.IsNullable
+	}
+
+	var findings []Finding
+	for _, bc := range BackfillColumns(constraints) {
+		key := columnKey(bc.Schema, bc.Table, bc.Column)
+		if !nullable[key] {
+			continue
+		}
+		detail := map[string]string{
+			"constraint": bc.Constraint,
+		}
+
+		cs, ok := colStats[key]
+		if !ok {
+			detail["null_frac"] = "unknown"
+			findings = append(findings, Finding{
+				Type:     FindingIncompleteBackfill,
+				Severity: SeverityLow,
+				Schema:   bc.Schema,
+				Table:    bc.Table,
+				Column:   bc.Column,
+				Message:  fmt.Sprintf("column %q has a pending NOT NULL check %q but no statistics; run ANALYZE or --sample-backfill to check backfill progress", bc.Column, bc.Constraint),
+				Detail:   detail,
+			})
+			continue
+		}
+		if cs.NullFrac <= 0 {
+			continue
+		}
+
+		detail["null_frac"] = fmt.Sprintf("%.4f", cs.NullFrac)
+		detail["source"] = "pg_stats"
+		if cs.Sampled {
+			detail["source"] = "sample"
+		}
+		findings = append(findings, Finding{
+			Type:     FindingIncompleteBackfill,
+			Severity: SeverityMedium,
+			Schema:   bc.Schema,
+			Table:    bc.Table,
+			Column:   bc.Column,
+			Message:  fmt.Sprintf("column %q is still %.1f%% NULL; finish the backfill before validating %q", bc.Column, cs.NullFrac*100, bc.Constraint),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestBackfillColumns(t *testing.T) {
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "users", Name: "users_email_nn", Type: "c", Definition: "CHECK ((email IS NOT NULL)) NOT VALID"},
+		{Schema: "public", Table: "users", Name: "users_Org_nn", Type: "c", Definition: `CHECK (("OrgID" IS NOT NULL)) NOT VALID`},
+		{Schema: "public", Table: "users", Name: "users_name_nn", Type: "c", Definition: "CHECK ((name IS NOT NULL))", Validated: true},
+		{Schema: "public", Table: "users", Name: "users_age_chk", Type: "c", Definition: "CHECK ((age > 0)) NOT VALID"},
+		{Schema: "public", Table: "orders", Name: "orders_user_fk", Type: "f", Definition: "FOREIGN KEY (user_id) REFERENCES users(id) NOT VALID"},
+	}
+	got := BackfillColumns(constraints)
+	if len(got) != 2 {
+		t.Fatalf("got %d backfill columns, want 2: %+v", len(got), got)
+	}
+	if got[0].Column != "email" || got[0].Constraint != "users_email_nn" {
+		t.Errorf("got[0] = %+v", got[0])
+	}
+	if got[1].Column != "OrgID" {
+		t.Errorf("quoted column = %q, want OrgID", got[1].Column)
+	}
+}
+
+func TestDetectIncompleteBackfills(t *testing.T) {
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "users", Name: "users_email_nn", Type: "c", Definition: "CHECK ((email IS NOT NULL)) NOT VALID"},
+		{Schema: "public", Table: "users", Name: "users_phone_nn", Type: "c", Definition: "CHECK ((phone IS NOT NULL)) NOT VALID"},
+		{Schema: "public", Table: "users", Name: "users_org_nn", Type: "c", Definition: "CHECK ((org_id IS NOT NULL)) NOT VALID"},
+		{Schema: "public", Table: "users", Name: "users_team_nn", Type: "c", Definition: "CHECK ((team_id IS NOT NULL)) NOT VALID"},
+		{Schema: "public", Table: "users", Name: "users_code_nn", Type: "c", Definition: "CHECK ((code IS NOT NULL)) NOT VALID"},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "users", Name: "email", IsNullable: true},
+		{Schema: "public", Table: "users", Name: "phone", IsNullable: true},
+		{Schema: "public", Table: "users", Name: "org_id", IsNullable: true},
+		{Schema: "public", Table: "users", Name: "team_id", IsNullable: false},
+		{Schema: "public", Table: "users", Name: "code", IsNullable: true},
+	}
+	colStats := buildColumnStatsMap([]postgres.ColumnStats{
+		{Schema: "public", Table: "users", Column: "email", NullFrac: 0.42},
+		{Schema: "public", Table: "users", Column: "phone", NullFrac: 0},
+		{Schema: "public", Table: "users", Column: "team_id", NullFrac: 0.5},
+		{Schema: "public", Table: "users", Column: "code", NullFrac: 0.01, Sampled: true},
+	})
+
+	findings := detectIncompleteBackfills(constraints, columns, colStats)
+	byColumn := map[string]Finding{}
+	for _, f := range findings {
+		byColumn[f.Column] = f
+	}
+	if len(byColumn) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	if f := byColumn["email"]; f.Severity != SeverityMedium || f.Detail["null_frac"] != "0.4200" || f.Detail["source"] != "pg_stats" {
+		t.Errorf("email: %+v", f)
+	}
+	if f := byColumn["org_id"]; f.Severity != SeverityLow || f.Detail["null_frac"] != "unknown" {
+		t.Errorf("org_id without stats: %+v", f)
+	}
+	if f := byColumn["code"]; f.Detail["source"] != "sample" {
+		t.Errorf("code source = %q, want sample", f.Detail["source"])
+	}
+	if _, ok := byColumn["phone"]; ok {
+		t.Error("fully backfilled column should not be flagged")
+	}
+	if _, ok := byColumn["team_id"]; ok {
+		t.Error("NOT NULL column should not be flagged")
+	}
+}
@@ -0,0 +1,15 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+)
+
+func BenchmarkAudit(b *testing.B) {
+	snap := fixtures.GenerateSnapshot(5000, 1)
+	opts := DefaultAuditOptions()
+	for b.Loop() {
+		Audit(snap, opts)
+	}
+}
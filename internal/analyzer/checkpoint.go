@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+const (
+	// checkpointMinCount skips servers with too few checkpoints to judge.
+	checkpointMinCount = 10
+	// requestedCheckpointRatio is the share of checkpoints forced by WAL
+	// volume, rather than checkpoint_timeout, above which max_wal_size is
+	// too small for the write load.
+	requestedCheckpointRatio = 0.2
+	// backendWriteRatio is the share of buffer writes done by client
+	// backends above which the background writer isn't keeping up.
+	backendWriteRatio = 0.2
+	// backendWriteMinBuffers skips servers that have written few buffers.
+	backendWriteMinBuffers = 10000
+)
+
+// InstanceSummary condenses server-wide checkpoint and WAL activity. It
+// gives schema findings such as bloat the write-pressure context needed to
+// prioritize them.
+type InstanceSummary struct {
+	CheckpointsTimed     int64   `json:"checkpointsTimed"`
+	CheckpointsRequested int64   `json:"checkpointsRequested"`
+	CheckpointInterval   float64 `json:"checkpointIntervalSeconds,omitempty"` // mean time between checkpoints
+	WALBytesPerHour      int64   `json:"walBytesPerHour,omitempty"`           // PostgreSQL 14+
+	BackendWriteRatio    float64 `json:"backendWriteRatio"`                   // share of buffer writes done by client backends
+	backendWrites        int64
+	totalWrites          int64
+}
+
+// SummarizeInstance derives rates and ratios from instance counters. On
+// PostgreSQL 16+ buffer writes come from pg_stat_io. It returns nil
+// without instance stats.
+func SummarizeInstance(s *postgres.InstanceStats, io []postgres.IOStats) *InstanceSummary {
+	if s == nil {
+		return nil
+	}
+	sum := &InstanceSummary{
+		CheckpointsTimed:     s.CheckpointsTimed,
+		CheckpointsRequested: s.CheckpointsRequested,
+	}
+	if n := s.CheckpointsTimed + s.CheckpointsRequested; n > 0 {
+		sum.CheckpointInterval = s.CheckpointerSeconds / float64(n)
+	}
+	if s.WALSeconds > 0 {
+		sum.WALBytesPerHour = int64(float64(s.WALBytes) / s.WALSeconds * 3600)
+	}
+
+	if len(io) > 0 {
+		for _, row := range io {
+			if row.Object != "relation" {
+				continue
+			}
+			switch row.BackendType {
+			case "client backend":
+				sum.backendWrites += row.Writes
+				sum.totalWrites += row.Writes
+			case "checkpointer", "background writer":
+				sum.totalWrites += row.Writes
+			}
+		}
+	} else {
+		sum.backendWrites = s.BuffersBackend
+		sum.totalWrites = s.BuffersCheckpoint + s.BuffersClean + s.BuffersBackend
+	}
+	if sum.totalWrites > 0 {
+		sum.BackendWriteRatio = float64(sum.backendWrites) / float64(sum.totalWrites)
+	}
+	return sum
+}
+
+// detectFrequentCheckpoints flags servers where WAL volume forces most
+// checkpoints before checkpoint_timeout. Each checkpoint writes every
+// dirty buffer and makes the next change to each page log a full page
+// image, so frequent ones multiply I/O and WAL.
+func detectFrequentCheckpoints(s *postgres.InstanceStats, sum *InstanceSummary) []Finding {
+	if s == nil {
+		return nil
+	}
+	total := s.CheckpointsTimed + s.CheckpointsRequested
+	if total < checkpointMinCount {
+		return nil
+	}
+	ratio := float64(s.CheckpointsRequested) / float64(total)
+	if ratio <= requestedCheckpointRatio {
+		return nil
+	}
+
+	detail := map[string]string{
+		"checkpoints_timed":     strconv.FormatInt(s.CheckpointsTimed, 10),
+		"checkpoints_requested": strconv.FormatInt(s.CheckpointsRequested, 10),
+		"requested_ratio":       fmt.Sprintf("%.2f", ratio),
+		"checkpoint_interval":   fmt.Sprintf("%.0fs", sum.CheckpointInterval),
+		"checkpoint_timeout":    fmt.Sprintf("%ds", s.CheckpointTimeoutSec),
+		"max_wal_size":          fmt.Sprintf("%dMB", s.MaxWALSizeMB),
+	}
+	if sum.WALBytesPerHour > 0 {
+		detail["wal_per_hour"] = formatBytes(sum.WALBytesPerHour)
+		// Checkpoints start once about half of max_wal_size has been
+		// written, so it needs twice the WAL of one checkpoint_timeout.
+		perTimeout := float64(sum.WALBytesPerHour) / 3600 * float64(s.CheckpointTimeoutSec)
+		gb := int64(math.Ceil(2 * perTimeout / (1 << 30)))
+		if gb*1024 > s.MaxWALSizeMB {
+			detail["suggested_max_wal_size"] = fmt.Sprintf("%dGB", gb)
+			detail["suggestion"] = fmt.Sprintf("ALTER SYSTEM SET max_wal_size = '%dGB';", gb)
+		}
+	}
+	return []Finding{{
+		Type:     FindingFrequentCheckpoints,
+		Severity: SeverityMedium,
+		Message: fmt.Sprintf("%d of %d checkpoints (%.0f%%) were forced by WAL volume before checkpoint_timeout; raise max_wal_size",
+			s.CheckpointsRequested, total, ratio*100),
+		Detail: detail,
+	}}
+}
+
+// detectBackendBufferWrites flags servers where client backends write out
+// dirty buffers themselves to free space, stalling queries, because the
+// background writer and checkpointer fall behind.
+func detectBackendBufferWrites(sum *InstanceSummary) []Finding {
+	if sum == nil || sum.totalWrites < backendWriteMinBuffers || sum.BackendWriteRatio <= backendWriteRatio {
+		return nil
+	}
+	return []Finding{{
+		Type:     FindingBackendBufferWrites,
+		Severity: SeverityLow,
+		Message: fmt.Sprintf("client backends wrote %.0f%% of dirty buffers themselves; raise bgwriter_lru_maxpages or shared_buffers",
+			sum.BackendWriteRatio*100),
+		Detail: map[string]string{
+			"backend_writes": strconv.FormatInt(sum.backendWrites, 10),
+			"total_writes":   strconv.FormatInt(sum.totalWrites, 10),
+			"ratio":          fmt.Sprintf("%.2f", sum.BackendWriteRatio),
+		},
+	}}
+}
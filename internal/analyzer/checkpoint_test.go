@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestSummarizeInstance(t *testing.T) {
+	if SummarizeInstance(nil, nil) != nil {
+		t.Error("nil stats should summarize to nil")
+	}
+
+	s := &postgres.InstanceStats{
+		CheckpointsTimed: 30, CheckpointsRequested: 90, CheckpointerSeconds: 36000,
+		BuffersCheckpoint: 60000, BuffersClean: 10000, BuffersBackend: 30000,
+		WALBytes: 10 << 30, WALSeconds: 36000,
+	}
+	sum := SummarizeInstance(s, nil)
+	if sum.CheckpointInterval != 300 || sum.WALBytesPerHour != 1<<30 || sum.BackendWriteRatio != 0.3 {
+		t.Errorf("summary = %+v", sum)
+	}
+
+	// pg_stat_io supersedes buffers_backend.
+	io := []postgres.IOStats{
+		{BackendType: "client backend", Object: "relation", Context: "normal", Writes: 1000},
+		{BackendType: "client backend", Object: "temp relation", Context: "normal", Writes: 50000},
+		{BackendType: "checkpointer", Object: "relation", Context: "normal", Writes: 8000},
+		{BackendType: "background writer", Object: "relation", Context: "normal", Writes: 1000},
+	}
+	if sum := SummarizeInstance(s, io); sum.BackendWriteRatio != 0.1 {
+		t.Errorf("pg_stat_io ratio = %v, want 0.1", sum.BackendWriteRatio)
+	}
+}
+
+func TestDetectFrequentCheckpoints(t *testing.T) {
+	s := &postgres.InstanceStats{
+		CheckpointsTimed: 30, CheckpointsRequested: 90, CheckpointerSeconds: 36000,
+		WALBytes: 10 << 30, WALSeconds: 36000,
+		CheckpointTimeoutSec: 300, MaxWALSizeMB: 1024,
+	}
+	findings := detectFrequentCheckpoints(s, SummarizeInstance(s, nil))
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != FindingFrequentCheckpoints || f.Detail["requested_ratio"] != "0.75" || f.Detail["checkpoint_interval"] != "300s" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	// 1 GB/h over a 5 minute timeout is ~85 MB; doubled, 1 GB already covers it.
+	if _, ok := f.Detail["suggestion"]; ok {
+		t.Errorf("suggestion = %q, want none", f.Detail["suggestion"])
+	}
+
+	s.WALBytes = 300 << 30
+	f = detectFrequentCheckpoints(s, SummarizeInstance(s, nil))[0]
+	if f.Detail["suggestion"] != "ALTER SYSTEM SET max_wal_size = '5GB';" {
+		t.Errorf("suggestion = %q", f.Detail["suggestion"])
+	}
+
+	s.CheckpointsRequested = 5
+	if got := detectFrequentCheckpoints(s, SummarizeInstance(s, nil)); len(got) != 0 {
+		t.Errorf("mostly timed checkpoints: got %+v", got)
+	}
+}
+
+func TestDetectBackendBufferWrites(t *testing.T) {
+	s := &postgres.InstanceStats{BuffersCheckpoint: 60000, BuffersClean: 10000, BuffersBackend: 30000}
+	findings := detectBackendBufferWrites(SummarizeInstance(s, nil))
+	if len(findings) != 1 || findings[0].Detail["backend_writes"] != "30000" || findings[0].Detail["ratio"] != "0.30" {
+		t.Errorf("got %+v", findings)
+	}
+
+	s.BuffersBackend = 1000
+	if got := detectBackendBufferWrites(SummarizeInstance(s, nil)); len(got) != 0 {
+		t.Errorf("low backend writes: got %+v", got)
+	}
+}
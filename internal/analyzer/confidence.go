@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// Confidence is how strongly the evidence behind a finding supports it.
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+)
+
+// Confidences lists all confidence levels from strongest to weakest.
+var Confidences = []Confidence{ConfidenceHigh, ConfidenceMedium, ConfidenceLow}
+
+var confidenceOrder = map[Confidence]int{
+	ConfidenceLow:    0,
+	ConfidenceMedium: 1,
+	ConfidenceHigh:   2,
+}
+
+// ParseConfidence converts a case-insensitive confidence name to a Confidence.
+func ParseConfidence(s string) (Confidence, bool) {
+	c := Confidence(strings.ToLower(strings.TrimSpace(s)))
+	_, ok := confidenceOrder[c]
+	return c, ok
+}
+
+// AtLeast reports whether c is as strong as min. An unset confidence
+// counts as high: the finding is a catalog fact.
+func (c Confidence) AtLeast(min Confidence) bool {
+	if c == "" {
+		c = ConfidenceHigh
+	}
+	return confidenceOrder[c] >= confidenceOrder[min]
+}
+
+// usageStatsTypes rest on cumulative counters and timestamps that a
+// statistics reset or a restart clears, so they are only as good as the
+// window those counters cover.
+var usageStatsTypes = map[FindingType]bool{
+	FindingUnusedTable:         true,
+	FindingUnusedIndex:         true,
+	FindingUnusedView:          true,
+	FindingMissingVacuum:       true,
+	FindingHighSeqScan:         true,
+	FindingSeqScanQuery:        true,
+	FindingHotDefaultPartition: true,
+	FindingLowHOTRatio:         true,
+	FindingLowCacheHit:         true,
+	FindingLowCacheHitRatio:    true,
+	FindingQuerySpill:          true,
+	FindingFrequentCheckpoints: true,
+	FindingBackendBufferWrites: true,
+	FindingUnreferencedTable:   true,
+	FindingUnscannedQueryTable: true,
+}
+
+// estimatedTypes rest on pg_stats samples, planner estimates, or name
+// patterns rather than exact catalog facts.
+var estimatedTypes = map[FindingType]bool{
+	FindingBloatedIndex:        true,
+	FindingLowSelectivityIndex: true,
+	FindingAlwaysNullColumn:    true,
+	FindingNullableFKColumn:    true,
+	FindingMissingForeignKey:   true,
+	FindingSensitiveColumn:     true,
+	FindingIncompleteBackfill:  true,
+	FindingToastBloat:          true,
+	FindingOversizedRows:       true,
+	FindingSuggestedIndex:      true,
+}
+
+// codeRefTypes rest on references the scanner found in code; the more
+// places refer to the same thing, the less likely it is a false match.
+var codeRefTypes = map[FindingType]bool{
+	FindingMissingTable:    true,
+	FindingMissingColumn:   true,
+	FindingUnindexedQuery:  true,
+	FindingMissingGINIndex: true,
+}
+
+// evidence is what confidence is judged from. refs counts the distinct
+// code locations of each table ("orders") and column ("orders.status"),
+// lowercased; migrationOnly marks tables referenced only by migrations.
+type evidence struct {
+	db            *postgres.DatabaseStats
+	minStatsDays  int
+	refs          map[string]int
+	migrationOnly map[string]bool
+}
+
+// annotateConfidence sets the Confidence of findings that have none:
+//   - usage statistics: high once they cover minStatsDays, low before,
+//     medium when the window is unknown;
+//   - samples, estimates and name patterns: medium;
+//   - code references: high from two locations, medium from one, low when
+//     only migrations refer to the table;
+//   - everything else is read from the catalog: high.
+func annotateConfidence(findings []Finding, ev evidence) {
+	for i := range findings {
+		f := &findings[i]
+		if f.Confidence != "" {
+			continue
+		}
+		switch {
+		case usageStatsTypes[f.Type]:
+			f.Confidence = statsConfidence(ev.db, ev.minStatsDays)
+		case estimatedTypes[f.Type]:
+			f.Confidence = ConfidenceMedium
+		case codeRefTypes[f.Type]:
+			f.Confidence = codeRefConfidence(f, ev)
+		default:
+			f.Confidence = ConfidenceHigh
+		}
+	}
+}
+
+// statsConfidence grades usage statistics by the window they cover.
+func statsConfidence(db *postgres.DatabaseStats, minDays int) Confidence {
+	if db == nil || db.StatsWindowSeconds <= 0 {
+		return ConfidenceMedium
+	}
+	if seconds(db.StatsWindowSeconds) < time.Duration(minDays)*24*time.Hour {
+		return ConfidenceLow
+	}
+	return ConfidenceHigh
+}
+
+// codeRefConfidence grades a code-derived finding by how many places in
+// code refer to its table or column.
+func codeRefConfidence(f *Finding, ev evidence) Confidence {
+	table := strings.ToLower(f.Table)
+	key := table
+	if f.Column != "" {
+		key += "." + strings.ToLower(f.Column)
+	}
+	switch {
+	case ev.refs == nil:
+		return ConfidenceMedium
+	case f.Column == "" && ev.migrationOnly[table]:
+		return ConfidenceLow
+	case ev.refs[key] >= 2:
+		return ConfidenceHigh
+	default:
+		return ConfidenceMedium
+	}
+}
+
+// codeEvidence adds the distinct code locations of every table and
+// column the scan found to ev. If spilled references can't be read back,
+// ev is returned without them and code findings stay medium.
+func codeEvidence(scan *scanner.ScanResult, ev evidence) evidence {
+	refs := make(map[string]int)
+	migrationOnly := make(map[string]bool)
+	type location struct {
+		key, file string
+		line      int
+	}
+	seen := make(map[location]bool)
+	count := func(key, file string, line int) {
+		loc := location{key, file, line}
+		if !seen[loc] {
+			seen[loc] = true
+			refs[key]++
+		}
+	}
+	inCode := make(map[string]bool)
+	err := scan.EachRef(func(r scanner.TableRef) {
+		table := strings.ToLower(r.Table)
+		count(table, r.File, r.Line)
+		if r.Pattern == scanner.PatternMigration {
+			if !inCode[table] {
+				migrationOnly[table] = true
+			}
+			return
+		}
+		inCode[table] = true
+		delete(migrationOnly, table)
+	})
+	if err != nil {
+		return ev
+	}
+	for _, c := range scan.ColumnRefs {
+		count(strings.ToLower(c.Table)+"."+strings.ToLower(c.Column), c.File, c.Line)
+	}
+	ev.refs, ev.migrationOnly = refs, migrationOnly
+	return ev
+}
@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestAnnotateConfidence_StatsWindow(t *testing.T) {
+	tests := []struct {
+		name string
+		db   *postgres.DatabaseStats
+		want Confidence
+	}{
+		{"long window", &postgres.DatabaseStats{StatsWindowSeconds: 30 * 86400}, ConfidenceHigh},
+		{"short window", &postgres.DatabaseStats{StatsWindowSeconds: 86400}, ConfidenceLow},
+		{"unknown window", nil, ConfidenceMedium},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := []Finding{
+				{Type: FindingUnusedIndex},
+				{Type: FindingLowSelectivityIndex},
+				{Type: FindingNoPrimaryKey},
+			}
+			annotateConfidence(findings, evidence{db: tt.db, minStatsDays: 7})
+			if findings[0].Confidence != tt.want {
+				t.Errorf("UNUSED_INDEX confidence = %s, want %s", findings[0].Confidence, tt.want)
+			}
+			if findings[1].Confidence != ConfidenceMedium {
+				t.Errorf("LOW_SELECTIVITY_INDEX confidence = %s, want medium", findings[1].Confidence)
+			}
+			if findings[2].Confidence != ConfidenceHigh {
+				t.Errorf("NO_PRIMARY_KEY confidence = %s, want high", findings[2].Confidence)
+			}
+		})
+	}
+}
+
+func TestAnnotateConfidence_CodeRefs(t *testing.T) {
+	scan := &scanner.ScanResult{
+		Refs: []scanner.TableRef{
+			{Table: "orders", File: "a.go", Line: 1, Pattern: scanner.PatternSQL},
+			{Table: "orders", File: "b.go", Line: 7, Pattern: scanner.PatternSQL},
+			{Table: "Refunds", File: "a.go", Line: 9, Pattern: scanner.PatternSQL},
+			{Table: "refunds", File: "a.go", Line: 9, Pattern: scanner.PatternSQL},
+			{Table: "legacy", File: "migrations/001.sql", Line: 1, Pattern: scanner.PatternMigration},
+		},
+		ColumnRefs: []scanner.ColumnRef{
+			{Table: "users", Column: "nickname", File: "a.go", Line: 3},
+		},
+	}
+	findings := []Finding{
+		{Type: FindingMissingTable, Table: "orders"},
+		{Type: FindingMissingTable, Table: "refunds"},
+		{Type: FindingMissingTable, Table: "legacy"},
+		{Type: FindingMissingColumn, Table: "users", Column: "nickname"},
+	}
+	annotateConfidence(findings, codeEvidence(scan, evidence{}))
+
+	want := []Confidence{ConfidenceHigh, ConfidenceMedium, ConfidenceLow, ConfidenceMedium}
+	for i, f := range findings {
+		if f.Confidence != want[i] {
+			t.Errorf("%s %s.%s confidence = %s, want %s", f.Type, f.Table, f.Column, f.Confidence, want[i])
+		}
+	}
+}
+
+func TestAnnotateConfidence_KeepsSet(t *testing.T) {
+	findings := []Finding{{Type: FindingNoPrimaryKey, Confidence: ConfidenceLow}}
+	annotateConfidence(findings, evidence{})
+	if findings[0].Confidence != ConfidenceLow {
+		t.Errorf("confidence = %s, want low kept", findings[0].Confidence)
+	}
+}
+
+func TestConfidenceAtLeast(t *testing.T) {
+	if !ConfidenceMedium.AtLeast(ConfidenceLow) || ConfidenceLow.AtLeast(ConfidenceMedium) {
+		t.Error("medium should be at least low, and low not at least medium")
+	}
+	if !Confidence("").AtLeast(ConfidenceHigh) {
+		t.Error("unset confidence should count as high")
+	}
+	if c, ok := ParseConfidence(" Medium "); !ok || c != ConfidenceMedium {
+		t.Errorf("ParseConfidence = %q, %v", c, ok)
+	}
+	if _, ok := ParseConfidence("certain"); ok {
+		t.Error("ParseConfidence accepted an unknown level")
+	}
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// NamingConventions are the patterns object names must match. A nil pattern
+// leaves that kind of object unchecked, so nothing is reported by default.
+type NamingConventions struct {
+	Tables  *regexp.Regexp
+	Columns *regexp.Regexp
+	// TimestampColumns applies to date and timestamp columns in addition
+	// to Columns, e.g. _at$.
+	TimestampColumns *regexp.Regexp
+	Indexes          *regexp.Regexp
+	Constraints      *regexp.Regexp
+}
+
+// empty reports whether no convention is configured.
+func (n NamingConventions) empty() bool {
+	return n.Tables == nil && n.Columns == nil && n.TimestampColumns == nil &&
+		n.Indexes == nil && n.Constraints == nil
+}
+
+// isTimestampType reports whether a column data type holds a point in time.
+func isTimestampType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return t == "date" || strings.HasPrefix(t, "timestamp")
+}
+
+func namingViolation(kind, schema, table, name string, pattern *regexp.Regexp) Finding {
+	return Finding{
+		Type:     FindingNamingViolation,
+		Severity: SeverityInfo,
+		Schema:   schema,
+		Table:    table,
+		Message:  fmt.Sprintf("%s name %q does not match naming convention %s", kind, name, pattern),
+		Detail: map[string]string{
+			"kind":    kind,
+			"pattern": pattern.String(),
+		},
+	}
+}
+
+// detectNamingViolations flags tables, columns, indexes, and constraints
+// whose names don't match the configured conventions. Partitions are passed
+// in already filtered out: their indexes and constraints are named by
+// PostgreSQL after the parent's.
+func detectNamingViolations(tables []postgres.TableInfo, columns []postgres.ColumnInfo, indexes []postgres.IndexInfo, constraints []postgres.ConstraintInfo, conv NamingConventions) []Finding {
+	if conv.empty() {
+		return nil
+	}
+
+	var findings []Finding
+	if conv.Tables != nil {
+		for _, t := range tables {
+			if !conv.Tables.MatchString(t.Name) {
+				findings = append(findings, namingViolation("table", t.Schema, t.Name, t.Name, conv.Tables))
+			}
+		}
+	}
+	for _, c := range columns {
+		pattern, kind := conv.Columns, "column"
+		if conv.TimestampColumns != nil && isTimestampType(c.DataType) && (pattern == nil || pattern.MatchString(c.Name)) {
+			pattern, kind = conv.TimestampColumns, "timestamp column"
+		}
+		if pattern == nil || pattern.MatchString(c.Name) {
+			continue
+		}
+		f := namingViolation(kind, c.Schema, c.Table, c.Name, pattern)
+		f.Column = c.Name
+		f.Detail["data_type"] = c.DataType
+		findings = append(findings, f)
+	}
+	if conv.Indexes != nil {
+		for _, idx := range indexes {
+			if !conv.Indexes.MatchString(idx.Name) {
+				f := namingViolation("index", idx.Schema, idx.Table, idx.Name, conv.Indexes)
+				f.Index = idx.Name
+				findings = append(findings, f)
+			}
+		}
+	}
+	if conv.Constraints != nil {
+		for _, c := range constraints {
+			if !conv.Constraints.MatchString(c.Name) {
+				f := namingViolation("constraint", c.Schema, c.Table, c.Name, conv.Constraints)
+				f.Detail["constraint"] = c.Name
+				f.Detail["constraint_type"] = c.Type
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
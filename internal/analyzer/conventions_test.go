@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+)
+
+func TestDetectNamingViolations(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "users").
+		NotNullColumn("id", "bigint").
+		Column("createdAt", "timestamp with time zone").
+		Column("updated", "timestamp with time zone").
+		Column("deleted_at", "timestamp with time zone").
+		PrimaryKey("id").
+		Index("idx_users_deleted_at", "deleted_at").
+		Index("users_updated", "updated")
+	b.Table("public", "OrderItems").
+		Column("user_id", "bigint").
+		ForeignKey("user_id", "users", "id")
+	snap := b.Build()
+
+	conv := NamingConventions{
+		Tables:           regexp.MustCompile(`^[a-z][a-z0-9_]*$`),
+		Columns:          regexp.MustCompile(`^[a-z][a-z0-9_]*$`),
+		TimestampColumns: regexp.MustCompile(`_at$`),
+		Indexes:          regexp.MustCompile(`^idx_|_pkey$`),
+		Constraints:      regexp.MustCompile(`^fk_|_pkey$`),
+	}
+	findings := detectNamingViolations(snap.Tables, snap.Columns, snap.Indexes, snap.Constraints, conv)
+
+	got := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Type != FindingNamingViolation || f.Severity != SeverityInfo {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+		got[f.Detail["kind"]+":"+f.Table+"."+f.Column+f.Index+f.Detail["constraint"]] = f
+	}
+	want := []string{
+		"table:OrderItems.",
+		"column:users.createdAt", // fails columns before timestamp_columns is checked
+		"timestamp column:users.updated",
+		"index:users.users_updated",
+		"constraint:OrderItems.OrderItems_user_id_fkey",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %v", len(findings), len(want), got)
+	}
+	for _, k := range want {
+		if _, ok := got[k]; !ok {
+			t.Errorf("missing %s in %v", k, got)
+		}
+	}
+	if f := got["timestamp column:users.updated"]; f.Detail["pattern"] != "_at$" || f.Detail["data_type"] != "timestamp with time zone" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+
+	if got := detectNamingViolations(snap.Tables, snap.Columns, snap.Indexes, snap.Constraints, NamingConventions{}); got != nil {
+		t.Errorf("no conventions: got %+v", got)
+	}
+}
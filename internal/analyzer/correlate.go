@@ -0,0 +1,87 @@
+package analyzer
+
+import "slices"
+
+// FindingRef identifies another finding in the same report.
+type FindingRef struct {
+	Type   FindingType `json:"type"`
+	Schema string      `json:"schema,omitempty"`
+	Table  string      `json:"table,omitempty"`
+	Column string      `json:"column,omitempty"`
+	Index  string      `json:"index,omitempty"`
+}
+
+// Ref returns the reference other findings use to point at f.
+func (f *Finding) Ref() FindingRef {
+	return FindingRef{Type: f.Type, Schema: f.Schema, Table: f.Table, Column: f.Column, Index: f.Index}
+}
+
+// correlation links findings on the same table: causes explain the effects,
+// so fixing a cause (or dropping the table) usually clears them too.
+type correlation struct {
+	causes  []FindingType
+	effects []FindingType // nil means every other finding on the table
+}
+
+var correlations = []correlation{
+	// Nothing reads the table; its index, vacuum, and design findings go
+	// away with it.
+	{causes: []FindingType{FindingUnusedTable, FindingUnreferencedTable}},
+	// Dead tuples pile up when vacuum can't run, bloating indexes and
+	// leaving no room for HOT updates.
+	{
+		causes:  []FindingType{FindingAutovacuumDisabled, FindingMissingVacuum},
+		effects: []FindingType{FindingBloatedIndex, FindingLowHOTRatio},
+	},
+	// A missing index shows up as sequential scans and cache misses.
+	{
+		causes:  []FindingType{FindingSuggestedIndex, FindingUnindexedQuery, FindingMissingGINIndex},
+		effects: []FindingType{FindingHighSeqScan, FindingSeqScanQuery, FindingLowCacheHit},
+	},
+}
+
+// Correlate fills in Related for findings that share a root cause: each
+// cause lists every finding it is linked with, and each effect lists its
+// causes. Run it after filtering, so references only point at findings
+// that are reported.
+func Correlate(findings []Finding) {
+	byTable := make(map[string][]int)
+	for i, f := range findings {
+		if f.Table != "" {
+			key := tableKey(f.Schema, f.Table)
+			byTable[key] = append(byTable[key], i)
+		}
+	}
+
+	for _, idx := range byTable {
+		for _, c := range correlations {
+			var causes, effects []int
+			for _, i := range idx {
+				switch t := findings[i].Type; {
+				case slices.Contains(c.causes, t):
+					causes = append(causes, i)
+				case c.effects == nil || slices.Contains(c.effects, t):
+					effects = append(effects, i)
+				}
+			}
+			if len(causes) == 0 || len(causes)+len(effects) < 2 {
+				continue
+			}
+			linked := append(append([]int(nil), causes...), effects...)
+			for _, ci := range causes {
+				for _, other := range linked {
+					if other != ci {
+						addRelated(&findings[ci], findings[other].Ref())
+						addRelated(&findings[other], findings[ci].Ref())
+					}
+				}
+			}
+		}
+	}
+}
+
+func addRelated(f *Finding, ref FindingRef) {
+	if !slices.Contains(f.Related, ref) {
+		f.Related = append(f.Related, ref)
+	}
+}
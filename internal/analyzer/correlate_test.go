@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCorrelate(t *testing.T) {
+	findings := []Finding{
+		{Type: FindingUnreferencedTable, Schema: "public", Table: "legacy"},
+		{Type: FindingUnusedIndex, Schema: "public", Table: "legacy", Index: "legacy_a_idx"},
+		{Type: FindingNoPrimaryKey, Schema: "public", Table: "legacy"},
+		{Type: FindingMissingVacuum, Schema: "public", Table: "events"},
+		{Type: FindingBloatedIndex, Schema: "public", Table: "events", Index: "events_pkey"},
+		{Type: FindingUnusedIndex, Schema: "public", Table: "events", Index: "events_b_idx"},
+		{Type: FindingHighSeqScan, Schema: "public", Table: "orders"},
+		{Type: FindingUnusedIndex, Schema: "public", Table: "users", Index: "users_a_idx"},
+	}
+	Correlate(findings)
+
+	refs := func(i int) []FindingRef { return findings[i].Related }
+	if got := refs(0); len(got) != 2 || got[0] != findings[1].Ref() || got[1] != findings[2].Ref() {
+		t.Errorf("UNREFERENCED_TABLE related = %+v", got)
+	}
+	if got := refs(1); len(got) != 1 || got[0] != findings[0].Ref() {
+		t.Errorf("UNUSED_INDEX on legacy related = %+v", got)
+	}
+	if got := refs(3); !slices.Equal(got, []FindingRef{findings[4].Ref()}) {
+		t.Errorf("MISSING_VACUUM related = %+v", got)
+	}
+	if got := refs(4); !slices.Equal(got, []FindingRef{findings[3].Ref()}) {
+		t.Errorf("BLOATED_INDEX related = %+v", got)
+	}
+	// Not an effect of missing vacuum; no cause on orders or users.
+	for _, i := range []int{5, 6, 7} {
+		if len(refs(i)) != 0 {
+			t.Errorf("%s on %s: unexpected related %+v", findings[i].Type, findings[i].Table, refs(i))
+		}
+	}
+}
+
+func TestCorrelate_LinksCauses(t *testing.T) {
+	findings := []Finding{
+		{Type: FindingUnusedTable, Schema: "public", Table: "old"},
+		{Type: FindingUnreferencedTable, Schema: "public", Table: "old"},
+	}
+	Correlate(findings)
+	if len(findings[0].Related) != 1 || len(findings[1].Related) != 1 {
+		t.Errorf("causes not linked: %+v", findings)
+	}
+
+	single := []Finding{{Type: FindingSuggestedIndex, Schema: "public", Table: "t"}}
+	Correlate(single)
+	if single[0].Related != nil {
+		t.Errorf("lone cause got related %+v", single[0].Related)
+	}
+}
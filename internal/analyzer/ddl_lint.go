@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// ddlWork is how much of a table a DDL statement has to touch.
+type ddlWork int
+
+const (
+	workScan    ddlWork = iota + 1 // reads every row to validate
+	workRewrite                    // writes a new copy of the table
+)
+
+// ddlRule matches one ALTER TABLE subcommand that scans or rewrites the table.
+type ddlRule struct {
+	re   *regexp.Regexp
+	work ddlWork
+	lock string
+	// skip reports whether a match is actually safe, e.g. NOT VALID.
+	skip func(clause string, serverMajor int) bool
+	why  func(clause string, serverMajor int) string
+}
+
+// volatileDefaultRe matches defaults PostgreSQL must evaluate per row.
+// Stable functions like now() are evaluated once and don't force a rewrite
+// on PostgreSQL 11+.
+var volatileDefaultRe = regexp.MustCompile(`(?i)\b(random|clock_timestamp|timeofday|gen_random_uuid|uuid_generate_v[14]|nextval)\s*\(`)
+
+var ddlRules = []ddlRule{
+	{
+		re:   regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?\S+\s+(?:SET\s+DATA\s+)?TYPE\s`),
+		work: workRewrite,
+		lock: lockAccessExclusive,
+		why:  func(string, int) string { return "changing a column type rewrites the table and its indexes" },
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?\S+\s+SET\s+NOT\s+NULL\b`),
+		work: workScan,
+		lock: lockAccessExclusive,
+		why: func(string, int) string {
+			return "SET NOT NULL scans the whole table; add a CHECK (col IS NOT NULL) NOT VALID constraint and validate it first"
+		},
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?\S+\s+.*\bDEFAULT\b`),
+		work: workRewrite,
+		lock: lockAccessExclusive,
+		skip: func(clause string, major int) bool {
+			preV11 := major > 0 && major < 11
+			return !preV11 && !volatileDefaultRe.MatchString(clause)
+		},
+		why: func(clause string, major int) string {
+			if major > 0 && major < 11 {
+				return fmt.Sprintf("ADD COLUMN with a DEFAULT rewrites the table on PostgreSQL %d", major)
+			}
+			return "ADD COLUMN with a volatile DEFAULT rewrites the table"
+		},
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?\S+\s+(?:BIG|SMALL)?SERIAL\b`),
+		work: workRewrite,
+		lock: lockAccessExclusive,
+		why:  func(string, int) string { return "ADD COLUMN of a serial type fills every row from a sequence" },
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?.*\bGENERATED\s+ALWAYS\s+AS\s*\(.*\bSTORED\b`),
+		work: workRewrite,
+		lock: lockAccessExclusive,
+		why:  func(string, int) string { return "ADD COLUMN ... GENERATED ALWAYS AS (...) STORED rewrites the table" },
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+\S+\s+)?CHECK\b`),
+		work: workScan,
+		lock: lockAccessExclusive,
+		skip: notValid,
+		why: func(string, int) string {
+			return "ADD CHECK validates every row; add it NOT VALID, then VALIDATE CONSTRAINT separately"
+		},
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+\S+\s+)?FOREIGN\s+KEY\b`),
+		work: workScan,
+		lock: lockShareRowExclusive,
+		skip: notValid,
+		why: func(string, int) string {
+			return "ADD FOREIGN KEY validates every row; add it NOT VALID, then VALIDATE CONSTRAINT separately"
+		},
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+\S+\s+)?(?:PRIMARY\s+KEY|UNIQUE)\b`),
+		work: workScan,
+		lock: lockAccessExclusive,
+		skip: func(clause string, _ int) bool { return strings.Contains(strings.ToUpper(clause), "USING INDEX") },
+		why: func(string, int) string {
+			return "adding a PRIMARY KEY or UNIQUE constraint builds its index under lock; build it CONCURRENTLY and add it USING INDEX"
+		},
+	},
+	{
+		re:   regexp.MustCompile(`(?i)^SET\s+(?:LOGGED|UNLOGGED|TABLESPACE)\b`),
+		work: workRewrite,
+		lock: lockAccessExclusive,
+		why:  func(string, int) string { return "changing persistence or tablespace rewrites the table" },
+	},
+}
+
+func notValid(clause string, _ int) bool {
+	return strings.Contains(strings.ToUpper(clause), "NOT VALID")
+}
+
+// rewriteCommandRe matches standalone commands that rewrite a table.
+var rewriteCommandRe = regexp.MustCompile(`(?i)^(?:VACUUM\s+(?:\([^)]*\bFULL\b[^)]*\)|FULL(?:\s+\w+)*)|CLUSTER(?:\s+VERBOSE)?)\s+("?[\w$]+"?(?:\."?[\w$]+"?)?)`)
+
+// lintDDL checks a statement on an existing table for DDL that scans or
+// rewrites the table while holding a lock.
+func lintDDL(s migration.Statement, tbl postgres.TableInfo, opts MigrationOptions, detail func() map[string]string) []Finding {
+	var findings []Finding
+	for _, clause := range splitClauses(s.Clause) {
+		for _, rule := range ddlRules {
+			if !rule.re.MatchString(clause) {
+				continue
+			}
+			if rule.skip != nil && rule.skip(clause, opts.ServerMajor) {
+				continue
+			}
+			findings = append(findings, ddlFinding(tbl, rule.work, rule.lock, rule.why(clause, opts.ServerMajor), opts.LargeTableBytes, detail()))
+			break
+		}
+	}
+	return findings
+}
+
+// lintRewriteCommand checks VACUUM FULL and CLUSTER statements, returning
+// the table they rewrite.
+func lintRewriteCommand(s migration.Statement) (schema, table string, ok bool) {
+	m := rewriteCommandRe.FindStringSubmatch(s.SQL)
+	if m == nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(m[1], ".", 2)
+	for i, p := range parts {
+		if strings.HasPrefix(p, `"`) {
+			parts[i] = strings.Trim(p, `"`)
+		} else {
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	if len(parts) == 2 {
+		return parts[0], parts[1], true
+	}
+	return "", parts[0], true
+}
+
+// ddlFinding builds a DDL_TABLE_REWRITE or DDL_FULL_SCAN finding whose
+// severity follows the lock scope.
+func ddlFinding(tbl postgres.TableInfo, work ddlWork, lock, why string, largeBytes int64, detail map[string]string) Finding {
+	ft := FindingDDLFullScan
+	if work == workRewrite {
+		ft = FindingDDLTableRewrite
+	}
+	detail["lock"] = lock
+	detail["table_size"] = formatBytes(tbl.SizeBytes)
+	return Finding{
+		Type:     ft,
+		Severity: lockScopeSeverity(lock, tbl.SizeBytes >= largeBytes),
+		Schema:   tbl.Schema,
+		Table:    tbl.Name,
+		Message:  fmt.Sprintf("%s (%s, %s lock)", why, formatBytes(tbl.SizeBytes), lock),
+		Detail:   detail,
+	}
+}
+
+// lockScopeSeverity grades a DDL hazard by what the lock blocks and for how
+// long: ACCESS EXCLUSIVE blocks reads as well as writes, and large tables
+// hold the lock longest.
+func lockScopeSeverity(lock string, large bool) Severity {
+	blocksReads := lock == lockAccessExclusive
+	switch {
+	case blocksReads && large:
+		return SeverityHigh
+	case blocksReads || large:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// splitClauses splits an ALTER TABLE action list on top-level commas.
+func splitClauses(clause string) []string {
+	var (
+		out   []string
+		depth int
+		start int
+		quote byte
+	)
+	for i := 0; i < len(clause); i++ {
+		c := clause[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			out = append(out, strings.TrimSpace(clause[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(clause[start:]); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+func TestSplitClauses(t *testing.T) {
+	got := splitClauses("ADD COLUMN a numeric(10, 2) DEFAULT 0, ALTER COLUMN b SET NOT NULL, ADD CONSTRAINT c CHECK (x IN ('a,b'))")
+	want := []string{
+		"ADD COLUMN a numeric(10, 2) DEFAULT 0",
+		"ALTER COLUMN b SET NOT NULL",
+		"ADD CONSTRAINT c CHECK (x IN ('a,b'))",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitClauses = %q, want %q", got, want)
+	}
+}
+
+func TestCheckMigration_DDLLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		major    int
+		wantType FindingType
+		wantSev  Severity
+	}{
+		{"set not null on large table", "ALTER TABLE users ALTER COLUMN email SET NOT NULL", 16, FindingDDLFullScan, SeverityHigh},
+		{"set not null on small table", "ALTER TABLE tags ALTER COLUMN name SET NOT NULL", 16, FindingDDLFullScan, SeverityMedium},
+		{"column type change", "ALTER TABLE users ALTER COLUMN id TYPE bigint", 16, FindingDDLTableRewrite, SeverityHigh},
+		{"volatile default", "ALTER TABLE users ADD COLUMN token uuid DEFAULT gen_random_uuid()", 16, FindingDDLTableRewrite, SeverityHigh},
+		{"constant default pre-11", "ALTER TABLE tags ADD COLUMN active boolean DEFAULT true", 10, FindingDDLTableRewrite, SeverityMedium},
+		{"foreign key without NOT VALID", "ALTER TABLE users ADD CONSTRAINT fk FOREIGN KEY (org_id) REFERENCES orgs (id)", 16, FindingDDLFullScan, SeverityMedium},
+		{"foreign key on small table", "ALTER TABLE tags ADD FOREIGN KEY (org_id) REFERENCES orgs (id)", 16, FindingDDLFullScan, SeverityLow},
+		{"set logged", "ALTER TABLE users SET LOGGED", 16, FindingDDLTableRewrite, SeverityHigh},
+		{"vacuum full", "VACUUM FULL users", 16, FindingDDLTableRewrite, SeverityHigh},
+		{"cluster", "CLUSTER tags USING tags_pkey", 16, FindingDDLTableRewrite, SeverityMedium},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultMigrationOptions()
+			opts.ServerMajor = tt.major
+			findings := CheckMigration("m.sql", migration.Parse(tt.sql), migrationSnapshot(), opts)
+
+			var ddl []Finding
+			for _, f := range findings {
+				if f.Type == FindingDDLFullScan || f.Type == FindingDDLTableRewrite {
+					ddl = append(ddl, f)
+				}
+			}
+			if len(ddl) != 1 {
+				t.Fatalf("got %d DDL findings, want 1: %+v", len(ddl), ddl)
+			}
+			if ddl[0].Type != tt.wantType || ddl[0].Severity != tt.wantSev {
+				t.Errorf("got %s/%s, want %s/%s", ddl[0].Type, ddl[0].Severity, tt.wantType, tt.wantSev)
+			}
+			if ddl[0].Detail["lock"] == "" || ddl[0].Detail["line"] == "" {
+				t.Errorf("missing detail: %v", ddl[0].Detail)
+			}
+		})
+	}
+}
+
+func TestCheckMigration_DDLLintSafePatterns(t *testing.T) {
+	safe := []string{
+		"ALTER TABLE users ADD COLUMN created_at timestamptz DEFAULT now()",
+		"ALTER TABLE users ADD COLUMN flag boolean DEFAULT false",
+		"ALTER TABLE users ADD CONSTRAINT fk FOREIGN KEY (org_id) REFERENCES orgs (id) NOT VALID",
+		"ALTER TABLE users ADD CONSTRAINT chk CHECK (age > 0) NOT VALID",
+		"ALTER TABLE users ADD CONSTRAINT users_pkey PRIMARY KEY USING INDEX users_id_idx",
+		"ALTER TABLE users VALIDATE CONSTRAINT fk",
+		"CREATE TABLE fresh (id int); ALTER TABLE fresh ALTER COLUMN id SET NOT NULL",
+	}
+	for _, sql := range safe {
+		findings := CheckMigration("m.sql", migration.Parse(sql), migrationSnapshot(), DefaultMigrationOptions())
+		for _, f := range findings {
+			if f.Type == FindingDDLFullScan || f.Type == FindingDDLTableRewrite {
+				t.Errorf("%q: unexpected %s: %s", sql, f.Type, f.Message)
+			}
+		}
+	}
+}
+
+func TestCheckMigration_DDLLintMultipleClauses(t *testing.T) {
+	sql := "ALTER TABLE users ALTER COLUMN a TYPE bigint, ALTER COLUMN b SET NOT NULL, ADD COLUMN c int"
+	findings := CheckMigration("m.sql", migration.Parse(sql), migrationSnapshot(), DefaultMigrationOptions())
+	if got := len(findingsOfType(findings, FindingDDLTableRewrite)); got != 1 {
+		t.Errorf("DDL_TABLE_REWRITE = %d, want 1", got)
+	}
+	if got := len(findingsOfType(findings, FindingDDLFullScan)); got != 1 {
+		t.Errorf("DDL_FULL_SCAN = %d, want 1", got)
+	}
+}
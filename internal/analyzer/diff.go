@@ -30,12 +30,16 @@ func Diff(scan *scanner.ScanResult, snap *postgres.Snapshot, opts AuditOptions)
 		codeRefs[strings.ToLower(t)] = true
 	}
 
+	relations := relationsByName(snap)
+
 	var findings []Finding
 
-	// Check code refs against DB
+	// Check code refs against DB. Code may query any kind of relation, so a
+	// view or foreign table is a match too.
 	for _, tableName := range scan.Tables {
 		lower := strings.ToLower(tableName)
-		if _, ok := dbTables[lower]; !ok {
+		rel, ok := relations[lower]
+		if !ok {
 			findings = append(findings, Finding{
 				Type:     FindingMissingTable,
 				Severity: SeverityHigh,
@@ -46,9 +50,10 @@ func Diff(scan *scanner.ScanResult, snap *postgres.Snapshot, opts AuditOptions)
 			findings = append(findings, Finding{
 				Type:     FindingCodeMatch,
 				Severity: SeverityInfo,
-				Schema:   dbTables[lower].Schema,
+				Schema:   rel.Schema,
 				Table:    tableName,
-				Message:  fmt.Sprintf("table %q exists in database and is referenced in code", tableName),
+				Message:  fmt.Sprintf("%s %q exists in database and is referenced in code", rel.Kind, tableName),
+				Detail:   map[string]string{"relation_type": rel.Kind},
 			})
 		}
 	}
@@ -87,13 +92,22 @@ func Diff(scan *scanner.ScanResult, snap *postgres.Snapshot, opts AuditOptions)
 		}
 	}
 
-	// Check DB tables not referenced in code
+	// Check DB tables not referenced in code. Code queries a partitioned
+	// table through its parent, whose activity is that of its partitions,
+	// and the tables a database function uses through the function.
+	partitions := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+	functionRefs := functionTableRefs(snap.Functions)
+	allStats := statsByKey(snap.Stats)
 	for _, t := range snap.Tables {
 		lower := strings.ToLower(t.Name)
-		if codeRefs[lower] {
+		key := tableKey(t.Schema, t.Name)
+		if codeRefs[lower] || functionRefs[lower] || functionRefs[strings.ToLower(key)] || partitions.isPartition(key) {
 			continue
 		}
 		stats := statsMap[lower]
+		if partitions.partitioned[key] {
+			stats, _ = partitions.leafStats(key, allStats)
+		}
 		if stats.SeqScan == 0 && stats.IdxScan == 0 {
 			findings = append(findings, Finding{
 				Type:     FindingUnreferencedTable,
@@ -107,9 +121,51 @@ func Diff(scan *scanner.ScanResult, snap *postgres.Snapshot, opts AuditOptions)
 
 	// Detect unindexed query columns
 	findings = append(findings, DetectUnindexedQueries(scan.ColumnRefs, snap.Indexes, snap.Tables)...)
+	findings = append(findings, detectMissingGINIndexes(scan.ColumnRefs, snap.Columns, snap.Indexes)...)
+	findings = append(findings, detectUnscannedQueryTables(snap.Statements, snap.Tables, codeRefs)...)
+	if newCheckSet(opts.Checks).enabled(FindingSelectStar) {
+		findings = append(findings, detectSelectStar(scan.Queries, relations)...)
+	}
+	findings = append(findings, detectDynamicSQL(scan.DynamicRefs)...)
+	annotateStatementWorkload(findings, snap.Statements, snap.Tables)
+	annotateConfidence(findings, codeEvidence(scan, evidence{db: snap.Database, minStatsDays: opts.withDefaults().MinStatsWindowDays}))
 
 	// Include audit findings for cluster-only issues
 	findings = append(findings, Audit(snap, opts)...)
+	annotateCodeLocations(findings, scan.Queries)
 
-	return findings
+	return newCheckSet(opts.Checks).filter(findings)
+}
+
+// relationsByName maps lowercased names to the relations code can query.
+// Snapshots recorded before relations were gathered still list their
+// tables, views, and materialized views; base tables win a name clash.
+func relationsByName(snap *postgres.Snapshot) map[string]postgres.RelationInfo {
+	relations := make(map[string]postgres.RelationInfo, len(snap.Relations)+len(snap.Tables))
+	add := func(schema, name, kind string) {
+		if _, ok := relations[strings.ToLower(name)]; !ok {
+			relations[strings.ToLower(name)] = postgres.RelationInfo{Schema: schema, Name: name, Kind: kind}
+		}
+	}
+	partitioned := make(map[string]bool, len(snap.PartitionedTables))
+	for _, pt := range snap.PartitionedTables {
+		partitioned[tableKey(pt.Schema, pt.Name)] = true
+	}
+	for _, t := range snap.Tables {
+		kind := postgres.RelationTable
+		if partitioned[tableKey(t.Schema, t.Name)] {
+			kind = postgres.RelationPartitionedTable
+		}
+		add(t.Schema, t.Name, kind)
+	}
+	for _, r := range snap.Relations {
+		add(r.Schema, r.Name, r.Kind)
+	}
+	for _, v := range snap.Views {
+		add(v.Schema, v.Name, postgres.RelationView)
+	}
+	for _, mv := range snap.MatViews {
+		add(mv.Schema, mv.Name, postgres.RelationMatView)
+	}
+	return relations
 }
@@ -63,6 +63,46 @@ func TestDiff_CodeMatch(t *testing.T) {
 	}
 }
 
+func TestDiff_OtherRelationKinds(t *testing.T) {
+	scan := scanResult("users", "active_users", "daily_totals", "remote_orders", "legacy_view")
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{tableInfo("public", "users", 100)},
+		Relations: []postgres.RelationInfo{
+			{Schema: "public", Name: "users", Kind: postgres.RelationTable},
+			{Schema: "public", Name: "active_users", Kind: postgres.RelationView},
+			{Schema: "reporting", Name: "daily_totals", Kind: postgres.RelationMatView},
+			{Schema: "public", Name: "remote_orders", Kind: postgres.RelationForeignTable},
+		},
+		// A snapshot recorded without relations still lists its views.
+		Views: []postgres.ViewInfo{{Schema: "public", Name: "legacy_view"}},
+		Stats: []postgres.TableStats{makeStats("public", "users", 10, 5)},
+	}
+
+	findings := Diff(&scan, snap, DefaultAuditOptions())
+
+	want := map[string]string{
+		"users":         postgres.RelationTable,
+		"active_users":  postgres.RelationView,
+		"daily_totals":  postgres.RelationMatView,
+		"remote_orders": postgres.RelationForeignTable,
+		"legacy_view":   postgres.RelationView,
+	}
+	for _, f := range findings {
+		switch f.Type {
+		case FindingMissingTable:
+			t.Errorf("unexpected MISSING_TABLE for %s", f.Table)
+		case FindingCodeMatch:
+			if got := f.Detail["relation_type"]; got != want[f.Table] {
+				t.Errorf("%s relation_type = %q, want %q", f.Table, got, want[f.Table])
+			}
+			delete(want, f.Table)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("no CODE_MATCH for %v", want)
+	}
+}
+
 func TestDiff_UnreferencedTable(t *testing.T) {
 	scan := scanResult("users")
 	snap := &postgres.Snapshot{
@@ -89,6 +129,47 @@ func TestDiff_UnreferencedTable(t *testing.T) {
 	}
 }
 
+func TestDiff_FunctionReferencedTable_NotFlagged(t *testing.T) {
+	scan := scanResult("users")
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			tableInfo("public", "users", 100),
+			tableInfo("public", "audit_log", 0),
+			tableInfo("billing", "ledger", 0),
+			tableInfo("public", "ledger", 0),
+			tableInfo("public", "old_data", 0),
+		},
+		Stats: []postgres.TableStats{
+			makeStats("public", "users", 10, 5),
+			makeStats("public", "audit_log", 0, 0),
+			makeStats("billing", "ledger", 0, 0),
+			makeStats("public", "ledger", 0, 0),
+			makeStats("public", "old_data", 0, 0),
+		},
+		Functions: []postgres.FunctionInfo{
+			{Schema: "public", Name: "log_change", Kind: postgres.FunctionKindFunction, Language: "plpgsql", Body: `
+DECLARE
+	n int;
+BEGIN
+	INSERT INTO audit_log (msg) VALUES ('FROM old_data'); -- SELECT * FROM old_data
+	SELECT count(*) INTO n FROM billing.ledger;
+END`},
+		},
+	}
+
+	findings := Diff(&scan, snap, DefaultAuditOptions())
+
+	var unreferenced []string
+	for _, f := range findings {
+		if f.Type == FindingUnreferencedTable {
+			unreferenced = append(unreferenced, f.Schema+"."+f.Table)
+		}
+	}
+	if want := "public.ledger,public.old_data"; strings.Join(unreferenced, ",") != want {
+		t.Errorf("UNREFERENCED_TABLE = %v, want %s", unreferenced, want)
+	}
+}
+
 func TestDiff_ActiveUnreferencedTable_NotFlagged(t *testing.T) {
 	scan := scanResult("users")
 	snap := &postgres.Snapshot{
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// detectDynamicSQL reports each file with statements whose table name is
+// built at runtime. Drift analysis can't tell which table they touch, so
+// a missing or renamed one goes unreported; the finding says where that
+// blind spot is instead of leaving it silent.
+func detectDynamicSQL(refs []scanner.DynamicRef) []Finding {
+	byFile := make(map[string][]scanner.DynamicRef)
+	for _, r := range refs {
+		byFile[r.File] = append(byFile[r.File], r)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var findings []Finding
+	for _, file := range files {
+		fileRefs := byFile[file]
+		sort.Slice(fileRefs, func(i, j int) bool { return fileRefs[i].Line < fileRefs[j].Line })
+		var locs, keywords []string
+		seen := make(map[string]bool)
+		for _, r := range fileRefs {
+			locs = append(locs, file+":"+strconv.Itoa(r.Line))
+			if !seen[r.Keyword] {
+				seen[r.Keyword] = true
+				keywords = append(keywords, r.Keyword)
+			}
+		}
+		sort.Strings(keywords)
+		if len(locs) > maxCodeLocations {
+			locs = append(locs[:maxCodeLocations:maxCodeLocations], fmt.Sprintf("and %d more", len(fileRefs)-maxCodeLocations))
+		}
+		findings = append(findings, Finding{
+			Type:     FindingDynamicSQL,
+			Severity: SeverityLow,
+			Message: fmt.Sprintf("%d statements in %s build their table name at runtime; drift analysis can't verify the tables they reference",
+				len(fileRefs), file),
+			Detail: map[string]string{
+				"file":           file,
+				"keywords":       strings.Join(keywords, ", "),
+				"code_locations": strings.Join(locs, ", "),
+			},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestDetectDynamicSQL(t *testing.T) {
+	refs := []scanner.DynamicRef{
+		{File: "b.go", Line: 4, Keyword: "FROM"},
+		{File: "a.py", Line: 30, Keyword: "INSERT INTO"},
+		{File: "a.py", Line: 12, Keyword: "FROM"},
+		{File: "a.py", Line: 20, Keyword: "FROM"},
+	}
+
+	findings := detectDynamicSQL(refs)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingDynamicSQL || f.Severity != SeverityLow || f.Table != "" {
+		t.Errorf("finding = %+v", f)
+	}
+	if f.Detail["file"] != "a.py" || f.Detail["keywords"] != "FROM, INSERT INTO" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+	if got := f.Detail["code_locations"]; got != "a.py:12, a.py:20, a.py:30" {
+		t.Errorf("code_locations = %q", got)
+	}
+	if !strings.Contains(f.Message, "3 statements in a.py") {
+		t.Errorf("message = %q", f.Message)
+	}
+	if findings[1].Detail["file"] != "b.go" {
+		t.Errorf("second finding = %+v", findings[1])
+	}
+}
+
+func TestDetectDynamicSQL_CapsLocations(t *testing.T) {
+	var refs []scanner.DynamicRef
+	for i := 1; i <= maxCodeLocations+2; i++ {
+		refs = append(refs, scanner.DynamicRef{File: "a.go", Line: i, Keyword: "FROM"})
+	}
+	findings := detectDynamicSQL(refs)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if got := findings[0].Detail["code_locations"]; !strings.HasSuffix(got, "and 2 more") {
+		t.Errorf("code_locations = %q", got)
+	}
+}
+
+func TestDiff_DynamicSQL(t *testing.T) {
+	scan := scanResult("users")
+	scan.DynamicRefs = []scanner.DynamicRef{{File: "app.go", Line: 3, Keyword: "FROM"}}
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{tableInfo("public", "users", 100)},
+		Stats:  []postgres.TableStats{makeStats("public", "users", 10, 5)},
+	}
+
+	n := 0
+	for _, f := range Diff(&scan, snap, AuditOptions{}) {
+		if f.Type == FindingDynamicSQL {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d DYNAMIC_SQL findings, want 1", n)
+	}
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import "strings"
+
+// Effort is a rough estimate of the work needed to remediate a finding.
+type Effort string
+
+const (
+	EffortTrivial Effort = "trivial"
+	EffortSmall   Effort = "small"
+	EffortMedium  Effort = "medium"
+	EffortLarge   Effort = "large"
+)
+
+// Efforts lists all effort levels from least to most work.
+var Efforts = []Effort{EffortTrivial, EffortSmall, EffortMedium, EffortLarge}
+
+// defaultEffort maps finding types to their typical remediation effort.
+// Informational types are intentionally absent.
+var defaultEffort = map[FindingType]Effort{
+	FindingUnusedTable:         EffortMedium, // confirm with owners, archive, drop
+	FindingUnusedIndex:         EffortSmall,  // DROP INDEX CONCURRENTLY
+	FindingBloatedIndex:        EffortSmall,  // REINDEX CONCURRENTLY
+	FindingMissingVacuum:       EffortTrivial,
+	FindingNoPrimaryKey:        EffortMedium, // may need a new column and backfill
+	FindingDuplicateIndex:      EffortTrivial,
+	FindingInvalidIndex:        EffortSmall,
+	FindingSequenceExhaustion:  EffortLarge, // column type migration on a hot table
+	FindingHighSeqScan:         EffortSmall,
+	FindingSeqScanQuery:        EffortSmall,  // index the filtered columns
+	FindingSensitiveColumn:     EffortMedium, // hash or encrypt, migrate readers
+	FindingRiskyExtension:      EffortSmall,
+	FindingPublicGrant:         EffortSmall,  // REVOKE, after granting the roles that rely on it
+	FindingSuperuserAppRole:    EffortMedium, // a new role with only the grants the application needs
+	FindingAuditTableWrite:     EffortTrivial,
+	FindingMissingRLS:          EffortMedium, // write and test a policy per tenant access path
+	FindingLowSelectivityIndex: EffortSmall,  // drop, or replace with a partial index
+	FindingAlwaysNullColumn:    EffortSmall,  // confirm no writers, drop column
+	FindingNullableFKColumn:    EffortTrivial,
+	FindingMissingForeignKey:   EffortMedium, // orphaned rows must be cleaned up first
+	FindingFKTypeMismatch:      EffortLarge,  // column type rewrite under lock
+	FindingIncompleteBackfill:  EffortMedium, // finish the batched backfill
+	FindingNotValidConstraint:  EffortSmall,  // VALIDATE CONSTRAINT, after fixing violating rows
+	FindingUnloggedTable:       EffortSmall,  // SET LOGGED rewrites the table
+	FindingStaleMatView:        EffortSmall,  // schedule a refresh or drop it
+	FindingUnusedView:          EffortTrivial,
+	FindingBrokenView:          EffortSmall,
+	FindingDisabledTrigger:     EffortTrivial,
+	FindingBrokenTrigger:       EffortSmall,
+	FindingHotDefaultPartition: EffortMedium, // create the missing partitions and move rows out of DEFAULT
+	FindingPartitionKeyIndex:   EffortSmall,
+	FindingPartitionIndexGap:   EffortSmall,
+	FindingAutovacuumDisabled:  EffortTrivial, // ALTER TABLE ... RESET the storage parameter
+	FindingLowHOTRatio:         EffortSmall,   // fillfactor only applies to pages written after the change
+	FindingLowCacheHit:         EffortMedium,  // add indexes, trim the working set, or grow shared_buffers
+	FindingLowCacheHitRatio:    EffortMedium,  // grow shared_buffers or memory, a restart
+	FindingQuerySpill:          EffortSmall,   // raise work_mem for the role or query, or index the sort
+	FindingFrequentCheckpoints: EffortTrivial, // max_wal_size needs only a reload
+	FindingBackendBufferWrites: EffortSmall,
+	FindingIdleInTransaction:   EffortSmall,   // fix the client that leaves transactions open; idle_in_transaction_session_timeout meanwhile
+	FindingLockChain:           EffortTrivial, // end or cancel the blocking session
+	FindingInactiveSlot:        EffortTrivial, // drop the slot, or bring its consumer back
+	FindingReplicationLag:      EffortMedium,  // replica I/O, network, or long queries on the standby
+	FindingArchiverFailing:     EffortSmall,   // fix archive_command or its destination
+	FindingSettings:            EffortSmall,   // ALTER SYSTEM; shared_buffers needs a restart
+	FindingToastBloat:          EffortLarge,   // move values to object storage and migrate the data
+	FindingOversizedRows:       EffortLarge,
+	FindingWideTable:           EffortLarge,  // splitting a table touches every query that reads it
+	FindingProblemIdentifier:   EffortMedium, // renaming needs every client updated
+	FindingNamingViolation:     EffortMedium, // same as any rename
+	FindingMissingTable:        EffortMedium,
+	FindingMissingColumn:       EffortSmall,
+	FindingUnreferencedTable:   EffortSmall,
+	FindingUnindexedQuery:      EffortSmall,
+	FindingMissingGINIndex:     EffortSmall,
+	FindingSuggestedIndex:      EffortSmall,   // CREATE INDEX CONCURRENTLY
+	FindingUnscannedQueryTable: EffortSmall,   // find the other client, or scan its repo too
+	FindingSelectStar:          EffortTrivial, // name the columns the code reads
+	FindingDynamicSQL:          EffortSmall,   // name the table, or mark the line pgspectre:ignore
+
+	FindingMigrationConflict:      EffortTrivial,
+	FindingMigrationMissingGuard:  EffortTrivial,
+	FindingMigrationLock:          EffortSmall, // split or reschedule the migration
+	FindingMigrationBlockingIndex: EffortTrivial,
+	FindingDDLTableRewrite:        EffortMedium, // expand-contract or batched backfill
+	FindingDDLFullScan:            EffortSmall,  // NOT VALID, then VALIDATE separately
+	FindingRiskyMigration:         EffortTrivial,
+	FindingMigrationRename:        EffortLarge, // expand-contract across several deploys
+
+	FindingUnappliedTable:   EffortSmall, // run the pending migration
+	FindingUnappliedColumn:  EffortSmall,
+	FindingUnappliedIndex:   EffortSmall,
+	FindingUndeclaredTable:  EffortSmall, // write a migration for it, or drop it
+	FindingUndeclaredColumn: EffortSmall,
+}
+
+// ParseEffort converts a case-insensitive effort name to an Effort.
+func ParseEffort(s string) (Effort, bool) {
+	e := Effort(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Efforts {
+		if e == known {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// AssignEffort sets the Effort of each finding from the built-in table,
+// with overrides taking precedence. Findings with no estimate are left empty.
+func AssignEffort(findings []Finding, overrides map[FindingType]Effort) {
+	for i := range findings {
+		if e, ok := overrides[findings[i].Type]; ok {
+			findings[i].Effort = e
+			continue
+		}
+		findings[i].Effort = defaultEffort[findings[i].Type]
+	}
+}
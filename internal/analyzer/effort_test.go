@@ -0,0 +1,42 @@
+package analyzer
+
+import "testing"
+
+func TestParseEffort(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Effort
+		ok   bool
+	}{
+		{"trivial", EffortTrivial, true},
+		{"Small", EffortSmall, true},
+		{" LARGE ", EffortLarge, true},
+		{"huge", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseEffort(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseEffort(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAssignEffort(t *testing.T) {
+	findings := []Finding{
+		{Type: FindingUnusedIndex},
+		{Type: FindingSequenceExhaustion},
+		{Type: FindingCodeMatch},
+		{Type: FindingNoPrimaryKey},
+	}
+
+	AssignEffort(findings, map[FindingType]Effort{FindingNoPrimaryKey: EffortLarge})
+
+	want := []Effort{EffortSmall, EffortLarge, "", EffortLarge}
+	for i, f := range findings {
+		if f.Effort != want[i] {
+			t.Errorf("findings[%d] (%s) effort = %q, want %q", i, f.Type, f.Effort, want[i])
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// quoteIdent quotes a SQL identifier unless it is a plain lowercase name
+// that is not a reserved word.
+func quoteIdent(name string) string {
+	plain := name != "" && !reservedKeywords[name]
+	for i, r := range name {
+		if r == '_' || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		plain = false
+		break
+	}
+	if plain {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// detectNullableFKColumns flags nullable foreign key columns that pg_stats
+// reports as never NULL. Such columns can usually be made NOT NULL, which
+// documents the relationship as mandatory and lets the planner assume it.
+func detectNullableFKColumns(constraints []postgres.ConstraintInfo, columns []postgres.ColumnInfo, colStats map[string]postgres.ColumnStats) []Finding {
+	nullable := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		if c.IsNullable {
+			nullable[columnKey(c.Schema, c.Table, c.Name)] = true
+		}
+	}
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, con := range constraints {
+		if con.Type != "f" {
+			continue
+		}
+		for _, col := range con.Columns {
+			key := columnKey(con.Schema, con.Table, col)
+			if seen[key] || !nullable[key] {
+				continue
+			}
+			cs, ok := colStats[key]
+			if !ok || cs.NullFrac > 0 {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, Finding{
+				Type:     FindingNullableFKColumn,
+				Severity: SeverityLow,
+				Schema:   con.Schema,
+				Table:    con.Table,
+				Column:   col,
+				Message:  fmt.Sprintf("foreign key column %q is nullable but contains no NULLs", col),
+				Detail: map[string]string{
+					"constraint": con.Name,
+					"suggestion": fmt.Sprintf("ALTER TABLE %s.%s ALTER COLUMN %s SET NOT NULL;",
+						quoteIdent(con.Schema), quoteIdent(con.Table), quoteIdent(col)),
+				},
+			})
+		}
+	}
+	return findings
+}
+
+// fkPatternPrefix returns the part of column matched by the '*' in pattern.
+// Patterns without exactly one '*' never match.
+func fkPatternPrefix(pattern, column string) (string, bool) {
+	pattern = strings.ToLower(pattern)
+	before, after, ok := strings.Cut(pattern, "*")
+	if !ok || strings.Contains(after, "*") {
+		return "", false
+	}
+	col := strings.ToLower(column)
+	if len(col) <= len(before)+len(after) || !strings.HasPrefix(col, before) || !strings.HasSuffix(col, after) {
+		return "", false
+	}
+	return col[len(before) : len(col)-len(after)], true
+}
+
+// tableCandidates returns table names a foreign key prefix may refer to:
+// the prefix itself and its common English plurals.
+func tableCandidates(prefix string) []string {
+	candidates := []string{prefix, prefix + "s", prefix + "es"}
+	if strings.HasSuffix(prefix, "y") {
+		candidates = append(candidates, strings.TrimSuffix(prefix, "y")+"ies")
+	}
+	return candidates
+}
+
+// detectMissingForeignKeys flags columns whose name implies a reference to
+// an existing table (user_id -> users) but which have no foreign key.
+// Tables in the same schema are preferred over other schemas.
+func detectMissingForeignKeys(columns []postgres.ColumnInfo, tables []postgres.TableInfo, constraints []postgres.ConstraintInfo, patterns []string) []Finding {
+	tablesByName := make(map[string][]postgres.TableInfo)
+	for _, t := range tables {
+		name := strings.ToLower(t.Name)
+		tablesByName[name] = append(tablesByName[name], t)
+	}
+
+	hasFK := make(map[string]bool)
+	pkCols := make(map[string][]string)
+	for _, c := range constraints {
+		switch c.Type {
+		case "f":
+			for _, col := range c.Columns {
+				hasFK[columnKey(c.Schema, c.Table, col)] = true
+			}
+		case "p":
+			pkCols[tableKey(c.Schema, c.Table)] = c.Columns
+		}
+	}
+
+	var findings []Finding
+	for _, col := range columns {
+		if hasFK[columnKey(col.Schema, col.Table, col.Name)] {
+			continue
+		}
+		ref, ok := inferReferencedTable(col, tablesByName, patterns)
+		if !ok {
+			continue
+		}
+
+		detail := map[string]string{
+			"referenced_table": ref.Schema + "." + ref.Name,
+		}
+		if pk := pkCols[tableKey(ref.Schema, ref.Name)]; len(pk) == 1 {
+			detail["referenced_column"] = pk[0]
+			detail["suggestion"] = fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY (%s) REFERENCES %s.%s (%s) NOT VALID;",
+				quoteIdent(col.Schema), quoteIdent(col.Table), quoteIdent(col.Name),
+				quoteIdent(ref.Schema), quoteIdent(ref.Name), quoteIdent(pk[0]))
+		}
+
+		findings = append(findings, Finding{
+			Type:     FindingMissingForeignKey,
+			Severity: SeverityLow,
+			Schema:   col.Schema,
+			Table:    col.Table,
+			Column:   col.Name,
+			Message:  fmt.Sprintf("column %q looks like a reference to %q but has no foreign key", col.Name, ref.Name),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// inferReferencedTable finds the table a column name points at, if any.
+func inferReferencedTable(col postgres.ColumnInfo, tablesByName map[string][]postgres.TableInfo, patterns []string) (postgres.TableInfo, bool) {
+	for _, pattern := range patterns {
+		prefix, ok := fkPatternPrefix(pattern, col.Name)
+		if !ok {
+			continue
+		}
+		for _, name := range tableCandidates(prefix) {
+			matches := tablesByName[name]
+			var fallback *postgres.TableInfo
+			for i := range matches {
+				t := matches[i]
+				if t.Schema == col.Schema && t.Name == col.Table {
+					continue // self-reference by name, e.g. users.user_id
+				}
+				if t.Schema == col.Schema {
+					return t, true
+				}
+				if fallback == nil {
+					fallback = &matches[i]
+				}
+			}
+			if fallback != nil {
+				return *fallback, true
+			}
+		}
+	}
+	return postgres.TableInfo{}, false
+}
+
+// detectFKTypeMismatches flags foreign key columns whose type differs from
+// the referenced column. Joins across such keys need implicit casts, which
+// can keep the planner from using the referenced index.
+// columns should be unfiltered so referenced tables in excluded schemas
+// can still be resolved.
+func detectFKTypeMismatches(constraints []postgres.ConstraintInfo, columns []postgres.ColumnInfo) []Finding {
+	colTypes := make(map[string]string, len(columns))
+	for _, c := range columns {
+		colTypes[columnKey(c.Schema, c.Table, c.Name)] = c.DataType
+	}
+
+	var findings []Finding
+	for _, con := range constraints {
+		if con.Type != "f" || con.RefTable == nil || len(con.Columns) != len(con.RefColumns) {
+			continue
+		}
+		refSchema := con.Schema
+		if con.RefSchema != nil {
+			refSchema = *con.RefSchema
+		}
+		for i, col := range con.Columns {
+			colType, ok := colTypes[columnKey(con.Schema, con.Table, col)]
+			if !ok {
+				continue
+			}
+			refCol := con.RefColumns[i]
+			refType, ok := colTypes[columnKey(refSchema, *con.RefTable, refCol)]
+			if !ok || colType == refType {
+				continue
+			}
+			findings = append(findings, Finding{
+				Type:     FindingFKTypeMismatch,
+				Severity: SeverityMedium,
+				Schema:   con.Schema,
+				Table:    con.Table,
+				Column:   col,
+				Message: fmt.Sprintf("foreign key column %q is %s but references %s.%s which is %s",
+					col, colType, *con.RefTable, refCol, refType),
+				Detail: map[string]string{
+					"constraint":        con.Name,
+					"column_type":       colType,
+					"referenced_column": refSchema + "." + *con.RefTable + "." + refCol,
+					"referenced_type":   refType,
+				},
+			})
+		}
+	}
+	return findings
+}
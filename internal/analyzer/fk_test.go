@@ -0,0 +1,198 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tests := map[string]string{
+		"users":     "users",
+		"user_id2":  "user_id2",
+		"UserID":    `"UserID"`,
+		"2fa":       `"2fa"`,
+		`we"ird`:    `"we""ird"`,
+		"has space": `"has space"`,
+		"order":     `"order"`,
+	}
+	for in, want := range tests {
+		if got := quoteIdent(in); got != want {
+			t.Errorf("quoteIdent(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDetectNullableFKColumns(t *testing.T) {
+	ref := "users"
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "orders", Name: "orders_user_fk", Type: "f", Columns: []string{"user_id"}, RefTable: &ref, RefColumns: []string{"id"}},
+		{Schema: "public", Table: "orders", Name: "orders_coupon_fk", Type: "f", Columns: []string{"coupon_id"}},
+		{Schema: "public", Table: "orders", Name: "orders_shop_fk", Type: "f", Columns: []string{"shop_id"}},
+		{Schema: "public", Table: "orders", Name: "orders_pkey", Type: "p", Columns: []string{"id"}},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "orders", Name: "id", IsNullable: false},
+		{Schema: "public", Table: "orders", Name: "user_id", IsNullable: true},
+		{Schema: "public", Table: "orders", Name: "coupon_id", IsNullable: true},
+		{Schema: "public", Table: "orders", Name: "shop_id", IsNullable: false},
+	}
+	colStats := buildColumnStatsMap([]postgres.ColumnStats{
+		{Schema: "public", Table: "orders", Column: "id", NullFrac: 0},
+		{Schema: "public", Table: "orders", Column: "user_id", NullFrac: 0},
+		{Schema: "public", Table: "orders", Column: "coupon_id", NullFrac: 0.6},
+		{Schema: "public", Table: "orders", Column: "shop_id", NullFrac: 0},
+	})
+
+	findings := detectNullableFKColumns(constraints, columns, colStats)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != FindingNullableFKColumn || f.Column != "user_id" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	want := "ALTER TABLE public.orders ALTER COLUMN user_id SET NOT NULL;"
+	if f.Detail["suggestion"] != want {
+		t.Errorf("suggestion = %q, want %q", f.Detail["suggestion"], want)
+	}
+}
+
+func TestDetectNullableFKColumns_NoStats(t *testing.T) {
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "orders", Name: "orders_user_fk", Type: "f", Columns: []string{"user_id"}},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "orders", Name: "user_id", IsNullable: true},
+	}
+
+	if findings := detectNullableFKColumns(constraints, columns, nil); len(findings) != 0 {
+		t.Errorf("expected no findings without stats, got %d", len(findings))
+	}
+}
+
+func TestFKPatternPrefix(t *testing.T) {
+	tests := []struct {
+		pattern, column, want string
+		ok                    bool
+	}{
+		{"*_id", "user_id", "user", true},
+		{"*_id", "User_ID", "user", true},
+		{"*_id", "_id", "", false},
+		{"*_id", "userid", "", false},
+		{"fk_*", "fk_account", "account", true},
+		{"*_uuid", "user_id", "", false},
+		{"no_star", "no_star", "", false},
+		{"*_*", "a_b", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := fkPatternPrefix(tt.pattern, tt.column)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("fkPatternPrefix(%q, %q) = %q, %v; want %q, %v", tt.pattern, tt.column, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetectMissingForeignKeys(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "users"},
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "categories"},
+		{Schema: "billing", Name: "invoices"},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "orders", Name: "user_id"},
+		{Schema: "public", Table: "orders", Name: "category_id"},
+		{Schema: "public", Table: "orders", Name: "invoice_id"},
+		{Schema: "public", Table: "orders", Name: "external_id"},
+		{Schema: "public", Table: "orders", Name: "linked_order_id"},
+		{Schema: "public", Table: "users", Name: "user_id"},
+	}
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "users", Name: "users_pkey", Type: "p", Columns: []string{"id"}},
+		{Schema: "public", Table: "orders", Name: "orders_category_fk", Type: "f", Columns: []string{"category_id"}},
+	}
+
+	findings := detectMissingForeignKeys(columns, tables, constraints, []string{"*_id"})
+
+	got := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Type != FindingMissingForeignKey {
+			t.Errorf("unexpected type %s", f.Type)
+		}
+		got[f.Table+"."+f.Column] = f
+	}
+	if len(got) != 2 {
+		t.Fatalf("got findings %v, want orders.user_id and orders.invoice_id", got)
+	}
+
+	userFK, ok := got["orders.user_id"]
+	if !ok {
+		t.Fatal("missing orders.user_id finding")
+	}
+	if userFK.Detail["referenced_table"] != "public.users" {
+		t.Errorf("referenced_table = %q", userFK.Detail["referenced_table"])
+	}
+	wantSQL := "ALTER TABLE public.orders ADD FOREIGN KEY (user_id) REFERENCES public.users (id) NOT VALID;"
+	if userFK.Detail["suggestion"] != wantSQL {
+		t.Errorf("suggestion = %q, want %q", userFK.Detail["suggestion"], wantSQL)
+	}
+
+	invoice, ok := got["orders.invoice_id"]
+	if !ok {
+		t.Fatal("missing cross-schema orders.invoice_id finding")
+	}
+	if invoice.Detail["referenced_table"] != "billing.invoices" {
+		t.Errorf("referenced_table = %q", invoice.Detail["referenced_table"])
+	}
+	if _, ok := invoice.Detail["suggestion"]; ok {
+		t.Error("no suggestion expected without a known primary key")
+	}
+}
+
+func TestDetectFKTypeMismatches(t *testing.T) {
+	users, accounts, public := "users", "accounts", "public"
+	billing := "billing"
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "orders", Name: "orders_user_fk", Type: "f", Columns: []string{"user_id"}, RefSchema: &public, RefTable: &users, RefColumns: []string{"id"}},
+		{Schema: "public", Table: "orders", Name: "orders_account_fk", Type: "f", Columns: []string{"account_id"}, RefSchema: &billing, RefTable: &accounts, RefColumns: []string{"id"}},
+		{Schema: "public", Table: "orders", Name: "orders_pkey", Type: "p", Columns: []string{"id"}},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "users", Name: "id", DataType: "bigint"},
+		{Schema: "public", Table: "orders", Name: "id", DataType: "bigint"},
+		{Schema: "public", Table: "orders", Name: "user_id", DataType: "integer"},
+		{Schema: "public", Table: "orders", Name: "account_id", DataType: "bigint"},
+		{Schema: "billing", Table: "accounts", Name: "id", DataType: "bigint"},
+	}
+
+	findings := detectFKTypeMismatches(constraints, columns)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != FindingFKTypeMismatch || f.Column != "user_id" || f.Severity != SeverityMedium {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["column_type"] != "integer" || f.Detail["referenced_type"] != "bigint" {
+		t.Errorf("unexpected detail: %v", f.Detail)
+	}
+	if f.Detail["referenced_column"] != "public.users.id" {
+		t.Errorf("referenced_column = %q", f.Detail["referenced_column"])
+	}
+}
+
+func TestDetectFKTypeMismatches_NoRefSchemaUsesOwnSchema(t *testing.T) {
+	users := "users"
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "app", Table: "orders", Name: "orders_user_fk", Type: "f", Columns: []string{"user_id"}, RefTable: &users, RefColumns: []string{"id"}},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "app", Table: "users", Name: "id", DataType: "uuid"},
+		{Schema: "app", Table: "orders", Name: "user_id", DataType: "text"},
+	}
+
+	if findings := detectFKTypeMismatches(constraints, columns); len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+}
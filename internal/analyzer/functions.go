@@ -0,0 +1,21 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// functionTableRefs returns the relations that SQL and PL/pgSQL function
+// bodies read or write: lowercase names, qualified with the schema when
+// the body qualifies them. Code that calls a function uses the tables it
+// does, so those tables are referenced even when no code names them.
+func functionTableRefs(functions []postgres.FunctionInfo) map[string]bool {
+	refs := make(map[string]bool)
+	for _, f := range functions {
+		for _, rel := range bodyRelations(stripBody(f.Body)) {
+			refs[strings.ToLower(rel.String())] = true
+		}
+	}
+	return refs
+}
@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// writePrivileges change or remove rows, or the whole table's contents.
+var writePrivileges = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"TRUNCATE": true,
+}
+
+// auditTableRe matches table names that suggest an audit trail or log.
+var auditTableRe = regexp.MustCompile(`(?i)(^|_)(audit|audits|auditlog|log|logs|history|journal)(_|$)`)
+
+// tableGrants is the distinct grantees and privileges of a table's grants.
+type tableGrants struct {
+	schema, table string
+	grantees      []string
+	privileges    []string
+}
+
+// groupGrants groups the grants keep accepts by table, in first-seen order.
+func groupGrants(grants []postgres.GrantInfo, keep func(postgres.GrantInfo) bool) []*tableGrants {
+	var order []*tableGrants
+	byKey := make(map[string]*tableGrants)
+	for _, g := range grants {
+		if !keep(g) {
+			continue
+		}
+		key := tableKey(g.Schema, g.Table)
+		tg := byKey[key]
+		if tg == nil {
+			tg = &tableGrants{schema: g.Schema, table: g.Table}
+			byKey[key] = tg
+			order = append(order, tg)
+		}
+		if !slices.Contains(tg.grantees, g.Grantee) {
+			tg.grantees = append(tg.grantees, g.Grantee)
+		}
+		if !slices.Contains(tg.privileges, g.Privilege) {
+			tg.privileges = append(tg.privileges, g.Privilege)
+		}
+	}
+	return order
+}
+
+// detectPublicGrants flags tables with privileges granted to PUBLIC, which
+// every current and future role inherits. Write privileges are high.
+func detectPublicGrants(grants []postgres.GrantInfo) []Finding {
+	var findings []Finding
+	for _, tg := range groupGrants(grants, func(g postgres.GrantInfo) bool { return g.Grantee == "PUBLIC" }) {
+		severity := SeverityMedium
+		for _, p := range tg.privileges {
+			if writePrivileges[p] {
+				severity = SeverityHigh
+				break
+			}
+		}
+		privs := strings.Join(tg.privileges, ", ")
+		findings = append(findings, Finding{
+			Type:     FindingPublicGrant,
+			Severity: severity,
+			Schema:   tg.schema,
+			Table:    tg.table,
+			Message:  fmt.Sprintf("%s granted to PUBLIC; every role has it", privs),
+			Detail: map[string]string{
+				"privileges": privs,
+				"revoke":     fmt.Sprintf("REVOKE %s ON %s.%s FROM PUBLIC", privs, quoteIdent(tg.schema), quoteIdent(tg.table)),
+			},
+		})
+	}
+	return findings
+}
+
+// detectSuperuserAppRoles flags superuser roles that client sessions are
+// logged in as: an application holding superuser can bypass every grant
+// and row-level security policy, and run code on the server.
+func detectSuperuserAppRoles(roles []postgres.RoleInfo) []Finding {
+	var findings []Finding
+	for _, r := range roles {
+		if !r.Superuser || !r.CanLogin || r.Connections == 0 {
+			continue
+		}
+		f := Finding{
+			Type:     FindingSuperuserAppRole,
+			Severity: SeverityHigh,
+			Message:  fmt.Sprintf("superuser role %q has %d client connections", r.Name, r.Connections),
+			Detail: map[string]string{
+				"role":        r.Name,
+				"connections": strconv.Itoa(r.Connections),
+			},
+		}
+		if len(r.Applications) > 0 {
+			apps := strings.Join(r.Applications, ", ")
+			f.Message = fmt.Sprintf("superuser role %q is used by %s (%d connections)", r.Name, apps, r.Connections)
+			f.Detail["applications"] = apps
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// detectAuditTableWrites flags audit and log tables that roles other than
+// the owner can UPDATE, DELETE from, or TRUNCATE, so the trail they keep
+// can be rewritten. INSERT is expected and not reported.
+func detectAuditTableWrites(grants []postgres.GrantInfo) []Finding {
+	keep := func(g postgres.GrantInfo) bool {
+		return g.Privilege != "INSERT" && writePrivileges[g.Privilege] && auditTableRe.MatchString(g.Table)
+	}
+	var findings []Finding
+	for _, tg := range groupGrants(grants, keep) {
+		grantees := strings.Join(tg.grantees, ", ")
+		privs := strings.Join(tg.privileges, ", ")
+		findings = append(findings, Finding{
+			Type:     FindingAuditTableWrite,
+			Severity: SeverityMedium,
+			Schema:   tg.schema,
+			Table:    tg.table,
+			Message:  fmt.Sprintf("audit table %q can be rewritten by %s (%s)", tg.table, grantees, privs),
+			Detail: map[string]string{
+				"grantees":   grantees,
+				"privileges": privs,
+			},
+		})
+	}
+	return findings
+}
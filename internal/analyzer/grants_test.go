@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectPublicGrants(t *testing.T) {
+	grants := []postgres.GrantInfo{
+		{Schema: "public", Table: "countries", Grantee: "PUBLIC", Privilege: "SELECT"},
+		{Schema: "public", Table: "users", Grantee: "PUBLIC", Privilege: "SELECT"},
+		{Schema: "public", Table: "users", Grantee: "PUBLIC", Privilege: "UPDATE"},
+		{Schema: "public", Table: "users", Grantee: "app", Privilege: "DELETE"},
+	}
+
+	findings := detectPublicGrants(grants)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Table != "countries" || f.Severity != SeverityMedium {
+		t.Errorf("countries: %+v", f)
+	}
+	f := findings[1]
+	if f.Table != "users" || f.Severity != SeverityHigh || f.Detail["privileges"] != "SELECT, UPDATE" {
+		t.Errorf("users: %+v", f)
+	}
+	if f.Detail["revoke"] != `REVOKE SELECT, UPDATE ON public.users FROM PUBLIC` {
+		t.Errorf("revoke = %q", f.Detail["revoke"])
+	}
+}
+
+func TestDetectSuperuserAppRoles(t *testing.T) {
+	roles := []postgres.RoleInfo{
+		{Name: "postgres", Superuser: true, CanLogin: true},
+		{Name: "app_admin", Superuser: true, CanLogin: true, Connections: 12, Applications: []string{"api", "worker"}},
+		{Name: "app", CanLogin: true, Connections: 40},
+	}
+
+	findings := detectSuperuserAppRoles(roles)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Detail["role"] != "app_admin" || f.Detail["connections"] != "12" || f.Detail["applications"] != "api, worker" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Severity != SeverityHigh || f.Table != "" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestDetectAuditTableWrites(t *testing.T) {
+	grants := []postgres.GrantInfo{
+		{Schema: "public", Table: "audit_log", Grantee: "app", Privilege: "INSERT"},
+		{Schema: "public", Table: "audit_log", Grantee: "app", Privilege: "DELETE"},
+		{Schema: "public", Table: "audit_log", Grantee: "support", Privilege: "DELETE"},
+		{Schema: "public", Table: "audit_log", Grantee: "support", Privilege: "UPDATE"},
+		{Schema: "public", Table: "login_history", Grantee: "app", Privilege: "INSERT"},
+		{Schema: "public", Table: "catalog", Grantee: "app", Privilege: "DELETE"},
+		{Schema: "public", Table: "dialogs", Grantee: "app", Privilege: "TRUNCATE"},
+	}
+
+	findings := detectAuditTableWrites(grants)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Table != "audit_log" || f.Detail["grantees"] != "app, support" || f.Detail["privileges"] != "DELETE, UPDATE" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// A table counts as heavily updated once it has seen hotMinUpdates updates.
+// Below hotRatioThreshold of those being HOT, most updates write new index
+// entries and leave dead tuples spread across pages.
+const (
+	hotMinUpdates     = 10000
+	hotRatioThreshold = 0.5
+)
+
+// detectLowHOTRatios flags heavily updated tables where few updates are
+// heap-only (HOT). HOT needs free space on the same page and no change to
+// an indexed column, so a lower fillfactor or dropping indexes on updated
+// columns usually helps.
+func detectLowHOTRatios(stats []postgres.TableStats, tableOptions map[string][]string) []Finding {
+	var findings []Finding
+	for i := range stats {
+		s := &stats[i]
+		if s.TupUpdated < hotMinUpdates {
+			continue
+		}
+		ratio := float64(s.TupHotUpdated) / float64(s.TupUpdated)
+		if ratio >= hotRatioThreshold {
+			continue
+		}
+		fillfactor := parseRelOptions(tableOptions[tableKey(s.Schema, s.Name)])["fillfactor"]
+		if fillfactor == "" {
+			fillfactor = "100"
+		}
+		findings = append(findings, Finding{
+			Type:     FindingLowHOTRatio,
+			Severity: SeverityLow,
+			Schema:   s.Schema,
+			Table:    s.Name,
+			Message:  fmt.Sprintf("only %.0f%% of updates are HOT; lower fillfactor or review indexes on updated columns", ratio*100),
+			Detail: map[string]string{
+				"updates":     strconv.FormatInt(s.TupUpdated, 10),
+				"hot_updates": strconv.FormatInt(s.TupHotUpdated, 10),
+				"hot_ratio":   fmt.Sprintf("%.2f", ratio),
+				"fillfactor":  fillfactor,
+			},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectLowHOTRatios(t *testing.T) {
+	stats := []postgres.TableStats{
+		{Schema: "public", Name: "sessions", TupUpdated: 50000, TupHotUpdated: 5000},
+		{Schema: "public", Name: "counters", TupUpdated: 50000, TupHotUpdated: 45000},
+		{Schema: "public", Name: "rarely", TupUpdated: 900, TupHotUpdated: 0},
+		{Schema: "public", Name: "tuned", TupUpdated: 20000, TupHotUpdated: 2000},
+	}
+	options := map[string][]string{
+		"public.tuned": {"fillfactor=70"},
+	}
+
+	findings := detectLowHOTRatios(stats, options)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingLowHOTRatio || f.Table != "sessions" || f.Severity != SeverityLow {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["hot_ratio"] != "0.10" || f.Detail["fillfactor"] != "100" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+	if findings[1].Table != "tuned" || findings[1].Detail["fillfactor"] != "70" {
+		t.Errorf("unexpected finding: %+v", findings[1])
+	}
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// HypotheticalIndex returns the index an UNINDEXED_QUERY or SUGGESTED_INDEX
+// finding proposes and a representative query to plan against it. For
+// SUGGESTED_INDEX that is the tracked statement behind the suggestion; for
+// UNINDEXED_QUERY, a lookup on the column. Only SELECTs are returned.
+func HypotheticalIndex(f Finding, stmts []postgres.StatStatement) (createIndex, query string, ok bool) {
+	switch f.Type {
+	case FindingSuggestedIndex:
+		id := f.Detail["query_id"]
+		for _, s := range stmts {
+			if strconv.FormatInt(s.QueryID, 10) != id {
+				continue
+			}
+			if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(s.Query)), "SELECT") {
+				return "", "", false
+			}
+			return strings.Replace(f.Detail["ddl"], " CONCURRENTLY", "", 1), s.Query, true
+		}
+	case FindingUnindexedQuery:
+		if f.Schema == "" || f.Table == "" || f.Column == "" {
+			return "", "", false
+		}
+		rel := quoteIdent(f.Schema) + "." + quoteIdent(f.Table)
+		col := quoteIdent(f.Column)
+		return fmt.Sprintf("CREATE INDEX ON %s (%s)", rel, col),
+			fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", rel, col), true
+	}
+	return "", "", false
+}
+
+// SetIndexEstimate records the planner's cost for a finding's
+// representative query without and with its hypothetical index.
+func SetIndexEstimate(f *Finding, est postgres.IndexEstimate) {
+	if f.Detail == nil {
+		f.Detail = make(map[string]string)
+	}
+	f.Detail["estimated_cost_before"] = fmt.Sprintf("%.2f", est.CostBefore)
+	f.Detail["estimated_cost_after"] = fmt.Sprintf("%.2f", est.CostAfter)
+	if !est.Used {
+		f.Detail["hypothetical_index_used"] = "false"
+		return
+	}
+	if est.CostBefore > 0 {
+		f.Detail["estimated_improvement"] = fmt.Sprintf("%.0f%%", 100*(est.CostBefore-est.CostAfter)/est.CostBefore)
+	}
+}
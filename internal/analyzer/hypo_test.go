@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestHypotheticalIndex(t *testing.T) {
+	stmts := []postgres.StatStatement{
+		{QueryID: 42, Query: "SELECT * FROM orders WHERE user_id = $1"},
+		{QueryID: 43, Query: "UPDATE orders SET status = $1 WHERE user_id = $2"},
+	}
+	suggested := Finding{Type: FindingSuggestedIndex, Schema: "public", Table: "orders", Detail: map[string]string{
+		"query_id": "42", "ddl": "CREATE INDEX CONCURRENTLY ON public.orders (user_id);",
+	}}
+
+	ddl, query, ok := HypotheticalIndex(suggested, stmts)
+	if !ok || ddl != "CREATE INDEX ON public.orders (user_id);" || query != stmts[0].Query {
+		t.Errorf("SUGGESTED_INDEX = %q, %q, %v", ddl, query, ok)
+	}
+
+	suggested.Detail["query_id"] = "43"
+	if _, _, ok := HypotheticalIndex(suggested, stmts); ok {
+		t.Error("UPDATE statement should not be planned")
+	}
+
+	unindexed := Finding{Type: FindingUnindexedQuery, Schema: "public", Table: "Users", Column: "email"}
+	ddl, query, ok = HypotheticalIndex(unindexed, nil)
+	if !ok || ddl != `CREATE INDEX ON public."Users" (email)` || query != `SELECT * FROM public."Users" WHERE email = $1` {
+		t.Errorf("UNINDEXED_QUERY = %q, %q, %v", ddl, query, ok)
+	}
+
+	if _, _, ok := HypotheticalIndex(Finding{Type: FindingUnusedIndex}, nil); ok {
+		t.Error("UNUSED_INDEX has no hypothetical index")
+	}
+}
+
+func TestSetIndexEstimate(t *testing.T) {
+	var f Finding
+	SetIndexEstimate(&f, postgres.IndexEstimate{CostBefore: 1000, CostAfter: 8.3, Used: true})
+	if f.Detail["estimated_improvement"] != "99%" || f.Detail["estimated_cost_after"] != "8.30" {
+		t.Errorf("used index: %v", f.Detail)
+	}
+
+	f = Finding{}
+	SetIndexEstimate(&f, postgres.IndexEstimate{CostBefore: 12, CostAfter: 12})
+	if f.Detail["hypothetical_index_used"] != "false" || f.Detail["estimated_improvement"] != "" {
+		t.Errorf("unused index: %v", f.Detail)
+	}
+}
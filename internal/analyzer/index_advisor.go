@@ -3,6 +3,8 @@ package analyzer
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ppiankov/pgspectre/internal/postgres"
@@ -13,10 +15,19 @@ import (
 // Matches the parenthesized column list in CREATE INDEX ... (col1, col2, ...).
 var indexColumnRe = regexp.MustCompile(`\(([^)]+)\)`)
 
-// DetectUnindexedQueries finds columns used in WHERE/ORDER BY/JOIN that lack indexes.
+// DetectUnindexedQueries finds columns used in WHERE/JOIN/ORDER BY that lack
+// indexes, and ON CONFLICT targets that lack a unique index: without one
+// the upsert fails.
 func DetectUnindexedQueries(columnRefs []scanner.ColumnRef, indexes []postgres.IndexInfo, tables []postgres.TableInfo) []Finding {
 	// Build set of indexed columns: "schema.table.column" → true
 	indexedCols := buildIndexedColumns(indexes)
+	var unique []postgres.IndexInfo
+	for _, idx := range indexes {
+		if strings.Contains(strings.ToUpper(idx.Definition), "UNIQUE INDEX") {
+			unique = append(unique, idx)
+		}
+	}
+	uniqueCols := buildIndexedColumns(unique)
 
 	// Build table lookup
 	tableSet := make(map[string]postgres.TableInfo)
@@ -32,6 +43,7 @@ func DetectUnindexedQueries(columnRefs []scanner.ColumnRef, indexes []postgres.I
 		column string
 	}
 	refCounts := make(map[colKey]int)
+	conflictTargets := make(map[colKey]bool)
 	for _, cr := range columnRefs {
 		if !isIndexableContext(cr.Context) {
 			continue
@@ -45,6 +57,9 @@ func DetectUnindexedQueries(columnRefs []scanner.ColumnRef, indexes []postgres.I
 			column: strings.ToLower(cr.Column),
 		}
 		refCounts[k]++
+		if cr.Context == scanner.ContextConflict {
+			conflictTargets[k] = true
+		}
 	}
 
 	var findings []Finding
@@ -62,6 +77,20 @@ func DetectUnindexedQueries(columnRefs []scanner.ColumnRef, indexes []postgres.I
 
 		// Check if any index covers this column
 		fqCol := schema + "." + k.table + "." + k.column
+		if conflictTargets[k] {
+			if uniqueCols[fqCol] {
+				continue
+			}
+			findings = append(findings, Finding{
+				Type:     FindingUnindexedQuery,
+				Severity: SeverityHigh,
+				Schema:   schema,
+				Table:    k.table,
+				Column:   k.column,
+				Message:  fmt.Sprintf("column %q is an ON CONFLICT target (%d references) but no unique index covers it", k.column, count),
+			})
+			continue
+		}
 		if indexedCols[fqCol] {
 			continue
 		}
@@ -72,7 +101,7 @@ func DetectUnindexedQueries(columnRefs []scanner.ColumnRef, indexes []postgres.I
 			Schema:   schema,
 			Table:    k.table,
 			Column:   k.column,
-			Message:  fmt.Sprintf("column %q used in WHERE/ORDER BY (%d references) but has no index", k.column, count),
+			Message:  fmt.Sprintf("column %q used in WHERE/JOIN/ORDER BY (%d references) but has no index", k.column, count),
 		})
 	}
 
@@ -123,5 +152,272 @@ func parseIndexColumns(def string) []string {
 }
 
 func isIndexableContext(ctx scanner.Context) bool {
-	return ctx == scanner.ContextWhere || ctx == scanner.ContextOrderBy
+	switch ctx {
+	case scanner.ContextWhere, scanner.ContextJoin, scanner.ContextOrderBy, scanner.ContextConflict:
+		return true
+	}
+	return false
+}
+
+// statementPredicateRe matches one WHERE or AND predicate on an optionally
+// qualified column, capturing the qualifier, column, and operator.
+var statementPredicateRe = regexp.MustCompile(`(?i)\b(?:WHERE|AND)\s+(?:(\w+)\.)?(\w+)\s*(=|<=|>=|<|>|IN\b|IS\b|BETWEEN\b|LIKE\b)`)
+
+// statementAliasRe matches a FROM or JOIN table and its alias.
+var statementAliasRe = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+(?:\w+\.)?(\w+)(?:\s+(?:AS\s+)?(\w+))?`)
+
+// statementOrRe rejects statements whose predicates are OR-ed together,
+// which a single composite index can't serve.
+var statementOrRe = regexp.MustCompile(`(?i)\bOR\b`)
+
+// aliasStopwords are words that can follow a table name without being its alias.
+var aliasStopwords = map[string]bool{
+	"where": true, "join": true, "on": true, "using": true, "left": true,
+	"right": true, "inner": true, "outer": true, "cross": true, "full": true,
+	"natural": true, "group": true, "order": true, "limit": true, "offset": true,
+	"for": true, "set": true, "returning": true, "union": true, "window": true,
+}
+
+// statementPredicates is the columns one statement filters a table on.
+type statementPredicates struct {
+	equality []string // =, IN, IS
+	ranges   []string // <, >, BETWEEN, LIKE
+}
+
+// predicatesByTable extracts the indexable predicates of a normalized
+// statement, keyed by table. Unqualified columns are assigned to the one
+// statement table that has them; anything ambiguous is dropped.
+func predicatesByTable(query string, tableKeys []string, columnSet map[string]bool) map[string]*statementPredicates {
+	aliases := make(map[string]string)
+	for _, key := range tableKeys {
+		aliases[strings.ToLower(key[strings.IndexByte(key, '.')+1:])] = key
+	}
+	for _, m := range statementAliasRe.FindAllStringSubmatch(query, -1) {
+		key, ok := aliases[strings.ToLower(m[1])]
+		if ok && m[2] != "" && !aliasStopwords[strings.ToLower(m[2])] {
+			aliases[strings.ToLower(m[2])] = key
+		}
+	}
+
+	preds := make(map[string]*statementPredicates)
+	seen := make(map[string]bool)
+	for _, m := range statementPredicateRe.FindAllStringSubmatch(query, -1) {
+		column := strings.ToLower(m[2])
+		var key string
+		if m[1] != "" {
+			key = aliases[strings.ToLower(m[1])]
+		} else {
+			for _, k := range tableKeys {
+				if !columnSet[k+"."+column] {
+					continue
+				}
+				if key != "" {
+					key = ""
+					break
+				}
+				key = k
+			}
+		}
+		if key == "" || !columnSet[key+"."+column] || seen[key+"."+column] {
+			continue
+		}
+		seen[key+"."+column] = true
+
+		p := preds[key]
+		if p == nil {
+			p = &statementPredicates{}
+			preds[key] = p
+		}
+		switch strings.ToUpper(m[3]) {
+		case "=", "IN", "IS":
+			p.equality = append(p.equality, column)
+		default:
+			p.ranges = append(p.ranges, column)
+		}
+	}
+	return preds
+}
+
+// indexCandidate is a proposed index and the statements that want it.
+type indexCandidate struct {
+	key        string
+	columns    []string
+	equality   int // leading equality columns
+	merged     bool
+	statements int
+	calls      int64
+	example    postgres.StatStatement
+}
+
+// detectSuggestedIndexes proposes indexes for the filters that frequent
+// pg_stat_statements queries apply to large tables. Equality columns lead,
+// ordered by how many calls filter on each across the table's workload so
+// the index serves as many queries as possible, followed by the most
+// common range column. Statements an existing index already serves, by
+// its leading columns, are skipped, and a suggestion that another one
+// serves is folded into it.
+func detectSuggestedIndexes(stmts []postgres.StatStatement, tables []postgres.TableInfo, columns []postgres.ColumnInfo, indexes []postgres.IndexInfo) []Finding {
+	if len(stmts) == 0 {
+		return nil
+	}
+	columnSet := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		columnSet[tableKey(c.Schema, c.Table)+"."+strings.ToLower(c.Name)] = true
+	}
+	tableByKey := make(map[string]postgres.TableInfo, len(tables))
+	for _, t := range tables {
+		tableByKey[tableKey(t.Schema, t.Name)] = t
+	}
+	indexColumns := make(map[string][][]string)
+	for _, idx := range indexes {
+		if strings.Contains(strings.ToUpper(idx.Definition), " WHERE ") {
+			continue
+		}
+		cols := parseIndexColumns(idx.Definition)
+		for i := range cols {
+			cols[i] = strings.ToLower(strings.Trim(cols[i], `"`))
+		}
+		key := tableKey(idx.Schema, idx.Table)
+		indexColumns[key] = append(indexColumns[key], cols)
+	}
+	resolver := newStatementResolver(tables)
+
+	type statementFilter struct {
+		stmt  postgres.StatStatement
+		key   string
+		preds *statementPredicates
+	}
+	var filters []statementFilter
+	weight := make(map[string]int64) // table.column -> calls filtering on it
+	for _, s := range stmts {
+		if s.Calls < hotQueryMinCalls || statementOrRe.MatchString(s.Query) {
+			continue
+		}
+		query := strings.Join(strings.Fields(s.Query), " ")
+		for key, p := range predicatesByTable(query, resolver.tables(query), columnSet) {
+			t, ok := tableByKey[key]
+			if !ok || t.EstimatedRows < statsMinRows {
+				continue
+			}
+			filters = append(filters, statementFilter{stmt: s, key: key, preds: p})
+			for _, c := range append(append([]string(nil), p.equality...), p.ranges...) {
+				weight[key+"."+c] += s.Calls
+			}
+		}
+	}
+
+	byDDL := make(map[string]*indexCandidate)
+	var order []string
+	for _, f := range filters {
+		rank := func(cols []string) []string {
+			cols = append([]string(nil), cols...)
+			sort.SliceStable(cols, func(i, j int) bool {
+				wi, wj := weight[f.key+"."+cols[i]], weight[f.key+"."+cols[j]]
+				if wi != wj {
+					return wi > wj
+				}
+				return cols[i] < cols[j]
+			})
+			return cols
+		}
+		cols := rank(f.preds.equality)
+		if ranges := rank(f.preds.ranges); len(ranges) > 0 {
+			cols = append(cols, ranges[0])
+		}
+		if servedByIndex(cols, len(f.preds.equality), indexColumns[f.key]) {
+			continue
+		}
+
+		t := tableByKey[f.key]
+		quoted := make([]string, len(cols))
+		for i, c := range cols {
+			quoted[i] = quoteIdent(c)
+		}
+		ddl := fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s.%s (%s);", quoteIdent(t.Schema), quoteIdent(t.Name), strings.Join(quoted, ", "))
+		c := byDDL[ddl]
+		if c == nil {
+			c = &indexCandidate{key: f.key, columns: cols, equality: len(f.preds.equality), example: f.stmt}
+			byDDL[ddl] = c
+			order = append(order, ddl)
+		}
+		c.statements++
+		c.calls += f.stmt.Calls
+		if f.stmt.TotalExecTime > c.example.TotalExecTime {
+			c.example = f.stmt
+		}
+	}
+
+	// Fold candidates into longer ones on the same table that serve them.
+	byLength := make([]*indexCandidate, 0, len(order))
+	for _, ddl := range order {
+		byLength = append(byLength, byDDL[ddl])
+	}
+	sort.SliceStable(byLength, func(i, j int) bool { return len(byLength[i].columns) > len(byLength[j].columns) })
+	for i, c := range byLength {
+		for _, d := range byLength[:i] {
+			if d.merged || d.key != c.key || len(d.columns) == len(c.columns) ||
+				!servedByIndex(c.columns, c.equality, [][]string{d.columns}) {
+				continue
+			}
+			c.merged = true
+			d.statements += c.statements
+			d.calls += c.calls
+			if c.example.TotalExecTime > d.example.TotalExecTime {
+				d.example = c.example
+			}
+			break
+		}
+	}
+
+	var findings []Finding
+	for _, ddl := range order {
+		c := byDDL[ddl]
+		if c.merged {
+			continue
+		}
+		t := tableByKey[c.key]
+		findings = append(findings, Finding{
+			Type:     FindingSuggestedIndex,
+			Severity: SeverityMedium,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message: fmt.Sprintf("%d tracked statements (%d calls) filter on (%s) with no index leading with those columns",
+				c.statements, c.calls, strings.Join(c.columns, ", ")),
+			Detail: map[string]string{
+				"ddl":        ddl,
+				"columns":    strings.Join(c.columns, ", "),
+				"statements": strconv.Itoa(c.statements),
+				"calls":      strconv.FormatInt(c.calls, 10),
+				"query_id":   strconv.FormatInt(c.example.QueryID, 10),
+				"query":      truncateSQL(strings.Join(strings.Fields(c.example.Query), " ")),
+			},
+		})
+	}
+	return findings
+}
+
+// servedByIndex reports whether an existing index leads with the
+// candidate's equality columns, in any order, followed by its range
+// column if it has one.
+func servedByIndex(cols []string, equality int, existing [][]string) bool {
+	for _, idx := range existing {
+		if len(idx) < len(cols) {
+			continue
+		}
+		lead := make(map[string]bool, equality)
+		for _, c := range idx[:equality] {
+			lead[c] = true
+		}
+		served := true
+		for i, c := range cols {
+			if (i < equality && !lead[c]) || (i >= equality && idx[i] != c) {
+				served = false
+				break
+			}
+		}
+		if served {
+			return true
+		}
+	}
+	return false
 }
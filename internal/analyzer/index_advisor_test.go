@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/ppiankov/pgspectre/internal/postgres"
@@ -134,6 +135,50 @@ func TestDetectUnindexedQueries_OrderByContext(t *testing.T) {
 	}
 }
 
+func TestDetectUnindexedQueries_JoinContext(t *testing.T) {
+	columnRefs := []scanner.ColumnRef{
+		{Table: "orders", Column: "user_id", Context: scanner.ContextJoin},
+		{Table: "users", Column: "id", Context: scanner.ContextJoin},
+	}
+	indexes := []postgres.IndexInfo{
+		{Schema: "public", Table: "users", Name: "users_pkey", Definition: "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)"},
+	}
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "users"},
+	}
+
+	findings := DetectUnindexedQueries(columnRefs, indexes, tables)
+	if len(findings) != 1 || findings[0].Table != "orders" || findings[0].Column != "user_id" {
+		t.Fatalf("expected 1 finding for orders.user_id, got %v", findings)
+	}
+}
+
+func TestDetectUnindexedQueries_ConflictTarget(t *testing.T) {
+	columnRefs := []scanner.ColumnRef{
+		{Table: "counters", Column: "key", Context: scanner.ContextConflict},
+		{Table: "sessions", Column: "token", Context: scanner.ContextConflict},
+	}
+	indexes := []postgres.IndexInfo{
+		// A plain index doesn't satisfy ON CONFLICT; a unique one does.
+		{Schema: "public", Table: "counters", Name: "idx_counters_key", Definition: "CREATE INDEX idx_counters_key ON public.counters USING btree (key)"},
+		{Schema: "public", Table: "sessions", Name: "sessions_token_key", Definition: "CREATE UNIQUE INDEX sessions_token_key ON public.sessions USING btree (token)"},
+	}
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "counters"},
+		{Schema: "public", Name: "sessions"},
+	}
+
+	findings := DetectUnindexedQueries(columnRefs, indexes, tables)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Table != "counters" || f.Column != "key" || f.Severity != SeverityHigh {
+		t.Errorf("finding = %+v, want high for counters.key", f)
+	}
+}
+
 func TestDetectUnindexedQueries_UnknownTable(t *testing.T) {
 	columnRefs := []scanner.ColumnRef{
 		{Table: "nonexistent", Column: "id", Context: scanner.ContextWhere},
@@ -170,3 +215,93 @@ func TestBuildIndexedColumns(t *testing.T) {
 		t.Error("should not contain public.users.name")
 	}
 }
+
+func TestPredicatesByTable(t *testing.T) {
+	columnSet := map[string]bool{
+		"public.orders.user_id": true, "public.orders.status": true, "public.orders.created_at": true,
+		"public.users.id": true, "public.users.status": true,
+	}
+	query := "SELECT o.* FROM orders AS o JOIN users u ON u.id = o.user_id WHERE o.user_id = $1 AND u.status IN ($2) AND created_at > $3 AND status = $4"
+
+	preds := predicatesByTable(query, []string{"public.orders", "public.users"}, columnSet)
+	orders, users := preds["public.orders"], preds["public.users"]
+	if orders == nil || !slices.Equal(orders.equality, []string{"user_id"}) || !slices.Equal(orders.ranges, []string{"created_at"}) {
+		t.Errorf("orders = %+v", orders)
+	}
+	// Unqualified status exists in both tables and is dropped.
+	if users == nil || !slices.Equal(users.equality, []string{"status"}) || len(users.ranges) != 0 {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestDetectSuggestedIndexes(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "orders", EstimatedRows: 1000000},
+		{Schema: "public", Name: "small", EstimatedRows: 100},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "orders", Name: "id"},
+		{Schema: "public", Table: "orders", Name: "user_id"},
+		{Schema: "public", Table: "orders", Name: "status"},
+		{Schema: "public", Table: "orders", Name: "created_at"},
+		{Schema: "public", Table: "small", Name: "kind"},
+	}
+	indexes := []postgres.IndexInfo{
+		{Schema: "public", Table: "orders", Name: "orders_pkey", Definition: "CREATE UNIQUE INDEX orders_pkey ON public.orders USING btree (id)"},
+	}
+	stmts := []postgres.StatStatement{
+		{QueryID: 1, Query: "SELECT * FROM orders WHERE status = $1 AND user_id = $2 AND created_at > $3", Calls: 500, TotalExecTime: 900},
+		{QueryID: 2, Query: "SELECT * FROM orders WHERE user_id = $1", Calls: 5000, TotalExecTime: 100},
+		{QueryID: 3, Query: "SELECT * FROM orders WHERE id = $1", Calls: 100000},
+		{QueryID: 4, Query: "SELECT * FROM orders WHERE status = $1 OR user_id = $2", Calls: 1000},
+		{QueryID: 5, Query: "SELECT * FROM small WHERE kind = $1", Calls: 1000},
+		{QueryID: 6, Query: "SELECT * FROM orders WHERE created_at < $1", Calls: 10},
+	}
+
+	findings := detectSuggestedIndexes(stmts, tables, columns, indexes)
+	var ddls []string
+	for _, f := range findings {
+		if f.Type != FindingSuggestedIndex || f.Table != "orders" {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+		ddls = append(ddls, f.Detail["ddl"])
+	}
+	// The user_id lookup is folded into the composite index, which leads
+	// with user_id because more calls filter on it than on status.
+	want := []string{"CREATE INDEX CONCURRENTLY ON public.orders (user_id, status, created_at);"}
+	if !slices.Equal(ddls, want) {
+		t.Errorf("ddl = %q, want %q", ddls, want)
+	}
+	if f := findings[0]; f.Detail["statements"] != "2" || f.Detail["calls"] != "5500" || f.Detail["query_id"] != "1" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+
+	// An index leading with the equality columns in another order serves
+	// the composite filter, but not the user_id lookup.
+	indexes = append(indexes, postgres.IndexInfo{Schema: "public", Table: "orders", Name: "orders_status_user_idx",
+		Definition: "CREATE INDEX orders_status_user_idx ON public.orders USING btree (status, user_id, created_at)"})
+	findings = detectSuggestedIndexes(stmts, tables, columns, indexes)
+	if len(findings) != 1 || findings[0].Detail["ddl"] != "CREATE INDEX CONCURRENTLY ON public.orders (user_id);" {
+		t.Errorf("with composite index: got %+v", findings)
+	}
+}
+
+func TestServedByIndex(t *testing.T) {
+	existing := [][]string{{"status", "user_id", "created_at"}}
+	tests := []struct {
+		cols     []string
+		equality int
+		want     bool
+	}{
+		{[]string{"user_id", "status"}, 2, true},
+		{[]string{"user_id", "status", "created_at"}, 2, true},
+		{[]string{"user_id"}, 1, false},
+		{[]string{"created_at"}, 0, false},
+		{[]string{"status", "created_at"}, 1, false},
+	}
+	for _, tt := range tests {
+		if got := servedByIndex(tt.cols, tt.equality, existing); got != tt.want {
+			t.Errorf("servedByIndex(%v, %d) = %v, want %v", tt.cols, tt.equality, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// maxExampleRefs caps the code locations listed in a finding's detail.
+const maxExampleRefs = 3
+
+// coversJSONB reports whether an index can serve jsonb filters on col: a
+// GIN or GiST index with the column in a key, or an expression index
+// extracting a key from it for ->> comparisons. The column must appear as
+// a whole identifier, so an index on data doesn't cover metadata.
+func coversJSONB(def, col string) bool {
+	lower := strings.ToLower(def)
+	col = strings.ToLower(col)
+	i := strings.Index(lower, " using ")
+	if i < 0 {
+		return false
+	}
+	rest := strings.TrimSpace(lower[i+len(" using "):])
+	method, keys, _ := strings.Cut(rest, "(")
+	method = strings.TrimSpace(method)
+	gin := method == "gin" || method == "gist"
+	for _, rest := range identPositions(indexKeyList(keys), col) {
+		if gin {
+			return true
+		}
+		after := strings.TrimLeft(rest, " \t")
+		if strings.HasPrefix(after, "->") || strings.HasPrefix(after, "@>") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexKeyList returns the key list of an index definition, given the
+// text after its opening parenthesis: everything up to the matching
+// closing one.
+func indexKeyList(s string) string {
+	depth, inQuote := 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return s[:i]
+			}
+			depth--
+		}
+	}
+	return s
+}
+
+// identPositions finds name as a whole identifier, bare or double-quoted,
+// in a lowercased SQL expression, skipping string literals, and returns
+// the text following each occurrence.
+func identPositions(expr, name string) []string {
+	var rests []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(expr[i+1:], '\'')
+			if end < 0 {
+				return rests
+			}
+			i += end + 2
+		case c == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end < 0 {
+				return rests
+			}
+			if expr[i+1:i+1+end] == name {
+				rests = append(rests, expr[i+end+2:])
+			}
+			i += end + 2
+		case isIdentByte(c):
+			j := i
+			for j < len(expr) && isIdentByte(expr[j]) {
+				j++
+			}
+			if expr[i:j] == name {
+				rests = append(rests, expr[j:])
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return rests
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// detectMissingGINIndexes flags jsonb columns that code filters with jsonb
+// operators (@>, ?|, ->> and friends) or jsonb_* predicates when no index
+// can serve those filters, so every such query scans the table. A ref is
+// matched by its table qualifier when that names a table, otherwise by the
+// column name when only one jsonb column in the database has it.
+func detectMissingGINIndexes(columnRefs []scanner.ColumnRef, columns []postgres.ColumnInfo, indexes []postgres.IndexInfo) []Finding {
+	byName := make(map[string][]postgres.ColumnInfo)
+	for _, c := range columns {
+		if strings.EqualFold(c.DataType, "jsonb") {
+			name := strings.ToLower(c.Name)
+			byName[name] = append(byName[name], c)
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+
+	refs := make(map[string][]scanner.ColumnRef) // table key.column → refs
+	cols := make(map[string]postgres.ColumnInfo)
+	for _, cr := range columnRefs {
+		if cr.Context != scanner.ContextJSONB || cr.Suppressed {
+			continue
+		}
+		candidates := byName[strings.ToLower(cr.Column)]
+		var match []postgres.ColumnInfo
+		for _, c := range candidates {
+			if strings.EqualFold(c.Table, cr.Table) {
+				match = append(match, c)
+			}
+		}
+		if len(match) == 0 {
+			match = candidates // qualifier is an alias or missing
+		}
+		if len(match) != 1 {
+			continue
+		}
+		key := tableKey(match[0].Schema, match[0].Table) + "." + strings.ToLower(match[0].Name)
+		refs[key] = append(refs[key], cr)
+		cols[key] = match[0]
+	}
+
+	var findings []Finding
+	for key, c := range cols {
+		covered := false
+		for _, idx := range indexes {
+			if strings.EqualFold(idx.Schema, c.Schema) && strings.EqualFold(idx.Table, c.Table) && coversJSONB(idx.Definition, c.Name) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		var examples []string
+		for _, cr := range refs[key] {
+			examples = append(examples, fmt.Sprintf("%s:%d", cr.File, cr.Line))
+		}
+		sort.Strings(examples)
+		if len(examples) > maxExampleRefs {
+			examples = examples[:maxExampleRefs]
+		}
+		findings = append(findings, Finding{
+			Type:     FindingMissingGINIndex,
+			Severity: SeverityMedium,
+			Schema:   c.Schema,
+			Table:    c.Table,
+			Column:   c.Name,
+			Message:  fmt.Sprintf("jsonb column %q is filtered in code (%d references) but has no GIN index", c.Name, len(refs[key])),
+			Detail: map[string]string{
+				"references": strconv.Itoa(len(refs[key])),
+				"examples":   strings.Join(examples, ", "),
+				"suggestion": fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s.%s USING gin (%s);", quoteIdent(c.Schema), quoteIdent(c.Table), quoteIdent(c.Name)),
+			},
+		})
+	}
+	return findings
+}
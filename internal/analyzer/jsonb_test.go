@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestDetectMissingGINIndexes(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "events").Column("id", "bigint").Column("payload", "jsonb")
+	b.Table("public", "audit_log").Column("payload", "jsonb")
+	b.Table("public", "users").Column("settings", "jsonb").Column("name", "text")
+	b.Table("public", "orders").Column("meta", "jsonb")
+	snap := b.Build()
+	snap.Indexes = append(snap.Indexes,
+		makeIndex("public", "users", "users_settings_gin", "CREATE INDEX users_settings_gin ON public.users USING gin (settings jsonb_path_ops)", 0, 0),
+		makeIndex("public", "orders", "orders_meta_status", "CREATE INDEX orders_meta_status ON public.orders USING btree ((meta ->> 'status'::text))", 0, 0),
+	)
+
+	refs := []scanner.ColumnRef{
+		{Table: "events", Column: "payload", File: "b.go", Line: 3, Context: scanner.ContextJSONB},
+		{Table: "e", Column: "payload", File: "a.go", Line: 9, Context: scanner.ContextJSONB}, // alias: payload is ambiguous
+		{Column: "settings", File: "a.go", Line: 1, Context: scanner.ContextJSONB},            // GIN indexed
+		{Column: "meta", File: "a.go", Line: 2, Context: scanner.ContextJSONB},                // expression index
+		{Column: "name", File: "a.go", Line: 4, Context: scanner.ContextJSONB},                // not jsonb
+		{Table: "events", Column: "payload", File: "c.go", Line: 5, Context: scanner.ContextWhere},
+	}
+	findings := detectMissingGINIndexes(refs, snap.Columns, snap.Indexes)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingMissingGINIndex || f.Table != "events" || f.Column != "payload" || f.Severity != SeverityMedium {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["references"] != "1" || f.Detail["examples"] != "b.go:3" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+	if want := "CREATE INDEX CONCURRENTLY ON public.events USING gin (payload);"; f.Detail["suggestion"] != want {
+		t.Errorf("suggestion = %q, want %q", f.Detail["suggestion"], want)
+	}
+
+	// An unqualified ref resolves when only one jsonb column has the name.
+	refs = []scanner.ColumnRef{{Column: "payload", Context: scanner.ContextJSONB}}
+	if got := detectMissingGINIndexes(refs, snap.Columns[:2], snap.Indexes); len(got) != 1 || got[0].Table != "events" {
+		t.Errorf("unqualified ref: got %+v", got)
+	}
+}
+
+// An index on one column doesn't cover another whose name contains it.
+func TestDetectMissingGINIndexes_SimilarNames(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "docs").Column("data", "jsonb").Column("metadata", "jsonb").
+		Column("settings", "jsonb").Column("settings_v2", "jsonb")
+	snap := b.Build()
+	snap.Indexes = append(snap.Indexes,
+		makeIndex("public", "docs", "docs_metadata_gin", "CREATE INDEX docs_metadata_gin ON public.docs USING gin (metadata)", 0, 0),
+		makeIndex("public", "docs", "docs_settings_v2", "CREATE INDEX docs_settings_v2 ON public.docs USING btree ((settings_v2 ->> 'theme'::text))", 0, 0),
+		makeIndex("public", "docs", "docs_data_key", "CREATE INDEX docs_data_key ON public.docs USING btree ((metadata ->> 'data'::text))", 0, 0),
+	)
+	refs := []scanner.ColumnRef{
+		{Column: "data", File: "d.go", Line: 1, Context: scanner.ContextJSONB},
+		{Column: "metadata", File: "d.go", Line: 2, Context: scanner.ContextJSONB},
+		{Column: "settings", File: "d.go", Line: 3, Context: scanner.ContextJSONB},
+		{Column: "settings_v2", File: "d.go", Line: 4, Context: scanner.ContextJSONB},
+	}
+	var flagged []string
+	for _, f := range detectMissingGINIndexes(refs, snap.Columns, snap.Indexes) {
+		flagged = append(flagged, f.Column)
+	}
+	sort.Strings(flagged)
+	if want := []string{"data", "settings"}; !reflect.DeepEqual(flagged, want) {
+		t.Errorf("flagged = %v, want %v", flagged, want)
+	}
+}
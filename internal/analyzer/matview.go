@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// detectStaleMatViews flags materialized views that were never populated
+// (every query against them fails) or that have not been scanned since
+// the last statistics reset.
+func detectStaleMatViews(matviews []postgres.MatViewInfo) []Finding {
+	var findings []Finding
+	for _, mv := range matviews {
+		detail := map[string]string{
+			"size":         formatBytes(mv.SizeBytes),
+			"seq_scan":     strconv.FormatInt(mv.SeqScan, 10),
+			"idx_scan":     strconv.FormatInt(mv.IdxScan, 10),
+			"rows_written": strconv.FormatInt(mv.RowsWritten, 10),
+		}
+		if mv.LastRefresh != nil {
+			detail["last_refresh_estimate"] = mv.LastRefresh.Format(time.RFC3339)
+		}
+
+		switch {
+		case !mv.Populated:
+			detail["suggestion"] = fmt.Sprintf("REFRESH MATERIALIZED VIEW %s.%s;", quoteIdent(mv.Schema), quoteIdent(mv.Name))
+			findings = append(findings, Finding{
+				Type:     FindingStaleMatView,
+				Severity: SeverityMedium,
+				Schema:   mv.Schema,
+				Table:    mv.Name,
+				Message:  fmt.Sprintf("materialized view %q has never been populated; queries against it fail until REFRESH", mv.Name),
+				Detail:   detail,
+			})
+		case mv.SeqScan == 0 && mv.IdxScan == 0:
+			msg := fmt.Sprintf("materialized view %q has no scans (%s)", mv.Name, formatBytes(mv.SizeBytes))
+			if mv.RowsWritten > 0 {
+				msg += "; it is refreshed but never read"
+			}
+			findings = append(findings, Finding{
+				Type:     FindingStaleMatView,
+				Severity: SeverityLow,
+				Schema:   mv.Schema,
+				Table:    mv.Name,
+				Message:  msg,
+				Detail:   detail,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectStaleMatViews(t *testing.T) {
+	refreshed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	matviews := []postgres.MatViewInfo{
+		{Schema: "public", Name: "never_populated", Populated: false},
+		{Schema: "public", Name: "unread", Populated: true, SizeBytes: 8192, RowsWritten: 500, LastRefresh: &refreshed},
+		{Schema: "public", Name: "idle", Populated: true},
+		{Schema: "public", Name: "in_use", Populated: true, SeqScan: 3, IdxScan: 40},
+	}
+
+	findings := detectStaleMatViews(matviews)
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+
+	byName := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Type != FindingStaleMatView {
+			t.Errorf("type = %s, want STALE_MATVIEW", f.Type)
+		}
+		byName[f.Table] = f
+	}
+	if byName["never_populated"].Severity != SeverityMedium {
+		t.Errorf("never_populated severity = %s, want medium", byName["never_populated"].Severity)
+	}
+	unread := byName["unread"]
+	if unread.Severity != SeverityLow {
+		t.Errorf("unread severity = %s, want low", unread.Severity)
+	}
+	if unread.Detail["last_refresh_estimate"] != "2024-05-01T00:00:00Z" {
+		t.Errorf("last_refresh_estimate = %q", unread.Detail["last_refresh_estimate"])
+	}
+	if _, ok := byName["idle"].Detail["last_refresh_estimate"]; ok {
+		t.Error("idle has no refresh estimate but detail has one")
+	}
+	if _, ok := byName["in_use"]; ok {
+		t.Error("in_use matview should not be flagged")
+	}
+}
+
+func TestAudit_MatViewsNotReportedAsUnusedTables(t *testing.T) {
+	snap := &postgres.Snapshot{
+		Stats:    []postgres.TableStats{{Schema: "public", Name: "daily_totals"}},
+		MatViews: []postgres.MatViewInfo{{Schema: "public", Name: "daily_totals", Populated: true}},
+	}
+	findings := Audit(snap, DefaultAuditOptions())
+	for _, f := range findings {
+		if f.Type == FindingUnusedTable {
+			t.Errorf("matview reported as UNUSED_TABLE: %+v", f)
+		}
+	}
+	if len(findingsOfType(findings, FindingStaleMatView)) != 1 {
+		t.Errorf("want one STALE_MATVIEW, got %+v", findings)
+	}
+}
@@ -0,0 +1,350 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// Lock levels taken by DDL, from the PostgreSQL explicit locking docs.
+const (
+	lockAccessExclusive      = "ACCESS EXCLUSIVE"
+	lockShare                = "SHARE"
+	lockShareRowExclusive    = "SHARE ROW EXCLUSIVE"
+	lockShareUpdateExclusive = "SHARE UPDATE EXCLUSIVE"
+)
+
+// MigrationOptions controls migration checks.
+type MigrationOptions struct {
+	// LargeTableBytes is the table size at which blocking DDL is escalated.
+	LargeTableBytes int64
+	// ServerMajor is the target PostgreSQL major version; 0 means unknown,
+	// which is treated as a current release.
+	ServerMajor int
+	// RunnerTimeouts lists settings (lock_timeout, statement_timeout) the
+	// migration runner sets before each file, so the file needn't.
+	RunnerTimeouts []string
+}
+
+// DefaultMigrationOptions returns defaults matching the config defaults.
+func DefaultMigrationOptions() MigrationOptions {
+	return MigrationOptions{
+		LargeTableBytes: 100 * 1024 * 1024, // 100 MB
+	}
+}
+
+// migrationState tracks objects as the migration's statements are applied
+// in order, starting from the live snapshot.
+type migrationState struct {
+	tables   map[string]postgres.TableInfo
+	indexes  map[string]postgres.IndexInfo
+	created  map[string]bool // tables created earlier in this migration
+	timeouts map[string]bool // timeout settings in effect
+}
+
+func newMigrationState(snap *postgres.Snapshot, opts MigrationOptions) *migrationState {
+	st := &migrationState{
+		tables:   make(map[string]postgres.TableInfo, len(snap.Tables)),
+		indexes:  make(map[string]postgres.IndexInfo, len(snap.Indexes)),
+		created:  make(map[string]bool),
+		timeouts: make(map[string]bool),
+	}
+	// Runner timeouts are session-level, so they outlive COMMIT.
+	for _, name := range opts.RunnerTimeouts {
+		st.timeouts[strings.ToLower(name)] = false
+	}
+	for _, t := range snap.Tables {
+		st.tables[tableKey(t.Schema, t.Name)] = t
+	}
+	for _, idx := range snap.Indexes {
+		st.indexes[tableKey(idx.Schema, idx.Name)] = idx
+	}
+	return st
+}
+
+// CheckMigration analyzes the statements of a single migration file against
+// the live database: what it changes, conflicts with existing objects,
+// missing IF [NOT] EXISTS guards, lock impact on existing tables, and
+// locking DDL run without lock_timeout or statement_timeout.
+func CheckMigration(file string, stmts []migration.Statement, snap *postgres.Snapshot, opts MigrationOptions) []Finding {
+	if opts.LargeTableBytes <= 0 {
+		opts.LargeTableBytes = DefaultMigrationOptions().LargeTableBytes
+	}
+
+	st := newMigrationState(snap, opts)
+	var findings []Finding
+	for _, s := range stmts {
+		detail := func() map[string]string {
+			return map[string]string{
+				"file":      file,
+				"line":      strconv.Itoa(s.Line),
+				"statement": truncateSQL(s.SQL),
+			}
+		}
+		stmtFindings := st.check(s, opts, detail)
+		findings = append(findings, stmtFindings...)
+		if f, ok := st.timeoutFinding(stmtFindings, detail); ok {
+			findings = append(findings, f)
+		}
+	}
+	annotateConfidence(findings, evidence{})
+	return findings
+}
+
+// check analyzes one statement and applies it to the state.
+func (st *migrationState) check(s migration.Statement, opts MigrationOptions, detail func() map[string]string) []Finding {
+	if s.Kind == "" {
+		st.applySetting(s)
+		if schema, table, ok := lintRewriteCommand(s); ok {
+			key := tableKey(defaultSchema(schema), table)
+			if tbl, ok := st.tables[key]; ok && !st.created[key] {
+				return []Finding{ddlFinding(tbl, workRewrite, lockAccessExclusive,
+					"VACUUM FULL and CLUSTER rewrite the table", opts.LargeTableBytes, detail())}
+			}
+		}
+		return nil
+	}
+	schema := defaultSchema(s.Schema)
+	tableSchema := defaultSchema(s.TableSchema)
+
+	findings := []Finding{migrationChange(s, schema, tableSchema, detail())}
+
+	switch s.Kind {
+	case migration.KindTable:
+		key := tableKey(schema, s.Name)
+		tbl, exists := st.tables[key]
+		findings = append(findings, checkGuards(s, schema, exists, detail)...)
+		if exists && !st.created[key] {
+			if lock := statementLock(s); lock != "" {
+				findings = append(findings, lockFinding(s, tbl, lock, opts.LargeTableBytes, detail()))
+			}
+			if s.Action == migration.ActionAlter {
+				findings = append(findings, lintDDL(s, tbl, opts, detail)...)
+			}
+			if column, newName, ok := s.Renamed(); ok {
+				findings = append(findings, renameFinding(tbl, column, newName, detail()))
+			}
+		}
+		switch s.Action {
+		case migration.ActionCreate:
+			st.tables[key] = postgres.TableInfo{Schema: schema, Name: s.Name}
+			st.created[key] = true
+		case migration.ActionDrop:
+			delete(st.tables, key)
+		case migration.ActionAlter:
+			if column, newName, ok := s.Renamed(); ok && column == "" && exists {
+				newKey := tableKey(schema, newName)
+				tbl.Name = newName
+				st.tables[newKey] = tbl
+				st.created[newKey] = st.created[key]
+				delete(st.tables, key)
+				delete(st.created, key)
+			}
+		}
+
+	case migration.KindIndex:
+		var exists bool
+		if s.Name != "" {
+			_, exists = st.indexes[tableKey(schema, s.Name)]
+		}
+		if s.Action == migration.ActionCreate {
+			findings = append(findings, checkGuards(s, schema, exists, detail)...)
+			tkey := tableKey(tableSchema, s.Table)
+			if tbl, ok := st.tables[tkey]; ok && !st.created[tkey] && !s.Concurrently {
+				findings = append(findings, blockingIndexFinding(s, tbl, opts.LargeTableBytes, detail()))
+			}
+			if s.Name != "" {
+				st.indexes[tableKey(schema, s.Name)] = postgres.IndexInfo{Schema: tableSchema, Table: s.Table, Name: s.Name}
+			}
+			return findings
+		}
+
+		findings = append(findings, checkGuards(s, schema, exists, detail)...)
+		idx, ok := st.indexes[tableKey(schema, s.Name)]
+		if ok && !s.Concurrently {
+			tkey := tableKey(idx.Schema, idx.Table)
+			if tbl, ok := st.tables[tkey]; ok && !st.created[tkey] {
+				findings = append(findings, lockFinding(s, tbl, lockAccessExclusive, opts.LargeTableBytes, detail()))
+			}
+		}
+		delete(st.indexes, tableKey(schema, s.Name))
+	}
+	return findings
+}
+
+func defaultSchema(schema string) string {
+	if schema == "" {
+		return "public"
+	}
+	return schema
+}
+
+// migrationChange describes what a statement does as an info finding.
+func migrationChange(s migration.Statement, schema, tableSchema string, detail map[string]string) Finding {
+	f := Finding{
+		Type:     FindingMigrationChange,
+		Severity: SeverityInfo,
+		Schema:   tableSchema,
+		Table:    s.Table,
+		Detail:   detail,
+	}
+	switch s.Kind {
+	case migration.KindTable:
+		f.Schema = schema
+		f.Message = fmt.Sprintf("%s table %q", verb(s.Action), s.Name)
+	case migration.KindIndex:
+		f.Index = s.Name
+		name := s.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		f.Message = fmt.Sprintf("%s index %q", verb(s.Action), name)
+		if s.Table != "" {
+			f.Message += fmt.Sprintf(" on %q", s.Table)
+		}
+	}
+	return f
+}
+
+func verb(a migration.Action) string {
+	switch a {
+	case migration.ActionCreate:
+		return "creates"
+	case migration.ActionDrop:
+		return "drops"
+	default:
+		return "alters"
+	}
+}
+
+// checkGuards reports conflicts with existing objects and missing
+// IF [NOT] EXISTS guards.
+func checkGuards(s migration.Statement, schema string, exists bool, detail func() map[string]string) []Finding {
+	if s.Name == "" {
+		return nil
+	}
+	base := Finding{
+		Schema: schema,
+		Table:  s.Table,
+	}
+	if s.Kind == migration.KindIndex {
+		base.Index = s.Name
+	}
+
+	switch s.Action {
+	case migration.ActionCreate:
+		if s.IfNotExists {
+			return nil
+		}
+		if exists {
+			f := base
+			f.Type, f.Severity = FindingMigrationConflict, SeverityHigh
+			f.Message = fmt.Sprintf("%s %q already exists; CREATE will fail", s.Kind, s.Name)
+			f.Detail = detail()
+			return []Finding{f}
+		}
+		f := base
+		f.Type, f.Severity = FindingMigrationMissingGuard, SeverityLow
+		f.Message = fmt.Sprintf("CREATE %s %q has no IF NOT EXISTS guard", strings.ToUpper(string(s.Kind)), s.Name)
+		f.Detail = detail()
+		return []Finding{f}
+
+	case migration.ActionDrop, migration.ActionAlter:
+		if s.IfExists {
+			return nil
+		}
+		if !exists {
+			f := base
+			f.Type, f.Severity = FindingMigrationConflict, SeverityHigh
+			f.Message = fmt.Sprintf("%s %q does not exist; %s will fail", s.Kind, s.Name, strings.ToUpper(string(s.Action)))
+			f.Detail = detail()
+			return []Finding{f}
+		}
+		if s.Action == migration.ActionDrop {
+			f := base
+			f.Type, f.Severity = FindingMigrationMissingGuard, SeverityLow
+			f.Message = fmt.Sprintf("DROP %s %q has no IF EXISTS guard", strings.ToUpper(string(s.Kind)), s.Name)
+			f.Detail = detail()
+			return []Finding{f}
+		}
+	}
+	return nil
+}
+
+// statementLock returns the table lock a table statement takes, or "" if
+// it needs no warning.
+func statementLock(s migration.Statement) string {
+	switch s.Action {
+	case migration.ActionDrop:
+		return lockAccessExclusive
+	case migration.ActionAlter:
+		return alterTableLock(s.Clause)
+	}
+	return ""
+}
+
+// alterTableLock returns the lock level for an ALTER TABLE clause. Most
+// subcommands take ACCESS EXCLUSIVE; a few take weaker locks.
+func alterTableLock(clause string) string {
+	upper := strings.ToUpper(clause)
+	switch {
+	case strings.HasPrefix(upper, "VALIDATE CONSTRAINT"),
+		strings.Contains(upper, "SET STATISTICS"),
+		strings.HasPrefix(upper, "SET (") && strings.Contains(upper, "AUTOVACUUM"):
+		return lockShareUpdateExclusive
+	case strings.HasPrefix(upper, "ADD") && strings.Contains(upper, "FOREIGN KEY"),
+		strings.Contains(upper, "TRIGGER"):
+		return lockShareRowExclusive
+	}
+	return lockAccessExclusive
+}
+
+// lockFinding warns that a statement locks an existing table.
+func lockFinding(s migration.Statement, tbl postgres.TableInfo, lock string, largeBytes int64, detail map[string]string) Finding {
+	sev := SeverityLow
+	if tbl.SizeBytes >= largeBytes && lock == lockAccessExclusive {
+		sev = SeverityMedium
+	}
+	detail["lock"] = lock
+	detail["table_size"] = formatBytes(tbl.SizeBytes)
+	return Finding{
+		Type:     FindingMigrationLock,
+		Severity: sev,
+		Schema:   tbl.Schema,
+		Table:    tbl.Name,
+		Message:  fmt.Sprintf("takes %s lock on %q (%s)", lock, tbl.Name, formatBytes(tbl.SizeBytes)),
+		Detail:   detail,
+	}
+}
+
+// blockingIndexFinding flags CREATE INDEX without CONCURRENTLY on an
+// existing table, which blocks writes until the build finishes.
+func blockingIndexFinding(s migration.Statement, tbl postgres.TableInfo, largeBytes int64, detail map[string]string) Finding {
+	sev := SeverityLow
+	if tbl.SizeBytes >= largeBytes {
+		sev = SeverityHigh
+	}
+	detail["lock"] = lockShare
+	detail["table_size"] = formatBytes(tbl.SizeBytes)
+	return Finding{
+		Type:     FindingMigrationBlockingIndex,
+		Severity: sev,
+		Schema:   tbl.Schema,
+		Table:    tbl.Name,
+		Index:    s.Name,
+		Message:  fmt.Sprintf("CREATE INDEX without CONCURRENTLY blocks writes to %q (%s) for the whole build", tbl.Name, formatBytes(tbl.SizeBytes)),
+		Detail:   detail,
+	}
+}
+
+// truncateSQL shortens a statement for display in finding details.
+func truncateSQL(sql string) string {
+	const maxLen = 200
+	if len(sql) <= maxLen {
+		return sql
+	}
+	return sql[:maxLen-3] + "..."
+}
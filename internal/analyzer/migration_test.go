@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func migrationSnapshot() *postgres.Snapshot {
+	return &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "users", SizeBytes: 500 * 1024 * 1024},
+			{Schema: "public", Name: "tags", SizeBytes: 64 * 1024},
+		},
+		Indexes: []postgres.IndexInfo{
+			{Schema: "public", Table: "users", Name: "idx_users_email"},
+		},
+	}
+}
+
+func findingsOfType(findings []Finding, ft FindingType) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Type == ft {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestCheckMigration_ChangesAndGuards(t *testing.T) {
+	stmts := migration.Parse(`
+CREATE TABLE orders (id bigint);
+CREATE TABLE IF NOT EXISTS audit_log (id bigint);
+DROP TABLE legacy;
+DROP TABLE IF EXISTS legacy2;
+CREATE TABLE users (id bigint);
+`)
+	findings := CheckMigration("001.sql", stmts, migrationSnapshot(), DefaultMigrationOptions())
+
+	if got := len(findingsOfType(findings, FindingMigrationChange)); got != 5 {
+		t.Errorf("MIGRATION_CHANGE = %d, want 5", got)
+	}
+
+	guards := findingsOfType(findings, FindingMigrationMissingGuard)
+	if len(guards) != 1 || guards[0].Table != "orders" {
+		t.Errorf("MIGRATION_MISSING_GUARD = %+v, want orders only", guards)
+	}
+
+	conflicts := findingsOfType(findings, FindingMigrationConflict)
+	if len(conflicts) != 2 {
+		t.Fatalf("MIGRATION_CONFLICT = %d, want 2 (drop missing legacy, create existing users)", len(conflicts))
+	}
+	for _, f := range conflicts {
+		if f.Severity != SeverityHigh {
+			t.Errorf("conflict severity = %s, want high", f.Severity)
+		}
+		if f.Detail["file"] != "001.sql" || f.Detail["line"] == "" {
+			t.Errorf("conflict detail missing location: %v", f.Detail)
+		}
+	}
+}
+
+func TestCheckMigration_BlockingIndex(t *testing.T) {
+	stmts := migration.Parse(`
+CREATE INDEX idx_users_name ON users (name);
+CREATE INDEX CONCURRENTLY idx_users_age ON users (age);
+CREATE INDEX idx_tags_name ON tags (name);
+CREATE TABLE fresh (id int);
+CREATE INDEX idx_fresh_id ON fresh (id);
+`)
+	findings := CheckMigration("002.sql", stmts, migrationSnapshot(), DefaultMigrationOptions())
+
+	blocking := findingsOfType(findings, FindingMigrationBlockingIndex)
+	if len(blocking) != 2 {
+		t.Fatalf("MIGRATION_BLOCKING_INDEX = %d, want 2", len(blocking))
+	}
+	sev := map[string]Severity{}
+	for _, f := range blocking {
+		sev[f.Table] = f.Severity
+	}
+	if sev["users"] != SeverityHigh {
+		t.Errorf("users severity = %s, want high", sev["users"])
+	}
+	if sev["tags"] != SeverityLow {
+		t.Errorf("tags severity = %s, want low", sev["tags"])
+	}
+}
+
+func TestCheckMigration_LockImpact(t *testing.T) {
+	stmts := migration.Parse(`
+ALTER TABLE users ADD COLUMN nickname text;
+ALTER TABLE users VALIDATE CONSTRAINT users_org_fk;
+ALTER TABLE tags ADD COLUMN color text;
+ALTER TABLE missing ADD COLUMN x int;
+DROP INDEX idx_users_email;
+`)
+	findings := CheckMigration("003.sql", stmts, migrationSnapshot(), DefaultMigrationOptions())
+
+	locks := findingsOfType(findings, FindingMigrationLock)
+	if len(locks) != 4 {
+		t.Fatalf("MIGRATION_LOCK = %d, want 4: %+v", len(locks), locks)
+	}
+	if locks[0].Severity != SeverityMedium || locks[0].Detail["lock"] != lockAccessExclusive {
+		t.Errorf("ADD COLUMN on large table: %+v", locks[0])
+	}
+	if locks[1].Severity != SeverityLow || locks[1].Detail["lock"] != lockShareUpdateExclusive {
+		t.Errorf("VALIDATE CONSTRAINT: %+v", locks[1])
+	}
+	if locks[2].Severity != SeverityLow {
+		t.Errorf("ALTER on small table severity = %s, want low", locks[2].Severity)
+	}
+	if locks[3].Table != "users" || locks[3].Severity != SeverityMedium {
+		t.Errorf("DROP INDEX lock: %+v", locks[3])
+	}
+
+	conflicts := findingsOfType(findings, FindingMigrationConflict)
+	if len(conflicts) != 1 || conflicts[0].Table != "missing" {
+		t.Errorf("MIGRATION_CONFLICT = %+v, want missing table", conflicts)
+	}
+}
+
+func TestCheckMigration_DropThenCreateIsNotConflict(t *testing.T) {
+	stmts := migration.Parse(`
+DROP TABLE IF EXISTS tags;
+CREATE TABLE tags (id int);
+`)
+	findings := CheckMigration("004.sql", stmts, migrationSnapshot(), DefaultMigrationOptions())
+	if got := findingsOfType(findings, FindingMigrationConflict); len(got) != 0 {
+		t.Errorf("unexpected conflicts: %+v", got)
+	}
+}
+
+func TestAlterTableLock(t *testing.T) {
+	tests := map[string]string{
+		"ADD COLUMN x int":                                          lockAccessExclusive,
+		"ALTER COLUMN x TYPE bigint":                                lockAccessExclusive,
+		"VALIDATE CONSTRAINT c":                                     lockShareUpdateExclusive,
+		"ALTER COLUMN x SET STATISTICS 500":                         lockShareUpdateExclusive,
+		"ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES b":            lockShareRowExclusive,
+		"SET (autovacuum_vacuum_scale_factor = 0.01)":               lockShareUpdateExclusive,
+		"DISABLE TRIGGER audit_trigger":                             lockShareRowExclusive,
+		"ADD CONSTRAINT chk CHECK (x > 0)":                          lockAccessExclusive,
+		"RENAME COLUMN old_name TO new_name":                        lockAccessExclusive,
+		"ADD CONSTRAINT fk2 FOREIGN KEY (a) REFERENCES b NOT VALID": lockShareRowExclusive,
+	}
+	for clause, want := range tests {
+		if got := alterTableLock(clause); got != want {
+			t.Errorf("alterTableLock(%q) = %q, want %q", clause, got, want)
+		}
+	}
+}
+
+func TestCheckMigration_Timeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		runner  []string
+		want    int
+		wantSev Severity
+		missing string
+	}{
+		{"no timeouts on long lock", "ALTER TABLE users ALTER COLUMN email SET NOT NULL", nil, 1, SeverityHigh, "lock_timeout, statement_timeout"},
+		{"no timeouts on brief lock", "ALTER TABLE users ADD COLUMN x int", nil, 1, SeverityMedium, "lock_timeout, statement_timeout"},
+		{"lock_timeout only", "SET lock_timeout = '5s'; ALTER TABLE users ADD COLUMN x int", nil, 1, SeverityLow, "statement_timeout"},
+		{"both set", "SET lock_timeout TO '5s'; SET statement_timeout = 60000; ALTER TABLE users ADD COLUMN x int", nil, 0, "", ""},
+		{"set after the DDL", "ALTER TABLE tags ADD COLUMN x int; SET lock_timeout = '5s'; SET statement_timeout = '1min'", nil, 1, SeverityMedium, "lock_timeout, statement_timeout"},
+		{"disabled with zero", "SET lock_timeout = 0; SET statement_timeout = '0ms'; ALTER TABLE tags ADD COLUMN x int", nil, 1, SeverityMedium, "lock_timeout, statement_timeout"},
+		{"set_config", "SELECT set_config('lock_timeout', '5s', false), set_config('statement_timeout', '1min', false); ALTER TABLE tags ADD COLUMN x int", nil, 0, "", ""},
+		{"SET LOCAL ends at commit", "BEGIN; SET LOCAL lock_timeout = '5s'; SET LOCAL statement_timeout = '1min'; COMMIT; ALTER TABLE tags ADD COLUMN x int", nil, 1, SeverityMedium, "lock_timeout, statement_timeout"},
+		{"reset", "SET lock_timeout = '5s'; RESET lock_timeout; ALTER TABLE tags ADD COLUMN x int", []string{"statement_timeout"}, 1, SeverityMedium, "lock_timeout"},
+		{"set by runner", "ALTER TABLE users ADD COLUMN x int", []string{"lock_timeout", "statement_timeout"}, 0, "", ""},
+		{"set by runner across commit", "BEGIN; ALTER TABLE users ADD COLUMN x int; COMMIT; ALTER TABLE users ADD COLUMN y int", []string{"lock_timeout", "statement_timeout"}, 0, "", ""},
+		{"new table needs no timeout", "CREATE TABLE fresh (id int); CREATE INDEX idx_fresh ON fresh (id)", nil, 0, "", ""},
+		{"concurrent index", "CREATE INDEX CONCURRENTLY idx_tags_name ON tags (name)", nil, 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultMigrationOptions()
+			opts.RunnerTimeouts = tt.runner
+			risky := findingsOfType(CheckMigration("m.sql", migration.Parse(tt.sql), migrationSnapshot(), opts), FindingRiskyMigration)
+			if len(risky) != tt.want {
+				t.Fatalf("RISKY_MIGRATION = %d, want %d: %+v", len(risky), tt.want, risky)
+			}
+			if tt.want == 0 {
+				return
+			}
+			if risky[0].Severity != tt.wantSev {
+				t.Errorf("severity = %s, want %s", risky[0].Severity, tt.wantSev)
+			}
+			if risky[0].Detail["missing"] != tt.missing {
+				t.Errorf("missing = %q, want %q", risky[0].Detail["missing"], tt.missing)
+			}
+		})
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	for v, want := range map[string]bool{
+		"0": true, "'0'": true, "'0s'": true, "0ms": true, "DEFAULT": true,
+		"'5s'": false, "5000": false, "'1min'": false, "'0.5s'": false,
+	} {
+		if got := timeoutDisabled(v); got != want {
+			t.Errorf("timeoutDisabled(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
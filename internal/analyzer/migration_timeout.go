@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+// Settings a migration should set before taking locks on existing tables.
+// Without lock_timeout a blocked ALTER waits in the lock queue and every
+// later query on the table queues behind it.
+const (
+	settingLockTimeout      = "lock_timeout"
+	settingStatementTimeout = "statement_timeout"
+)
+
+var (
+	setTimeoutRe       = regexp.MustCompile(`(?i)^SET\s+(SESSION\s+|LOCAL\s+)?(lock_timeout|statement_timeout)\s*(?:=|\sTO\s)\s*(.+?)$`)
+	resetTimeoutRe     = regexp.MustCompile(`(?i)^RESET\s+(lock_timeout|statement_timeout|ALL)$`)
+	setConfigTimeoutRe = regexp.MustCompile(`(?i)\bset_config\s*\(\s*'(lock_timeout|statement_timeout)'\s*,\s*('[^']*'|[^,]+?)\s*,\s*(true|false)\s*\)`)
+	txEndRe            = regexp.MustCompile(`(?i)^(?:COMMIT|END|ROLLBACK|ABORT)\b`)
+)
+
+// applySetting tracks SET, RESET, and set_config of the timeout settings.
+// SET LOCAL lasts until the end of the transaction.
+func (st *migrationState) applySetting(s migration.Statement) {
+	sql := strings.TrimSuffix(strings.TrimSpace(s.SQL), ";")
+	switch {
+	case txEndRe.MatchString(sql):
+		for name, local := range st.timeouts {
+			if local {
+				delete(st.timeouts, name)
+			}
+		}
+	case resetTimeoutRe.MatchString(sql):
+		name := strings.ToLower(resetTimeoutRe.FindStringSubmatch(sql)[1])
+		if name == "all" {
+			clear(st.timeouts)
+		} else {
+			delete(st.timeouts, name)
+		}
+	case setTimeoutRe.MatchString(sql):
+		m := setTimeoutRe.FindStringSubmatch(sql)
+		st.setTimeout(strings.ToLower(m[2]), m[3], strings.EqualFold(strings.TrimSpace(m[1]), "LOCAL"))
+	default:
+		for _, m := range setConfigTimeoutRe.FindAllStringSubmatch(sql, -1) {
+			st.setTimeout(strings.ToLower(m[1]), m[2], strings.EqualFold(m[3], "true"))
+		}
+	}
+}
+
+func (st *migrationState) setTimeout(name, value string, local bool) {
+	if timeoutDisabled(value) {
+		delete(st.timeouts, name)
+		return
+	}
+	st.timeouts[name] = local
+}
+
+// timeoutDisabled reports whether a timeout value turns the timeout off:
+// zero in any unit, or DEFAULT (which is off unless the server sets one).
+func timeoutDisabled(value string) bool {
+	v := strings.ToLower(strings.Trim(strings.TrimSpace(value), `'"`))
+	if v == "default" {
+		return true
+	}
+	num := strings.TrimRight(v, "abcdefghijklmnopqrstuvwxyz ")
+	n, err := strconv.ParseFloat(num, 64)
+	return err == nil && n == 0
+}
+
+// timeoutFinding reports a statement that locks an existing table while
+// lock_timeout or statement_timeout is unset.
+func (st *migrationState) timeoutFinding(stmtFindings []Finding, detail func() map[string]string) (Finding, bool) {
+	var lockF *Finding
+	for i := range stmtFindings {
+		switch stmtFindings[i].Type {
+		case FindingMigrationLock, FindingMigrationBlockingIndex, FindingDDLTableRewrite, FindingDDLFullScan:
+			if lockF == nil || severityOrder[stmtFindings[i].Severity] > severityOrder[lockF.Severity] {
+				lockF = &stmtFindings[i]
+			}
+		}
+	}
+	if lockF == nil {
+		return Finding{}, false
+	}
+
+	var missing []string
+	for _, name := range []string{settingLockTimeout, settingStatementTimeout} {
+		if _, ok := st.timeouts[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return Finding{}, false
+	}
+
+	sev := SeverityLow
+	if missing[0] == settingLockTimeout {
+		sev = SeverityMedium
+		if lockF.Severity == SeverityHigh {
+			sev = SeverityHigh
+		}
+	}
+	d := detail()
+	d["lock"] = lockF.Detail["lock"]
+	d["missing"] = strings.Join(missing, ", ")
+	return Finding{
+		Type:     FindingRiskyMigration,
+		Severity: sev,
+		Schema:   lockF.Schema,
+		Table:    lockF.Table,
+		Index:    lockF.Index,
+		Message:  fmt.Sprintf("takes %s lock on %q without %s set", lockF.Detail["lock"], lockF.Table, strings.Join(missing, " or ")),
+		Detail:   d,
+	}, true
+}
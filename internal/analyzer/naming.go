@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// maxIdentifierBytes is NAMEDATALEN-1: PostgreSQL silently truncates longer
+// identifiers, so a name at the limit was most likely cut short.
+const maxIdentifierBytes = 63
+
+// reservedKeywords are PostgreSQL's reserved key words, including those
+// that may only be function or type names. None of them can name a table or
+// column without quoting.
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true,
+	"array": true, "as": true, "asc": true, "asymmetric": true, "authorization": true,
+	"binary": true, "both": true, "case": true, "cast": true, "check": true,
+	"collate": true, "collation": true, "column": true, "concurrently": true, "constraint": true,
+	"create": true, "cross": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_schema": true, "current_time": true, "current_timestamp": true, "current_user": true, "default": true,
+	"deferrable": true, "desc": true, "distinct": true, "do": true, "else": true,
+	"end": true, "except": true, "false": true, "fetch": true, "for": true,
+	"foreign": true, "freeze": true, "from": true, "full": true, "grant": true,
+	"group": true, "having": true, "ilike": true, "in": true, "initially": true,
+	"inner": true, "intersect": true, "into": true, "is": true, "isnull": true,
+	"join": true, "lateral": true, "leading": true, "left": true, "like": true,
+	"limit": true, "localtime": true, "localtimestamp": true, "natural": true, "not": true,
+	"notnull": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "outer": true, "overlaps": true, "placing": true,
+	"primary": true, "references": true, "returning": true, "right": true, "select": true,
+	"session_user": true, "similar": true, "some": true, "symmetric": true, "system_user": true,
+	"table": true, "tablesample": true, "then": true, "to": true, "trailing": true,
+	"true": true, "union": true, "unique": true, "user": true, "using": true,
+	"variadic": true, "verbose": true, "when": true, "where": true, "window": true,
+	"with": true,
+}
+
+// identifierProblems lists why a name is awkward to use in SQL.
+func identifierProblems(name string) []string {
+	var problems []string
+	if reservedKeywords[name] {
+		problems = append(problems, "reserved_word")
+	} else if quoteIdent(name) != name {
+		problems = append(problems, "requires_quoting")
+	}
+	if len(name) >= maxIdentifierBytes {
+		problems = append(problems, "length_limit")
+	}
+	return problems
+}
+
+func identifierMessage(kind, name string, problems []string) string {
+	var parts []string
+	for _, p := range problems {
+		switch p {
+		case "reserved_word":
+			parts = append(parts, "is a reserved word")
+		case "requires_quoting":
+			parts = append(parts, "must be double-quoted in every query")
+		case "length_limit":
+			parts = append(parts, fmt.Sprintf("is at the %d-byte limit and may have been truncated", maxIdentifierBytes))
+		}
+	}
+	return fmt.Sprintf("%s name %s %s", kind, quoteIdent(name), strings.Join(parts, " and "))
+}
+
+// detectProblematicIdentifiers flags table and column names that are
+// reserved words, need quoting (uppercase, spaces, punctuation), or sit at
+// the identifier length limit. Each needs care from every client and tool.
+func detectProblematicIdentifiers(tables []postgres.TableInfo, columns []postgres.ColumnInfo) []Finding {
+	var findings []Finding
+	for _, t := range tables {
+		problems := identifierProblems(t.Name)
+		if len(problems) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingProblemIdentifier,
+			Severity: SeverityLow,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  identifierMessage("table", t.Name, problems),
+			Detail: map[string]string{
+				"problems": strings.Join(problems, ","),
+				"bytes":    strconv.Itoa(len(t.Name)),
+			},
+		})
+	}
+	for _, c := range columns {
+		problems := identifierProblems(c.Name)
+		if len(problems) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingProblemIdentifier,
+			Severity: SeverityLow,
+			Schema:   c.Schema,
+			Table:    c.Table,
+			Column:   c.Name,
+			Message:  identifierMessage("column", c.Name, problems),
+			Detail: map[string]string{
+				"problems": strings.Join(problems, ","),
+				"bytes":    strconv.Itoa(len(c.Name)),
+			},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectProblematicIdentifiers(t *testing.T) {
+	long := strings.Repeat("a", maxIdentifierBytes)
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "users"},
+		{Schema: "public", Name: "order"},
+		{Schema: "public", Name: "UserEvents"},
+		{Schema: "public", Name: long},
+	}
+	columns := []postgres.ColumnInfo{
+		{Schema: "public", Table: "users", Name: "id"},
+		{Schema: "public", Table: "users", Name: "user"},
+		{Schema: "public", Table: "users", Name: "First Name"},
+		{Schema: "public", Table: "users", Name: "status_2"},
+	}
+
+	findings := detectProblematicIdentifiers(tables, columns)
+	got := make(map[string]string)
+	for _, f := range findings {
+		if f.Type != FindingProblemIdentifier || f.Severity != SeverityLow {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+		name := f.Table
+		if f.Column != "" {
+			name += "." + f.Column
+		}
+		got[name] = f.Detail["problems"]
+	}
+
+	want := map[string]string{
+		"order":            "reserved_word",
+		"UserEvents":       "requires_quoting",
+		long:               "length_limit",
+		"users.user":       "reserved_word",
+		"users.First Name": "requires_quoting",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for name, problems := range want {
+		if got[name] != problems {
+			t.Errorf("%s problems = %q, want %q", name, got[name], problems)
+		}
+	}
+}
+
+func TestIdentifierMessage(t *testing.T) {
+	msg := identifierMessage("column", "user", []string{"reserved_word"})
+	if msg != `column name "user" is a reserved word` {
+		t.Errorf("message = %q", msg)
+	}
+}
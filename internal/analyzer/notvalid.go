@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+var constraintKinds = map[string]string{
+	"c": "check",
+	"f": "foreign key",
+}
+
+// detectNotValidatedConstraints flags CHECK and foreign key constraints
+// added NOT VALID and never validated: new rows are checked, but existing
+// rows may violate them. Constraints in skip (keyed by schema.table.name)
+// are already reported as an unfinished backfill.
+func detectNotValidatedConstraints(constraints []postgres.ConstraintInfo, skip map[string]bool) []Finding {
+	var findings []Finding
+	for _, c := range constraints {
+		kind, ok := constraintKinds[c.Type]
+		if !ok || c.Validated || skip[columnKey(c.Schema, c.Table, c.Name)] {
+			continue
+		}
+		f := Finding{
+			Type:     FindingNotValidConstraint,
+			Severity: SeverityMedium,
+			Schema:   c.Schema,
+			Table:    c.Table,
+			Message:  fmt.Sprintf("%s constraint %q was added NOT VALID and never validated; existing rows are not checked", kind, c.Name),
+			Detail: map[string]string{
+				"constraint":      c.Name,
+				"constraint_type": kind,
+				"definition":      c.Definition,
+				"suggestion":      fmt.Sprintf("ALTER TABLE %s.%s VALIDATE CONSTRAINT %s;", quoteIdent(c.Schema), quoteIdent(c.Table), quoteIdent(c.Name)),
+			},
+		}
+		if len(c.Columns) == 1 {
+			f.Column = c.Columns[0]
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
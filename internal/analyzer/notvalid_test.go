@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectNotValidatedConstraints(t *testing.T) {
+	constraints := []postgres.ConstraintInfo{
+		{Schema: "public", Table: "orders", Name: "orders_user_fk", Type: "f", Columns: []string{"user_id"}, Definition: "FOREIGN KEY (user_id) REFERENCES users(id) NOT VALID"},
+		{Schema: "public", Table: "orders", Name: "orders_total_pos", Type: "c", Columns: []string{"total"}, Definition: "CHECK ((total > 0)) NOT VALID"},
+		{Schema: "public", Table: "orders", Name: "orders_range", Type: "c", Columns: []string{"a", "b"}, Definition: "CHECK ((a < b)) NOT VALID"},
+		{Schema: "public", Table: "orders", Name: "orders_qty_pos", Type: "c", Definition: "CHECK ((qty > 0))", Validated: true},
+		{Schema: "public", Table: "orders", Name: "orders_pkey", Type: "p"},
+		{Schema: "public", Table: "users", Name: "users_email_nn", Type: "c", Definition: "CHECK ((email IS NOT NULL)) NOT VALID"},
+	}
+	skip := map[string]bool{"public.users.users_email_nn": true}
+
+	findings := detectNotValidatedConstraints(constraints, skip)
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingNotValidConstraint || f.Column != "user_id" || f.Detail["constraint_type"] != "foreign key" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if want := "ALTER TABLE public.orders VALIDATE CONSTRAINT orders_user_fk;"; f.Detail["suggestion"] != want {
+		t.Errorf("suggestion = %q, want %q", f.Detail["suggestion"], want)
+	}
+	if findings[2].Column != "" {
+		t.Errorf("multi-column check should have no column: %+v", findings[2])
+	}
+}
+
+func TestAudit_NotValidatedSkipsIncompleteBackfill(t *testing.T) {
+	snap := &postgres.Snapshot{
+		Tables:  []postgres.TableInfo{{Schema: "public", Name: "users"}},
+		Columns: []postgres.ColumnInfo{{Schema: "public", Table: "users", Name: "email", IsNullable: true}},
+		Constraints: []postgres.ConstraintInfo{
+			{Schema: "public", Table: "users", Name: "users_email_nn", Type: "c", Definition: "CHECK ((email IS NOT NULL)) NOT VALID"},
+		},
+		ColumnStats: []postgres.ColumnStats{{Schema: "public", Table: "users", Column: "email", NullFrac: 0.3}},
+	}
+	count := func() (backfill, notValid int) {
+		for _, f := range Audit(snap, DefaultAuditOptions()) {
+			switch f.Type {
+			case FindingIncompleteBackfill:
+				backfill++
+			case FindingNotValidConstraint:
+				notValid++
+			}
+		}
+		return
+	}
+	if b, n := count(); b != 1 || n != 0 {
+		t.Errorf("with NULLs: INCOMPLETE_BACKFILL=%d NOT_VALIDATED_CONSTRAINT=%d, want 1 and 0", b, n)
+	}
+
+	// Once backfilled, the remaining step is validating the constraint.
+	snap.ColumnStats[0].NullFrac = 0
+	if b, n := count(); b != 0 || n != 1 {
+		t.Errorf("backfilled: INCOMPLETE_BACKFILL=%d NOT_VALIDATED_CONSTRAINT=%d, want 0 and 1", b, n)
+	}
+}
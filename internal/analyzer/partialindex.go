@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// splitIndexPredicate splits a normalized index definition into its key
+// part (table, access method, columns) and its WHERE predicate, if any.
+func splitIndexPredicate(def string) (key, predicate string) {
+	normalized := normalizeDef(def)
+	depth := 0
+	inQuote := false
+	upper := strings.ToUpper(normalized)
+	for i := 0; i < len(normalized); i++ {
+		switch c := normalized[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], " WHERE "):
+			return normalized[:i], strings.TrimSpace(normalized[i+len(" WHERE "):])
+		}
+	}
+	return normalized, ""
+}
+
+// stripOuterParens removes parentheses that enclose the whole expression.
+func stripOuterParens(s string) string {
+	for len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		depth := 0
+		inQuote := false
+		enclosed := true
+		for i := 0; i < len(s)-1; i++ {
+			switch {
+			case s[i] == '\'':
+				inQuote = !inQuote
+			case inQuote:
+			case s[i] == '(':
+				depth++
+			case s[i] == ')':
+				depth--
+			}
+			if depth == 0 {
+				enclosed = false
+				break
+			}
+		}
+		if !enclosed {
+			break
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// predicateConjuncts splits a predicate on its top-level ANDs. Each
+// conjunct is compared as text, which works because pg_get_indexdef prints
+// predicates in a canonical form.
+func predicateConjuncts(predicate string) map[string]bool {
+	conjuncts := make(map[string]bool)
+	predicate = stripOuterParens(predicate)
+	if predicate == "" {
+		return conjuncts
+	}
+	upper := strings.ToUpper(predicate)
+	depth, start := 0, 0
+	inQuote := false
+	for i := 0; i < len(predicate); i++ {
+		switch c := predicate[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], " AND "):
+			conjuncts[strings.ToLower(stripOuterParens(strings.TrimSpace(predicate[start:i])))] = true
+			start = i + len(" AND ")
+		}
+	}
+	conjuncts[strings.ToLower(stripOuterParens(strings.TrimSpace(predicate[start:])))] = true
+	return conjuncts
+}
+
+// predicateImplies reports whether every row matching a also matches b,
+// judged by b's conjuncts all appearing in a. An empty b matches all rows.
+func predicateImplies(a, b map[string]bool) bool {
+	for c := range b {
+		if !a[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// partialIndexOverlap reports whether partial index a is made redundant by
+// b: both index the same columns the same way and b's predicate covers
+// every row a's does. A unique index is only covered by a unique one.
+func partialIndexOverlap(a, b postgres.IndexInfo) (reason string, ok bool) {
+	keyA, predA := splitIndexPredicate(a.Definition)
+	keyB, predB := splitIndexPredicate(b.Definition)
+	if predA == "" || keyA != keyB {
+		return "", false
+	}
+	if isUniqueIndex(a.Definition) && !isUniqueIndex(b.Definition) {
+		return "", false
+	}
+	condA, condB := predicateConjuncts(predA), predicateConjuncts(predB)
+	if !predicateImplies(condA, condB) {
+		return "", false
+	}
+	switch {
+	case predB == "":
+		return fmt.Sprintf("partial index %q is covered by full index %q on the same columns", a.Name, b.Name), true
+	case predicateImplies(condB, condA):
+		return fmt.Sprintf("partial index %q has the same columns and an equivalent predicate as %q", a.Name, b.Name), true
+	default:
+		return fmt.Sprintf("partial index %q is covered by %q, whose predicate includes all its rows", a.Name, b.Name), true
+	}
+}
+
+func isUniqueIndex(def string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(def)), "CREATE UNIQUE ")
+}
+
+// detectOverlappingPartialIndexes flags partial indexes made redundant by
+// another index on the same table. Text-identical definitions are left to
+// the exact duplicate check.
+func detectOverlappingPartialIndexes(group []postgres.IndexInfo) []Finding {
+	var findings []Finding
+	for i, a := range group {
+		for j, b := range group {
+			if i == j || normalizeDef(a.Definition) == normalizeDef(b.Definition) {
+				continue
+			}
+			reason, ok := partialIndexOverlap(a, b)
+			if !ok {
+				continue
+			}
+			// Of two equivalent indexes, report only the later one.
+			if _, mutual := partialIndexOverlap(b, a); mutual && i < j {
+				continue
+			}
+			_, predA := splitIndexPredicate(a.Definition)
+			_, predB := splitIndexPredicate(b.Definition)
+			findings = append(findings, Finding{
+				Type:     FindingDuplicateIndex,
+				Severity: SeverityLow,
+				Schema:   a.Schema,
+				Table:    a.Table,
+				Index:    a.Name,
+				Message:  reason,
+				Detail: map[string]string{
+					"covered_by":         b.Name,
+					"predicate":          predA,
+					"covering_predicate": predB,
+					"size":               formatBytes(a.SizeBytes),
+				},
+			})
+			break
+		}
+	}
+	return findings
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestSplitIndexPredicate(t *testing.T) {
+	key, pred := splitIndexPredicate("CREATE INDEX a ON public.t USING btree (lower((email)::text)) WHERE ((deleted_at IS NULL) AND (note <> ' where '::text))")
+	if key != " ON public.t USING btree (lower((email)::text))" {
+		t.Errorf("key = %q", key)
+	}
+	if pred != "((deleted_at IS NULL) AND (note <> ' where '::text))" {
+		t.Errorf("predicate = %q", pred)
+	}
+	if _, pred := splitIndexPredicate("CREATE INDEX b ON public.t USING btree (email)"); pred != "" {
+		t.Errorf("full index predicate = %q", pred)
+	}
+}
+
+func TestPredicateConjuncts(t *testing.T) {
+	got := predicateConjuncts("((deleted_at IS NULL) AND ((status = 'a'::text) OR (status = 'b AND c'::text)))")
+	if len(got) != 2 || !got["deleted_at is null"] || !got["(status = 'a'::text) or (status = 'b and c'::text)"] {
+		t.Errorf("conjuncts = %v", got)
+	}
+}
+
+func TestDetectDuplicateIndexes_PartialOverlap(t *testing.T) {
+	const on = " ON public.orders USING btree (customer_id)"
+	indexes := []postgres.IndexInfo{
+		makeIndex("public", "orders", "orders_customer_open", "CREATE INDEX orders_customer_open"+on+" WHERE ((status = 'open'::text) AND (deleted_at IS NULL))", 8192, 0),
+		makeIndex("public", "orders", "orders_customer_live", "CREATE INDEX orders_customer_live"+on+" WHERE (deleted_at IS NULL)", 8192, 0),
+		makeIndex("public", "orders", "orders_live_customer", "CREATE INDEX orders_live_customer"+on+" WHERE ((deleted_at IS NULL))", 8192, 0),
+		// Unique partial indexes enforce a constraint and aren't covered by non-unique ones.
+		makeIndex("public", "orders", "orders_one_open", "CREATE UNIQUE INDEX orders_one_open"+on+" WHERE (status = 'open'::text)", 8192, 0),
+		// Different columns.
+		makeIndex("public", "orders", "orders_created_live", "CREATE INDEX orders_created_live ON public.orders USING btree (created_at) WHERE (deleted_at IS NULL)", 8192, 0),
+	}
+
+	findings := detectDuplicateIndexes(indexes)
+	got := make(map[string]string)
+	for _, f := range findings {
+		got[f.Index] = f.Detail["covered_by"]
+	}
+	want := map[string]string{
+		"orders_customer_open": "orders_customer_live",
+		"orders_live_customer": "orders_customer_live", // equivalent predicate, reported once
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for idx, by := range want {
+		if got[idx] != by {
+			t.Errorf("%s covered_by = %q, want %q", idx, got[idx], by)
+		}
+	}
+
+	full := makeIndex("public", "orders", "orders_customer", "CREATE INDEX orders_customer"+on, 8192, 0)
+	findings = detectDuplicateIndexes([]postgres.IndexInfo{indexes[1], full})
+	if len(findings) != 1 || findings[0].Index != "orders_customer_live" || findings[0].Detail["covering_predicate"] != "" {
+		t.Errorf("full index coverage: %+v", findings)
+	}
+}
@@ -0,0 +1,292 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// defaultPartitionMinWrites is the number of rows written to a partitioned
+// table before the share landing in its default partition is judged.
+const defaultPartitionMinWrites = 1000
+
+// partitionTree indexes the declarative partition hierarchy of a snapshot.
+// Keys are tableKey(schema, name).
+type partitionTree struct {
+	parent      map[string]string
+	children    map[string][]postgres.PartitionInfo
+	partitioned map[string]bool // tables that hold no rows themselves
+}
+
+func newPartitionTree(partitioned []postgres.PartitionedTableInfo, partitions []postgres.PartitionInfo) partitionTree {
+	tree := partitionTree{
+		parent:      make(map[string]string, len(partitions)),
+		children:    make(map[string][]postgres.PartitionInfo),
+		partitioned: make(map[string]bool, len(partitioned)),
+	}
+	for _, pt := range partitioned {
+		tree.partitioned[tableKey(pt.Schema, pt.Name)] = true
+	}
+	for _, p := range partitions {
+		parentKey := tableKey(p.ParentSchema, p.Parent)
+		tree.parent[tableKey(p.Schema, p.Name)] = parentKey
+		tree.children[parentKey] = append(tree.children[parentKey], p)
+		if p.IsPartitioned {
+			tree.partitioned[tableKey(p.Schema, p.Name)] = true
+		}
+	}
+	return tree
+}
+
+// isPartition reports whether key is a partition of another table.
+func (t partitionTree) isPartition(key string) bool {
+	_, ok := t.parent[key]
+	return ok
+}
+
+// leaves returns the partitions under key that hold rows, descending into
+// sub-partitioned ones. A table that isn't partitioned is its own leaf.
+func (t partitionTree) leaves(key string) []string {
+	if !t.partitioned[key] {
+		return []string{key}
+	}
+	var out []string
+	for _, c := range t.children[key] {
+		out = append(out, t.leaves(tableKey(c.Schema, c.Name))...)
+	}
+	return out
+}
+
+// leafStats sums scan and write counters over the leaves of key. ok is false
+// when none of the leaves has statistics.
+func (t partitionTree) leafStats(key string, stats map[string]postgres.TableStats) (sum postgres.TableStats, ok bool) {
+	for _, leaf := range t.leaves(key) {
+		s, found := stats[leaf]
+		if !found {
+			continue
+		}
+		ok = true
+		sum.SeqScan += s.SeqScan
+		sum.IdxScan += s.IdxScan
+		sum.LiveTuples += s.LiveTuples
+		sum.DeadTuples += s.DeadTuples
+		sum.TupInserted += s.TupInserted
+		sum.TupUpdated += s.TupUpdated
+		sum.TupHotUpdated += s.TupHotUpdated
+		sum.HeapBlksRead += s.HeapBlksRead
+		sum.HeapBlksHit += s.HeapBlksHit
+		sum.IdxBlksRead += s.IdxBlksRead
+		sum.IdxBlksHit += s.IdxBlksHit
+		sum.TupDeleted += s.TupDeleted
+	}
+	return sum, ok
+}
+
+func statsByKey(stats []postgres.TableStats) map[string]postgres.TableStats {
+	m := make(map[string]postgres.TableStats, len(stats))
+	for i := range stats {
+		m[tableKey(stats[i].Schema, stats[i].Name)] = stats[i]
+	}
+	return m
+}
+
+func rowsWritten(s postgres.TableStats) int64 {
+	return s.TupInserted + s.TupUpdated + s.TupDeleted
+}
+
+// detectUnusedPartitionedTables flags top-level partitioned tables none of
+// whose partitions has been scanned. Individual partitions are not judged
+// on their own: old or future ranges are expected to sit idle.
+func detectUnusedPartitionedTables(partitioned []postgres.PartitionedTableInfo, tree partitionTree, stats map[string]postgres.TableStats) []Finding {
+	var findings []Finding
+	for _, pt := range partitioned {
+		key := tableKey(pt.Schema, pt.Name)
+		if tree.isPartition(key) {
+			continue
+		}
+		sum, ok := tree.leafStats(key, stats)
+		if !ok || sum.SeqScan > 0 || sum.IdxScan > 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUnusedTable,
+			Severity: SeverityHigh,
+			Schema:   pt.Schema,
+			Table:    pt.Name,
+			Message:  "partitioned table has no sequential or index scans on any partition",
+			Detail: map[string]string{
+				"partitions":  strconv.Itoa(len(tree.leaves(key))),
+				"live_tuples": strconv.FormatInt(sum.LiveTuples, 10),
+				"dead_tuples": strconv.FormatInt(sum.DeadTuples, 10),
+			},
+		})
+	}
+	return findings
+}
+
+// detectHotDefaultPartitions flags partitioned tables whose default
+// partition receives most writes. Rows land there when no partition covers
+// them, usually because creating new range partitions stopped, and every
+// partition attached later has to scan the default under lock.
+func detectHotDefaultPartitions(partitioned []postgres.PartitionedTableInfo, tree partitionTree, stats map[string]postgres.TableStats) []Finding {
+	var findings []Finding
+	for _, pt := range partitioned {
+		key := tableKey(pt.Schema, pt.Name)
+		var def *postgres.PartitionInfo
+		var total int64
+		for i, c := range tree.children[key] {
+			sum, _ := tree.leafStats(tableKey(c.Schema, c.Name), stats)
+			total += rowsWritten(sum)
+			if c.IsDefault {
+				def = &tree.children[key][i]
+			}
+		}
+		if def == nil || total < defaultPartitionMinWrites {
+			continue
+		}
+		defSum, _ := tree.leafStats(tableKey(def.Schema, def.Name), stats)
+		written := rowsWritten(defSum)
+		if written*2 <= total {
+			continue
+		}
+		pct := float64(written) / float64(total) * 100
+		findings = append(findings, Finding{
+			Type:     FindingHotDefaultPartition,
+			Severity: SeverityMedium,
+			Schema:   pt.Schema,
+			Table:    pt.Name,
+			Message:  fmt.Sprintf("default partition %q receives %.0f%% of writes; create partitions covering these rows", def.Name, pct),
+			Detail: map[string]string{
+				"default_partition": def.Name,
+				"partition_key":     pt.KeyDefinition,
+				"rows_written":      strconv.FormatInt(written, 10),
+				"total_written":     strconv.FormatInt(total, 10),
+				"write_pct":         fmt.Sprintf("%.1f", pct),
+			},
+		})
+	}
+	return findings
+}
+
+// detectMissingPartitionKeyIndexes flags partitioned tables with no index
+// whose leading column is the first partition key column. Pruning picks the
+// partitions, but range queries within a partition still scan all of it.
+func detectMissingPartitionKeyIndexes(partitioned []postgres.PartitionedTableInfo, indexes []postgres.IndexInfo) []Finding {
+	leading := make(map[string]bool)
+	for _, idx := range indexes {
+		if cols := parseIndexColumns(idx.Definition); len(cols) > 0 {
+			leading[tableKey(idx.Schema, idx.Table)+"."+strings.ToLower(strings.Trim(cols[0], `"`))] = true
+		}
+	}
+
+	var findings []Finding
+	for _, pt := range partitioned {
+		if len(pt.KeyColumns) == 0 {
+			continue // expression keys can't be matched against index columns
+		}
+		col := pt.KeyColumns[0]
+		if leading[tableKey(pt.Schema, pt.Name)+"."+strings.ToLower(col)] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingPartitionKeyIndex,
+			Severity: SeverityLow,
+			Schema:   pt.Schema,
+			Table:    pt.Name,
+			Column:   col,
+			Message:  fmt.Sprintf("partitioned table has no index leading with partition key column %q", col),
+			Detail: map[string]string{
+				"partition_key": pt.KeyDefinition,
+			},
+		})
+	}
+	return findings
+}
+
+// indexShape strips the index and table names from an index definition,
+// leaving the access method, keys, and predicate, so the same index on two
+// partitions compares equal.
+func indexShape(def string) string {
+	normalized := strings.Join(strings.Fields(def), " ")
+	if i := strings.Index(strings.ToUpper(normalized), " USING "); i >= 0 {
+		prefix := ""
+		if strings.HasPrefix(strings.ToUpper(normalized), "CREATE UNIQUE ") {
+			prefix = "UNIQUE "
+		}
+		return prefix + normalized[i+1:]
+	}
+	return normalizeDef(def)
+}
+
+// detectInconsistentPartitionIndexes flags partitions missing an index that
+// most of their siblings have, typically left by partitions created by hand
+// without the parent's indexes.
+func detectInconsistentPartitionIndexes(partitioned []postgres.PartitionedTableInfo, tree partitionTree, indexes []postgres.IndexInfo) []Finding {
+	shapes := make(map[string]map[string]string) // table key → shape → index name
+	for _, idx := range indexes {
+		key := tableKey(idx.Schema, idx.Table)
+		if !tree.isPartition(key) {
+			continue
+		}
+		if shapes[key] == nil {
+			shapes[key] = make(map[string]string)
+		}
+		shapes[key][indexShape(idx.Definition)] = idx.Name
+	}
+
+	var findings []Finding
+	for _, pt := range partitioned {
+		var siblings []postgres.PartitionInfo
+		for _, c := range tree.children[tableKey(pt.Schema, pt.Name)] {
+			if !c.IsPartitioned {
+				siblings = append(siblings, c)
+			}
+		}
+		if len(siblings) < 2 {
+			continue
+		}
+
+		count := make(map[string]int)
+		example := make(map[string]string)
+		for _, c := range siblings {
+			for shape, name := range shapes[tableKey(c.Schema, c.Name)] {
+				count[shape]++
+				if example[shape] == "" {
+					example[shape] = name
+				}
+			}
+		}
+		for _, c := range siblings {
+			have := shapes[tableKey(c.Schema, c.Name)]
+			var missing, like []string
+			for shape, n := range count {
+				if n*2 > len(siblings) && have[shape] == "" {
+					missing = append(missing, shape)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			sort.Strings(missing)
+			for _, shape := range missing {
+				like = append(like, example[shape])
+			}
+			findings = append(findings, Finding{
+				Type:     FindingPartitionIndexGap,
+				Severity: SeverityMedium,
+				Schema:   c.Schema,
+				Table:    c.Name,
+				Message:  fmt.Sprintf("partition of %q lacks %d index(es) its siblings have", pt.Name, len(missing)),
+				Detail: map[string]string{
+					"parent":          pt.Name,
+					"missing_indexes": strings.Join(missing, "; "),
+					"sibling_indexes": strings.Join(like, ", "),
+				},
+			})
+		}
+	}
+	return findings
+}
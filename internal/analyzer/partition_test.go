@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// partitionedSnapshot has events partitioned by month and metrics
+// sub-partitioned by region, then by day.
+func partitionedSnapshot() *postgres.Snapshot {
+	return &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "events"},
+			{Schema: "public", Name: "events_2024_01"},
+			{Schema: "public", Name: "events_2024_02"},
+			{Schema: "public", Name: "events_default"},
+			{Schema: "public", Name: "metrics"},
+			{Schema: "public", Name: "metrics_eu"},
+			{Schema: "public", Name: "metrics_eu_d1"},
+		},
+		PartitionedTables: []postgres.PartitionedTableInfo{
+			{Schema: "public", Name: "events", KeyDefinition: "RANGE (created_at)", KeyColumns: []string{"created_at"}},
+			{Schema: "public", Name: "metrics", KeyDefinition: "LIST (region)", KeyColumns: []string{"region"}},
+			{Schema: "public", Name: "metrics_eu", KeyDefinition: "RANGE ((ts::date))"},
+		},
+		Partitions: []postgres.PartitionInfo{
+			{Schema: "public", Name: "events_2024_01", ParentSchema: "public", Parent: "events"},
+			{Schema: "public", Name: "events_2024_02", ParentSchema: "public", Parent: "events"},
+			{Schema: "public", Name: "events_default", ParentSchema: "public", Parent: "events", Bound: postgres.PartitionBoundDefault, IsDefault: true},
+			{Schema: "public", Name: "metrics_eu", ParentSchema: "public", Parent: "metrics", IsPartitioned: true},
+			{Schema: "public", Name: "metrics_eu_d1", ParentSchema: "public", Parent: "metrics_eu"},
+		},
+		Stats: []postgres.TableStats{
+			{Schema: "public", Name: "events"},
+			{Schema: "public", Name: "events_2024_01", SeqScan: 5, TupInserted: 100},
+			{Schema: "public", Name: "events_2024_02"},
+			{Schema: "public", Name: "events_default", IdxScan: 3, TupInserted: 5000, TupUpdated: 100},
+			{Schema: "public", Name: "metrics"},
+			{Schema: "public", Name: "metrics_eu"},
+			{Schema: "public", Name: "metrics_eu_d1", LiveTuples: 10},
+		},
+		Indexes: []postgres.IndexInfo{
+			makeIndex("public", "events", "events_created_idx", "CREATE INDEX events_created_idx ON ONLY public.events USING btree (created_at, id)", 0, 0),
+		},
+		Constraints: []postgres.ConstraintInfo{
+			makeConstraint("public", "events", "events_pkey", "p"),
+			makeConstraint("public", "events_2024_01", "events_2024_01_pkey", "p"),
+			makeConstraint("public", "events_2024_02", "events_2024_02_pkey", "p"),
+			makeConstraint("public", "events_default", "events_default_pkey", "p"),
+		},
+	}
+}
+
+func TestPartitionTree(t *testing.T) {
+	snap := partitionedSnapshot()
+	tree := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+
+	if !tree.isPartition("public.metrics_eu_d1") || tree.isPartition("public.metrics") {
+		t.Error("isPartition")
+	}
+	if got := tree.leaves("public.metrics"); len(got) != 1 || got[0] != "public.metrics_eu_d1" {
+		t.Errorf("leaves(metrics) = %v", got)
+	}
+	sum, ok := tree.leafStats("public.events", statsByKey(snap.Stats))
+	if !ok || sum.SeqScan != 5 || sum.IdxScan != 3 || rowsWritten(sum) != 5200 {
+		t.Errorf("leafStats(events) = %+v, %v", sum, ok)
+	}
+}
+
+func TestDetectUnusedPartitionedTables(t *testing.T) {
+	snap := partitionedSnapshot()
+	tree := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+
+	findings := detectUnusedPartitionedTables(snap.PartitionedTables, tree, statsByKey(snap.Stats))
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Table != "metrics" || f.Type != FindingUnusedTable || f.Detail["partitions"] != "1" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestDetectHotDefaultPartitions(t *testing.T) {
+	snap := partitionedSnapshot()
+	tree := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+
+	findings := detectHotDefaultPartitions(snap.PartitionedTables, tree, statsByKey(snap.Stats))
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Table != "events" || f.Severity != SeverityMedium || f.Detail["default_partition"] != "events_default" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["rows_written"] != "5100" || f.Detail["total_written"] != "5200" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+
+	// Too few writes to judge.
+	snap.Stats[3].TupInserted, snap.Stats[3].TupUpdated = 500, 0
+	if got := detectHotDefaultPartitions(snap.PartitionedTables, tree, statsByKey(snap.Stats)); len(got) != 0 {
+		t.Errorf("below min writes: got %+v", got)
+	}
+}
+
+func TestDetectMissingPartitionKeyIndexes(t *testing.T) {
+	snap := partitionedSnapshot()
+
+	findings := detectMissingPartitionKeyIndexes(snap.PartitionedTables, snap.Indexes)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Table != "metrics" || f.Column != "region" || f.Detail["partition_key"] != "LIST (region)" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestAudit_PartitionAware(t *testing.T) {
+	findings := Audit(partitionedSnapshot(), DefaultAuditOptions())
+
+	byType := make(map[FindingType][]string)
+	for _, f := range findings {
+		byType[f.Type] = append(byType[f.Type], f.Table)
+	}
+	if got := byType[FindingUnusedTable]; len(got) != 1 || got[0] != "metrics" {
+		t.Errorf("UNUSED_TABLE on %v, want only metrics", got)
+	}
+	if got := byType[FindingNoPrimaryKey]; len(got) != 1 || got[0] != "metrics" {
+		t.Errorf("NO_PRIMARY_KEY on %v, want only metrics", got)
+	}
+	if got := byType[FindingHotDefaultPartition]; len(got) != 1 || got[0] != "events" {
+		t.Errorf("HOT_DEFAULT_PARTITION on %v", got)
+	}
+	if got := byType[FindingMissingVacuum]; len(got) != 2 {
+		t.Errorf("MISSING_VACUUM on %v, want the two scanned partitions", got)
+	}
+}
+
+func TestDiff_PartitionsNotUnreferenced(t *testing.T) {
+	scan := &scanner.ScanResult{Tables: []string{"events"}}
+	findings := Diff(scan, partitionedSnapshot(), DefaultAuditOptions())
+
+	var unreferenced []string
+	for _, f := range findings {
+		if f.Type == FindingUnreferencedTable {
+			unreferenced = append(unreferenced, f.Table)
+		}
+	}
+	if len(unreferenced) != 1 || unreferenced[0] != "metrics" {
+		t.Errorf("UNREFERENCED_TABLE on %v, want only metrics", unreferenced)
+	}
+}
+
+func TestIndexShape(t *testing.T) {
+	a := indexShape("CREATE INDEX events_2024_01_created_at_idx ON public.events_2024_01 USING btree (created_at)")
+	b := indexShape("CREATE INDEX  manual_idx ON public.events_2024_02 USING btree (created_at)")
+	if a != b || a != "USING btree (created_at)" {
+		t.Errorf("shapes differ: %q vs %q", a, b)
+	}
+	if u := indexShape("CREATE UNIQUE INDEX x ON t USING btree (created_at)"); u == a {
+		t.Error("unique index should have a different shape")
+	}
+}
+
+func TestDetectInconsistentPartitionIndexes(t *testing.T) {
+	snap := partitionedSnapshot()
+	tree := newPartitionTree(snap.PartitionedTables, snap.Partitions)
+	indexes := []postgres.IndexInfo{
+		makeIndex("public", "events_2024_01", "events_2024_01_created_at_idx", "CREATE INDEX events_2024_01_created_at_idx ON public.events_2024_01 USING btree (created_at)", 0, 0),
+		makeIndex("public", "events_default", "events_default_created_at_idx", "CREATE INDEX events_default_created_at_idx ON public.events_default USING btree (created_at)", 0, 0),
+		// Only on one partition: not a sibling majority.
+		makeIndex("public", "events_default", "events_default_id_idx", "CREATE INDEX events_default_id_idx ON public.events_default USING btree (id)", 0, 0),
+	}
+
+	findings := detectInconsistentPartitionIndexes(snap.PartitionedTables, tree, indexes)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingPartitionIndexGap || f.Table != "events_2024_02" || f.Detail["parent"] != "events" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["missing_indexes"] != "USING btree (created_at)" {
+		t.Errorf("missing_indexes = %q", f.Detail["missing_indexes"])
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// optInChecks are finding types whose detectors only run when explicitly
+// selected through AuditOptions.Checks (usually via a profile).
+var optInChecks = map[FindingType]bool{
+	FindingSensitiveColumn:  true,
+	FindingRiskyExtension:   true,
+	FindingPublicGrant:      true,
+	FindingSuperuserAppRole: true,
+	FindingAuditTableWrite:  true,
+	FindingSelectStar:       true,
+}
+
+// profiles maps built-in profile names to the finding types they report.
+var profiles = map[string][]FindingType{
+	"security": {
+		FindingSensitiveColumn,
+		FindingRiskyExtension,
+		FindingPublicGrant,
+		FindingSuperuserAppRole,
+		FindingAuditTableWrite,
+		FindingMissingRLS,
+	},
+	// performance covers index advice, bloat, and statistics health;
+	// schema drift and code-reference checks are left out.
+	"performance": {
+		FindingUnusedIndex,
+		FindingBloatedIndex,
+		FindingDuplicateIndex,
+		FindingInvalidIndex,
+		FindingMissingVacuum,
+		FindingAutovacuumDisabled,
+		FindingLowHOTRatio,
+		FindingLowCacheHit,
+		FindingLowCacheHitRatio,
+		FindingQuerySpill,
+		FindingFrequentCheckpoints,
+		FindingBackendBufferWrites,
+		FindingToastBloat,
+		FindingOversizedRows,
+		FindingHighSeqScan,
+		FindingSeqScanQuery,
+		FindingLowSelectivityIndex,
+		FindingUnindexedQuery,
+		FindingMissingGINIndex,
+		FindingSuggestedIndex,
+	},
+}
+
+// ProfileChecks returns the finding types enabled by a built-in profile.
+func ProfileChecks(name string) ([]FindingType, error) {
+	checks, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return checks, nil
+}
+
+// ProfileNames returns the names of the built-in profiles, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckEnabled reports whether checks, as in AuditOptions.Checks, enables
+// the finding type ft.
+func CheckEnabled(checks []FindingType, ft FindingType) bool {
+	return newCheckSet(checks).enabled(ft)
+}
+
+// checkSet is the resolved set of enabled checks. A nil set means the
+// default behavior: every check except the opt-in ones.
+type checkSet map[FindingType]bool
+
+func newCheckSet(checks []FindingType) checkSet {
+	if len(checks) == 0 {
+		return nil
+	}
+	set := make(checkSet, len(checks))
+	for _, ft := range checks {
+		set[ft] = true
+	}
+	return set
+}
+
+func (s checkSet) enabled(ft FindingType) bool {
+	if s == nil {
+		return !optInChecks[ft]
+	}
+	return s[ft]
+}
+
+// filter drops findings whose type is not enabled.
+func (s checkSet) filter(findings []Finding) []Finding {
+	if s == nil {
+		return findings
+	}
+	var result []Finding
+	for _, f := range findings {
+		if s[f.Type] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestProfileChecks(t *testing.T) {
+	checks, err := ProfileChecks("Security")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) == 0 {
+		t.Error("expected security profile to enable checks")
+	}
+
+	if _, err := ProfileChecks("nonexistent"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestProfileChecks_PerformanceExcludesDrift(t *testing.T) {
+	checks, err := ProfileChecks("performance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := newCheckSet(checks)
+	for _, ft := range []FindingType{FindingMissingTable, FindingMissingColumn, FindingUnreferencedTable, FindingCodeMatch} {
+		if set.enabled(ft) {
+			t.Errorf("performance profile should not enable %s", ft)
+		}
+	}
+	for _, ft := range []FindingType{FindingBloatedIndex, FindingUnindexedQuery, FindingMissingVacuum} {
+		if !set.enabled(ft) {
+			t.Errorf("performance profile should enable %s", ft)
+		}
+	}
+}
+
+func TestDiff_PerformanceProfileDropsDrift(t *testing.T) {
+	checks, err := ProfileChecks("performance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultAuditOptions()
+	opts.Checks = checks
+
+	scan := scanResult("users", "nonexistent")
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{tableInfo("public", "users", 100)},
+		Stats:  []postgres.TableStats{makeStats("public", "users", 10, 5)},
+	}
+
+	for _, f := range Diff(&scan, snap, opts) {
+		switch f.Type {
+		case FindingMissingTable, FindingCodeMatch, FindingUnreferencedTable, FindingMissingColumn:
+			t.Errorf("unexpected drift finding %s with performance profile", f.Type)
+		}
+	}
+}
+
+func securitySnapshot() *postgres.Snapshot {
+	return &postgres.Snapshot{
+		Tables: []postgres.TableInfo{{Schema: "public", Name: "users", SizeBytes: 8192}},
+		Columns: []postgres.ColumnInfo{
+			{Schema: "public", Table: "users", Name: "password", DataType: "text"},
+		},
+		Stats:      []postgres.TableStats{makeStats("public", "users", 0, 0)},
+		Extensions: []postgres.ExtensionInfo{{Schema: "public", Name: "dblink"}},
+		Grants:     []postgres.GrantInfo{{Schema: "public", Table: "users", Grantee: "PUBLIC", Privilege: "SELECT"}},
+		Roles:      []postgres.RoleInfo{{Name: "app", Superuser: true, CanLogin: true, Connections: 3}},
+	}
+}
+
+func TestAudit_OptInChecksDisabledByDefault(t *testing.T) {
+	findings := Audit(securitySnapshot(), DefaultAuditOptions())
+
+	for _, f := range findings {
+		if optInChecks[f.Type] {
+			t.Errorf("unexpected opt-in finding %s without profile", f.Type)
+		}
+	}
+	if len(findings) == 0 {
+		t.Error("expected default findings (UNUSED_TABLE, NO_PRIMARY_KEY)")
+	}
+}
+
+func TestAudit_SecurityProfile(t *testing.T) {
+	checks, err := ProfileChecks("security")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultAuditOptions()
+	opts.Checks = checks
+
+	findings := Audit(securitySnapshot(), opts)
+
+	typeCounts := make(map[FindingType]int)
+	for _, f := range findings {
+		typeCounts[f.Type]++
+	}
+	if typeCounts[FindingSensitiveColumn] != 1 {
+		t.Errorf("expected 1 SENSITIVE_COLUMN, got %d", typeCounts[FindingSensitiveColumn])
+	}
+	if typeCounts[FindingRiskyExtension] != 1 {
+		t.Errorf("expected 1 RISKY_EXTENSION, got %d", typeCounts[FindingRiskyExtension])
+	}
+	if typeCounts[FindingPublicGrant] != 1 || typeCounts[FindingSuperuserAppRole] != 1 {
+		t.Errorf("expected PUBLIC_GRANT and SUPERUSER_APP_ROLE, got %v", typeCounts)
+	}
+	if typeCounts[FindingUnusedTable] != 0 || typeCounts[FindingNoPrimaryKey] != 0 {
+		t.Errorf("expected performance checks to be disabled, got %v", typeCounts)
+	}
+}
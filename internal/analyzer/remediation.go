@@ -0,0 +1,178 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Risk classifies what running a remediation statement can do to a
+// running application.
+type Risk string
+
+const (
+	// RiskSafe statements take no lock that blocks reads or writes for
+	// long: concurrent index builds, VACUUM, VALIDATE CONSTRAINT,
+	// ALTER SYSTEM.
+	RiskSafe Risk = "safe"
+	// RiskLocking statements take a lock that blocks writes, or reads,
+	// while they run.
+	RiskLocking Risk = "locking"
+	// RiskDestructive statements drop objects or data, or can break
+	// clients that rely on current behavior.
+	RiskDestructive Risk = "destructive"
+)
+
+// Risks lists all risk levels in the order remediations should be applied.
+var Risks = []Risk{RiskSafe, RiskLocking, RiskDestructive}
+
+// Remediation is a SQL statement that resolves a finding.
+type Remediation struct {
+	SQL  string `json:"sql"`
+	Risk Risk   `json:"risk"`
+	// Note says what to check before or after running the statement.
+	Note string `json:"note,omitempty"`
+}
+
+// Remediate returns the statement that resolves a finding. Findings that
+// need a judgment call (a primary key to choose, code to change) have
+// none. Statements are built from the finding's identifiers, quoted, never
+// copied from a suggestion, ddl, fix, or drop detail, so findings read back
+// from a report file can't carry other SQL into a fix script.
+func Remediate(f Finding) (Remediation, bool) {
+	rel := quoteIdent(f.Schema) + "." + quoteIdent(f.Table)
+	index := quoteIdent(f.Schema) + "." + quoteIdent(f.Index)
+
+	switch f.Type {
+	case FindingUnusedIndex, FindingDuplicateIndex:
+		// An index backing a constraint is dropped with the constraint,
+		// which is a judgment call.
+		if f.Detail["constraint"] != "" {
+			return Remediation{}, false
+		}
+		return Remediation{
+			SQL:  fmt.Sprintf("DROP INDEX CONCURRENTLY %s;", index),
+			Risk: RiskDestructive,
+			Note: "recreating the index later means a full build",
+		}, f.Index != ""
+	case FindingInvalidIndex, FindingBloatedIndex:
+		return Remediation{
+			SQL:  fmt.Sprintf("REINDEX INDEX CONCURRENTLY %s;", index),
+			Risk: RiskSafe,
+			Note: "needs PostgreSQL 12 or later and can't run in a transaction block",
+		}, f.Index != ""
+	case FindingMissingVacuum:
+		return Remediation{SQL: fmt.Sprintf("VACUUM (ANALYZE) %s;", rel), Risk: RiskSafe}, true
+	case FindingUnusedTable:
+		return Remediation{
+			SQL:  fmt.Sprintf("DROP TABLE %s;", rel),
+			Risk: RiskDestructive,
+			Note: "take a backup first; confirm no job or report reads it",
+		}, true
+	case FindingUnusedView:
+		return Remediation{SQL: fmt.Sprintf("DROP VIEW %s;", rel), Risk: RiskDestructive}, true
+	case FindingStaleMatView:
+		// Only a never-populated view carries a suggestion: refresh it.
+		if f.Detail["suggestion"] != "" {
+			return Remediation{
+				SQL:  fmt.Sprintf("REFRESH MATERIALIZED VIEW %s;", rel),
+				Risk: RiskLocking,
+				Note: "blocks reads of the view while it refreshes",
+			}, true
+		}
+		return Remediation{SQL: fmt.Sprintf("DROP MATERIALIZED VIEW %s;", rel), Risk: RiskDestructive}, true
+	case FindingDisabledTrigger:
+		trigger := f.Detail["trigger"]
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER TABLE %s ENABLE TRIGGER %s;", rel, quoteIdent(trigger)),
+			Risk: RiskLocking,
+			Note: "confirm the trigger was not disabled on purpose",
+		}, trigger != ""
+	case FindingAutovacuumDisabled:
+		var params []string
+		for _, s := range strings.Split(f.Detail["setting"], ", ") {
+			if name, _, ok := strings.Cut(s, "="); ok {
+				params = append(params, name)
+			}
+		}
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER TABLE %s RESET (%s);", rel, strings.Join(params, ", ")),
+			Risk: RiskLocking,
+		}, len(params) > 0
+	case FindingNullableFKColumn:
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", rel, quoteIdent(f.Column)),
+			Risk: RiskLocking,
+			Note: "scans the table under an ACCESS EXCLUSIVE lock",
+		}, f.Column != ""
+	case FindingMissingForeignKey:
+		refSchema, refTable, ok := strings.Cut(f.Detail["referenced_table"], ".")
+		refColumn := f.Detail["referenced_column"]
+		return Remediation{
+			SQL: fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s.%s (%s) NOT VALID;",
+				rel, quoteIdent(f.Column), quoteIdent(refSchema), quoteIdent(refTable), quoteIdent(refColumn)),
+			Risk: RiskLocking,
+			Note: "clean up orphaned rows, then VALIDATE CONSTRAINT",
+		}, ok && f.Column != "" && refColumn != ""
+	case FindingNotValidConstraint:
+		constraint := f.Detail["constraint"]
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", rel, quoteIdent(constraint)),
+			Risk: RiskSafe,
+		}, constraint != ""
+	case FindingMissingGINIndex:
+		return Remediation{
+			SQL:  fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s USING gin (%s);", rel, quoteIdent(f.Column)),
+			Risk: RiskSafe,
+		}, f.Column != ""
+	case FindingSuggestedIndex:
+		var cols []string
+		for _, c := range strings.Split(f.Detail["columns"], ", ") {
+			if c != "" {
+				cols = append(cols, quoteIdent(c))
+			}
+		}
+		return Remediation{
+			SQL:  fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s (%s);", rel, strings.Join(cols, ", ")),
+			Risk: RiskSafe,
+		}, len(cols) > 0
+	case FindingSettings:
+		name := f.Detail["setting"]
+		value, ok := safeSettingValue(name)
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER SYSTEM SET %s = %s;", quoteIdent(name), value),
+			Risk: RiskSafe,
+			Note: reloadNote,
+		}, ok
+	case FindingFrequentCheckpoints:
+		size := f.Detail["suggested_max_wal_size"]
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER SYSTEM SET max_wal_size = %s;", quoteLiteral(size)),
+			Risk: RiskSafe,
+			Note: reloadNote,
+		}, size != ""
+	case FindingMissingRLS:
+		// Only a table without row-level security carries a fix. Either
+		// way non-owner roles lose rows they see today, so it is
+		// destructive.
+		note := "denies every row to non-owner roles until policies are created"
+		if n := f.Detail["policies"]; n != "" && n != "0" {
+			note = "non-owner roles then see only the rows the table's " + n + " policies allow; check them against every access path first"
+		}
+		return Remediation{
+			SQL:  fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", rel),
+			Risk: RiskDestructive,
+			Note: note,
+		}, f.Detail["fix"] != ""
+	case FindingInactiveSlot:
+		slot := f.Detail["slot"]
+		return Remediation{
+			SQL:  fmt.Sprintf("SELECT pg_drop_replication_slot(%s);", quoteLiteral(slot)),
+			Risk: RiskDestructive,
+			Note: "its consumer can't resume and must be resynchronized",
+		}, slot != ""
+	}
+	return Remediation{}, false
+}
+
+// reloadNote is the note on ALTER SYSTEM remediations.
+const reloadNote = "takes effect after SELECT pg_reload_conf();"
@@ -0,0 +1,115 @@
+package analyzer
+
+import "testing"
+
+func TestRemediate(t *testing.T) {
+	tests := []struct {
+		name    string
+		finding Finding
+		sql     string
+		risk    Risk
+	}{
+		{
+			name:    "unused index",
+			finding: Finding{Type: FindingUnusedIndex, Schema: "public", Table: "users", Index: "idx_users_legacy"},
+			sql:     "DROP INDEX CONCURRENTLY public.idx_users_legacy;",
+			risk:    RiskDestructive,
+		},
+		{
+			name:    "missing vacuum",
+			finding: Finding{Type: FindingMissingVacuum, Schema: "Billing", Table: "invoices"},
+			sql:     `VACUUM (ANALYZE) "Billing".invoices;`,
+			risk:    RiskSafe,
+		},
+		{
+			name: "autovacuum disabled",
+			finding: Finding{Type: FindingAutovacuumDisabled, Schema: "public", Table: "events",
+				Detail: map[string]string{"setting": "autovacuum_enabled=false, toast.autovacuum_enabled=off"}},
+			sql:  "ALTER TABLE public.events RESET (autovacuum_enabled, toast.autovacuum_enabled);",
+			risk: RiskLocking,
+		},
+		{
+			name: "suggestion detail ignored",
+			finding: Finding{Type: FindingNotValidConstraint, Schema: "public", Table: "orders",
+				Detail: map[string]string{"constraint": "orders_user_fk", "suggestion": "DROP TABLE public.users;"}},
+			sql:  "ALTER TABLE public.orders VALIDATE CONSTRAINT orders_user_fk;",
+			risk: RiskSafe,
+		},
+		{
+			name: "rls fix",
+			finding: Finding{Type: FindingMissingRLS, Schema: "public", Table: "accounts",
+				Detail: map[string]string{"fix": "ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY"}},
+			sql:  "ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY;",
+			risk: RiskDestructive,
+		},
+		{
+			name: "missing foreign key",
+			finding: Finding{Type: FindingMissingForeignKey, Schema: "public", Table: "orders", Column: "user_id",
+				Detail: map[string]string{"referenced_table": "public.users", "referenced_column": "id"}},
+			sql:  "ALTER TABLE public.orders ADD FOREIGN KEY (user_id) REFERENCES public.users (id) NOT VALID;",
+			risk: RiskLocking,
+		},
+		{
+			name: "suggested index",
+			finding: Finding{Type: FindingSuggestedIndex, Schema: "public", Table: "orders",
+				Detail: map[string]string{"columns": "tenant_id, Status", "ddl": "DROP TABLE public.orders;"}},
+			sql:  `CREATE INDEX CONCURRENTLY ON public.orders (tenant_id, "Status");`,
+			risk: RiskSafe,
+		},
+		{
+			name:    "unsafe setting",
+			finding: Finding{Type: FindingSettings, Detail: map[string]string{"setting": "fsync", "value": "off"}},
+			sql:     "ALTER SYSTEM SET fsync = on;",
+			risk:    RiskSafe,
+		},
+		{
+			name:    "max_wal_size",
+			finding: Finding{Type: FindingFrequentCheckpoints, Detail: map[string]string{"suggested_max_wal_size": "4GB"}},
+			sql:     "ALTER SYSTEM SET max_wal_size = '4GB';",
+			risk:    RiskSafe,
+		},
+		{
+			name:    "slot name quoted",
+			finding: Finding{Type: FindingInactiveSlot, Detail: map[string]string{"slot": "x'); DROP TABLE users; --"}},
+			sql:     "SELECT pg_drop_replication_slot('x''); DROP TABLE users; --');",
+			risk:    RiskDestructive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rem, ok := Remediate(tt.finding)
+			if !ok {
+				t.Fatal("no remediation")
+			}
+			if rem.SQL != tt.sql || rem.Risk != tt.risk {
+				t.Errorf("got %q (%s), want %q (%s)", rem.SQL, rem.Risk, tt.sql, tt.risk)
+			}
+		})
+	}
+
+	for _, f := range []Finding{
+		{Type: FindingNoPrimaryKey, Schema: "public", Table: "events"},
+		{Type: FindingMissingForeignKey, Schema: "public", Table: "orders"},
+		{Type: FindingDisabledTrigger, Schema: "public", Table: "orders"},
+		{Type: FindingSettings, Detail: map[string]string{"setting": "shared_buffers", "suggestion": "ALTER SYSTEM SET shared_buffers = '8GB';"}},
+		{Type: FindingMissingRLS, Schema: "public", Table: "accounts", Detail: map[string]string{"policies": "0"}},
+	} {
+		if rem, ok := Remediate(f); ok {
+			t.Errorf("%s: unexpected remediation %q", f.Type, rem.SQL)
+		}
+	}
+}
+
+func TestRemediate_RLSNote(t *testing.T) {
+	fix := "ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY"
+	for policies, want := range map[string]string{
+		"0": "denies every row to non-owner roles until policies are created",
+		"2": "non-owner roles then see only the rows the table's 2 policies allow; check them against every access path first",
+	} {
+		rem, ok := Remediate(Finding{Type: FindingMissingRLS, Schema: "public", Table: "accounts",
+			Detail: map[string]string{"fix": fix, "policies": policies}})
+		if !ok || rem.Note != want || rem.Risk != RiskDestructive {
+			t.Errorf("policies %s: got %+v", policies, rem)
+		}
+	}
+}
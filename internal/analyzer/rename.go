@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// maxCallSites caps the call site locations listed in a finding's detail.
+const maxCallSites = 10
+
+// renameFinding warns that renaming a table or column in one step breaks
+// every deployed client still using the old name.
+func renameFinding(tbl postgres.TableInfo, column, newName string, detail map[string]string) Finding {
+	kind, oldName := "table", tbl.Name
+	if column != "" {
+		kind, oldName = "column", column
+	}
+	detail["old_name"] = oldName
+	detail["new_name"] = newName
+	detail["advice"] = fmt.Sprintf("expand-contract: add %s %q, backfill it, dual-write both, move reads to %q, then drop %q", kind, newName, newName, oldName)
+
+	msg := fmt.Sprintf("renames table %q to %q", tbl.Name, newName)
+	if column != "" {
+		msg = fmt.Sprintf("renames column %q on %q to %q", column, tbl.Name, newName)
+	}
+	return Finding{
+		Type:     FindingMigrationRename,
+		Severity: SeverityMedium,
+		Schema:   tbl.Schema,
+		Table:    tbl.Name,
+		Column:   column,
+		Message:  msg + "; code using the old name breaks until it is redeployed",
+		Detail:   detail,
+	}
+}
+
+// LinkRenameCallSites attaches the code references that still use the old
+// name to MIGRATION_RENAME findings, escalating those with call sites to
+// high. References in migration files, including skipFile (the migration
+// being checked, relative to the scanned repo), are not call sites.
+func LinkRenameCallSites(findings []Finding, scan *scanner.ScanResult, skipFile string) error {
+	skipFile = filepath.ToSlash(skipFile)
+	for i := range findings {
+		f := &findings[i]
+		if f.Type != FindingMigrationRename {
+			continue
+		}
+
+		var sites []string
+		add := func(file string, line int) {
+			if filepath.ToSlash(file) != skipFile {
+				sites = append(sites, file+":"+strconv.Itoa(line))
+			}
+		}
+		if f.Column == "" {
+			err := scan.EachRef(func(ref scanner.TableRef) {
+				if ref.Pattern != scanner.PatternMigration && strings.EqualFold(ref.Table, f.Table) {
+					add(ref.File, ref.Line)
+				}
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			for _, ref := range scan.ColumnRefs {
+				if strings.EqualFold(ref.Table, f.Table) && strings.EqualFold(ref.Column, f.Column) {
+					add(ref.File, ref.Line)
+				}
+			}
+		}
+
+		f.Detail["call_sites"] = strconv.Itoa(len(sites))
+		if len(sites) == 0 {
+			continue
+		}
+		f.Severity = SeverityHigh
+		f.Message += fmt.Sprintf("; %d call site(s) still use %q", len(sites), f.Detail["old_name"])
+		listed := sites
+		if len(listed) > maxCallSites {
+			listed = listed[:maxCallSites]
+		}
+		locations := strings.Join(listed, ", ")
+		if more := len(sites) - len(listed); more > 0 {
+			locations += fmt.Sprintf(" (+%d more)", more)
+		}
+		f.Detail["call_site_locations"] = locations
+	}
+	return nil
+}
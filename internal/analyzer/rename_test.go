@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestCheckMigration_Rename(t *testing.T) {
+	stmts := migration.Parse(`
+ALTER TABLE users RENAME COLUMN email TO email_address;
+ALTER TABLE tags RENAME TO labels;
+ALTER TABLE labels ADD COLUMN color text;
+CREATE TABLE fresh (id int);
+ALTER TABLE fresh RENAME TO fresher;
+`)
+	findings := CheckMigration("005.sql", stmts, migrationSnapshot(), DefaultMigrationOptions())
+
+	renames := findingsOfType(findings, FindingMigrationRename)
+	if len(renames) != 2 {
+		t.Fatalf("MIGRATION_RENAME = %d, want 2: %+v", len(renames), renames)
+	}
+	if renames[0].Table != "users" || renames[0].Column != "email" || renames[0].Detail["new_name"] != "email_address" {
+		t.Errorf("column rename: %+v", renames[0])
+	}
+	if renames[1].Table != "tags" || renames[1].Column != "" || renames[1].Detail["new_name"] != "labels" {
+		t.Errorf("table rename: %+v", renames[1])
+	}
+	if !strings.Contains(renames[0].Detail["advice"], "expand-contract") {
+		t.Errorf("advice = %q", renames[0].Detail["advice"])
+	}
+	if got := findingsOfType(findings, FindingMigrationConflict); len(got) != 0 {
+		t.Errorf("ALTER on renamed table should not conflict: %+v", got)
+	}
+}
+
+func TestLinkRenameCallSites(t *testing.T) {
+	findings := []Finding{
+		{Type: FindingMigrationRename, Severity: SeverityMedium, Table: "users", Column: "email", Detail: map[string]string{"old_name": "email"}},
+		{Type: FindingMigrationRename, Severity: SeverityMedium, Table: "tags", Detail: map[string]string{"old_name": "tags"}},
+		{Type: FindingMigrationRename, Severity: SeverityMedium, Table: "orders", Detail: map[string]string{"old_name": "orders"}},
+		{Type: FindingMigrationLock, Severity: SeverityLow, Table: "users", Detail: map[string]string{}},
+	}
+	scan := &scanner.ScanResult{
+		Refs: []scanner.TableRef{
+			{Table: "tags", File: "app/tags.go", Line: 12, Pattern: scanner.PatternSQL},
+			{Table: "Tags", File: "app/admin.py", Line: 3, Pattern: scanner.PatternORM},
+			{Table: "tags", File: "db/migrate/001_init.sql", Line: 1, Pattern: scanner.PatternMigration},
+			{Table: "orders", File: "db/migrate/005.sql", Line: 2, Pattern: scanner.PatternSQL},
+		},
+		ColumnRefs: []scanner.ColumnRef{
+			{Table: "users", Column: "email", File: "app/users.go", Line: 40},
+			{Table: "users", Column: "name", File: "app/users.go", Line: 41},
+		},
+	}
+
+	if err := LinkRenameCallSites(findings, scan, "db/migrate/005.sql"); err != nil {
+		t.Fatal(err)
+	}
+
+	if findings[0].Severity != SeverityHigh || findings[0].Detail["call_site_locations"] != "app/users.go:40" {
+		t.Errorf("column rename: %+v", findings[0])
+	}
+	if findings[1].Detail["call_sites"] != "2" || findings[1].Detail["call_site_locations"] != "app/tags.go:12, app/admin.py:3" {
+		t.Errorf("table rename: %+v", findings[1].Detail)
+	}
+	if findings[2].Severity != SeverityMedium || findings[2].Detail["call_sites"] != "0" {
+		t.Errorf("refs in the migration itself are not call sites: %+v", findings[2])
+	}
+	if _, ok := findings[3].Detail["call_sites"]; ok {
+		t.Error("non-rename finding was modified")
+	}
+}
+
+func TestLinkRenameCallSites_CapsLocations(t *testing.T) {
+	findings := []Finding{{Type: FindingMigrationRename, Table: "tags", Detail: map[string]string{"old_name": "tags"}}}
+	scan := &scanner.ScanResult{}
+	for i := range 12 {
+		scan.Refs = append(scan.Refs, scanner.TableRef{Table: "tags", File: "app.go", Line: i + 1, Pattern: scanner.PatternSQL})
+	}
+	if err := LinkRenameCallSites(findings, scan, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(findings[0].Detail["call_site_locations"], "(+2 more)") {
+		t.Errorf("locations = %q", findings[0].Detail["call_site_locations"])
+	}
+}
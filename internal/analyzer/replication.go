@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+const (
+	// slotRetainedMinBytes is the WAL an inactive slot may hold back before
+	// it is reported; below it, a standby may just be reconnecting.
+	slotRetainedMinBytes = 1024 * 1024 * 1024
+	// replicaLagMinBytes and replicaLagMin are the replay lag, in WAL or
+	// time, from which a replica is reported.
+	replicaLagMinBytes = 1024 * 1024 * 1024
+	replicaLagMin      = 5 * time.Minute
+	// clusterHighFactor is the multiple of a minimum at which slot
+	// retention or replica lag becomes high severity.
+	clusterHighFactor = 10
+)
+
+// detectInactiveSlots flags replication slots that no consumer is
+// reading but that still pin WAL. The server can't recycle it until the
+// slot is dropped or its consumer catches up, so pg_wal grows until the
+// disk fills.
+func detectInactiveSlots(h *postgres.ClusterHealth) []Finding {
+	if h == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, s := range h.Slots {
+		if s.Active {
+			continue
+		}
+		lost := s.WALStatus == "lost"
+		if !lost && s.RetainedBytes < slotRetainedMinBytes {
+			continue
+		}
+		f := Finding{
+			Type:     FindingInactiveSlot,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("inactive %s replication slot %q retains %s of WAL", s.Type, s.Name, formatBytes(s.RetainedBytes)),
+			Detail: map[string]string{
+				"slot":           s.Name,
+				"slot_type":      s.Type,
+				"retained_bytes": strconv.FormatInt(s.RetainedBytes, 10),
+				"drop":           fmt.Sprintf("SELECT pg_drop_replication_slot(%s);", quoteLiteral(s.Name)),
+			},
+		}
+		if s.Database != "" {
+			f.Detail["database"] = s.Database
+		}
+		if s.WALStatus != "" {
+			f.Detail["wal_status"] = s.WALStatus
+		}
+		switch {
+		case lost:
+			f.Severity = SeverityHigh
+			f.Message = fmt.Sprintf("inactive %s replication slot %q has lost required WAL; its consumer can't resume and must be rebuilt", s.Type, s.Name)
+		case s.WALStatus == "unreserved" || s.RetainedBytes >= clusterHighFactor*slotRetainedMinBytes:
+			f.Severity = SeverityHigh
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// detectReplicationLag flags standbys and subscribers whose replay has
+// fallen behind by replicaLagMinBytes of WAL or replicaLagMin of time.
+// Lagging synchronous standbys hold up commits, so they are high.
+func detectReplicationLag(h *postgres.ClusterHealth) []Finding {
+	if h == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, r := range h.Replicas {
+		lag := seconds(r.ReplayLagSeconds)
+		if r.LagBytes < replicaLagMinBytes && lag < replicaLagMin {
+			continue
+		}
+		name := r.Application
+		if name == "" {
+			name = r.ClientAddr
+		}
+		f := Finding{
+			Type:     FindingReplicationLag,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("replica %q is %s of WAL (%s) behind", name, formatBytes(r.LagBytes), lag),
+			Detail: map[string]string{
+				"application": r.Application,
+				"state":       r.State,
+				"sync_state":  r.SyncState,
+				"lag_bytes":   strconv.FormatInt(r.LagBytes, 10),
+				"replay_lag":  lag.String(),
+			},
+		}
+		if r.ClientAddr != "" {
+			f.Detail["client_addr"] = r.ClientAddr
+		}
+		if r.SyncState == "sync" || r.SyncState == "quorum" ||
+			r.LagBytes >= clusterHighFactor*replicaLagMinBytes || lag >= clusterHighFactor*replicaLagMin {
+			f.Severity = SeverityHigh
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// detectArchiverFailures flags an archiver whose latest attempt failed.
+// Segments that can't be archived stay in pg_wal, and point-in-time
+// recovery has a gap from the first of them. Past failures followed by a
+// success are not reported.
+func detectArchiverFailures(h *postgres.ClusterHealth) []Finding {
+	if h == nil || h.Archiver == nil {
+		return nil
+	}
+	a := h.Archiver
+	if a.LastFailedSeconds == nil {
+		return nil
+	}
+	if a.LastArchivedSeconds != nil && *a.LastArchivedSeconds <= *a.LastFailedSeconds {
+		return nil
+	}
+	since := "never succeeded"
+	if a.LastArchivedSeconds != nil {
+		since = "last succeeded " + seconds(*a.LastArchivedSeconds).String() + " ago"
+	}
+	return []Finding{{
+		Type:     FindingArchiverFailing,
+		Severity: SeverityHigh,
+		Message: fmt.Sprintf("WAL archiving is failing: %s failed %s ago and archiving %s",
+			a.LastFailedWAL, seconds(*a.LastFailedSeconds), since),
+		Detail: map[string]string{
+			"archive_mode":    a.ArchiveMode,
+			"failed_count":    strconv.FormatInt(a.FailedCount, 10),
+			"archived_count":  strconv.FormatInt(a.ArchivedCount, 10),
+			"last_failed_wal": a.LastFailedWAL,
+		},
+	}}
+}
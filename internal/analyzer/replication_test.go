@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestDetectInactiveSlots(t *testing.T) {
+	const gb = 1024 * 1024 * 1024
+	h := &postgres.ClusterHealth{Slots: []postgres.ReplicationSlot{
+		{Name: "standby1", Type: "physical", Active: true, RetainedBytes: 50 * gb},
+		{Name: "old_standby", Type: "physical", RetainedBytes: 3 * gb, WALStatus: "extended"},
+		{Name: "debezium", Type: "logical", Database: "app", RetainedBytes: 20 * gb},
+		{Name: "reconnecting", Type: "physical", RetainedBytes: 16 * 1024 * 1024},
+		{Name: "gone", Type: "physical", WALStatus: "lost"},
+	}}
+	findings := detectInactiveSlots(h)
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Detail["slot"] != "old_standby" || f.Severity != SeverityMedium ||
+		f.Detail["drop"] != "SELECT pg_drop_replication_slot('old_standby');" {
+		t.Errorf("old_standby: %+v", f)
+	}
+	if f := findings[1]; f.Detail["slot"] != "debezium" || f.Severity != SeverityHigh || f.Detail["database"] != "app" {
+		t.Errorf("debezium: %+v", f)
+	}
+	if f := findings[2]; f.Detail["slot"] != "gone" || f.Severity != SeverityHigh || f.Detail["wal_status"] != "lost" {
+		t.Errorf("gone: %+v", f)
+	}
+	if detectInactiveSlots(nil) != nil {
+		t.Error("nil cluster health should give no findings")
+	}
+}
+
+func TestDetectReplicationLag(t *testing.T) {
+	h := &postgres.ClusterHealth{Replicas: []postgres.ReplicaInfo{
+		{Application: "caught_up", SyncState: "async", LagBytes: 1024, ReplayLagSeconds: 0.2},
+		{Application: "slow", ClientAddr: "10.0.0.5", SyncState: "async", LagBytes: 64 * 1024 * 1024, ReplayLagSeconds: 600},
+		{ClientAddr: "10.0.0.6", SyncState: "sync", LagBytes: 2 * 1024 * 1024 * 1024, ReplayLagSeconds: 30},
+	}}
+	findings := detectReplicationLag(h)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Detail["application"] != "slow" || f.Severity != SeverityMedium || f.Detail["replay_lag"] != "10m0s" {
+		t.Errorf("slow: %+v", f)
+	}
+	if f := findings[1]; f.Detail["client_addr"] != "10.0.0.6" || f.Severity != SeverityHigh {
+		t.Errorf("sync replica: %+v", f)
+	}
+}
+
+func TestDetectArchiverFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *postgres.ArchiverStats
+		want bool
+	}{
+		{"archive_mode off", nil, false},
+		{"never failed", &postgres.ArchiverStats{ArchivedCount: 10, LastArchivedSeconds: ptr(30.0)}, false},
+		{"recovered", &postgres.ArchiverStats{FailedCount: 3, LastArchivedSeconds: ptr(30.0), LastFailedSeconds: ptr(600.0)}, false},
+		{"failing", &postgres.ArchiverStats{FailedCount: 40, LastFailedWAL: "00000001000000000000002A", LastArchivedSeconds: ptr(3600.0), LastFailedSeconds: ptr(20.0)}, true},
+		{"never archived", &postgres.ArchiverStats{FailedCount: 1, LastFailedSeconds: ptr(20.0)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectArchiverFailures(&postgres.ClusterHealth{Archiver: tt.a})
+			if got := len(findings) == 1; got != tt.want {
+				t.Fatalf("got %+v, want finding = %v", findings, tt.want)
+			}
+			if tt.want && findings[0].Severity != SeverityHigh {
+				t.Errorf("severity = %s, want high", findings[0].Severity)
+			}
+		})
+	}
+}
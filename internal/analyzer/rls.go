@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// detectMissingRLS checks that tables matching the tenant patterns, globs
+// on the table name or schema.table, enforce row-level security with at
+// least one policy. Without policies RLS denies every row to non-owners,
+// which breaks the application rather than leaking data, so that is
+// reported at medium.
+func detectMissingRLS(tables []postgres.TableInfo, policies []postgres.PolicyInfo, patterns []string) []Finding {
+	if len(patterns) == 0 {
+		return nil
+	}
+	policyCount := make(map[string]int)
+	for _, p := range policies {
+		policyCount[tableKey(p.Schema, p.Table)]++
+	}
+
+	var findings []Finding
+	for _, t := range tables {
+		pattern, ok := matchTenantTable(t, patterns)
+		if !ok {
+			continue
+		}
+		n := policyCount[tableKey(t.Schema, t.Name)]
+		detail := map[string]string{
+			"pattern":            pattern,
+			"row_security":       strconv.FormatBool(t.RowSecurity),
+			"force_row_security": strconv.FormatBool(t.ForceRowSecurity),
+			"policies":           strconv.Itoa(n),
+		}
+		switch {
+		case !t.RowSecurity:
+			detail["fix"] = fmt.Sprintf("ALTER TABLE %s.%s ENABLE ROW LEVEL SECURITY", quoteIdent(t.Schema), quoteIdent(t.Name))
+			msg := "multi-tenant table does not enable row-level security"
+			if n > 0 {
+				msg = fmt.Sprintf("multi-tenant table has %d policies but row-level security is not enabled, so they are ignored", n)
+			}
+			findings = append(findings, Finding{
+				Type:     FindingMissingRLS,
+				Severity: SeverityHigh,
+				Schema:   t.Schema,
+				Table:    t.Name,
+				Message:  msg,
+				Detail:   detail,
+			})
+		case n == 0:
+			findings = append(findings, Finding{
+				Type:     FindingMissingRLS,
+				Severity: SeverityMedium,
+				Schema:   t.Schema,
+				Table:    t.Name,
+				Message:  "row-level security is enabled but no policy exists; non-owner roles see no rows",
+				Detail:   detail,
+			})
+		}
+	}
+	return findings
+}
+
+// matchTenantTable returns the first pattern matching the table. Patterns
+// with a dot match schema.table; others match the table name in any schema.
+func matchTenantTable(t postgres.TableInfo, patterns []string) (string, bool) {
+	name := strings.ToLower(t.Name)
+	qualified := strings.ToLower(t.Schema) + "." + name
+	for _, p := range patterns {
+		target := name
+		if strings.Contains(p, ".") {
+			target = qualified
+		}
+		if ok, _ := path.Match(strings.ToLower(p), target); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
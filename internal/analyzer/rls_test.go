@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectMissingRLS(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "tenant_orders"},
+		{Schema: "public", Name: "tenant_users", RowSecurity: true},
+		{Schema: "public", Name: "tenant_invoices", RowSecurity: true, ForceRowSecurity: true},
+		{Schema: "app", Name: "Orders", RowSecurity: false},
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "countries"},
+	}
+	policies := []postgres.PolicyInfo{
+		{Schema: "public", Table: "tenant_orders", Name: "tenant_isolation", Command: "ALL"},
+		{Schema: "public", Table: "tenant_invoices", Name: "tenant_isolation", Command: "ALL"},
+	}
+
+	findings := detectMissingRLS(tables, policies, []string{"tenant_*", "app.orders"})
+	got := make(map[string]Finding)
+	for _, f := range findings {
+		got[f.Schema+"."+f.Table] = f
+	}
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	if f := got["public.tenant_orders"]; f.Severity != SeverityHigh || f.Detail["policies"] != "1" {
+		t.Errorf("tenant_orders: %+v", f)
+	}
+	if f := got["public.tenant_users"]; f.Severity != SeverityMedium || f.Detail["row_security"] != "true" {
+		t.Errorf("tenant_users: %+v", f)
+	}
+	if f := got["app.Orders"]; f.Severity != SeverityHigh || f.Detail["pattern"] != "app.orders" {
+		t.Errorf("app.Orders: %+v", f)
+	}
+	if f := got["app.Orders"]; f.Detail["fix"] != `ALTER TABLE app."Orders" ENABLE ROW LEVEL SECURITY` {
+		t.Errorf("fix = %q", f.Detail["fix"])
+	}
+
+	if got := detectMissingRLS(tables, policies, nil); got != nil {
+		t.Errorf("without patterns: got %+v", got)
+	}
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// bookkeepingTables are kept by migration tools themselves and never
+// appear in the schema files they dump.
+var bookkeepingTables = map[string]bool{
+	"schema_migrations":     true, // Rails, golang-migrate
+	"ar_internal_metadata":  true, // Rails
+	"flyway_schema_history": true,
+	"alembic_version":       true,
+	"goose_db_version":      true,
+}
+
+// DiffSchema compares the schema a codebase declares, from a schema dump
+// or its migrations, with the live database. Declared tables, columns,
+// and indexes the database lacks are migrations that were never applied;
+// live tables and columns in the declared schemas but missing from the
+// declaration were made outside the migrations.
+func DiffSchema(expected *migration.Schema, snap *postgres.Snapshot) []Finding {
+	liveTables := make(map[string]postgres.TableInfo, len(snap.Tables))
+	for _, t := range snap.Tables {
+		liveTables[strings.ToLower(tableKey(t.Schema, t.Name))] = t
+	}
+	liveColumns := make(map[string][]postgres.ColumnInfo)
+	for _, c := range snap.Columns {
+		key := strings.ToLower(tableKey(c.Schema, c.Table))
+		liveColumns[key] = append(liveColumns[key], c)
+	}
+	liveIndexes := make(map[string][]postgres.IndexInfo)
+	for _, idx := range snap.Indexes {
+		key := strings.ToLower(tableKey(idx.Schema, idx.Table))
+		liveIndexes[key] = append(liveIndexes[key], idx)
+	}
+
+	var findings []Finding
+	declared := make(map[string]bool)
+	declaredSchemas := make(map[string]bool)
+	for _, t := range expected.Tables() {
+		key := strings.ToLower(tableKey(t.Schema, t.Name))
+		declared[key] = true
+		declaredSchemas[strings.ToLower(t.Schema)] = true
+
+		live, ok := liveTables[key]
+		if !ok {
+			findings = append(findings, Finding{
+				Type:     FindingUnappliedTable,
+				Severity: SeverityHigh,
+				Schema:   t.Schema,
+				Table:    t.Name,
+				Message:  fmt.Sprintf("table %q is declared in %s but missing from the database; its migration was never applied", t.Name, t.File),
+				Detail:   declaredAt(t.File, t.Line),
+			})
+			continue
+		}
+		findings = append(findings, diffColumns(t, live, liveColumns[key])...)
+		findings = append(findings, diffIndexes(t, live, liveIndexes[key])...)
+	}
+
+	for _, t := range snap.Tables {
+		key := strings.ToLower(tableKey(t.Schema, t.Name))
+		if declared[key] || !declaredSchemas[strings.ToLower(t.Schema)] || bookkeepingTables[strings.ToLower(t.Name)] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUndeclaredTable,
+			Severity: SeverityLow,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("table %q exists in the database but not in the declared schema; it was created outside the migrations", t.Name),
+		})
+	}
+	annotateConfidence(findings, evidence{})
+	return findings
+}
+
+func diffColumns(t migration.SchemaTable, live postgres.TableInfo, columns []postgres.ColumnInfo) []Finding {
+	have := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		have[strings.ToLower(c.Name)] = true
+	}
+	want := make(map[string]bool, len(t.Columns))
+	var findings []Finding
+	for _, c := range t.Columns {
+		want[strings.ToLower(c.Name)] = true
+		if have[strings.ToLower(c.Name)] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUnappliedColumn,
+			Severity: SeverityHigh,
+			Schema:   live.Schema,
+			Table:    live.Name,
+			Column:   c.Name,
+			Message:  fmt.Sprintf("column %q of %q is declared in %s but missing from the database; its migration was never applied", c.Name, live.Name, c.File),
+			Detail:   declaredAt(c.File, c.Line),
+		})
+	}
+	for _, c := range columns {
+		if want[strings.ToLower(c.Name)] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUndeclaredColumn,
+			Severity: SeverityLow,
+			Schema:   live.Schema,
+			Table:    live.Name,
+			Column:   c.Name,
+			Message:  fmt.Sprintf("column %q of %q exists in the database but not in the declared schema; it was added outside the migrations", c.Name, live.Name),
+		})
+	}
+	return findings
+}
+
+// diffIndexes reports declared indexes the table lacks. A live index
+// satisfies a declared one if it has the same name, or indexes the same
+// columns in the same order with the same uniqueness.
+func diffIndexes(t migration.SchemaTable, live postgres.TableInfo, indexes []postgres.IndexInfo) []Finding {
+	var findings []Finding
+	for _, want := range t.Indexes {
+		if hasIndex(want, indexes) {
+			continue
+		}
+		name := want.Name
+		if name == "" {
+			name = "(" + strings.Join(want.Columns, ", ") + ")"
+		}
+		detail := declaredAt(want.File, want.Line)
+		detail["columns"] = strings.Join(want.Columns, ", ")
+		findings = append(findings, Finding{
+			Type:     FindingUnappliedIndex,
+			Severity: SeverityMedium,
+			Schema:   live.Schema,
+			Table:    live.Name,
+			Index:    want.Name,
+			Message:  fmt.Sprintf("index %s on %q is declared in %s but missing from the database; its migration was never applied", name, live.Name, want.File),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+func hasIndex(want migration.SchemaIndex, indexes []postgres.IndexInfo) bool {
+	for _, idx := range indexes {
+		if want.Name != "" && strings.EqualFold(idx.Name, want.Name) {
+			return true
+		}
+		unique := strings.HasPrefix(strings.ToUpper(idx.Definition), "CREATE UNIQUE")
+		if unique == want.Unique && equalFoldColumns(parseIndexColumns(idx.Definition), want.Columns) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalFoldColumns(a, b []string) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(strings.Trim(a[i], `"`), b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func declaredAt(file string, line int) map[string]string {
+	return map[string]string{"file": file, "line": strconv.Itoa(line)}
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDiffSchema(t *testing.T) {
+	expected := migration.NewSchema()
+	expected.Apply("structure.sql", migration.Parse(`
+CREATE TABLE users (id bigint, email text, nickname text);
+CREATE TABLE orders (id bigint);
+CREATE UNIQUE INDEX index_users_on_email ON users (email);
+CREATE INDEX idx_users_nickname ON users (nickname);
+CREATE INDEX idx_users_id ON users (id);
+`))
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "users"},
+			{Schema: "public", Name: "legacy"},
+			{Schema: "public", Name: "schema_migrations"},
+			{Schema: "audit", Name: "events"},
+		},
+		Columns: []postgres.ColumnInfo{
+			{Schema: "public", Table: "users", Name: "id"},
+			{Schema: "public", Table: "users", Name: "email"},
+			{Schema: "public", Table: "users", Name: "temp_flag"},
+		},
+		Indexes: []postgres.IndexInfo{
+			{Schema: "public", Table: "users", Name: "users_email_key", Definition: "CREATE UNIQUE INDEX users_email_key ON public.users USING btree (email)"},
+			{Schema: "public", Table: "users", Name: "idx_users_id", Definition: "CREATE INDEX idx_users_id ON public.users USING btree (id, email)"},
+		},
+	}
+
+	findings := DiffSchema(expected, snap)
+
+	tables := findingsOfType(findings, FindingUnappliedTable)
+	if len(tables) != 1 || tables[0].Table != "orders" || tables[0].Detail["line"] != "3" {
+		t.Errorf("UNAPPLIED_TABLE = %+v, want orders at line 3", tables)
+	}
+	columns := findingsOfType(findings, FindingUnappliedColumn)
+	if len(columns) != 1 || columns[0].Column != "nickname" {
+		t.Errorf("UNAPPLIED_COLUMN = %+v, want users.nickname", columns)
+	}
+	indexes := findingsOfType(findings, FindingUnappliedIndex)
+	if len(indexes) != 1 || indexes[0].Index != "idx_users_nickname" {
+		t.Errorf("UNAPPLIED_INDEX = %+v, want idx_users_nickname only", indexes)
+	}
+	undeclared := findingsOfType(findings, FindingUndeclaredTable)
+	if len(undeclared) != 1 || undeclared[0].Table != "legacy" {
+		t.Errorf("UNDECLARED_TABLE = %+v, want legacy only", undeclared)
+	}
+	undeclaredCols := findingsOfType(findings, FindingUndeclaredColumn)
+	if len(undeclaredCols) != 1 || undeclaredCols[0].Column != "temp_flag" {
+		t.Errorf("UNDECLARED_COLUMN = %+v, want users.temp_flag", undeclaredCols)
+	}
+}
+
+func TestDiffSchema_InSync(t *testing.T) {
+	expected := migration.NewSchema()
+	expected.ApplyRails("db/schema.rb", `create_table "users", force: :cascade do |t|
+  t.string "email"
+  t.index ["email"], name: "index_users_on_email", unique: true
+end
+`)
+	snap := &postgres.Snapshot{
+		Tables:  []postgres.TableInfo{{Schema: "public", Name: "users"}, {Schema: "public", Name: "ar_internal_metadata"}},
+		Columns: []postgres.ColumnInfo{{Schema: "public", Table: "users", Name: "id"}, {Schema: "public", Table: "users", Name: "email"}},
+		Indexes: []postgres.IndexInfo{{Schema: "public", Table: "users", Name: "index_users_on_email"}},
+	}
+	if findings := DiffSchema(expected, snap); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+}
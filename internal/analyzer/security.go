@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// sensitiveNameParts are column-name fragments that usually indicate secrets
+// or personal data.
+var sensitiveNameParts = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey", "private_key",
+	"ssn", "social_security", "credit_card", "card_number", "cc_number", "cvv",
+}
+
+// protectedNameSuffixes mark columns that already hold a derived value
+// (hash, ciphertext) rather than the raw secret.
+var protectedNameSuffixes = []string{"_hash", "_hashed", "_digest", "_encrypted", "_enc", "_ciphertext"}
+
+// plainTextTypes are column types that store values as readable text.
+var plainTextTypes = map[string]bool{
+	"text":              true,
+	"character varying": true,
+	"character":         true,
+	"json":              true,
+	"jsonb":             true,
+}
+
+// riskyExtensions maps extension names to why they widen the attack surface.
+var riskyExtensions = map[string]string{
+	"dblink":      "opens connections to arbitrary servers from SQL",
+	"file_fdw":    "reads files on the database server",
+	"adminpack":   "exposes server file system functions",
+	"pageinspect": "reads raw pages, bypassing row-level security",
+	"plpythonu":   "untrusted language with operating system access",
+	"plpython2u":  "untrusted language with operating system access",
+	"plpython3u":  "untrusted language with operating system access",
+	"plperlu":     "untrusted language with operating system access",
+	"pltclu":      "untrusted language with operating system access",
+}
+
+// detectSensitiveColumns flags plain-text columns whose names suggest they
+// hold secrets or personal data.
+func detectSensitiveColumns(columns []postgres.ColumnInfo) []Finding {
+	var findings []Finding
+	for _, c := range columns {
+		if !plainTextTypes[c.DataType] || !isSensitiveName(c.Name) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingSensitiveColumn,
+			Severity: SeverityLow,
+			Schema:   c.Schema,
+			Table:    c.Table,
+			Column:   c.Name,
+			Message:  fmt.Sprintf("column %q may store sensitive data as plain %s", c.Name, c.DataType),
+			Detail: map[string]string{
+				"data_type": c.DataType,
+			},
+		})
+	}
+	return findings
+}
+
+func isSensitiveName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range protectedNameSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectRiskyExtensions flags installed extensions that give SQL users
+// access beyond the database itself.
+func detectRiskyExtensions(extensions []postgres.ExtensionInfo) []Finding {
+	var findings []Finding
+	for _, ext := range extensions {
+		reason, ok := riskyExtensions[ext.Name]
+		if !ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingRiskyExtension,
+			Severity: SeverityMedium,
+			Schema:   ext.Schema,
+			Message:  fmt.Sprintf("extension %q is installed: %s", ext.Name, reason),
+			Detail: map[string]string{
+				"extension": ext.Name,
+				"version":   ext.Version,
+			},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectSensitiveColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		column postgres.ColumnInfo
+		want   int
+	}{
+		{"plain password", postgres.ColumnInfo{Schema: "public", Table: "users", Name: "password", DataType: "text"}, 1},
+		{"api key varchar", postgres.ColumnInfo{Schema: "public", Table: "integrations", Name: "stripe_api_key", DataType: "character varying"}, 1},
+		{"hashed password", postgres.ColumnInfo{Schema: "public", Table: "users", Name: "password_hash", DataType: "text"}, 0},
+		{"encrypted token", postgres.ColumnInfo{Schema: "public", Table: "sessions", Name: "token_encrypted", DataType: "text"}, 0},
+		{"binary secret", postgres.ColumnInfo{Schema: "public", Table: "vault", Name: "secret", DataType: "bytea"}, 0},
+		{"ordinary column", postgres.ColumnInfo{Schema: "public", Table: "users", Name: "email", DataType: "text"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectSensitiveColumns([]postgres.ColumnInfo{tt.column})
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingSensitiveColumn {
+					t.Errorf("expected type SENSITIVE_COLUMN, got %s", f.Type)
+				}
+				if f.Column != tt.column.Name {
+					t.Errorf("column = %q, want %q", f.Column, tt.column.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectRiskyExtensions(t *testing.T) {
+	extensions := []postgres.ExtensionInfo{
+		{Schema: "public", Name: "pg_trgm", Version: "1.6"},
+		{Schema: "public", Name: "dblink", Version: "1.2"},
+		{Schema: "pg_catalog", Name: "plpython3u", Version: "1.0"},
+	}
+
+	findings := detectRiskyExtensions(extensions)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+	if findings[0].Detail["extension"] != "dblink" {
+		t.Errorf("extension = %q, want dblink", findings[0].Detail["extension"])
+	}
+	if findings[0].Severity != SeverityMedium {
+		t.Errorf("severity = %s, want medium", findings[0].Severity)
+	}
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// detectSelectStar reports each relation that code reads with SELECT *.
+// Those queries change shape when a column is added or reordered, and
+// break when one is dropped, in ways a schema diff can't see coming.
+// Relations missing from the database are left to MISSING_TABLE.
+func detectSelectStar(queries []scanner.QueryRef, relations map[string]postgres.RelationInfo) []Finding {
+	locations := make(map[string][]string)
+	for _, q := range queries {
+		seen := make(map[string]bool)
+		for _, t := range q.StarTables {
+			lower := strings.ToLower(t)
+			if seen[lower] {
+				continue
+			}
+			seen[lower] = true
+			locations[lower] = append(locations[lower], q.File+":"+strconv.Itoa(q.Line))
+		}
+	}
+
+	var findings []Finding
+	for lower, locs := range locations {
+		rel, ok := relations[lower]
+		if !ok {
+			continue
+		}
+		sort.Strings(locs)
+		listed := locs
+		if len(listed) > maxCodeLocations {
+			listed = append(listed[:maxCodeLocations:maxCodeLocations], fmt.Sprintf("and %d more", len(locs)-maxCodeLocations))
+		}
+		findings = append(findings, Finding{
+			Type:     FindingSelectStar,
+			Severity: SeverityInfo,
+			Schema:   rel.Schema,
+			Table:    rel.Name,
+			Message:  fmt.Sprintf("%d queries read %q with SELECT *; they break or change shape when its columns change", len(locs), rel.Name),
+			Detail:   map[string]string{"code_locations": strings.Join(listed, ", ")},
+		})
+	}
+	return findings
+}
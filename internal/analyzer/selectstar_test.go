@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestDetectSelectStar(t *testing.T) {
+	queries := []scanner.QueryRef{
+		{File: "b.go", Line: 9, StarTables: []string{"users"}},
+		{File: "a.go", Line: 3, StarTables: []string{"Users", "users"}},
+		{File: "a.go", Line: 7, StarTables: []string{"ghost"}},
+		{File: "a.go", Line: 8},
+	}
+	relations := map[string]postgres.RelationInfo{
+		"users": {Schema: "app", Name: "users", Kind: postgres.RelationTable},
+	}
+
+	findings := detectSelectStar(queries, relations)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingSelectStar || f.Severity != SeverityInfo || f.Schema != "app" || f.Table != "users" {
+		t.Errorf("finding = %+v", f)
+	}
+	if got := f.Detail["code_locations"]; got != "a.go:3, b.go:9" {
+		t.Errorf("code_locations = %q, want a.go:3, b.go:9", got)
+	}
+	if !strings.Contains(f.Message, "2 queries") {
+		t.Errorf("message = %q", f.Message)
+	}
+}
+
+func TestDiff_SelectStarOptIn(t *testing.T) {
+	scan := scanResult("users")
+	scan.Queries = []scanner.QueryRef{{Fingerprint: scanner.Fingerprint("SELECT * FROM users"), File: "app.go", Line: 1, StarTables: []string{"users"}}}
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{tableInfo("public", "users", 100)},
+		Stats:  []postgres.TableStats{makeStats("public", "users", 10, 5)},
+	}
+
+	count := func(findings []Finding) int {
+		n := 0
+		for _, f := range findings {
+			if f.Type == FindingSelectStar {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(Diff(&scan, snap, DefaultAuditOptions())); n != 0 {
+		t.Errorf("SELECT_STAR reported by default: %d", n)
+	}
+	opts := DefaultAuditOptions()
+	opts.Checks = []FindingType{FindingSelectStar}
+	if n := count(Diff(&scan, snap, opts)); n != 1 {
+		t.Errorf("SELECT_STAR findings with the check enabled = %d, want 1", n)
+	}
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+const (
+	// sharedBuffersMinBytes is the shared_buffers size at or below which a
+	// large database is reported; it is PostgreSQL's default.
+	sharedBuffersMinBytes = 128 * 1024 * 1024
+	// sharedBuffersDatasetBytes is the database size from which default
+	// shared_buffers is reported.
+	sharedBuffersDatasetBytes = 10 * 1024 * 1024 * 1024
+)
+
+// settingUnitRe matches a pg_settings unit such as 8kB or 16MB.
+var settingUnitRe = regexp.MustCompile(`^(\d*)(B|kB|MB|GB|TB)$`)
+
+// unsafeSettings are boolean settings that are never safe to change from
+// their default in production, with why.
+var unsafeSettings = []struct {
+	name, bad, reason string
+	severity          Severity
+}{
+	{"fsync", "off", "a crash or power loss can corrupt the whole cluster", SeverityHigh},
+	{"full_page_writes", "off", "a crash during a partial page write can leave torn pages that WAL replay can't repair", SeverityHigh},
+	{"zero_damaged_pages", "on", "pages with damaged headers are silently zeroed, destroying their rows", SeverityHigh},
+	{"autovacuum", "off", "dead tuples accumulate everywhere and transaction ID wraparound is left to anti-wraparound vacuums", SeverityHigh},
+	{"track_counts", "off", "autovacuum can't tell which tables need work, and usage statistics stop updating", SeverityHigh},
+}
+
+// safeSettingValue returns the value an unsafe setting should be reset to.
+func safeSettingValue(name string) (string, bool) {
+	for _, u := range unsafeSettings {
+		if u.name != name {
+			continue
+		}
+		if u.bad == "on" {
+			return "off", true
+		}
+		return "on", true
+	}
+	return "", false
+}
+
+// detectSettings flags server settings that trade away durability or
+// maintenance, and a default shared_buffers on a large database.
+func detectSettings(settings []postgres.SettingInfo, db *postgres.DatabaseStats) []Finding {
+	byName := make(map[string]postgres.SettingInfo, len(settings))
+	for _, s := range settings {
+		byName[s.Name] = s
+	}
+
+	var findings []Finding
+	for _, u := range unsafeSettings {
+		s, ok := byName[u.name]
+		if !ok || s.Setting != u.bad {
+			continue
+		}
+		good, _ := safeSettingValue(u.name)
+		findings = append(findings, Finding{
+			Type:     FindingSettings,
+			Severity: u.severity,
+			Message:  fmt.Sprintf("%s is %s: %s", u.name, u.bad, u.reason),
+			Detail:   settingDetail(s, fmt.Sprintf("ALTER SYSTEM SET %s = %s;", u.name, good)),
+		})
+	}
+
+	if s, ok := byName["shared_buffers"]; ok && db != nil && db.SizeBytes >= sharedBuffersDatasetBytes {
+		if size, ok := settingBytes(s); ok && size <= sharedBuffersMinBytes {
+			f := Finding{
+				Type:     FindingSettings,
+				Severity: SeverityMedium,
+				Message: fmt.Sprintf("shared_buffers is %s for a %s database; around a quarter of server memory is usual",
+					formatBytes(size), formatBytes(db.SizeBytes)),
+				Detail: settingDetail(s, ""),
+			}
+			f.Detail["value"] = formatBytes(size)
+			f.Detail["database_size"] = strconv.FormatInt(db.SizeBytes, 10)
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// settingDetail describes a setting and, if given, the statement that fixes it.
+func settingDetail(s postgres.SettingInfo, suggestion string) map[string]string {
+	detail := map[string]string{
+		"setting": s.Name,
+		"value":   s.Setting,
+		"source":  s.Source,
+	}
+	if suggestion != "" {
+		detail["suggestion"] = suggestion
+	}
+	return detail
+}
+
+// settingBytes converts a memory setting from its unit to bytes.
+func settingBytes(s postgres.SettingInfo) (int64, bool) {
+	m := settingUnitRe.FindStringSubmatch(s.Unit)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s.Setting, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[1] != "" {
+		n, _ := strconv.ParseInt(m[1], 10, 64)
+		v *= n
+	}
+	switch m[2] {
+	case "kB":
+		v <<= 10
+	case "MB":
+		v <<= 20
+	case "GB":
+		v <<= 30
+	case "TB":
+		v <<= 40
+	}
+	return v, true
+}
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectSettings(t *testing.T) {
+	settings := []postgres.SettingInfo{
+		{Name: "autovacuum", Setting: "off", Source: "configuration file"},
+		{Name: "fsync", Setting: "on", Source: "default"},
+		{Name: "full_page_writes", Setting: "off", Source: "command line"},
+		{Name: "shared_buffers", Setting: "16384", Unit: "8kB", Source: "configuration file"},
+		{Name: "track_counts", Setting: "on", Source: "default"},
+		{Name: "zero_damaged_pages", Setting: "off", Source: "default"},
+	}
+	db := &postgres.DatabaseStats{SizeBytes: 50 * 1024 * 1024 * 1024}
+
+	findings := detectSettings(settings, db)
+	got := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Type != FindingSettings {
+			t.Errorf("type = %s, want SETTINGS", f.Type)
+		}
+		got[f.Detail["setting"]] = f
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	if f := got["full_page_writes"]; f.Severity != SeverityHigh || f.Detail["suggestion"] != "ALTER SYSTEM SET full_page_writes = on;" || f.Detail["source"] != "command line" {
+		t.Errorf("full_page_writes: %+v", f)
+	}
+	if f := got["autovacuum"]; f.Severity != SeverityHigh {
+		t.Errorf("autovacuum: %+v", f)
+	}
+	if f := got["shared_buffers"]; f.Severity != SeverityMedium || f.Detail["value"] != "128.0 MB" {
+		t.Errorf("shared_buffers: %+v", f)
+	}
+
+	// Default shared_buffers is fine for a small database.
+	if findings := detectSettings(settings[3:4], &postgres.DatabaseStats{SizeBytes: 1 << 30}); len(findings) != 0 {
+		t.Errorf("small database: %+v", findings)
+	}
+}
+
+func TestSettingBytes(t *testing.T) {
+	tests := []struct {
+		setting, unit string
+		want          int64
+		ok            bool
+	}{
+		{"16384", "8kB", 128 * 1024 * 1024, true},
+		{"65536", "kB", 64 * 1024 * 1024, true},
+		{"1024", "MB", 1 << 30, true},
+		{"64", "16MB", 1 << 30, true},
+		{"200", "ms", 0, false},
+		{"on", "", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := settingBytes(postgres.SettingInfo{Setting: tt.setting, Unit: tt.unit})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("settingBytes(%s %s) = %d, %v; want %d, %v", tt.setting, tt.unit, got, ok, tt.want, tt.ok)
+		}
+	}
+}
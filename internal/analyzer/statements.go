@@ -0,0 +1,212 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+const (
+	// hotQueryMinCalls skips statements run too rarely for their plan to matter.
+	hotQueryMinCalls = 100
+	// seqScanQueryMinBlocks skips tables under 10 MB of heap, which are
+	// cheap to scan.
+	seqScanQueryMinBlocks = 1280
+	// blockSize is PostgreSQL's default page size.
+	blockSize = 8192
+)
+
+// tableWorkload is what pg_stat_statements recorded against one table.
+type tableWorkload struct {
+	statements int
+	calls      int64
+}
+
+// statementResolver maps table names in query text onto snapshot tables.
+type statementResolver struct {
+	byKey  map[string]string   // lowercase schema.table -> table key
+	byName map[string][]string // lowercase table -> table keys
+}
+
+func newStatementResolver(tables []postgres.TableInfo) statementResolver {
+	r := statementResolver{
+		byKey:  make(map[string]string, len(tables)),
+		byName: make(map[string][]string, len(tables)),
+	}
+	for _, t := range tables {
+		key := tableKey(t.Schema, t.Name)
+		r.byKey[strings.ToLower(key)] = key
+		r.byName[strings.ToLower(t.Name)] = append(r.byName[strings.ToLower(t.Name)], key)
+	}
+	return r
+}
+
+// tables returns the keys of the tables a query reads or writes. An
+// unqualified name matching tables in several schemas resolves to the one
+// in public, or to none.
+func (r statementResolver) tables(query string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range scanner.ScanLine(strings.Join(strings.Fields(query), " ")) {
+		if m.Pattern != scanner.PatternSQL {
+			continue
+		}
+		var key string
+		if m.Schema != "" {
+			key = r.byKey[strings.ToLower(m.Schema+"."+m.Table)]
+		} else if candidates := r.byName[strings.ToLower(m.Table)]; len(candidates) == 1 {
+			key = candidates[0]
+		} else {
+			key = r.byKey["public."+strings.ToLower(m.Table)]
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// statementWorkload totals the tracked statements touching each table.
+func statementWorkload(stmts []postgres.StatStatement, tables []postgres.TableInfo) map[string]*tableWorkload {
+	resolver := newStatementResolver(tables)
+	workload := make(map[string]*tableWorkload)
+	for _, s := range stmts {
+		for _, key := range resolver.tables(s.Query) {
+			w := workload[key]
+			if w == nil {
+				w = &tableWorkload{}
+				workload[key] = w
+			}
+			w.statements++
+			w.calls += s.Calls
+		}
+	}
+	return workload
+}
+
+// detectSeqScanQueries flags frequent statements that touch about as many
+// blocks per call as a table they read has, which means a sequential scan,
+// while returning a small part of it.
+func detectSeqScanQueries(stmts []postgres.StatStatement, tables []postgres.TableInfo, indexes []postgres.IndexInfo) []Finding {
+	if len(stmts) == 0 {
+		return nil
+	}
+	indexBytes := make(map[string]int64)
+	for _, idx := range indexes {
+		indexBytes[tableKey(idx.Schema, idx.Table)] += idx.SizeBytes
+	}
+	tableByKey := make(map[string]postgres.TableInfo, len(tables))
+	for _, t := range tables {
+		tableByKey[tableKey(t.Schema, t.Name)] = t
+	}
+	resolver := newStatementResolver(tables)
+
+	var findings []Finding
+	for _, s := range stmts {
+		if s.Calls < hotQueryMinCalls {
+			continue
+		}
+		perCall := (s.SharedBlksHit + s.SharedBlksRead) / s.Calls
+		rowsPerCall := s.Rows / s.Calls
+
+		// Report the largest table the statement scans.
+		var scanned postgres.TableInfo
+		var scannedBlocks int64
+		for _, key := range resolver.tables(s.Query) {
+			t := tableByKey[key]
+			heap := (t.SizeBytes - t.ToastBytes - indexBytes[key]) / blockSize
+			if heap < seqScanQueryMinBlocks || perCall < heap/2 || rowsPerCall*10 > t.EstimatedRows {
+				continue
+			}
+			if heap > scannedBlocks {
+				scanned, scannedBlocks = t, heap
+			}
+		}
+		if scannedBlocks == 0 {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Type:     FindingSeqScanQuery,
+			Severity: SeverityMedium,
+			Schema:   scanned.Schema,
+			Table:    scanned.Name,
+			Message: fmt.Sprintf("query reads %s per call for %d rows, a sequential scan, and ran %d times",
+				formatBytes(perCall*blockSize), rowsPerCall, s.Calls),
+			Detail: map[string]string{
+				"query_id":        strconv.FormatInt(s.QueryID, 10),
+				"query":           truncateSQL(strings.Join(strings.Fields(s.Query), " ")),
+				"calls":           strconv.FormatInt(s.Calls, 10),
+				"mean_time_ms":    fmt.Sprintf("%.1f", s.TotalExecTime/float64(s.Calls)),
+				"blocks_per_call": strconv.FormatInt(perCall, 10),
+				"table_blocks":    strconv.FormatInt(scannedBlocks, 10),
+				"rows_per_call":   strconv.FormatInt(rowsPerCall, 10),
+			},
+		})
+	}
+	return findings
+}
+
+// detectUnscannedQueryTables flags tables the database workload queries
+// that the scanned code never mentions: another service or a part of the
+// codebase the scan didn't cover uses them.
+func detectUnscannedQueryTables(stmts []postgres.StatStatement, tables []postgres.TableInfo, codeRefs map[string]bool) []Finding {
+	if len(stmts) == 0 {
+		return nil
+	}
+	workload := statementWorkload(stmts, tables)
+	var findings []Finding
+	for _, t := range tables {
+		w := workload[tableKey(t.Schema, t.Name)]
+		if w == nil || codeRefs[strings.ToLower(t.Name)] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUnscannedQueryTable,
+			Severity: SeverityLow,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("table %q is queried by %d tracked statements but not referenced in code", t.Name, w.statements),
+			Detail: map[string]string{
+				"statements": strconv.Itoa(w.statements),
+				"calls":      strconv.FormatInt(w.calls, 10),
+			},
+		})
+	}
+	return findings
+}
+
+// annotateStatementWorkload checks UNUSED_TABLE and UNREFERENCED_TABLE
+// findings against pg_stat_statements. A table no tracked statement
+// touches is confirmed unused; one that statements do touch, as
+// insert-only tables are, drops to low severity. Only the most expensive
+// statements are tracked, so confirmation is strong evidence, not proof.
+func annotateStatementWorkload(findings []Finding, stmts []postgres.StatStatement, tables []postgres.TableInfo) {
+	if len(stmts) == 0 {
+		return
+	}
+	workload := statementWorkload(stmts, tables)
+	for i := range findings {
+		f := &findings[i]
+		if f.Type != FindingUnusedTable && f.Type != FindingUnreferencedTable {
+			continue
+		}
+		if f.Detail == nil {
+			f.Detail = make(map[string]string)
+		}
+		w := workload[tableKey(f.Schema, f.Table)]
+		if w == nil {
+			f.Detail["statements_checked"] = strconv.Itoa(len(stmts))
+			continue
+		}
+		f.Severity = SeverityLow
+		f.Message += fmt.Sprintf(", but %d tracked statements ran %d times against it", w.statements, w.calls)
+		f.Detail["statements"] = strconv.Itoa(w.statements)
+		f.Detail["statement_calls"] = strconv.FormatInt(w.calls, 10)
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+)
+
+func TestStatementResolver(t *testing.T) {
+	r := newStatementResolver([]postgres.TableInfo{
+		{Schema: "public", Name: "orders"},
+		{Schema: "public", Name: "users"},
+		{Schema: "billing", Name: "users"},
+		{Schema: "billing", Name: "invoices"},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"SELECT * FROM orders o\n  JOIN users u ON u.id = o.user_id WHERE o.id = $1",
+			[]string{"public.orders", "public.users"}},
+		{"select id from billing.users where id = $1", []string{"billing.users"}},
+		{"INSERT INTO invoices (id) VALUES ($1)", []string{"billing.invoices"}},
+		{"SELECT extract(epoch FROM now())", nil},
+		{"SELECT * FROM pg_catalog.pg_class", nil},
+	}
+	for _, tt := range tests {
+		if got := r.tables(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("tables(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDetectSeqScanQueries(t *testing.T) {
+	tables := []postgres.TableInfo{
+		// 100 MB with 20 MB of indexes: 10240 heap blocks.
+		{Schema: "public", Name: "orders", SizeBytes: 100 * 1024 * 1024, EstimatedRows: 1000000},
+		{Schema: "public", Name: "tiny", SizeBytes: 1024 * 1024, EstimatedRows: 1000},
+	}
+	indexes := []postgres.IndexInfo{{Schema: "public", Table: "orders", Name: "orders_pkey", SizeBytes: 20 * 1024 * 1024}}
+	stmts := []postgres.StatStatement{
+		{QueryID: 1, Query: "SELECT * FROM orders WHERE status = $1", Calls: 1000, Rows: 5000, SharedBlksHit: 9000000, SharedBlksRead: 1000000, TotalExecTime: 250000},
+		{QueryID: 2, Query: "SELECT * FROM orders WHERE id = $1", Calls: 1000, Rows: 1000, SharedBlksHit: 4000},
+		{QueryID: 3, Query: "SELECT * FROM orders WHERE note LIKE $1", Calls: 5, Rows: 5, SharedBlksHit: 50000},
+		{QueryID: 4, Query: "SELECT * FROM orders", Calls: 1000, Rows: 1000000000, SharedBlksHit: 10000000},
+		{QueryID: 5, Query: "SELECT * FROM tiny WHERE x = $1", Calls: 1000, Rows: 1000, SharedBlksHit: 128000},
+	}
+
+	findings := detectSeqScanQueries(stmts, tables, indexes)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingSeqScanQuery || f.Table != "orders" || f.Detail["query_id"] != "1" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["blocks_per_call"] != "10000" || f.Detail["table_blocks"] != "10240" || f.Detail["mean_time_ms"] != "250.0" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+}
+
+func TestDetectUnscannedQueryTables(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "users"},
+		{Schema: "public", Name: "audit_log"},
+		{Schema: "public", Name: "legacy"},
+	}
+	stmts := []postgres.StatStatement{
+		{Query: "SELECT * FROM users WHERE id = $1", Calls: 10},
+		{Query: "INSERT INTO audit_log (msg) VALUES ($1)", Calls: 40},
+		{Query: "SELECT count(*) FROM audit_log", Calls: 2},
+	}
+
+	findings := detectUnscannedQueryTables(stmts, tables, map[string]bool{"users": true})
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Table != "audit_log" || f.Detail["statements"] != "2" || f.Detail["calls"] != "42" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	if got := detectUnscannedQueryTables(nil, tables, nil); got != nil {
+		t.Errorf("without statements: got %+v", got)
+	}
+}
+
+func TestAudit_StatementWorkload(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "events").PrimaryKey("id")
+	b.Table("public", "legacy").PrimaryKey("id")
+	snap := b.Build()
+
+	findings := Audit(snap, AuditOptions{Checks: []FindingType{FindingUnusedTable}})
+	for _, f := range findings {
+		if _, ok := f.Detail["statements_checked"]; ok {
+			t.Errorf("%s: annotated without pg_stat_statements", f.Table)
+		}
+	}
+
+	snap.Statements = []postgres.StatStatement{
+		{Query: "INSERT INTO events (id, kind) VALUES ($1, $2)", Calls: 5000},
+		{Query: "SELECT 1", Calls: 100},
+	}
+	findings = Audit(snap, AuditOptions{Checks: []FindingType{FindingUnusedTable}})
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	for _, f := range findings {
+		switch f.Table {
+		case "events":
+			if f.Severity != SeverityLow || f.Detail["statement_calls"] != "5000" {
+				t.Errorf("events: %+v", f)
+			}
+		case "legacy":
+			if f.Severity != SeverityHigh || f.Detail["statements_checked"] != "2" {
+				t.Errorf("legacy: %+v", f)
+			}
+		}
+	}
+}
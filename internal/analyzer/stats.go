@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+const (
+	// lowSelectivityMaxDistinct is the largest number of distinct values for
+	// which a single-column btree index is considered low selectivity.
+	lowSelectivityMaxDistinct = 10
+	// statsMinRows skips small tables where statistics-based findings don't matter.
+	statsMinRows = 10000
+)
+
+// columnKey builds a lookup key for a schema-qualified column.
+func columnKey(schema, table, column string) string {
+	return schema + "." + table + "." + column
+}
+
+// buildColumnStatsMap indexes column statistics by schema.table.column.
+func buildColumnStatsMap(stats []postgres.ColumnStats) map[string]postgres.ColumnStats {
+	m := make(map[string]postgres.ColumnStats, len(stats))
+	for _, cs := range stats {
+		m[columnKey(cs.Schema, cs.Table, cs.Column)] = cs
+	}
+	return m
+}
+
+// distinctValues converts pg_stats.n_distinct to an absolute estimate.
+// Negative values are a fraction of the row count.
+func distinctValues(nDistinct float64, rows int64) float64 {
+	if nDistinct < 0 {
+		return -nDistinct * float64(rows)
+	}
+	return nDistinct
+}
+
+// singleIndexColumn returns the column of a plain single-column btree index.
+// Expression, multi-column, partial, and non-btree indexes return false.
+func singleIndexColumn(def string) (string, bool) {
+	upper := strings.ToUpper(def)
+	if strings.Contains(upper, " WHERE ") {
+		return "", false
+	}
+	if strings.Contains(upper, " USING ") && !strings.Contains(upper, " USING BTREE") {
+		return "", false
+	}
+	m := indexColumnRe.FindStringSubmatch(def)
+	if len(m) < 2 || strings.ContainsAny(m[1], ",(") {
+		return "", false
+	}
+	cols := parseIndexColumns(def)
+	if len(cols) != 1 {
+		return "", false
+	}
+	return strings.Trim(cols[0], `"`), true
+}
+
+// detectLowSelectivityIndexes flags single-column btree indexes on large
+// tables whose column has only a handful of distinct values (booleans,
+// status flags). The planner rarely uses these, but every write pays for them.
+func detectLowSelectivityIndexes(indexes []postgres.IndexInfo, colStats map[string]postgres.ColumnStats, tableRows map[string]int64) []Finding {
+	var findings []Finding
+	for _, idx := range indexes {
+		if idx.IsUnique || isUniqueIndex(idx.Definition) {
+			continue
+		}
+		col, ok := singleIndexColumn(idx.Definition)
+		if !ok {
+			continue
+		}
+		rows := tableRows[tableKey(idx.Schema, idx.Table)]
+		if rows < statsMinRows {
+			continue
+		}
+		cs, ok := colStats[columnKey(idx.Schema, idx.Table, col)]
+		if !ok || cs.NDistinct == 0 {
+			continue
+		}
+		distinct := distinctValues(cs.NDistinct, rows)
+		if distinct > lowSelectivityMaxDistinct {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingLowSelectivityIndex,
+			Severity: SeverityLow,
+			Schema:   idx.Schema,
+			Table:    idx.Table,
+			Column:   col,
+			Index:    idx.Name,
+			Message:  fmt.Sprintf("index %q is on column %q with only ~%.0f distinct values", idx.Name, col, distinct),
+			Detail: map[string]string{
+				"n_distinct":     strconv.FormatFloat(cs.NDistinct, 'f', -1, 64),
+				"null_frac":      strconv.FormatFloat(cs.NullFrac, 'f', -1, 64),
+				"estimated_rows": strconv.FormatInt(rows, 10),
+				"size":           formatBytes(idx.SizeBytes),
+			},
+		})
+	}
+	return findings
+}
+
+// detectAlwaysNullColumns flags columns on large tables where pg_stats saw
+// no non-NULL values. These are usually leftovers that code no longer writes.
+func detectAlwaysNullColumns(stats []postgres.ColumnStats, tableRows map[string]int64) []Finding {
+	var findings []Finding
+	for _, cs := range stats {
+		if cs.NullFrac < 1 {
+			continue
+		}
+		rows := tableRows[tableKey(cs.Schema, cs.Table)]
+		if rows < statsMinRows {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingAlwaysNullColumn,
+			Severity: SeverityLow,
+			Schema:   cs.Schema,
+			Table:    cs.Table,
+			Column:   cs.Column,
+			Message:  fmt.Sprintf("column %q is NULL in every sampled row", cs.Column),
+			Detail: map[string]string{
+				"null_frac":      strconv.FormatFloat(cs.NullFrac, 'f', -1, 64),
+				"estimated_rows": strconv.FormatInt(rows, 10),
+			},
+		})
+	}
+	return findings
+}
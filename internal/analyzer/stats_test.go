@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestSingleIndexColumn(t *testing.T) {
+	tests := []struct {
+		def    string
+		want   string
+		wantOK bool
+	}{
+		{"CREATE INDEX idx ON public.users USING btree (status)", "status", true},
+		{"CREATE INDEX idx ON users (active)", "active", true},
+		{`CREATE INDEX idx ON public.users USING btree ("Status")`, "Status", true},
+		{"CREATE INDEX idx ON public.users USING btree (status, created_at)", "", false},
+		{"CREATE INDEX idx ON public.users USING btree (lower(email))", "", false},
+		{"CREATE INDEX idx ON public.users USING btree (status) WHERE (status = 'pending'::text)", "", false},
+		{"CREATE INDEX idx ON public.docs USING gin (tags)", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := singleIndexColumn(tt.def)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("singleIndexColumn(%q) = %q, %v; want %q, %v", tt.def, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDetectLowSelectivityIndexes(t *testing.T) {
+	tableRows := map[string]int64{"public.users": 1_000_000, "public.tiny": 50}
+	colStats := buildColumnStatsMap([]postgres.ColumnStats{
+		{Schema: "public", Table: "users", Column: "active", NDistinct: 2},
+		{Schema: "public", Table: "users", Column: "is_unique_visitor", NDistinct: 2},
+		{Schema: "public", Table: "users", Column: "email", NDistinct: -1},
+		{Schema: "public", Table: "users", Column: "status", NDistinct: -0.000004},
+		{Schema: "public", Table: "tiny", Column: "flag", NDistinct: 2},
+	})
+
+	tests := []struct {
+		name string
+		idx  postgres.IndexInfo
+		want int
+	}{
+		{"boolean column", makeIndex("public", "users", "idx_active", "CREATE INDEX idx_active ON public.users USING btree (active)", 8192, 10), 1},
+		{"negative n_distinct resolves to few values", makeIndex("public", "users", "idx_status", "CREATE INDEX idx_status ON public.users USING btree (status)", 8192, 10), 1},
+		{"high cardinality", makeIndex("public", "users", "idx_email", "CREATE INDEX idx_email ON public.users USING btree (email)", 8192, 10), 0},
+		{"unique index", makeIndex("public", "users", "uq_active", "CREATE UNIQUE INDEX uq_active ON public.users USING btree (active)", 8192, 10), 0},
+		{"unique in column name", makeIndex("public", "users", "idx_unique_visitor", "CREATE INDEX idx_unique_visitor ON public.users USING btree (is_unique_visitor)", 8192, 10), 1},
+		{"small table", makeIndex("public", "tiny", "idx_flag", "CREATE INDEX idx_flag ON public.tiny USING btree (flag)", 8192, 10), 0},
+		{"no stats", makeIndex("public", "users", "idx_other", "CREATE INDEX idx_other ON public.users USING btree (other)", 8192, 10), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := detectLowSelectivityIndexes([]postgres.IndexInfo{tt.idx}, colStats, tableRows)
+			if len(findings) != tt.want {
+				t.Fatalf("got %d findings, want %d", len(findings), tt.want)
+			}
+			for _, f := range findings {
+				if f.Type != FindingLowSelectivityIndex {
+					t.Errorf("expected type LOW_SELECTIVITY_INDEX, got %s", f.Type)
+				}
+				if f.Index != tt.idx.Name {
+					t.Errorf("index = %q, want %q", f.Index, tt.idx.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectAlwaysNullColumns(t *testing.T) {
+	tableRows := map[string]int64{"public.users": 1_000_000, "public.tiny": 50}
+	stats := []postgres.ColumnStats{
+		{Schema: "public", Table: "users", Column: "legacy_code", NullFrac: 1},
+		{Schema: "public", Table: "users", Column: "nickname", NullFrac: 0.98},
+		{Schema: "public", Table: "tiny", Column: "notes", NullFrac: 1},
+		{Schema: "public", Table: "unknown", Column: "x", NullFrac: 1},
+	}
+
+	findings := detectAlwaysNullColumns(stats, tableRows)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != FindingAlwaysNullColumn || f.Column != "legacy_code" || f.Severity != SeverityLow {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["estimated_rows"] != "1000000" {
+		t.Errorf("estimated_rows = %q", f.Detail["estimated_rows"])
+	}
+}
+
+func TestAudit_AlwaysNullColumnRespectsExclusions(t *testing.T) {
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "users", EstimatedRows: 50_000},
+		},
+		ColumnStats: []postgres.ColumnStats{
+			{Schema: "public", Table: "users", Column: "legacy_code", NullFrac: 1},
+		},
+	}
+
+	opts := DefaultAuditOptions()
+	if got := countType(Audit(snap, opts), FindingAlwaysNullColumn); got != 1 {
+		t.Fatalf("got %d ALWAYS_NULL_COLUMN findings, want 1", got)
+	}
+
+	opts.ExcludeTables = []string{"users"}
+	if got := countType(Audit(snap, opts), FindingAlwaysNullColumn); got != 0 {
+		t.Fatalf("excluded table still reported: %d findings", got)
+	}
+}
+
+func countType(findings []Finding, ft FindingType) int {
+	n := 0
+	for _, f := range findings {
+		if f.Type == ft {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// annotateStatsWindow caveats UNUSED_TABLE and UNUSED_INDEX findings when
+// the usage counters they rest on cover less than minDays, since a
+// statistics reset or a restart: a weekly job or a monthly report may not
+// have run yet. They drop to low severity. An unknown window (0) leaves
+// findings unchanged.
+func annotateStatsWindow(findings []Finding, db *postgres.DatabaseStats, minDays int) {
+	if db == nil || db.StatsWindowSeconds <= 0 {
+		return
+	}
+	window := seconds(db.StatsWindowSeconds)
+	if window >= time.Duration(minDays)*24*time.Hour {
+		return
+	}
+	caveat := fmt.Sprintf("stats window too short: usage statistics only cover %s", formatStatsWindow(window))
+	for i := range findings {
+		f := &findings[i]
+		if f.Type != FindingUnusedTable && f.Type != FindingUnusedIndex {
+			continue
+		}
+		if f.Detail == nil {
+			f.Detail = make(map[string]string)
+		}
+		f.Severity = SeverityLow
+		f.Message += " (" + caveat + ")"
+		f.Detail["caveat"] = caveat
+		f.Detail["stats_window"] = window.String()
+		if db.StatsReset != nil {
+			f.Detail["stats_reset"] = db.StatsReset.UTC().Format(time.RFC3339)
+		}
+	}
+}
+
+// formatStatsWindow renders a window in days and hours, or hours and
+// minutes when under a day.
+func formatStatsWindow(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestAnnotateStatsWindow(t *testing.T) {
+	newFindings := func() []Finding {
+		return []Finding{
+			{Type: FindingUnusedTable, Severity: SeverityHigh, Table: "events", Message: "table has no scans"},
+			{Type: FindingUnusedIndex, Severity: SeverityMedium, Index: "idx_events_at", Message: "index has no scans"},
+			{Type: FindingNoPrimaryKey, Severity: SeverityMedium, Table: "events"},
+		}
+	}
+	reset := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	findings := newFindings()
+	annotateStatsWindow(findings, &postgres.DatabaseStats{StatsReset: &reset, StatsWindowSeconds: 2*86400 + 3*3600}, 7)
+	for _, f := range findings[:2] {
+		if f.Severity != SeverityLow {
+			t.Errorf("%s severity = %s, want low", f.Type, f.Severity)
+		}
+		if f.Detail["caveat"] != "stats window too short: usage statistics only cover 2d3h" {
+			t.Errorf("%s caveat = %q", f.Type, f.Detail["caveat"])
+		}
+		if f.Detail["stats_reset"] != "2024-03-01T12:00:00Z" || !strings.Contains(f.Message, "stats window too short") {
+			t.Errorf("%s: %+v", f.Type, f)
+		}
+	}
+	if f := findings[2]; f.Severity != SeverityMedium || f.Detail != nil {
+		t.Errorf("NO_PRIMARY_KEY should be untouched: %+v", f)
+	}
+
+	// A long enough or unknown window leaves findings alone.
+	for _, db := range []*postgres.DatabaseStats{nil, {}, {StatsWindowSeconds: 30 * 86400}} {
+		findings := newFindings()
+		annotateStatsWindow(findings, db, 7)
+		if findings[0].Severity != SeverityHigh || findings[0].Detail != nil {
+			t.Errorf("window %+v: %+v", db, findings[0])
+		}
+	}
+}
+
+func TestFormatStatsWindow(t *testing.T) {
+	if got := formatStatsWindow(5*time.Hour + 30*time.Minute); got != "5h30m" {
+		t.Errorf("got %q, want 5h30m", got)
+	}
+	if got := formatStatsWindow(49 * time.Hour); got != "2d1h" {
+		t.Errorf("got %q, want 2d1h", got)
+	}
+}
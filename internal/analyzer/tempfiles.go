@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+const (
+	// querySpillMinBytes skips statements that wrote less than this to temp
+	// files in total.
+	querySpillMinBytes = 100 * 1024 * 1024
+	// databaseSpillMinBytes is the database-wide temp volume reported when
+	// pg_stat_statements can't say which statements wrote it.
+	databaseSpillMinBytes = 1024 * 1024 * 1024
+	// maxCodeLocations caps the code locations listed per finding.
+	maxCodeLocations = 5
+)
+
+// detectQuerySpills flags statements whose sorts and hashes outgrow
+// work_mem and spill to temp files. Without pg_stat_statements it falls
+// back to the database's pg_stat_database totals.
+func detectQuerySpills(stmts []postgres.StatStatement, db *postgres.DatabaseStats, tables []postgres.TableInfo) []Finding {
+	var workMem string
+	if db != nil {
+		workMem = db.WorkMem
+	}
+
+	if len(stmts) == 0 {
+		if db == nil || db.TempBytes < databaseSpillMinBytes {
+			return nil
+		}
+		return []Finding{{
+			Type:     FindingQuerySpill,
+			Severity: SeverityMedium,
+			Message: fmt.Sprintf("database wrote %s to %d temp files; install pg_stat_statements to see which queries spill",
+				formatBytes(db.TempBytes), db.TempFiles),
+			Detail: map[string]string{
+				"temp_files": strconv.FormatInt(db.TempFiles, 10),
+				"temp_bytes": strconv.FormatInt(db.TempBytes, 10),
+				"work_mem":   workMem,
+			},
+		}}
+	}
+
+	resolver := newStatementResolver(tables)
+	var findings []Finding
+	for _, s := range stmts {
+		written := s.TempBlksWritten * blockSize
+		if written < querySpillMinBytes || s.Calls == 0 {
+			continue
+		}
+		f := Finding{
+			Type:     FindingQuerySpill,
+			Severity: SeverityMedium,
+			Message: fmt.Sprintf("query wrote %s to temp files across %d calls (%s per call), exceeding work_mem",
+				formatBytes(written), s.Calls, formatBytes(written/s.Calls)),
+			Detail: map[string]string{
+				"query_id":      strconv.FormatInt(s.QueryID, 10),
+				"query":         truncateSQL(strings.Join(strings.Fields(s.Query), " ")),
+				"fingerprint":   scanner.Fingerprint(s.Query),
+				"calls":         strconv.FormatInt(s.Calls, 10),
+				"temp_written":  strconv.FormatInt(written, 10),
+				"temp_per_call": strconv.FormatInt(written/s.Calls, 10),
+			},
+		}
+		if workMem != "" {
+			f.Detail["work_mem"] = workMem
+		}
+		if keys := resolver.tables(s.Query); len(keys) > 0 {
+			schema, table, _ := strings.Cut(keys[0], ".")
+			f.Schema, f.Table = schema, table
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// annotateCodeLocations lists where in code the statement behind each
+// finding with a query fingerprint is issued.
+func annotateCodeLocations(findings []Finding, queries []scanner.QueryRef) {
+	if len(queries) == 0 {
+		return
+	}
+	sorted := append([]scanner.QueryRef(nil), queries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+	locations := make(map[string][]string)
+	for _, q := range sorted {
+		locations[q.Fingerprint] = append(locations[q.Fingerprint], q.File+":"+strconv.Itoa(q.Line))
+	}
+	for i := range findings {
+		f := &findings[i]
+		fp := f.Detail["fingerprint"]
+		locs := locations[fp]
+		if fp == "" || len(locs) == 0 {
+			continue
+		}
+		if len(locs) > maxCodeLocations {
+			locs = append(locs[:maxCodeLocations:maxCodeLocations], fmt.Sprintf("and %d more", len(locs)-maxCodeLocations))
+		}
+		f.Detail["code_locations"] = strings.Join(locs, ", ")
+	}
+}
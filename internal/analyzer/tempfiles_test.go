@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestDetectQuerySpills(t *testing.T) {
+	tables := []postgres.TableInfo{{Schema: "public", Name: "orders"}}
+	db := &postgres.DatabaseStats{Name: "app", TempFiles: 900, TempBytes: 5 << 30, WorkMem: "4MB"}
+	stmts := []postgres.StatStatement{
+		{QueryID: 7, Query: "SELECT * FROM orders ORDER BY total DESC", Calls: 50, TempBlksWritten: 64000},
+		{QueryID: 8, Query: "SELECT * FROM orders WHERE id = $1", Calls: 100000},
+	}
+
+	findings := detectQuerySpills(stmts, db, tables)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingQuerySpill || f.Table != "orders" || f.Detail["query_id"] != "7" || f.Detail["work_mem"] != "4MB" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["temp_per_call"] != "10485760" {
+		t.Errorf("temp_per_call = %s, want 10485760", f.Detail["temp_per_call"])
+	}
+
+	// Without pg_stat_statements, the database totals stand in.
+	findings = detectQuerySpills(nil, db, tables)
+	if len(findings) != 1 || findings[0].Table != "" || findings[0].Detail["temp_files"] != "900" {
+		t.Errorf("database fallback: got %+v", findings)
+	}
+	db.TempBytes = 1 << 20
+	if got := detectQuerySpills(nil, db, tables); len(got) != 0 {
+		t.Errorf("little temp usage: got %+v", got)
+	}
+}
+
+func TestAnnotateCodeLocations(t *testing.T) {
+	query := "SELECT * FROM orders ORDER BY total DESC LIMIT $1"
+	findings := []Finding{
+		{Type: FindingQuerySpill, Detail: map[string]string{"fingerprint": scanner.Fingerprint(query)}},
+		{Type: FindingQuerySpill, Detail: map[string]string{"fingerprint": scanner.Fingerprint("SELECT 1")}},
+		{Type: FindingUnusedTable},
+	}
+	var queries []scanner.QueryRef
+	for _, line := range []int{100, 14, 13, 12, 11, 10, 20} {
+		queries = append(queries, scanner.QueryRef{Fingerprint: scanner.Fingerprint("select * from orders order by total desc limit 20"), File: "api/orders.go", Line: line})
+	}
+
+	annotateCodeLocations(findings, queries)
+	want := "api/orders.go:10, api/orders.go:11, api/orders.go:12, api/orders.go:13, api/orders.go:14, and 2 more"
+	if got := findings[0].Detail["code_locations"]; got != want {
+		t.Errorf("code_locations = %q, want %q", got, want)
+	}
+	if _, ok := findings[1].Detail["code_locations"]; ok {
+		t.Error("unmatched query annotated")
+	}
+}
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// TOAST_BLOAT is judged on tables of at least toastMinBytes whose TOAST
+// relation makes up more than toastDominantPct of the total size.
+const (
+	toastMinBytes    = 100 * 1024 * 1024 // 100 MB
+	toastDominantPct = 50
+)
+
+// Rows are judged for width only once a table holds oversizedMinRows rows.
+// Columns averaging wideColumnBytes or more are named as candidates to move
+// out of the row, at most wideColumnsLimit of them.
+const (
+	oversizedMinRows = 1000
+	wideColumnBytes  = 1024
+	wideColumnsLimit = 3
+)
+
+// wideColumns returns the widest columns of each table, widest first.
+func wideColumns(colStats []postgres.ColumnStats) map[string][]postgres.ColumnStats {
+	m := make(map[string][]postgres.ColumnStats)
+	for _, cs := range colStats {
+		if cs.AvgWidth >= wideColumnBytes {
+			key := tableKey(cs.Schema, cs.Table)
+			m[key] = append(m[key], cs)
+		}
+	}
+	for key, cols := range m {
+		sort.Slice(cols, func(i, j int) bool {
+			if cols[i].AvgWidth != cols[j].AvgWidth {
+				return cols[i].AvgWidth > cols[j].AvgWidth
+			}
+			return cols[i].Column < cols[j].Column
+		})
+		if len(cols) > wideColumnsLimit {
+			m[key] = cols[:wideColumnsLimit]
+		}
+	}
+	return m
+}
+
+func wideColumnList(cols []postgres.ColumnStats) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprintf("%s (%s)", c.Column, formatBytes(c.AvgWidth))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// detectToastBloat flags large tables whose size is mostly TOAST: values
+// too big to keep in the row, often documents or blobs better kept in
+// object storage.
+func detectToastBloat(tables []postgres.TableInfo, wide map[string][]postgres.ColumnStats) []Finding {
+	var findings []Finding
+	for _, t := range tables {
+		if t.SizeBytes < toastMinBytes || t.ToastBytes*100 <= t.SizeBytes*toastDominantPct {
+			continue
+		}
+		pct := float64(t.ToastBytes) / float64(t.SizeBytes) * 100
+		detail := map[string]string{
+			"toast_size":  formatBytes(t.ToastBytes),
+			"table_size":  formatBytes(t.SizeBytes),
+			"toast_pct":   fmt.Sprintf("%.1f", pct),
+			"toast_bytes": strconv.FormatInt(t.ToastBytes, 10),
+		}
+		if cols := wide[tableKey(t.Schema, t.Name)]; len(cols) > 0 {
+			detail["wide_columns"] = wideColumnList(cols)
+		}
+		findings = append(findings, Finding{
+			Type:     FindingToastBloat,
+			Severity: SeverityMedium,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("TOAST holds %.0f%% of the table's %s; consider moving large values to object storage", pct, formatBytes(t.SizeBytes)),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// detectOversizedRows flags tables whose average row is wider than
+// maxWidth bytes, which leaves few rows per page and makes every scan read
+// more than it needs.
+func detectOversizedRows(tables []postgres.TableInfo, wide map[string][]postgres.ColumnStats, maxWidth int64) []Finding {
+	var findings []Finding
+	for _, t := range tables {
+		if t.EstimatedRows < oversizedMinRows || t.AvgRowWidth <= maxWidth {
+			continue
+		}
+		detail := map[string]string{
+			"avg_row_width": strconv.FormatInt(t.AvgRowWidth, 10),
+			"threshold":     strconv.FormatInt(maxWidth, 10),
+		}
+		if cols := wide[tableKey(t.Schema, t.Name)]; len(cols) > 0 {
+			detail["wide_columns"] = wideColumnList(cols)
+		}
+		findings = append(findings, Finding{
+			Type:     FindingOversizedRows,
+			Severity: SeverityLow,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("average row is %s wide; move large columns out of the row or to object storage", formatBytes(t.AvgRowWidth)),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectToastBloat(t *testing.T) {
+	const mb = 1024 * 1024
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "documents", SizeBytes: 800 * mb, ToastBytes: 700 * mb},
+		{Schema: "public", Name: "orders", SizeBytes: 800 * mb, ToastBytes: 100 * mb},
+		{Schema: "public", Name: "small", SizeBytes: 10 * mb, ToastBytes: 9 * mb},
+	}
+	wide := wideColumns([]postgres.ColumnStats{
+		{Schema: "public", Table: "documents", Column: "body", AvgWidth: 4096},
+		{Schema: "public", Table: "documents", Column: "title", AvgWidth: 40},
+	})
+
+	findings := detectToastBloat(tables, wide)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingToastBloat || f.Table != "documents" || f.Severity != SeverityMedium {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["toast_pct"] != "87.5" || f.Detail["wide_columns"] != "body (4.0 KB)" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+}
+
+func TestDetectOversizedRows(t *testing.T) {
+	tables := []postgres.TableInfo{
+		{Schema: "public", Name: "wide", EstimatedRows: 50000, AvgRowWidth: 6000},
+		{Schema: "public", Name: "narrow", EstimatedRows: 50000, AvgRowWidth: 120},
+		{Schema: "public", Name: "tiny", EstimatedRows: 10, AvgRowWidth: 9000},
+	}
+	wide := wideColumns([]postgres.ColumnStats{
+		{Schema: "public", Table: "wide", Column: "a", AvgWidth: 1500},
+		{Schema: "public", Table: "wide", Column: "b", AvgWidth: 3000},
+		{Schema: "public", Table: "wide", Column: "c", AvgWidth: 1200},
+		{Schema: "public", Table: "wide", Column: "d", AvgWidth: 1100},
+	})
+
+	findings := detectOversizedRows(tables, wide, 2048)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingOversizedRows || f.Table != "wide" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if got := f.Detail["wide_columns"]; got != "b (2.9 KB), a (1.5 KB), c (1.2 KB)" {
+		t.Errorf("wide_columns = %q", got)
+	}
+}
@@ -0,0 +1,277 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+var (
+	// bodyTableRe matches relations read or written by a function body.
+	// SELECT ... INTO is a variable assignment in PL/pgSQL, so only
+	// INSERT INTO counts.
+	bodyTableRe = regexp.MustCompile(`(?i)\b(FROM|JOIN|INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+((?:"[^"]+"|[a-z_][\w$]*)(?:\.(?:"[^"]+"|[a-z_][\w$]*))?)(\s*\()?`)
+	// bodyRecordColumnRe matches NEW.col and OLD.col.
+	bodyRecordColumnRe = regexp.MustCompile(`(?i)\b(?:NEW|OLD)\.("[^"]+"|[a-z_][\w$]*)`)
+	// sqlFuncFromRe matches an unclosed call whose arguments use FROM as a
+	// keyword, e.g. EXTRACT(EPOCH FROM ts) or TRIM(BOTH FROM s).
+	sqlFuncFromRe = regexp.MustCompile(`(?i)\b(?:extract|substring|trim|overlay|position)\s*\([^()]*$`)
+	bodyCommentRe = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	bodyStringRe  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// bodyDeclareRe matches the DECLARE section of a PL/pgSQL block.
+	bodyDeclareRe = regexp.MustCompile(`(?is)\bDECLARE\b(.*?)\bBEGIN\b`)
+	declNameRe    = regexp.MustCompile(`(?m)^\s*([a-z_][\w$]*)\s`)
+	bodyCTERe     = regexp.MustCompile(`(?i)(?:\bWITH(?:\s+RECURSIVE)?|,)\s+([a-z_][\w$]*)\s+AS\s*(?:NOT\s+)?(?:MATERIALIZED\s+)?\(`)
+)
+
+// detectDisabledTriggers flags triggers disabled with ALTER TABLE ...
+// DISABLE TRIGGER, often left behind by a bulk load or a migration.
+func detectDisabledTriggers(triggers []postgres.TriggerInfo) []Finding {
+	var findings []Finding
+	for _, tr := range triggers {
+		if tr.Enabled != postgres.TriggerDisabled {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingDisabledTrigger,
+			Severity: SeverityMedium,
+			Schema:   tr.Schema,
+			Table:    tr.Table,
+			Message:  fmt.Sprintf("trigger %q is disabled; %s never runs", tr.Name, tr.Function),
+			Detail: map[string]string{
+				"trigger":  tr.Name,
+				"function": tr.Function,
+			},
+		})
+	}
+	return findings
+}
+
+// detectBrokenTriggers flags PL/pgSQL and SQL trigger functions whose body
+// references tables, or NEW/OLD columns, that no longer exist. PostgreSQL
+// doesn't track these dependencies, so the error only surfaces when the
+// trigger fires. With a partial catalog only columns are checked.
+func detectBrokenTriggers(triggers []postgres.TriggerInfo, snap *postgres.Snapshot, partialCatalog bool) []Finding {
+	relations := make(map[string]bool)
+	schemas := make(map[string]bool)
+	addRelation := func(schema, name string) {
+		relations[name] = true
+		relations[schema+"."+name] = true
+		schemas[schema] = true
+	}
+	for _, t := range snap.Tables {
+		addRelation(t.Schema, t.Name)
+	}
+	for _, v := range snap.Views {
+		addRelation(v.Schema, v.Name)
+	}
+	for _, mv := range snap.MatViews {
+		addRelation(mv.Schema, mv.Name)
+	}
+	tableColumns := make(map[string]map[string]bool)
+	for _, c := range snap.Columns {
+		key := tableKey(c.Schema, c.Table)
+		if tableColumns[key] == nil {
+			tableColumns[key] = make(map[string]bool)
+		}
+		tableColumns[key][c.Name] = true
+	}
+
+	var findings []Finding
+	for _, tr := range triggers {
+		lang := strings.ToLower(tr.Language)
+		if lang != "plpgsql" && lang != "sql" {
+			continue
+		}
+		body := stripBody(tr.Body)
+
+		var missingTables []string
+		if !partialCatalog {
+			missingTables = missingBodyTables(body, relations, schemas)
+		}
+		var missingColumns []string
+		if cols := tableColumns[tableKey(tr.Schema, tr.Table)]; cols != nil {
+			seen := make(map[string]bool)
+			for _, m := range bodyRecordColumnRe.FindAllStringSubmatch(body, -1) {
+				col := normalizeIdent(m[1])
+				if !cols[col] && !seen[col] {
+					seen[col] = true
+					missingColumns = append(missingColumns, col)
+				}
+			}
+		}
+		if len(missingTables) == 0 && len(missingColumns) == 0 {
+			continue
+		}
+
+		var problems []string
+		detail := map[string]string{
+			"trigger":  tr.Name,
+			"function": tr.Function,
+		}
+		if len(missingTables) > 0 {
+			detail["missing_tables"] = strings.Join(missingTables, ", ")
+			problems = append(problems, "missing tables "+strings.Join(missingTables, ", "))
+		}
+		if len(missingColumns) > 0 {
+			detail["missing_columns"] = strings.Join(missingColumns, ", ")
+			problems = append(problems, fmt.Sprintf("columns not on %q: %s", tr.Table, strings.Join(missingColumns, ", ")))
+		}
+		findings = append(findings, Finding{
+			Type:     FindingBrokenTrigger,
+			Severity: SeverityMedium,
+			Schema:   tr.Schema,
+			Table:    tr.Table,
+			Message:  fmt.Sprintf("trigger %q function %s references %s", tr.Name, tr.Function, strings.Join(problems, "; ")),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// stripBody removes comments and string literals from a function body so
+// their contents aren't mistaken for references.
+func stripBody(body string) string {
+	body = bodyCommentRe.ReplaceAllString(body, " ")
+	return bodyStringRe.ReplaceAllString(body, "''")
+}
+
+// missingBodyTables returns the relations a function body references that
+// are not in relations. Names qualified with a schema outside the
+// snapshot are ignored.
+func missingBodyTables(body string, relations, schemas map[string]bool) []string {
+	var missing []string
+	for _, rel := range bodyRelations(body) {
+		if rel.schema != "" && !schemas[rel.schema] {
+			continue
+		}
+		if name := rel.String(); !relations[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// bodyRelation is a relation named in a function body, with its schema
+// if qualified.
+type bodyRelation struct {
+	schema, name string
+}
+
+func (r bodyRelation) String() string {
+	if r.schema == "" {
+		return r.name
+	}
+	return r.schema + "." + r.name
+}
+
+// bodyRelations returns the relations a function body reads or writes, in
+// order and without repeats. Declared variables, CTE names, function
+// calls, and system catalogs are ignored.
+func bodyRelations(body string) []bodyRelation {
+	local := make(map[string]bool)
+	for _, m := range bodyDeclareRe.FindAllStringSubmatch(body, -1) {
+		for _, d := range declNameRe.FindAllStringSubmatch(m[1], -1) {
+			local[strings.ToLower(d[1])] = true
+		}
+	}
+	for _, m := range bodyCTERe.FindAllStringSubmatch(body, -1) {
+		local[strings.ToLower(m[1])] = true
+	}
+
+	seen := make(map[bodyRelation]bool)
+	var rels []bodyRelation
+	for _, loc := range bodyTableRe.FindAllStringSubmatchIndex(body, -1) {
+		keyword := strings.ToUpper(body[loc[2]:loc[3]])
+		if loc[6] >= 0 && !strings.HasPrefix(keyword, "INSERT") { // a function call
+			continue
+		}
+		if keyword == "FROM" && sqlFuncFromRe.MatchString(body[:loc[0]]) {
+			continue
+		}
+		if keyword == "FROM" && strings.HasSuffix(strings.ToUpper(strings.TrimSpace(body[:loc[0]])), "DISTINCT") {
+			continue
+		}
+
+		var parts []string
+		for _, p := range splitQualifiedIdent(body[loc[4]:loc[5]]) {
+			parts = append(parts, normalizeIdent(p))
+		}
+		first := parts[0]
+		if first == "new" || first == "old" || first == "pg_catalog" || first == "information_schema" ||
+			strings.HasPrefix(first, "pg_") || local[first] || sqlReservedWord(first) {
+			continue
+		}
+		rel := bodyRelation{name: parts[0]}
+		if len(parts) == 2 {
+			rel = bodyRelation{schema: parts[0], name: parts[1]}
+		}
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		rels = append(rels, rel)
+	}
+	return rels
+}
+
+// splitQualifiedIdent splits schema.name outside double quotes.
+func splitQualifiedIdent(s string) []string {
+	inQuote := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == '.' && !inQuote:
+			return []string{s[:i], s[i+1:]}
+		}
+	}
+	return []string{s}
+}
+
+// normalizeIdent folds an unquoted identifier to lower case and strips the
+// quotes from a quoted one.
+func normalizeIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return strings.ToLower(s)
+}
+
+// sqlReservedWord reports words that follow FROM or UPDATE in PL/pgSQL
+// without naming a relation.
+func sqlReservedWord(w string) bool {
+	switch w {
+	case "select", "only", "lateral", "values", "set", "where", "of", "skip", "nowait", "current_date", "current_timestamp":
+		return true
+	}
+	return false
+}
+
+// annotateTriggerCounts adds the number of user triggers on a finding's
+// table to its detail, since triggers change what writes to the table cost.
+func annotateTriggerCounts(findings []Finding, triggers []postgres.TriggerInfo) {
+	if len(triggers) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	for _, tr := range triggers {
+		counts[tableKey(tr.Schema, tr.Table)]++
+	}
+	for i := range findings {
+		f := &findings[i]
+		n := counts[tableKey(f.Schema, f.Table)]
+		if f.Table == "" || n == 0 {
+			continue
+		}
+		if f.Detail == nil {
+			f.Detail = make(map[string]string)
+		}
+		f.Detail["triggers"] = strconv.Itoa(n)
+	}
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectDisabledTriggers(t *testing.T) {
+	triggers := []postgres.TriggerInfo{
+		{Schema: "public", Table: "orders", Name: "orders_audit", Enabled: postgres.TriggerEnabled, Function: "public.audit()"},
+		{Schema: "public", Table: "orders", Name: "orders_touch", Enabled: postgres.TriggerDisabled, Function: "public.touch_order()"},
+		{Schema: "public", Table: "users", Name: "users_sync", Enabled: postgres.TriggerReplica, Function: "public.sync()"},
+	}
+	findings := detectDisabledTriggers(triggers)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != FindingDisabledTrigger || f.Severity != SeverityMedium || f.Table != "orders" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["trigger"] != "orders_touch" || f.Detail["function"] != "public.touch_order()" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+}
+
+func triggerSnapshot() *postgres.Snapshot {
+	return &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "orders"},
+			{Schema: "public", Name: "order_log"},
+			{Schema: "audit", Name: "events"},
+		},
+		Views: []postgres.ViewInfo{{Schema: "public", Name: "active_users"}},
+		Columns: []postgres.ColumnInfo{
+			{Schema: "public", Table: "orders", Name: "id"},
+			{Schema: "public", Table: "orders", Name: "status"},
+			{Schema: "public", Table: "orders", Name: "updated_at"},
+		},
+	}
+}
+
+func TestDetectBrokenTriggers(t *testing.T) {
+	body := `
+DECLARE
+  prev_total numeric;
+BEGIN
+  -- old code wrote to FROM legacy_log
+  WITH recent AS (SELECT id FROM public.order_log)
+  SELECT count(*) INTO prev_total FROM recent;
+  INSERT INTO order_history (order_id) VALUES (NEW.id);
+  PERFORM 1 FROM audit.events WHERE kind = 'FROM ghosts';
+  PERFORM extract(epoch FROM NEW.updated_at);
+  PERFORM 1 FROM generate_series(1, 3);
+  PERFORM 1 FROM pg_catalog.pg_class;
+  IF NEW.status IS DISTINCT FROM OLD.status THEN
+    UPDATE "Order_Log" SET id = NEW.id;
+    DELETE FROM active_users WHERE NEW.shipped_at IS NULL;
+  END IF;
+  PERFORM 1 FROM elsewhere.things;
+  RETURN NEW;
+END`
+	triggers := []postgres.TriggerInfo{
+		{Schema: "public", Table: "orders", Name: "orders_log", Function: "public.log_order()", Language: "plpgsql", Body: body},
+		{Schema: "public", Table: "orders", Name: "orders_ok", Function: "public.ok()", Language: "plpgsql", Body: "BEGIN UPDATE orders SET status = NEW.status; RETURN NEW; END"},
+		{Schema: "public", Table: "orders", Name: "orders_c", Function: "public.c_func()", Language: "c", Body: "SELECT 1 FROM missing"},
+	}
+
+	findings := detectBrokenTriggers(triggers, triggerSnapshot(), false)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingBrokenTrigger || f.Severity != SeverityMedium || f.Detail["trigger"] != "orders_log" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if got := f.Detail["missing_tables"]; got != "Order_Log, order_history" {
+		t.Errorf("missing_tables = %q", got)
+	}
+	if got := f.Detail["missing_columns"]; got != "shipped_at" {
+		t.Errorf("missing_columns = %q", got)
+	}
+
+	partial := detectBrokenTriggers(triggers, triggerSnapshot(), true)
+	if len(partial) != 1 {
+		t.Fatalf("partial catalog: got %d findings, want 1", len(partial))
+	}
+	if _, ok := partial[0].Detail["missing_tables"]; ok {
+		t.Errorf("partial catalog should skip missing tables: %v", partial[0].Detail)
+	}
+}
+
+func TestAnnotateTriggerCounts(t *testing.T) {
+	triggers := []postgres.TriggerInfo{
+		{Schema: "public", Table: "orders", Name: "a"},
+		{Schema: "public", Table: "orders", Name: "b"},
+	}
+	findings := []Finding{
+		{Type: FindingUnusedIndex, Schema: "public", Table: "orders", Index: "idx"},
+		{Type: FindingUnusedTable, Schema: "public", Table: "users"},
+		{Type: FindingMissingTable, Table: ""},
+	}
+	annotateTriggerCounts(findings, triggers)
+	if findings[0].Detail["triggers"] != "2" {
+		t.Errorf("orders triggers = %q, want 2", findings[0].Detail["triggers"])
+	}
+	if _, ok := findings[1].Detail["triggers"]; ok {
+		t.Error("table without triggers should not be annotated")
+	}
+	if findings[2].Detail != nil {
+		t.Error("finding without a table should not be annotated")
+	}
+}
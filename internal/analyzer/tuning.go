@@ -0,0 +1,205 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// tuningMinFindings is how many findings of a type a threshold must
+	// produce before raising it is suggested.
+	tuningMinFindings = 10
+	// tuningMinRemoved is the share of a type's findings a suggestion must
+	// remove to be worth making.
+	tuningMinRemoved = 0.5
+)
+
+// tuningSteps are the multiples of the current value tried, smallest first.
+var tuningSteps = []float64{2, 5, 10}
+
+// TuningSuggestion proposes raising a threshold that produces many findings
+// close to it.
+type TuningSuggestion struct {
+	Setting   string      `json:"setting"` // .pgspectre.yml key
+	Finding   FindingType `json:"finding"`
+	Current   string      `json:"current"`
+	Suggested string      `json:"suggested"`
+	Value     float64     `json:"value"`   // suggested value in the setting's units
+	Removes   int         `json:"removes"` // findings of this type the new value drops
+	Of        int         `json:"of"`      // findings of this type reported
+}
+
+// Tuning summarizes how much of a report is threshold noise.
+type Tuning struct {
+	// NoiseScore is the percentage of findings the suggestions would
+	// remove together.
+	NoiseScore  int                `json:"noiseScore"`
+	Noise       int                `json:"noise"`
+	Total       int                `json:"total"`
+	Suggestions []TuningSuggestion `json:"suggestions"`
+}
+
+// tunable is a threshold whose findings record the value compared to it.
+// Findings are reported when their value exceeds the threshold.
+type tunable struct {
+	setting string
+	finding FindingType
+	current func(AuditOptions) float64
+	value   func(f Finding, now time.Time) (float64, bool)
+	format  func(float64) string
+}
+
+var tunables = []tunable{
+	{
+		setting: "thresholds.unused_index_min_bytes",
+		finding: FindingUnusedIndex,
+		current: func(o AuditOptions) float64 { return float64(o.UnusedIndexMinBytes) },
+		value:   detailValue("size_bytes"),
+		format:  formatByteSetting,
+	},
+	{
+		setting: "thresholds.bloat_min_bytes",
+		finding: FindingBloatedIndex,
+		current: func(o AuditOptions) float64 { return float64(o.BloatMinBytes) },
+		value:   detailValue("index_size_bytes"),
+		format:  formatByteSetting,
+	},
+	{
+		setting: "thresholds.seq_scan_min_bytes",
+		finding: FindingHighSeqScan,
+		current: func(o AuditOptions) float64 { return float64(o.SeqScanMinBytes) },
+		value:   detailValue("table_size_bytes"),
+		format:  formatByteSetting,
+	},
+	{
+		setting: "thresholds.seq_scan_ratio",
+		finding: FindingHighSeqScan,
+		current: func(o AuditOptions) float64 { return o.SeqScanRatio },
+		value:   seqScanRatio,
+		format:  func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) },
+	},
+	{
+		setting: "thresholds.vacuum_days",
+		finding: FindingMissingVacuum,
+		current: func(o AuditOptions) float64 { return float64(o.VacuumDays) },
+		value:   daysSinceAutovacuum,
+		format:  formatIntSetting,
+	},
+	{
+		setting: "thresholds.row_width_bytes",
+		finding: FindingOversizedRows,
+		current: func(o AuditOptions) float64 { return float64(o.RowWidthBytes) },
+		value:   detailValue("avg_row_width"),
+		format:  formatIntSetting,
+	},
+	{
+		setting: "thresholds.wide_table_columns",
+		finding: FindingWideTable,
+		current: func(o AuditOptions) float64 { return float64(o.WideTableColumns) },
+		value:   detailValue("columns"),
+		format:  formatIntSetting,
+	},
+}
+
+// SuggestTuning finds thresholds that produce many findings and, for each,
+// the smallest raise (2x, 5x, or 10x) that would drop at least half of
+// them. It returns nil when no threshold is worth raising.
+func SuggestTuning(findings []Finding, opts AuditOptions, now time.Time) *Tuning {
+	opts = opts.withDefaults()
+
+	noise := make(map[int]bool)
+	var suggestions []TuningSuggestion
+	for _, t := range tunables {
+		var idx []int
+		var values []float64
+		total := 0
+		for i, f := range findings {
+			if f.Type != t.finding {
+				continue
+			}
+			total++
+			if v, ok := t.value(f, now); ok {
+				idx = append(idx, i)
+				values = append(values, v)
+			}
+		}
+		if total < tuningMinFindings {
+			continue
+		}
+
+		current := t.current(opts)
+		for _, step := range tuningSteps {
+			raised := current * step
+			var removed []int
+			for k, v := range values {
+				if v <= raised {
+					removed = append(removed, idx[k])
+				}
+			}
+			if float64(len(removed)) < tuningMinRemoved*float64(total) {
+				continue
+			}
+			for _, i := range removed {
+				noise[i] = true
+			}
+			suggestions = append(suggestions, TuningSuggestion{
+				Setting:   t.setting,
+				Finding:   t.finding,
+				Current:   t.format(current),
+				Suggested: t.format(raised),
+				Value:     raised,
+				Removes:   len(removed),
+				Of:        total,
+			})
+			break
+		}
+	}
+	if len(suggestions) == 0 {
+		return nil
+	}
+	return &Tuning{
+		NoiseScore:  int(math.Round(100 * float64(len(noise)) / float64(len(findings)))),
+		Noise:       len(noise),
+		Total:       len(findings),
+		Suggestions: suggestions,
+	}
+}
+
+// detailValue reads a numeric detail.
+func detailValue(key string) func(Finding, time.Time) (float64, bool) {
+	return func(f Finding, _ time.Time) (float64, bool) {
+		v, err := strconv.ParseFloat(f.Detail[key], 64)
+		return v, err == nil
+	}
+}
+
+// seqScanRatio is a HIGH_SEQ_SCAN finding's sequential to index scan ratio,
+// counting zero index scans as one as detectHighSeqScans does.
+func seqScanRatio(f Finding, _ time.Time) (float64, bool) {
+	seq, err1 := strconv.ParseFloat(f.Detail["seq_scan"], 64)
+	idx, err2 := strconv.ParseFloat(f.Detail["idx_scan"], 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return seq / math.Max(idx, 1), true
+}
+
+// daysSinceAutovacuum is how stale a MISSING_VACUUM finding's table is.
+// Tables never autovacuumed have no value and are never tuned away.
+func daysSinceAutovacuum(f Finding, now time.Time) (float64, bool) {
+	last, err := time.Parse(time.RFC3339, f.Detail["last_autovacuum"])
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(last).Hours() / 24, true
+}
+
+func formatByteSetting(v float64) string {
+	return fmt.Sprintf("%d (%s)", int64(v), formatBytes(int64(v)))
+}
+
+func formatIntSetting(v float64) string {
+	return strconv.FormatInt(int64(v), 10)
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSuggestTuning(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := DefaultAuditOptions() // unused_index_min_bytes: 100 MB
+
+	var findings []Finding
+	// 12 unused indexes: 8 under 200 MB, 4 at 1 GB.
+	for i := 0; i < 12; i++ {
+		size := int64(150 * 1024 * 1024)
+		if i >= 8 {
+			size = 1024 * 1024 * 1024
+		}
+		findings = append(findings, Finding{
+			Type:   FindingUnusedIndex,
+			Detail: map[string]string{"size_bytes": strconv.FormatInt(size, 10)},
+		})
+	}
+	// 10 stale tables, spread too widely for a 10x raise to halve them.
+	for i := 0; i < 10; i++ {
+		days := 40
+		if i >= 4 {
+			days = 400
+		}
+		findings = append(findings, Finding{
+			Type:   FindingMissingVacuum,
+			Detail: map[string]string{"last_autovacuum": now.AddDate(0, 0, -days).Format(time.RFC3339)},
+		})
+	}
+	// Too few to tune.
+	findings = append(findings, Finding{Type: FindingWideTable, Detail: map[string]string{"columns": "51"}})
+
+	tuning := SuggestTuning(findings, opts, now)
+	if tuning == nil {
+		t.Fatal("expected a tuning suggestion")
+	}
+	if len(tuning.Suggestions) != 1 {
+		t.Fatalf("got %d suggestions, want 1: %+v", len(tuning.Suggestions), tuning.Suggestions)
+	}
+	s := tuning.Suggestions[0]
+	if s.Setting != "thresholds.unused_index_min_bytes" || s.Removes != 8 || s.Of != 12 {
+		t.Errorf("unexpected suggestion: %+v", s)
+	}
+	if s.Suggested != "209715200 (200.0 MB)" {
+		t.Errorf("Suggested = %q", s.Suggested)
+	}
+	if tuning.Noise != 8 || tuning.Total != 23 || tuning.NoiseScore != 35 {
+		t.Errorf("noise = %d of %d (%d%%)", tuning.Noise, tuning.Total, tuning.NoiseScore)
+	}
+
+	if got := SuggestTuning(findings[12:], opts, now); got != nil {
+		t.Errorf("expected no suggestion, got %+v", got)
+	}
+}
@@ -1,5 +1,11 @@
 package analyzer
 
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
 // Severity indicates the risk level of a finding.
 type Severity string
 
@@ -14,20 +20,195 @@ const (
 type FindingType string
 
 const (
-	FindingUnusedTable       FindingType = "UNUSED_TABLE"
-	FindingUnusedIndex       FindingType = "UNUSED_INDEX"
-	FindingBloatedIndex      FindingType = "BLOATED_INDEX"
-	FindingMissingVacuum     FindingType = "MISSING_VACUUM"
-	FindingNoPrimaryKey      FindingType = "NO_PRIMARY_KEY"
-	FindingDuplicateIndex    FindingType = "DUPLICATE_INDEX"
-	FindingMissingTable      FindingType = "MISSING_TABLE"
-	FindingMissingColumn     FindingType = "MISSING_COLUMN"
-	FindingUnreferencedTable FindingType = "UNREFERENCED_TABLE"
-	FindingCodeMatch         FindingType = "CODE_MATCH"
-	FindingUnindexedQuery    FindingType = "UNINDEXED_QUERY"
-	FindingOK                FindingType = "OK"
+	FindingUnusedTable         FindingType = "UNUSED_TABLE"
+	FindingUnusedIndex         FindingType = "UNUSED_INDEX"
+	FindingBloatedIndex        FindingType = "BLOATED_INDEX"
+	FindingMissingVacuum       FindingType = "MISSING_VACUUM"
+	FindingNoPrimaryKey        FindingType = "NO_PRIMARY_KEY"
+	FindingDuplicateIndex      FindingType = "DUPLICATE_INDEX"
+	FindingInvalidIndex        FindingType = "INVALID_INDEX"
+	FindingSequenceExhaustion  FindingType = "SEQUENCE_EXHAUSTION"
+	FindingHighSeqScan         FindingType = "HIGH_SEQ_SCAN"
+	FindingSeqScanQuery        FindingType = "HOT_SEQ_SCAN_QUERY"
+	FindingSensitiveColumn     FindingType = "SENSITIVE_COLUMN"
+	FindingRiskyExtension      FindingType = "RISKY_EXTENSION"
+	FindingPublicGrant         FindingType = "PUBLIC_GRANT"
+	FindingSuperuserAppRole    FindingType = "SUPERUSER_APP_ROLE"
+	FindingAuditTableWrite     FindingType = "AUDIT_TABLE_WRITE_GRANT"
+	FindingMissingRLS          FindingType = "MISSING_RLS"
+	FindingLowSelectivityIndex FindingType = "LOW_SELECTIVITY_INDEX"
+	FindingAlwaysNullColumn    FindingType = "ALWAYS_NULL_COLUMN"
+	FindingNullableFKColumn    FindingType = "NULLABLE_FK_COLUMN"
+	FindingMissingForeignKey   FindingType = "MISSING_FOREIGN_KEY"
+	FindingFKTypeMismatch      FindingType = "FK_TYPE_MISMATCH"
+	FindingIncompleteBackfill  FindingType = "INCOMPLETE_BACKFILL"
+	FindingNotValidConstraint  FindingType = "NOT_VALIDATED_CONSTRAINT"
+	FindingUnloggedTable       FindingType = "UNLOGGED_TABLE"
+	FindingStaleMatView        FindingType = "STALE_MATVIEW"
+	FindingUnusedView          FindingType = "UNUSED_VIEW"
+	FindingBrokenView          FindingType = "BROKEN_VIEW"
+	FindingDisabledTrigger     FindingType = "DISABLED_TRIGGER"
+	FindingBrokenTrigger       FindingType = "BROKEN_TRIGGER"
+	FindingHotDefaultPartition FindingType = "HOT_DEFAULT_PARTITION"
+	FindingPartitionKeyIndex   FindingType = "MISSING_PARTITION_KEY_INDEX"
+	FindingPartitionIndexGap   FindingType = "INCONSISTENT_PARTITION_INDEXES"
+	FindingAutovacuumDisabled  FindingType = "AUTOVACUUM_DISABLED"
+	FindingLowHOTRatio         FindingType = "LOW_HOT_RATIO"
+	FindingLowCacheHit         FindingType = "LOW_CACHE_HIT"
+	FindingLowCacheHitRatio    FindingType = "LOW_CACHE_HIT_RATIO"
+	FindingQuerySpill          FindingType = "QUERY_SPILLS_TO_DISK"
+	FindingFrequentCheckpoints FindingType = "FREQUENT_CHECKPOINTS"
+	FindingBackendBufferWrites FindingType = "BACKEND_BUFFER_WRITES"
+	FindingIdleInTransaction   FindingType = "IDLE_IN_TRANSACTION"
+	FindingLockChain           FindingType = "LOCK_CHAIN"
+	FindingInactiveSlot        FindingType = "INACTIVE_REPLICATION_SLOT"
+	FindingReplicationLag      FindingType = "REPLICATION_LAG"
+	FindingArchiverFailing     FindingType = "ARCHIVER_FAILING"
+	FindingSettings            FindingType = "SETTINGS"
+	FindingToastBloat          FindingType = "TOAST_BLOAT"
+	FindingOversizedRows       FindingType = "OVERSIZED_ROWS"
+	FindingWideTable           FindingType = "WIDE_TABLE"
+	FindingProblemIdentifier   FindingType = "PROBLEMATIC_IDENTIFIER"
+	FindingNamingViolation     FindingType = "NAMING_VIOLATION"
+
+	FindingMigrationChange        FindingType = "MIGRATION_CHANGE"
+	FindingMigrationConflict      FindingType = "MIGRATION_CONFLICT"
+	FindingMigrationMissingGuard  FindingType = "MIGRATION_MISSING_GUARD"
+	FindingMigrationLock          FindingType = "MIGRATION_LOCK"
+	FindingMigrationBlockingIndex FindingType = "MIGRATION_BLOCKING_INDEX"
+	FindingDDLTableRewrite        FindingType = "DDL_TABLE_REWRITE"
+	FindingDDLFullScan            FindingType = "DDL_FULL_SCAN"
+	FindingRiskyMigration         FindingType = "RISKY_MIGRATION"
+	FindingMigrationRename        FindingType = "MIGRATION_RENAME"
+
+	FindingUnappliedTable   FindingType = "UNAPPLIED_TABLE"
+	FindingUnappliedColumn  FindingType = "UNAPPLIED_COLUMN"
+	FindingUnappliedIndex   FindingType = "UNAPPLIED_INDEX"
+	FindingUndeclaredTable  FindingType = "UNDECLARED_TABLE"
+	FindingUndeclaredColumn FindingType = "UNDECLARED_COLUMN"
+
+	FindingMissingTable        FindingType = "MISSING_TABLE"
+	FindingMissingColumn       FindingType = "MISSING_COLUMN"
+	FindingUnreferencedTable   FindingType = "UNREFERENCED_TABLE"
+	FindingCodeMatch           FindingType = "CODE_MATCH"
+	FindingUnindexedQuery      FindingType = "UNINDEXED_QUERY"
+	FindingMissingGINIndex     FindingType = "MISSING_GIN_INDEX"
+	FindingSuggestedIndex      FindingType = "SUGGESTED_INDEX"
+	FindingUnscannedQueryTable FindingType = "QUERY_TABLE_NOT_IN_CODE"
+	FindingSelectStar          FindingType = "SELECT_STAR"
+	FindingDynamicSQL          FindingType = "DYNAMIC_SQL"
+	FindingOK                  FindingType = "OK"
 )
 
+// findingTypeAliases maps legacy and renamed names to current finding types.
+var findingTypeAliases = map[string]FindingType{
+	"SCHEMA_DRIFT": FindingMissingColumn,
+}
+
+// findingTypeDescriptions is the registry of finding types pgspectre
+// reports, with a one-line description of each.
+var findingTypeDescriptions = map[FindingType]string{
+	FindingMissingTable:        "Table referenced in code does not exist in database",
+	FindingMissingColumn:       "Column referenced in code does not exist in table",
+	FindingUnusedTable:         "Table has no read activity (seq_scan=0, idx_scan=0)",
+	FindingUnreferencedTable:   "Table exists in database but not referenced in code",
+	FindingUnusedIndex:         "Index has never been used for scans",
+	FindingBloatedIndex:        "Index size exceeds table size",
+	FindingMissingVacuum:       "Table has not been vacuumed recently",
+	FindingNoPrimaryKey:        "Table has no primary key constraint",
+	FindingDuplicateIndex:      "Multiple indexes with same definition on same table",
+	FindingInvalidIndex:        "Index is invalid (failed CREATE INDEX CONCURRENTLY)",
+	FindingSequenceExhaustion:  "Sequence is close to exhausting its integer range",
+	FindingHighSeqScan:         "Large table is read mostly by sequential scans",
+	FindingSeqScanQuery:        "Frequent query reads a whole large table for a few rows (pg_stat_statements)",
+	FindingSensitiveColumn:     "Column name suggests sensitive data stored as plain text",
+	FindingRiskyExtension:      "Installed extension grants access beyond the database",
+	FindingPublicGrant:         "Table privileges are granted to PUBLIC, so every role has them",
+	FindingSuperuserAppRole:    "Application connects with a superuser role",
+	FindingAuditTableWrite:     "Role other than the owner can modify or erase an audit or log table",
+	FindingMissingRLS:          "Multi-tenant table lacks row-level security or has no policies",
+	FindingLowSelectivityIndex: "Single-column index on a column with very few distinct values",
+	FindingAlwaysNullColumn:    "Column is NULL in every sampled row",
+	FindingNullableFKColumn:    "Nullable foreign key column never contains NULLs",
+	FindingMissingForeignKey:   "Column name implies a reference to another table but no foreign key exists",
+	FindingFKTypeMismatch:      "Foreign key column type differs from the referenced column type",
+	FindingIncompleteBackfill:  "Column with a pending NOT NULL check still contains NULLs",
+	FindingNotValidConstraint:  "CHECK or foreign key constraint was added NOT VALID and never validated",
+	FindingUnloggedTable:       "Unlogged table holds data that is lost on crash",
+	FindingStaleMatView:        "Materialized view was never populated or is never read",
+	FindingUnusedView:          "View reads only tables that have no scans",
+	FindingBrokenView:          "View references an object that no longer exists",
+	FindingDisabledTrigger:     "Trigger is disabled and never fires",
+	FindingBrokenTrigger:       "Trigger function references tables or columns that no longer exist",
+	FindingHotDefaultPartition: "Default partition receives most writes to a partitioned table",
+	FindingPartitionKeyIndex:   "Partitioned table has no index leading with its partition key",
+	FindingPartitionIndexGap:   "Partition lacks an index that its sibling partitions have",
+	FindingAutovacuumDisabled:  "Table disables autovacuum or sets thresholds too lax for its dead tuples",
+	FindingLowHOTRatio:         "Heavily updated table has few heap-only (HOT) updates",
+	FindingLowCacheHit:         "Large, frequently read table is mostly read from disk rather than shared buffers",
+	FindingLowCacheHitRatio:    "Frequently read table has a low buffer cache hit ratio",
+	FindingQuerySpill:          "Query sorts or hashes exceed work_mem and spill to temp files",
+	FindingFrequentCheckpoints: "Most checkpoints are forced by WAL volume before checkpoint_timeout",
+	FindingBackendBufferWrites: "Client backends write out dirty buffers because the background writer falls behind",
+	FindingIdleInTransaction:   "Session has been idle in an open transaction, holding locks and blocking vacuum",
+	FindingLockChain:           "Session holds locks other sessions are waiting on",
+	FindingInactiveSlot:        "Inactive replication slot retains WAL the server can't recycle",
+	FindingReplicationLag:      "Replica has fallen behind in replaying WAL",
+	FindingArchiverFailing:     "WAL archiving is failing, so segments pile up in pg_wal",
+	FindingSettings:            "Server setting risks data loss, disables maintenance, or is far too small for the data",
+	FindingToastBloat:          "TOAST storage dominates the table's size",
+	FindingOversizedRows:       "Average row width exceeds the configured threshold",
+	FindingWideTable:           "Table has more columns than the configured threshold",
+	FindingProblemIdentifier:   "Table or column name is a reserved word, needs quoting, or is at the length limit",
+	FindingNamingViolation:     "Object name does not match the configured naming convention",
+	FindingMissingGINIndex:     "jsonb column filtered in code has no GIN index",
+	FindingSuggestedIndex:      "Frequent queries filter on columns no index leads with (pg_stat_statements)",
+	FindingUnindexedQuery:      "Column filtered, joined, or sorted on in code has no index",
+	FindingUnscannedQueryTable: "Table is queried by the database workload but not referenced in code",
+	FindingSelectStar:          "Code reads the table with SELECT *, which breaks when its columns change",
+	FindingDynamicSQL:          "Code builds a table name at runtime, so drift analysis can't verify it",
+	FindingCodeMatch:           "Table reference in code matches database table",
+	FindingOK:                  "No issues detected",
+
+	FindingMigrationChange:        "Object created, dropped, or altered by a migration",
+	FindingMigrationConflict:      "Migration statement conflicts with the current schema and will fail",
+	FindingMigrationMissingGuard:  "Migration DDL lacks an IF EXISTS / IF NOT EXISTS guard",
+	FindingMigrationLock:          "Migration statement takes a heavy lock on an existing table",
+	FindingMigrationBlockingIndex: "CREATE INDEX without CONCURRENTLY on an existing table",
+	FindingDDLTableRewrite:        "Migration DDL rewrites an existing table under lock",
+	FindingDDLFullScan:            "Migration DDL scans an existing table under lock",
+	FindingRiskyMigration:         "Migration takes locks without lock_timeout or statement_timeout set",
+	FindingMigrationRename:        "Migration renames a table or column in place instead of expand-contract",
+
+	FindingUnappliedTable:   "Table declared by the schema file is missing from the database",
+	FindingUnappliedColumn:  "Column declared by the schema file is missing from the database",
+	FindingUnappliedIndex:   "Index declared by the schema file is missing from the database",
+	FindingUndeclaredTable:  "Table exists in the database but not in the schema file",
+	FindingUndeclaredColumn: "Column exists in the database but not in the schema file",
+}
+
+// KnownFindingType reports whether ft is a finding type pgspectre reports.
+func KnownFindingType(ft FindingType) bool {
+	_, ok := findingTypeDescriptions[ft]
+	return ok
+}
+
+// FindingTypeDescription returns a one-line description of ft, or "" if it
+// is not a known finding type.
+func FindingTypeDescription(ft FindingType) string {
+	return findingTypeDescriptions[ft]
+}
+
+// CanonicalFindingType normalizes a user-supplied finding type name: case,
+// surrounding space, and aliases. An empty name stays empty.
+func CanonicalFindingType(t string) FindingType {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if alias, ok := findingTypeAliases[t]; ok {
+		return alias
+	}
+	return FindingType(t)
+}
+
 // Finding represents a single audit or check result.
 type Finding struct {
 	Type     FindingType       `json:"type"`
@@ -38,23 +219,73 @@ type Finding struct {
 	Index    string            `json:"index,omitempty"`
 	Message  string            `json:"message"`
 	Detail   map[string]string `json:"detail,omitempty"`
+	Effort   Effort            `json:"effort,omitempty"`
+	// Confidence is how strongly the evidence supports the finding; see
+	// annotateConfidence.
+	Confidence Confidence `json:"confidence,omitempty"`
+	// Related lists findings that share a root cause with this one; see
+	// Correlate.
+	Related []FindingRef `json:"related,omitempty"`
 }
 
 // AuditOptions controls thresholds and exclusions for analysis.
 type AuditOptions struct {
-	VacuumDays          int
-	UnusedIndexMinBytes int64
-	BloatMinBytes       int64
-	ExcludeTables       []string
-	ExcludeSchemas      []string
+	VacuumDays            int
+	UnusedIndexMinBytes   int64
+	BloatMinBytes         int64
+	SequenceExhaustionPct int
+	SeqScanMinBytes       int64
+	SeqScanRatio          float64
+	CacheHitRatio         float64
+	RowWidthBytes         int64
+	WideTableColumns      int
+	// HotTableBlocks is the number of heap and index block requests that
+	// makes a table hot enough for LOW_CACHE_HIT_RATIO.
+	HotTableBlocks int64
+	// IdleTransactionSeconds is how long a session may sit idle in a
+	// transaction before it is reported; only checked with --activity.
+	IdleTransactionSeconds int
+	// MinStatsWindowDays is how long usage statistics must have been
+	// collecting, since a reset or restart, for UNUSED_TABLE and
+	// UNUSED_INDEX to keep their severity.
+	MinStatsWindowDays int
+	// FKColumnPatterns are globs with a single '*' matching columns that
+	// look like foreign keys; the '*' part names the referenced table.
+	FKColumnPatterns []string
+	// TenantTables are globs on table names, or schema.table, of
+	// multi-tenant tables that must enforce row-level security.
+	TenantTables []string
+	// Naming holds the configured naming conventions; empty by default.
+	Naming         NamingConventions
+	ExcludeTables  []string
+	ExcludeSchemas []string
+	// Checks limits analysis to these finding types. Empty means all
+	// default checks; opt-in checks run only when listed here.
+	Checks []FindingType
+	// ServerMajor is the server's PostgreSQL major version, used to skip
+	// unsupported checks and add version-specific advice; 0 means unknown.
+	ServerMajor int
+	// PartialCatalog is set when the snapshot was restricted to some tables,
+	// so a relation missing from it may still exist.
+	PartialCatalog bool
 }
 
 // DefaultAuditOptions returns sensible defaults matching the config defaults.
 func DefaultAuditOptions() AuditOptions {
 	return AuditOptions{
-		VacuumDays:          30,
-		UnusedIndexMinBytes: 100 * 1024 * 1024, // 100 MB
-		BloatMinBytes:       1024 * 1024,       // 1 MB
+		VacuumDays:             30,
+		UnusedIndexMinBytes:    100 * 1024 * 1024, // 100 MB
+		BloatMinBytes:          1024 * 1024,       // 1 MB
+		SequenceExhaustionPct:  70,
+		SeqScanMinBytes:        100 * 1024 * 1024, // 100 MB
+		SeqScanRatio:           10,
+		CacheHitRatio:          0.9,
+		HotTableBlocks:         1000000,
+		RowWidthBytes:          2048,
+		WideTableColumns:       50,
+		IdleTransactionSeconds: 300,
+		MinStatsWindowDays:     7,
+		FKColumnPatterns:       []string{"*_id"},
 	}
 }
 
@@ -65,6 +296,22 @@ var severityOrder = map[Severity]int{
 	SeverityHigh:   3,
 }
 
+// SortFindings orders findings by type, schema, table, column, and index,
+// then message, so identical runs produce identical reports regardless of
+// map iteration or goroutine scheduling.
+func SortFindings(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.Schema, b.Schema),
+			cmp.Compare(a.Table, b.Table),
+			cmp.Compare(a.Column, b.Column),
+			cmp.Compare(a.Index, b.Index),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+}
+
 // MaxSeverity returns the highest severity among findings.
 func MaxSeverity(findings []Finding) Severity {
 	max := SeverityInfo
@@ -52,3 +52,46 @@ func TestExitCode(t *testing.T) {
 		})
 	}
 }
+
+func TestSortFindings(t *testing.T) {
+	findings := []Finding{
+		{Type: FindingUnusedTable, Schema: "public", Table: "orders"},
+		{Type: FindingMissingColumn, Schema: "public", Table: "users", Column: "name"},
+		{Type: FindingUnusedIndex, Schema: "public", Table: "users", Index: "idx_b"},
+		{Type: FindingUnusedIndex, Schema: "public", Table: "users", Index: "idx_a"},
+		{Type: FindingMissingColumn, Schema: "public", Table: "users", Column: "email"},
+		{Type: FindingUnusedTable, Schema: "app", Table: "orders"},
+	}
+	SortFindings(findings)
+
+	want := []string{
+		"MISSING_COLUMN public.users.email",
+		"MISSING_COLUMN public.users.name",
+		"UNUSED_INDEX public.users.idx_a",
+		"UNUSED_INDEX public.users.idx_b",
+		"UNUSED_TABLE app.orders.",
+		"UNUSED_TABLE public.orders.",
+	}
+	for i, f := range findings {
+		got := string(f.Type) + " " + f.Schema + "." + f.Table + "." + f.Column + f.Index
+		if got != want[i] {
+			t.Errorf("findings[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestFindingTypeRegistry(t *testing.T) {
+	for ft := range defaultEffort {
+		if !KnownFindingType(ft) || FindingTypeDescription(ft) == "" {
+			t.Errorf("%s has a default effort but is not registered", ft)
+		}
+	}
+	for alias, ft := range findingTypeAliases {
+		if !KnownFindingType(ft) {
+			t.Errorf("alias %s maps to unregistered %s", alias, ft)
+		}
+	}
+	if KnownFindingType("NOT_A_TYPE") || FindingTypeDescription("NOT_A_TYPE") != "" {
+		t.Error("unknown type should not be registered")
+	}
+}
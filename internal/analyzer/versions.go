@@ -0,0 +1,84 @@
+package analyzer
+
+// versionRange is an inclusive range of PostgreSQL major versions; zero
+// bounds are open.
+type versionRange struct {
+	min, max int
+}
+
+func (r versionRange) contains(major int) bool {
+	return (r.min == 0 || major >= r.min) && (r.max == 0 || major <= r.max)
+}
+
+// versionAdvice is remediation advice that applies to some server versions.
+type versionAdvice struct {
+	versions versionRange
+	text     string
+}
+
+// versionRule declares the server versions a check supports and the advice
+// its findings get on particular versions.
+type versionRule struct {
+	supported versionRange
+	advice    []versionAdvice
+}
+
+var ioAdvice = []versionAdvice{
+	{versionRange{min: 16}, "pg_stat_io shows whether disk reads come from client backends or from vacuum and checkpoints"},
+}
+
+var reindexAdvice = []versionAdvice{
+	{versionRange{}, "REINDEX INDEX CONCURRENTLY rebuilds it without blocking writes"},
+}
+
+var versionRules = map[FindingType]versionRule{
+	FindingUnusedTable: {advice: []versionAdvice{
+		{versionRange{min: 16}, "pg_stat_user_tables.last_seq_scan and last_idx_scan show when the table was last read"},
+	}},
+	FindingUnusedIndex: {advice: []versionAdvice{
+		{versionRange{min: 16}, "pg_stat_user_indexes.last_idx_scan shows when the index was last used"},
+	}},
+	FindingHighSeqScan: {advice: []versionAdvice{
+		{versionRange{min: 16}, "pg_stat_user_tables.last_seq_scan shows whether sequential scans are still happening"},
+	}},
+	FindingLowCacheHit:      {advice: ioAdvice},
+	FindingLowCacheHitRatio: {advice: ioAdvice},
+	FindingMissingVacuum: {advice: []versionAdvice{
+		{versionRange{max: 12}, "autovacuum never triggers on inserts alone before PostgreSQL 13; schedule VACUUM (FREEZE) for insert-only tables"},
+		{versionRange{min: 13}, "inserts also trigger autovacuum (autovacuum_vacuum_insert_threshold); check whether it is disabled for the table or blocked by long transactions"},
+	}},
+	FindingInvalidIndex: {advice: reindexAdvice},
+	FindingBloatedIndex: {advice: reindexAdvice},
+}
+
+// applyVersionRules drops findings from checks the server version doesn't
+// support and adds version-specific advice to the rest. An unknown version
+// (0) leaves findings unchanged.
+func applyVersionRules(findings []Finding, major int) []Finding {
+	if major == 0 {
+		return findings
+	}
+	out := findings[:0]
+	for _, f := range findings {
+		rule, ok := versionRules[f.Type]
+		if !ok {
+			out = append(out, f)
+			continue
+		}
+		if !rule.supported.contains(major) {
+			continue
+		}
+		for _, a := range rule.advice {
+			if !a.versions.contains(major) {
+				continue
+			}
+			if f.Detail == nil {
+				f.Detail = make(map[string]string)
+			}
+			f.Detail["version_advice"] = a.text
+			break
+		}
+		out = append(out, f)
+	}
+	return out
+}
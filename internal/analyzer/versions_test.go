@@ -0,0 +1,60 @@
+package analyzer
+
+import "testing"
+
+func TestApplyVersionRules(t *testing.T) {
+	// No shipped check is gated on a version yet, so register one.
+	const gated FindingType = "PG16_ONLY"
+	versionRules[gated] = versionRule{supported: versionRange{min: 16}}
+	t.Cleanup(func() { delete(versionRules, gated) })
+
+	findings := func() []Finding {
+		return []Finding{
+			{Type: gated},
+			{Type: FindingMissingVacuum, Table: "logs"},
+			{Type: FindingUnusedIndex, Table: "users", Detail: map[string]string{"size": "1 MB"}},
+			{Type: FindingNoPrimaryKey, Table: "audit"},
+		}
+	}
+
+	tests := []struct {
+		major      int
+		count      int
+		vacuum     string
+		indexAdded bool
+		ioKept     bool
+	}{
+		{0, 4, "", false, true},
+		{12, 3, "autovacuum never triggers on inserts alone before PostgreSQL 13; schedule VACUUM (FREEZE) for insert-only tables", false, false},
+		{15, 3, "inserts also trigger autovacuum (autovacuum_vacuum_insert_threshold); check whether it is disabled for the table or blocked by long transactions", false, false},
+		{16, 4, "inserts also trigger autovacuum (autovacuum_vacuum_insert_threshold); check whether it is disabled for the table or blocked by long transactions", true, true},
+	}
+	for _, tt := range tests {
+		got := applyVersionRules(findings(), tt.major)
+		if len(got) != tt.count {
+			t.Errorf("PG %d: got %d findings, want %d", tt.major, len(got), tt.count)
+			continue
+		}
+		byTable := make(map[string]Finding)
+		ioKept := false
+		for _, f := range got {
+			byTable[f.Table] = f
+			if f.Type == gated {
+				ioKept = true
+			}
+		}
+		if ioKept != tt.ioKept {
+			t.Errorf("PG %d: PG16_ONLY kept = %v, want %v", tt.major, ioKept, tt.ioKept)
+		}
+		if advice := byTable["logs"].Detail["version_advice"]; advice != tt.vacuum {
+			t.Errorf("PG %d: vacuum advice = %q", tt.major, advice)
+		}
+		idx := byTable["users"].Detail
+		if _, ok := idx["version_advice"]; ok != tt.indexAdded || idx["size"] != "1 MB" {
+			t.Errorf("PG %d: unused index detail = %v", tt.major, idx)
+		}
+		if _, ok := byTable["audit"].Detail["version_advice"]; ok {
+			t.Errorf("PG %d: unexpected advice on NO_PRIMARY_KEY", tt.major)
+		}
+	}
+}
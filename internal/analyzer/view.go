@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// detectBrokenViews flags views the planner rejects because they read an
+// object that no longer exists.
+func detectBrokenViews(views []postgres.ViewInfo) []Finding {
+	var findings []Finding
+	for _, v := range views {
+		if v.Error == "" {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingBrokenView,
+			Severity: SeverityHigh,
+			Schema:   v.Schema,
+			Table:    v.Name,
+			Message:  fmt.Sprintf("view %q fails to plan: %s", v.Name, v.Error),
+			Detail: map[string]string{
+				"error": v.Error,
+			},
+		})
+	}
+	return findings
+}
+
+// detectUnusedViews flags views that cannot have been read since the last
+// statistics reset. PostgreSQL doesn't count view scans, but reading a view
+// scans the tables beneath it, so a view whose base tables all have zero
+// scans is unused. Views whose base tables have no statistics are skipped.
+func detectUnusedViews(views []postgres.ViewInfo, allViews []postgres.ViewInfo, stats []postgres.TableStats) []Finding {
+	viewByKey := make(map[string]postgres.ViewInfo, len(allViews))
+	for _, v := range allViews {
+		viewByKey[tableKey(v.Schema, v.Name)] = v
+	}
+	statsByKey := make(map[string]postgres.TableStats, len(stats))
+	for _, s := range stats {
+		statsByKey[tableKey(s.Schema, s.Name)] = s
+	}
+
+	var findings []Finding
+	for _, v := range views {
+		base, ok := viewBaseTables(v, viewByKey, statsByKey, map[string]bool{})
+		if !ok || len(base) == 0 {
+			continue
+		}
+		unused := true
+		for _, key := range base {
+			if s := statsByKey[key]; s.SeqScan > 0 || s.IdxScan > 0 {
+				unused = false
+				break
+			}
+		}
+		if !unused {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingUnusedView,
+			Severity: SeverityLow,
+			Schema:   v.Schema,
+			Table:    v.Name,
+			Message:  fmt.Sprintf("view %q reads only tables with no scans, so it has not been queried", v.Name),
+			Detail: map[string]string{
+				"base_tables": strings.Join(base, ", "),
+			},
+		})
+	}
+	return findings
+}
+
+// viewBaseTables resolves a view's dependencies through nested views to the
+// tables and materialized views beneath it. It reports false if any
+// dependency has no statistics, since the view may then be in use.
+func viewBaseTables(v postgres.ViewInfo, viewByKey map[string]postgres.ViewInfo, statsByKey map[string]postgres.TableStats, seen map[string]bool) ([]string, bool) {
+	key := tableKey(v.Schema, v.Name)
+	if seen[key] {
+		return nil, true
+	}
+	seen[key] = true
+
+	var base []string
+	for _, dep := range v.DependsOn {
+		if _, ok := statsByKey[dep]; ok {
+			base = append(base, dep)
+			continue
+		}
+		nested, ok := viewByKey[dep]
+		if !ok {
+			return nil, false
+		}
+		nestedBase, ok := viewBaseTables(nested, viewByKey, statsByKey, seen)
+		if !ok {
+			return nil, false
+		}
+		base = append(base, nestedBase...)
+	}
+	return base, true
+}
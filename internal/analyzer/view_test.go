@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestDetectBrokenViews(t *testing.T) {
+	views := []postgres.ViewInfo{
+		{Schema: "public", Name: "ok_view"},
+		{Schema: "public", Name: "report", Error: `relation "legacy_totals" does not exist`},
+	}
+	findings := detectBrokenViews(views)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	if findings[0].Type != FindingBrokenView || findings[0].Severity != SeverityHigh || findings[0].Table != "report" {
+		t.Errorf("unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestDetectUnusedViews(t *testing.T) {
+	stats := []postgres.TableStats{
+		{Schema: "public", Name: "users", SeqScan: 10},
+		{Schema: "public", Name: "archive"},
+		{Schema: "public", Name: "archive_2023"},
+	}
+	views := []postgres.ViewInfo{
+		{Schema: "public", Name: "active_users", DependsOn: []string{"public.users"}},
+		{Schema: "public", Name: "old_orders", DependsOn: []string{"public.archive", "public.archive_2023"}},
+		{Schema: "public", Name: "old_orders_summary", DependsOn: []string{"public.old_orders"}},
+		{Schema: "public", Name: "mixed", DependsOn: []string{"public.archive", "public.active_users"}},
+		{Schema: "public", Name: "external", DependsOn: []string{"other.unknown"}},
+		{Schema: "public", Name: "constants"},
+	}
+
+	findings := detectUnusedViews(views, views, stats)
+	got := map[string]Finding{}
+	for _, f := range findings {
+		got[f.Table] = f
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	if got["old_orders"].Detail["base_tables"] != "public.archive, public.archive_2023" {
+		t.Errorf("old_orders base_tables = %q", got["old_orders"].Detail["base_tables"])
+	}
+	if _, ok := got["old_orders_summary"]; !ok {
+		t.Error("nested view over an unused view should be flagged")
+	}
+	for _, name := range []string{"active_users", "mixed", "external", "constants"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("%s should not be flagged", name)
+		}
+	}
+}
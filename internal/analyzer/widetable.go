@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// wideTableTypesLimit caps the data types and columns listed in a
+// WIDE_TABLE finding.
+const wideTableTypesLimit = 5
+
+// detectWideTables flags tables with more than maxColumns columns. They are
+// usually several entities folded into one and make every row expensive to
+// read and update. Tables with more than twice the limit are low severity,
+// the rest informational.
+func detectWideTables(tables []postgres.TableInfo, columns []postgres.ColumnInfo, colStats map[string]postgres.ColumnStats, maxColumns int) []Finding {
+	byTable := make(map[string][]postgres.ColumnInfo)
+	for _, c := range columns {
+		key := tableKey(c.Schema, c.Table)
+		byTable[key] = append(byTable[key], c)
+	}
+
+	var findings []Finding
+	for _, t := range tables {
+		cols := byTable[tableKey(t.Schema, t.Name)]
+		if len(cols) <= maxColumns {
+			continue
+		}
+		sev := SeverityInfo
+		if len(cols) > 2*maxColumns {
+			sev = SeverityLow
+		}
+		detail := map[string]string{
+			"columns":    strconv.Itoa(len(cols)),
+			"threshold":  strconv.Itoa(maxColumns),
+			"data_types": dataTypeCounts(cols),
+		}
+		if widest := widestColumns(cols, colStats); widest != "" {
+			detail["widest_columns"] = widest
+		}
+		findings = append(findings, Finding{
+			Type:     FindingWideTable,
+			Severity: sev,
+			Schema:   t.Schema,
+			Table:    t.Name,
+			Message:  fmt.Sprintf("table has %d columns (threshold %d); consider splitting it", len(cols), maxColumns),
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// dataTypeCounts lists the most common data types as "type×count", most
+// common first.
+func dataTypeCounts(cols []postgres.ColumnInfo) string {
+	counts := make(map[string]int)
+	for _, c := range cols {
+		counts[c.DataType]++
+	}
+	types := make([]string, 0, len(counts))
+	for dt := range counts {
+		types = append(types, dt)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > wideTableTypesLimit {
+		types = types[:wideTableTypesLimit]
+	}
+	parts := make([]string, len(types))
+	for i, dt := range types {
+		parts[i] = fmt.Sprintf("%s×%d", dt, counts[dt])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// widestColumns lists the columns with the largest average width from
+// pg_stats, widest first. It is empty when the table was never analyzed.
+func widestColumns(cols []postgres.ColumnInfo, colStats map[string]postgres.ColumnStats) string {
+	type width struct {
+		col      postgres.ColumnInfo
+		avgWidth int64
+	}
+	var widths []width
+	for _, c := range cols {
+		if cs, ok := colStats[columnKey(c.Schema, c.Table, c.Name)]; ok && cs.AvgWidth > 0 {
+			widths = append(widths, width{col: c, avgWidth: cs.AvgWidth})
+		}
+	}
+	sort.Slice(widths, func(i, j int) bool {
+		if widths[i].avgWidth != widths[j].avgWidth {
+			return widths[i].avgWidth > widths[j].avgWidth
+		}
+		return widths[i].col.Name < widths[j].col.Name
+	})
+	if len(widths) > wideTableTypesLimit {
+		widths = widths[:wideTableTypesLimit]
+	}
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = fmt.Sprintf("%s %s (%s)", w.col.Name, w.col.DataType, formatBytes(w.avgWidth))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+)
+
+func TestDetectWideTables(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	wide := b.Table("public", "customers")
+	for i := 0; i < 12; i++ {
+		wide.Column(fmt.Sprintf("attr_%02d", i), "text")
+	}
+	wide.Column("id", "bigint").Column("score", "integer").Column("notes", "jsonb")
+	veryWide := b.Table("public", "legacy")
+	for i := 0; i < 25; i++ {
+		veryWide.Column(fmt.Sprintf("c%d", i), "integer")
+	}
+	b.Table("public", "narrow").Column("id", "bigint")
+	snap := b.Build()
+	snap.ColumnStats = []postgres.ColumnStats{
+		{Schema: "public", Table: "customers", Column: "notes", AvgWidth: 3000},
+		{Schema: "public", Table: "customers", Column: "attr_00", AvgWidth: 20},
+	}
+
+	findings := detectWideTables(snap.Tables, snap.Columns, buildColumnStatsMap(snap.ColumnStats), 10)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != FindingWideTable || f.Table != "customers" || f.Severity != SeverityInfo {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Detail["columns"] != "15" || f.Detail["data_types"] != "text×12, bigint×1, integer×1, jsonb×1" {
+		t.Errorf("detail = %v", f.Detail)
+	}
+	if f.Detail["widest_columns"] != "notes jsonb (2.9 KB), attr_00 text (20 bytes)" {
+		t.Errorf("widest_columns = %q", f.Detail["widest_columns"])
+	}
+	if f := findings[1]; f.Table != "legacy" || f.Severity != SeverityLow {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if _, ok := findings[1].Detail["widest_columns"]; ok {
+		t.Error("widest_columns without stats")
+	}
+}
@@ -86,6 +86,10 @@ func (b *Baseline) Filter(findings []analyzer.Finding) ([]analyzer.Finding, int)
 // Fingerprint computes a stable identifier for a finding.
 func Fingerprint(f *analyzer.Finding) string {
 	key := fmt.Sprintf("%s|%s|%s|%s|%s", f.Type, f.Schema, f.Table, f.Column, f.Index)
+	// A sequence no column owns has no table; its name tells it apart.
+	if seq := f.Detail["sequence"]; f.Table == "" && seq != "" {
+		key += "|" + seq
+	}
 	h := sha256.Sum256([]byte(key))
 	return fmt.Sprintf("%x", h[:16])
 }
@@ -33,6 +33,14 @@ func TestFingerprint_IncludesColumn(t *testing.T) {
 	}
 }
 
+func TestFingerprint_StandaloneSequences(t *testing.T) {
+	f1 := analyzer.Finding{Type: analyzer.FindingSequenceExhaustion, Schema: "public", Detail: map[string]string{"sequence": "invoice_no"}}
+	f2 := analyzer.Finding{Type: analyzer.FindingSequenceExhaustion, Schema: "public", Detail: map[string]string{"sequence": "ticket_no"}}
+	if Fingerprint(&f1) == Fingerprint(&f2) {
+		t.Error("sequences without a table should have different fingerprints")
+	}
+}
+
 func TestLoad_NoFile(t *testing.T) {
 	b, err := Load("/nonexistent/path.json")
 	if err != nil {
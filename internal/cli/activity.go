@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// gatherActivity adds live sessions and lock waits to the snapshot for
+// audit --activity. Recorded catalogs have none to offer.
+func gatherActivity(ctx context.Context, inspector postgres.CatalogSource, snap *postgres.Snapshot) error {
+	src, ok := inspector.(postgres.ActivitySource)
+	if !ok {
+		return fmt.Errorf("--activity needs a live database connection, without --record or --replay")
+	}
+	sessions, err := src.GetActivity(ctx)
+	if err != nil {
+		return err
+	}
+	snap.Activity = sessions
+	return nil
+}
+
+// gatherClusterHealth adds replication slots, replicas, and archiver state
+// to the snapshot for audit --cluster-health.
+func gatherClusterHealth(ctx context.Context, inspector postgres.CatalogSource, snap *postgres.Snapshot) error {
+	src, ok := inspector.(postgres.ClusterHealthSource)
+	if !ok {
+		return fmt.Errorf("--cluster-health needs a live database connection, without --record or --replay")
+	}
+	health, err := src.GetClusterHealth(ctx)
+	if err != nil {
+		return err
+	}
+	snap.Cluster = health
+	return nil
+}
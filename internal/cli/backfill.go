@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// backfillSamplePercent is the share of table pages read per sampled column.
+const backfillSamplePercent = 1.0
+
+// sampleBackfills replaces the pg_stats NULL fraction of columns with a
+// pending NOT NULL check by a fresh sample. Failed samples keep pg_stats.
+func sampleBackfills(ctx context.Context, inspector postgres.CatalogSource, snap *postgres.Snapshot) {
+	for _, bc := range analyzer.BackfillColumns(snap.Constraints) {
+		frac, err := inspector.SampleNullFraction(ctx, bc.Schema, bc.Table, bc.Column, backfillSamplePercent)
+		if err != nil {
+			slog.Warn("backfill sample failed", "table", bc.Table, "column", bc.Column, "error", err)
+			continue
+		}
+		setSampledNullFrac(snap, bc.Schema, bc.Table, bc.Column, frac)
+	}
+}
+
+// setSampledNullFrac records a sampled NULL fraction in the snapshot's
+// column statistics, adding an entry if the column was never analyzed.
+func setSampledNullFrac(snap *postgres.Snapshot, schema, table, column string, frac float64) {
+	for i := range snap.ColumnStats {
+		cs := &snap.ColumnStats[i]
+		if cs.Schema == schema && cs.Table == table && cs.Column == column {
+			cs.NullFrac, cs.Sampled = frac, true
+			return
+		}
+	}
+	snap.ColumnStats = append(snap.ColumnStats, postgres.ColumnStats{
+		Schema: schema, Table: table, Column: column, NullFrac: frac, Sampled: true,
+	})
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestSetSampledNullFrac(t *testing.T) {
+	snap := &postgres.Snapshot{
+		ColumnStats: []postgres.ColumnStats{
+			{Schema: "public", Table: "users", Column: "email", NullFrac: 0.9, NDistinct: -1},
+		},
+	}
+
+	setSampledNullFrac(snap, "public", "users", "email", 0.1)
+	setSampledNullFrac(snap, "public", "users", "phone", 0.5)
+
+	if len(snap.ColumnStats) != 2 {
+		t.Fatalf("ColumnStats = %d entries, want 2", len(snap.ColumnStats))
+	}
+	email := snap.ColumnStats[0]
+	if email.NullFrac != 0.1 || !email.Sampled || email.NDistinct != -1 {
+		t.Errorf("email = %+v, want sampled 0.1 keeping n_distinct", email)
+	}
+	phone := snap.ColumnStats[1]
+	if phone.Column != "phone" || phone.NullFrac != 0.5 || !phone.Sampled {
+		t.Errorf("phone = %+v", phone)
+	}
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// benchResult is the machine-readable output of bench scan. Throughput is
+// computed from the median run.
+type benchResult struct {
+	Version      string  `json:"version"`
+	GoVersion    string  `json:"goVersion"`
+	Repo         string  `json:"repo"`
+	Workers      int     `json:"workers"`
+	Iterations   int     `json:"iterations"`
+	Files        int     `json:"files"`
+	Bytes        int64   `json:"bytes"`
+	Refs         int     `json:"refs"`
+	ColumnRefs   int     `json:"columnRefs"`
+	RunsMillis   []int64 `json:"runsMillis"`
+	MedianMillis int64   `json:"medianMillis"`
+	MBPerSec     float64 `json:"mbPerSec"`
+	RefsPerSec   float64 `json:"refsPerSec"`
+}
+
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure pgspectre performance",
+	}
+	cmd.AddCommand(newBenchScanCmd())
+	return cmd
+}
+
+func newBenchScanCmd() *cobra.Command {
+	var (
+		repo        string
+		iterations  int
+		parallel    int
+		resultPath  string
+		comparePath string
+		maxSlowdown float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Benchmark code scanning throughput (MB/s and refs/s) on a repository",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == "" {
+				return fmt.Errorf("--repo is required")
+			}
+			if iterations <= 0 {
+				return fmt.Errorf("--iterations must be positive")
+			}
+
+			res, err := benchScan(repo, iterations, parallel)
+			if err != nil {
+				return err
+			}
+			if err := writeBenchText(cmd.OutOrStdout(), &res); err != nil {
+				return err
+			}
+			if resultPath != "" {
+				if err := writeFileAtomic(resultPath, func(w io.Writer) error {
+					return writeBenchJSON(w, &res)
+				}); err != nil {
+					return fmt.Errorf("bench result: %w", err)
+				}
+			}
+
+			if comparePath != "" {
+				prev, err := readBenchResult(comparePath)
+				if err != nil {
+					return err
+				}
+				slowdown := benchSlowdown(prev, &res)
+				if _, err := fmt.Fprintf(cmd.OutOrStdout(), "vs %s: %.1f MB/s → %.1f MB/s (%+.1f%%)\n",
+					comparePath, prev.MBPerSec, res.MBPerSec, -slowdown); err != nil {
+					return err
+				}
+				if slowdown > maxSlowdown {
+					return &ExitError{Code: 2}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "path to code repository to scan (required)")
+	cmd.Flags().IntVar(&iterations, "iterations", 5, "number of timed scans")
+	cmd.Flags().IntVar(&parallel, "parallel", 0, "number of scanner workers (default: number of CPUs)")
+	cmd.Flags().StringVar(&resultPath, "result", "", "write the result as JSON to this file")
+	cmd.Flags().StringVar(&comparePath, "compare", "", "previous JSON result to compare against; exits 2 on regression")
+	cmd.Flags().Float64Var(&maxSlowdown, "max-slowdown", 10, "percent drop in MB/s versus --compare tolerated before failing")
+	return cmd
+}
+
+// benchScan scans repo iterations times after one untimed warm-up run that
+// fills the OS page cache.
+func benchScan(repo string, iterations, workers int) (benchResult, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	count, err := scanner.CountFiles(repo, scanner.ScanOptions{})
+	if err != nil {
+		return benchResult{}, fmt.Errorf("bench: %w", err)
+	}
+
+	res := benchResult{
+		Version:    buildVersion,
+		GoVersion:  runtime.Version(),
+		Repo:       repo,
+		Workers:    workers,
+		Iterations: iterations,
+		Files:      count.Files,
+		Bytes:      count.Bytes,
+	}
+	runs := make([]time.Duration, 0, iterations)
+	for i := 0; i <= iterations; i++ {
+		start := time.Now()
+		result, err := scanner.ScanParallel(repo, workers)
+		elapsed := time.Since(start)
+		if err != nil {
+			return benchResult{}, fmt.Errorf("bench: %w", err)
+		}
+		res.Refs, res.ColumnRefs = result.RefCount(), len(result.ColumnRefs)
+		if err := result.Close(); err != nil {
+			return benchResult{}, fmt.Errorf("bench: %w", err)
+		}
+		if i > 0 {
+			runs = append(runs, elapsed)
+			res.RunsMillis = append(res.RunsMillis, elapsed.Milliseconds())
+		}
+	}
+
+	slices.Sort(runs)
+	median := runs[len(runs)/2]
+	res.MedianMillis = median.Milliseconds()
+	if secs := median.Seconds(); secs > 0 {
+		res.MBPerSec = float64(res.Bytes) / (1024 * 1024) / secs
+		res.RefsPerSec = float64(res.Refs+res.ColumnRefs) / secs
+	}
+	return res, nil
+}
+
+// benchSlowdown returns the percent drop in MB/s from prev to cur; negative
+// means cur is faster.
+func benchSlowdown(prev, cur *benchResult) float64 {
+	if prev.MBPerSec <= 0 {
+		return 0
+	}
+	return (prev.MBPerSec - cur.MBPerSec) / prev.MBPerSec * 100
+}
+
+func readBenchResult(path string) (*benchResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read bench result: %w", err)
+	}
+	var res benchResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("parse bench result %s: %w", path, err)
+	}
+	return &res, nil
+}
+
+func writeBenchText(w io.Writer, res *benchResult) error {
+	_, err := fmt.Fprintf(w,
+		"Scanned %d files (%s) in %d ms (median of %d, %d workers)\n  %.1f MB/s  %.0f refs/s  (%d table refs, %d column refs)\n",
+		res.Files, formatBenchBytes(res.Bytes), res.MedianMillis, res.Iterations, res.Workers,
+		res.MBPerSec, res.RefsPerSec, res.Refs, res.ColumnRefs)
+	return err
+}
+
+func writeBenchJSON(w io.Writer, res *benchResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func formatBenchBytes(b int64) string {
+	const mb = 1024 * 1024
+	if b >= mb {
+		return fmt.Sprintf("%.1f MB", float64(b)/mb)
+	}
+	return fmt.Sprintf("%.1f KB", float64(b)/1024)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBenchScanCmd(t *testing.T) {
+	repo := t.TempDir()
+	src := "package main\n\nconst q = \"SELECT id FROM users WHERE id = $1\"\n"
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resultPath := filepath.Join(t.TempDir(), "bench.json")
+
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"bench", "scan", "--repo", repo, "--iterations", "2", "--result", resultPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Scanned 1 files") || !strings.Contains(out.String(), "MB/s") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	res, err := readBenchResult(resultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 1 || res.Refs != 1 || len(res.RunsMillis) != 2 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestBenchScanCmd_Regression(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "q.sql"), []byte("SELECT * FROM users;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// An impossibly fast previous run makes any real run a regression.
+	prev := filepath.Join(t.TempDir(), "prev.json")
+	if err := os.WriteFile(prev, []byte(`{"mbPerSec": 1e12}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"bench", "scan", "--repo", repo, "--iterations", "1", "--compare", prev})
+	err := cmd.Execute()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("err = %v, want exit code 2", err)
+	}
+}
+
+func TestBenchSlowdown(t *testing.T) {
+	prev := &benchResult{MBPerSec: 100}
+	if got := benchSlowdown(prev, &benchResult{MBPerSec: 80}); got != 20 {
+		t.Errorf("slowdown = %v, want 20", got)
+	}
+	if got := benchSlowdown(prev, &benchResult{MBPerSec: 120}); got != -20 {
+		t.Errorf("slowdown = %v, want -20", got)
+	}
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+)
+
+// useStaticCatalog makes commands read src instead of connecting.
+func useStaticCatalog(t *testing.T, src postgres.CatalogSource) {
+	t.Helper()
+	prev := openCatalog
+	t.Cleanup(func() { openCatalog = prev })
+	openCatalog = func(ctx context.Context, cfg postgres.Config) (postgres.CatalogSource, error) {
+		return src, nil
+	}
+}
+
+func staticSnapshot() *postgres.Snapshot {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "users").NotNullColumn("id", "bigint").Column("email", "text").PrimaryKey("id").Scans(10, 500)
+	b.Table("public", "audit_log").Column("payload", "jsonb").Scans(0, 0)
+	return b.Build()
+}
+
+func runReport(t *testing.T, args ...string) reporter.Report {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	// Findings set a non-zero exit code; only other errors fail the test.
+	var exitErr *ExitError
+	if err := cmd.Execute(); err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("%v: %v", args, err)
+	}
+	var report reporter.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	return report
+}
+
+func findingTables(report reporter.Report, typ string) []string {
+	var tables []string
+	for _, f := range report.Findings {
+		if string(f.Type) == typ {
+			tables = append(tables, f.Table)
+		}
+	}
+	return tables
+}
+
+func TestAuditCmd_StaticCatalog(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+
+	report := runReport(t, "audit", "--db-url", "postgres://static/db", "--format", "json")
+	if got := findingTables(report, "NO_PRIMARY_KEY"); len(got) != 1 || got[0] != "audit_log" {
+		t.Errorf("NO_PRIMARY_KEY on %v, want audit_log", got)
+	}
+	if got := findingTables(report, "UNUSED_TABLE"); len(got) != 1 || got[0] != "audit_log" {
+		t.Errorf("UNUSED_TABLE on %v, want audit_log", got)
+	}
+}
+
+func TestCheckCmd_StaticCatalog(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+
+	repo := t.TempDir()
+	code := "package store\n\nconst q = `SELECT id FROM users JOIN orders ON orders.user_id = users.id`\n"
+	if err := os.WriteFile(filepath.Join(repo, "store.go"), []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := runReport(t, "check", "--repo", repo, "--db-url", "postgres://static/db", "--format", "json")
+	if got := findingTables(report, "MISSING_TABLE"); len(got) != 1 || got[0] != "orders" {
+		t.Errorf("MISSING_TABLE on %v, want orders", got)
+	}
+	for _, f := range report.Findings {
+		if f.Confidence == "" {
+			t.Errorf("%s on %s has no confidence", f.Type, f.Table)
+		}
+	}
+
+	// orders is referenced from one place only.
+	report = runReport(t, "check", "--repo", repo, "--db-url", "postgres://static/db", "--format", "json", "--min-confidence", "high")
+	if got := findingTables(report, "MISSING_TABLE"); len(got) != 0 {
+		t.Errorf("MISSING_TABLE on %v with --min-confidence high, want none", got)
+	}
+}
+
+func TestCheckCmd_MultipleRepos(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+
+	root := t.TempDir()
+	web, worker := filepath.Join(root, "web"), filepath.Join(root, "worker")
+	writeTestFile(t, web, "store.go", "package store\n\nconst q = `SELECT id FROM users`\n")
+	writeTestFile(t, worker, "jobs.sql", "INSERT INTO audit_log (payload) SELECT payload FROM orders;\n")
+
+	report := runReport(t, "check", "--repo", web, "--db-url", "postgres://static/db", "--format", "json")
+	if got := findingTables(report, "UNREFERENCED_TABLE"); len(got) != 1 || got[0] != "audit_log" {
+		t.Errorf("UNREFERENCED_TABLE on %v with one repo, want audit_log", got)
+	}
+
+	writeTestFile(t, root, "repos.txt", "# services sharing the database\nworker\n")
+	for _, args := range [][]string{
+		{"--repo", web, "--repo", worker},
+		{"--repo", web + "," + worker},
+		{"--repo", web, "--repos-file", filepath.Join(root, "repos.txt")},
+	} {
+		args = append([]string{"check", "--db-url", "postgres://static/db", "--format", "json"}, args...)
+		report := runReport(t, args...)
+		if got := findingTables(report, "UNREFERENCED_TABLE"); len(got) != 0 {
+			t.Errorf("%v: UNREFERENCED_TABLE on %v, want none", args, got)
+		}
+		if got := findingTables(report, "MISSING_TABLE"); len(got) != 1 || got[0] != "orders" {
+			t.Errorf("%v: MISSING_TABLE on %v, want orders", args, got)
+		}
+	}
+}
+
+func TestStaticSource(t *testing.T) {
+	src := &postgres.StaticSource{
+		Snapshot:      staticSnapshot(),
+		NullFractions: map[string]float64{"public.users.email": 0.25},
+	}
+	ctx := context.Background()
+	if n, _ := src.CountTables(ctx, []string{"PUBLIC"}); n != 2 {
+		t.Errorf("CountTables = %d, want 2", n)
+	}
+	if n, _ := src.CountTables(ctx, []string{"other"}); n != 0 {
+		t.Errorf("CountTables(other) = %d, want 0", n)
+	}
+	if frac, err := src.SampleNullFraction(ctx, "public", "users", "email", 1); err != nil || frac != 0.25 {
+		t.Errorf("SampleNullFraction = %v, %v", frac, err)
+	}
+	if _, err := src.SampleNullFraction(ctx, "public", "users", "id", 1); err == nil {
+		t.Error("expected error for unsampled column")
+	}
+}
+
+func TestAuditCmd_RecordReplay(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+	dir := filepath.Join(t.TempDir(), "fixtures")
+
+	recorded := runReport(t, "audit", "--db-url", "postgres://static/db", "--format", "json", "--canonical", "--record", dir)
+	for _, name := range []string{"server_version.json", "snapshot.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("fixture %s not recorded: %v", name, err)
+		}
+	}
+
+	// Replay needs neither a connection nor --db-url.
+	openCatalog = func(ctx context.Context, cfg postgres.Config) (postgres.CatalogSource, error) {
+		t.Fatal("replay connected to the database")
+		return nil, nil
+	}
+	replayed := runReport(t, "audit", "--format", "json", "--canonical", "--replay", dir)
+	if len(replayed.Findings) == 0 || len(replayed.Findings) != len(recorded.Findings) {
+		t.Fatalf("replayed %d findings, recorded %d", len(replayed.Findings), len(recorded.Findings))
+	}
+	for i := range recorded.Findings {
+		if recorded.Findings[i].Type != replayed.Findings[i].Type || recorded.Findings[i].Table != replayed.Findings[i].Table {
+			t.Errorf("finding %d: recorded %+v, replayed %+v", i, recorded.Findings[i], replayed.Findings[i])
+		}
+	}
+}
+
+func TestAuditCmd_RecordAndReplayExclusive(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"audit", "--record", "a", "--replay", "b"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error for --record with --replay")
+	}
+}
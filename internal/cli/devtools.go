@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+	"github.com/spf13/cobra"
+)
+
+func newDevtoolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "devtools",
+		Short:  "Helpers for developing and benchmarking pgspectre",
+		Hidden: true,
+	}
+	cmd.AddCommand(newGenSnapshotCmd())
+	cmd.AddCommand(newFuzzCmd())
+	return cmd
+}
+
+func newGenSnapshotCmd() *cobra.Command {
+	var (
+		tables int
+		seed   uint64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gen-snapshot",
+		Short: "Write a fabricated catalog snapshot as JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if tables <= 0 {
+				return fmt.Errorf("--tables must be positive")
+			}
+			snap := fixtures.GenerateSnapshot(tables, seed)
+			return toOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(snap)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&tables, "tables", 1000, "number of tables to generate")
+	cmd.Flags().Uint64Var(&seed, "seed", 1, "random seed; the same seed yields the same snapshot")
+	return cmd
+}
+
+// fuzzPackage holds the fuzz targets run by devtools fuzz.
+const fuzzPackage = "./internal/scanner"
+
+// fuzzTargets are the Fuzz* functions in fuzzPackage, in the order they run.
+var fuzzTargets = []string{
+	"FuzzScanLine",
+	"FuzzScanLineColumns",
+	"FuzzSplitOnSemicolons",
+	"FuzzSQLBuffer",
+}
+
+func newFuzzCmd() *cobra.Command {
+	var (
+		targets  []string
+		fuzztime time.Duration
+		dir      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fuzz",
+		Short: "Run the scanner fuzz targets one after another (needs a source checkout and the go tool)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(targets) == 0 {
+				targets = fuzzTargets
+			}
+			for _, t := range targets {
+				if !slices.Contains(fuzzTargets, t) {
+					return fmt.Errorf("unknown fuzz target %q (available: %s)", t, strings.Join(fuzzTargets, ", "))
+				}
+			}
+			for _, t := range targets {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "fuzzing %s for %s\n", t, fuzztime)
+				run := exec.CommandContext(cmd.Context(), "go", fuzzArgs(t, fuzztime)...)
+				run.Dir = dir
+				run.Stdout = cmd.OutOrStdout()
+				run.Stderr = cmd.ErrOrStderr()
+				if err := run.Run(); err != nil {
+					return fmt.Errorf("%s: %w (failing inputs are saved under internal/scanner/testdata/fuzz)", t, err)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&targets, "target", nil, "fuzz targets to run (default: all)")
+	cmd.Flags().DurationVar(&fuzztime, "fuzztime", 30*time.Second, "time to spend on each target")
+	cmd.Flags().StringVar(&dir, "dir", ".", "root of the pgspectre source checkout")
+	return cmd
+}
+
+// fuzzArgs builds the go test arguments that fuzz a single target.
+func fuzzArgs(target string, fuzztime time.Duration) []string {
+	return []string{"test", "-run", "^$", "-fuzz", "^" + target + "$", "-fuzztime", fuzztime.String(), fuzzPackage}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestGenSnapshotCmd(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"devtools", "gen-snapshot", "--tables", "25", "--seed", "7"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var snap postgres.Snapshot
+	if err := json.Unmarshal(out.Bytes(), &snap); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(snap.Tables) != 25 {
+		t.Errorf("tables = %d, want 25", len(snap.Tables))
+	}
+}
+
+func TestFuzzCmd_UnknownTarget(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"devtools", "fuzz", "--target", "FuzzNope"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown fuzz target") {
+		t.Fatalf("err = %v, want unknown fuzz target", err)
+	}
+}
+
+func TestFuzzArgs(t *testing.T) {
+	got := strings.Join(fuzzArgs("FuzzScanLine", 10*time.Second), " ")
+	want := "test -run ^$ -fuzz ^FuzzScanLine$ -fuzztime 10s ./internal/scanner"
+	if got != want {
+		t.Errorf("fuzzArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/config"
+)
+
+func TestEffortOverridesFromConfig(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Effort = map[string]string{
+		"unused_table": "Large",
+		"SCHEMA_DRIFT": "trivial",
+	}
+
+	got := effortOverridesFromConfig()
+	if got[analyzer.FindingUnusedTable] != analyzer.EffortLarge {
+		t.Errorf("UNUSED_TABLE = %q, want large", got[analyzer.FindingUnusedTable])
+	}
+	if got[analyzer.FindingMissingColumn] != analyzer.EffortTrivial {
+		t.Errorf("MISSING_COLUMN (via alias) = %q, want trivial", got[analyzer.FindingMissingColumn])
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d overrides, want 2", len(got))
+	}
+}
+
+func TestEffortOverridesFromConfig_Empty(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	if got := effortOverridesFromConfig(); got != nil {
+		t.Errorf("expected nil overrides, got %v", got)
+	}
+}
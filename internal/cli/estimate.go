@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// Rough throughput figures used by --estimate. They are deliberately
+// conservative; the goal is an order of magnitude, not a stopwatch.
+const (
+	estimateScanBytesPerSec = 20 * 1024 * 1024 // per scanner goroutine
+	estimateQueryLatency    = 50 * time.Millisecond
+	estimatePerTable        = 2 * time.Millisecond
+)
+
+// estimate is the output of --estimate: object counts and a predicted runtime.
+type estimate struct {
+	Command         string             `json:"command"`
+	Files           *scanner.FileCount `json:"files,omitempty"`
+	Tables          int                `json:"tables"`
+	CatalogQueries  int                `json:"catalogQueries"`
+	EstimatedTime   string             `json:"estimatedTime"`
+	EstimatedMillis int64              `json:"estimatedMillis"`
+}
+
+// buildEstimate predicts runtime from file, table, and catalog query
+// counts. files is nil for commands that don't scan code.
+func buildEstimate(command string, files *scanner.FileCount, tables, queries, workers int) estimate {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var d time.Duration
+	if files != nil {
+		perSec := int64(estimateScanBytesPerSec * workers)
+		d += time.Duration(files.Bytes * int64(time.Second) / perSec)
+	}
+	d += time.Duration(queries) * estimateQueryLatency
+	d += time.Duration(tables) * estimatePerTable
+
+	return estimate{
+		Command:         command,
+		Files:           files,
+		Tables:          tables,
+		CatalogQueries:  queries,
+		EstimatedTime:   roundEstimate(d).String(),
+		EstimatedMillis: d.Milliseconds(),
+	}
+}
+
+// roundEstimate trims false precision from a predicted duration.
+func roundEstimate(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+// estimateFormat picks the format of the --format output written to
+// stdout, or of the first output if all go to files.
+func estimateFormat(outputs []reporter.Output) reporter.Format {
+	for _, out := range outputs {
+		if out.Path == reporter.StdoutPath {
+			return out.Format
+		}
+	}
+	if len(outputs) > 0 {
+		return outputs[0].Format
+	}
+	return reporter.FormatText
+}
+
+// writeEstimate writes an estimate as JSON for the json format and as text
+// for any other.
+func writeEstimate(w io.Writer, e *estimate, format reporter.Format) error {
+	if format == reporter.FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(e)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pgspectre %s estimate (nothing was analyzed)\n\n", e.Command)
+	if e.Files != nil {
+		fmt.Fprintf(&b, "  Files:            %d to scan, %d skipped (%.1f MB)\n",
+			e.Files.Files, e.Files.Skipped, float64(e.Files.Bytes)/(1024*1024))
+	}
+	fmt.Fprintf(&b, "  Tables:           %d\n", e.Tables)
+	fmt.Fprintf(&b, "  Catalog queries:  %d\n", e.CatalogQueries)
+	fmt.Fprintf(&b, "  Estimated time:   ~%s\n", e.EstimatedTime)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
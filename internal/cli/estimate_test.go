@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+func TestBuildEstimate_AuditOnly(t *testing.T) {
+	queries := postgres.InspectQueries(postgres.Config{})
+	e := buildEstimate("audit", nil, 500, queries, 1)
+	if e.CatalogQueries != queries {
+		t.Errorf("CatalogQueries = %d, want %d", e.CatalogQueries, queries)
+	}
+	// InspectQueries * 50ms + 500 tables * 2ms
+	want := int64(queries)*50 + 500*2
+	if e.EstimatedMillis != want {
+		t.Errorf("EstimatedMillis = %d, want %d", e.EstimatedMillis, want)
+	}
+	if wantTime := roundEstimate(time.Duration(want) * time.Millisecond).String(); e.EstimatedTime != wantTime {
+		t.Errorf("EstimatedTime = %q, want %s", e.EstimatedTime, wantTime)
+	}
+}
+
+func TestCatalogConfig_ViewCheckFollowsChecks(t *testing.T) {
+	if catalogConfig(nil, nil).SkipViewCheck {
+		t.Error("default checks should run the view check")
+	}
+	perf, err := analyzer.ProfileChecks("performance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcfg := catalogConfig(nil, perf)
+	if !pcfg.SkipViewCheck {
+		t.Error("performance profile should skip the view check")
+	}
+	if postgres.InspectQueries(pcfg) >= postgres.InspectQueries(postgres.Config{}) {
+		t.Error("skipping the view check should lower the query count")
+	}
+}
+
+func TestCatalogConfig_PrivilegeQueriesFollowChecks(t *testing.T) {
+	pcfg := catalogConfig(nil, nil)
+	if !pcfg.SkipGrants || !pcfg.SkipRoles {
+		t.Error("default checks should skip the opt-in grant and role queries")
+	}
+	if pcfg.SkipPolicies {
+		t.Error("default checks should read policies for MISSING_RLS")
+	}
+
+	security, err := analyzer.ProfileChecks("security")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcfg = catalogConfig(nil, security)
+	if pcfg.SkipGrants || pcfg.SkipRoles || pcfg.SkipPolicies {
+		t.Errorf("security profile should read grants, roles and policies: %+v", pcfg)
+	}
+
+	perf, err := analyzer.ProfileChecks("performance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pcfg := catalogConfig(nil, perf); !pcfg.SkipGrants || !pcfg.SkipRoles || !pcfg.SkipPolicies {
+		t.Errorf("performance profile should skip grants, roles and policies: %+v", pcfg)
+	}
+}
+
+func TestBuildEstimate_ScalesWithWorkers(t *testing.T) {
+	files := &scanner.FileCount{Files: 1000, Bytes: 400 * 1024 * 1024}
+	one := buildEstimate("check", files, 0, 0, 1)
+	four := buildEstimate("check", files, 0, 0, 4)
+	if four.EstimatedMillis >= one.EstimatedMillis {
+		t.Errorf("4 workers (%dms) should be faster than 1 (%dms)", four.EstimatedMillis, one.EstimatedMillis)
+	}
+}
+
+func TestWriteEstimate_Text(t *testing.T) {
+	e := buildEstimate("check", &scanner.FileCount{Files: 12, Skipped: 3, Bytes: 2 * 1024 * 1024}, 40, 28, 1)
+	var buf bytes.Buffer
+	if err := writeEstimate(&buf, &e, reporter.FormatText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"check estimate", "12 to scan, 3 skipped (2.0 MB)", "Tables:           40", "Catalog queries:  28"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteEstimate_JSON(t *testing.T) {
+	e := buildEstimate("audit", nil, 10, 28, 1)
+	var buf bytes.Buffer
+	if err := writeEstimate(&buf, &e, reporter.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded["files"]; ok {
+		t.Error("audit estimate should omit files")
+	}
+	if decoded["tables"] != float64(10) {
+		t.Errorf("tables = %v, want 10", decoded["tables"])
+	}
+}
+
+func TestEstimateFormat(t *testing.T) {
+	tests := map[string]reporter.Format{
+		"":                       reporter.FormatText,
+		"JSON":                   reporter.FormatJSON,
+		" json ":                 reporter.FormatJSON,
+		"text,json=report.json":  reporter.FormatText,
+		"sarif=out.sarif,json":   reporter.FormatJSON,
+		"json=report.json,sarif": reporter.FormatSARIF,
+	}
+	for spec, want := range tests {
+		outputs, err := reporter.ParseOutputs(spec)
+		if err != nil {
+			t.Fatalf("%q: %v", spec, err)
+		}
+		if got := estimateFormat(outputs); got != want {
+			t.Errorf("%q: format = %q, want %q", spec, got, want)
+		}
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriteEstimate_WriteError(t *testing.T) {
+	e := buildEstimate("audit", nil, 10, 28, 1)
+	if err := writeEstimate(failWriter{}, &e, reporter.FormatText); err == nil {
+		t.Error("expected the write error")
+	}
+}
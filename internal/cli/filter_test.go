@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/config"
 )
 
 var testFindings = []analyzer.Finding{
@@ -83,6 +84,14 @@ func TestFilterByType_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestFilterByType_RenamedType(t *testing.T) {
+	findings := []analyzer.Finding{{Type: analyzer.FindingMissingColumn}, {Type: analyzer.FindingMissingTable}}
+	result := filterByType(findings, "SCHEMA_DRIFT")
+	if len(result) != 1 || result[0].Type != analyzer.FindingMissingColumn {
+		t.Fatalf("SCHEMA_DRIFT should select MISSING_COLUMN, got %v", result)
+	}
+}
+
 func TestFilterByType_Empty(t *testing.T) {
 	result := filterByType(testFindings, "")
 	if len(result) != 5 {
@@ -114,3 +123,38 @@ func TestApplyReportFilters_None(t *testing.T) {
 		t.Fatalf("no filters should return all, got %d", len(result))
 	}
 }
+
+func TestFilterByConfidence(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Confidence: analyzer.ConfidenceLow},
+		{Type: analyzer.FindingMissingColumn, Confidence: analyzer.ConfidenceMedium},
+		{Type: analyzer.FindingNoPrimaryKey, Confidence: analyzer.ConfidenceHigh},
+		{Type: analyzer.FindingCodeMatch},
+	}
+	if got := filterByConfidence(findings, analyzer.ConfidenceMedium); len(got) != 3 || got[0].Type != analyzer.FindingMissingColumn {
+		t.Errorf("medium: got %+v", got)
+	}
+	if got := filterByConfidence(findings, ""); len(got) != 4 {
+		t.Errorf("no filter: got %d findings, want 4", len(got))
+	}
+	if _, err := parseMinConfidence("sure"); err == nil {
+		t.Error("expected an error for an unknown confidence")
+	}
+}
+
+func TestResolveTablesFlag(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Tables = []string{"from_config*"}
+
+	got := resolveTablesFlag("orders*, payments")
+	if len(got) != 2 || got[0] != "orders*" || got[1] != "payments" {
+		t.Errorf("flag tables = %v", got)
+	}
+	got = resolveTablesFlag("")
+	if len(got) != 1 || got[0] != "from_config*" {
+		t.Errorf("config tables = %v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/spf13/cobra"
+)
+
+// riskHeadings introduce each section of a fix script.
+var riskHeadings = map[analyzer.Risk]string{
+	analyzer.RiskSafe:        "Safe: no long blocking locks",
+	analyzer.RiskLocking:     "Locking: blocks writes or reads on the table while it runs",
+	analyzer.RiskDestructive: "Destructive: drops objects or changes behavior clients rely on",
+}
+
+func newFixCmd() *cobra.Command {
+	var (
+		reportPath         string
+		schemaFlag         string
+		typeFilter         string
+		minSeverity        string
+		includeDestructive bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fix",
+		Short: "Write a SQL script that remediates audit findings, for review before running",
+		Long: "Turns the findings of a JSON report (--report), or of a fresh audit, into a SQL script. " +
+			"Statements are grouped by risk: safe ones first, then those that take locks, then destructive ones, " +
+			"which are commented out unless --include-destructive is set. Nothing is run against the database.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var findings []analyzer.Finding
+			source := reportPath
+			if reportPath != "" {
+				var err error
+				if findings, err = readReportFindings(reportPath); err != nil {
+					return err
+				}
+			} else {
+				if dbURL == "" && replayDir == "" {
+					return fmt.Errorf("--db-url or --report is required")
+				}
+				ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+				defer cancel()
+				var err error
+				if findings, err = auditForFix(ctx, resolveSchemaFlag(schemaFlag)); err != nil {
+					return err
+				}
+				source = "audit of " + extractDatabase(dbURL)
+			}
+
+			findings = applyReportFilters(findings, minSeverity, typeFilter)
+			findings, _, err := filterFindings(findings, "")
+			if err != nil {
+				return err
+			}
+			return toOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+				return writeFixScript(w, findings, source, includeDestructive)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "JSON report from audit or check to remediate (default: run an audit)")
+	cmd.Flags().StringVar(&schemaFlag, "schema", "", "schemas to audit when no --report is given (comma-separated, or 'all')")
+	cmd.Flags().StringVar(&typeFilter, "type", "", "remediate only these finding types (comma-separated, e.g. UNUSED_INDEX,MISSING_VACUUM)")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "remediate only findings at or above this severity (high, medium, low, info)")
+	cmd.Flags().BoolVar(&includeDestructive, "include-destructive", false, "emit destructive statements (DROP, pg_drop_replication_slot, ...) uncommented")
+
+	return cmd
+}
+
+// readReportFindings loads the findings of a JSON report.
+func readReportFindings(path string) ([]analyzer.Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+	var report reporter.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s: not a JSON report: %w", path, err)
+	}
+	return report.Findings, nil
+}
+
+// auditForFix runs a catalog audit, as audit does with default flags.
+func auditForFix(ctx context.Context, schemas []string) ([]analyzer.Finding, error) {
+	inspector, err := connectCatalog(ctx, postgres.Config{URL: dbURL})
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	defer inspector.Close()
+
+	ver, err := inspector.ServerVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server version: %w", err)
+	}
+	snap, err := inspector.Inspect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+	snap = postgres.FilterSnapshot(snap, schemas)
+
+	opts := auditOptsFromConfig(schemas)
+	opts.ServerMajor = postgres.MajorVersion(ver)
+	return analyzer.Audit(snap, opts), nil
+}
+
+// writeFixScript writes the remediations of findings as a SQL script,
+// grouped by risk in the order they should be applied. Each statement is
+// preceded by the finding it resolves; a statement two findings share is
+// written once. Destructive statements are commented out unless
+// includeDestructive is set.
+func writeFixScript(w io.Writer, findings []analyzer.Finding, source string, includeDestructive bool) error {
+	analyzer.SortFindings(findings)
+
+	type entry struct {
+		rem      analyzer.Remediation
+		findings []analyzer.Finding
+	}
+	byRisk := make(map[analyzer.Risk][]*entry)
+	seen := make(map[string]*entry)
+	skipped := 0
+	for _, f := range findings {
+		rem, ok := analyzer.Remediate(f)
+		if !ok {
+			skipped++
+			continue
+		}
+		if e, ok := seen[rem.SQL]; ok {
+			e.findings = append(e.findings, f)
+			continue
+		}
+		e := &entry{rem: rem, findings: []analyzer.Finding{f}}
+		seen[rem.SQL] = e
+		byRisk[rem.Risk] = append(byRisk[rem.Risk], e)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- pgspectre fix: remediations for %s\n", commentText(source))
+	b.WriteString("-- Review every statement before running it. CONCURRENTLY, VACUUM, and\n")
+	b.WriteString("-- ALTER SYSTEM statements can't run inside a transaction block.\n")
+	fmt.Fprintf(&b, "-- %d statements for %d findings; %d findings have no automatic remediation.\n",
+		len(seen), len(findings)-skipped, skipped)
+
+	for _, risk := range analyzer.Risks {
+		entries := byRisk[risk]
+		if len(entries) == 0 {
+			continue
+		}
+		commented := risk == analyzer.RiskDestructive && !includeDestructive
+		fmt.Fprintf(&b, "\n-- == %s (%d) ==\n", riskHeadings[risk], len(entries))
+		if commented {
+			b.WriteString("-- Commented out; uncomment the ones you want, or rerun with --include-destructive.\n")
+		}
+		for _, e := range entries {
+			b.WriteString("\n")
+			for _, f := range e.findings {
+				fmt.Fprintf(&b, "-- %s\n", commentText(fmt.Sprintf("%s %s: %s", f.Type, findingTargetName(f), f.Message)))
+			}
+			if e.rem.Note != "" {
+				fmt.Fprintf(&b, "-- Note: %s\n", commentText(e.rem.Note))
+			}
+			sql := e.rem.SQL
+			if commented {
+				// A quoted identifier can span lines; each must stay commented.
+				sql = "-- " + strings.ReplaceAll(sql, "\n", "\n-- ")
+			}
+			b.WriteString(sql + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// commentText flattens s onto one line so it can't end a "--" comment.
+func commentText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// findingTargetName names the object a finding is about.
+func findingTargetName(f analyzer.Finding) string {
+	name := f.Table
+	if f.Schema != "" && f.Table != "" {
+		name = f.Schema + "." + f.Table
+	}
+	switch {
+	case f.Index != "":
+		name += " index " + f.Index
+	case f.Column != "":
+		name += "." + f.Column
+	}
+	if name == "" {
+		return "(cluster)"
+	}
+	return name
+}
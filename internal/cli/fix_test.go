@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestWriteFixScript(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Schema: "public", Table: "users", Index: "idx_users_legacy", Message: "index has never been used"},
+		{Type: analyzer.FindingMissingVacuum, Schema: "public", Table: "users", Message: "table has not been vacuumed in 45 days"},
+		{Type: analyzer.FindingMissingVacuum, Schema: "public", Table: "users", Message: "table was never vacuumed"},
+		{Type: analyzer.FindingNoPrimaryKey, Schema: "public", Table: "events", Message: "table has no primary key"},
+	}
+
+	var out bytes.Buffer
+	if err := writeFixScript(&out, findings, "report.json", false); err != nil {
+		t.Fatal(err)
+	}
+	script := out.String()
+	for _, want := range []string{
+		"-- 2 statements for 3 findings; 1 findings have no automatic remediation.",
+		"-- MISSING_VACUUM public.users: table has not been vacuumed in 45 days\n-- MISSING_VACUUM public.users: table was never vacuumed\nVACUUM (ANALYZE) public.users;",
+		"-- DROP INDEX CONCURRENTLY public.idx_users_legacy;",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Index(script, "VACUUM (ANALYZE)") > strings.Index(script, "DROP INDEX") {
+		t.Errorf("safe statements should come before destructive ones:\n%s", script)
+	}
+
+	out.Reset()
+	if err := writeFixScript(&out, findings, "report.json", true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\nDROP INDEX CONCURRENTLY public.idx_users_legacy;") {
+		t.Errorf("--include-destructive should leave DROP INDEX uncommented:\n%s", out.String())
+	}
+}
+
+func TestWriteFixScript_MultiLineDetails(t *testing.T) {
+	findings := []analyzer.Finding{{
+		Type: analyzer.FindingUnusedTable, Schema: "public", Table: "old\ndata",
+		Message: "no scans\nDROP TABLE public.users;",
+		Detail:  map[string]string{"suggestion": "SELECT 1;\nDROP TABLE public.users;"},
+	}}
+
+	var out bytes.Buffer
+	if err := writeFixScript(&out, findings, "report.json", false); err != nil {
+		t.Fatal(err)
+	}
+	script := out.String()
+	for _, line := range strings.Split(strings.TrimSpace(script), "\n") {
+		if line != "" && !strings.HasPrefix(line, "--") {
+			t.Errorf("uncommented line %q in:\n%s", line, script)
+		}
+	}
+	if !strings.Contains(script, "-- UNUSED_TABLE public.old data: no scans DROP TABLE public.users;\n") {
+		t.Errorf("finding comment should be flattened:\n%s", script)
+	}
+	if !strings.Contains(script, "-- DROP TABLE public.\"old\n-- data\";\n") {
+		t.Errorf("statement should be commented line by line:\n%s", script)
+	}
+}
+
+func TestFixCmd(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := newRootCmd(BuildInfo{Version: "test"})
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	script := run("fix", "--db-url", "postgres://static/db")
+	if !strings.Contains(script, "-- pgspectre fix: remediations for audit of db") {
+		t.Errorf("unexpected header:\n%s", script)
+	}
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "report.json", `{"findings": [
+		{"type": "MISSING_VACUUM", "severity": "low", "schema": "public", "table": "orders", "message": "never vacuumed"},
+		{"type": "UNUSED_TABLE", "severity": "high", "schema": "public", "table": "old_data", "message": "no scans"}
+	]}`)
+	script = run("fix", "--report", dir+"/report.json", "--type", "UNUSED_TABLE", "--include-destructive")
+	if !strings.Contains(script, "\nDROP TABLE public.old_data;") || strings.Contains(script, "VACUUM (ANALYZE)") {
+		t.Errorf("unexpected script:\n%s", script)
+	}
+}
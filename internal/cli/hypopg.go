@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// maxIndexValidations caps the hypothetical indexes planned per report.
+const maxIndexValidations = 20
+
+// validateIndexes plans the representative query of each index suggestion
+// against a hypothetical index and records the cost change. It does
+// nothing unless the catalog source can plan and hypopg is installed.
+func validateIndexes(ctx context.Context, inspector postgres.CatalogSource, snap *postgres.Snapshot, findings []analyzer.Finding) {
+	v, ok := inspector.(postgres.IndexValidator)
+	if !ok || !hasExtension(snap.Extensions, "hypopg") {
+		return
+	}
+	n := 0
+	for i := range findings {
+		f := &findings[i]
+		createIndex, query, ok := analyzer.HypotheticalIndex(*f, snap.Statements)
+		if !ok {
+			continue
+		}
+		if n == maxIndexValidations {
+			slog.Info("hypothetical index validation capped", "validated", n)
+			return
+		}
+		n++
+		est, err := v.EstimateIndex(ctx, createIndex, query)
+		if err != nil {
+			slog.Debug("hypothetical index validation failed", "table", f.Table, "index", createIndex, "error", err)
+			continue
+		}
+		analyzer.SetIndexEstimate(f, est)
+	}
+}
+
+func hasExtension(exts []postgres.ExtensionInfo, name string) bool {
+	for _, e := range exts {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// planningSource is a static catalog that can plan hypothetical indexes.
+type planningSource struct {
+	postgres.StaticSource
+	planned []string
+}
+
+func (s *planningSource) EstimateIndex(ctx context.Context, createIndex, query string) (postgres.IndexEstimate, error) {
+	s.planned = append(s.planned, createIndex)
+	return postgres.IndexEstimate{CostBefore: 200, CostAfter: 50, Used: true}, nil
+}
+
+func TestValidateIndexes(t *testing.T) {
+	snap := &postgres.Snapshot{}
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnindexedQuery, Schema: "public", Table: "users", Column: "email"},
+		{Type: analyzer.FindingUnusedTable, Schema: "public", Table: "legacy"},
+	}
+
+	src := &planningSource{}
+	validateIndexes(context.Background(), src, snap, findings)
+	if len(src.planned) != 0 {
+		t.Fatalf("planned %v without hypopg installed", src.planned)
+	}
+
+	snap.Extensions = []postgres.ExtensionInfo{{Schema: "public", Name: "hypopg"}}
+	validateIndexes(context.Background(), src, snap, findings)
+	if len(src.planned) != 1 || src.planned[0] != "CREATE INDEX ON public.users (email)" {
+		t.Errorf("planned %v", src.planned)
+	}
+	if got := findings[0].Detail["estimated_improvement"]; got != "75%" {
+		t.Errorf("estimated_improvement = %q, want 75%%", got)
+	}
+	if findings[1].Detail != nil {
+		t.Errorf("UNUSED_TABLE annotated: %v", findings[1].Detail)
+	}
+
+	// Sources that can't plan are skipped.
+	validateIndexes(context.Background(), &postgres.StaticSource{}, snap, findings[:1])
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ppiankov/pgspectre/internal/gitrepo"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// scanRefShare is the fraction of --max-memory that table references from
+// a code scan may hold before they spill to disk. The rest is left for
+// column references, the catalog snapshot, findings, and the GC.
+const scanRefShare = 4
+
+var maxMemory string
+
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+}
+
+// parseByteSize parses sizes like "512MiB", "512M", "1.5GB", or "1048576".
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// applyMemoryLimit sets the Go runtime's soft memory limit from
+// --max-memory (or defaults.max_memory), so the GC works harder instead of
+// letting the heap grow past it. It returns the limit in bytes, 0 if unset.
+func applyMemoryLimit() (int64, error) {
+	s := maxMemory
+	if s == "" {
+		s = cfg.Defaults.MaxMemory
+	}
+	if s == "" {
+		return 0, nil
+	}
+	limit, err := parseByteSize(s)
+	if err != nil {
+		return 0, fmt.Errorf("--max-memory: %w", err)
+	}
+	debug.SetMemoryLimit(limit)
+	slog.Debug("memory limit set", "bytes", limit)
+	return limit, nil
+}
+
+// addScanFlags registers the flags that change how code is scanned on the
+// commands that scan it.
+func addScanFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&parserName, "parser", "", "SQL parser for scanned code: regex, or ast to parse complete statements with libpg_query (builds with -tags pgquery)")
+	cmd.Flags().BoolVar(&replayMigrate, "replay-migrations", false, "replay versioned migration directories (Flyway, golang-migrate, Alembic) in order and reference only the tables they leave, so tables a later migration drops don't count as used")
+	cmd.Flags().BoolVar(&djangoTables, "django-tables", false, "reference the implicit <app_label>_<model> table of Django models without db_table; set django.app_labels where an app's label isn't its directory name")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "scan every file of the repository instead of reusing references from the scan cache (in the user cache directory, or $"+scanner.CacheDirEnv+") for files whose content hasn't changed")
+}
+
+// scanOptions configures a repository scan from the flags and config.
+func scanOptions(workers int) scanner.ScanOptions {
+	return scanner.ScanOptions{
+		Workers:          workers,
+		Parser:           scanParser,
+		ReplayMigrations: replayMigrate,
+		DjangoTables:     djangoTables,
+		DjangoAppLabels:  cfg.Django.AppLabels,
+		ExcludePaths:     cfg.Scan.ExcludePaths,
+		Extensions:       cfg.Scan.IncludeExtensions,
+		SkipDirs:         cfg.Scan.SkipDirs,
+		Cache:            !noCache,
+	}
+}
+
+// memoryLimit is the limit applied at startup, 0 if none.
+var memoryLimit int64
+
+// scanRepo scans a repository, spilling table references to a temp file
+// once they use more than their share of the memory limit. A git URL is
+// cloned at ref first. With changedSince set, only files changed since
+// that git ref are read and the rest come from the scan cache. Call Close
+// on the result when done.
+func scanRepo(repo, ref, changedSince string, workers int) (scanner.ScanResult, error) {
+	opts := scanOptions(workers)
+	if memoryLimit > 0 {
+		opts.MaxRefBytes = memoryLimit / scanRefShare
+	}
+	var scan scanner.ScanResult
+	dir, cleanup, err := checkoutRepo(repo, ref)
+	if err != nil {
+		return scan, err
+	}
+	defer cleanup()
+	if dir != repo {
+		if changedSince != "" {
+			return scan, fmt.Errorf("--changed-since needs a local checkout, not a git URL")
+		}
+		opts.Cache = false // the clone is removed after the scan
+	}
+
+	if changedSince != "" {
+		if noCache {
+			return scan, fmt.Errorf("--changed-since takes unchanged files from the scan cache and cannot be used with --no-cache")
+		}
+		scan, err = scanner.ScanChanged(dir, changedSince, opts)
+	} else {
+		scan, err = scanner.ScanWithOptions(dir, opts)
+	}
+	scan.RepoPath = gitrepo.RedactURL(repo)
+	if err != nil {
+		return scan, err
+	}
+	if n := scan.Spilled(); n > 0 {
+		slog.Info("scan references spilled to disk", "refs", n)
+	}
+	return scan, nil
+}
@@ -0,0 +1,33 @@
+package cli
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1048576", 1 << 20},
+		{"512MiB", 512 << 20},
+		{"512M", 512 << 20},
+		{"512mb", 512_000_000},
+		{"1.5GiB", 3 << 29},
+		{"64 KiB", 64 << 10},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.in)
+		if err != nil {
+			t.Errorf("parseByteSize(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "MiB", "0", "-1G", "12 parsecs"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("parseByteSize(%q): expected error", in)
+		}
+	}
+}
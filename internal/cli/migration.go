@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/spf13/cobra"
+)
+
+func newCheckMigrationCmd() *cobra.Command {
+	var (
+		format        string
+		failOn        string
+		noColor       bool
+		width         int
+		targetVersion int
+		repo          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check-migration <file.sql>",
+		Short: "Analyze a single migration file against the live database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dbURL == "" && replayDir == "" {
+				return fmt.Errorf("--db-url is required")
+			}
+			path := args[0]
+
+			if !cmd.Flags().Changed("format") && cfg.Defaults.Format != "" {
+				format = cfg.Defaults.Format
+			}
+			outputs, err := reporter.ParseOutputs(format)
+			if err != nil {
+				return err
+			}
+
+			stmts, err := migration.ParseFile(path)
+			if err != nil {
+				return err
+			}
+			slog.Info("migration parsed", "path", path, "statements", len(stmts))
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+			defer cancel()
+
+			inspector, err := connectCatalog(ctx, postgres.Config{URL: dbURL})
+			if err != nil {
+				return fmt.Errorf("connect: %w", err)
+			}
+			defer inspector.Close()
+
+			opts := migrationOptsFromConfig()
+			opts.ServerMajor = targetVersion
+			if opts.ServerMajor == 0 {
+				ver, err := inspector.ServerVersion(ctx)
+				if err != nil {
+					return fmt.Errorf("server version: %w", err)
+				}
+				opts.ServerMajor = postgres.MajorVersion(ver)
+				slog.Info("connected", "version", ver)
+			}
+
+			snap, err := inspector.Inspect(ctx)
+			if err != nil {
+				return fmt.Errorf("inspect: %w", err)
+			}
+
+			findings := analyzer.CheckMigration(path, stmts, snap, opts)
+			if repo != "" {
+				scan, err := scanRepo(repo, "", "", 0)
+				if err != nil {
+					return fmt.Errorf("scan repo: %w", err)
+				}
+				defer func() { _ = scan.Close() }()
+				slog.Info("scan complete", "refs", scan.RefCount(), "files", scan.FilesScanned)
+				if err := analyzer.LinkRenameCallSites(findings, &scan, relativeTo(repo, path)); err != nil {
+					return fmt.Errorf("link rename call sites: %w", err)
+				}
+			}
+			analyzer.AssignEffort(findings, effortOverridesFromConfig())
+
+			report := reporter.NewReport("check-migration", findings, buildVersion)
+			report.Metadata.URIHash = reporter.HashURI(dbURL)
+			report.Metadata.Database = extractDatabase(dbURL)
+			report.Scanned = scanContext(snap)
+
+			if err := writeReport(cmd.OutOrStdout(), &report, outputs, writeOptions(noColor, width)); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+
+			if failOn != "" && shouldFailOn(findings, failOn) {
+				return &ExitError{Code: 2}
+			}
+
+			code := analyzer.ExitCode(report.MaxSeverity)
+			if code != 0 {
+				return &ExitError{Code: code}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub; or format=path pairs, e.g. json=report.json,text=-")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit 2 if findings match (comma-separated types or severity: high,medium)")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output (also honors NO_COLOR)")
+	cmd.Flags().IntVar(&width, "width", 0, "text output width in columns (0=detect terminal, or COLUMNS when not a terminal; <=100 uses condensed layout)")
+	cmd.Flags().StringVar(&repo, "repo", "", "code repository to search for call sites of renamed tables and columns")
+	cmd.Flags().IntVar(&targetVersion, "target-version", 0, "PostgreSQL major version the migration will run on (0=use the connected server)")
+
+	return cmd
+}
+
+// relativeTo returns path relative to dir, or "" if it is outside dir.
+func relativeTo(dir, path string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
+
+func migrationOptsFromConfig() analyzer.MigrationOptions {
+	return analyzer.MigrationOptions{
+		LargeTableBytes: cfg.Thresholds.LargeTableBytes,
+		RunnerTimeouts:  cfg.Migration.RunnerTimeouts,
+	}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/config"
+)
+
+func TestNamingFromConfig(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	conv, err := namingFromConfig()
+	if err != nil || conv.Tables != nil || conv.Indexes != nil {
+		t.Fatalf("defaults: conv = %+v, err = %v", conv, err)
+	}
+
+	cfg.Naming = config.Naming{Tables: "^[a-z_]+$", TimestampColumns: "_at$"}
+	conv, err = namingFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conv.Tables == nil || !conv.TimestampColumns.MatchString("created_at") || conv.Columns != nil {
+		t.Errorf("conv = %+v", conv)
+	}
+
+	cfg.Naming.Indexes = "idx_("
+	if _, err := namingFromConfig(); err == nil || !strings.Contains(err.Error(), "naming.indexes") {
+		t.Errorf("err = %v, want naming.indexes error", err)
+	}
+}
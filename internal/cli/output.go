@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ppiankov/pgspectre/internal/reporter"
+)
+
+// outputFileMode is the permission for new report files. An existing file
+// keeps its mode.
+const outputFileMode os.FileMode = 0o644
+
+// writeReport writes the report once per output: to stdout (or --output)
+// for "-", and to its own file otherwise.
+func writeReport(w io.Writer, report *reporter.Report, outputs []reporter.Output, opts reporter.WriteOptions) error {
+	for _, out := range outputs {
+		write := func(w io.Writer) error {
+			return reporter.Write(w, report, out.Format, opts)
+		}
+		if out.Path == reporter.StdoutPath {
+			if err := toOutput(w, write); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeFileAtomic(out.Path, write); err != nil {
+			return fmt.Errorf("%s output: %w", out.Format, err)
+		}
+	}
+	return nil
+}
+
+// toOutput runs write against the --output file, or against w when no file
+// was given.
+func toOutput(w io.Writer, write func(io.Writer) error) error {
+	if outputPath == "" {
+		return write(w)
+	}
+	return writeFileAtomic(outputPath, write)
+}
+
+// writeFileAtomic writes to a temporary file in the same directory as path
+// and renames it into place only once write succeeds, so a failed run never
+// leaves a truncated report behind.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	mode := outputFileMode
+	if fi, err := os.Stat(path); err == nil {
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("%s: not a regular file", path)
+		}
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/reporter"
+)
+
+func TestWriteReport_MultipleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "report.json")
+	sarifPath := filepath.Join(dir, "report.sarif")
+	outputs, err := reporter.ParseOutputs("json=" + jsonPath + ",sarif=" + sarifPath + ",text=-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := reporter.NewReport("audit", nil, "test")
+	var stdout bytes.Buffer
+	if err := writeReport(&stdout, &report, outputs, reporter.WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "No findings.") {
+		t.Errorf("stdout = %q, want text report", stdout.String())
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil || !strings.Contains(string(data), `"command": "audit"`) {
+		t.Errorf("json file = %q, %v", data, err)
+	}
+	data, err = os.ReadFile(sarifPath)
+	if err != nil || !strings.Contains(string(data), `"version": "2.1.0"`) {
+		t.Errorf("sarif file = %q, %v", data, err)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "first")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != outputFileMode {
+		t.Errorf("mode = %v, want %v", fi.Mode().Perm(), outputFileMode)
+	}
+
+	// A failed write leaves the previous report and no temp files.
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("boom")
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "first" {
+		t.Errorf("content = %q, %v; want first", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the report", len(entries))
+	}
+
+	// Replacing an existing file keeps its mode.
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "second")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode after replace = %v, want 0600", fi.Mode().Perm())
+	}
+}
+
+func TestToOutput_OutputFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	outputPath = path
+	t.Cleanup(func() { outputPath = "" })
+
+	var stdout bytes.Buffer
+	if err := toOutput(&stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, "report")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want empty", stdout.String())
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "report") {
+		t.Errorf("file = %q, %v", data, err)
+	}
+}
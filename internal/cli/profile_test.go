@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/config"
+)
+
+func TestResolveProfile_Empty(t *testing.T) {
+	prof, err := resolveProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prof.checks != nil || prof.minSeverity != "" {
+		t.Errorf("expected default settings, got %+v", prof)
+	}
+}
+
+func TestResolveProfile_BuiltIn(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+	cfg = config.DefaultConfig()
+
+	prof, err := resolveProfile("security")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prof.checks) == 0 {
+		t.Error("expected security profile checks")
+	}
+}
+
+func TestResolveProfile_Config(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Profiles = map[string]config.Profile{
+		"nightly":  {Checks: []string{"unused_table", "SCHEMA_DRIFT"}, MinSeverity: "Low"},
+		"security": {Checks: []string{"RISKY_EXTENSION"}},
+	}
+
+	prof, err := resolveProfile("Nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []analyzer.FindingType{analyzer.FindingUnusedTable, analyzer.FindingMissingColumn}
+	if len(prof.checks) != len(want) || prof.checks[0] != want[0] || prof.checks[1] != want[1] {
+		t.Errorf("checks = %v, want %v", prof.checks, want)
+	}
+	if prof.minSeverity != "low" {
+		t.Errorf("minSeverity = %q, want low", prof.minSeverity)
+	}
+
+	// Config profiles replace built-ins of the same name.
+	prof, err = resolveProfile("security")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prof.checks) != 1 || prof.checks[0] != analyzer.FindingRiskyExtension {
+		t.Errorf("security override checks = %v", prof.checks)
+	}
+}
+
+func TestResolveProfile_InvalidMinSeverity(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Profiles = map[string]config.Profile{"pr": {MinSeverity: "urgent"}}
+
+	if _, err := resolveProfile("pr"); err == nil {
+		t.Fatal("expected invalid min_severity error")
+	}
+}
+
+func TestResolveProfile_UnknownCheck(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Profiles = map[string]config.Profile{"ci": {Checks: []string{"UNUSED_TABLE", "UNUSED_INDX"}}}
+
+	_, err := resolveProfile("ci")
+	if err == nil || !strings.Contains(err.Error(), `unknown check "UNUSED_INDX"`) {
+		t.Fatalf("err = %v, want unknown check", err)
+	}
+}
+
+func TestResolveProfile_UnknownListsConfigProfiles(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+
+	cfg = config.DefaultConfig()
+	cfg.Profiles = map[string]config.Profile{"nightly": {}}
+
+	_, err := resolveProfile("bogus")
+	if err == nil {
+		t.Fatal("expected unknown profile error")
+	}
+	if !strings.Contains(err.Error(), "nightly") || !strings.Contains(err.Error(), "security") {
+		t.Errorf("error should list available profiles: %v", err)
+	}
+}
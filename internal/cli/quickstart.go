@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/baseline"
+	"github.com/ppiankov/pgspectre/internal/config"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// quickstartMaxTables caps the catalog quickstart will audit; larger
+	// databases should be narrowed with --schema or --tables first.
+	quickstartMaxTables = 1000
+	// quickstartTopIssues is how many finding types are explained.
+	quickstartTopIssues = 5
+)
+
+// quickstartHint explains a finding type to someone new to pgspectre.
+type quickstartHint struct {
+	what string
+	fix  string
+}
+
+var quickstartHints = map[analyzer.FindingType]quickstartHint{
+	analyzer.FindingUnusedTable: {
+		"No query has read this table since statistics were last reset.",
+		"Check with its owners, then archive and drop it; or exclude it if it is read rarely on purpose.",
+	},
+	analyzer.FindingUnusedIndex: {
+		"The index has never been used, but every write still has to update it.",
+		"DROP INDEX CONCURRENTLY after confirming replicas don't use it either.",
+	},
+	analyzer.FindingBloatedIndex: {
+		"The index is larger than its table, usually from churn vacuum can't reclaim.",
+		"REINDEX INDEX CONCURRENTLY (PostgreSQL 12+) rebuilds it without blocking writes.",
+	},
+	analyzer.FindingDuplicateIndex: {
+		"Two indexes have the same definition, doubling the write cost for no gain.",
+		"Drop one of them with DROP INDEX CONCURRENTLY.",
+	},
+	analyzer.FindingInvalidIndex: {
+		"A CREATE INDEX CONCURRENTLY failed part way; the index is maintained but never used.",
+		"Drop it and create it again.",
+	},
+	analyzer.FindingMissingVacuum: {
+		"Autovacuum hasn't processed this active table recently, so dead rows accumulate.",
+		"Check autovacuum settings and long-running transactions; run VACUUM ANALYZE meanwhile.",
+	},
+	analyzer.FindingNoPrimaryKey: {
+		"Without a primary key rows can't be identified reliably, and logical replication can't update them.",
+		"Add a primary key, adding an identity column if no natural key exists.",
+	},
+	analyzer.FindingSequenceExhaustion: {
+		"The sequence is running out of values for its column type; inserts fail when it does.",
+		"Plan the migration of the column to bigint now, before it becomes urgent.",
+	},
+	analyzer.FindingHighSeqScan: {
+		"A large table is read mostly by full scans, which usually means a query lacks an index.",
+		"Find the query with pg_stat_statements and index the columns it filters on.",
+	},
+	analyzer.FindingAutovacuumDisabled: {
+		"The table turns autovacuum off or sets thresholds it never reaches.",
+		"ALTER TABLE ... RESET the storage parameter unless it is vacuumed by a job.",
+	},
+	analyzer.FindingMissingForeignKey: {
+		"A column named like a reference to another table has no foreign key, so orphaned rows go unnoticed.",
+		"Clean up orphaned rows, then add the foreign key NOT VALID and validate it.",
+	},
+	analyzer.FindingLowSelectivityIndex: {
+		"The index is on a column with very few distinct values, so the planner rarely uses it.",
+		"Drop it, or replace it with a partial index on the rare value.",
+	},
+	analyzer.FindingWideTable: {
+		"The table has many columns; every row read carries all of them.",
+		"Consider moving rarely read columns to a separate table.",
+	},
+	analyzer.FindingProblemIdentifier: {
+		"The name is a reserved word or needs quoting, which trips up ORMs and hand-written SQL.",
+		"Rename it when clients can be updated together.",
+	},
+}
+
+func newQuickstartCmd() *cobra.Command {
+	var (
+		schemaFlag   string
+		tablesFlag   string
+		configPath   string
+		baselinePath string
+		force        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "quickstart",
+		Short: "Guided first run: explain the top issues and write a starter config and baseline",
+		Long: "Runs a default audit, explains the most important kinds of findings with remediation hints, " +
+			"writes a starter .pgspectre.yml and a baseline of the current findings, and prints the next commands to run.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dbURL == "" && replayDir == "" {
+				return fmt.Errorf("--db-url is required")
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+			defer cancel()
+
+			inspector, err := connectCatalog(ctx, postgres.Config{URL: dbURL, Tables: resolveTablesFlag(tablesFlag)})
+			if err != nil {
+				return fmt.Errorf("connect: %w", err)
+			}
+			defer inspector.Close()
+
+			schemas := resolveSchemaFlag(schemaFlag)
+			n, err := inspector.CountTables(ctx, schemas)
+			if err != nil {
+				return err
+			}
+			if n > quickstartMaxTables {
+				return fmt.Errorf("database has %d tables; quickstart audits at most %d, so narrow it with --schema or --tables", n, quickstartMaxTables)
+			}
+
+			ver, err := inspector.ServerVersion(ctx)
+			if err != nil {
+				return fmt.Errorf("server version: %w", err)
+			}
+			snap, err := inspector.Inspect(ctx)
+			if err != nil {
+				return fmt.Errorf("inspect: %w", err)
+			}
+			snap = postgres.FilterSnapshot(snap, schemas)
+
+			opts := auditOptsFromConfig(schemas)
+			opts.PartialCatalog = len(resolveTablesFlag(tablesFlag)) > 0
+			opts.ServerMajor = postgres.MajorVersion(ver)
+			findings := analyzer.Audit(snap, opts)
+			analyzer.AssignEffort(findings, effortOverridesFromConfig())
+			tuning := analyzer.SuggestTuning(findings, opts, time.Now())
+
+			w := cmd.OutOrStdout()
+			if err := writeQuickstartSummary(w, extractDatabase(dbURL), ver, snap, findings, tuning); err != nil {
+				return err
+			}
+
+			wroteConfig, err := writeIfAbsent(configPath, force, func(w io.Writer) error {
+				return writeStarterConfig(w, schemas, tuning)
+			})
+			if err != nil {
+				return fmt.Errorf("write config: %w", err)
+			}
+			if err := reportWritten(w, configPath, wroteConfig, "starter config"); err != nil {
+				return err
+			}
+
+			wroteBaseline := false
+			if force || !fileExists(baselinePath) {
+				if err := baseline.Save(baselinePath, findings); err != nil {
+					return fmt.Errorf("save baseline: %w", err)
+				}
+				wroteBaseline = true
+			}
+			if err := reportWritten(w, baselinePath, wroteBaseline, fmt.Sprintf("baseline of %d current findings", len(findings))); err != nil {
+				return err
+			}
+
+			return writeNextSteps(w, baselinePath, topIssues(findings))
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaFlag, "schema", "", "comma-separated schemas to audit (default: all)")
+	cmd.Flags().StringVar(&tablesFlag, "tables", "", "comma-separated table globs to restrict the audit to")
+	cmd.Flags().StringVar(&configPath, "config", ".pgspectre.yml", "where to write the starter config")
+	cmd.Flags().StringVar(&baselinePath, "baseline", ".pgspectre-baseline.json", "where to write the baseline of current findings")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config or baseline")
+	return cmd
+}
+
+// issue is one finding type in the quickstart summary.
+type issue struct {
+	ft       analyzer.FindingType
+	severity analyzer.Severity
+	count    int
+	example  analyzer.Finding
+}
+
+// topIssues ranks finding types by their highest severity, then by count.
+func topIssues(findings []analyzer.Finding) []issue {
+	byType := make(map[analyzer.FindingType]*issue)
+	var issues []*issue
+	for _, f := range findings {
+		is := byType[f.Type]
+		if is == nil {
+			is = &issue{ft: f.Type, severity: f.Severity, example: f}
+			byType[f.Type] = is
+			issues = append(issues, is)
+		}
+		is.count++
+		if severityOrder[string(f.Severity)] > severityOrder[string(is.severity)] {
+			is.severity, is.example = f.Severity, f
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.severity != b.severity {
+			return severityOrder[string(a.severity)] > severityOrder[string(b.severity)]
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.ft < b.ft
+	})
+	out := make([]issue, 0, quickstartTopIssues)
+	for _, is := range issues {
+		if len(out) == quickstartTopIssues {
+			break
+		}
+		out = append(out, *is)
+	}
+	return out
+}
+
+func writeQuickstartSummary(w io.Writer, database, version string, snap *postgres.Snapshot, findings []analyzer.Finding, tuning *analyzer.Tuning) error {
+	counts := make(map[analyzer.Severity]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	if database == "" {
+		database = "database"
+	}
+	if _, err := fmt.Fprintf(w, "Audited %s (PostgreSQL %s): %d tables, %d indexes.\n", database, version, len(snap.Tables), len(snap.Indexes)); err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No issues found.")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Found %d issues: %d high, %d medium, %d low, %d info.\n",
+		len(findings), counts[analyzer.SeverityHigh], counts[analyzer.SeverityMedium], counts[analyzer.SeverityLow], counts[analyzer.SeverityInfo]); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "\nStart with these:"); err != nil {
+		return err
+	}
+	for i, is := range topIssues(findings) {
+		hint, ok := quickstartHints[is.ft]
+		if !ok {
+			hint = quickstartHint{what: is.example.Message, fix: "See the " + string(is.ft) + " entry in docs/cli-reference.md."}
+		}
+		label := "finding"
+		if is.count != 1 {
+			label = "findings"
+		}
+		if _, err := fmt.Fprintf(w, "\n  %d. %s (%s, %d %s), e.g. %s\n     %s\n     Fix: %s\n",
+			i+1, is.ft, is.severity, is.count, label, quickstartObject(is.example), hint.what, hint.fix); err != nil {
+			return err
+		}
+	}
+	if tuning != nil {
+		if _, err := fmt.Fprintf(w, "\n%d%% of the findings sit close to a default threshold; the starter config raises %d of them.\n",
+			tuning.NoiseScore, len(tuning.Suggestions)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// quickstartObject names the object a finding is about.
+func quickstartObject(f analyzer.Finding) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{f.Schema, f.Table} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	switch {
+	case f.Index != "":
+		parts = append(parts, f.Index)
+	case f.Column != "":
+		parts = append(parts, f.Column)
+	}
+	if len(parts) == 0 {
+		return "the server"
+	}
+	return strings.Join(parts, ".")
+}
+
+// writeStarterConfig writes a commented .pgspectre.yml with the current
+// thresholds, raised where the audit suggested it.
+func writeStarterConfig(w io.Writer, schemas []string, tuning *analyzer.Tuning) error {
+	th := cfg.Thresholds
+	tuned := make(map[string]bool)
+	if tuning != nil {
+		for _, s := range tuning.Suggestions {
+			if applyTuning(&th, s) {
+				tuned[s.Setting] = true
+			}
+		}
+	}
+	note := func(setting string) string {
+		if tuned["thresholds."+setting] {
+			return "  # raised by quickstart"
+		}
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("# pgspectre starter config, written by `pgspectre quickstart`.\n")
+	b.WriteString("# Keep credentials out of this file: set PGSPECTRE_DB_URL instead of db_url.\n\n")
+	if len(schemas) > 0 {
+		fmt.Fprintf(&b, "schemas: [%s]\n\n", strings.Join(schemas, ", "))
+	}
+	b.WriteString("thresholds:\n")
+	fmt.Fprintf(&b, "  vacuum_days: %d%s\n", th.VacuumDays, note("vacuum_days"))
+	fmt.Fprintf(&b, "  unused_index_min_bytes: %d%s\n", th.UnusedIndexMinBytes, note("unused_index_min_bytes"))
+	fmt.Fprintf(&b, "  bloat_min_bytes: %d%s\n", th.BloatMinBytes, note("bloat_min_bytes"))
+	fmt.Fprintf(&b, "  sequence_exhaustion_pct: %d\n", th.SequenceExhaustionPct)
+	fmt.Fprintf(&b, "  seq_scan_min_bytes: %d%s\n", th.SeqScanMinBytes, note("seq_scan_min_bytes"))
+	fmt.Fprintf(&b, "  seq_scan_ratio: %g%s\n", th.SeqScanRatio, note("seq_scan_ratio"))
+	fmt.Fprintf(&b, "  cache_hit_ratio: %g\n", th.CacheHitRatio)
+	fmt.Fprintf(&b, "  hot_table_blocks: %d\n", th.HotTableBlocks)
+	fmt.Fprintf(&b, "  row_width_bytes: %d%s\n", th.RowWidthBytes, note("row_width_bytes"))
+	fmt.Fprintf(&b, "  wide_table_columns: %d%s\n", th.WideTableColumns, note("wide_table_columns"))
+	b.WriteString("\n# Tables and schemas to leave out of every report.\n")
+	b.WriteString("exclude:\n  tables: []\n  schemas: []\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// applyTuning sets the threshold a suggestion names. It reports false for
+// settings it doesn't know.
+func applyTuning(th *config.Thresholds, s analyzer.TuningSuggestion) bool {
+	switch s.Setting {
+	case "thresholds.vacuum_days":
+		th.VacuumDays = int(s.Value)
+	case "thresholds.unused_index_min_bytes":
+		th.UnusedIndexMinBytes = int64(s.Value)
+	case "thresholds.bloat_min_bytes":
+		th.BloatMinBytes = int64(s.Value)
+	case "thresholds.seq_scan_min_bytes":
+		th.SeqScanMinBytes = int64(s.Value)
+	case "thresholds.seq_scan_ratio":
+		th.SeqScanRatio = s.Value
+	case "thresholds.row_width_bytes":
+		th.RowWidthBytes = int64(s.Value)
+	case "thresholds.wide_table_columns":
+		th.WideTableColumns = int(s.Value)
+	default:
+		return false
+	}
+	return true
+}
+
+// writeIfAbsent writes path unless it exists and force is off. It reports
+// whether the file was written.
+func writeIfAbsent(path string, force bool, write func(io.Writer) error) (bool, error) {
+	if !force && fileExists(path) {
+		return false, nil
+	}
+	return true, writeFileAtomic(path, write)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func reportWritten(w io.Writer, path string, wrote bool, what string) error {
+	if wrote {
+		_, err := fmt.Fprintf(w, "Wrote %s (%s).\n", path, what)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Kept existing %s; rerun with --force to replace it.\n", path)
+	return err
+}
+
+func writeNextSteps(w io.Writer, baselinePath string, issues []issue) error {
+	steps := [][2]string{
+		{"pgspectre audit --baseline " + baselinePath, "report only findings that are new from now on"},
+	}
+	if len(issues) > 0 {
+		steps = append(steps, [2]string{"pgspectre audit --type " + string(issues[0].ft), "review one kind of issue at a time"})
+	}
+	steps = append(steps,
+		[2]string{"pgspectre check --repo . --baseline " + baselinePath, "compare the tables your code uses with the database"},
+		[2]string{"pgspectre audit --profile security", "run the opt-in security checks"},
+	)
+
+	width := 0
+	for _, s := range steps {
+		width = max(width, len(s[0]))
+	}
+	if _, err := fmt.Fprintln(w, "\nNext steps:"); err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if _, err := fmt.Fprintf(w, "  %-*s  # %s\n", width, s[0], s[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
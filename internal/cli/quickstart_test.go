@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/baseline"
+	"github.com/ppiankov/pgspectre/internal/config"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"go.yaml.in/yaml/v3"
+)
+
+func runQuickstart(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append([]string{"quickstart", "--db-url", "postgres://static/shop"}, args...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("quickstart: %v\n%s", err, out.String())
+	}
+	return out.String()
+}
+
+func TestQuickstartCmd(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "pgspectre.yml")
+	basePath := filepath.Join(dir, "baseline.json")
+
+	out := runQuickstart(t, "--config", cfgPath, "--baseline", basePath)
+	for _, want := range []string{
+		"Audited shop (PostgreSQL 16.2): 2 tables",
+		"1. UNUSED_TABLE (high, 1 finding), e.g. public.audit_log",
+		"Fix: Check with its owners",
+		"Wrote " + cfgPath + " (starter config).",
+		"pgspectre audit --baseline " + basePath,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written config.Config
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		t.Fatalf("starter config is not valid YAML: %v\n%s", err, data)
+	}
+	if written.Thresholds.VacuumDays != 30 || written.DBURL != "" {
+		t.Errorf("starter config = %+v", written)
+	}
+
+	b, err := baseline.Load(basePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Contains(&analyzer.Finding{Type: analyzer.FindingUnusedTable, Schema: "public", Table: "audit_log"}) {
+		t.Error("baseline is missing the UNUSED_TABLE finding")
+	}
+
+	// A second run keeps the files it wrote.
+	if err := os.WriteFile(cfgPath, []byte("# edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out = runQuickstart(t, "--config", cfgPath, "--baseline", basePath)
+	if !strings.Contains(out, "Kept existing "+cfgPath) {
+		t.Errorf("expected config to be kept:\n%s", out)
+	}
+	if data, _ := os.ReadFile(cfgPath); string(data) != "# edited\n" {
+		t.Errorf("config was overwritten: %q", data)
+	}
+}
+
+func TestApplyTuning(t *testing.T) {
+	th := config.DefaultConfig().Thresholds
+	if !applyTuning(&th, analyzer.TuningSuggestion{Setting: "thresholds.unused_index_min_bytes", Value: 524288000}) {
+		t.Fatal("unused_index_min_bytes not applied")
+	}
+	if th.UnusedIndexMinBytes != 524288000 {
+		t.Errorf("UnusedIndexMinBytes = %d", th.UnusedIndexMinBytes)
+	}
+	if applyTuning(&th, analyzer.TuningSuggestion{Setting: "thresholds.unknown"}) {
+		t.Error("unknown setting applied")
+	}
+}
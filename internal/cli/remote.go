@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ppiankov/pgspectre/internal/gitrepo"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// checkoutRepo returns the directory to scan for --repo: the path itself,
+// or a shallow clone at ref if it is a git URL. Call cleanup when done.
+func checkoutRepo(repo, ref string) (dir string, cleanup func(), err error) {
+	if !gitrepo.IsURL(repo) {
+		if ref != "" {
+			return "", nil, fmt.Errorf("--ref only applies when --repo is a git URL")
+		}
+		return repo, func() {}, nil
+	}
+	slog.Info("cloning repo", "url", gitrepo.RedactURL(repo), "ref", ref)
+	return scanner.CloneRepo(repo, ref)
+}
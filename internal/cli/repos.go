@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/gitrepo"
+	"github.com/ppiankov/pgspectre/internal/scanner"
+)
+
+// repoSpec is a repository to scan: a path or git URL, and the ref to
+// clone if it is a URL.
+type repoSpec struct {
+	repo string
+	ref  string
+}
+
+// readRepoList reads a file listing repositories, one per line: a path,
+// relative to the file, or a git URL, optionally followed by the ref to
+// clone. Blank lines and lines starting with # are skipped.
+func readRepoList(path string) ([]repoSpec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var specs []repoSpec
+	sc := bufio.NewScanner(f)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: want a repo and an optional ref", path, lineNum)
+		}
+		s := repoSpec{repo: fields[0]}
+		if len(fields) == 2 {
+			s.ref = fields[1]
+		}
+		if !gitrepo.IsURL(s.repo) && !filepath.IsAbs(s.repo) {
+			s.repo = filepath.Join(filepath.Dir(path), s.repo)
+		}
+		specs = append(specs, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s: no repos", path)
+	}
+	return specs, nil
+}
+
+// repoNames names each repository after the last element of its path or
+// URL, without .git, numbering repeats: app, app-2.
+func repoNames(specs []repoSpec) []string {
+	names := make([]string, len(specs))
+	seen := make(map[string]int)
+	for i, s := range specs {
+		repo := strings.TrimRight(s.repo, "/")
+		if !gitrepo.IsURL(repo) {
+			if abs, err := filepath.Abs(repo); err == nil {
+				repo = abs
+			}
+		}
+		name := strings.TrimSuffix(repo[strings.LastIndexAny(repo, `/:\`)+1:], ".git")
+		if name == "" {
+			name = "repo"
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name += "-" + strconv.Itoa(n)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// scanRepos scans each repository and merges their references. With more
+// than one, each reference's file is prefixed with its repository's name.
+// Call Close on the result when done.
+func scanRepos(specs []repoSpec, changedSince string, workers int) (scanner.ScanResult, error) {
+	if len(specs) == 1 {
+		return scanRepo(specs[0].repo, specs[0].ref, changedSince, workers)
+	}
+	results := make([]scanner.ScanResult, 0, len(specs))
+	defer func() {
+		for _, r := range results {
+			_ = r.Close()
+		}
+	}()
+	for _, s := range specs {
+		slog.Debug("scanning repo", "path", gitrepo.RedactURL(s.repo))
+		r, err := scanRepo(s.repo, s.ref, changedSince, workers)
+		if err != nil {
+			return r, fmt.Errorf("%s: %w", gitrepo.RedactURL(s.repo), err)
+		}
+		results = append(results, r)
+	}
+	var maxRefBytes int64
+	if memoryLimit > 0 {
+		maxRefBytes = memoryLimit / scanRefShare
+	}
+	return scanner.Merge(results, repoNames(specs), maxRefBytes)
+}
+
+// checkRepos collects the repositories given to check with --repo and
+// --repos-file. ref applies to the git URLs given with --repo.
+func checkRepos(repos []string, reposFile, ref string) ([]repoSpec, error) {
+	var specs []repoSpec
+	remote := false
+	for _, r := range repos {
+		s := repoSpec{repo: r}
+		if gitrepo.IsURL(r) {
+			s.ref, remote = ref, true
+		}
+		specs = append(specs, s)
+	}
+	if ref != "" && !remote {
+		return nil, fmt.Errorf("--ref only applies when --repo is a git URL")
+	}
+	if reposFile != "" {
+		listed, err := readRepoList(reposFile)
+		if err != nil {
+			return nil, fmt.Errorf("--repos-file: %w", err)
+		}
+		specs = append(specs, listed...)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("--repo is required")
+	}
+	return specs, nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRepoList(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "repos.txt", `# shared database
+services/web
+/src/worker
+
+git@github.com:acme/billing.git release-2.4
+`)
+	specs, err := readRepoList(filepath.Join(dir, "repos.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []repoSpec{
+		{repo: filepath.Join(dir, "services", "web")},
+		{repo: "/src/worker"},
+		{repo: "git@github.com:acme/billing.git", ref: "release-2.4"},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("specs = %+v, want %+v", specs, want)
+	}
+
+	writeTestFile(t, dir, "bad.txt", "web main extra\n")
+	if _, err := readRepoList(filepath.Join(dir, "bad.txt")); err == nil || !strings.Contains(err.Error(), "bad.txt:1") {
+		t.Errorf("expected line error, got %v", err)
+	}
+	writeTestFile(t, dir, "empty.txt", "# nothing\n")
+	if _, err := readRepoList(filepath.Join(dir, "empty.txt")); err == nil {
+		t.Error("expected error for a list without repos")
+	}
+}
+
+func TestRepoNames(t *testing.T) {
+	specs := []repoSpec{
+		{repo: "/src/web/"},
+		{repo: "https://github.com/acme/billing.git"},
+		{repo: "git@github.com:other/web.git"},
+		{repo: "git@host:web"},
+	}
+	want := []string{"web", "billing", "web-2", "web-3"}
+	if got := repoNames(specs); !reflect.DeepEqual(got, want) {
+		t.Errorf("repoNames = %v, want %v", got, want)
+	}
+}
+
+func TestCheckRepos(t *testing.T) {
+	specs, err := checkRepos([]string{"./web", "https://github.com/acme/billing.git"}, "", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []repoSpec{{repo: "./web"}, {repo: "https://github.com/acme/billing.git", ref: "main"}}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("specs = %+v, want %+v", specs, want)
+	}
+
+	if _, err := checkRepos([]string{"./web"}, "", "main"); err == nil || !strings.Contains(err.Error(), "--ref") {
+		t.Errorf("expected --ref error, got %v", err)
+	}
+	if _, err := checkRepos(nil, "", ""); err == nil || !strings.Contains(err.Error(), "--repo is required") {
+		t.Errorf("expected --repo error, got %v", err)
+	}
+}
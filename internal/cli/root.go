@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
-	"net/url"
 	"os"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ppiankov/pgspectre/internal/analyzer"
 	"github.com/ppiankov/pgspectre/internal/baseline"
@@ -40,10 +43,20 @@ type BuildInfo struct {
 }
 
 var (
-	dbURL        string
-	verbose      bool
-	cfg          config.Config
-	buildVersion string
+	dbURL           string
+	verbose         bool
+	canonical       bool
+	reportTimestamp string
+	outputPath      string
+	recordDir       string
+	replayDir       string
+	parserName      string
+	scanParser      scanner.Parser
+	replayMigrate   bool
+	djangoTables    bool
+	noCache         bool
+	cfg             config.Config
+	buildVersion    string
 )
 
 func newRootCmd(info BuildInfo) *cobra.Command {
@@ -78,17 +91,51 @@ func newRootCmd(info BuildInfo) *cobra.Command {
 					dbURL = cfg.DBURL
 				}
 			}
-			return nil
+			if recordDir != "" && replayDir != "" {
+				return fmt.Errorf("--record and --replay cannot be used together")
+			}
+			if reportTimestamp != "" {
+				if _, err := time.Parse(time.RFC3339, reportTimestamp); err != nil {
+					return fmt.Errorf("--timestamp must be RFC 3339, e.g. 2024-01-01T00:00:00Z: %w", err)
+				}
+			}
+			if parserName == "" {
+				parserName = cfg.Defaults.Parser
+			}
+			if scanParser, err = scanner.ParseParser(parserName); err != nil {
+				return fmt.Errorf("--parser: %w", err)
+			}
+			if !cmd.Flags().Changed("replay-migrations") && cfg.Defaults.ReplayMigrations {
+				replayMigrate = true
+			}
+			if !cmd.Flags().Changed("django-tables") && cfg.Defaults.DjangoTables {
+				djangoTables = true
+			}
+			memoryLimit, err = applyMemoryLimit()
+			return err
 		},
 	}
 
 	root.PersistentFlags().StringVar(&dbURL, "db-url", "", "PostgreSQL connection URL (or set PGSPECTRE_DB_URL)")
 	root.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable debug-level logging")
+	root.PersistentFlags().BoolVar(&canonical, "canonical", false, "byte-stable json, sarif, and spectrehub output: sorted keys and no generation time unless --timestamp is set")
+	root.PersistentFlags().StringVar(&reportTimestamp, "timestamp", "", "report timestamp to use instead of the current time (RFC 3339)")
+	root.PersistentFlags().StringVar(&outputPath, "output", "", "write the report to this file instead of stdout; the file is replaced atomically once the report is complete")
+	root.PersistentFlags().StringVar(&recordDir, "record", "", "save catalog query results to this fixture directory for --replay")
+	root.PersistentFlags().StringVar(&replayDir, "replay", "", "read catalog query results from a fixture directory saved with --record instead of connecting")
+	root.PersistentFlags().StringVar(&maxMemory, "max-memory", "", "soft memory limit, e.g. 512MiB; the GC works harder near it and scan references spill to a temp file")
 
 	root.AddCommand(newVersionCmd(info))
 	root.AddCommand(newAuditCmd())
 	root.AddCommand(newCheckCmd())
 	root.AddCommand(newScanCmd())
+	root.AddCommand(newCheckMigrationCmd())
+	root.AddCommand(newCheckSchemaCmd())
+	root.AddCommand(newQuickstartCmd())
+	root.AddCommand(newWorkspaceCmd())
+	root.AddCommand(newFixCmd())
+	root.AddCommand(newBenchCmd())
+	root.AddCommand(newDevtoolsCmd())
 
 	return root
 }
@@ -123,34 +170,74 @@ func newAuditCmd() *cobra.Command {
 		baselinePath   string
 		updateBaseline string
 		minSeverity    string
+		minConfidence  string
 		typeFilter     string
 		schemaFlag     string
+		tablesFlag     string
+		profile        string
 		noColor        bool
+		width          int
+		estimateOnly   bool
+		sampleBackfill bool
+		activity       bool
+		clusterHealth  bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "audit",
 		Short: "Cluster-only analysis: unused tables, indexes, missing stats",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if dbURL == "" {
+			if dbURL == "" && replayDir == "" {
 				return fmt.Errorf("--db-url is required")
 			}
+			prof, err := resolveProfile(profile)
+			if err != nil {
+				return err
+			}
+			naming, err := namingFromConfig()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("min-severity") && prof.minSeverity != "" {
+				minSeverity = prof.minSeverity
+			}
+			confidence, err := parseMinConfidence(minConfidence)
+			if err != nil {
+				return err
+			}
 
 			// Use config format as default if flag not explicitly set
 			if !cmd.Flags().Changed("format") && cfg.Defaults.Format != "" {
 				format = cfg.Defaults.Format
 			}
+			outputs, err := reporter.ParseOutputs(format)
+			if err != nil {
+				return err
+			}
 
 			timeout := cfg.TimeoutDuration()
 			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
 			defer cancel()
 
-			inspector, err := postgres.NewInspector(ctx, postgres.Config{URL: dbURL})
+			pcfg := catalogConfig(resolveTablesFlag(tablesFlag), prof.checks)
+			inspector, err := connectCatalog(ctx, pcfg)
 			if err != nil {
 				return fmt.Errorf("connect: %w", err)
 			}
 			defer inspector.Close()
 
+			schemas := resolveSchemaFlag(schemaFlag)
+			if estimateOnly {
+				tables, err := inspector.CountTables(ctx, schemas)
+				if err != nil {
+					return err
+				}
+				e := buildEstimate("audit", nil, tables, postgres.InspectQueries(pcfg), 0)
+				return toOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+					return writeEstimate(w, &e, estimateFormat(outputs))
+				})
+			}
+
 			ver, err := inspector.ServerVersion(ctx)
 			if err != nil {
 				return fmt.Errorf("server version: %w", err)
@@ -162,10 +249,23 @@ func newAuditCmd() *cobra.Command {
 				return fmt.Errorf("inspect: %w", err)
 			}
 
-			schemas := resolveSchemaFlag(schemaFlag)
 			snap = postgres.FilterSnapshot(snap, schemas)
 			slog.Info("inspected", "tables", len(snap.Tables), "indexes", len(snap.Indexes), "constraints", len(snap.Constraints), "schemas", schemas)
 
+			if sampleBackfill {
+				sampleBackfills(ctx, inspector, snap)
+			}
+			if activity {
+				if err := gatherActivity(ctx, inspector, snap); err != nil {
+					return fmt.Errorf("activity: %w", err)
+				}
+			}
+			if clusterHealth {
+				if err := gatherClusterHealth(ctx, inspector, snap); err != nil {
+					return fmt.Errorf("cluster health: %w", err)
+				}
+			}
+
 			if len(snap.Tables) == 0 {
 				schemaHint := "public"
 				if len(schemas) > 0 {
@@ -174,11 +274,19 @@ func newAuditCmd() *cobra.Command {
 				slog.Warn("no tables found", "schemas", schemaHint)
 			}
 
-			findings := analyzer.Audit(snap, auditOptsFromConfig(schemas))
+			opts := auditOptsFromConfig(schemas)
+			opts.Checks = prof.checks
+			opts.Naming = naming
+			opts.PartialCatalog = len(resolveTablesFlag(tablesFlag)) > 0
+			opts.ServerMajor = postgres.MajorVersion(ver)
+			findings := analyzer.Audit(snap, opts)
+			analyzer.AssignEffort(findings, effortOverridesFromConfig())
 			totalBeforeFilter := len(findings)
 
 			// Apply report filters (severity, type)
 			findings = applyReportFilters(findings, minSeverity, typeFilter)
+			findings = filterByConfidence(findings, confidence)
+			validateIndexes(ctx, inspector, snap, findings)
 
 			// Save baseline before baseline/suppress filtering
 			if updateBaseline != "" {
@@ -193,15 +301,14 @@ func newAuditCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			analyzer.Correlate(findings)
 
 			report := reporter.NewReport("audit", findings, buildVersion)
 			report.Metadata.URIHash = reporter.HashURI(dbURL)
 			report.Metadata.Database = extractDatabase(dbURL)
-			report.Scanned = reporter.ScanContext{
-				Tables:  len(snap.Tables),
-				Indexes: len(snap.Indexes),
-				Schemas: countSchemas(snap),
-			}
+			report.Scanned = scanContext(snap)
+			report.Instance = analyzer.SummarizeInstance(snap.Instance, snap.IO)
+			report.Tuning = analyzer.SuggestTuning(report.Findings, opts, time.Now())
 			filtered := totalBeforeFilter - len(findings) - totalSuppressed
 			if totalSuppressed > 0 || filtered > 0 {
 				slog.Info("findings filtered",
@@ -211,7 +318,7 @@ func newAuditCmd() *cobra.Command {
 					"filtered", filtered)
 			}
 
-			if err := reporter.Write(cmd.OutOrStdout(), &report, reporter.Format(format), reporter.WriteOptions{NoColor: noColor}); err != nil {
+			if err := writeReport(cmd.OutOrStdout(), &report, outputs, writeOptions(noColor, width)); err != nil {
 				return fmt.Errorf("write report: %w", err)
 			}
 
@@ -227,64 +334,114 @@ func newAuditCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub; or format=path pairs, e.g. json=report.json,text=-")
 	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit 2 if findings match (comma-separated types or severity: high,medium)")
 	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "show only findings at or above this severity (high, medium, low, info)")
+	cmd.Flags().StringVar(&minConfidence, "min-confidence", "", "show only findings at or above this confidence (high, medium, low)")
 	cmd.Flags().StringVar(&typeFilter, "type", "", "show only these finding types (comma-separated, e.g. UNUSED_INDEX,BLOATED_INDEX)")
 	cmd.Flags().StringVar(&schemaFlag, "schema", "", "schemas to analyze (comma-separated, or 'all' for all non-system schemas)")
-	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output")
+	cmd.Flags().StringVar(&tablesFlag, "tables", "", "only inspect tables matching these globs (comma-separated, e.g. \"orders*,payments\")")
+	cmd.Flags().StringVar(&profile, "profile", "", "run a focused set of checks: "+strings.Join(analyzer.ProfileNames(), ", ")+", or a profile from .pgspectre.yml")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output (also honors NO_COLOR)")
+	cmd.Flags().IntVar(&width, "width", 0, "text output width in columns (0=detect terminal, or COLUMNS when not a terminal; <=100 uses condensed layout)")
 	cmd.Flags().StringVar(&baselinePath, "baseline", "", "path to baseline file (suppress known findings)")
 	cmd.Flags().StringVar(&updateBaseline, "update-baseline", "", "save current findings as new baseline")
+	cmd.Flags().BoolVar(&estimateOnly, "estimate", false, "count tables and print an estimated runtime without running the audit")
+	cmd.Flags().BoolVar(&sampleBackfill, "sample-backfill", false, "sample columns with a pending NOT NULL check for their current NULL fraction instead of relying on pg_stats")
+	cmd.Flags().BoolVar(&activity, "activity", false, "also snapshot pg_stat_activity and pg_locks for long idle-in-transaction sessions and lock wait chains")
+	cmd.Flags().BoolVar(&clusterHealth, "cluster-health", false, "also check replication slots, replica lag, and WAL archiving (needs pg_read_all_stats or superuser for replica lag)")
 
 	return cmd
 }
 
 func newCheckCmd() *cobra.Command {
 	var (
-		repo           string
+		repos          []string
+		reposFile      string
 		format         string
 		failOn         string
 		failOnMissing  bool
 		failOnDrift    bool
 		minSeverity    string
+		minConfidence  string
 		typeFilter     string
 		schemaFlag     string
+		tablesFlag     string
+		profile        string
 		noColor        bool
+		width          int
 		baselinePath   string
 		updateBaseline string
 		parallel       int
+		estimateOnly   bool
+		changedSince   string
+		ref            string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "check",
 		Short: "Code repo + cluster: missing tables, schema drift, unindexed queries",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if dbURL == "" {
+			if dbURL == "" && replayDir == "" {
 				return fmt.Errorf("--db-url is required")
 			}
-			if repo == "" {
-				return fmt.Errorf("--repo is required")
+			specs, err := checkRepos(repos, reposFile, ref)
+			if err != nil {
+				return err
+			}
+			prof, err := resolveProfile(profile)
+			if err != nil {
+				return err
+			}
+			naming, err := namingFromConfig()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("min-severity") && prof.minSeverity != "" {
+				minSeverity = prof.minSeverity
+			}
+			confidence, err := parseMinConfidence(minConfidence)
+			if err != nil {
+				return err
 			}
 
 			// Use config format as default if flag not explicitly set
 			if !cmd.Flags().Changed("format") && cfg.Defaults.Format != "" {
 				format = cfg.Defaults.Format
 			}
+			outputs, err := reporter.ParseOutputs(format)
+			if err != nil {
+				return err
+			}
+
+			tables := resolveTablesFlag(tablesFlag)
+			if estimateOnly {
+				return runCheckEstimate(cmd, specs, resolveSchemaFlag(schemaFlag), catalogConfig(tables, prof.checks), parallel, estimateFormat(outputs))
+			}
 
-			// Scan code repo (no timeout needed — local filesystem)
-			slog.Debug("scanning repo", "path", repo)
-			scan, err := scanner.ScanParallel(repo, parallel)
+			// Scan code repos (no timeout needed — local filesystem)
+			slog.Debug("scanning repos", "repos", len(specs))
+			scan, err := scanRepos(specs, changedSince, parallel)
 			if err != nil {
 				return fmt.Errorf("scan repo: %w", err)
 			}
-			slog.Info("scan complete", "refs", len(scan.Refs), "files", scan.FilesScanned)
+			defer func() { _ = scan.Close() }()
+			slog.Info("scan complete", "refs", scan.RefCount(), "files", scan.FilesScanned)
+			if matcher := postgres.NewTableMatcher(tables); matcher != nil {
+				restricted, err := scan.Restrict(matcher.Match)
+				if err != nil {
+					return fmt.Errorf("scan repo: %w", err)
+				}
+				_ = scan.Close()
+				scan = restricted
+			}
 
 			// Connect to PostgreSQL
 			timeout := cfg.TimeoutDuration()
 			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
 			defer cancel()
 
-			inspector, err := postgres.NewInspector(ctx, postgres.Config{URL: dbURL})
+			inspector, err := connectCatalog(ctx, catalogConfig(tables, prof.checks))
 			if err != nil {
 				return fmt.Errorf("connect: %w", err)
 			}
@@ -314,11 +471,19 @@ func newCheckCmd() *cobra.Command {
 			}
 
 			// Run diff analysis
-			findings := analyzer.Diff(&scan, snap, auditOptsFromConfig(schemas))
+			opts := auditOptsFromConfig(schemas)
+			opts.Checks = prof.checks
+			opts.Naming = naming
+			opts.PartialCatalog = len(tables) > 0
+			opts.ServerMajor = postgres.MajorVersion(ver)
+			findings := analyzer.Diff(&scan, snap, opts)
+			analyzer.AssignEffort(findings, effortOverridesFromConfig())
 			totalBeforeFilter := len(findings)
 
 			// Apply report filters (severity, type)
 			findings = applyReportFilters(findings, minSeverity, typeFilter)
+			findings = filterByConfidence(findings, confidence)
+			validateIndexes(ctx, inspector, snap, findings)
 
 			// Save baseline before baseline/suppress filtering
 			if updateBaseline != "" {
@@ -333,15 +498,14 @@ func newCheckCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			analyzer.Correlate(findings)
 
 			report := reporter.NewReport("check", findings, buildVersion)
 			report.Metadata.URIHash = reporter.HashURI(dbURL)
 			report.Metadata.Database = extractDatabase(dbURL)
-			report.Scanned = reporter.ScanContext{
-				Tables:  len(snap.Tables),
-				Indexes: len(snap.Indexes),
-				Schemas: countSchemas(snap),
-			}
+			report.Scanned = scanContext(snap)
+			report.Instance = analyzer.SummarizeInstance(snap.Instance, snap.IO)
+			report.Tuning = analyzer.SuggestTuning(report.Findings, opts, time.Now())
 			filtered := totalBeforeFilter - len(findings) - totalSuppressed
 			if totalSuppressed > 0 || filtered > 0 {
 				slog.Info("findings filtered",
@@ -351,7 +515,7 @@ func newCheckCmd() *cobra.Command {
 					"filtered", filtered)
 			}
 
-			if err := reporter.Write(cmd.OutOrStdout(), &report, reporter.Format(format), reporter.WriteOptions{NoColor: noColor}); err != nil {
+			if err := writeReport(cmd.OutOrStdout(), &report, outputs, writeOptions(noColor, width)); err != nil {
 				return fmt.Errorf("write report: %w", err)
 			}
 
@@ -369,22 +533,79 @@ func newCheckCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&repo, "repo", "", "path to code repository to scan")
-	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub")
+	cmd.Flags().StringSliceVar(&repos, "repo", nil, "path to code repository to scan, or a git URL (https or ssh) to clone shallowly; repeat or comma-separate to merge the references of several repos")
+	cmd.Flags().StringVar(&reposFile, "repos-file", "", "file listing repos to scan, one path or git URL (with an optional ref) per line, on top of --repo")
+	cmd.Flags().StringVar(&ref, "ref", "", "branch, tag, or commit to clone when --repo is a git URL (default: the default branch)")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub; or format=path pairs, e.g. json=report.json,text=-")
 	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit 2 if findings match (comma-separated types or severity: high,medium)")
 	cmd.Flags().BoolVar(&failOnMissing, "fail-on-missing", false, "exit 2 if any MISSING_TABLE found (deprecated, use --fail-on)")
 	cmd.Flags().BoolVar(&failOnDrift, "fail-on-drift", false, "exit 2 if any schema drift found (alias for MISSING_COLUMN, deprecated, use --fail-on)")
 	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "show only findings at or above this severity (high, medium, low, info)")
+	cmd.Flags().StringVar(&minConfidence, "min-confidence", "", "show only findings at or above this confidence (high, medium, low)")
 	cmd.Flags().StringVar(&typeFilter, "type", "", "show only these finding types (comma-separated, e.g. MISSING_TABLE,UNUSED_INDEX)")
 	cmd.Flags().StringVar(&schemaFlag, "schema", "", "schemas to analyze (comma-separated, or 'all' for all non-system schemas)")
-	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output")
+	cmd.Flags().StringVar(&tablesFlag, "tables", "", "only inspect tables matching these globs (comma-separated, e.g. \"orders*,payments\")")
+	cmd.Flags().StringVar(&profile, "profile", "", "run a focused set of checks: "+strings.Join(analyzer.ProfileNames(), ", ")+", or a profile from .pgspectre.yml")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output (also honors NO_COLOR)")
+	cmd.Flags().IntVar(&width, "width", 0, "text output width in columns (0=detect terminal, or COLUMNS when not a terminal; <=100 uses condensed layout)")
 	cmd.Flags().StringVar(&baselinePath, "baseline", "", "path to baseline file (suppress known findings)")
 	cmd.Flags().StringVar(&updateBaseline, "update-baseline", "", "save current findings as new baseline")
 	cmd.Flags().IntVar(&parallel, "parallel", 0, "number of scanner goroutines (0=NumCPU, 1=sequential)")
+	cmd.Flags().BoolVar(&estimateOnly, "estimate", false, "count files and tables and print an estimated runtime without running the check")
+	cmd.Flags().StringVar(&changedSince, "changed-since", "", "read only files changed since this git ref (or its merge-base with HEAD); the rest come from the scan cache")
+	addScanFlags(cmd)
 
 	return cmd
 }
 
+// runCheckEstimate counts repo files and database tables for --estimate.
+func runCheckEstimate(cmd *cobra.Command, specs []repoSpec, schemas []string, pcfg postgres.Config, parallel int, format reporter.Format) error {
+	var files scanner.FileCount
+	for _, s := range specs {
+		dir, cleanup, err := checkoutRepo(s.repo, s.ref)
+		if err != nil {
+			return fmt.Errorf("scan repo: %w", err)
+		}
+		count, err := scanner.CountFiles(dir, scanOptions(parallel))
+		cleanup()
+		if err != nil {
+			return fmt.Errorf("scan repo: %w", err)
+		}
+		files.Files += count.Files
+		files.Skipped += count.Skipped
+		files.Bytes += count.Bytes
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+	defer cancel()
+
+	inspector, err := connectCatalog(ctx, pcfg)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer inspector.Close()
+
+	n, err := inspector.CountTables(ctx, schemas)
+	if err != nil {
+		return err
+	}
+	e := buildEstimate("check", &files, n, postgres.InspectQueries(pcfg), parallel)
+	return toOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+		return writeEstimate(w, &e, format)
+	})
+}
+
+// writeOptions builds reporter options from CLI flags and config.
+func writeOptions(noColor bool, width int) reporter.WriteOptions {
+	return reporter.WriteOptions{
+		NoColor:   noColor,
+		Theme:     reporter.Theme(cfg.Output.Theme),
+		Width:     width,
+		Canonical: canonical,
+		Timestamp: reportTimestamp,
+	}
+}
+
 // filterFindings applies baseline and suppression rules to findings.
 func filterFindings(findings []analyzer.Finding, baselinePath string) ([]analyzer.Finding, int, error) {
 	totalSuppressed := 0
@@ -479,6 +700,33 @@ func applyReportFilters(findings []analyzer.Finding, minSeverity, typeFilter str
 	return findings
 }
 
+// parseMinConfidence validates a --min-confidence value; empty means no
+// filtering.
+func parseMinConfidence(s string) (analyzer.Confidence, error) {
+	if s == "" {
+		return "", nil
+	}
+	c, ok := analyzer.ParseConfidence(s)
+	if !ok {
+		return "", fmt.Errorf("invalid --min-confidence %q: must be high, medium, or low", s)
+	}
+	return c, nil
+}
+
+// filterByConfidence keeps only findings at or above the given confidence.
+func filterByConfidence(findings []analyzer.Finding, min analyzer.Confidence) []analyzer.Finding {
+	if min == "" {
+		return findings
+	}
+	var result []analyzer.Finding
+	for _, f := range findings {
+		if f.Confidence.AtLeast(min) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
 // filterBySeverity keeps only findings at or above the given severity level.
 func filterBySeverity(findings []analyzer.Finding, minSev string) []analyzer.Finding {
 	threshold, ok := severityOrder[strings.ToLower(minSev)]
@@ -502,20 +750,8 @@ var severityOrder = map[string]int{
 	"high":   3,
 }
 
-// findingTypeAliases maps legacy names to current finding types.
-var findingTypeAliases = map[string]string{
-	"SCHEMA_DRIFT": string(analyzer.FindingMissingColumn),
-}
-
 func canonicalFindingType(t string) string {
-	t = strings.ToUpper(strings.TrimSpace(t))
-	if t == "" {
-		return ""
-	}
-	if alias, ok := findingTypeAliases[t]; ok {
-		return alias
-	}
-	return t
+	return string(analyzer.CanonicalFindingType(t))
 }
 
 // filterByType keeps only findings matching the given types (comma-separated).
@@ -540,22 +776,82 @@ func filterByType(findings []analyzer.Finding, typeFilter string) []analyzer.Fin
 	return result
 }
 
-// countSchemas returns the number of unique schemas in a snapshot.
-// extractDatabase returns the database name from a PostgreSQL connection URL.
+// extractDatabase returns the database name from a PostgreSQL connection
+// URL or keyword/value string.
 func extractDatabase(rawURL string) string {
-	u, err := url.Parse(rawURL)
+	t, err := postgres.ParseConnString(rawURL)
 	if err != nil {
 		return ""
 	}
-	return strings.TrimPrefix(u.Path, "/")
+	return t.Database
 }
 
-func countSchemas(snap *postgres.Snapshot) int {
-	schemas := make(map[string]bool)
+// scanContext counts the tables, indexes, and schemas in a snapshot, in
+// total and per schema.
+func scanContext(snap *postgres.Snapshot) reporter.ScanContext {
+	bySchema := make(map[string]reporter.SchemaCounts)
 	for _, t := range snap.Tables {
-		schemas[t.Schema] = true
+		c := bySchema[t.Schema]
+		c.Tables++
+		bySchema[t.Schema] = c
+	}
+	for _, idx := range snap.Indexes {
+		c := bySchema[idx.Schema]
+		c.Indexes++
+		bySchema[idx.Schema] = c
 	}
-	return len(schemas)
+	return reporter.ScanContext{
+		Tables:   len(snap.Tables),
+		Indexes:  len(snap.Indexes),
+		Schemas:  len(bySchema),
+		BySchema: bySchema,
+	}
+}
+
+// catalogConfig is the connection config for an audit restricted to tables
+// that reports the given checks, leaving out catalog work no enabled check
+// needs.
+func catalogConfig(tables []string, checks []analyzer.FindingType) postgres.Config {
+	return postgres.Config{
+		URL:           dbURL,
+		Tables:        tables,
+		SkipViewCheck: !analyzer.CheckEnabled(checks, analyzer.FindingBrokenView),
+		SkipRoles:     !analyzer.CheckEnabled(checks, analyzer.FindingSuperuserAppRole),
+		SkipPolicies:  !analyzer.CheckEnabled(checks, analyzer.FindingMissingRLS),
+		SkipGrants: !analyzer.CheckEnabled(checks, analyzer.FindingPublicGrant) &&
+			!analyzer.CheckEnabled(checks, analyzer.FindingAuditTableWrite),
+	}
+}
+
+// openCatalog connects to the database described by cfg. Tests replace it
+// to run commands against a postgres.StaticSource.
+var openCatalog = func(ctx context.Context, cfg postgres.Config) (postgres.CatalogSource, error) {
+	inspector, err := postgres.NewInspector(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return inspector, nil
+}
+
+// connectCatalog opens the catalog for a command: the fixtures named by
+// --replay, or the database, recording into --record if set.
+func connectCatalog(ctx context.Context, pcfg postgres.Config) (postgres.CatalogSource, error) {
+	if replayDir != "" {
+		return postgres.OpenReplay(replayDir)
+	}
+	src, err := openCatalog(ctx, pcfg)
+	if err != nil {
+		return nil, err
+	}
+	if recordDir == "" {
+		return src, nil
+	}
+	rec, err := postgres.NewRecorder(src, recordDir)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return rec, nil
 }
 
 // resolveSchemaFlag parses the --schema flag value and falls back to config.
@@ -570,6 +866,14 @@ func resolveSchemaFlag(flag string) []string {
 	return nil
 }
 
+// resolveTablesFlag parses the --tables flag value and falls back to config.
+func resolveTablesFlag(flag string) []string {
+	if flag != "" {
+		return postgres.ResolveTables(strings.Split(flag, ","))
+	}
+	return postgres.ResolveTables(cfg.Tables)
+}
+
 func auditOptsFromConfig(includeSchemas []string) analyzer.AuditOptions {
 	// Include wins over exclude: remove included schemas from the exclude list
 	excludeSchemas := cfg.Exclude.Schemas
@@ -588,12 +892,127 @@ func auditOptsFromConfig(includeSchemas []string) analyzer.AuditOptions {
 	}
 
 	return analyzer.AuditOptions{
-		VacuumDays:          cfg.Thresholds.VacuumDays,
-		UnusedIndexMinBytes: cfg.Thresholds.UnusedIndexMinBytes,
-		BloatMinBytes:       cfg.Thresholds.BloatMinBytes,
-		ExcludeTables:       cfg.Exclude.Tables,
-		ExcludeSchemas:      excludeSchemas,
+		VacuumDays:             cfg.Thresholds.VacuumDays,
+		UnusedIndexMinBytes:    cfg.Thresholds.UnusedIndexMinBytes,
+		BloatMinBytes:          cfg.Thresholds.BloatMinBytes,
+		SequenceExhaustionPct:  cfg.Thresholds.SequenceExhaustionPct,
+		SeqScanMinBytes:        cfg.Thresholds.SeqScanMinBytes,
+		SeqScanRatio:           cfg.Thresholds.SeqScanRatio,
+		CacheHitRatio:          cfg.Thresholds.CacheHitRatio,
+		HotTableBlocks:         cfg.Thresholds.HotTableBlocks,
+		RowWidthBytes:          cfg.Thresholds.RowWidthBytes,
+		WideTableColumns:       cfg.Thresholds.WideTableColumns,
+		IdleTransactionSeconds: cfg.Thresholds.IdleTransactionSeconds,
+		MinStatsWindowDays:     cfg.Thresholds.MinStatsWindowDays,
+		FKColumnPatterns:       cfg.Thresholds.FKColumnPatterns,
+		TenantTables:           cfg.Security.TenantTables,
+		ExcludeTables:          cfg.Exclude.Tables,
+		ExcludeSchemas:         excludeSchemas,
+	}
+}
+
+// profileSettings is a resolved --profile selection.
+type profileSettings struct {
+	checks      []analyzer.FindingType
+	minSeverity string
+}
+
+// resolveProfile looks up the named profile in config, then among the
+// built-in profiles. An empty name means the default set of checks.
+func resolveProfile(name string) (profileSettings, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return profileSettings{}, nil
+	}
+
+	for key, p := range cfg.Profiles {
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+		minSev := strings.ToLower(strings.TrimSpace(p.MinSeverity))
+		if _, ok := severityOrder[minSev]; minSev != "" && !ok {
+			return profileSettings{}, fmt.Errorf("profile %q: invalid min_severity %q (use high, medium, low, info)", key, p.MinSeverity)
+		}
+		var checks []analyzer.FindingType
+		for _, c := range p.Checks {
+			t := canonicalFindingType(c)
+			if t == "" {
+				continue
+			}
+			if !analyzer.KnownFindingType(analyzer.FindingType(t)) {
+				return profileSettings{}, fmt.Errorf("profile %q: unknown check %q", key, c)
+			}
+			checks = append(checks, analyzer.FindingType(t))
+		}
+		return profileSettings{checks: checks, minSeverity: minSev}, nil
+	}
+
+	checks, err := analyzer.ProfileChecks(name)
+	if err != nil {
+		if len(cfg.Profiles) == 0 {
+			return profileSettings{}, err
+		}
+		return profileSettings{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(profileNames(), ", "))
+	}
+	return profileSettings{checks: checks}, nil
+}
+
+// profileNames returns built-in and config-defined profile names, sorted.
+func profileNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, n := range analyzer.ProfileNames() {
+		seen[n] = true
+		names = append(names, n)
+	}
+	for n := range cfg.Profiles {
+		if !seen[strings.ToLower(n)] {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// effortOverridesFromConfig converts config effort overrides to analyzer types.
+// Entries are validated when the config is loaded.
+func effortOverridesFromConfig() map[analyzer.FindingType]analyzer.Effort {
+	if len(cfg.Effort) == 0 {
+		return nil
+	}
+	overrides := make(map[analyzer.FindingType]analyzer.Effort, len(cfg.Effort))
+	for ft, raw := range cfg.Effort {
+		e, _ := analyzer.ParseEffort(raw)
+		overrides[analyzer.FindingType(canonicalFindingType(ft))] = e
+	}
+	return overrides
+}
+
+// namingFromConfig compiles the naming conventions from config.
+func namingFromConfig() (analyzer.NamingConventions, error) {
+	var conv analyzer.NamingConventions
+	patterns := []struct {
+		key     string
+		pattern string
+		dst     **regexp.Regexp
+	}{
+		{"tables", cfg.Naming.Tables, &conv.Tables},
+		{"columns", cfg.Naming.Columns, &conv.Columns},
+		{"timestamp_columns", cfg.Naming.TimestampColumns, &conv.TimestampColumns},
+		{"indexes", cfg.Naming.Indexes, &conv.Indexes},
+		{"constraints", cfg.Naming.Constraints, &conv.Constraints},
+	}
+	for _, p := range patterns {
+		if p.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return conv, fmt.Errorf("naming.%s: %w", p.key, err)
+		}
+		*p.dst = re
 	}
+	return conv, nil
 }
 
 // Execute runs the root command.
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
 )
 
 func TestAuditCmd_InvalidDBURL_ErrorIsGraceful(t *testing.T) {
@@ -23,3 +26,86 @@ func TestAuditCmd_InvalidDBURL_ErrorIsGraceful(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestAuditCmd_UnknownProfile(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"audit", "--db-url", "not-a-url", "--profile", "bogus"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected unknown profile error")
+	}
+	if !strings.Contains(err.Error(), `unknown profile "bogus"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckMigrationCmd_RequiresFile(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"check-migration", "--db-url", "postgres://localhost/db"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error without a migration file argument")
+	}
+}
+
+func TestCheckMigrationCmd_MissingFile(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"check-migration", "--db-url", "postgres://localhost/db", "does-not-exist.sql"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "read migration") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestCheckSchemaCmd_MissingFile(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"check-schema", "--db-url", "postgres://localhost/db", "schema.yml"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "read schema") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestRelativeTo(t *testing.T) {
+	dir := t.TempDir()
+	if got := relativeTo(dir, filepath.Join(dir, "db", "migrate", "001.sql")); got != filepath.Join("db", "migrate", "001.sql") {
+		t.Errorf("inside repo: got %q", got)
+	}
+	if got := relativeTo(filepath.Join(dir, "app"), filepath.Join(dir, "001.sql")); got != "" {
+		t.Errorf("outside repo: got %q, want empty", got)
+	}
+}
+
+func TestScanContext_BySchema(t *testing.T) {
+	snap := &postgres.Snapshot{
+		Tables: []postgres.TableInfo{
+			{Schema: "public", Name: "users"},
+			{Schema: "public", Name: "orders"},
+			{Schema: "billing", Name: "invoices"},
+		},
+		Indexes: []postgres.IndexInfo{
+			{Schema: "public", Table: "users", Name: "users_pkey"},
+		},
+	}
+	sc := scanContext(snap)
+	if sc.Tables != 3 || sc.Indexes != 1 || sc.Schemas != 2 {
+		t.Errorf("totals = %+v", sc)
+	}
+	if got := sc.BySchema["public"]; got.Tables != 2 || got.Indexes != 1 {
+		t.Errorf("public = %+v", got)
+	}
+	if got := sc.BySchema["billing"]; got.Tables != 1 || got.Indexes != 0 {
+		t.Errorf("billing = %+v", got)
+	}
+}
@@ -12,17 +12,39 @@ import (
 
 func newScanCmd() *cobra.Command {
 	var (
-		repo     string
-		format   string
-		parallel int
+		repo         string
+		files        []string
+		stdin        bool
+		stdinPath    string
+		changedSince string
+		ref          string
+		format       string
+		parallel     int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "scan",
 		Short: "Scan code repo for SQL table/column references (no database required)",
+		Long: "Scans a code repository for SQL table and column references. --file scans just the files given, " +
+			"and --stdin a file's contents piped in, for editors and pre-commit hooks; --stdin-path names the file, " +
+			"whose extension decides its language. --changed-since reads only the files changed since a git ref and " +
+			"takes the rest from the scan cache.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if repo == "" {
-				return fmt.Errorf("--repo is required")
+			sources := 0
+			for _, set := range []bool{repo != "", len(files) > 0, stdin} {
+				if set {
+					sources++
+				}
+			}
+			switch {
+			case sources == 0:
+				return fmt.Errorf("--repo is required, or --file or --stdin")
+			case sources > 1:
+				return fmt.Errorf("--repo, --file, and --stdin cannot be used together")
+			case changedSince != "" && repo == "":
+				return fmt.Errorf("--changed-since requires --repo")
+			case ref != "" && repo == "":
+				return fmt.Errorf("--ref requires --repo")
 			}
 
 			// Use config format as default if flag not explicitly set
@@ -30,24 +52,49 @@ func newScanCmd() *cobra.Command {
 				format = cfg.Defaults.Format
 			}
 
-			slog.Debug("scanning repo", "path", repo)
-			result, err := scanner.ScanParallel(repo, parallel)
+			var result scanner.ScanResult
+			var err error
+			switch {
+			case stdin:
+				slog.Debug("scanning stdin", "path", stdinPath)
+				result, err = scanner.ScanReader(cmd.InOrStdin(), stdinPath, scanOptions(1))
+			case len(files) > 0:
+				slog.Debug("scanning files", "files", len(files))
+				result, err = scanner.ScanFiles(files, scanOptions(1))
+			default:
+				slog.Debug("scanning repo", "path", repo)
+				result, err = scanRepo(repo, ref, changedSince, parallel)
+			}
 			if err != nil {
 				return fmt.Errorf("scan: %w", err)
 			}
+			defer func() { _ = result.Close() }()
 			slog.Info("scan complete",
 				"files", result.FilesScanned,
 				"skipped", result.FilesSkipped,
-				"tables", len(result.Refs),
+				"tables", result.RefCount(),
 				"columns", len(result.ColumnRefs))
+			// The report lists every reference, so spilled ones are needed
+			// back in memory.
+			if err := result.LoadRefs(); err != nil {
+				return fmt.Errorf("scan: %w", err)
+			}
 
-			return writeScanResult(cmd.OutOrStdout(), &result, format)
+			return toOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+				return writeScanResult(w, &result, format)
+			})
 		},
 	}
 
-	cmd.Flags().StringVar(&repo, "repo", "", "path to code repository to scan (required)")
+	cmd.Flags().StringVar(&repo, "repo", "", "path to code repository to scan, or a git URL (https or ssh) to clone shallowly (required unless --file or --stdin is set)")
+	cmd.Flags().StringVar(&ref, "ref", "", "branch, tag, or commit to clone when --repo is a git URL (default: the default branch)")
+	cmd.Flags().StringArrayVar(&files, "file", nil, "scan only this file instead of a repository (repeatable)")
+	cmd.Flags().BoolVar(&stdin, "stdin", false, "scan a file's contents read from stdin")
+	cmd.Flags().StringVar(&changedSince, "changed-since", "", "read only files changed since this git ref (or its merge-base with HEAD); the rest come from the scan cache")
+	cmd.Flags().StringVar(&stdinPath, "stdin-path", "stdin.sql", "path reported for --stdin input; its extension decides the language")
 	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, or sarif")
 	cmd.Flags().IntVar(&parallel, "parallel", 0, "number of scanner goroutines (0=NumCPU, 1=sequential)")
+	addScanFlags(cmd)
 
 	return cmd
 }
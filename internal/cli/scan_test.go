@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -80,6 +81,145 @@ func TestScanCmd_NoRepo(t *testing.T) {
 	}
 }
 
+func TestScanCmd_Files(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "query.sql", "SELECT name FROM accounts;")
+	writeTestFile(t, dir, "other.sql", "SELECT id FROM invoices;")
+
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"scan", "--file", filepath.Join(dir, "query.sql"), "--format", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var result scanner.ScanResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(result.Tables) != 1 || result.Tables[0] != "accounts" {
+		t.Errorf("expected [accounts], got %v", result.Tables)
+	}
+}
+
+func TestScanCmd_Stdin(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetIn(strings.NewReader(`cur.execute("SELECT * FROM orders")`))
+	cmd.SetArgs([]string{"scan", "--stdin", "--stdin-path", "app/jobs.py"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "orders") || !strings.Contains(output, "app/jobs.py") {
+		t.Errorf("expected orders in app/jobs.py, got:\n%s", output)
+	}
+}
+
+func TestScanCmd_ConflictingSources(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"scan", "--repo", t.TempDir(), "--stdin"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("expected conflicting sources error, got %v", err)
+	}
+}
+
+func TestScanCmd_GitURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	cacheDir := t.TempDir()
+	t.Setenv(scanner.CacheDirEnv, cacheDir)
+	src := t.TempDir()
+	writeTestFile(t, src, "query.sql", "SELECT name FROM accounts;")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", src}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"scan", "--repo", "file://" + src, "--format", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var result scanner.ScanResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(result.Tables) != 1 || result.Tables[0] != "accounts" || result.RepoPath != "file://"+src {
+		t.Errorf("expected [accounts] from file://%s, got %v from %s", src, result.Tables, result.RepoPath)
+	}
+	if entries, err := os.ReadDir(cacheDir); err != nil || len(entries) != 0 {
+		t.Errorf("expected no scan cache for a cloned repo, got %v (%v)", entries, err)
+	}
+}
+
+func TestScanCmd_RefNeedsGitURL(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"scan", "--repo", t.TempDir(), "--ref", "main"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--ref") {
+		t.Errorf("expected --ref error, got %v", err)
+	}
+}
+
+func TestScanCmd_UnknownParser(t *testing.T) {
+	cmd := newRootCmd(BuildInfo{Version: "test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"scan", "--repo", t.TempDir(), "--parser", "yacc"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--parser") {
+		t.Errorf("expected --parser error, got %v", err)
+	}
+}
+
+func TestScanFlags_OnlyOnScanningCommands(t *testing.T) {
+	root := newRootCmd(BuildInfo{Version: "test"})
+	for _, flag := range []string{"parser", "replay-migrations", "django-tables", "no-cache"} {
+		for _, name := range []string{"scan", "check"} {
+			cmd, _, err := root.Find([]string{name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Flags().Lookup(flag) == nil {
+				t.Errorf("%s should have --%s", name, flag)
+			}
+		}
+		audit, _, err := root.Find([]string{"audit"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if audit.Flags().Lookup(flag) != nil || audit.InheritedFlags().Lookup(flag) != nil {
+			t.Errorf("audit should not have --%s", flag)
+		}
+	}
+}
+
 func TestScanCmd_EmptyDir(t *testing.T) {
 	dir := t.TempDir()
 
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/migration"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/spf13/cobra"
+)
+
+func newCheckSchemaCmd() *cobra.Command {
+	var (
+		format  string
+		failOn  string
+		noColor bool
+		width   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check-schema <schema.rb|structure.sql|schema.prisma|migrations-dir>",
+		Short: "Compare a schema dump with the live database to find unapplied migrations",
+		Long: "Treats a Rails db/schema.rb, or SQL DDL such as db/structure.sql or pg_dump --schema-only output, a Prisma schema, " +
+			"or the versioned migrations in a directory replayed in order, as the expected schema: declared tables, columns, and indexes the database lacks are unapplied migrations, " +
+			"and tables and columns in the same schemas that it lacks were made outside the migrations.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dbURL == "" && replayDir == "" {
+				return fmt.Errorf("--db-url is required")
+			}
+			path := args[0]
+
+			if !cmd.Flags().Changed("format") && cfg.Defaults.Format != "" {
+				format = cfg.Defaults.Format
+			}
+			outputs, err := reporter.ParseOutputs(format)
+			if err != nil {
+				return err
+			}
+
+			expected, err := migration.LoadSchema(path)
+			if err != nil {
+				return err
+			}
+			slog.Info("schema loaded", "path", path, "tables", len(expected.Tables()))
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+			defer cancel()
+
+			inspector, err := connectCatalog(ctx, postgres.Config{URL: dbURL})
+			if err != nil {
+				return fmt.Errorf("connect: %w", err)
+			}
+			defer inspector.Close()
+
+			snap, err := inspector.Inspect(ctx)
+			if err != nil {
+				return fmt.Errorf("inspect: %w", err)
+			}
+
+			findings := analyzer.DiffSchema(expected, snap)
+			analyzer.AssignEffort(findings, effortOverridesFromConfig())
+
+			report := reporter.NewReport("check-schema", findings, buildVersion)
+			report.Metadata.URIHash = reporter.HashURI(dbURL)
+			report.Metadata.Database = extractDatabase(dbURL)
+			report.Scanned = scanContext(snap)
+
+			if err := writeReport(cmd.OutOrStdout(), &report, outputs, writeOptions(noColor, width)); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+
+			if failOn != "" && shouldFailOn(findings, failOn) {
+				return &ExitError{Code: 2}
+			}
+
+			code := analyzer.ExitCode(report.MaxSeverity)
+			if code != 0 {
+				return &ExitError{Code: code}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub; or format=path pairs, e.g. json=report.json,text=-")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit 2 if findings match (comma-separated types or severity: high,medium)")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output (also honors NO_COLOR)")
+	cmd.Flags().IntVar(&width, "width", 0, "text output width in columns (0=detect terminal, or COLUMNS when not a terminal; <=100 uses condensed layout)")
+
+	return cmd
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/config"
+	"github.com/ppiankov/pgspectre/internal/postgres"
+	"github.com/ppiankov/pgspectre/internal/reporter"
+	"github.com/spf13/cobra"
+)
+
+func newWorkspaceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Check several repos against their databases from one pgspectre.workspace.yml",
+	}
+	cmd.AddCommand(newWorkspaceCheckCmd())
+	return cmd
+}
+
+func newWorkspaceCheckCmd() *cobra.Command {
+	var (
+		file          string
+		format        string
+		failOn        string
+		minSeverity   string
+		minConfidence string
+		typeFilter    string
+		noColor       bool
+		width         int
+		parallel      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Run check for every service in the workspace and report the findings together",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if recordDir != "" || replayDir != "" {
+				return fmt.Errorf("--record and --replay work on one database; they can't be used with workspace check")
+			}
+			confidence, err := parseMinConfidence(minConfidence)
+			if err != nil {
+				return err
+			}
+			ws, err := config.LoadWorkspace(file)
+			if err != nil {
+				return fmt.Errorf("load workspace: %w", err)
+			}
+			if !cmd.Flags().Changed("format") && cfg.Defaults.Format != "" {
+				format = cfg.Defaults.Format
+			}
+			outputs, err := reporter.ParseOutputs(format)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), cfg.TimeoutDuration())
+			defer cancel()
+
+			findings, services, err := runWorkspace(ctx, ws, parallel)
+			if err != nil {
+				return err
+			}
+			findings = applyReportFilters(findings, minSeverity, typeFilter)
+			findings = filterByConfidence(findings, confidence)
+			findings, totalSuppressed, err := filterFindings(findings, "")
+			if err != nil {
+				return err
+			}
+			if totalSuppressed > 0 {
+				slog.Info("findings suppressed", "suppressed", totalSuppressed)
+			}
+			analyzer.Correlate(findings)
+
+			report := reporter.NewReport("workspace check", findings, buildVersion)
+			report.Services = summarizeServices(services, report.Findings)
+
+			if err := writeReport(cmd.OutOrStdout(), &report, outputs, writeOptions(noColor, width)); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+			if failOn != "" && shouldFailOn(findings, failOn) {
+				return &ExitError{Code: 2}
+			}
+			if code := analyzer.ExitCode(report.MaxSeverity); code != 0 {
+				return &ExitError{Code: code}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", config.WorkspaceFile, "workspace file mapping services to databases and schemas")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, sarif, or spectrehub; or format=path pairs, e.g. json=report.json,text=-")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit 2 if findings match (comma-separated types or severity: high,medium)")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "show only findings at or above this severity (high, medium, low, info)")
+	cmd.Flags().StringVar(&minConfidence, "min-confidence", "", "show only findings at or above this confidence (high, medium, low)")
+	cmd.Flags().StringVar(&typeFilter, "type", "", "show only these finding types (comma-separated, e.g. MISSING_TABLE,UNUSED_INDEX)")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable ANSI color output (also honors NO_COLOR)")
+	cmd.Flags().IntVar(&width, "width", 0, "text output width in columns (0=detect terminal, or COLUMNS when not a terminal; <=100 uses condensed layout)")
+	cmd.Flags().IntVar(&parallel, "parallel", 0, "number of scanner goroutines (0=NumCPU, 1=sequential)")
+
+	return cmd
+}
+
+// runWorkspace checks every service in the workspace. Each database is
+// inspected once and narrowed to each of its services' schemas. Findings
+// carry the service and owner they belong to in their details.
+func runWorkspace(ctx context.Context, ws config.Workspace, parallel int) ([]analyzer.Finding, []config.WorkspaceService, error) {
+	byDatabase := make(map[string][]config.WorkspaceService)
+	var databases []string
+	for _, s := range ws.Services {
+		if _, ok := byDatabase[s.Database]; !ok {
+			databases = append(databases, s.Database)
+		}
+		byDatabase[s.Database] = append(byDatabase[s.Database], s)
+	}
+	sort.Strings(databases)
+
+	var findings []analyzer.Finding
+	var services []config.WorkspaceService
+	for _, name := range databases {
+		connString := ws.Databases[name].ConnString()
+		if connString == "" {
+			return nil, nil, fmt.Errorf("database %q: no connection string (set url, or the variable named by url_env)", name)
+		}
+		inspector, err := openCatalog(ctx, postgres.Config{URL: connString})
+		if err != nil {
+			return nil, nil, fmt.Errorf("database %q: connect: %w", name, err)
+		}
+		ver, err := inspector.ServerVersion(ctx)
+		if err != nil {
+			inspector.Close()
+			return nil, nil, fmt.Errorf("database %q: server version: %w", name, err)
+		}
+		snap, err := inspector.Inspect(ctx)
+		inspector.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("database %q: inspect: %w", name, err)
+		}
+		slog.Info("inspected", "database", name, "tables", len(snap.Tables))
+
+		for _, svc := range byDatabase[name] {
+			found, err := checkService(svc, snap, postgres.MajorVersion(ver), parallel)
+			if err != nil {
+				return nil, nil, fmt.Errorf("service %q: %w", svc.Name, err)
+			}
+			findings = append(findings, found...)
+			services = append(services, svc)
+		}
+	}
+	return findings, services, nil
+}
+
+// checkService runs check for one service: its repo against its schemas
+// of an already inspected database.
+func checkService(svc config.WorkspaceService, snap *postgres.Snapshot, serverMajor, parallel int) ([]analyzer.Finding, error) {
+	scan, err := scanRepo(svc.Repo, svc.Ref, "", parallel)
+	if err != nil {
+		return nil, fmt.Errorf("scan repo: %w", err)
+	}
+	defer func() { _ = scan.Close() }()
+	slog.Info("scan complete", "service", svc.Name, "refs", scan.RefCount(), "files", scan.FilesScanned)
+
+	filtered := postgres.FilterSnapshot(snap, svc.Schemas)
+
+	opts := auditOptsFromConfig(svc.Schemas)
+	opts.ServerMajor = serverMajor
+	findings := analyzer.Diff(&scan, filtered, opts)
+	analyzer.AssignEffort(findings, effortOverridesFromConfig())
+	for i := range findings {
+		f := &findings[i]
+		if f.Detail == nil {
+			f.Detail = make(map[string]string)
+		}
+		f.Detail["service"] = svc.Name
+		if svc.Owner != "" {
+			f.Detail["owner"] = svc.Owner
+		}
+	}
+	return findings, nil
+}
+
+// summarizeServices counts the reported findings of each service.
+func summarizeServices(services []config.WorkspaceService, findings []analyzer.Finding) []reporter.ServiceSummary {
+	summaries := make([]reporter.ServiceSummary, len(services))
+	index := make(map[string]int, len(services))
+	for i, s := range services {
+		index[s.Name] = i
+		summaries[i] = reporter.ServiceSummary{
+			Name:     s.Name,
+			Owner:    s.Owner,
+			Repo:     s.Repo,
+			Database: s.Database,
+			Schemas:  s.Schemas,
+		}
+	}
+	for _, f := range findings {
+		i, ok := index[f.Detail["service"]]
+		if !ok {
+			continue
+		}
+		summaries[i].Findings++
+		if f.Severity == analyzer.SeverityHigh {
+			summaries[i].High++
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].Name) < strings.ToLower(summaries[j].Name)
+	})
+	return summaries
+}
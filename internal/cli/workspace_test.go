@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+func TestWorkspaceCheckCmd(t *testing.T) {
+	useStaticCatalog(t, &postgres.StaticSource{Snapshot: staticSnapshot(), Version: "16.2"})
+	dir := t.TempDir()
+	writeTestFile(t, dir, "service-a/store.go",
+		"package store\n\nconst q = \"SELECT id FROM orders WHERE user_id = $1\"\n")
+	writeTestFile(t, dir, "service-b/audit.go",
+		"package audit\n\nconst q = \"SELECT payload FROM audit_log\"\n")
+	t.Setenv("PGSPECTRE_TEST_DB_A", "postgres://static/shop")
+	wsPath := filepath.Join(dir, "pgspectre.workspace.yml")
+	writeTestFile(t, dir, "pgspectre.workspace.yml", `databases:
+  db-a:
+    url_env: PGSPECTRE_TEST_DB_A
+services:
+  - name: service-b
+    repo: service-b
+    database: db-a
+    schemas: [public]
+    owner: team-audit
+  - name: service-a
+    repo: service-a
+    database: db-a
+    owner: team-orders
+`)
+
+	report := runReport(t, "workspace", "check", "--file", wsPath, "--format", "json")
+	if report.Metadata.Command != "workspace check" {
+		t.Errorf("command = %q", report.Metadata.Command)
+	}
+	if len(report.Services) != 2 || report.Services[0].Name != "service-a" || report.Services[1].Owner != "team-audit" {
+		t.Fatalf("services = %+v", report.Services)
+	}
+	if report.Services[0].Repo != filepath.Join(dir, "service-a") || report.Services[0].Findings == 0 {
+		t.Errorf("service-a summary = %+v", report.Services[0])
+	}
+
+	var missing int
+	for _, f := range report.Findings {
+		if f.Detail["service"] == "" {
+			t.Errorf("finding without service: %+v", f)
+		}
+		if f.Type == "MISSING_TABLE" && f.Table == "orders" {
+			missing++
+			if f.Detail["service"] != "service-a" || f.Detail["owner"] != "team-orders" {
+				t.Errorf("MISSING_TABLE orders attributed to %q/%q", f.Detail["service"], f.Detail["owner"])
+			}
+		}
+	}
+	if missing != 1 {
+		t.Errorf("got %d MISSING_TABLE orders findings, want 1", missing)
+	}
+}
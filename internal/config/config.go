@@ -1,27 +1,80 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"go.yaml.in/yaml/v3"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
 )
 
 // Config holds all pgspectre configuration.
 type Config struct {
 	DBURL      string     `yaml:"db_url"`
 	Schemas    []string   `yaml:"schemas"`
+	Tables     []string   `yaml:"tables"` // table globs to restrict analysis to
 	Thresholds Thresholds `yaml:"thresholds"`
 	Exclude    Exclude    `yaml:"exclude"`
 	Defaults   Defaults   `yaml:"defaults"`
+	Output     Output     `yaml:"output"`
+	Migration  Migration  `yaml:"migration"`
+	Naming     Naming     `yaml:"naming"`
+	Security   Security   `yaml:"security"`
+	Django     Django     `yaml:"django"`
+	Scan       Scan       `yaml:"scan"`
+	// Effort overrides the remediation effort estimate per finding type,
+	// e.g. {UNUSED_TABLE: large}.
+	Effort map[string]string `yaml:"effort"`
+	// Profiles defines named check sets selectable with --profile.
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a user-defined set of checks. Profiles with the same name as
+// a built-in profile replace it.
+type Profile struct {
+	Checks      []string `yaml:"checks"`       // finding types to report; empty means the default set
+	MinSeverity string   `yaml:"min_severity"` // default for --min-severity when this profile is used
 }
 
 // Thresholds control detection sensitivity.
 type Thresholds struct {
-	VacuumDays          int   `yaml:"vacuum_days"`            // days since last autovacuum to flag
-	UnusedIndexMinBytes int64 `yaml:"unused_index_min_bytes"` // minimum unused index size to report
-	BloatMinBytes       int64 `yaml:"bloat_min_bytes"`        // minimum index size to flag as bloated
+	VacuumDays             int      `yaml:"vacuum_days"`                 // days since last autovacuum to flag
+	UnusedIndexMinBytes    int64    `yaml:"unused_index_min_bytes"`      // minimum unused index size to report
+	BloatMinBytes          int64    `yaml:"bloat_min_bytes"`             // minimum index size to flag as bloated
+	SequenceExhaustionPct  int      `yaml:"sequence_exhaustion_pct"`     // percent of sequence range used before flagging
+	SeqScanMinBytes        int64    `yaml:"seq_scan_min_bytes"`          // minimum table size to check for heavy sequential scans
+	SeqScanRatio           float64  `yaml:"seq_scan_ratio"`              // seq_scan/idx_scan ratio to flag
+	CacheHitRatio          float64  `yaml:"cache_hit_ratio"`             // heap buffer hit ratio below which large tables are flagged
+	HotTableBlocks         int64    `yaml:"hot_table_blocks"`            // block requests that make a table hot for LOW_CACHE_HIT_RATIO
+	RowWidthBytes          int64    `yaml:"row_width_bytes"`             // average row width above which rows are oversized
+	WideTableColumns       int      `yaml:"wide_table_columns"`          // column count above which a table is wide
+	IdleTransactionSeconds int      `yaml:"idle_in_transaction_seconds"` // idle-in-transaction age to flag with --activity
+	MinStatsWindowDays     int      `yaml:"min_stats_window_days"`       // statistics age below which unused findings are downgraded
+	FKColumnPatterns       []string `yaml:"fk_column_patterns"`          // column globs implying a foreign key, e.g. *_id
+	LargeTableBytes        int64    `yaml:"large_table_bytes"`           // table size at which migration locks are escalated
+}
+
+// Naming holds regular expressions object names must match, reported as
+// NAMING_VIOLATION. An empty pattern leaves that kind of object unchecked.
+type Naming struct {
+	Tables           string `yaml:"tables"`            // e.g. ^[a-z][a-z0-9_]*$
+	Columns          string `yaml:"columns"`           // all columns
+	TimestampColumns string `yaml:"timestamp_columns"` // date and timestamp columns, e.g. _at$
+	Indexes          string `yaml:"indexes"`           // e.g. ^idx_
+	Constraints      string `yaml:"constraints"`
+}
+
+// Security configures the security checks.
+type Security struct {
+	// TenantTables are globs on table names, or schema.table, of
+	// multi-tenant tables that must enforce row-level security
+	// (MISSING_RLS), e.g. [orders, "tenant_*", "app.*"].
+	TenantTables []string `yaml:"tenant_tables"`
 }
 
 // Exclude lists tables, schemas, and finding types to skip during analysis.
@@ -33,22 +86,77 @@ type Exclude struct {
 
 // Defaults holds default CLI flag values.
 type Defaults struct {
-	Format  string `yaml:"format"`
-	Timeout string `yaml:"timeout"` // parsed as time.Duration
+	Format    string `yaml:"format"`
+	Timeout   string `yaml:"timeout"`    // parsed as time.Duration
+	MaxMemory string `yaml:"max_memory"` // e.g. 512MiB; see --max-memory
+	Parser    string `yaml:"parser"`     // regex or ast; see --parser
+	// ReplayMigrations sets --replay-migrations.
+	ReplayMigrations bool `yaml:"replay_migrations"`
+	// DjangoTables sets --django-tables.
+	DjangoTables bool `yaml:"django_tables"`
+}
+
+// Scan configures which files of a repository are scanned.
+type Scan struct {
+	// ExcludePaths are globs of paths to skip, relative to the repository,
+	// e.g. ["**/testdata/**", "generated/**", "*.pb.go"]. Paths that
+	// .gitignore files ignore are always skipped.
+	ExcludePaths []string `yaml:"exclude_paths"`
+	// IncludeExtensions are file extensions to scan on top of the
+	// built-in ones, e.g. [.cshtml, .vue].
+	IncludeExtensions []string `yaml:"include_extensions"`
+	// SkipDirs are directories to skip on top of node_modules, vendor,
+	// and the other built-in ones: names, skipped at any depth, or paths
+	// relative to the repository, e.g. [third_party, services/legacy].
+	SkipDirs []string `yaml:"skip_dirs"`
+}
+
+// Django configures the implicit table names inferred with --django-tables.
+type Django struct {
+	// AppLabels maps an app's directory name, or its path in the
+	// repository, to its app label where AppConfig.label differs, e.g.
+	// {accounts: users}.
+	AppLabels map[string]string `yaml:"app_labels"`
+}
+
+// Migration describes how migrations are run, for check-migration.
+type Migration struct {
+	// RunnerTimeouts lists timeout settings the migration runner sets before
+	// each file, e.g. [lock_timeout, statement_timeout].
+	RunnerTimeouts []string `yaml:"runner_timeouts"`
+}
+
+// Output controls how reports are rendered.
+type Output struct {
+	Theme string `yaml:"theme"` // text color theme: default, high-contrast, monochrome
 }
 
 // DefaultConfig returns the built-in defaults.
 func DefaultConfig() Config {
 	return Config{
 		Thresholds: Thresholds{
-			VacuumDays:          30,
-			UnusedIndexMinBytes: 100 * 1024 * 1024, // 100 MB
-			BloatMinBytes:       1024 * 1024,       // 1 MB
+			VacuumDays:             30,
+			UnusedIndexMinBytes:    100 * 1024 * 1024, // 100 MB
+			BloatMinBytes:          1024 * 1024,       // 1 MB
+			SequenceExhaustionPct:  70,
+			SeqScanMinBytes:        100 * 1024 * 1024, // 100 MB
+			SeqScanRatio:           10,
+			CacheHitRatio:          0.9,
+			HotTableBlocks:         1000000,
+			RowWidthBytes:          2048,
+			WideTableColumns:       50,
+			IdleTransactionSeconds: 300,
+			MinStatsWindowDays:     7,
+			FKColumnPatterns:       []string{"*_id"},
+			LargeTableBytes:        100 * 1024 * 1024, // 100 MB
 		},
 		Defaults: Defaults{
 			Format:  "text",
 			Timeout: "30s",
 		},
+		Output: Output{
+			Theme: "default",
+		},
 	}
 }
 
@@ -84,12 +192,45 @@ func Load(dir string) (Config, error) {
 		if err := yaml.Unmarshal(data, &cfg); err != nil {
 			return cfg, err
 		}
+		if err := cfg.validate(); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
 		return cfg, nil
 	}
 
 	return cfg, nil
 }
 
+// validate rejects settings that would otherwise be silently ignored.
+func (c *Config) validate() error {
+	switch c.Output.Theme {
+	case "", "default", "high-contrast", "monochrome":
+	default:
+		return fmt.Errorf("output.theme: unknown theme %q (use default, high-contrast, monochrome)", c.Output.Theme)
+	}
+	for _, name := range c.Migration.RunnerTimeouts {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "lock_timeout", "statement_timeout":
+		default:
+			return fmt.Errorf("migration.runner_timeouts: unknown setting %q (use lock_timeout, statement_timeout)", name)
+		}
+	}
+	types := make([]string, 0, len(c.Effort))
+	for ft := range c.Effort {
+		types = append(types, ft)
+	}
+	sort.Strings(types)
+	for _, ft := range types {
+		if !analyzer.KnownFindingType(analyzer.CanonicalFindingType(ft)) {
+			return fmt.Errorf("effort.%s: unknown finding type %q", ft, ft)
+		}
+		if _, ok := analyzer.ParseEffort(c.Effort[ft]); !ok {
+			return fmt.Errorf("effort.%s: unknown effort %q (use trivial, small, medium, large)", ft, c.Effort[ft])
+		}
+	}
+	return nil
+}
+
 // TimeoutDuration parses the Defaults.Timeout string as a time.Duration.
 // Returns 30s if parsing fails.
 func (c *Config) TimeoutDuration() time.Duration {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -19,12 +20,18 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Thresholds.BloatMinBytes != 1024*1024 {
 		t.Errorf("BloatMinBytes = %d, want %d", cfg.Thresholds.BloatMinBytes, 1024*1024)
 	}
+	if cfg.Thresholds.SequenceExhaustionPct != 70 {
+		t.Errorf("SequenceExhaustionPct = %d, want 70", cfg.Thresholds.SequenceExhaustionPct)
+	}
 	if cfg.Defaults.Format != "text" {
 		t.Errorf("Format = %q, want text", cfg.Defaults.Format)
 	}
 	if cfg.Defaults.Timeout != "30s" {
 		t.Errorf("Timeout = %q, want 30s", cfg.Defaults.Timeout)
 	}
+	if cfg.Output.Theme != "default" {
+		t.Errorf("Theme = %q, want default", cfg.Output.Theme)
+	}
 }
 
 func TestLoad_NoFile(t *testing.T) {
@@ -55,6 +62,18 @@ exclude:
 defaults:
   format: json
   timeout: "60s"
+output:
+  theme: monochrome
+migration:
+  runner_timeouts: [lock_timeout]
+tables:
+  - "orders*"
+security:
+  tenant_tables: ["tenant_*", "app.orders"]
+profiles:
+  nightly:
+    checks: [UNUSED_TABLE, UNUSED_INDEX]
+    min_severity: low
 `)
 	if err := os.WriteFile(filepath.Join(dir, ".pgspectre.yml"), content, 0644); err != nil {
 		t.Fatal(err)
@@ -89,6 +108,25 @@ defaults:
 	if cfg.Defaults.Timeout != "60s" {
 		t.Errorf("Timeout = %q, want 60s", cfg.Defaults.Timeout)
 	}
+	if cfg.Output.Theme != "monochrome" {
+		t.Errorf("Theme = %q, want monochrome", cfg.Output.Theme)
+	}
+	if len(cfg.Migration.RunnerTimeouts) != 1 || cfg.Migration.RunnerTimeouts[0] != "lock_timeout" {
+		t.Errorf("RunnerTimeouts = %v, want [lock_timeout]", cfg.Migration.RunnerTimeouts)
+	}
+	if len(cfg.Tables) != 1 || cfg.Tables[0] != "orders*" {
+		t.Errorf("Tables = %v, want [orders*]", cfg.Tables)
+	}
+	if len(cfg.Security.TenantTables) != 2 || cfg.Security.TenantTables[1] != "app.orders" {
+		t.Errorf("TenantTables = %v", cfg.Security.TenantTables)
+	}
+	nightly, ok := cfg.Profiles["nightly"]
+	if !ok {
+		t.Fatalf("Profiles = %v, want nightly", cfg.Profiles)
+	}
+	if len(nightly.Checks) != 2 || nightly.MinSeverity != "low" {
+		t.Errorf("nightly = %+v", nightly)
+	}
 }
 
 func TestLoad_InvalidYAML(t *testing.T) {
@@ -103,6 +141,32 @@ func TestLoad_InvalidYAML(t *testing.T) {
 	}
 }
 
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown theme", "output:\n  theme: high_contrast\n", `unknown theme "high_contrast"`},
+		{"runner timeout typo", "migration:\n  runner_timeouts: [lock_timout]\n", `unknown setting "lock_timout"`},
+		{"effort type typo", "effort:\n  UNUSED_TABEL: small\n", `unknown finding type "UNUSED_TABEL"`},
+		{"effort value typo", "effort:\n  unused_table: tiny\n", `unknown effort "tiny"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ".pgspectre.yml"), []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestTimeoutDuration(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -169,3 +233,77 @@ thresholds:
 		t.Errorf("BloatMinBytes = %d, want default %d", cfg.Thresholds.BloatMinBytes, 1024*1024)
 	}
 }
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, WorkspaceFile)
+	content := `databases:
+  db-a:
+    url_env: PGSPECTRE_TEST_WS_URL
+    url: postgres://fallback/a
+services:
+  - name: service-a
+    repo: services/a
+    database: db-a
+    schemas: [public]
+    owner: team-a
+  - name: service-b
+    repo: /src/b
+    database: db-a
+    schemas: [billing]
+  - name: service-c
+    repo: git@github.com:acme/c.git
+    ref: main
+    database: db-a
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := LoadWorkspace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Services) != 3 {
+		t.Fatalf("services = %+v", ws.Services)
+	}
+	if got := ws.Services[0].Repo; got != filepath.Join(dir, "services", "a") {
+		t.Errorf("relative repo = %q", got)
+	}
+	if got := ws.Services[1].Repo; got != "/src/b" {
+		t.Errorf("absolute repo = %q", got)
+	}
+	if got := ws.Services[2]; got.Repo != "git@github.com:acme/c.git" || got.Ref != "main" {
+		t.Errorf("git URL repo = %q at %q", got.Repo, got.Ref)
+	}
+	db := ws.Databases["db-a"]
+	if got := db.ConnString(); got != "postgres://fallback/a" {
+		t.Errorf("ConnString without env = %q", got)
+	}
+	t.Setenv("PGSPECTRE_TEST_WS_URL", "postgres://env/a")
+	if got := db.ConnString(); got != "postgres://env/a" {
+		t.Errorf("ConnString with env = %q", got)
+	}
+}
+
+func TestLoadWorkspace_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no services":       "databases:\n  a:\n    url: postgres://x/a\n",
+		"undeclared db":     "services:\n  - name: s\n    repo: .\n    database: missing\n",
+		"missing repo":      "databases:\n  a: {}\nservices:\n  - name: s\n    database: a\n",
+		"duplicate service": "databases:\n  a: {}\nservices:\n  - {name: s, repo: ., database: a}\n  - {name: s, repo: ., database: a}\n",
+		"unnamed service":   "databases:\n  a: {}\nservices:\n  - {repo: ., database: a}\n",
+		"ref on a path":     "databases:\n  a: {}\nservices:\n  - {name: s, repo: ., ref: main, database: a}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), WorkspaceFile)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadWorkspace(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
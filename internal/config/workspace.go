@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ppiankov/pgspectre/internal/gitrepo"
+	"go.yaml.in/yaml/v3"
+)
+
+// WorkspaceFile is the default workspace file name.
+const WorkspaceFile = "pgspectre.workspace.yml"
+
+// Workspace maps the services of a platform to the databases and schemas
+// they use, so that `pgspectre workspace check` can check every pair in
+// one run.
+type Workspace struct {
+	Databases map[string]WorkspaceDatabase `yaml:"databases"`
+	Services  []WorkspaceService           `yaml:"services"`
+}
+
+// WorkspaceDatabase is a database services connect to. URLEnv names an
+// environment variable holding the connection string, which keeps
+// credentials out of the file; URL is used when it is unset.
+type WorkspaceDatabase struct {
+	URL    string `yaml:"url"`
+	URLEnv string `yaml:"url_env"`
+}
+
+// WorkspaceService is a code repository and the database schemas it owns.
+type WorkspaceService struct {
+	Name     string   `yaml:"name"`
+	Repo     string   `yaml:"repo"`     // relative to the workspace file, or a git URL
+	Ref      string   `yaml:"ref"`      // branch, tag, or commit to clone when Repo is a git URL
+	Database string   `yaml:"database"` // key in Workspace.Databases
+	Schemas  []string `yaml:"schemas"`  // empty means all non-system schemas
+	Owner    string   `yaml:"owner"`    // team or person findings are attributed to
+}
+
+// LoadWorkspace reads a workspace file and checks that every service
+// names a repo and a declared database. Repo paths are made relative to
+// the file's directory; git URLs are left as they are.
+func LoadWorkspace(path string) (Workspace, error) {
+	var ws Workspace
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ws, err
+	}
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return ws, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(ws.Services) == 0 {
+		return ws, fmt.Errorf("%s: no services", path)
+	}
+
+	dir := filepath.Dir(path)
+	seen := make(map[string]bool, len(ws.Services))
+	for i := range ws.Services {
+		s := &ws.Services[i]
+		switch {
+		case s.Name == "":
+			return ws, fmt.Errorf("%s: service %d has no name", path, i+1)
+		case seen[s.Name]:
+			return ws, fmt.Errorf("%s: duplicate service %q", path, s.Name)
+		case s.Repo == "":
+			return ws, fmt.Errorf("%s: service %q has no repo", path, s.Name)
+		case s.Ref != "" && !gitrepo.IsURL(s.Repo):
+			return ws, fmt.Errorf("%s: service %q has a ref, but its repo is not a git URL", path, s.Name)
+		}
+		if _, ok := ws.Databases[s.Database]; !ok {
+			return ws, fmt.Errorf("%s: service %q uses undeclared database %q", path, s.Name, s.Database)
+		}
+		seen[s.Name] = true
+		if !filepath.IsAbs(s.Repo) && !gitrepo.IsURL(s.Repo) {
+			s.Repo = filepath.Join(dir, s.Repo)
+		}
+	}
+	return ws, nil
+}
+
+// ConnString returns the database's connection string, from URLEnv when
+// that variable is set.
+func (d WorkspaceDatabase) ConnString() string {
+	if d.URLEnv != "" {
+		if v := os.Getenv(d.URLEnv); v != "" {
+			return v
+		}
+	}
+	return d.URL
+}
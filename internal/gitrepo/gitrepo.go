@@ -0,0 +1,25 @@
+// Package gitrepo recognizes repositories given as git URLs rather than
+// local paths.
+package gitrepo
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// urlRe matches git URLs: the https, ssh, git, and file schemes, and the
+// scp-like user@host:path form.
+var urlRe = regexp.MustCompile(`^(?:(?:https?|ssh|git|file)://|[\w.-]+@[\w.-]+:)`)
+
+// IsURL reports whether repo is a git URL rather than a local path.
+func IsURL(repo string) bool {
+	return urlRe.MatchString(repo)
+}
+
+// RedactURL hides the password of a git URL, for reports and logs.
+func RedactURL(repo string) string {
+	if u, err := url.Parse(repo); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return repo
+}
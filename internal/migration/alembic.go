@@ -0,0 +1,303 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Alembic revision files. The upgrade() function holds the operations
+// the revision applies; revision and down_revision chain the files.
+var (
+	alembicRevisionRe     = regexp.MustCompile(`(?m)^revision\s*(?::[^=\n]*)?=\s*["'](\w+)["']`)
+	alembicDownRevisionRe = regexp.MustCompile(`(?m)^down_revision\s*(?::[^=\n]*)?=\s*(.*)$`)
+	alembicUpgradeRe      = regexp.MustCompile(`(?m)^def\s+upgrade\s*\(`)
+	alembicNextDefRe      = regexp.MustCompile(`(?m)^def\s`)
+	alembicOpRe           = regexp.MustCompile(`\bop\.(\w+)\s*\(`)
+	pyCommentLineRe       = regexp.MustCompile(`(?m)^[ \t]*#.*$`)
+	alembicQuotedRe       = regexp.MustCompile(`["'](\w+)["']`)
+	pyKeywordArgRe        = regexp.MustCompile(`(?s)^(\w+)\s*=([^=].*)$`)
+	pyStringRe            = regexp.MustCompile(`^[rRbBuU]?("""|'''|"|')`)
+	pyColumnRe            = regexp.MustCompile(`^(?:sa\.|sqlalchemy\.)?Column\s*\(`)
+	pyConstraintRe        = regexp.MustCompile(`^(?:sa\.|sqlalchemy\.)?(PrimaryKeyConstraint|UniqueConstraint)\s*\(`)
+	pyTextRe              = regexp.MustCompile(`^(?:sa\.|sqlalchemy\.)?text\s*\(`)
+)
+
+// alembicRevision returns the revision a file declares and those it
+// follows: none for the first, two or more for a merge.
+func alembicRevision(src string) (revision string, down []string, ok bool) {
+	m := alembicRevisionRe.FindStringSubmatch(src)
+	if m == nil {
+		return "", nil, false
+	}
+	if d := alembicDownRevisionRe.FindStringSubmatch(src); d != nil {
+		for _, q := range alembicQuotedRe.FindAllStringSubmatch(d[1], -1) {
+			down = append(down, q[1])
+		}
+	}
+	return m[1], down, true
+}
+
+// ApplyAlembic applies the operations in the upgrade() function of an
+// Alembic revision: op.create_table, drop_table, rename_table,
+// add_column, drop_column, alter_column renames, create_index,
+// drop_index, create_primary_key, create_unique_constraint,
+// drop_constraint, and the SQL passed to op.execute.
+func (s *Schema) ApplyAlembic(file, src string) {
+	loc := alembicUpgradeRe.FindStringIndex(src)
+	if loc == nil {
+		return
+	}
+	start := loc[1]
+	end := len(src)
+	if next := alembicNextDefRe.FindStringIndex(src[start:]); next != nil {
+		end = start + next[0]
+	}
+
+	// Blank commented-out lines, keeping the line count.
+	upgrade := pyCommentLineRe.ReplaceAllString(src[start:end], "")
+	for _, m := range alembicOpRe.FindAllStringSubmatchIndex(upgrade, -1) {
+		open := m[1] - 1
+		body, ok := parenBody(upgrade[open:])
+		if !ok {
+			continue
+		}
+		line := strings.Count(src[:start], "\n") + strings.Count(upgrade[:open], "\n") + 1
+		s.alembicOp(upgrade[m[2]:m[3]], body, file, line)
+	}
+}
+
+func (s *Schema) alembicOp(op, args, file string, line int) {
+	pos, kw := pyArgs(args)
+	arg := func(i int) string {
+		if i < len(pos) {
+			v, _ := pyString(pos[i])
+			return v
+		}
+		return ""
+	}
+	schema, _ := pyString(kw["schema"])
+
+	switch op {
+	case "create_table":
+		name := arg(0)
+		if name == "" {
+			return
+		}
+		t := s.createTable(schema, name, file, line)
+		var pk []string
+		for _, a := range pos[1:] {
+			if c, ok := pyColumn(a); ok {
+				t.addColumn(c.name, file, line)
+				if c.primary {
+					pk = append(pk, c.name)
+				}
+				c.addIndexes(t, file, line)
+			} else if m := pyConstraintRe.FindStringSubmatch(a); m != nil {
+				body, _ := parenBody(a[len(m[0])-1:])
+				cols, ckw := pyArgs(body)
+				idx := SchemaIndex{Columns: pyStrings(cols), Unique: true, File: file, Line: line}
+				idx.Name, _ = pyString(ckw["name"])
+				if idx.Name == "" {
+					idx.Name = constraintName(t.Name, m[1], idx.Columns)
+				}
+				t.addIndex(idx)
+			}
+		}
+		if len(pk) > 0 {
+			t.addIndex(SchemaIndex{Name: t.Name + "_pkey", Columns: pk, Unique: true, File: file, Line: line})
+		}
+	case "drop_table":
+		s.dropTable(schema, arg(0))
+	case "rename_table":
+		s.renameTable(schema, arg(0), arg(1))
+	case "add_column":
+		t := s.table(schema, arg(0))
+		if t == nil || len(pos) < 2 {
+			return
+		}
+		if c, ok := pyColumn(pos[1]); ok {
+			t.addColumn(c.name, file, line)
+			c.addIndexes(t, file, line)
+		}
+	case "drop_column":
+		if t := s.table(schema, arg(0)); t != nil {
+			t.dropColumn(arg(1))
+		}
+	case "alter_column":
+		newName, _ := pyString(kw["new_column_name"])
+		if t := s.table(schema, arg(0)); t != nil && newName != "" {
+			t.renameColumn(arg(1), newName)
+		}
+	case "create_index":
+		table := arg(1)
+		if table == "" {
+			table, _ = pyString(kw["table_name"])
+		}
+		t := s.table(schema, table)
+		if t == nil {
+			return
+		}
+		cols := kw["columns"]
+		if len(pos) > 2 {
+			cols = pos[2]
+		}
+		t.addIndex(SchemaIndex{Name: arg(0), Columns: pyList(cols), Unique: kw["unique"] == "True", File: file, Line: line})
+	case "create_primary_key", "create_unique_constraint":
+		t := s.table(schema, arg(1))
+		if t == nil || len(pos) < 3 {
+			return
+		}
+		t.addIndex(SchemaIndex{Name: arg(0), Columns: pyList(pos[2]), Unique: true, File: file, Line: line})
+	case "drop_index", "drop_constraint":
+		table := arg(1)
+		if table == "" {
+			table, _ = pyString(kw["table_name"])
+		}
+		if t := s.table(schema, table); t != nil {
+			t.dropIndex(arg(0))
+			return
+		}
+		for _, t := range s.tables {
+			if t.dropIndex(arg(0)) {
+				return
+			}
+		}
+	case "execute":
+		sql := arg(0)
+		if sql == "" && len(pos) > 0 {
+			if m := pyTextRe.FindString(pos[0]); m != "" {
+				body, _ := parenBody(pos[0][len(m)-1:])
+				sql, _ = pyString(body)
+			}
+		}
+		stmts := Parse(sql)
+		for i := range stmts {
+			stmts[i].Line += line - 1
+		}
+		s.Apply(file, stmts)
+	}
+}
+
+// pyColumnDef is a Column(...) argument.
+type pyColumnDef struct {
+	name                   string
+	primary, unique, index bool
+}
+
+func pyColumn(arg string) (pyColumnDef, bool) {
+	m := pyColumnRe.FindString(arg)
+	if m == "" {
+		return pyColumnDef{}, false
+	}
+	body, _ := parenBody(arg[len(m)-1:])
+	pos, kw := pyArgs(body)
+	if len(pos) == 0 {
+		return pyColumnDef{}, false
+	}
+	name, ok := pyString(pos[0])
+	if !ok {
+		return pyColumnDef{}, false
+	}
+	return pyColumnDef{
+		name:    name,
+		primary: kw["primary_key"] == "True",
+		unique:  kw["unique"] == "True",
+		index:   kw["index"] == "True",
+	}, true
+}
+
+// addIndexes adds the indexes a column's unique=True and index=True
+// create, named as PostgreSQL and SQLAlchemy name them.
+func (c pyColumnDef) addIndexes(t *SchemaTable, file string, line int) {
+	cols := []string{c.name}
+	switch {
+	case c.index:
+		t.addIndex(SchemaIndex{Name: "ix_" + t.Name + "_" + c.name, Columns: cols, Unique: c.unique, File: file, Line: line})
+	case c.unique:
+		t.addIndex(SchemaIndex{Name: constraintName(t.Name, "UniqueConstraint", cols), Columns: cols, Unique: true, File: file, Line: line})
+	}
+}
+
+// constraintName is the name PostgreSQL gives an unnamed constraint.
+func constraintName(table, kind string, cols []string) string {
+	if kind == "PrimaryKeyConstraint" {
+		return table + "_pkey"
+	}
+	return table + "_" + strings.Join(cols, "_") + "_key"
+}
+
+// pyArgs splits the arguments of a Python call into positional and
+// keyword arguments.
+func pyArgs(args string) ([]string, map[string]string) {
+	var pos []string
+	kw := make(map[string]string)
+	for _, a := range splitTopLevel(args) {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if m := pyKeywordArgRe.FindStringSubmatch(a); m != nil {
+			kw[m[1]] = strings.TrimSpace(m[2])
+			continue
+		}
+		pos = append(pos, a)
+	}
+	return pos, kw
+}
+
+// pyString returns the value of a Python string literal, or of one
+// wrapped in op.f() to mark it as already named.
+func pyString(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if strings.HasPrefix(arg, "op.f(") {
+		body, _ := parenBody(arg[len("op.f"):])
+		return pyString(body)
+	}
+	m := pyStringRe.FindStringSubmatch(arg)
+	if m == nil {
+		return "", false
+	}
+	q := m[1]
+	rest := arg[len(m[0]):]
+	end := strings.Index(rest, q)
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+// pyList returns the strings of a Python list or tuple literal. An
+// element such as sa.text("lower(email)") is an indexed expression.
+func pyList(arg string) []string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < 2 || !strings.ContainsRune("[(", rune(arg[0])) {
+		if s, ok := pyString(arg); ok {
+			return []string{s}
+		}
+		return nil
+	}
+	var out []string
+	for _, el := range splitTopLevel(arg[1 : len(arg)-1]) {
+		el = strings.TrimSpace(el)
+		if s, ok := pyString(el); ok {
+			out = append(out, s)
+		} else if i := strings.IndexByte(el, '('); i > 0 {
+			body, _ := parenBody(el[i:])
+			if s, ok := pyString(body); ok {
+				out = append(out, strings.ToLower(s))
+			}
+		}
+	}
+	return out
+}
+
+// pyStrings returns the string literals among args.
+func pyStrings(args []string) []string {
+	var out []string
+	for _, a := range args {
+		if s, ok := pyString(a); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
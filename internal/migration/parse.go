@@ -0,0 +1,203 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Action is what a statement does to its object.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionDrop   Action = "drop"
+	ActionAlter  Action = "alter"
+	ActionOther  Action = "other"
+)
+
+// ObjectKind is the kind of object a statement targets.
+type ObjectKind string
+
+const (
+	KindTable ObjectKind = "table"
+	KindIndex ObjectKind = "index"
+)
+
+// Statement is a single classified SQL statement from a migration file.
+type Statement struct {
+	Line         int        `json:"line"`
+	SQL          string     `json:"sql"`
+	Action       Action     `json:"action"`
+	Kind         ObjectKind `json:"kind,omitempty"`
+	Schema       string     `json:"schema,omitempty"` // object schema, empty if unqualified
+	Name         string     `json:"name,omitempty"`   // object name
+	TableSchema  string     `json:"tableSchema,omitempty"`
+	Table        string     `json:"table,omitempty"` // table the object belongs to (same as Name for tables)
+	IfExists     bool       `json:"ifExists,omitempty"`
+	IfNotExists  bool       `json:"ifNotExists,omitempty"`
+	Concurrently bool       `json:"concurrently,omitempty"`
+	Unique       bool       `json:"unique,omitempty"`
+	// Clause is the rest of an ALTER TABLE statement after the table name.
+	Clause string `json:"clause,omitempty"`
+}
+
+const identPattern = `(?:"(?:[^"]|"")+"|[\w$]+)`
+const namePattern = `(` + identPattern + `(?:\.` + identPattern + `)?)`
+
+var (
+	createTableRe = regexp.MustCompile(`(?i)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:UNLOGGED\s+|TEMP\s+|TEMPORARY\s+)?TABLE\s+(IF\s+NOT\s+EXISTS\s+)?` + namePattern)
+	dropTableRe   = regexp.MustCompile(`(?i)^DROP\s+TABLE\s+(IF\s+EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	createIndexRe = regexp.MustCompile(`(?i)^CREATE\s+(UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(IF\s+NOT\s+EXISTS\s+)?(?:` + namePattern + `\s+)?ON\s+(?:ONLY\s+)?` + namePattern)
+	dropIndexRe   = regexp.MustCompile(`(?i)^DROP\s+INDEX\s+(CONCURRENTLY\s+)?(IF\s+EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	alterTableRe  = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(IF\s+EXISTS\s+)?(?:ONLY\s+)?` + namePattern + `\s*\*?\s+(.+)$`)
+	whitespaceRe  = regexp.MustCompile(`\s+`)
+	renameRe      = regexp.MustCompile(`(?i)^RENAME\s+(?:(?:COLUMN\s+)?(` + identPattern + `)\s+)?TO\s+(` + identPattern + `)$`)
+)
+
+// ParseFile reads and parses a migration file.
+func ParseFile(path string) ([]Statement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read migration: %w", err)
+	}
+	return Parse(string(data)), nil
+}
+
+// Parse splits SQL source into statements and classifies each one.
+// Statements it doesn't recognize are returned with ActionOther.
+func Parse(src string) []Statement {
+	var stmts []Statement
+	for _, raw := range splitStatements(src) {
+		stmts = append(stmts, classify(raw)...)
+	}
+	return stmts
+}
+
+// classify turns one raw statement into one or more Statements. DROP
+// statements listing several objects produce one Statement per object.
+func classify(raw rawStatement) []Statement {
+	sql := whitespaceRe.ReplaceAllString(strings.TrimSpace(raw.sql), " ")
+	base := Statement{Line: raw.line, SQL: sql, Action: ActionOther}
+
+	if m := createTableRe.FindStringSubmatch(sql); m != nil {
+		s := base
+		s.Action, s.Kind = ActionCreate, KindTable
+		s.IfNotExists = m[1] != ""
+		s.Schema, s.Name = splitName(m[2])
+		s.TableSchema, s.Table = s.Schema, s.Name
+		return []Statement{s}
+	}
+	if m := createIndexRe.FindStringSubmatch(sql); m != nil {
+		s := base
+		s.Action, s.Kind = ActionCreate, KindIndex
+		s.Unique = m[1] != ""
+		s.Concurrently = m[2] != ""
+		s.IfNotExists = m[3] != ""
+		if m[4] != "" {
+			s.Schema, s.Name = splitName(m[4])
+		}
+		s.TableSchema, s.Table = splitName(m[5])
+		return []Statement{s}
+	}
+	if m := alterTableRe.FindStringSubmatch(sql); m != nil {
+		s := base
+		s.Action, s.Kind = ActionAlter, KindTable
+		s.IfExists = m[1] != ""
+		s.Schema, s.Name = splitName(m[2])
+		s.TableSchema, s.Table = s.Schema, s.Name
+		s.Clause = m[3]
+		return []Statement{s}
+	}
+	if m := dropTableRe.FindStringSubmatch(sql); m != nil {
+		var out []Statement
+		for _, name := range splitList(m[2]) {
+			s := base
+			s.Action, s.Kind = ActionDrop, KindTable
+			s.IfExists = m[1] != ""
+			s.Schema, s.Name = splitName(name)
+			s.TableSchema, s.Table = s.Schema, s.Name
+			out = append(out, s)
+		}
+		return out
+	}
+	if m := dropIndexRe.FindStringSubmatch(sql); m != nil {
+		var out []Statement
+		for _, name := range splitList(m[3]) {
+			s := base
+			s.Action, s.Kind = ActionDrop, KindIndex
+			s.Concurrently = m[1] != ""
+			s.IfExists = m[2] != ""
+			s.Schema, s.Name = splitName(name)
+			out = append(out, s)
+		}
+		return out
+	}
+	return []Statement{base}
+}
+
+// Renamed reports whether s is an ALTER TABLE ... RENAME, returning the
+// renamed column (empty when the table itself is renamed) and the new name.
+func (s Statement) Renamed() (column, newName string, ok bool) {
+	if s.Kind != KindTable || s.Action != ActionAlter {
+		return "", "", false
+	}
+	m := renameRe.FindStringSubmatch(s.Clause)
+	if m == nil {
+		return "", "", false
+	}
+	if m[1] != "" {
+		column = unquoteIdent(m[1])
+	}
+	return column, unquoteIdent(m[2]), true
+}
+
+// splitList splits a comma-separated object list.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// splitName splits a possibly schema-qualified name. Unquoted identifiers
+// are folded to lower case, as PostgreSQL does.
+func splitName(qualified string) (schema, name string) {
+	parts := splitQualified(qualified)
+	if len(parts) == 2 {
+		return unquoteIdent(parts[0]), unquoteIdent(parts[1])
+	}
+	return "", unquoteIdent(parts[0])
+}
+
+// splitQualified splits on dots outside double quotes.
+func splitQualified(s string) []string {
+	var parts []string
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case r == '.' && !inQuote:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return append(parts, cur.String())
+}
+
+func unquoteIdent(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return strings.ToLower(s)
+}
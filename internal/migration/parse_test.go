@@ -0,0 +1,143 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	src := `-- add orders; not a statement
+CREATE TABLE orders (
+	id bigint PRIMARY KEY,
+	note text DEFAULT 'a;b'
+);
+
+/* block ; comment */
+CREATE FUNCTION f() RETURNS trigger AS $body$
+BEGIN
+	RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+ALTER TABLE "weird;name" ADD COLUMN x int`
+
+	stmts := splitStatements(src)
+	if len(stmts) != 3 {
+		t.Fatalf("got %d statements, want 3: %#v", len(stmts), stmts)
+	}
+	wantLines := []int{2, 8, 13}
+	for i, want := range wantLines {
+		if stmts[i].line != want {
+			t.Errorf("statement %d line = %d, want %d", i, stmts[i].line, want)
+		}
+	}
+}
+
+func TestParse_Classify(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want Statement
+	}{
+		{
+			"CREATE TABLE IF NOT EXISTS app.Orders (id int)",
+			Statement{Action: ActionCreate, Kind: KindTable, Schema: "app", Name: "orders", TableSchema: "app", Table: "orders", IfNotExists: true},
+		},
+		{
+			"CREATE UNLOGGED TABLE staging (id int)",
+			Statement{Action: ActionCreate, Kind: KindTable, Name: "staging", Table: "staging"},
+		},
+		{
+			"CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_email ON public.users USING btree (email)",
+			Statement{Action: ActionCreate, Kind: KindIndex, Name: "idx_email", TableSchema: "public", Table: "users", Unique: true, Concurrently: true, IfNotExists: true},
+		},
+		{
+			"CREATE INDEX ON users (created_at)",
+			Statement{Action: ActionCreate, Kind: KindIndex, Table: "users"},
+		},
+		{
+			`ALTER TABLE ONLY "Users" ADD COLUMN age int`,
+			Statement{Action: ActionAlter, Kind: KindTable, Name: "Users", Table: "Users", Clause: "ADD COLUMN age int"},
+		},
+		{
+			"DROP INDEX CONCURRENTLY IF EXISTS idx_old",
+			Statement{Action: ActionDrop, Kind: KindIndex, Name: "idx_old", Concurrently: true, IfExists: true},
+		},
+		{
+			"INSERT INTO users VALUES (1)",
+			Statement{Action: ActionOther},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			got := Parse(tt.sql)
+			if len(got) != 1 {
+				t.Fatalf("got %d statements, want 1", len(got))
+			}
+			g := got[0]
+			g.Line, g.SQL = 0, ""
+			if g != tt.want {
+				t.Errorf("got %+v\nwant %+v", g, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_DropMultiple(t *testing.T) {
+	got := Parse("DROP TABLE IF EXISTS a, public.b CASCADE;")
+	if len(got) != 2 {
+		t.Fatalf("got %d statements, want 2", len(got))
+	}
+	if got[0].Name != "a" || got[1].Schema != "public" || got[1].Name != "b" {
+		t.Errorf("unexpected names: %+v", got)
+	}
+	for _, s := range got {
+		if s.Action != ActionDrop || !s.IfExists {
+			t.Errorf("unexpected statement: %+v", s)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "001_init.sql")
+	if err := os.WriteFile(path, []byte("CREATE TABLE a (id int);\nDROP TABLE b;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Line != 2 {
+		t.Errorf("unexpected statements: %+v", got)
+	}
+
+	if _, err := ParseFile(filepath.Join(t.TempDir(), "missing.sql")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestStatementRenamed(t *testing.T) {
+	tests := []struct {
+		sql        string
+		wantOK     bool
+		wantColumn string
+		wantNew    string
+	}{
+		{"ALTER TABLE users RENAME TO accounts", true, "", "accounts"},
+		{"ALTER TABLE users RENAME COLUMN email TO email_address", true, "email", "email_address"},
+		{`ALTER TABLE users RENAME "Email" TO "EmailAddress"`, true, "Email", "EmailAddress"},
+		{"ALTER TABLE users RENAME CONSTRAINT users_pkey TO accounts_pkey", false, "", ""},
+		{"ALTER TABLE users ADD COLUMN renamed_at timestamptz", false, "", ""},
+		{"CREATE TABLE users (id int)", false, "", ""},
+	}
+	for _, tt := range tests {
+		stmts := Parse(tt.sql)
+		if len(stmts) != 1 {
+			t.Fatalf("%q: got %d statements", tt.sql, len(stmts))
+		}
+		column, newName, ok := stmts[0].Renamed()
+		if ok != tt.wantOK || column != tt.wantColumn || newName != tt.wantNew {
+			t.Errorf("%q: Renamed() = (%q, %q, %v), want (%q, %q, %v)", tt.sql, column, newName, ok, tt.wantColumn, tt.wantNew, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Prisma schema blocks, fields, and attributes.
+var (
+	prismaModelRe      = regexp.MustCompile(`^\s*model\s+(\w+)\s*\{`)
+	prismaEnumRe       = regexp.MustCompile(`^\s*enum\s+(\w+)\s*\{`)
+	prismaBlockEndRe   = regexp.MustCompile(`^\s*\}`)
+	prismaFieldRe      = regexp.MustCompile(`^\s*(\w+)\s+(\w+)(?:\([^)]*\))?(\[\])?\??(.*)$`)
+	prismaMapRe        = regexp.MustCompile(`@map\(\s*(?:name:\s*)?"([^"]+)"`)
+	prismaIDRe         = regexp.MustCompile(`@id\b`)
+	prismaUniqueRe     = regexp.MustCompile(`@unique\b`)
+	prismaBlockMapRe   = regexp.MustCompile(`^\s*@@map\(\s*(?:name:\s*)?"([^"]+)"`)
+	prismaBlockSchema  = regexp.MustCompile(`^\s*@@schema\(\s*"([^"]+)"`)
+	prismaBlockIndexRe = regexp.MustCompile(`^\s*@@(id|unique|index)\(\s*(?:fields:\s*)?\[(.*?)\]`)
+	prismaIndexMapRe   = regexp.MustCompile(`\bmap:\s*"([^"]+)"`)
+)
+
+// prismaScalars are Prisma's built-in scalar types. A field of another
+// type is a relation, or an enum or composite type.
+var prismaScalars = map[string]bool{
+	"String": true, "Boolean": true, "Int": true, "BigInt": true, "Float": true,
+	"Decimal": true, "DateTime": true, "Json": true, "Bytes": true, "Unsupported": true,
+}
+
+// prismaModel is a model being read. Index fields name model fields,
+// which are mapped to columns once the whole model is read.
+type prismaModel struct {
+	table, schema string
+	line          int
+	fields        []prismaField
+	indexes       []prismaIndex
+}
+
+type prismaField struct {
+	name, column string
+	line         int
+}
+
+type prismaIndex struct {
+	kind   string // id, unique, or index
+	name   string // from map:, if set
+	fields []string
+	line   int
+}
+
+// ApplyPrisma adds the models of a Prisma schema. A model's table is its
+// name unless @@map sets one, and its columns are its scalar and enum
+// fields, named by @map if set; relation fields have no column. Indexes
+// come from @id, @unique, @@id, @@unique, and @@index, named as Prisma
+// Migrate names them unless map: is set. Enums declared in other files
+// of a multi-file schema aren't known, so their fields are left out.
+func (s *Schema) ApplyPrisma(file, src string) {
+	lines := strings.Split(src, "\n")
+	enums := make(map[string]bool)
+	for _, line := range lines {
+		if m := prismaEnumRe.FindStringSubmatch(line); m != nil {
+			enums[m[1]] = true
+		}
+	}
+
+	var model *prismaModel
+	for i, line := range lines {
+		lineNum := i + 1
+		line = stripPrismaComment(line)
+		if model == nil {
+			if m := prismaModelRe.FindStringSubmatch(line); m != nil {
+				model = &prismaModel{table: m[1], line: lineNum}
+			}
+			continue
+		}
+		if prismaBlockEndRe.MatchString(line) {
+			s.addPrismaModel(model, file)
+			model = nil
+			continue
+		}
+
+		if m := prismaBlockMapRe.FindStringSubmatch(line); m != nil {
+			model.table = m[1]
+			continue
+		}
+		if m := prismaBlockSchema.FindStringSubmatch(line); m != nil {
+			model.schema = m[1]
+			continue
+		}
+		if m := prismaBlockIndexRe.FindStringSubmatch(line); m != nil {
+			idx := prismaIndex{kind: m[1], line: lineNum}
+			for _, el := range splitTopLevel(m[2]) {
+				if f := firstIdent(strings.TrimSpace(el)); f != "" {
+					idx.fields = append(idx.fields, f)
+				}
+			}
+			if n := prismaIndexMapRe.FindStringSubmatch(line[len(m[0]):]); n != nil {
+				idx.name = n[1]
+			}
+			model.indexes = append(model.indexes, idx)
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "@@") {
+			continue
+		}
+
+		m := prismaFieldRe.FindStringSubmatch(line)
+		if m == nil || !(prismaScalars[m[2]] || enums[m[2]]) {
+			continue
+		}
+		f := prismaField{name: m[1], column: m[1], line: lineNum}
+		attrs := m[4]
+		if mm := prismaMapRe.FindStringSubmatch(attrs); mm != nil {
+			f.column = mm[1]
+		}
+		model.fields = append(model.fields, f)
+		for _, fi := range prismaFieldIndexes {
+			loc := fi.re.FindStringIndex(attrs)
+			if loc == nil {
+				continue
+			}
+			idx := prismaIndex{kind: fi.kind, fields: []string{f.name}, line: lineNum}
+			if args, ok := parenBody(attrs[loc[1]:]); ok {
+				if n := prismaIndexMapRe.FindStringSubmatch(args); n != nil {
+					idx.name = n[1]
+				}
+			}
+			model.indexes = append(model.indexes, idx)
+		}
+	}
+}
+
+// prismaFieldIndexes are the field attributes that create an index.
+var prismaFieldIndexes = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"id", prismaIDRe},
+	{"unique", prismaUniqueRe},
+}
+
+// stripPrismaComment removes a // comment from a line of a Prisma schema.
+func stripPrismaComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			i = closingQuote(line, i) - 1
+		case '/':
+			if strings.HasPrefix(line[i:], "//") {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func (s *Schema) addPrismaModel(m *prismaModel, file string) {
+	t := s.createTable(m.schema, m.table, file, m.line)
+	columns := make(map[string]string, len(m.fields))
+	for _, f := range m.fields {
+		t.addColumn(f.column, file, f.line)
+		columns[f.name] = f.column
+	}
+	for _, idx := range m.indexes {
+		var cols []string
+		for _, f := range idx.fields {
+			if c, ok := columns[f]; ok {
+				cols = append(cols, c)
+			} else {
+				cols = append(cols, f)
+			}
+		}
+		name := idx.name
+		if name == "" {
+			switch idx.kind {
+			case "id":
+				name = m.table + "_pkey"
+			case "unique":
+				name = m.table + "_" + strings.Join(cols, "_") + "_key"
+			default:
+				name = m.table + "_" + strings.Join(cols, "_") + "_idx"
+			}
+		}
+		t.addIndex(SchemaIndex{Name: name, Columns: cols, Unique: idx.kind != "index", File: file, Line: idx.line})
+	}
+}
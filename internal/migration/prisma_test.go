@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaApplyPrisma(t *testing.T) {
+	src := `datasource db {
+  provider = "postgresql"
+  url      = env("DATABASE_URL")
+}
+
+enum Role {
+  USER
+  ADMIN
+}
+
+model User {
+  id        Int      @id @default(autoincrement())
+  email     String   @unique
+  fullName  String?  @map("full_name") // "display" name
+  role      Role     @default(USER)
+  posts     Post[]
+  profile   Profile?
+  // legacy  String
+}
+
+model Post {
+  id       Int    @id
+  authorId Int    @map("author_id")
+  author   User   @relation(fields: [authorId], references: [id])
+  slug     String
+  tags     String[]
+
+  @@unique([authorId, slug], map: "post_author_slug")
+  @@index([authorId])
+  @@map("blog_posts")
+  @@schema("content")
+}
+`
+	s := NewSchema()
+	s.ApplyPrisma("prisma/schema.prisma", src)
+	tables := s.Tables()
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want 2: %+v", len(tables), tables)
+	}
+
+	posts, users := tables[0], tables[1]
+	if posts.Schema != "content" || posts.Name != "blog_posts" || posts.Line != 21 {
+		t.Errorf("posts = %s.%s at line %d, want content.blog_posts at 21", posts.Schema, posts.Name, posts.Line)
+	}
+	if got := columnNames(posts); !reflect.DeepEqual(got, []string{"id", "author_id", "slug", "tags"}) {
+		t.Errorf("posts columns = %v", got)
+	}
+	wantPosts := []SchemaIndex{
+		{Name: "blog_posts_pkey", Columns: []string{"id"}, Unique: true, File: "prisma/schema.prisma", Line: 22},
+		{Name: "post_author_slug", Columns: []string{"author_id", "slug"}, Unique: true, File: "prisma/schema.prisma", Line: 28},
+		{Name: "blog_posts_author_id_idx", Columns: []string{"author_id"}, File: "prisma/schema.prisma", Line: 29},
+	}
+	if !reflect.DeepEqual(posts.Indexes, wantPosts) {
+		t.Errorf("posts indexes = %+v\nwant %+v", posts.Indexes, wantPosts)
+	}
+
+	if users.Schema != "public" || users.Name != "User" {
+		t.Errorf("users = %s.%s, want public.User", users.Schema, users.Name)
+	}
+	if got := columnNames(users); !reflect.DeepEqual(got, []string{"id", "email", "full_name", "role"}) {
+		t.Errorf("users columns = %v", got)
+	}
+	wantUsers := []SchemaIndex{
+		{Name: "User_pkey", Columns: []string{"id"}, Unique: true, File: "prisma/schema.prisma", Line: 12},
+		{Name: "User_email_key", Columns: []string{"email"}, Unique: true, File: "prisma/schema.prisma", Line: 13},
+	}
+	if !reflect.DeepEqual(users.Indexes, wantUsers) {
+		t.Errorf("users indexes = %+v\nwant %+v", users.Indexes, wantUsers)
+	}
+}
@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rails db/schema.rb statements. The dumper writes one per line, with
+// names in double quotes; migrations may use symbols.
+var (
+	railsCreateTableRe = regexp.MustCompile(`^\s*create_table\s*\(?\s*["':]([\w.]+)"?(.*?)\s+do\s*\|\w+\|`)
+	railsColumnRe      = regexp.MustCompile(`^\s*\w+\.(\w+)\s*\(?\s*["':](\w+)"?(.*)$`)
+	railsTimestampsRe  = regexp.MustCompile(`^\s*\w+\.timestamps\b`)
+	railsIndexRe       = regexp.MustCompile(`^\s*\w+\.index\s*\(?\s*(\[[^\]]*\]|"[^"]*"|:\w+)(.*)$`)
+	railsAddIndexRe    = regexp.MustCompile(`^\s*add_index\s*\(?\s*["':]([\w.]+)"?\s*,\s*(\[[^\]]*\]|"[^"]*"|:\w+)(.*)$`)
+	railsEndRe         = regexp.MustCompile(`^\s*end\b`)
+	railsNameOptRe     = regexp.MustCompile(`\bname:\s*"([^"]+)"`)
+	railsUniqueOptRe   = regexp.MustCompile(`\bunique:\s*true\b`)
+	railsNoIDOptRe     = regexp.MustCompile(`\bid:\s*false\b`)
+	railsPrimaryKeyRe  = regexp.MustCompile(`\bprimary_key:\s*["':](\w+)`)
+	railsPolymorphicRe = regexp.MustCompile(`\bpolymorphic:\s*true\b`)
+	railsStringRe      = regexp.MustCompile(`"([^"]*)"|:(\w+)`)
+)
+
+// railsNonColumns are table definition methods that don't add a column
+// named by their first argument.
+var railsNonColumns = map[string]bool{
+	"index": true, "timestamps": true, "check_constraint": true, "exclusion_constraint": true,
+	"unique_constraint": true, "foreign_key": true, "remove": true, "rename": true,
+}
+
+// ApplyRails applies a Rails db/schema.rb: its create_table blocks, with
+// their columns and t.index lines, and add_index statements. A table has
+// an id column unless it is created with id: false or another
+// primary_key.
+func (s *Schema) ApplyRails(file, src string) {
+	var t *SchemaTable
+	for i, line := range strings.Split(src, "\n") {
+		lineNum := i + 1
+		if t == nil {
+			if m := railsCreateTableRe.FindStringSubmatch(line); m != nil {
+				schema, name := splitName(m[1])
+				t = s.createTable(schema, name, file, lineNum)
+				opts := m[2]
+				if pk := railsPrimaryKeyRe.FindStringSubmatch(opts); pk != nil {
+					t.addColumn(pk[1], file, lineNum)
+				} else if !railsNoIDOptRe.MatchString(opts) {
+					t.addColumn("id", file, lineNum)
+				}
+			} else if m := railsAddIndexRe.FindStringSubmatch(line); m != nil {
+				schema, name := splitName(m[1])
+				if target := s.table(schema, name); target != nil {
+					target.addIndex(railsIndex(target.Name, m[2], m[3], file, lineNum))
+				}
+			}
+			continue
+		}
+
+		switch {
+		case railsEndRe.MatchString(line):
+			t = nil
+		case railsTimestampsRe.MatchString(line):
+			t.addColumn("created_at", file, lineNum)
+			t.addColumn("updated_at", file, lineNum)
+		case railsIndexRe.MatchString(line):
+			m := railsIndexRe.FindStringSubmatch(line)
+			t.addIndex(railsIndex(t.Name, m[1], m[2], file, lineNum))
+		default:
+			m := railsColumnRe.FindStringSubmatch(line)
+			if m == nil || railsNonColumns[m[1]] {
+				continue
+			}
+			if m[1] == "references" || m[1] == "belongs_to" {
+				t.addColumn(m[2]+"_id", file, lineNum)
+				if railsPolymorphicRe.MatchString(m[3]) {
+					t.addColumn(m[2]+"_type", file, lineNum)
+				}
+				continue
+			}
+			t.addColumn(m[2], file, lineNum)
+		}
+	}
+}
+
+// railsIndex builds an index from the columns and options of t.index or
+// add_index. An index without name: gets the name Rails would give it.
+func railsIndex(table, columns, opts, file string, line int) SchemaIndex {
+	idx := SchemaIndex{Unique: railsUniqueOptRe.MatchString(opts), File: file, Line: line}
+	if strings.HasPrefix(columns, `"`) && strings.ContainsAny(columns, "( ") {
+		idx.Columns = []string{strings.ToLower(strings.Trim(columns, `"`))} // an expression
+	} else {
+		for _, m := range railsStringRe.FindAllStringSubmatch(columns, -1) {
+			idx.Columns = append(idx.Columns, m[1]+m[2])
+		}
+	}
+	if m := railsNameOptRe.FindStringSubmatch(opts); m != nil {
+		idx.Name = m[1]
+	} else {
+		idx.Name = "index_" + table + "_on_" + strings.Join(idx.Columns, "_and_")
+	}
+	return idx
+}
@@ -0,0 +1,384 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultSchema is where unqualified names are created, on PostgreSQL's
+// default search_path.
+const defaultSchema = "public"
+
+// Schema is the schema a codebase declares: the tables, columns, and
+// indexes of a schema dump, or of its migrations applied in order.
+type Schema struct {
+	tables map[string]*SchemaTable // by lowercased schema.name
+}
+
+// SchemaTable is a table a schema declares. File and Line locate the
+// statement that created it; its columns and indexes carry their own.
+type SchemaTable struct {
+	Schema  string         `json:"schema"`
+	Name    string         `json:"name"`
+	Columns []SchemaColumn `json:"columns"`
+	Indexes []SchemaIndex  `json:"indexes,omitempty"`
+	File    string         `json:"file"`
+	Line    int            `json:"line"`
+}
+
+// SchemaColumn is a column a schema declares.
+type SchemaColumn struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// SchemaIndex is an index a schema declares, including those backing
+// PRIMARY KEY and UNIQUE constraints. Columns holds the indexed columns,
+// or the text of indexed expressions.
+type SchemaIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns,omitempty"`
+	Unique  bool     `json:"unique,omitempty"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+}
+
+// NewSchema returns an empty schema.
+func NewSchema() *Schema {
+	return &Schema{tables: make(map[string]*SchemaTable)}
+}
+
+// LoadSchema reads the schema declared by a schema dump: a Rails
+// db/schema.rb, or SQL DDL such as a Rails structure.sql or pg_dump
+// --schema-only output, or a Prisma schema. A directory is replayed as versioned migrations.
+func LoadSchema(path string) (*Schema, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return ReplayDir(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	s := NewSchema()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rb":
+		s.ApplyRails(path, string(data))
+	case ".sql":
+		s.Apply(path, Parse(string(data)))
+	case ".prisma":
+		s.ApplyPrisma(path, string(data))
+	default:
+		return nil, fmt.Errorf("%s: not a schema.rb, .sql, or .prisma file, or a migration directory", path)
+	}
+	return s, nil
+}
+
+// Tables returns the declared tables, sorted by schema and name.
+func (s *Schema) Tables() []SchemaTable {
+	out := make([]SchemaTable, 0, len(s.tables))
+	for _, t := range s.tables {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Schema != out[j].Schema {
+			return out[i].Schema < out[j].Schema
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (s *Schema) table(schema, name string) *SchemaTable {
+	return s.tables[schemaKey(schema, name)]
+}
+
+func (s *Schema) createTable(schema, name, file string, line int) *SchemaTable {
+	if schema == "" {
+		schema = defaultSchema
+	}
+	t := &SchemaTable{Schema: schema, Name: name, File: file, Line: line}
+	s.tables[schemaKey(schema, name)] = t
+	return t
+}
+
+func (s *Schema) dropTable(schema, name string) {
+	delete(s.tables, schemaKey(schema, name))
+}
+
+func (s *Schema) renameTable(schema, name, newName string) {
+	key := schemaKey(schema, name)
+	t := s.tables[key]
+	if t == nil {
+		return
+	}
+	delete(s.tables, key)
+	t.Name = newName
+	s.tables[schemaKey(t.Schema, newName)] = t
+}
+
+func schemaKey(schema, name string) string {
+	if schema == "" {
+		schema = defaultSchema
+	}
+	return strings.ToLower(schema + "." + name)
+}
+
+func (t *SchemaTable) addColumn(name, file string, line int) {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return
+		}
+	}
+	t.Columns = append(t.Columns, SchemaColumn{Name: name, File: file, Line: line})
+}
+
+func (t *SchemaTable) dropColumn(name string) {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			t.Columns = append(t.Columns[:i:i], t.Columns[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *SchemaTable) renameColumn(name, newName string) {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			t.Columns[i].Name = newName
+		}
+	}
+	for i, idx := range t.Indexes {
+		for j, col := range idx.Columns {
+			if strings.EqualFold(col, name) {
+				t.Indexes[i].Columns[j] = newName
+			}
+		}
+	}
+}
+
+func (t *SchemaTable) addIndex(idx SchemaIndex) {
+	for i, existing := range t.Indexes {
+		if idx.Name != "" && strings.EqualFold(existing.Name, idx.Name) {
+			t.Indexes[i] = idx
+			return
+		}
+	}
+	t.Indexes = append(t.Indexes, idx)
+}
+
+func (t *SchemaTable) dropIndex(name string) bool {
+	for i, idx := range t.Indexes {
+		if strings.EqualFold(idx.Name, name) {
+			t.Indexes = append(t.Indexes[:i:i], t.Indexes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ALTER TABLE actions that change the declared columns and indexes.
+var (
+	addColumnRe     = regexp.MustCompile(`(?i)^ADD\s+(COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `)`)
+	dropColumnRe    = regexp.MustCompile(`(?i)^DROP\s+(COLUMN\s+)?(?:IF\s+EXISTS\s+)?(` + identPattern + `)`)
+	addConstraintRe = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+(` + identPattern + `)\s+)?(PRIMARY\s+KEY|UNIQUE)\s*(?:NULLS\s+(?:NOT\s+)?DISTINCT\s*)?(\(.*)$`)
+	dropConstraint  = regexp.MustCompile(`(?i)^DROP\s+CONSTRAINT\s+(?:IF\s+EXISTS\s+)?(` + identPattern + `)`)
+	usingMethodRe   = regexp.MustCompile(`(?i)^USING\s+\w+\s*`)
+)
+
+// constraintWords start a table constraint rather than a column, in a
+// CREATE TABLE body or after ALTER TABLE ... ADD.
+var constraintWords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "CHECK": true,
+	"FOREIGN": true, "EXCLUDE": true, "LIKE": true,
+}
+
+// Apply applies the statements of a migration or schema file in order:
+// tables and indexes are created and dropped, and ALTER TABLE adds,
+// drops, and renames columns and constraints. Other statements are
+// ignored.
+func (s *Schema) Apply(file string, stmts []Statement) {
+	for _, st := range stmts {
+		switch {
+		case st.Kind == KindTable && st.Action == ActionCreate:
+			if st.IfNotExists && s.table(st.Schema, st.Name) != nil {
+				continue
+			}
+			t := s.createTable(st.Schema, st.Name, file, st.Line)
+			for _, col := range tableColumns(st.SQL) {
+				t.addColumn(col, file, st.Line)
+			}
+		case st.Kind == KindTable && st.Action == ActionDrop:
+			s.dropTable(st.Schema, st.Name)
+		case st.Kind == KindTable && st.Action == ActionAlter:
+			s.alterTable(st, file)
+		case st.Kind == KindIndex && st.Action == ActionCreate:
+			if t := s.table(st.TableSchema, st.Table); t != nil {
+				t.addIndex(SchemaIndex{Name: st.Name, Columns: indexColumns(st.SQL), Unique: st.Unique, File: file, Line: st.Line})
+			}
+		case st.Kind == KindIndex && st.Action == ActionDrop:
+			for _, t := range s.tables {
+				if t.dropIndex(st.Name) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (s *Schema) alterTable(st Statement, file string) {
+	t := s.table(st.Schema, st.Name)
+	if t == nil {
+		return
+	}
+	if column, newName, ok := st.Renamed(); ok {
+		if column != "" {
+			t.renameColumn(column, newName)
+			return
+		}
+		s.renameTable(st.Schema, st.Name, newName)
+		return
+	}
+	for _, action := range splitTopLevel(st.Clause) {
+		action = strings.TrimSpace(action)
+		if m := addConstraintRe.FindStringSubmatch(action); m != nil {
+			body, _ := parenBody(m[3])
+			name := unquoteIdent(m[1])
+			primary := strings.HasPrefix(strings.ToUpper(m[2]), "PRIMARY")
+			if name == "" && primary {
+				name = t.Name + "_pkey"
+			}
+			t.addIndex(SchemaIndex{Name: name, Columns: elementNames(body), Unique: true, File: file, Line: st.Line})
+			continue
+		}
+		if m := dropConstraint.FindStringSubmatch(action); m != nil {
+			t.dropIndex(unquoteIdent(m[1]))
+			continue
+		}
+		if m := addColumnRe.FindStringSubmatch(action); m != nil {
+			if m[1] != "" || !constraintWords[strings.ToUpper(m[2])] {
+				t.addColumn(unquoteIdent(m[2]), file, st.Line)
+			}
+			continue
+		}
+		if m := dropColumnRe.FindStringSubmatch(action); m != nil && (m[1] != "" || !strings.EqualFold(m[2], "CONSTRAINT")) {
+			t.dropColumn(unquoteIdent(m[2]))
+		}
+	}
+}
+
+// tableColumns returns the columns a CREATE TABLE statement defines in
+// its parenthesized body. CREATE TABLE ... AS, OF, and PARTITION OF
+// have none.
+func tableColumns(sql string) []string {
+	m := createTableRe.FindStringIndex(sql)
+	if m == nil {
+		return nil
+	}
+	rest := strings.TrimSpace(sql[m[1]:])
+	if !strings.HasPrefix(rest, "(") {
+		return nil
+	}
+	body, _ := parenBody(rest)
+	var cols []string
+	for _, def := range splitTopLevel(body) {
+		first := firstIdent(strings.TrimSpace(def))
+		if first == "" || (first[0] != '"' && constraintWords[strings.ToUpper(first)]) {
+			continue
+		}
+		cols = append(cols, unquoteIdent(first))
+	}
+	return cols
+}
+
+// indexColumns returns the columns, or expressions, a CREATE INDEX
+// statement indexes.
+func indexColumns(sql string) []string {
+	m := createIndexRe.FindStringIndex(sql)
+	if m == nil {
+		return nil
+	}
+	rest := usingMethodRe.ReplaceAllString(strings.TrimSpace(sql[m[1]:]), "")
+	body, ok := parenBody(rest)
+	if !ok {
+		return nil
+	}
+	return elementNames(body)
+}
+
+// elementNames returns the column of each element of an index or key
+// column list, dropping sort order, operator class, and collation. An
+// expression element is returned as written, lowercased.
+func elementNames(list string) []string {
+	var out []string
+	for _, el := range splitTopLevel(list) {
+		el = strings.TrimSpace(el)
+		first := firstIdent(el)
+		rest := strings.TrimSpace(el[len(first):])
+		if first == "" || strings.HasPrefix(rest, "(") {
+			out = append(out, strings.ToLower(whitespaceRe.ReplaceAllString(el, " ")))
+			continue
+		}
+		out = append(out, unquoteIdent(first))
+	}
+	return out
+}
+
+var firstIdentRe = regexp.MustCompile(`^` + identPattern)
+
+// firstIdent returns the identifier s starts with, quoted as written.
+func firstIdent(s string) string {
+	return firstIdentRe.FindString(s)
+}
+
+// parenBody returns what is inside the parentheses s starts with.
+func parenBody(s string) (string, bool) {
+	if !strings.HasPrefix(s, "(") {
+		return "", false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"':
+			i = closingQuote(s, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return s[1:], false
+}
+
+// splitTopLevel splits s on commas outside parentheses, brackets, and
+// quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"':
+			i = closingQuote(s, i) - 1
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
@@ -0,0 +1,159 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func columnNames(t SchemaTable) []string {
+	var out []string
+	for _, c := range t.Columns {
+		out = append(out, c.Name)
+	}
+	return out
+}
+
+func TestSchemaApply_StructureSQL(t *testing.T) {
+	src := `CREATE TABLE public.users (
+    id bigint NOT NULL,
+    email character varying NOT NULL,
+    "Name" text,
+    legacy integer,
+    CONSTRAINT email_present CHECK ((email <> ''::text))
+);
+
+CREATE TABLE public.orders (id bigint, user_id bigint);
+CREATE TABLE IF NOT EXISTS public.orders (replaced int);
+CREATE TABLE scratch (id int);
+DROP TABLE scratch;
+
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE public.users ADD COLUMN age int, DROP COLUMN legacy, ADD CONSTRAINT fk FOREIGN KEY (id) REFERENCES x(id);
+ALTER TABLE public.users RENAME COLUMN email TO email_address;
+ALTER TABLE public.orders RENAME TO purchases;
+CREATE UNIQUE INDEX index_users_on_email ON public.users USING btree (email_address);
+CREATE INDEX idx_lower_email ON public.users USING btree (lower((email_address)::text));
+CREATE INDEX idx_dropped ON public.purchases (user_id);
+DROP INDEX idx_dropped;
+`
+	s := NewSchema()
+	s.Apply("structure.sql", Parse(src))
+	tables := s.Tables()
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want 2: %+v", len(tables), tables)
+	}
+
+	purchases, users := tables[0], tables[1]
+	if purchases.Name != "purchases" || purchases.Schema != "public" {
+		t.Errorf("first table = %s.%s, want public.purchases", purchases.Schema, purchases.Name)
+	}
+	if got := columnNames(purchases); !reflect.DeepEqual(got, []string{"id", "user_id"}) {
+		t.Errorf("purchases columns = %v", got)
+	}
+	if len(purchases.Indexes) != 0 {
+		t.Errorf("purchases indexes = %+v, want none", purchases.Indexes)
+	}
+
+	if got := columnNames(users); !reflect.DeepEqual(got, []string{"id", "email_address", "Name", "age"}) {
+		t.Errorf("users columns = %v", got)
+	}
+	if users.Line != 1 || users.File != "structure.sql" {
+		t.Errorf("users declared at %s:%d, want structure.sql:1", users.File, users.Line)
+	}
+	want := []SchemaIndex{
+		{Name: "users_pkey", Columns: []string{"id"}, Unique: true, File: "structure.sql", Line: 14},
+		{Name: "index_users_on_email", Columns: []string{"email_address"}, Unique: true, File: "structure.sql", Line: 18},
+		{Name: "idx_lower_email", Columns: []string{"lower((email_address)::text)"}, File: "structure.sql", Line: 19},
+	}
+	if !reflect.DeepEqual(users.Indexes, want) {
+		t.Errorf("users indexes = %+v\nwant %+v", users.Indexes, want)
+	}
+}
+
+func TestSchemaApplyRails(t *testing.T) {
+	src := `ActiveRecord::Schema[7.1].define(version: 2024_05_01_120000) do
+  enable_extension "plpgsql"
+
+  create_table "users", force: :cascade do |t|
+    t.string "email", null: false
+    t.references "account", null: false, foreign_key: true
+    t.references "owner", polymorphic: true
+    t.timestamps
+    t.index ["email"], name: "index_users_on_email", unique: true
+    t.index "lower((email)::text)", name: "index_users_on_lower_email"
+    t.check_constraint "email <> ''", name: "email_present"
+  end
+
+  create_table "tags", id: false, force: :cascade do |t|
+    t.string "name"
+  end
+
+  create_table "app.settings", primary_key: "key", id: :string do |t|
+    t.jsonb "value"
+  end
+
+  add_index "tags", ["name"], unique: true
+  add_foreign_key "users", "accounts"
+end
+`
+	s := NewSchema()
+	s.ApplyRails("db/schema.rb", src)
+	tables := s.Tables()
+	if len(tables) != 3 {
+		t.Fatalf("got %d tables, want 3: %+v", len(tables), tables)
+	}
+
+	settings, tags, users := tables[0], tables[1], tables[2]
+	if settings.Schema != "app" || settings.Name != "settings" {
+		t.Errorf("first table = %s.%s, want app.settings", settings.Schema, settings.Name)
+	}
+	if got := columnNames(settings); !reflect.DeepEqual(got, []string{"key", "value"}) {
+		t.Errorf("settings columns = %v", got)
+	}
+	if got := columnNames(tags); !reflect.DeepEqual(got, []string{"name"}) {
+		t.Errorf("tags columns = %v", got)
+	}
+	wantTags := []SchemaIndex{{Name: "index_tags_on_name", Columns: []string{"name"}, Unique: true, File: "db/schema.rb", Line: 22}}
+	if !reflect.DeepEqual(tags.Indexes, wantTags) {
+		t.Errorf("tags indexes = %+v", tags.Indexes)
+	}
+
+	wantCols := []string{"id", "email", "account_id", "owner_id", "owner_type", "created_at", "updated_at"}
+	if got := columnNames(users); !reflect.DeepEqual(got, wantCols) {
+		t.Errorf("users columns = %v, want %v", got, wantCols)
+	}
+	if users.Line != 4 || users.Columns[1].Line != 5 {
+		t.Errorf("users at line %d, email at %d; want 4 and 5", users.Line, users.Columns[1].Line)
+	}
+	wantIdx := []SchemaIndex{
+		{Name: "index_users_on_email", Columns: []string{"email"}, Unique: true, File: "db/schema.rb", Line: 9},
+		{Name: "index_users_on_lower_email", Columns: []string{"lower((email)::text)"}, File: "db/schema.rb", Line: 10},
+	}
+	if !reflect.DeepEqual(users.Indexes, wantIdx) {
+		t.Errorf("users indexes = %+v\nwant %+v", users.Indexes, wantIdx)
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+	rb := filepath.Join(dir, "schema.rb")
+	if err := os.WriteFile(rb, []byte("create_table \"users\" do |t|\nend\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSchema(rb)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+	if tables := s.Tables(); len(tables) != 1 || tables[0].Name != "users" {
+		t.Errorf("tables = %+v, want users", tables)
+	}
+
+	if _, err := LoadSchema(filepath.Join(dir, "schema.yml")); err == nil {
+		t.Error("expected error for unsupported file")
+	}
+	if _, err := LoadSchema(filepath.Join(dir, "missing.sql")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
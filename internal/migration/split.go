@@ -0,0 +1,123 @@
+package migration
+
+import "strings"
+
+// rawStatement is one statement's text and the line it starts on.
+type rawStatement struct {
+	line int
+	sql  string
+}
+
+// splitStatements splits SQL source on semicolons, ignoring semicolons
+// inside comments, quoted strings, quoted identifiers, and dollar-quoted
+// bodies. Comments are dropped from the returned text.
+func splitStatements(src string) []rawStatement {
+	var (
+		stmts     []rawStatement
+		cur       strings.Builder
+		line      = 1
+		startLine = 0
+	)
+
+	flush := func() {
+		text := strings.TrimSpace(cur.String())
+		if text != "" {
+			stmts = append(stmts, rawStatement{line: startLine, sql: text})
+		}
+		cur.Reset()
+		startLine = 0
+	}
+	write := func(s string) {
+		if startLine == 0 && strings.TrimSpace(s) != "" {
+			startLine = line
+		}
+		cur.WriteString(s)
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			cur.WriteByte(c)
+			line++
+			i++
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			body := src[i:]
+			if end >= 0 {
+				body = src[i : i+2+end+2]
+			}
+			line += strings.Count(body, "\n")
+			cur.WriteByte(' ')
+			i += len(body)
+		case c == '\'' || c == '"':
+			end := closingQuote(src, i)
+			body := src[i:end]
+			write(body)
+			line += strings.Count(body, "\n")
+			i = end
+		case c == '$':
+			tag, ok := dollarTag(src[i:])
+			if !ok {
+				write(string(c))
+				i++
+				continue
+			}
+			end := strings.Index(src[i+len(tag):], tag)
+			body := src[i:]
+			if end >= 0 {
+				body = src[i : i+len(tag)+end+len(tag)]
+			}
+			write(body)
+			line += strings.Count(body, "\n")
+			i += len(body)
+		case c == ';':
+			flush()
+			i++
+		default:
+			write(string(c))
+			i++
+		}
+	}
+	flush()
+	return stmts
+}
+
+// closingQuote returns the index just past the quote that closes the one
+// at src[start]. Doubled quotes are treated as escapes.
+func closingQuote(src string, start int) int {
+	q := src[start]
+	for i := start + 1; i < len(src); i++ {
+		if src[i] != q {
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == q {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(src)
+}
+
+// dollarTag returns the opening dollar-quote tag ($$ or $name$) at the
+// start of s.
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1], true
+		}
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 1 && c >= '0' && c <= '9')) {
+			return "", false
+		}
+	}
+	return "", false
+}
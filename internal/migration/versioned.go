@@ -0,0 +1,207 @@
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tools whose versioned migration files are recognized.
+const (
+	ToolFlyway        = "flyway"
+	ToolGolangMigrate = "golang-migrate"
+	ToolAlembic       = "alembic"
+)
+
+// Versioned migration file names: Flyway V1__add_users.sql (U for undo,
+// R__ for repeatable), golang-migrate 000001_add_users.up.sql, and
+// Alembic revisions in a versions directory.
+var (
+	flywayFileRe        = regexp.MustCompile(`^([VU])(\d+(?:[._]\d+)*)__.*\.sql$`)
+	flywayRepeatableRe  = regexp.MustCompile(`^R__.*\.sql$`)
+	golangMigrateFileRe = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+	versionPartRe       = regexp.MustCompile(`[._]`)
+)
+
+// File is a file of a versioned migration directory.
+type File struct {
+	Path string // to read it
+	Name string // to report it, e.g. relative to the repository
+	Tool string
+	// Version orders Flyway and golang-migrate files; it is empty for
+	// repeatable Flyway migrations and Alembic revisions, which declare
+	// their place in the file.
+	Version string
+	// Down is set for golang-migrate down and Flyway undo migrations,
+	// which reverse a version rather than apply one.
+	Down bool
+}
+
+// VersionedFile reports whether path is a file of a versioned migration
+// directory, and which.
+func VersionedFile(path string) (File, bool) {
+	base := filepath.Base(path)
+	f := File{Path: path, Name: path}
+	switch {
+	case flywayFileRe.MatchString(base):
+		m := flywayFileRe.FindStringSubmatch(base)
+		f.Tool, f.Version, f.Down = ToolFlyway, m[2], m[1] == "U"
+	case flywayRepeatableRe.MatchString(base):
+		f.Tool = ToolFlyway
+	case golangMigrateFileRe.MatchString(base):
+		m := golangMigrateFileRe.FindStringSubmatch(base)
+		f.Tool, f.Version, f.Down = ToolGolangMigrate, m[1], m[2] == "down"
+	case strings.HasSuffix(base, ".py") && base != "__init__.py" && filepath.Base(filepath.Dir(path)) == "versions":
+		f.Tool = ToolAlembic
+	default:
+		return File{}, false
+	}
+	return f, true
+}
+
+// Replay applies versioned migrations in order and returns the schema
+// they build. Each directory is replayed in turn: Flyway and
+// golang-migrate files by version, then repeatable Flyway migrations by
+// name, then Alembic revisions along their down_revision chain. Down and
+// undo migrations are skipped, as are .py files that aren't Alembic
+// revisions.
+func Replay(files []File) (*Schema, error) {
+	byDir := make(map[string][]File)
+	for _, f := range files {
+		if f.Down {
+			continue
+		}
+		dir := filepath.Dir(f.Path)
+		byDir[dir] = append(byDir[dir], f)
+	}
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	s := NewSchema()
+	for _, dir := range dirs {
+		var versioned, repeatable, alembic []File
+		for _, f := range byDir[dir] {
+			switch {
+			case f.Tool == ToolAlembic:
+				alembic = append(alembic, f)
+			case f.Version == "":
+				repeatable = append(repeatable, f)
+			default:
+				versioned = append(versioned, f)
+			}
+		}
+		sort.SliceStable(versioned, func(i, j int) bool {
+			if c := compareVersions(versioned[i].Version, versioned[j].Version); c != 0 {
+				return c < 0
+			}
+			return versioned[i].Path < versioned[j].Path
+		})
+		sort.Slice(repeatable, func(i, j int) bool { return repeatable[i].Path < repeatable[j].Path })
+
+		for _, f := range append(versioned, repeatable...) {
+			data, err := os.ReadFile(f.Path)
+			if err != nil {
+				return nil, fmt.Errorf("read migration: %w", err)
+			}
+			s.Apply(f.Name, Parse(string(data)))
+		}
+		if err := s.replayAlembic(alembic); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// replayAlembic applies Alembic revisions so that each follows the
+// revisions it names as down_revision. Revisions on parallel branches
+// are applied in file order.
+func (s *Schema) replayAlembic(files []File) error {
+	type revision struct {
+		file File
+		src  string
+		down []string
+	}
+	revs := make(map[string]*revision)
+	var ids []string
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("read migration: %w", err)
+		}
+		id, down, ok := alembicRevision(string(data))
+		if !ok {
+			continue
+		}
+		revs[id] = &revision{file: f, src: string(data), down: down}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return revs[ids[i]].file.Path < revs[ids[j]].file.Path })
+
+	applied := make(map[string]bool, len(ids))
+	for len(applied) < len(ids) {
+		progress := false
+		for _, id := range ids {
+			if applied[id] {
+				continue
+			}
+			ready := true
+			for _, d := range revs[id].down {
+				if _, known := revs[d]; known && !applied[d] {
+					ready = false
+				}
+			}
+			if !ready {
+				continue
+			}
+			s.ApplyAlembic(revs[id].file.Name, revs[id].src)
+			applied[id] = true
+			progress = true
+		}
+		if !progress {
+			return fmt.Errorf("alembic revisions form a cycle")
+		}
+	}
+	return nil
+}
+
+// ReplayDir replays the versioned migrations in dir and its
+// subdirectories.
+func ReplayDir(dir string) (*Schema, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if f, ok := VersionedFile(path); ok && !d.IsDir() {
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	return Replay(files)
+}
+
+// compareVersions compares dotted or underscored version numbers part
+// by part, numerically: 1.10 follows 1.9, and 2 precedes 2.1.
+func compareVersions(a, b string) int {
+	pa, pb := versionPartRe.Split(a, -1), versionPartRe.Split(b, -1)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := strings.TrimLeft(pa[i], "0"), strings.TrimLeft(pb[i], "0")
+		if len(x) != len(y) {
+			return len(x) - len(y)
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return len(pa) - len(pb)
+}
@@ -0,0 +1,198 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVersionedFile(t *testing.T) {
+	tests := []struct {
+		path    string
+		tool    string
+		version string
+		down    bool
+		ok      bool
+	}{
+		{"db/migration/V1__init.sql", ToolFlyway, "1", false, true},
+		{"db/migration/V2.1_3__add_users.sql", ToolFlyway, "2.1_3", false, true},
+		{"db/migration/U2__add_users.sql", ToolFlyway, "2", true, true},
+		{"db/migration/R__views.sql", ToolFlyway, "", false, true},
+		{"migrations/000001_init.up.sql", ToolGolangMigrate, "000001", false, true},
+		{"migrations/20240101120000_init.down.sql", ToolGolangMigrate, "20240101120000", true, true},
+		{"alembic/versions/3f2a_add_users.py", ToolAlembic, "", false, true},
+		{"alembic/versions/__init__.py", "", "", false, false},
+		{"app/models.py", "", "", false, false},
+		{"db/schema.sql", "", "", false, false},
+		{"migrations/001_init.sql", "", "", false, false},
+	}
+	for _, tt := range tests {
+		f, ok := VersionedFile(filepath.FromSlash(tt.path))
+		if ok != tt.ok || f.Tool != tt.tool || f.Version != tt.version || f.Down != tt.down {
+			t.Errorf("VersionedFile(%q) = %+v, %v; want tool %q version %q down %v, %v", tt.path, f, ok, tt.tool, tt.version, tt.down, tt.ok)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9", "1.10", -1},
+		{"2", "2.1", -1},
+		{"002", "2", 0},
+		{"10", "9", 1},
+		{"1_2", "1.2", 0},
+	}
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tableNames(s *Schema) []string {
+	var out []string
+	for _, t := range s.Tables() {
+		out = append(out, t.Name)
+	}
+	return out
+}
+
+func TestReplayDir_SQL(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// Version 10 sorts after 9, not after 1.
+		"flyway/V10__drop_legacy.sql":           "DROP TABLE legacy;",
+		"flyway/V9__add_email.sql":              "ALTER TABLE users ADD COLUMN email text;",
+		"flyway/V1__init.sql":                   "CREATE TABLE users (id int);\nCREATE TABLE legacy (id int);",
+		"flyway/U10__drop_legacy.sql":           "CREATE TABLE legacy (id int);",
+		"flyway/R__views.sql":                   "CREATE TABLE report_cache (id int);",
+		"migrate/000002_orders.up.sql":          "CREATE TABLE orders (id int);",
+		"migrate/000002_orders.down.sql":        "DROP TABLE orders;",
+		"migrate/000001_accounts.up.sql":        "CREATE TABLE accounts (id int);",
+		"migrate/000003_drop_accounts.up.sql":   "DROP TABLE accounts;",
+		"migrate/000003_drop_accounts.down.sql": "CREATE TABLE accounts (id int);",
+		"notes/readme.sql":                      "CREATE TABLE not_a_migration (id int);",
+	})
+
+	s, err := ReplayDir(dir)
+	if err != nil {
+		t.Fatalf("ReplayDir: %v", err)
+	}
+	if got, want := tableNames(s), []string{"orders", "report_cache", "users"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+	users := s.table("", "users")
+	if got := columnNames(*users); !reflect.DeepEqual(got, []string{"id", "email"}) {
+		t.Errorf("users columns = %v", got)
+	}
+	if users.File != filepath.Join(dir, "flyway", "V1__init.sql") || users.Line != 1 {
+		t.Errorf("users declared at %s:%d", users.File, users.Line)
+	}
+}
+
+func TestReplayDir_Alembic(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// File names don't order revisions; down_revision does.
+		"alembic/versions/a_second.py": `"""drop legacy, add email"""
+from alembic import op
+import sqlalchemy as sa
+
+revision = "bbb"
+down_revision = "aaa"
+
+
+def upgrade():
+    op.drop_table("legacy")
+    op.add_column("users", sa.Column("email", sa.String(), nullable=True))
+    op.create_index(op.f("ix_users_email"), "users", ["email"], unique=True)
+    # op.drop_table("users")
+    op.execute("ALTER TABLE users RENAME COLUMN name TO full_name")
+
+
+def downgrade():
+    op.drop_column("users", "email")
+    op.create_table("legacy", sa.Column("id", sa.Integer()))
+`,
+		"alembic/versions/b_first.py": `revision: str = "aaa"
+down_revision: Union[str, None] = None
+
+
+def upgrade() -> None:
+    op.create_table(
+        "users",
+        sa.Column("id", sa.Integer(), nullable=False),
+        sa.Column("name", sa.String(length=50), server_default=sa.text("'x, y'")),
+        sa.PrimaryKeyConstraint("id"),
+        sa.UniqueConstraint("name", name="uq_users_name"),
+    )
+    op.create_table("legacy", sa.Column("id", sa.Integer(), primary_key=True), schema="archive")
+    op.create_table("legacy", sa.Column("id", sa.Integer(), primary_key=True))
+    op.rename_table("legacy", "legacy", schema="archive")
+
+
+def downgrade() -> None:
+    op.drop_table("users")
+`,
+		"alembic/versions/c_merge.py": `revision = "ccc"
+down_revision = ("aaa", "bbb")
+
+def upgrade():
+    op.alter_column("users", "email", new_column_name="email_address")
+`,
+	})
+
+	s, err := ReplayDir(dir)
+	if err != nil {
+		t.Fatalf("ReplayDir: %v", err)
+	}
+	tables := s.Tables()
+	if len(tables) != 2 || tables[0].Schema != "archive" || tables[1].Name != "users" {
+		t.Fatalf("tables = %+v, want archive.legacy and users", tables)
+	}
+	users := tables[1]
+	if got, want := columnNames(users), []string{"id", "full_name", "email_address"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("users columns = %v, want %v", got, want)
+	}
+	var names []string
+	for _, idx := range users.Indexes {
+		names = append(names, idx.Name)
+	}
+	if want := []string{"users_pkey", "uq_users_name", "ix_users_email"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("users indexes = %v, want %v", names, want)
+	}
+	email := users.Indexes[2]
+	if !email.Unique || !reflect.DeepEqual(email.Columns, []string{"email_address"}) || email.Line != 12 {
+		t.Errorf("ix_users_email = %+v", email)
+	}
+}
+
+func TestLoadSchema_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"V1__init.sql": "CREATE TABLE users (id int);"})
+	s, err := LoadSchema(dir)
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+	if got := tableNames(s); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("tables = %v, want users", got)
+	}
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// ActivitySource is implemented by catalog sources that can read live
+// sessions and lock waits. *Inspector does; recorded catalogs can't.
+type ActivitySource interface {
+	GetActivity(ctx context.Context) ([]SessionInfo, error)
+}
+
+var _ ActivitySource = (*Inspector)(nil)
+
+// GetActivity snapshots the client sessions connected to the current
+// database, other than its own, with the sessions blocking each one
+// (pg_blocking_pids) and the relation whose lock it is waiting for.
+// Durations are computed on the server, so client clock skew doesn't
+// matter.
+func (i *Inspector) GetActivity(ctx context.Context) ([]SessionInfo, error) {
+	query := `
+		SELECT
+			a.pid,
+			COALESCE(a.usename, ''),
+			COALESCE(a.application_name, ''),
+			COALESCE(host(a.client_addr), ''),
+			COALESCE(a.state, ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - a.xact_start), 0)::float8,
+			COALESCE(EXTRACT(EPOCH FROM now() - a.state_change), 0)::float8,
+			COALESCE(a.wait_event_type, ''),
+			COALESCE(a.query, ''),
+			pg_catalog.pg_blocking_pids(a.pid),
+			COALESCE(w.nspname, ''),
+			COALESCE(w.relname, '')
+		FROM pg_catalog.pg_stat_activity a
+		LEFT JOIN LATERAL (
+			SELECT n.nspname, c.relname
+			FROM pg_catalog.pg_locks l
+			JOIN pg_catalog.pg_class c ON c.oid = l.relation
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE l.pid = a.pid AND NOT l.granted
+			LIMIT 1
+		) w ON true
+		WHERE a.datname = current_database()
+		  AND a.backend_type = 'client backend'
+		  AND a.pid <> pg_catalog.pg_backend_pid()
+		ORDER BY a.pid`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get activity: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []SessionInfo
+	for rows.Next() {
+		var s SessionInfo
+		if err := rows.Scan(&s.PID, &s.User, &s.Application, &s.ClientAddr, &s.State,
+			&s.XactSeconds, &s.StateSeconds, &s.WaitEventType, &s.Query,
+			&s.BlockedBy, &s.WaitSchema, &s.WaitTable); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
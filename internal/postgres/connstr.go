@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultPort is the port libpq assumes when a host has none.
+const defaultPort = "5432"
+
+// credentialParams are connection parameters left out of a ConnTarget.
+var credentialParams = map[string]bool{
+	"user":        true,
+	"password":    true,
+	"passfile":    true,
+	"sslpassword": true,
+}
+
+// portSuffixRe matches the port at the end of a URL host entry.
+var portSuffixRe = regexp.MustCompile(`:(\d*)$`)
+
+// ConnTarget is what a connection string points at, without credentials:
+// every host it may connect to, the database, and the remaining
+// parameters, such as target_session_attrs or sslmode.
+type ConnTarget struct {
+	Hosts    []string // host:port; a Unix socket directory starts with '/'
+	Database string
+	Params   map[string]string
+}
+
+// ParseConnString parses a postgres:// or postgresql:// URL, including
+// the multi-host form (postgres://a:5432,b:5433/app) and Unix socket
+// directories, given percent-encoded as the host
+// (postgres://%2Fvar%2Frun%2Fpostgresql/app) or as a host parameter.
+// Parameters in the query override those in the URL, as in libpq.
+// Anything else is parsed as libpq keyword/value settings
+// (host=a,b dbname=app target_session_attrs=read-only).
+func ParseConnString(connString string) (ConnTarget, error) {
+	var settings map[string]string
+	var err error
+	if isURL(connString) {
+		settings, err = parseURLSettings(connString)
+	} else {
+		settings, err = parseKeywordValueSettings(connString)
+	}
+	if err != nil {
+		return ConnTarget{}, err
+	}
+
+	t := ConnTarget{Database: settings["dbname"], Params: make(map[string]string)}
+	var hosts, ports []string
+	if settings["host"] != "" {
+		hosts = strings.Split(settings["host"], ",")
+	}
+	if settings["port"] != "" {
+		ports = strings.Split(settings["port"], ",")
+	}
+	for i, h := range hosts {
+		port := defaultPort
+		switch {
+		case len(ports) == 1:
+			port = ports[0]
+		case i < len(ports) && ports[i] != "":
+			port = ports[i]
+		}
+		if !strings.HasPrefix(h, "/") {
+			h = strings.ToLower(h)
+		}
+		t.Hosts = append(t.Hosts, h+":"+port)
+	}
+	for k, v := range settings {
+		switch k {
+		case "host", "port", "dbname":
+		default:
+			if !credentialParams[k] {
+				t.Params[k] = v
+			}
+		}
+	}
+	return t, nil
+}
+
+// String renders the target as a canonical URL: credentials dropped,
+// default ports filled in, host names lowercased, and parameters sorted.
+// Equivalent connection strings render the same.
+func (t ConnTarget) String() string {
+	var b strings.Builder
+	b.WriteString("postgres://")
+	for i, h := range t.Hosts {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		host, port := h, ""
+		if j := strings.LastIndexByte(h, ':'); j >= 0 {
+			host, port = h[:j], h[j:]
+		}
+		switch {
+		case strings.HasPrefix(host, "/"):
+			host = strings.ReplaceAll(url.PathEscape(host), "/", "%2F")
+		case strings.Contains(host, ":"):
+			host = "[" + host + "]"
+		}
+		b.WriteString(host + port)
+	}
+	b.WriteString("/" + url.PathEscape(t.Database))
+	if len(t.Params) > 0 {
+		q := make(url.Values, len(t.Params))
+		for k, v := range t.Params {
+			q.Set(k, v)
+		}
+		b.WriteString("?" + q.Encode())
+	}
+	return b.String()
+}
+
+// isURL reports whether a connection string is in URL form.
+func isURL(connString string) bool {
+	return strings.HasPrefix(connString, "postgres://") || strings.HasPrefix(connString, "postgresql://")
+}
+
+// parseKeywordValueSettings parses libpq keyword/value settings: key=value
+// pairs separated by whitespace, with optional spaces around '=' and
+// values single-quoted when they are empty or contain spaces. Inside
+// quotes, and outside them, a backslash escapes the next character.
+func parseKeywordValueSettings(connString string) (map[string]string, error) {
+	settings := make(map[string]string)
+	s := strings.TrimSpace(connString)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("missing '=' after %q in connection string", s)
+		}
+		key := strings.TrimSpace(s[:eq])
+		if key == "" || strings.ContainsAny(key, " \t\n") {
+			return nil, fmt.Errorf("invalid keyword %q in connection string", key)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t\n")
+
+		var value strings.Builder
+		quoted := strings.HasPrefix(s, "'")
+		if quoted {
+			s = s[1:]
+		}
+		closed := false
+		i := 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+				continue
+			}
+			if quoted && c == '\'' {
+				closed = true
+				i++
+				break
+			}
+			if !quoted && (c == ' ' || c == '\t' || c == '\n') {
+				break
+			}
+			value.WriteByte(c)
+		}
+		if quoted && !closed {
+			return nil, fmt.Errorf("unterminated quoted value for %q in connection string", key)
+		}
+		settings[key] = value.String()
+		s = strings.TrimLeft(s[i:], " \t\n")
+	}
+	return settings, nil
+}
+
+// parseURLSettings splits a connection URL into libpq keyword/value
+// settings. Hosts and ports are comma-separated lists in the same order;
+// a host without a port has an empty entry.
+func parseURLSettings(connString string) (map[string]string, error) {
+	rest, ok := strings.CutPrefix(connString, "postgres://")
+	if !ok {
+		rest = strings.TrimPrefix(connString, "postgresql://")
+	}
+	settings := make(map[string]string)
+
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	authority, path, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndexByte(authority, '@'); at >= 0 {
+		userinfo := authority[:at]
+		authority = authority[at+1:]
+		user, password, hasPassword := strings.Cut(userinfo, ":")
+		var err error
+		if settings["user"], err = url.PathUnescape(user); err != nil {
+			return nil, fmt.Errorf("parse user: %w", err)
+		}
+		if hasPassword {
+			if settings["password"], err = url.PathUnescape(password); err != nil {
+				return nil, fmt.Errorf("parse password: %w", err)
+			}
+		}
+	}
+
+	var hosts, ports []string
+	for entry := range strings.SplitSeq(authority, ",") {
+		if entry == "" {
+			continue
+		}
+		host, port := entry, ""
+		if strings.HasPrefix(entry, "[") {
+			end := strings.IndexByte(entry, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("parse host %q: missing ']'", entry)
+			}
+			host, port = entry[1:end], strings.TrimPrefix(entry[end+1:], ":")
+		} else if m := portSuffixRe.FindStringSubmatchIndex(entry); m != nil {
+			host, port = entry[:m[0]], entry[m[2]:m[3]]
+		}
+		host, err := url.PathUnescape(host)
+		if err != nil {
+			return nil, fmt.Errorf("parse host %q: %w", entry, err)
+		}
+		hosts = append(hosts, host)
+		ports = append(ports, port)
+	}
+	if len(hosts) > 0 {
+		settings["host"] = strings.Join(hosts, ",")
+		if strings.Join(ports, "") != "" {
+			settings["port"] = strings.Join(ports, ",")
+		}
+	}
+
+	if path != "" {
+		db, err := url.PathUnescape(path)
+		if err != nil {
+			return nil, fmt.Errorf("parse database: %w", err)
+		}
+		settings["dbname"] = db
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("parse parameters: %w", err)
+	}
+	for k, v := range query {
+		settings[k] = v[0]
+	}
+	return settings, nil
+}
+
+// connString returns the connection string in a form pgx accepts. pgx
+// can't parse a Unix socket directory percent-encoded as a URL's host,
+// which libpq allows, so such URLs are passed on as keyword/value
+// settings. Keyword/value strings are passed through.
+func (c Config) connString() string {
+	if !isURL(c.URL) {
+		return c.URL
+	}
+	settings, err := parseURLSettings(c.URL)
+	if err != nil || !strings.Contains(settings["host"], "/") {
+		return c.URL
+	}
+	if ports := strings.Split(settings["port"], ","); settings["port"] != "" {
+		for i, p := range ports {
+			if p == "" {
+				ports[i] = defaultPort
+			}
+		}
+		settings["port"] = strings.Join(ports, ",")
+	}
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + quoteSetting(settings[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// quoteSetting quotes a keyword/value setting when libpq syntax needs it.
+func quoteSetting(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseConnString(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		hosts    []string
+		database string
+		params   map[string]string
+	}{
+		{
+			name:     "single host",
+			in:       "postgres://app@DB.example.com:5433/orders?sslmode=require",
+			hosts:    []string{"db.example.com:5433"},
+			database: "orders",
+			params:   map[string]string{"sslmode": "require"},
+		},
+		{
+			name:     "default port",
+			in:       "postgresql://localhost/orders",
+			hosts:    []string{"localhost:5432"},
+			database: "orders",
+		},
+		{
+			name:     "multi-host",
+			in:       "postgres://app@a:5432,b,[::1]:5434/orders?target_session_attrs=read-only",
+			hosts:    []string{"a:5432", "b:5432", "::1:5434"},
+			database: "orders",
+			params:   map[string]string{"target_session_attrs": "read-only"},
+		},
+		{
+			name:     "encoded socket directory",
+			in:       "postgres://%2Fvar%2Frun%2Fpostgresql/orders",
+			hosts:    []string{"/var/run/postgresql:5432"},
+			database: "orders",
+		},
+		{
+			name:     "socket and port parameters",
+			in:       "postgres:///orders?host=/tmp&port=6432",
+			hosts:    []string{"/tmp:6432"},
+			database: "orders",
+		},
+		{
+			name:     "dbname parameter overrides path",
+			in:       "postgres://localhost/ignored?dbname=orders&user=app",
+			hosts:    []string{"localhost:5432"},
+			database: "orders",
+		},
+		{
+			name:     "keyword/value multi-host",
+			in:       "host=a,B port=5432,5433 dbname=orders user=app target_session_attrs=read-only",
+			hosts:    []string{"a:5432", "b:5433"},
+			database: "orders",
+			params:   map[string]string{"target_session_attrs": "read-only"},
+		},
+		{
+			name:     "keyword/value quoting and spaces",
+			in:       "  host = /var/run/postgresql dbname='my orders' application_name='it\\'s' ",
+			hosts:    []string{"/var/run/postgresql:5432"},
+			database: "my orders",
+			params:   map[string]string{"application_name": "it's"},
+		},
+		{
+			name:  "no host or database",
+			in:    "postgres://",
+			hosts: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConnString(tt.in)
+			if err != nil {
+				t.Fatalf("ParseConnString(%q): %v", tt.in, err)
+			}
+			if !slices.Equal(got.Hosts, tt.hosts) {
+				t.Errorf("hosts = %q, want %q", got.Hosts, tt.hosts)
+			}
+			if got.Database != tt.database {
+				t.Errorf("database = %q, want %q", got.Database, tt.database)
+			}
+			if len(got.Params) != len(tt.params) {
+				t.Errorf("params = %v, want %v", got.Params, tt.params)
+			}
+			for k, v := range tt.params {
+				if got.Params[k] != v {
+					t.Errorf("params[%s] = %q, want %q", k, got.Params[k], v)
+				}
+			}
+		})
+	}
+
+	for _, bad := range []string{"mysql://localhost/orders", "host=a dbname", "dbname='orders"} {
+		if _, err := ParseConnString(bad); err == nil {
+			t.Errorf("ParseConnString(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestConnTargetStringDropsCredentials(t *testing.T) {
+	q := url.Values{"password": {"x"}, "sslmode": {"disable"}}
+	u := &url.URL{Scheme: "postgres", Host: "localhost:5432", Path: "/orders", RawQuery: q.Encode()}
+	u.User = url.UserPassword("app", "x")
+
+	target, err := ParseConnString(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := target.String()
+	if want := "postgres://localhost:5432/orders?sslmode=disable"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestConnTargetStringEquivalentForms(t *testing.T) {
+	same := [][]string{
+		{"postgres://%2Fvar%2Frun%2Fpostgresql/orders", "postgresql:///orders?host=/var/run/postgresql&port=5432"},
+		{"postgres://A:5432,b/orders?target_session_attrs=read-only", "postgres://app@a,b:5432/orders?target_session_attrs=read-only"},
+		{"postgres://app@db1:5432,db2:5433/orders?sslmode=require", "host=db1,db2 port=5432,5433 dbname=orders user=app sslmode=require"},
+	}
+	for _, forms := range same {
+		var rendered []string
+		for _, f := range forms {
+			target, err := ParseConnString(f)
+			if err != nil {
+				t.Fatalf("ParseConnString(%q): %v", f, err)
+			}
+			rendered = append(rendered, target.String())
+		}
+		if rendered[0] != rendered[1] {
+			t.Errorf("%q and %q render differently: %s", forms[0], forms[1], strings.Join(rendered, " vs "))
+		}
+	}
+}
+
+func TestConfigConnString(t *testing.T) {
+	cfg := Config{URL: "postgres://app@%2Fvar%2Frun%2Fpostgresql:5433/orders?application_name=pg spectre"}
+	want := "application_name='pg spectre' dbname=orders host=/var/run/postgresql port=5433 user=app"
+	if got := cfg.connString(); got != want {
+		t.Errorf("connString() = %q, want %q", got, want)
+	}
+
+	for _, s := range []string{"postgres://a:5432,b:5432/orders", "host=/tmp dbname=orders"} {
+		plain := Config{URL: s}
+		if got := plain.connString(); got != s {
+			t.Errorf("connString() = %q, want %q unchanged", got, s)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetDatabaseStats fetches the current database's size, temp file
+// counters, and work_mem, the setting that decides when sorts and hashes
+// spill to them, with how long its statistics have been collecting.
+func (i *Inspector) GetDatabaseStats(ctx context.Context) (*DatabaseStats, error) {
+	query := `
+		SELECT
+			datname,
+			temp_files,
+			temp_bytes,
+			current_setting('work_mem'),
+			pg_catalog.pg_database_size(datid),
+			stats_reset,
+			pg_catalog.pg_postmaster_start_time(),
+			EXTRACT(EPOCH FROM now() - GREATEST(stats_reset, pg_catalog.pg_postmaster_start_time()))::float8
+		FROM pg_catalog.pg_stat_database
+		WHERE datname = current_database()`
+
+	var s DatabaseStats
+	err := i.pool.QueryRow(ctx, query).Scan(&s.Name, &s.TempFiles, &s.TempBytes, &s.WorkMem, &s.SizeBytes, &s.StatsReset,
+		&s.PostmasterStart, &s.StatsWindowSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("get database stats: %w", err)
+	}
+	return &s, nil
+}
@@ -37,13 +37,19 @@ func FilterSnapshot(snap *Snapshot, schemas []string) *Snapshot {
 		include[strings.ToLower(s)] = true
 	}
 
-	filtered := &Snapshot{}
+	// Functions in any schema may use the tables kept.
+	filtered := &Snapshot{Functions: snap.Functions}
 
 	for _, t := range snap.Tables {
 		if include[strings.ToLower(t.Schema)] {
 			filtered.Tables = append(filtered.Tables, t)
 		}
 	}
+	for _, r := range snap.Relations {
+		if include[strings.ToLower(r.Schema)] {
+			filtered.Relations = append(filtered.Relations, r)
+		}
+	}
 	for _, c := range snap.Columns {
 		if include[strings.ToLower(c.Schema)] {
 			filtered.Columns = append(filtered.Columns, c)
@@ -64,6 +70,46 @@ func FilterSnapshot(snap *Snapshot, schemas []string) *Snapshot {
 			filtered.Constraints = append(filtered.Constraints, c)
 		}
 	}
+	for _, seq := range snap.Sequences {
+		if include[strings.ToLower(seq.Schema)] {
+			filtered.Sequences = append(filtered.Sequences, seq)
+		}
+	}
+	for _, cs := range snap.ColumnStats {
+		if include[strings.ToLower(cs.Schema)] {
+			filtered.ColumnStats = append(filtered.ColumnStats, cs)
+		}
+	}
+	for _, mv := range snap.MatViews {
+		if include[strings.ToLower(mv.Schema)] {
+			filtered.MatViews = append(filtered.MatViews, mv)
+		}
+	}
+	for _, v := range snap.Views {
+		if include[strings.ToLower(v.Schema)] {
+			filtered.Views = append(filtered.Views, v)
+		}
+	}
+	for _, tr := range snap.Triggers {
+		if include[strings.ToLower(tr.Schema)] {
+			filtered.Triggers = append(filtered.Triggers, tr)
+		}
+	}
+	for _, pt := range snap.PartitionedTables {
+		if include[strings.ToLower(pt.Schema)] {
+			filtered.PartitionedTables = append(filtered.PartitionedTables, pt)
+		}
+	}
+	for _, p := range snap.Partitions {
+		if include[strings.ToLower(p.Schema)] {
+			filtered.Partitions = append(filtered.Partitions, p)
+		}
+	}
+	for _, ext := range snap.Extensions {
+		if include[strings.ToLower(ext.Schema)] {
+			filtered.Extensions = append(filtered.Extensions, ext)
+		}
+	}
 
 	return filtered
 }
@@ -67,11 +67,19 @@ func TestFilterSnapshot_Empty(t *testing.T) {
 
 func TestFilterSnapshot_SingleSchema(t *testing.T) {
 	snap := &Snapshot{
-		Tables:      []TableInfo{{Schema: "public", Name: "users"}, {Schema: "app", Name: "orders"}},
-		Columns:     []ColumnInfo{{Schema: "public", Table: "users", Name: "id"}, {Schema: "app", Table: "orders", Name: "id"}},
-		Indexes:     []IndexInfo{{Schema: "public", Table: "users", Name: "users_pkey"}, {Schema: "app", Table: "orders", Name: "orders_pkey"}},
-		Stats:       []TableStats{{Schema: "public", Name: "users"}, {Schema: "app", Name: "orders"}},
-		Constraints: []ConstraintInfo{{Schema: "public", Table: "users", Name: "pk"}, {Schema: "app", Table: "orders", Name: "pk"}},
+		Tables:            []TableInfo{{Schema: "public", Name: "users"}, {Schema: "app", Name: "orders"}},
+		Columns:           []ColumnInfo{{Schema: "public", Table: "users", Name: "id"}, {Schema: "app", Table: "orders", Name: "id"}},
+		Indexes:           []IndexInfo{{Schema: "public", Table: "users", Name: "users_pkey"}, {Schema: "app", Table: "orders", Name: "orders_pkey"}},
+		Stats:             []TableStats{{Schema: "public", Name: "users"}, {Schema: "app", Name: "orders"}},
+		Constraints:       []ConstraintInfo{{Schema: "public", Table: "users", Name: "pk"}, {Schema: "app", Table: "orders", Name: "pk"}},
+		Sequences:         []SequenceInfo{{Schema: "public", Name: "users_id_seq"}, {Schema: "app", Name: "orders_id_seq"}},
+		Extensions:        []ExtensionInfo{{Schema: "public", Name: "pg_trgm"}, {Schema: "app", Name: "dblink"}},
+		ColumnStats:       []ColumnStats{{Schema: "public", Table: "users", Column: "id"}, {Schema: "app", Table: "orders", Column: "id"}},
+		MatViews:          []MatViewInfo{{Schema: "public", Name: "daily_totals"}, {Schema: "app", Name: "order_rollup"}},
+		Views:             []ViewInfo{{Schema: "public", Name: "active_users"}, {Schema: "app", Name: "open_orders"}},
+		Triggers:          []TriggerInfo{{Schema: "public", Table: "users", Name: "audit"}, {Schema: "app", Table: "orders", Name: "audit"}},
+		PartitionedTables: []PartitionedTableInfo{{Schema: "public", Name: "events"}, {Schema: "app", Name: "payments"}},
+		Partitions:        []PartitionInfo{{Schema: "public", Name: "events_2024", Parent: "events"}, {Schema: "app", Name: "payments_default", Parent: "payments"}},
 	}
 
 	got := FilterSnapshot(snap, []string{"public"})
@@ -91,6 +99,30 @@ func TestFilterSnapshot_SingleSchema(t *testing.T) {
 	if len(got.Constraints) != 1 || got.Constraints[0].Schema != "public" {
 		t.Errorf("constraints: got %v", got.Constraints)
 	}
+	if len(got.Sequences) != 1 || got.Sequences[0].Schema != "public" {
+		t.Errorf("sequences: got %v", got.Sequences)
+	}
+	if len(got.Extensions) != 1 || got.Extensions[0].Schema != "public" {
+		t.Errorf("extensions: got %v", got.Extensions)
+	}
+	if len(got.ColumnStats) != 1 || got.ColumnStats[0].Schema != "public" {
+		t.Errorf("column stats: got %v", got.ColumnStats)
+	}
+	if len(got.MatViews) != 1 || got.MatViews[0].Schema != "public" {
+		t.Errorf("matviews: got %v", got.MatViews)
+	}
+	if len(got.Views) != 1 || got.Views[0].Schema != "public" {
+		t.Errorf("views: got %v", got.Views)
+	}
+	if len(got.Triggers) != 1 || got.Triggers[0].Schema != "public" {
+		t.Errorf("triggers: got %v", got.Triggers)
+	}
+	if len(got.PartitionedTables) != 1 || got.PartitionedTables[0].Schema != "public" {
+		t.Errorf("partitioned tables: got %v", got.PartitionedTables)
+	}
+	if len(got.Partitions) != 1 || got.Partitions[0].Schema != "public" {
+		t.Errorf("partitions: got %v", got.Partitions)
+	}
 }
 
 func TestFilterSnapshot_MultipleSchemas(t *testing.T) {
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Fixture files written by Recorder and read by OpenReplay.
+const (
+	fixtureVersion       = "server_version.json"
+	fixtureSnapshot      = "snapshot.json"
+	fixtureTableCounts   = "table_counts.json"
+	fixtureNullFractions = "null_fractions.json"
+)
+
+// Recorder passes calls through to a CatalogSource and saves each result in
+// a fixture directory, so the run can be repeated later with OpenReplay.
+type Recorder struct {
+	src CatalogSource
+	dir string
+
+	mu     sync.Mutex
+	counts map[string]int
+	fracs  map[string]float64
+}
+
+// NewRecorder creates dir if needed and records src's results into it.
+func NewRecorder(src CatalogSource, dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("record fixtures: %w", err)
+	}
+	return &Recorder{src: src, dir: dir, counts: make(map[string]int), fracs: make(map[string]float64)}, nil
+}
+
+// ServerVersion records the server version.
+func (r *Recorder) ServerVersion(ctx context.Context) (string, error) {
+	v, err := r.src.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return v, r.save(fixtureVersion, v)
+}
+
+// CountTables records the count under the requested schemas.
+func (r *Recorder) CountTables(ctx context.Context, schemas []string) (int, error) {
+	n, err := r.src.CountTables(ctx, schemas)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[schemaKey(schemas)] = n
+	return n, r.save(fixtureTableCounts, r.counts)
+}
+
+// Inspect records the snapshot.
+func (r *Recorder) Inspect(ctx context.Context) (*Snapshot, error) {
+	snap, err := r.src.Inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return snap, r.save(fixtureSnapshot, snap)
+}
+
+// SampleNullFraction records the sampled fraction for the column.
+func (r *Recorder) SampleNullFraction(ctx context.Context, schema, table, column string, percent float64) (float64, error) {
+	frac, err := r.src.SampleNullFraction(ctx, schema, table, column, percent)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fracs[schema+"."+table+"."+column] = frac
+	return frac, r.save(fixtureNullFractions, r.fracs)
+}
+
+// Close closes the wrapped source.
+func (r *Recorder) Close() {
+	r.src.Close()
+}
+
+func (r *Recorder) save(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("record %s: %w", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, name), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("record %s: %w", name, err)
+	}
+	return nil
+}
+
+// Replay serves results saved by a Recorder without a database. Calls whose
+// results were never recorded fail, except CountTables, which falls back to
+// counting the recorded snapshot.
+type Replay struct {
+	dir    string
+	static StaticSource
+	counts map[string]int
+}
+
+// OpenReplay loads the fixtures in dir.
+func OpenReplay(dir string) (*Replay, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("replay fixtures: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("replay fixtures: %s is not a directory", dir)
+	}
+	r := &Replay{dir: dir}
+	for name, dst := range map[string]any{
+		fixtureVersion:       &r.static.Version,
+		fixtureSnapshot:      &r.static.Snapshot,
+		fixtureTableCounts:   &r.counts,
+		fixtureNullFractions: &r.static.NullFractions,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("replay fixtures: %w", err)
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return nil, fmt.Errorf("replay %s: %w", filepath.Join(dir, name), err)
+		}
+	}
+	return r, nil
+}
+
+// ServerVersion returns the recorded server version.
+func (r *Replay) ServerVersion(ctx context.Context) (string, error) {
+	if r.static.Version == "" {
+		return "", r.missing(fixtureVersion)
+	}
+	return r.static.Version, nil
+}
+
+// CountTables returns the recorded count for schemas, or counts the
+// recorded snapshot's tables.
+func (r *Replay) CountTables(ctx context.Context, schemas []string) (int, error) {
+	if n, ok := r.counts[schemaKey(schemas)]; ok {
+		return n, nil
+	}
+	if r.static.Snapshot == nil {
+		return 0, r.missing(fixtureTableCounts)
+	}
+	return r.static.CountTables(ctx, schemas)
+}
+
+// Inspect returns the recorded snapshot.
+func (r *Replay) Inspect(ctx context.Context) (*Snapshot, error) {
+	if r.static.Snapshot == nil {
+		return nil, r.missing(fixtureSnapshot)
+	}
+	return r.static.Inspect(ctx)
+}
+
+// SampleNullFraction returns the recorded sample for the column.
+func (r *Replay) SampleNullFraction(ctx context.Context, schema, table, column string, percent float64) (float64, error) {
+	return r.static.SampleNullFraction(ctx, schema, table, column, percent)
+}
+
+// Close does nothing.
+func (r *Replay) Close() {}
+
+func (r *Replay) missing(name string) error {
+	return fmt.Errorf("replay fixtures: %s not recorded in %s", name, r.dir)
+}
+
+// schemaKey identifies a schema list in recorded table counts.
+func schemaKey(schemas []string) string {
+	if len(schemas) == 0 {
+		return "*"
+	}
+	return strings.Join(schemas, ",")
+}
@@ -0,0 +1,251 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
+)
+
+// SnapshotBuilder assembles a catalog snapshot for analyzer tests and
+// benchmarks without hand-written structs:
+//
+//	b := fixtures.NewSnapshotBuilder()
+//	b.Table("public", "users").Column("id", "integer").PrimaryKey("id").Rows(1000)
+//	b.Table("public", "orders").Column("user_id", "integer").ForeignKey("user_id", "users", "id")
+//	snap := b.Build()
+type SnapshotBuilder struct {
+	snap   postgres.Snapshot
+	tables []*TableBuilder
+}
+
+// TableBuilder adds columns, constraints, indexes, and statistics to one
+// table of a SnapshotBuilder.
+type TableBuilder struct {
+	b     *SnapshotBuilder
+	info  postgres.TableInfo
+	stats postgres.TableStats
+}
+
+// NewSnapshotBuilder returns an empty builder.
+func NewSnapshotBuilder() *SnapshotBuilder {
+	return &SnapshotBuilder{}
+}
+
+// Table adds a base table with empty statistics.
+func (b *SnapshotBuilder) Table(schema, name string) *TableBuilder {
+	t := &TableBuilder{
+		b:     b,
+		info:  postgres.TableInfo{Schema: schema, Name: name, Type: "BASE TABLE", Persistence: "p"},
+		stats: postgres.TableStats{Schema: schema, Name: name},
+	}
+	b.tables = append(b.tables, t)
+	return t
+}
+
+// Build returns the snapshot. The builder can keep being used afterwards.
+func (b *SnapshotBuilder) Build() *postgres.Snapshot {
+	snap := b.snap
+	snap.Tables = make([]postgres.TableInfo, 0, len(b.tables))
+	snap.Stats = make([]postgres.TableStats, 0, len(b.tables))
+	for _, t := range b.tables {
+		snap.Tables = append(snap.Tables, t.info)
+		snap.Stats = append(snap.Stats, t.stats)
+	}
+	snap.Columns = append([]postgres.ColumnInfo(nil), b.snap.Columns...)
+	snap.Indexes = append([]postgres.IndexInfo(nil), b.snap.Indexes...)
+	snap.Constraints = append([]postgres.ConstraintInfo(nil), b.snap.Constraints...)
+	return &snap
+}
+
+// Column adds a nullable column.
+func (t *TableBuilder) Column(name, dataType string) *TableBuilder {
+	return t.addColumn(name, dataType, true)
+}
+
+// NotNullColumn adds a NOT NULL column.
+func (t *TableBuilder) NotNullColumn(name, dataType string) *TableBuilder {
+	return t.addColumn(name, dataType, false)
+}
+
+func (t *TableBuilder) addColumn(name, dataType string, nullable bool) *TableBuilder {
+	pos := 1
+	for _, c := range t.b.snap.Columns {
+		if c.Schema == t.info.Schema && c.Table == t.info.Name {
+			pos++
+		}
+	}
+	t.b.snap.Columns = append(t.b.snap.Columns, postgres.ColumnInfo{
+		Schema:          t.info.Schema,
+		Table:           t.info.Name,
+		Name:            name,
+		OrdinalPosition: pos,
+		DataType:        dataType,
+		IsNullable:      nullable,
+	})
+	return t
+}
+
+// PrimaryKey adds a primary key constraint and its unique index.
+func (t *TableBuilder) PrimaryKey(columns ...string) *TableBuilder {
+	name := t.info.Name + "_pkey"
+	t.b.snap.Constraints = append(t.b.snap.Constraints, postgres.ConstraintInfo{
+		Schema:    t.info.Schema,
+		Table:     t.info.Name,
+		Name:      name,
+		Type:      "p",
+		Columns:   columns,
+		Validated: true,
+	})
+	t.addIndex(name, true, name, columns)
+	return t
+}
+
+// ForeignKey adds a foreign key from column to refTable(refColumn) in the
+// same schema.
+func (t *TableBuilder) ForeignKey(column, refTable, refColumn string) *TableBuilder {
+	schema := t.info.Schema
+	t.b.snap.Constraints = append(t.b.snap.Constraints, postgres.ConstraintInfo{
+		Schema:     schema,
+		Table:      t.info.Name,
+		Name:       fmt.Sprintf("%s_%s_fkey", t.info.Name, column),
+		Type:       "f",
+		Columns:    []string{column},
+		RefSchema:  &schema,
+		RefTable:   &refTable,
+		RefColumns: []string{refColumn},
+		Definition: fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)", column, refTable, refColumn),
+		Validated:  true,
+	})
+	return t
+}
+
+// Index adds a valid btree index on columns.
+func (t *TableBuilder) Index(name string, columns ...string) *TableBuilder {
+	t.addIndex(name, false, "", columns)
+	return t
+}
+
+// IndexUsage sets the size and scan count of an index added earlier.
+func (t *TableBuilder) IndexUsage(name string, sizeBytes, scans int64) *TableBuilder {
+	for i := range t.b.snap.Indexes {
+		idx := &t.b.snap.Indexes[i]
+		if idx.Schema == t.info.Schema && idx.Table == t.info.Name && idx.Name == name {
+			idx.SizeBytes, idx.IndexScans = sizeBytes, scans
+		}
+	}
+	return t
+}
+
+func (t *TableBuilder) addIndex(name string, unique bool, constraint string, columns []string) {
+	kind := "INDEX"
+	if unique {
+		kind = "UNIQUE INDEX"
+	}
+	t.b.snap.Indexes = append(t.b.snap.Indexes, postgres.IndexInfo{
+		Schema: t.info.Schema,
+		Table:  t.info.Name,
+		Name:   name,
+		Definition: fmt.Sprintf("CREATE %s %s ON %s.%s USING btree (%s)",
+			kind, name, t.info.Schema, t.info.Name, strings.Join(columns, ", ")),
+		IsValid:    true,
+		IsUnique:   unique,
+		Constraint: constraint,
+	})
+}
+
+// Rows sets the estimated and live row counts.
+func (t *TableBuilder) Rows(n int64) *TableBuilder {
+	t.info.EstimatedRows = n
+	t.stats.LiveTuples = n
+	return t
+}
+
+// Size sets the total relation size in bytes.
+func (t *TableBuilder) Size(bytes int64) *TableBuilder {
+	t.info.SizeBytes = bytes
+	return t
+}
+
+// Scans sets the sequential and index scan counters.
+func (t *TableBuilder) Scans(seq, idx int64) *TableBuilder {
+	t.stats.SeqScan, t.stats.IdxScan = seq, idx
+	return t
+}
+
+// DeadTuples sets the dead tuple count.
+func (t *TableBuilder) DeadTuples(n int64) *TableBuilder {
+	t.stats.DeadTuples = n
+	return t
+}
+
+// Writes sets the inserted, updated, and deleted row counters.
+func (t *TableBuilder) Writes(inserted, updated, deleted int64) *TableBuilder {
+	t.stats.TupInserted, t.stats.TupUpdated, t.stats.TupDeleted = inserted, updated, deleted
+	return t
+}
+
+// Options sets the table's storage parameters, e.g. "fillfactor=90".
+func (t *TableBuilder) Options(options ...string) *TableBuilder {
+	t.info.Options = options
+	return t
+}
+
+// Stats edits the table's statistics directly for counters without a
+// dedicated setter.
+func (t *TableBuilder) Stats(fn func(*postgres.TableStats)) *TableBuilder {
+	fn(&t.stats)
+	return t
+}
+
+// generatedSchemas spreads generated tables over a few schemas the way a
+// multi-team database does.
+var generatedSchemas = []string{"public", "billing", "accounts", "analytics"}
+
+// GenerateSnapshot fabricates a snapshot with n tables for benchmarks. Each
+// table has a primary key, a few typed columns, and usually a foreign key
+// to an earlier table in its schema, with randomized sizes and counters.
+// The same seed always yields the same snapshot.
+func GenerateSnapshot(n int, seed uint64) *postgres.Snapshot {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	b := NewSnapshotBuilder()
+	bySchema := make(map[string][]string)
+
+	for i := 0; i < n; i++ {
+		schema := generatedSchemas[i%len(generatedSchemas)]
+		name := fmt.Sprintf("table_%05d", i)
+		rows := rng.Int64N(5_000_000)
+		t := b.Table(schema, name).
+			NotNullColumn("id", "bigint").
+			NotNullColumn("created_at", "timestamp with time zone").
+			Column("status", "text").
+			Column("payload", "jsonb").
+			PrimaryKey("id").
+			Rows(rows).
+			Size(rows*(64+rng.Int64N(512))+8192).
+			Scans(rng.Int64N(100_000), rng.Int64N(1_000_000)).
+			DeadTuples(rng.Int64N(rows/5+1)).
+			Writes(rows, rng.Int64N(rows+1), rng.Int64N(rows/10+1))
+
+		if refs := bySchema[schema]; len(refs) > 0 && rng.IntN(4) > 0 {
+			ref := refs[rng.IntN(len(refs))]
+			col := ref + "_id"
+			t.NotNullColumn(col, "bigint")
+			// Leave some foreign keys unindexed or undeclared.
+			switch rng.IntN(10) {
+			case 0:
+			case 1:
+				t.ForeignKey(col, ref, "id")
+			default:
+				t.ForeignKey(col, ref, "id").Index(name+"_"+col+"_idx", col)
+			}
+		}
+		if rng.IntN(5) == 0 {
+			idx := name + "_status_idx"
+			t.Index(idx, "status").IndexUsage(idx, rng.Int64N(200<<20), rng.Int64N(3))
+		}
+		bySchema[schema] = append(bySchema[schema], name)
+	}
+	return b.Build()
+}
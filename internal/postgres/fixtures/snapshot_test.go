@@ -0,0 +1,50 @@
+package fixtures_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"github.com/ppiankov/pgspectre/internal/postgres/fixtures"
+)
+
+func TestSnapshotBuilder(t *testing.T) {
+	b := fixtures.NewSnapshotBuilder()
+	b.Table("public", "users").NotNullColumn("id", "integer").Column("email", "text").PrimaryKey("id").Rows(1000).Scans(0, 50)
+	b.Table("public", "orders").Column("user_id", "integer").ForeignKey("user_id", "users", "id").Scans(0, 0)
+	snap := b.Build()
+
+	if len(snap.Tables) != 2 || len(snap.Stats) != 2 || len(snap.Columns) != 3 {
+		t.Fatalf("tables=%d stats=%d columns=%d", len(snap.Tables), len(snap.Stats), len(snap.Columns))
+	}
+	if c := snap.Columns[1]; c.Name != "email" || c.OrdinalPosition != 2 || !c.IsNullable {
+		t.Errorf("email column = %+v", c)
+	}
+	if len(snap.Indexes) != 1 || snap.Indexes[0].Definition != "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)" {
+		t.Errorf("indexes = %+v", snap.Indexes)
+	}
+
+	byType := make(map[analyzer.FindingType][]string)
+	for _, f := range analyzer.Audit(snap, analyzer.DefaultAuditOptions()) {
+		byType[f.Type] = append(byType[f.Type], f.Table)
+	}
+	if got := byType[analyzer.FindingNoPrimaryKey]; len(got) != 1 || got[0] != "orders" {
+		t.Errorf("NO_PRIMARY_KEY on %v, want orders", got)
+	}
+	if got := byType[analyzer.FindingUnusedTable]; len(got) != 1 || got[0] != "orders" {
+		t.Errorf("UNUSED_TABLE on %v, want orders", got)
+	}
+}
+
+func TestGenerateSnapshot(t *testing.T) {
+	snap := fixtures.GenerateSnapshot(200, 42)
+	if len(snap.Tables) != 200 || len(snap.Stats) != 200 {
+		t.Fatalf("tables=%d stats=%d", len(snap.Tables), len(snap.Stats))
+	}
+	if !reflect.DeepEqual(snap, fixtures.GenerateSnapshot(200, 42)) {
+		t.Error("same seed produced different snapshots")
+	}
+	if findings := analyzer.Audit(snap, analyzer.DefaultAuditOptions()); len(findings) == 0 {
+		t.Error("expected findings from a generated snapshot")
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRecorderReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snap := &Snapshot{
+		Tables:  []TableInfo{{Schema: "public", Name: "users"}, {Schema: "app", Name: "jobs"}},
+		Columns: []ColumnInfo{{Schema: "public", Table: "users", Name: "id", DataType: "bigint"}},
+	}
+	src := &StaticSource{Snapshot: snap, Version: "16.2", NullFractions: map[string]float64{"public.users.email": 0.5}}
+
+	rec, err := NewRecorder(src, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.ServerVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := rec.CountTables(ctx, []string{"app"}); err != nil || n != 1 {
+		t.Fatalf("CountTables = %d, %v", n, err)
+	}
+	if _, err := rec.Inspect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.SampleNullFraction(ctx, "public", "users", "email", 1); err != nil {
+		t.Fatal(err)
+	}
+	rec.Close()
+
+	replay, err := OpenReplay(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := replay.ServerVersion(ctx); err != nil || v != "16.2" {
+		t.Errorf("ServerVersion = %q, %v", v, err)
+	}
+	got, err := replay.Inspect(ctx)
+	if err != nil || !reflect.DeepEqual(got, snap) {
+		t.Errorf("Inspect = %+v, %v", got, err)
+	}
+	if n, _ := replay.CountTables(ctx, []string{"app"}); n != 1 {
+		t.Errorf("recorded CountTables = %d, want 1", n)
+	}
+	if n, _ := replay.CountTables(ctx, nil); n != 2 {
+		t.Errorf("CountTables from snapshot = %d, want 2", n)
+	}
+	if frac, err := replay.SampleNullFraction(ctx, "public", "users", "email", 1); err != nil || frac != 0.5 {
+		t.Errorf("SampleNullFraction = %v, %v", frac, err)
+	}
+}
+
+func TestOpenReplay_Missing(t *testing.T) {
+	if _, err := OpenReplay(t.TempDir() + "/nope"); err == nil {
+		t.Error("expected error for missing fixture directory")
+	}
+
+	replay, err := OpenReplay(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.Inspect(context.Background()); err == nil {
+		t.Error("expected error for unrecorded snapshot")
+	}
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetFunctions fetches the source of user-defined SQL and PL/pgSQL
+// functions and procedures. Functions that belong to an extension are
+// omitted. The inspector's table patterns don't apply: a function in any
+// schema may use the tables they select.
+func (i *Inspector) GetFunctions(ctx context.Context) ([]FunctionInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			p.proname,
+			p.prokind::text,
+			l.lanname,
+			COALESCE(p.prosrc, '') AS body
+		FROM pg_catalog.pg_proc p
+		JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+		JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+		WHERE l.lanname IN ('sql', 'plpgsql')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.classid = 'pg_catalog.pg_proc'::regclass
+					AND d.objid = p.oid
+					AND d.deptype = 'e'
+			)
+		ORDER BY n.nspname, p.proname, p.oid`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get functions: %w", err)
+	}
+	defer rows.Close()
+
+	var functions []FunctionInfo
+	for rows.Next() {
+		var f FunctionInfo
+		if err := rows.Scan(&f.Schema, &f.Name, &f.Kind, &f.Language, &f.Body); err != nil {
+			return nil, fmt.Errorf("scan function: %w", err)
+		}
+		functions = append(functions, f)
+	}
+	return functions, rows.Err()
+}
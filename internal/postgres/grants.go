@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetGrants fetches table privileges held by roles other than the table's
+// owner. It reads pg_class.relacl directly: information_schema's grant
+// views only show privileges involving the current user.
+func (i *Inspector) GetGrants(ctx context.Context) ([]GrantInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			CASE WHEN a.grantee = 0 THEN 'PUBLIC' ELSE pg_catalog.pg_get_userbyid(a.grantee) END,
+			a.privilege_type
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL pg_catalog.aclexplode(c.relacl) a
+		WHERE c.relkind IN ('r', 'p')
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg_toast%'
+		  AND a.grantee <> c.relowner
+		  AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname, 3, a.privilege_type`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []GrantInfo
+	for rows.Next() {
+		var g GrantInfo
+		if err := rows.Scan(&g.Schema, &g.Table, &g.Grantee, &g.Privilege); err != nil {
+			return nil, fmt.Errorf("scan grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// GetRoles fetches the non-system roles with the client sessions each has
+// open.
+func (i *Inspector) GetRoles(ctx context.Context) ([]RoleInfo, error) {
+	query := `
+		SELECT
+			r.rolname,
+			r.rolsuper,
+			r.rolcanlogin,
+			count(a.pid),
+			COALESCE(array_agg(DISTINCT a.application_name ORDER BY a.application_name)
+				FILTER (WHERE a.application_name <> ''), '{}')
+		FROM pg_catalog.pg_roles r
+		LEFT JOIN pg_catalog.pg_stat_activity a
+			ON a.usesysid = r.oid
+			AND a.backend_type = 'client backend'
+			AND a.pid <> pg_catalog.pg_backend_pid()
+		WHERE r.rolname !~ '^pg_'
+		GROUP BY r.rolname, r.rolsuper, r.rolcanlogin
+		ORDER BY r.rolname`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []RoleInfo
+	for rows.Next() {
+		var r RoleInfo
+		if err := rows.Scan(&r.Name, &r.Superuser, &r.CanLogin, &r.Connections, &r.Applications); err != nil {
+			return nil, fmt.Errorf("scan role: %w", err)
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// GetPolicies fetches the row-level security policies on user tables.
+func (i *Inspector) GetPolicies(ctx context.Context) ([]PolicyInfo, error) {
+	query := `
+		SELECT schemaname, tablename, policyname, cmd, permissive = 'PERMISSIVE', roles::text[]
+		FROM pg_catalog.pg_policies
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+		  AND (cardinality($1::text[]) = 0 OR tablename ILIKE ANY($1))
+		ORDER BY schemaname, tablename, policyname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []PolicyInfo
+	for rows.Next() {
+		var p PolicyInfo
+		if err := rows.Scan(&p.Schema, &p.Table, &p.Name, &p.Command, &p.Permissive, &p.Roles); err != nil {
+			return nil, fmt.Errorf("scan policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
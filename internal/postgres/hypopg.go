@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IndexEstimate is the planner's cost for a query without and with a
+// hypothetical index.
+type IndexEstimate struct {
+	CostBefore float64
+	CostAfter  float64
+	Used       bool // the plan with the index uses it
+}
+
+// IndexValidator is implemented by catalog sources that can plan queries
+// against hypothetical indexes. *Inspector does, through the hypopg
+// extension; callers should check that it is installed first.
+type IndexValidator interface {
+	EstimateIndex(ctx context.Context, createIndex, query string) (IndexEstimate, error)
+}
+
+var _ IndexValidator = (*Inspector)(nil)
+
+// paramRe matches the $n parameters of a normalized statement.
+var paramRe = regexp.MustCompile(`\$(\d+)`)
+
+// hypoStatements numbers the prepared statements EstimateIndex creates, so
+// one left behind by a failed cleanup can't collide with the next.
+var hypoStatements atomic.Int64
+
+// EstimateIndex plans query, which may have $n parameters, as a generic
+// plan before and after creating createIndex as a hypopg hypothetical
+// index. Nothing is executed and no index is built; the hypothetical index
+// only exists in the session used, and is removed before it is released.
+func (i *Inspector) EstimateIndex(ctx context.Context, createIndex, query string) (IndexEstimate, error) {
+	conn, err := i.pool.Acquire(ctx)
+	if err != nil {
+		return IndexEstimate{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	name := fmt.Sprintf("pgspectre_hypo_%d", hypoStatements.Add(1))
+	defer func() {
+		cleanup := context.WithoutCancel(ctx)
+		_, _ = conn.Exec(cleanup, "DEALLOCATE "+name)
+		_, _ = conn.Exec(cleanup, "SELECT hypopg_reset()")
+		_, _ = conn.Exec(cleanup, "RESET plan_cache_mode")
+	}()
+
+	// A generic plan doesn't look at parameter values, so NULLs will do.
+	if _, err := conn.Exec(ctx, "SET plan_cache_mode = force_generic_plan"); err != nil {
+		return IndexEstimate{}, fmt.Errorf("set plan_cache_mode: %w", err)
+	}
+	if _, err := conn.Exec(ctx, fmt.Sprintf("PREPARE %s AS %s", name, query)); err != nil {
+		return IndexEstimate{}, fmt.Errorf("prepare: %w", err)
+	}
+	explain := "EXPLAIN (FORMAT JSON) EXECUTE " + name
+	if n := maxParam(query); n > 0 {
+		explain += "(" + strings.TrimSuffix(strings.Repeat("NULL, ", n), ", ") + ")"
+	}
+
+	before, _, err := explainCost(ctx, conn, explain)
+	if err != nil {
+		return IndexEstimate{}, err
+	}
+	var hypoName string
+	if err := conn.QueryRow(ctx, "SELECT indexname FROM hypopg_create_index($1)", createIndex).Scan(&hypoName); err != nil {
+		return IndexEstimate{}, fmt.Errorf("create hypothetical index: %w", err)
+	}
+	after, plan, err := explainCost(ctx, conn, explain)
+	if err != nil {
+		return IndexEstimate{}, err
+	}
+	return IndexEstimate{CostBefore: before, CostAfter: after, Used: strings.Contains(plan, hypoName)}, nil
+}
+
+// explainCost runs a JSON EXPLAIN and returns its total cost and the plan.
+func explainCost(ctx context.Context, conn *pgxpool.Conn, explain string) (float64, string, error) {
+	var plan string
+	if err := conn.QueryRow(ctx, explain).Scan(&plan); err != nil {
+		return 0, "", fmt.Errorf("explain: %w", err)
+	}
+	var out []struct {
+		Plan struct {
+			TotalCost float64 `json:"Total Cost"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &out); err != nil {
+		return 0, "", fmt.Errorf("parse plan: %w", err)
+	}
+	if len(out) == 0 {
+		return 0, "", fmt.Errorf("parse plan: empty")
+	}
+	return out[0].Plan.TotalCost, plan, nil
+}
+
+// maxParam returns the highest $n parameter in a statement.
+func maxParam(query string) int {
+	n := 0
+	for _, m := range paramRe.FindAllStringSubmatch(query, -1) {
+		if v, err := strconv.Atoi(m[1]); err == nil && v > n {
+			n = v
+		}
+	}
+	return n
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import "testing"
+
+func TestMaxParam(t *testing.T) {
+	tests := map[string]int{
+		"SELECT 1": 0,
+		"SELECT * FROM t WHERE a = $1 AND b IN ($2, $12) LIMIT $3": 12,
+	}
+	for query, want := range tests {
+		if got := maxParam(query); got != want {
+			t.Errorf("maxParam(%q) = %d, want %d", query, got, want)
+		}
+	}
+}
@@ -9,7 +9,12 @@ import (
 
 // Inspector reads PostgreSQL catalog metadata and statistics.
 type Inspector struct {
-	pool *pgxpool.Pool
+	pool       *pgxpool.Pool
+	tables     []string // ILIKE patterns; empty means all tables
+	checkViews bool     // run CheckViews during Inspect
+	grants     bool     // run GetGrants during Inspect
+	roles      bool     // run GetRoles during Inspect
+	policies   bool     // run GetPolicies during Inspect
 }
 
 // NewInspector connects to PostgreSQL with retry on transient errors.
@@ -19,7 +24,7 @@ func NewInspector(ctx context.Context, cfg Config) (*Inspector, error) {
 
 // newInspectorOnce connects to PostgreSQL without retry.
 func newInspectorOnce(ctx context.Context, cfg Config) (*Inspector, error) {
-	pool, err := pgxpool.New(ctx, cfg.URL)
+	pool, err := pgxpool.New(ctx, cfg.connString())
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +34,14 @@ func newInspectorOnce(ctx context.Context, cfg Config) (*Inspector, error) {
 		return nil, fmt.Errorf("ping: %w", err)
 	}
 
-	return &Inspector{pool: pool}, nil
+	return &Inspector{
+		pool:       pool,
+		tables:     likePatterns(cfg.Tables),
+		checkViews: !cfg.SkipViewCheck,
+		grants:     !cfg.SkipGrants,
+		roles:      !cfg.SkipRoles,
+		policies:   !cfg.SkipPolicies,
+	}, nil
 }
 
 // Close releases the connection pool.
@@ -55,18 +67,32 @@ func (i *Inspector) GetTables(ctx context.Context) ([]TableInfo, error) {
 			t.table_name,
 			t.table_type,
 			COALESCE(c.reltuples::bigint, 0) AS estimated_rows,
-			COALESCE(pg_catalog.pg_total_relation_size(c.oid), 0) AS size_bytes
+			COALESCE(pg_catalog.pg_total_relation_size(c.oid), 0) AS size_bytes,
+			COALESCE(c.relpersistence::text, 'p') AS persistence,
+			COALESCE(pg_catalog.pg_total_relation_size(NULLIF(c.reltoastrelid, 0)), 0) AS toast_bytes,
+			COALESCE((
+				SELECT SUM(s.avg_width) FROM pg_catalog.pg_stats s
+				WHERE s.schemaname = t.table_schema AND s.tablename = t.table_name AND NOT s.inherited
+			), 0)::bigint AS avg_row_width,
+			COALESCE(c.reloptions, '{}') || COALESCE(
+				(SELECT array_agg('toast.' || o) FROM unnest(tc.reloptions) o),
+				'{}'
+			) AS options,
+			COALESCE(c.relrowsecurity, false) AS row_security,
+			COALESCE(c.relforcerowsecurity, false) AS force_row_security
 		FROM information_schema.tables t
 		LEFT JOIN pg_catalog.pg_class c
 			ON c.relname = t.table_name
 			AND c.relnamespace = (
 				SELECT oid FROM pg_catalog.pg_namespace WHERE nspname = t.table_schema
 			)
+		LEFT JOIN pg_catalog.pg_class tc ON tc.oid = c.reltoastrelid
 		WHERE t.table_schema NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
 			AND t.table_type = 'BASE TABLE'
+			AND (cardinality($1::text[]) = 0 OR t.table_name ILIKE ANY($1))
 		ORDER BY t.table_schema, t.table_name`
 
-	rows, err := i.pool.Query(ctx, query)
+	rows, err := i.pool.Query(ctx, query, i.tables)
 	if err != nil {
 		return nil, fmt.Errorf("get tables: %w", err)
 	}
@@ -75,7 +101,7 @@ func (i *Inspector) GetTables(ctx context.Context) ([]TableInfo, error) {
 	var tables []TableInfo
 	for rows.Next() {
 		var t TableInfo
-		if err := rows.Scan(&t.Schema, &t.Name, &t.Type, &t.EstimatedRows, &t.SizeBytes); err != nil {
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Type, &t.EstimatedRows, &t.SizeBytes, &t.Persistence, &t.ToastBytes, &t.AvgRowWidth, &t.Options, &t.RowSecurity, &t.ForceRowSecurity); err != nil {
 			return nil, fmt.Errorf("scan table: %w", err)
 		}
 		tables = append(tables, t)
@@ -96,9 +122,10 @@ func (i *Inspector) GetColumns(ctx context.Context) ([]ColumnInfo, error) {
 			column_default
 		FROM information_schema.columns
 		WHERE table_schema NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR table_name ILIKE ANY($1))
 		ORDER BY table_schema, table_name, ordinal_position`
 
-	rows, err := i.pool.Query(ctx, query)
+	rows, err := i.pool.Query(ctx, query, i.tables)
 	if err != nil {
 		return nil, fmt.Errorf("get columns: %w", err)
 	}
@@ -126,15 +153,29 @@ func (i *Inspector) GetIndexes(ctx context.Context) ([]IndexInfo, error) {
 			COALESCE(pg_catalog.pg_relation_size(si.indexrelid), 0) AS size_bytes,
 			COALESCE(si.idx_scan, 0) AS idx_scan,
 			COALESCE(si.idx_tup_read, 0) AS idx_tup_read,
-			COALESCE(si.idx_tup_fetch, 0) AS idx_tup_fetch
+			COALESCE(si.idx_tup_fetch, 0) AS idx_tup_fetch,
+			COALESCE(ix.indisvalid, true) AS is_valid,
+			COALESCE(ix.indisunique, false) AS is_unique,
+			COALESCE(con.conname, '') AS constraint_name
 		FROM pg_catalog.pg_indexes pi
 		LEFT JOIN pg_catalog.pg_stat_user_indexes si
 			ON si.indexrelname = pi.indexname
 			AND si.schemaname = pi.schemaname
+		LEFT JOIN pg_catalog.pg_class ic
+			ON ic.relname = pi.indexname
+			AND ic.relnamespace = (
+				SELECT oid FROM pg_catalog.pg_namespace WHERE nspname = pi.schemaname
+			)
+		LEFT JOIN pg_catalog.pg_index ix ON ix.indexrelid = ic.oid
+		LEFT JOIN pg_catalog.pg_constraint con
+			ON con.conindid = ic.oid
+			AND con.conrelid = ix.indrelid
+			AND con.contype IN ('p', 'u', 'x')
 		WHERE pi.schemaname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR pi.tablename ILIKE ANY($1))
 		ORDER BY pi.schemaname, pi.tablename, pi.indexname`
 
-	rows, err := i.pool.Query(ctx, query)
+	rows, err := i.pool.Query(ctx, query, i.tables)
 	if err != nil {
 		return nil, fmt.Errorf("get indexes: %w", err)
 	}
@@ -143,7 +184,7 @@ func (i *Inspector) GetIndexes(ctx context.Context) ([]IndexInfo, error) {
 	var indexes []IndexInfo
 	for rows.Next() {
 		var idx IndexInfo
-		if err := rows.Scan(&idx.Schema, &idx.Table, &idx.Name, &idx.Definition, &idx.SizeBytes, &idx.IndexScans, &idx.TupRead, &idx.TupFetch); err != nil {
+		if err := rows.Scan(&idx.Schema, &idx.Table, &idx.Name, &idx.Definition, &idx.SizeBytes, &idx.IndexScans, &idx.TupRead, &idx.TupFetch, &idx.IsValid, &idx.IsUnique, &idx.Constraint); err != nil {
 			return nil, fmt.Errorf("scan index: %w", err)
 		}
 		indexes = append(indexes, idx)
@@ -155,12 +196,20 @@ func (i *Inspector) GetIndexes(ctx context.Context) ([]IndexInfo, error) {
 func (i *Inspector) GetTableStats(ctx context.Context) ([]TableStats, error) {
 	query := `
 		SELECT
-			schemaname,
-			relname,
+			s.schemaname,
+			s.relname,
 			COALESCE(seq_scan, 0),
 			COALESCE(seq_tup_read, 0),
 			COALESCE(idx_scan, 0),
 			COALESCE(idx_tup_fetch, 0),
+			COALESCE(n_tup_ins, 0),
+			COALESCE(n_tup_upd, 0),
+			COALESCE(n_tup_hot_upd, 0),
+			COALESCE(n_tup_del, 0),
+			COALESCE(io.heap_blks_read, 0),
+			COALESCE(io.heap_blks_hit, 0),
+			COALESCE(io.idx_blks_read, 0),
+			COALESCE(io.idx_blks_hit, 0),
 			COALESCE(n_live_tup, 0),
 			COALESCE(n_dead_tup, 0),
 			last_vacuum,
@@ -171,10 +220,12 @@ func (i *Inspector) GetTableStats(ctx context.Context) ([]TableStats, error) {
 			COALESCE(autovacuum_count, 0),
 			COALESCE(analyze_count, 0),
 			COALESCE(autoanalyze_count, 0)
-		FROM pg_catalog.pg_stat_user_tables
-		ORDER BY schemaname, relname`
+		FROM pg_catalog.pg_stat_user_tables s
+		LEFT JOIN pg_catalog.pg_statio_user_tables io ON io.relid = s.relid
+		WHERE cardinality($1::text[]) = 0 OR s.relname ILIKE ANY($1)
+		ORDER BY s.schemaname, s.relname`
 
-	rows, err := i.pool.Query(ctx, query)
+	rows, err := i.pool.Query(ctx, query, i.tables)
 	if err != nil {
 		return nil, fmt.Errorf("get table stats: %w", err)
 	}
@@ -186,6 +237,8 @@ func (i *Inspector) GetTableStats(ctx context.Context) ([]TableStats, error) {
 		if err := rows.Scan(
 			&s.Schema, &s.Name,
 			&s.SeqScan, &s.SeqTupRead, &s.IdxScan, &s.IdxTupFetch,
+			&s.TupInserted, &s.TupUpdated, &s.TupHotUpdated, &s.TupDeleted,
+			&s.HeapBlksRead, &s.HeapBlksHit, &s.IdxBlksRead, &s.IdxBlksHit,
 			&s.LiveTuples, &s.DeadTuples,
 			&s.LastVacuum, &s.LastAutovacuum, &s.LastAnalyze, &s.LastAutoanalyze,
 			&s.VacuumCount, &s.AutovacuumCount, &s.AnalyzeCount, &s.AutoanalyzeCount,
@@ -215,6 +268,7 @@ func (i *Inspector) GetConstraints(ctx context.Context) ([]ConstraintInfo, error
 				),
 				'{}'
 			) AS columns,
+			fn.nspname AS ref_schema,
 			frel.relname AS ref_table,
 			COALESCE(
 				ARRAY(
@@ -225,16 +279,20 @@ func (i *Inspector) GetConstraints(ctx context.Context) ([]ConstraintInfo, error
 					ORDER BY u.ord
 				),
 				'{}'
-			) AS ref_columns
+			) AS ref_columns,
+			pg_catalog.pg_get_constraintdef(c.oid) AS definition,
+			c.convalidated
 		FROM pg_catalog.pg_constraint c
 		JOIN pg_catalog.pg_namespace n ON n.oid = c.connamespace
 		JOIN pg_catalog.pg_class rel ON rel.oid = c.conrelid
 		LEFT JOIN pg_catalog.pg_class frel ON frel.oid = c.confrelid
+		LEFT JOIN pg_catalog.pg_namespace fn ON fn.oid = frel.relnamespace
 		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
 			AND c.conrelid > 0
+			AND (cardinality($1::text[]) = 0 OR rel.relname ILIKE ANY($1))
 		ORDER BY n.nspname, rel.relname, c.conname`
 
-	rows, err := i.pool.Query(ctx, query)
+	rows, err := i.pool.Query(ctx, query, i.tables)
 	if err != nil {
 		return nil, fmt.Errorf("get constraints: %w", err)
 	}
@@ -243,7 +301,7 @@ func (i *Inspector) GetConstraints(ctx context.Context) ([]ConstraintInfo, error
 	var constraints []ConstraintInfo
 	for rows.Next() {
 		var ci ConstraintInfo
-		if err := rows.Scan(&ci.Schema, &ci.Table, &ci.Name, &ci.Type, &ci.Columns, &ci.RefTable, &ci.RefColumns); err != nil {
+		if err := rows.Scan(&ci.Schema, &ci.Table, &ci.Name, &ci.Type, &ci.Columns, &ci.RefSchema, &ci.RefTable, &ci.RefColumns, &ci.Definition, &ci.Validated); err != nil {
 			return nil, fmt.Errorf("scan constraint: %w", err)
 		}
 		constraints = append(constraints, ci)
@@ -251,6 +309,196 @@ func (i *Inspector) GetConstraints(ctx context.Context) ([]ConstraintInfo, error
 	return constraints, rows.Err()
 }
 
+// GetColumnStats fetches planner statistics (null fraction, distinct values)
+// for analyzed user table columns. Columns the role cannot read are omitted
+// by pg_stats itself.
+func (i *Inspector) GetColumnStats(ctx context.Context) ([]ColumnStats, error) {
+	query := `
+		SELECT
+			schemaname,
+			tablename,
+			attname,
+			COALESCE(null_frac, 0)::float8,
+			COALESCE(n_distinct, 0)::float8,
+			COALESCE(avg_width, 0)::bigint
+		FROM pg_catalog.pg_stats
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND NOT inherited
+			AND (cardinality($1::text[]) = 0 OR tablename ILIKE ANY($1))
+		ORDER BY schemaname, tablename, attname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get column stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []ColumnStats
+	for rows.Next() {
+		var cs ColumnStats
+		if err := rows.Scan(&cs.Schema, &cs.Table, &cs.Column, &cs.NullFrac, &cs.NDistinct, &cs.AvgWidth); err != nil {
+			return nil, fmt.Errorf("scan column stats: %w", err)
+		}
+		stats = append(stats, cs)
+	}
+	return stats, rows.Err()
+}
+
+// GetSequences fetches all user sequences with their current value and the
+// column that owns them (serial or identity), if any.
+func (i *Inspector) GetSequences(ctx context.Context) ([]SequenceInfo, error) {
+	query := `
+		SELECT
+			s.schemaname,
+			s.sequencename,
+			s.data_type::text,
+			COALESCE(s.last_value, 0) AS last_value,
+			s.min_value,
+			s.max_value,
+			s.increment_by,
+			s.cycle,
+			COALESCE(t.relname, '') AS table_name,
+			COALESCE(a.attname, '') AS column_name,
+			COALESCE(pg_catalog.format_type(a.atttypid, a.atttypmod), '') AS column_type
+		FROM pg_catalog.pg_sequences s
+		JOIN pg_catalog.pg_class sc
+			ON sc.relname = s.sequencename
+			AND sc.relnamespace = (
+				SELECT oid FROM pg_catalog.pg_namespace WHERE nspname = s.schemaname
+			)
+		LEFT JOIN pg_catalog.pg_depend d
+			ON d.objid = sc.oid
+			AND d.classid = 'pg_catalog.pg_class'::regclass
+			AND d.refclassid = 'pg_catalog.pg_class'::regclass
+			AND d.deptype IN ('a', 'i')
+		LEFT JOIN pg_catalog.pg_class t ON t.oid = d.refobjid
+		LEFT JOIN pg_catalog.pg_attribute a
+			ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE s.schemaname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR t.relname ILIKE ANY($1))
+		ORDER BY s.schemaname, s.sequencename`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get sequences: %w", err)
+	}
+	defer rows.Close()
+
+	var sequences []SequenceInfo
+	for rows.Next() {
+		var seq SequenceInfo
+		if err := rows.Scan(&seq.Schema, &seq.Name, &seq.DataType, &seq.LastValue, &seq.MinValue, &seq.MaxValue, &seq.Increment, &seq.Cycle, &seq.Table, &seq.Column, &seq.ColumnType); err != nil {
+			return nil, fmt.Errorf("scan sequence: %w", err)
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, rows.Err()
+}
+
+// GetExtensions fetches all installed extensions.
+func (i *Inspector) GetExtensions(ctx context.Context) ([]ExtensionInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			e.extname,
+			e.extversion
+		FROM pg_catalog.pg_extension e
+		JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+		ORDER BY n.nspname, e.extname`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var extensions []ExtensionInfo
+	for rows.Next() {
+		var ext ExtensionInfo
+		if err := rows.Scan(&ext.Schema, &ext.Name, &ext.Version); err != nil {
+			return nil, fmt.Errorf("scan extension: %w", err)
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, rows.Err()
+}
+
+// GetMatViews fetches all user materialized views with scan and refresh
+// activity.
+func (i *Inspector) GetMatViews(ctx context.Context) ([]MatViewInfo, error) {
+	query := `
+		SELECT
+			m.schemaname,
+			m.matviewname,
+			m.ispopulated,
+			COALESCE(pg_catalog.pg_total_relation_size(s.relid), 0) AS size_bytes,
+			COALESCE(s.seq_scan, 0),
+			COALESCE(s.idx_scan, 0),
+			COALESCE(s.n_tup_ins + s.n_tup_del, 0) AS rows_written,
+			GREATEST(s.last_analyze, s.last_autoanalyze) AS last_refresh
+		FROM pg_catalog.pg_matviews m
+		LEFT JOIN pg_catalog.pg_stat_user_tables s
+			ON s.schemaname = m.schemaname
+			AND s.relname = m.matviewname
+		WHERE m.schemaname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR m.matviewname ILIKE ANY($1))
+		ORDER BY m.schemaname, m.matviewname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get matviews: %w", err)
+	}
+	defer rows.Close()
+
+	var matviews []MatViewInfo
+	for rows.Next() {
+		var mv MatViewInfo
+		if err := rows.Scan(&mv.Schema, &mv.Name, &mv.Populated, &mv.SizeBytes, &mv.SeqScan, &mv.IdxScan, &mv.RowsWritten, &mv.LastRefresh); err != nil {
+			return nil, fmt.Errorf("scan matview: %w", err)
+		}
+		matviews = append(matviews, mv)
+	}
+	return matviews, rows.Err()
+}
+
+// CountTables returns the number of user tables, optionally limited to
+// the given schemas and the inspector's table patterns. It is a cheap
+// single query used for cost estimates.
+func (i *Inspector) CountTables(ctx context.Context, schemas []string) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR n.nspname = ANY($1))
+			AND (cardinality($2::text[]) = 0 OR c.relname ILIKE ANY($2))`
+
+	if schemas == nil {
+		schemas = []string{}
+	}
+	var n int
+	if err := i.pool.QueryRow(ctx, query, schemas, i.tables).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count tables: %w", err)
+	}
+	return n, nil
+}
+
+// InspectQueries returns the number of catalog queries Inspect runs with
+// cfg, not counting the EXPLAIN that CheckViews runs per candidate view.
+func InspectQueries(cfg Config) int {
+	n := 27
+	if !cfg.SkipViewCheck {
+		n++ // CheckViews candidate lookup
+	}
+	for _, skip := range []bool{cfg.SkipGrants, cfg.SkipRoles, cfg.SkipPolicies} {
+		if skip {
+			n--
+		}
+	}
+	return n
+}
+
 // Inspect gathers the full catalog snapshot for the connected database.
 func (i *Inspector) Inspect(ctx context.Context) (*Snapshot, error) {
 	tables, err := i.GetTables(ctx)
@@ -258,6 +506,11 @@ func (i *Inspector) Inspect(ctx context.Context) (*Snapshot, error) {
 		return nil, err
 	}
 
+	relations, err := i.GetRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	columns, err := i.GetColumns(ctx)
 	if err != nil {
 		return nil, err
@@ -278,11 +531,128 @@ func (i *Inspector) Inspect(ctx context.Context) (*Snapshot, error) {
 		return nil, err
 	}
 
+	sequences, err := i.GetSequences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions, err := i.GetExtensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	columnStats, err := i.GetColumnStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matviews, err := i.GetMatViews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := i.GetViews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if i.checkViews {
+		if err := i.CheckViews(ctx, views); err != nil {
+			return nil, err
+		}
+	}
+
+	triggers, err := i.GetTriggers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	functions, err := i.GetFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partitioned, err := i.GetPartitionedTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := i.GetPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ioStats, err := i.GetIOStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := i.GetStatStatements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := i.GetDatabaseStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := i.GetInstanceStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var grants []GrantInfo
+	if i.grants {
+		grants, err = i.GetGrants(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var roles []RoleInfo
+	if i.roles {
+		roles, err = i.GetRoles(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var policies []PolicyInfo
+	if i.policies {
+		policies, err = i.GetPolicies(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := i.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Snapshot{
-		Tables:      tables,
-		Columns:     columns,
-		Indexes:     indexes,
-		Stats:       stats,
-		Constraints: constraints,
+		Tables:            tables,
+		Relations:         relations,
+		Columns:           columns,
+		Indexes:           indexes,
+		Stats:             stats,
+		Constraints:       constraints,
+		Sequences:         sequences,
+		Extensions:        extensions,
+		ColumnStats:       columnStats,
+		MatViews:          matviews,
+		Views:             views,
+		Triggers:          triggers,
+		Functions:         functions,
+		PartitionedTables: partitioned,
+		Partitions:        partitions,
+		IO:                ioStats,
+		Statements:        statements,
+		Database:          database,
+		Instance:          instance,
+		Grants:            grants,
+		Roles:             roles,
+		Policies:          policies,
+		Settings:          settings,
 	}, nil
 }
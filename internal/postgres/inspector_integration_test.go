@@ -1,16 +1,18 @@
 //go:build integration
 
-package postgres
+package postgres_test
 
 import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/ppiankov/pgspectre/internal/postgres"
 	"github.com/ppiankov/pgspectre/internal/testutil"
 )
 
@@ -21,7 +23,7 @@ func TestIntegration_Inspector(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	inspector, err := NewInspector(ctx, Config{URL: connStr})
+	inspector, err := postgres.NewInspector(ctx, postgres.Config{URL: connStr})
 	if err != nil {
 		t.Fatalf("NewInspector: %v", err)
 	}
@@ -63,6 +65,15 @@ func TestIntegration_Inspector(t *testing.T) {
 			if tbl.Schema != "public" {
 				t.Errorf("users schema = %q, want public", tbl.Schema)
 			}
+			if tbl.Persistence != "p" {
+				t.Errorf("users persistence = %q, want p", tbl.Persistence)
+			}
+			if tbl.ToastBytes <= 0 || tbl.ToastBytes > tbl.SizeBytes {
+				t.Errorf("users toast bytes = %d, want > 0 and <= size %d", tbl.ToastBytes, tbl.SizeBytes)
+			}
+		}
+		if tbl.Name == "empty_table" && !slices.Contains(tbl.Options, "autovacuum_enabled=false") {
+			t.Errorf("empty_table options = %v, want autovacuum_enabled=false", tbl.Options)
 		}
 	}
 
@@ -91,6 +102,9 @@ func TestIntegration_Inspector(t *testing.T) {
 	idxNames := make(map[string]bool)
 	for _, idx := range indexes {
 		idxNames[idx.Name] = true
+		if idx.Name == "users_pkey" && (!idx.IsUnique || idx.Constraint != "users_pkey") {
+			t.Errorf("users_pkey: unique = %v, constraint = %q", idx.IsUnique, idx.Constraint)
+		}
 	}
 	for _, want := range []string{"idx_users_email", "idx_orders_user_id", "idx_orders_created"} {
 		if !idxNames[want] {
@@ -103,7 +117,7 @@ func TestIntegration_Inspector(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetTableStats: %v", err)
 	}
-	statsMap := make(map[string]TableStats)
+	statsMap := make(map[string]postgres.TableStats)
 	for _, s := range stats {
 		statsMap[s.Name] = s
 	}
@@ -111,6 +125,10 @@ func TestIntegration_Inspector(t *testing.T) {
 		t.Error("GetTableStats: missing users stats")
 	} else if s.LiveTuples <= 0 {
 		t.Errorf("users live_tuples = %d, want > 0", s.LiveTuples)
+	} else if s.HeapBlksRead+s.HeapBlksHit <= 0 {
+		t.Errorf("users heap blocks = %d read, %d hit, want some", s.HeapBlksRead, s.HeapBlksHit)
+	} else if s.TupHotUpdated > s.TupUpdated {
+		t.Errorf("users hot updates = %d, more than updates %d", s.TupHotUpdated, s.TupUpdated)
 	}
 	if _, ok := statsMap["empty_table"]; !ok {
 		t.Error("GetTableStats: missing empty_table stats")
@@ -139,6 +157,9 @@ func TestIntegration_Inspector(t *testing.T) {
 			if c.RefTable == nil || *c.RefTable != "users" {
 				t.Errorf("orders FK ref_table = %v, want users", c.RefTable)
 			}
+			if c.RefSchema == nil || *c.RefSchema != "public" {
+				t.Errorf("orders FK ref_schema = %v, want public", c.RefSchema)
+			}
 		case c.Table == "users" && c.Type == "u":
 			hasUQ = true
 		}
@@ -153,6 +174,214 @@ func TestIntegration_Inspector(t *testing.T) {
 		t.Error("GetConstraints: no unique constraint found for users.email")
 	}
 
+	// GetSequences
+	sequences, err := inspector.GetSequences(ctx)
+	if err != nil {
+		t.Fatalf("GetSequences: %v", err)
+	}
+	var hasUsersSeq bool
+	for _, seq := range sequences {
+		if seq.Table == "users" && seq.Column == "id" {
+			hasUsersSeq = true
+			if seq.ColumnType != "integer" {
+				t.Errorf("users_id_seq column_type = %q, want integer", seq.ColumnType)
+			}
+			if seq.LastValue < 3 {
+				t.Errorf("users_id_seq last_value = %d, want >= 3", seq.LastValue)
+			}
+		}
+	}
+	if !hasUsersSeq {
+		t.Error("GetSequences: no sequence owned by users.id")
+	}
+
+	// GetColumnStats (seed data is analyzed by the container's autovacuum
+	// only eventually, so just verify the query runs)
+	if _, err := inspector.GetColumnStats(ctx); err != nil {
+		t.Fatalf("GetColumnStats: %v", err)
+	}
+
+	// GetMatViews
+	matviews, err := inspector.GetMatViews(ctx)
+	if err != nil {
+		t.Fatalf("GetMatViews: %v", err)
+	}
+	var hasOrderTotals bool
+	for _, mv := range matviews {
+		if mv.Name == "order_totals" {
+			hasOrderTotals = true
+			if mv.Populated {
+				t.Error("order_totals created WITH NO DATA reported as populated")
+			}
+		}
+	}
+	if !hasOrderTotals {
+		t.Error("GetMatViews: missing order_totals")
+	}
+
+	// GetViews and CheckViews
+	views, err := inspector.GetViews(ctx)
+	if err != nil {
+		t.Fatalf("GetViews: %v", err)
+	}
+	if err := inspector.CheckViews(ctx, views); err != nil {
+		t.Fatalf("CheckViews: %v", err)
+	}
+	var hasActiveUsers bool
+	for _, v := range views {
+		if v.Name == "active_users" {
+			hasActiveUsers = true
+			if len(v.DependsOn) != 1 || v.DependsOn[0] != "public.users" {
+				t.Errorf("active_users depends_on = %v, want [public.users]", v.DependsOn)
+			}
+			if v.Error != "" {
+				t.Errorf("active_users error = %q", v.Error)
+			}
+		}
+	}
+	if !hasActiveUsers {
+		t.Error("GetViews: missing active_users")
+	}
+
+	// GetTriggers (FK triggers are internal and omitted)
+	triggers, err := inspector.GetTriggers(ctx)
+	if err != nil {
+		t.Fatalf("GetTriggers: %v", err)
+	}
+	if len(triggers) != 1 {
+		t.Fatalf("GetTriggers = %d triggers, want 1: %+v", len(triggers), triggers)
+	}
+	if tr := triggers[0]; tr.Name != "orders_touch" || tr.Enabled != postgres.TriggerDisabled || tr.Language != "plpgsql" || !strings.Contains(tr.Body, "NEW.created_at") {
+		t.Errorf("orders_touch = %+v", tr)
+	}
+
+	// GetFunctions
+	functions, err := inspector.GetFunctions(ctx)
+	if err != nil {
+		t.Fatalf("GetFunctions: %v", err)
+	}
+	var hasOrderCount bool
+	for _, f := range functions {
+		if f.Name == "user_order_count" {
+			hasOrderCount = true
+			if f.Kind != postgres.FunctionKindFunction || f.Language != "sql" || !strings.Contains(f.Body, "FROM orders") {
+				t.Errorf("user_order_count = %+v", f)
+			}
+		}
+	}
+	if !hasOrderCount {
+		t.Errorf("GetFunctions: missing user_order_count: %+v", functions)
+	}
+
+	// GetPartitionedTables
+	partitioned, err := inspector.GetPartitionedTables(ctx)
+	if err != nil {
+		t.Fatalf("GetPartitionedTables: %v", err)
+	}
+	if len(partitioned) != 1 || partitioned[0].Name != "events" ||
+		len(partitioned[0].KeyColumns) != 1 || partitioned[0].KeyColumns[0] != "created_at" {
+		t.Errorf("GetPartitionedTables = %+v", partitioned)
+	}
+
+	// GetPartitions
+	partitions, err := inspector.GetPartitions(ctx)
+	if err != nil {
+		t.Fatalf("GetPartitions: %v", err)
+	}
+	if len(partitions) != 2 {
+		t.Fatalf("GetPartitions = %d partitions, want 2: %+v", len(partitions), partitions)
+	}
+	for _, p := range partitions {
+		if p.Parent != "events" || p.IsDefault != (p.Name == "events_default") {
+			t.Errorf("partition %+v", p)
+		}
+	}
+
+	// GetStatStatements (the extension isn't installed in the test database)
+	stmts, err := inspector.GetStatStatements(ctx)
+	if err != nil {
+		t.Fatalf("GetStatStatements: %v", err)
+	}
+	if stmts != nil {
+		t.Errorf("GetStatStatements = %d statements, want none without the extension", len(stmts))
+	}
+
+	// GetDatabaseStats
+	db, err := inspector.GetDatabaseStats(ctx)
+	if err != nil {
+		t.Fatalf("GetDatabaseStats: %v", err)
+	}
+	if db.Name == "" || db.WorkMem == "" {
+		t.Errorf("GetDatabaseStats = %+v", db)
+	}
+
+	// GetInstanceStats
+	instance, err := inspector.GetInstanceStats(ctx)
+	if err != nil {
+		t.Fatalf("GetInstanceStats: %v", err)
+	}
+	if instance.CheckpointTimeoutSec <= 0 || instance.MaxWALSizeMB <= 0 || instance.CheckpointerSeconds <= 0 {
+		t.Errorf("GetInstanceStats = %+v", instance)
+	}
+
+	// GetRoles
+	roles, err := inspector.GetRoles(ctx)
+	if err != nil {
+		t.Fatalf("GetRoles: %v", err)
+	}
+	if !slices.ContainsFunc(roles, func(r postgres.RoleInfo) bool { return r.Superuser && r.CanLogin }) {
+		t.Errorf("GetRoles: no superuser login role in %+v", roles)
+	}
+
+	// GetPolicies
+	if _, err := inspector.GetPolicies(ctx); err != nil {
+		t.Fatalf("GetPolicies: %v", err)
+	}
+
+	// GetActivity
+	if _, err := inspector.GetActivity(ctx); err != nil {
+		t.Fatalf("GetActivity: %v", err)
+	}
+
+	// GetSettings
+	settings, err := inspector.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if len(settings) == 0 {
+		t.Error("GetSettings returned no settings")
+	}
+
+	// GetClusterHealth
+	if _, err := inspector.GetClusterHealth(ctx); err != nil {
+		t.Fatalf("GetClusterHealth: %v", err)
+	}
+
+	// GetRelations
+	relations, err := inspector.GetRelations(ctx)
+	if err != nil {
+		t.Fatalf("GetRelations: %v", err)
+	}
+	if !slices.ContainsFunc(relations, func(r postgres.RelationInfo) bool { return r.Kind == postgres.RelationTable }) {
+		t.Errorf("GetRelations: no tables in %+v", relations)
+	}
+
+	// CountTables
+	n, err := inspector.CountTables(ctx, nil)
+	if err != nil {
+		t.Fatalf("CountTables: %v", err)
+	}
+	if n < 3 {
+		t.Errorf("CountTables = %d, want >= 3", n)
+	}
+	n, err = inspector.CountTables(ctx, []string{"no_such_schema"})
+	if err != nil {
+		t.Fatalf("CountTables(no_such_schema): %v", err)
+	}
+	if n != 0 {
+		t.Errorf("CountTables(no_such_schema) = %d, want 0", n)
+	}
+
 	// Inspect (full snapshot)
 	snap, err := inspector.Inspect(ctx)
 	if err != nil {
@@ -177,11 +406,48 @@ func TestIntegration_Inspector(t *testing.T) {
 		len(snap.Tables), len(snap.Columns), len(snap.Indexes), len(snap.Stats), len(snap.Constraints))
 }
 
+func TestIntegration_Inspector_TableFilter(t *testing.T) {
+	connStr, cleanup := testutil.SetupPostgres(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	inspector, err := postgres.NewInspector(ctx, postgres.Config{URL: connStr, Tables: []string{"user*"}})
+	if err != nil {
+		t.Fatalf("NewInspector: %v", err)
+	}
+	defer inspector.Close()
+
+	snap, err := inspector.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(snap.Tables) == 0 {
+		t.Fatal("expected users table")
+	}
+	for _, tbl := range snap.Tables {
+		if tbl.Name != "users" {
+			t.Errorf("unexpected table %q with filter user*", tbl.Name)
+		}
+	}
+	for _, idx := range snap.Indexes {
+		if idx.Table != "users" {
+			t.Errorf("unexpected index on %q with filter user*", idx.Table)
+		}
+	}
+	for _, c := range snap.Constraints {
+		if c.Table != "users" {
+			t.Errorf("unexpected constraint on %q with filter user*", c.Table)
+		}
+	}
+}
+
 func TestIntegration_NewInspector_BadURL(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := NewInspector(ctx, Config{URL: "postgres://invalid:5432/nodb"})
+	_, err := postgres.NewInspector(ctx, postgres.Config{URL: "postgres://invalid:5432/nodb"})
 	if err == nil {
 		t.Error("expected error for bad URL")
 	}
@@ -230,7 +496,7 @@ func TestIntegration_Inspector_NonSuperuser(t *testing.T) {
 	parsed.User = url.UserPassword(roleName, rolePassword)
 	readerConnStr := parsed.String()
 
-	inspector, err := NewInspector(ctx, Config{URL: readerConnStr})
+	inspector, err := postgres.NewInspector(ctx, postgres.Config{URL: readerConnStr})
 	if err != nil {
 		t.Fatalf("NewInspector (non-superuser): %v", err)
 	}
@@ -253,6 +519,11 @@ func TestIntegration_Inspector_NonSuperuser(t *testing.T) {
 	if len(snap.Stats) == 0 {
 		t.Fatal("expected table stats for non-superuser")
 	}
+	if !slices.ContainsFunc(snap.Grants, func(g postgres.GrantInfo) bool {
+		return g.Grantee == roleName && g.Table == "users" && g.Privilege == "SELECT"
+	}) {
+		t.Errorf("expected SELECT grant on users to %s, got %+v", roleName, snap.Grants)
+	}
 }
 
 func quoteIdentifier(s string) string {
@@ -80,6 +80,14 @@ func TestTypeFieldsHaveJSONTags(t *testing.T) {
 		reflect.TypeOf(IndexInfo{}),
 		reflect.TypeOf(TableStats{}),
 		reflect.TypeOf(ConstraintInfo{}),
+		reflect.TypeOf(SequenceInfo{}),
+		reflect.TypeOf(ExtensionInfo{}),
+		reflect.TypeOf(ColumnStats{}),
+		reflect.TypeOf(MatViewInfo{}),
+		reflect.TypeOf(ViewInfo{}),
+		reflect.TypeOf(TriggerInfo{}),
+		reflect.TypeOf(PartitionedTableInfo{}),
+		reflect.TypeOf(PartitionInfo{}),
 		reflect.TypeOf(Snapshot{}),
 	}
 
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkpointerQuery and bgwriterQuery read checkpoint and background
+// writer counters. PostgreSQL 17 moved the checkpoint columns to pg_stat_checkpointer and
+// dropped buffers_backend, whose successor is pg_stat_io.
+const (
+	checkpointerQuery = `
+		SELECT
+			c.num_timed,
+			c.num_requested,
+			c.write_time,
+			c.sync_time,
+			c.buffers_written,
+			b.buffers_clean,
+			b.maxwritten_clean,
+			0,
+			EXTRACT(EPOCH FROM now() - COALESCE(c.stats_reset, pg_postmaster_start_time()))
+		FROM pg_catalog.pg_stat_checkpointer c, pg_catalog.pg_stat_bgwriter b`
+
+	bgwriterQuery = `
+		SELECT
+			checkpoints_timed,
+			checkpoints_req,
+			checkpoint_write_time,
+			checkpoint_sync_time,
+			buffers_checkpoint,
+			buffers_clean,
+			maxwritten_clean,
+			buffers_backend,
+			EXTRACT(EPOCH FROM now() - COALESCE(stats_reset, pg_postmaster_start_time()))
+		FROM pg_catalog.pg_stat_bgwriter`
+)
+
+// GetInstanceStats fetches checkpoint, background writer, and (on
+// PostgreSQL 14+) WAL generation counters with the settings that govern
+// checkpoint frequency.
+func (i *Inspector) GetInstanceStats(ctx context.Context) (*InstanceStats, error) {
+	var hasCheckpointer, hasWAL bool
+	var s InstanceStats
+	err := i.pool.QueryRow(ctx, `
+		SELECT
+			to_regclass('pg_catalog.pg_stat_checkpointer') IS NOT NULL,
+			to_regclass('pg_catalog.pg_stat_wal') IS NOT NULL,
+			(SELECT setting::bigint FROM pg_catalog.pg_settings WHERE name = 'checkpoint_timeout'),
+			(SELECT setting::bigint FROM pg_catalog.pg_settings WHERE name = 'max_wal_size')`,
+	).Scan(&hasCheckpointer, &hasWAL, &s.CheckpointTimeoutSec, &s.MaxWALSizeMB)
+	if err != nil {
+		return nil, fmt.Errorf("check instance stats: %w", err)
+	}
+
+	query := bgwriterQuery
+	if hasCheckpointer {
+		query = checkpointerQuery
+	}
+	err = i.pool.QueryRow(ctx, query).Scan(
+		&s.CheckpointsTimed, &s.CheckpointsRequested, &s.CheckpointWriteMs, &s.CheckpointSyncMs,
+		&s.BuffersCheckpoint, &s.BuffersClean, &s.MaxWrittenClean, &s.BuffersBackend, &s.CheckpointerSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("get checkpoint stats: %w", err)
+	}
+
+	if hasWAL {
+		err = i.pool.QueryRow(ctx, `
+			SELECT
+				wal_bytes::bigint,
+				EXTRACT(EPOCH FROM now() - COALESCE(stats_reset, pg_postmaster_start_time()))
+			FROM pg_catalog.pg_stat_wal`).Scan(&s.WALBytes, &s.WALSeconds)
+		if err != nil {
+			return nil, fmt.Errorf("get wal stats: %w", err)
+		}
+	}
+	return &s, nil
+}
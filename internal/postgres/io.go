@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetIOStats fetches pg_stat_io. It returns nil on servers older than
+// PostgreSQL 16, which don't have the view.
+func (i *Inspector) GetIOStats(ctx context.Context) ([]IOStats, error) {
+	var exists bool
+	if err := i.pool.QueryRow(ctx, "SELECT to_regclass('pg_catalog.pg_stat_io') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check pg_stat_io: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	query := `
+		SELECT
+			backend_type,
+			object,
+			context,
+			COALESCE(reads, 0),
+			COALESCE(hits, 0),
+			COALESCE(evictions, 0),
+			COALESCE(writes, 0)
+		FROM pg_catalog.pg_stat_io
+		ORDER BY backend_type, object, context`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get io stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []IOStats
+	for rows.Next() {
+		var s IOStats
+		if err := rows.Scan(&s.BackendType, &s.Object, &s.Context, &s.Reads, &s.Hits, &s.Evictions, &s.Writes); err != nil {
+			return nil, fmt.Errorf("scan io stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
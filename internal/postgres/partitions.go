@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetPartitionedTables fetches declaratively partitioned tables with their
+// partition key.
+func (i *Inspector) GetPartitionedTables(ctx context.Context) ([]PartitionedTableInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			pg_catalog.pg_get_partkeydef(c.oid) AS key_definition,
+			COALESCE(
+				(SELECT array_agg(a.attname ORDER BY k.ord)
+				FROM unnest(pt.partattrs::int2[]) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_catalog.pg_attribute a
+					ON a.attrelid = c.oid AND a.attnum = k.attnum),
+				'{}'
+			) AS key_columns
+		FROM pg_catalog.pg_partitioned_table pt
+		JOIN pg_catalog.pg_class c ON c.oid = pt.partrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get partitioned tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []PartitionedTableInfo
+	for rows.Next() {
+		var t PartitionedTableInfo
+		if err := rows.Scan(&t.Schema, &t.Name, &t.KeyDefinition, &t.KeyColumns); err != nil {
+			return nil, fmt.Errorf("scan partitioned table: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// GetPartitions fetches the partitions of declaratively partitioned tables
+// (pg_inherits), including those that are themselves partitioned.
+func (i *Inspector) GetPartitions(ctx context.Context) ([]PartitionInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			pn.nspname,
+			p.relname,
+			COALESCE(pg_catalog.pg_get_expr(c.relpartbound, c.oid), '') AS bound,
+			c.relkind = 'p' AS is_partitioned
+		FROM pg_catalog.pg_inherits inh
+		JOIN pg_catalog.pg_class c ON c.oid = inh.inhrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_class p ON p.oid = inh.inhparent
+		JOIN pg_catalog.pg_namespace pn ON pn.oid = p.relnamespace
+		WHERE p.relkind = 'p'
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []PartitionInfo
+	for rows.Next() {
+		var p PartitionInfo
+		if err := rows.Scan(&p.Schema, &p.Name, &p.ParentSchema, &p.Parent, &p.Bound, &p.IsPartitioned); err != nil {
+			return nil, fmt.Errorf("scan partition: %w", err)
+		}
+		p.IsDefault = p.Bound == PartitionBoundDefault
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
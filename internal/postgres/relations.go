@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// Relation kinds reported by GetRelations.
+const (
+	RelationTable            = "table"
+	RelationPartitionedTable = "partitioned table"
+	RelationView             = "view"
+	RelationMatView          = "materialized view"
+	RelationForeignTable     = "foreign table"
+)
+
+// GetRelations fetches every relation code can query by name: tables,
+// partitioned tables, views, materialized views, and foreign tables.
+func (i *Inspector) GetRelations(ctx context.Context) ([]RelationInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			CASE c.relkind
+				WHEN 'r' THEN 'table'
+				WHEN 'p' THEN 'partitioned table'
+				WHEN 'v' THEN 'view'
+				WHEN 'm' THEN 'materialized view'
+				WHEN 'f' THEN 'foreign table'
+			END AS kind
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND c.relpersistence <> 't'
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get relations: %w", err)
+	}
+	defer rows.Close()
+
+	var relations []RelationInfo
+	for rows.Next() {
+		var r RelationInfo
+		if err := rows.Scan(&r.Schema, &r.Name, &r.Kind); err != nil {
+			return nil, fmt.Errorf("scan relation: %w", err)
+		}
+		relations = append(relations, r)
+	}
+	return relations, rows.Err()
+}
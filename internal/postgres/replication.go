@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClusterHealthSource is implemented by catalog sources that can read the
+// server's replication and WAL archiving state. *Inspector does; recorded
+// catalogs can't.
+type ClusterHealthSource interface {
+	GetClusterHealth(ctx context.Context) (*ClusterHealth, error)
+}
+
+var _ ClusterHealthSource = (*Inspector)(nil)
+
+// GetClusterHealth reads replication slots, connected standbys, and
+// archiver counters. WAL positions are compared on the server against the
+// current WAL location, or the replayed location on a standby. Replica
+// lag needs pg_read_all_stats or superuser; without it, PostgreSQL hides
+// the LSN columns and lag reads as zero.
+func (i *Inspector) GetClusterHealth(ctx context.Context) (*ClusterHealth, error) {
+	var h ClusterHealth
+	if err := i.pool.QueryRow(ctx, "SELECT pg_catalog.pg_is_in_recovery()").Scan(&h.InRecovery); err != nil {
+		return nil, fmt.Errorf("check recovery: %w", err)
+	}
+	var err error
+	if h.Slots, err = i.getReplicationSlots(ctx); err != nil {
+		return nil, err
+	}
+	if h.Replicas, err = i.getReplicas(ctx); err != nil {
+		return nil, err
+	}
+	if h.Archiver, err = i.getArchiver(ctx); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// getReplicationSlots reads pg_replication_slots. wal_status (PostgreSQL
+// 13+) is read through to_jsonb so older servers report it empty.
+func (i *Inspector) getReplicationSlots(ctx context.Context) ([]ReplicationSlot, error) {
+	query := `
+		SELECT
+			s.slot_name,
+			s.slot_type,
+			COALESCE(s.database, ''),
+			s.active,
+			COALESCE(pg_catalog.pg_wal_lsn_diff(
+				CASE WHEN pg_catalog.pg_is_in_recovery()
+					THEN pg_catalog.pg_last_wal_replay_lsn()
+					ELSE pg_catalog.pg_current_wal_lsn() END,
+				s.restart_lsn), 0)::bigint,
+			COALESCE(to_jsonb(s) ->> 'wal_status', '')
+		FROM pg_catalog.pg_replication_slots s
+		ORDER BY s.slot_name`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get replication slots: %w", err)
+	}
+	defer rows.Close()
+
+	var slots []ReplicationSlot
+	for rows.Next() {
+		var s ReplicationSlot
+		if err := rows.Scan(&s.Name, &s.Type, &s.Database, &s.Active, &s.RetainedBytes, &s.WALStatus); err != nil {
+			return nil, fmt.Errorf("scan replication slot: %w", err)
+		}
+		slots = append(slots, s)
+	}
+	return slots, rows.Err()
+}
+
+// getReplicas reads pg_stat_replication: the standbys and logical
+// subscribers streaming from this server.
+func (i *Inspector) getReplicas(ctx context.Context) ([]ReplicaInfo, error) {
+	query := `
+		SELECT
+			COALESCE(r.application_name, ''),
+			COALESCE(host(r.client_addr), ''),
+			COALESCE(r.state, ''),
+			COALESCE(r.sync_state, ''),
+			COALESCE(pg_catalog.pg_wal_lsn_diff(
+				CASE WHEN pg_catalog.pg_is_in_recovery()
+					THEN pg_catalog.pg_last_wal_replay_lsn()
+					ELSE pg_catalog.pg_current_wal_lsn() END,
+				r.replay_lsn), 0)::bigint,
+			COALESCE(EXTRACT(EPOCH FROM r.replay_lag), 0)::float8
+		FROM pg_catalog.pg_stat_replication r
+		ORDER BY r.application_name, r.pid`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get replicas: %w", err)
+	}
+	defer rows.Close()
+
+	var replicas []ReplicaInfo
+	for rows.Next() {
+		var r ReplicaInfo
+		if err := rows.Scan(&r.Application, &r.ClientAddr, &r.State, &r.SyncState, &r.LagBytes, &r.ReplayLagSeconds); err != nil {
+			return nil, fmt.Errorf("scan replica: %w", err)
+		}
+		replicas = append(replicas, r)
+	}
+	return replicas, rows.Err()
+}
+
+// getArchiver reads pg_stat_archiver. It returns nil when archive_mode is
+// off, since the counters then mean nothing.
+func (i *Inspector) getArchiver(ctx context.Context) (*ArchiverStats, error) {
+	var a ArchiverStats
+	err := i.pool.QueryRow(ctx, `
+		SELECT
+			current_setting('archive_mode'),
+			archived_count,
+			failed_count,
+			COALESCE(last_failed_wal, ''),
+			EXTRACT(EPOCH FROM now() - last_archived_time)::float8,
+			EXTRACT(EPOCH FROM now() - last_failed_time)::float8
+		FROM pg_catalog.pg_stat_archiver`,
+	).Scan(&a.ArchiveMode, &a.ArchivedCount, &a.FailedCount, &a.LastFailedWAL, &a.LastArchivedSeconds, &a.LastFailedSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("get archiver stats: %w", err)
+	}
+	if a.ArchiveMode == "off" {
+		return nil, nil
+	}
+	return &a, nil
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SampleNullFraction measures the fraction of NULLs in a column from a
+// TABLESAMPLE SYSTEM sample of percent of the table's pages. Tables too
+// small to yield a sample are counted in full. pg_stats can lag a running
+// backfill by a whole autoanalyze cycle; this reads the current data.
+func (i *Inspector) SampleNullFraction(ctx context.Context, schema, table, column string, percent float64) (float64, error) {
+	rel := pgx.Identifier{schema, table}.Sanitize()
+	col := pgx.Identifier{column}.Sanitize()
+
+	var total, nulls int64
+	query := fmt.Sprintf("SELECT count(*), count(*) FILTER (WHERE %s IS NULL) FROM %s TABLESAMPLE SYSTEM ($1)", col, rel)
+	if err := i.pool.QueryRow(ctx, query, percent).Scan(&total, &nulls); err != nil {
+		return 0, fmt.Errorf("sample %s.%s: %w", table, column, err)
+	}
+	if total == 0 {
+		query = fmt.Sprintf("SELECT count(*), count(*) FILTER (WHERE %s IS NULL) FROM %s", col, rel)
+		if err := i.pool.QueryRow(ctx, query).Scan(&total, &nulls); err != nil {
+			return 0, fmt.Errorf("count %s.%s: %w", table, column, err)
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(nulls) / float64(total), nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// auditedSettings are the pg_settings GetSettings reads.
+var auditedSettings = []string{
+	"autovacuum",
+	"fsync",
+	"full_page_writes",
+	"shared_buffers",
+	"track_counts",
+	"zero_damaged_pages",
+}
+
+// GetSettings fetches the server settings that the configuration audit
+// checks, with where each value came from.
+func (i *Inspector) GetSettings(ctx context.Context) ([]SettingInfo, error) {
+	query := `
+		SELECT name, setting, COALESCE(unit, ''), source
+		FROM pg_catalog.pg_settings
+		WHERE name = ANY($1)
+		ORDER BY name`
+
+	rows, err := i.pool.Query(ctx, query, auditedSettings)
+	if err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []SettingInfo
+	for rows.Next() {
+		var s SettingInfo
+		if err := rows.Scan(&s.Name, &s.Setting, &s.Unit, &s.Source); err != nil {
+			return nil, fmt.Errorf("scan setting: %w", err)
+		}
+		settings = append(settings, s)
+	}
+	return settings, rows.Err()
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CatalogSource supplies catalog snapshots and the few live queries run
+// around them. *Inspector reads them from PostgreSQL; tests and embedders
+// can supply their own, such as StaticSource.
+type CatalogSource interface {
+	ServerVersion(ctx context.Context) (string, error)
+	CountTables(ctx context.Context, schemas []string) (int, error)
+	Inspect(ctx context.Context) (*Snapshot, error)
+	SampleNullFraction(ctx context.Context, schema, table, column string, percent float64) (float64, error)
+	Close()
+}
+
+var _ CatalogSource = (*Inspector)(nil)
+
+// StaticSource serves a fixed snapshot without a database.
+type StaticSource struct {
+	Snapshot *Snapshot
+	Version  string // server_version, e.g. "16.2"
+	// NullFractions answers SampleNullFraction, keyed by schema.table.column.
+	NullFractions map[string]float64
+}
+
+// ServerVersion returns the configured version.
+func (s *StaticSource) ServerVersion(ctx context.Context) (string, error) {
+	return s.Version, nil
+}
+
+// CountTables counts the snapshot's tables in schemas, or in all schemas
+// when schemas is empty.
+func (s *StaticSource) CountTables(ctx context.Context, schemas []string) (int, error) {
+	if s.Snapshot == nil {
+		return 0, nil
+	}
+	n := 0
+	for _, t := range s.Snapshot.Tables {
+		if len(schemas) == 0 || containsFold(schemas, t.Schema) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Inspect returns the snapshot. Callers may modify it, as they would a
+// freshly inspected one.
+func (s *StaticSource) Inspect(ctx context.Context) (*Snapshot, error) {
+	if s.Snapshot == nil {
+		return &Snapshot{}, nil
+	}
+	return s.Snapshot, nil
+}
+
+// SampleNullFraction looks the column up in NullFractions.
+func (s *StaticSource) SampleNullFraction(ctx context.Context, schema, table, column string, percent float64) (float64, error) {
+	frac, ok := s.NullFractions[schema+"."+table+"."+column]
+	if !ok {
+		return 0, fmt.Errorf("sample %s.%s.%s: no sample recorded", schema, table, column)
+	}
+	return frac, nil
+}
+
+// Close does nothing.
+func (s *StaticSource) Close() {}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// statStatementsLimit caps how many statements are fetched, by total
+// execution time.
+const statStatementsLimit = 500
+
+// GetStatStatements fetches the most expensive statements from
+// pg_stat_statements. It returns nil when the extension isn't installed
+// in the search path or its library isn't preloaded.
+func (i *Inspector) GetStatStatements(ctx context.Context) ([]StatStatement, error) {
+	// total_time was split into plan and exec time in PostgreSQL 13.
+	var exists, execTime bool
+	err := i.pool.QueryRow(ctx, `
+		SELECT
+			to_regclass('pg_stat_statements') IS NOT NULL,
+			EXISTS (
+				SELECT 1 FROM pg_catalog.pg_attribute
+				WHERE attrelid = to_regclass('pg_stat_statements')
+				  AND attname = 'total_exec_time'
+			)`).Scan(&exists, &execTime)
+	if err != nil {
+		return nil, fmt.Errorf("check pg_stat_statements: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	timeCol := "total_time"
+	if execTime {
+		timeCol = "total_exec_time"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(queryid, 0),
+			query,
+			calls,
+			%[1]s,
+			rows,
+			shared_blks_hit,
+			shared_blks_read,
+			temp_blks_read,
+			temp_blks_written
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database())
+		  AND query <> '<insufficient privilege>'
+		ORDER BY %[1]s DESC
+		LIMIT %[2]d`, timeCol, statStatementsLimit)
+
+	rows, err := i.pool.Query(ctx, query)
+	if notPreloaded(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get stat statements: %w", err)
+	}
+	defer rows.Close()
+
+	var stmts []StatStatement
+	for rows.Next() {
+		var s StatStatement
+		if err := rows.Scan(&s.QueryID, &s.Query, &s.Calls, &s.TotalExecTime, &s.Rows, &s.SharedBlksHit, &s.SharedBlksRead, &s.TempBlksRead, &s.TempBlksWritten); err != nil {
+			return nil, fmt.Errorf("scan stat statements: %w", err)
+		}
+		stmts = append(stmts, s)
+	}
+	if err := rows.Err(); err != nil {
+		if notPreloaded(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get stat statements: %w", err)
+	}
+	return stmts, nil
+}
+
+// notPreloaded reports whether err is pg_stat_statements refusing to run
+// because it isn't in shared_preload_libraries.
+func notPreloaded(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "55000"
+}
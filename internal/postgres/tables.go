@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ResolveTables normalizes table glob patterns. Only '*' and '?' are
+// wildcards. Empty input means all tables.
+func ResolveTables(patterns []string) []string {
+	var result []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// TableMatcher reports whether a table name matches any glob pattern.
+// Matching is case-insensitive; a nil matcher matches everything.
+type TableMatcher struct {
+	res []*regexp.Regexp
+}
+
+// NewTableMatcher compiles glob patterns. It returns nil when patterns is
+// empty so that callers can skip filtering.
+func NewTableMatcher(patterns []string) *TableMatcher {
+	if len(patterns) == 0 {
+		return nil
+	}
+	m := &TableMatcher{}
+	for _, p := range patterns {
+		var b strings.Builder
+		b.WriteString("(?i)^")
+		for _, r := range p {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		m.res = append(m.res, regexp.MustCompile(b.String()))
+	}
+	return m
+}
+
+// Match reports whether name matches any pattern.
+func (m *TableMatcher) Match(name string) bool {
+	if m == nil {
+		return true
+	}
+	for _, re := range m.res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// likePatterns converts table globs to ILIKE patterns for catalog queries.
+// The result is never nil so it can be bound as a text[] parameter.
+func likePatterns(globs []string) []string {
+	result := make([]string, 0, len(globs))
+	for _, g := range globs {
+		var b strings.Builder
+		for _, r := range g {
+			switch r {
+			case '*':
+				b.WriteByte('%')
+			case '?':
+				b.WriteByte('_')
+			case '%', '_', '\\':
+				b.WriteByte('\\')
+				b.WriteRune(r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		result = append(result, b.String())
+	}
+	return result
+}
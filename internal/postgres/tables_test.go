@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveTables(t *testing.T) {
+	got := ResolveTables([]string{" orders* ", "", "payments"})
+	want := []string{"orders*", "payments"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveTables = %v, want %v", got, want)
+	}
+	if ResolveTables(nil) != nil {
+		t.Error("expected nil for empty input")
+	}
+}
+
+func TestTableMatcher(t *testing.T) {
+	m := NewTableMatcher([]string{"orders*", "payments", "log_?"})
+	tests := map[string]bool{
+		"orders":       true,
+		"orders_2024":  true,
+		"Orders_Items": true,
+		"payments":     true,
+		"payments_old": false,
+		"log_a":        true,
+		"log_ab":       false,
+		"users":        false,
+	}
+	for name, want := range tests {
+		if got := m.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestTableMatcher_NilMatchesAll(t *testing.T) {
+	m := NewTableMatcher(nil)
+	if m != nil {
+		t.Fatal("expected nil matcher for no patterns")
+	}
+	if !m.Match("anything") {
+		t.Error("nil matcher should match everything")
+	}
+}
+
+func TestTableMatcher_RegexMetaIsLiteral(t *testing.T) {
+	m := NewTableMatcher([]string{"a.b"})
+	if m.Match("axb") {
+		t.Error("'.' should be literal")
+	}
+	if !m.Match("a.b") {
+		t.Error("expected literal match")
+	}
+}
+
+func TestLikePatterns(t *testing.T) {
+	got := likePatterns([]string{"orders*", "log_?", "100%"})
+	want := []string{`orders%`, `log\__`, `100\%`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("likePatterns = %v, want %v", got, want)
+	}
+	if likePatterns(nil) == nil {
+		t.Error("expected non-nil slice")
+	}
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetTriggers fetches user-defined triggers with their function source.
+// Internal triggers, such as those implementing foreign keys, are omitted.
+func (i *Inspector) GetTriggers(ctx context.Context) ([]TriggerInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			t.tgname,
+			t.tgenabled::text,
+			pn.nspname || '.' || p.proname AS function,
+			l.lanname,
+			COALESCE(p.prosrc, '') AS body,
+			pg_catalog.pg_get_triggerdef(t.oid) AS definition
+		FROM pg_catalog.pg_trigger t
+		JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_proc p ON p.oid = t.tgfoid
+		JOIN pg_catalog.pg_namespace pn ON pn.oid = p.pronamespace
+		JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+		WHERE NOT t.tgisinternal
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname, t.tgname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []TriggerInfo
+	for rows.Next() {
+		var tr TriggerInfo
+		if err := rows.Scan(&tr.Schema, &tr.Table, &tr.Name, &tr.Enabled, &tr.Function, &tr.Language, &tr.Body, &tr.Definition); err != nil {
+			return nil, fmt.Errorf("scan trigger: %w", err)
+		}
+		triggers = append(triggers, tr)
+	}
+	return triggers, rows.Err()
+}
@@ -5,15 +5,33 @@ import "time"
 // Config holds PostgreSQL connection settings.
 type Config struct {
 	URL string
+	// Tables restricts catalog queries to tables matching these globs
+	// ('*' and '?' wildcards). Empty means all tables.
+	Tables []string
+	// SkipViewCheck leaves out the EXPLAIN of candidate views that finds
+	// broken views; set it when BROKEN_VIEW isn't reported.
+	SkipViewCheck bool
+	// SkipGrants, SkipRoles and SkipPolicies leave out the privilege and
+	// row-security catalog queries, which need more than read access to
+	// the application schema; set them when no check reads the result.
+	SkipGrants   bool
+	SkipRoles    bool
+	SkipPolicies bool
 }
 
 // TableInfo describes a table from information_schema + pg_class.
 type TableInfo struct {
-	Schema        string `json:"schema"`
-	Name          string `json:"name"`
-	Type          string `json:"type"`          // BASE TABLE, VIEW, etc.
-	EstimatedRows int64  `json:"estimatedRows"` // from pg_class.reltuples
-	SizeBytes     int64  `json:"sizeBytes"`     // from pg_total_relation_size
+	Schema           string   `json:"schema"`
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`                       // BASE TABLE, VIEW, etc.
+	EstimatedRows    int64    `json:"estimatedRows"`              // from pg_class.reltuples
+	SizeBytes        int64    `json:"sizeBytes"`                  // from pg_total_relation_size
+	Persistence      string   `json:"persistence,omitempty"`      // pg_class.relpersistence: p=permanent, u=unlogged, t=temporary
+	Options          []string `json:"options,omitempty"`          // pg_class.reloptions, e.g. autovacuum_enabled=false
+	ToastBytes       int64    `json:"toastBytes,omitempty"`       // TOAST relation and its index, included in SizeBytes
+	AvgRowWidth      int64    `json:"avgRowWidth,omitempty"`      // sum of pg_stats.avg_width; 0 if never analyzed
+	RowSecurity      bool     `json:"rowSecurity,omitempty"`      // pg_class.relrowsecurity
+	ForceRowSecurity bool     `json:"forceRowSecurity,omitempty"` // pg_class.relforcerowsecurity: policies apply to the owner too
 }
 
 // ColumnInfo describes a table column.
@@ -37,6 +55,11 @@ type IndexInfo struct {
 	IndexScans int64  `json:"indexScans"`
 	TupRead    int64  `json:"tupRead"`
 	TupFetch   int64  `json:"tupFetch"`
+	IsValid    bool   `json:"isValid"`  // from pg_index.indisvalid
+	IsUnique   bool   `json:"isUnique"` // from pg_index.indisunique
+	// Constraint names the PRIMARY KEY, UNIQUE, or exclusion constraint the
+	// index backs, if any; such an index can't be dropped on its own.
+	Constraint string `json:"constraint,omitempty"`
 }
 
 // TableStats holds usage statistics from pg_stat_user_tables.
@@ -47,6 +70,14 @@ type TableStats struct {
 	SeqTupRead       int64      `json:"seqTupRead"`
 	IdxScan          int64      `json:"idxScan"`
 	IdxTupFetch      int64      `json:"idxTupFetch"`
+	TupInserted      int64      `json:"tupInserted"`
+	TupUpdated       int64      `json:"tupUpdated"`
+	TupHotUpdated    int64      `json:"tupHotUpdated"`
+	TupDeleted       int64      `json:"tupDeleted"`
+	HeapBlksRead     int64      `json:"heapBlksRead"` // pg_statio_user_tables: blocks read from disk
+	HeapBlksHit      int64      `json:"heapBlksHit"`  // pg_statio_user_tables: blocks found in shared buffers
+	IdxBlksRead      int64      `json:"idxBlksRead"`  // same, for all the table's indexes
+	IdxBlksHit       int64      `json:"idxBlksHit"`
 	LiveTuples       int64      `json:"liveTuples"`
 	DeadTuples       int64      `json:"deadTuples"`
 	LastVacuum       *time.Time `json:"lastVacuum,omitempty"`
@@ -66,15 +97,319 @@ type ConstraintInfo struct {
 	Name       string   `json:"name"`
 	Type       string   `json:"type"` // p=primary key, u=unique, f=foreign key, c=check
 	Columns    []string `json:"columns"`
+	RefSchema  *string  `json:"refSchema,omitempty"`
 	RefTable   *string  `json:"refTable,omitempty"`
 	RefColumns []string `json:"refColumns,omitempty"`
+	Definition string   `json:"definition,omitempty"` // pg_get_constraintdef
+	Validated  bool     `json:"validated"`            // false for constraints added NOT VALID
+}
+
+// ColumnStats holds planner statistics for a column from pg_stats.
+type ColumnStats struct {
+	Schema    string  `json:"schema"`
+	Table     string  `json:"table"`
+	Column    string  `json:"column"`
+	NullFrac  float64 `json:"nullFrac"`           // fraction of sampled rows that are NULL
+	NDistinct float64 `json:"nDistinct"`          // >0: distinct count; <0: negated fraction of rows
+	AvgWidth  int64   `json:"avgWidth,omitempty"` // average stored width in bytes
+	Sampled   bool    `json:"sampled,omitempty"`  // NullFrac measured by sampling the table, not from pg_stats
+}
+
+// SequenceInfo describes a sequence and the column that owns it, if any.
+type SequenceInfo struct {
+	Schema     string `json:"schema"`
+	Name       string `json:"name"`
+	DataType   string `json:"dataType"`             // sequence type: smallint, integer, bigint
+	LastValue  int64  `json:"lastValue"`            // 0 if never called or not readable
+	MinValue   int64  `json:"minValue"`             // sequence MINVALUE
+	MaxValue   int64  `json:"maxValue"`             // sequence MAXVALUE
+	Increment  int64  `json:"increment,omitempty"`  // INCREMENT BY; negative counts down, 0 if unknown
+	Cycle      bool   `json:"cycle,omitempty"`      // wraps around at the limit instead of failing
+	Table      string `json:"table,omitempty"`      // owning table (serial/identity)
+	Column     string `json:"column,omitempty"`     // owning column
+	ColumnType string `json:"columnType,omitempty"` // owning column type, may be narrower than the sequence
+}
+
+// IOStats is one row of pg_stat_io (PostgreSQL 16+): I/O by one kind of
+// backend on one kind of object in one context.
+type IOStats struct {
+	BackendType string `json:"backendType"` // e.g. client backend, autovacuum worker
+	Object      string `json:"object"`      // relation or temp relation
+	Context     string `json:"context"`     // normal, vacuum, bulkread, bulkwrite
+	Reads       int64  `json:"reads"`
+	Hits        int64  `json:"hits"`
+	Evictions   int64  `json:"evictions"`
+	Writes      int64  `json:"writes"`
+}
+
+// StatStatement is one row of pg_stat_statements: a normalized query and
+// its cumulative execution counters.
+type StatStatement struct {
+	QueryID         int64   `json:"queryId"`
+	Query           string  `json:"query"`
+	Calls           int64   `json:"calls"`
+	TotalExecTime   float64 `json:"totalExecTime"` // milliseconds
+	Rows            int64   `json:"rows"`
+	SharedBlksHit   int64   `json:"sharedBlksHit"`
+	SharedBlksRead  int64   `json:"sharedBlksRead"`
+	TempBlksRead    int64   `json:"tempBlksRead,omitempty"`
+	TempBlksWritten int64   `json:"tempBlksWritten,omitempty"`
+}
+
+// DatabaseStats is the pg_stat_database row of the current database.
+type DatabaseStats struct {
+	Name       string     `json:"name"`
+	TempFiles  int64      `json:"tempFiles"`
+	TempBytes  int64      `json:"tempBytes"`
+	WorkMem    string     `json:"workMem"`             // current_setting('work_mem'), e.g. 4MB
+	SizeBytes  int64      `json:"sizeBytes,omitempty"` // pg_database_size
+	StatsReset *time.Time `json:"statsReset,omitempty"`
+	// PostmasterStart is when the server started. Statistics don't
+	// survive a crash, so usage counters cover at most StatsWindowSeconds,
+	// the time since the later of the two.
+	PostmasterStart    *time.Time `json:"postmasterStart,omitempty"`
+	StatsWindowSeconds float64    `json:"statsWindowSeconds,omitempty"`
+}
+
+// InstanceStats holds server-wide checkpoint, background writer, and WAL
+// activity. Counters are cumulative over the seconds since their stats
+// were reset, or since the server started if they never were.
+type InstanceStats struct {
+	CheckpointsTimed     int64   `json:"checkpointsTimed"`
+	CheckpointsRequested int64   `json:"checkpointsRequested"`
+	CheckpointWriteMs    float64 `json:"checkpointWriteMs"`
+	CheckpointSyncMs     float64 `json:"checkpointSyncMs"`
+	BuffersCheckpoint    int64   `json:"buffersCheckpoint"`
+	BuffersClean         int64   `json:"buffersClean"`
+	MaxWrittenClean      int64   `json:"maxWrittenClean"`
+	BuffersBackend       int64   `json:"buffersBackend,omitempty"` // before PostgreSQL 17; see IO after
+	CheckpointerSeconds  float64 `json:"checkpointerSeconds"`
+	WALBytes             int64   `json:"walBytes,omitempty"` // PostgreSQL 14+
+	WALSeconds           float64 `json:"walSeconds,omitempty"`
+	CheckpointTimeoutSec int64   `json:"checkpointTimeoutSec"`
+	MaxWALSizeMB         int64   `json:"maxWalSizeMB"`
+}
+
+// GrantInfo is one privilege on a table granted to a role other than its
+// owner.
+type GrantInfo struct {
+	Schema    string `json:"schema"`
+	Table     string `json:"table"`
+	Grantee   string `json:"grantee"`   // role name, or PUBLIC
+	Privilege string `json:"privilege"` // SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER
+}
+
+// PolicyInfo describes a row-level security policy.
+type PolicyInfo struct {
+	Schema     string   `json:"schema"`
+	Table      string   `json:"table"`
+	Name       string   `json:"name"`
+	Command    string   `json:"command"` // ALL, SELECT, INSERT, UPDATE, DELETE
+	Permissive bool     `json:"permissive"`
+	Roles      []string `json:"roles"` // "public" when the policy applies to all roles
+}
+
+// SessionInfo is a client session from pg_stat_activity.
+type SessionInfo struct {
+	PID           int32   `json:"pid"`
+	User          string  `json:"user"`
+	Application   string  `json:"application,omitempty"`
+	ClientAddr    string  `json:"clientAddr,omitempty"`
+	State         string  `json:"state"`                   // active, idle, idle in transaction, ...
+	XactSeconds   float64 `json:"xactSeconds"`             // age of the open transaction; 0 when none
+	StateSeconds  float64 `json:"stateSeconds"`            // time in the current state
+	WaitEventType string  `json:"waitEventType,omitempty"` // Lock when waiting on a heavyweight lock
+	Query         string  `json:"query"`                   // current or, when idle, last statement
+	BlockedBy     []int32 `json:"blockedBy,omitempty"`     // pg_blocking_pids
+	WaitSchema    string  `json:"waitSchema,omitempty"`    // relation whose lock is awaited
+	WaitTable     string  `json:"waitTable,omitempty"`
+}
+
+// RelationInfo is a relation of any kind code can query: a table, view,
+// materialized view, or foreign table.
+type RelationInfo struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"` // RelationTable, RelationView, ...
+}
+
+// SettingInfo is a server setting from pg_settings. Setting is in the
+// setting's base unit, such as 8kB blocks for shared_buffers.
+type SettingInfo struct {
+	Name    string `json:"name"`
+	Setting string `json:"setting"`
+	Unit    string `json:"unit,omitempty"`
+	Source  string `json:"source"` // default, configuration file, command line, ...
+}
+
+// ClusterHealth is the server's replication and WAL archiving state.
+type ClusterHealth struct {
+	InRecovery bool              `json:"inRecovery"` // the server is a standby
+	Slots      []ReplicationSlot `json:"slots,omitempty"`
+	Replicas   []ReplicaInfo     `json:"replicas,omitempty"`
+	Archiver   *ArchiverStats    `json:"archiver,omitempty"` // nil when archive_mode is off
+}
+
+// ReplicationSlot is a row of pg_replication_slots.
+type ReplicationSlot struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`               // physical or logical
+	Database      string `json:"database,omitempty"` // logical slots only
+	Active        bool   `json:"active"`
+	RetainedBytes int64  `json:"retainedBytes"`       // WAL kept back from restart_lsn
+	WALStatus     string `json:"walStatus,omitempty"` // reserved, extended, unreserved, lost; PostgreSQL 13+
+}
+
+// ReplicaInfo is a standby or subscriber streaming from the server, from
+// pg_stat_replication.
+type ReplicaInfo struct {
+	Application      string  `json:"application"`
+	ClientAddr       string  `json:"clientAddr,omitempty"`
+	State            string  `json:"state"`     // streaming, catchup, ...
+	SyncState        string  `json:"syncState"` // async, sync, potential, quorum
+	LagBytes         int64   `json:"lagBytes"`  // WAL not yet replayed
+	ReplayLagSeconds float64 `json:"replayLagSeconds"`
+}
+
+// ArchiverStats is pg_stat_archiver. The ages are seconds since the last
+// success or failure, nil when there hasn't been one.
+type ArchiverStats struct {
+	ArchiveMode         string   `json:"archiveMode"`
+	ArchivedCount       int64    `json:"archivedCount"`
+	FailedCount         int64    `json:"failedCount"`
+	LastFailedWAL       string   `json:"lastFailedWal,omitempty"`
+	LastArchivedSeconds *float64 `json:"lastArchivedSeconds,omitempty"`
+	LastFailedSeconds   *float64 `json:"lastFailedSeconds,omitempty"`
+}
+
+// RoleInfo describes a role and its current client sessions.
+type RoleInfo struct {
+	Name         string   `json:"name"`
+	Superuser    bool     `json:"superuser"`
+	CanLogin     bool     `json:"canLogin"`
+	Connections  int      `json:"connections"`            // client sessions, not counting pgspectre's own
+	Applications []string `json:"applications,omitempty"` // distinct application_name of those sessions
+}
+
+// ExtensionInfo describes an installed extension.
+type ExtensionInfo struct {
+	Schema  string `json:"schema"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// MatViewInfo describes a materialized view and its activity since the
+// last statistics reset. PostgreSQL doesn't record refresh times, so
+// LastRefresh is estimated from the latest analyze, which autovacuum runs
+// after a refresh rewrites enough rows.
+type MatViewInfo struct {
+	Schema      string     `json:"schema"`
+	Name        string     `json:"name"`
+	Populated   bool       `json:"populated"` // pg_matviews.ispopulated; false after WITH NO DATA
+	SizeBytes   int64      `json:"sizeBytes"`
+	SeqScan     int64      `json:"seqScan"`
+	IdxScan     int64      `json:"idxScan"`
+	RowsWritten int64      `json:"rowsWritten"` // n_tup_ins + n_tup_del, written by refreshes
+	LastRefresh *time.Time `json:"lastRefresh,omitempty"`
+}
+
+// ViewInfo describes a view and the relations it reads.
+type ViewInfo struct {
+	Schema     string   `json:"schema"`
+	Name       string   `json:"name"`
+	Definition string   `json:"definition"`
+	DependsOn  []string `json:"dependsOn,omitempty"` // schema.name of tables, views, and matviews it reads
+	Error      string   `json:"error,omitempty"`     // planner error if the view reads a missing object
+}
+
+// Trigger enabled states from pg_trigger.tgenabled.
+const (
+	TriggerEnabled  = "O" // fires in origin and local sessions
+	TriggerDisabled = "D"
+	TriggerReplica  = "R" // fires only in replica sessions
+	TriggerAlways   = "A"
+)
+
+// TriggerInfo describes a user-defined trigger and its function.
+type TriggerInfo struct {
+	Schema     string `json:"schema"`
+	Table      string `json:"table"`
+	Name       string `json:"name"`
+	Enabled    string `json:"enabled"`  // one of the Trigger* states
+	Function   string `json:"function"` // schema.name of the trigger function
+	Language   string `json:"language"` // plpgsql, sql, c, ...
+	Body       string `json:"body,omitempty"`
+	Definition string `json:"definition"` // pg_get_triggerdef
+}
+
+// Function kinds from pg_proc.prokind.
+const (
+	FunctionKindFunction  = "f"
+	FunctionKindProcedure = "p"
+	FunctionKindAggregate = "a"
+	FunctionKindWindow    = "w"
+)
+
+// FunctionInfo describes a user-defined SQL or PL/pgSQL function or
+// procedure.
+type FunctionInfo struct {
+	Schema   string `json:"schema"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`     // one of the FunctionKind* values
+	Language string `json:"language"` // sql or plpgsql
+	Body     string `json:"body,omitempty"`
+}
+
+// PartitionBoundDefault is the bound pg_get_expr reports for a DEFAULT
+// partition.
+const PartitionBoundDefault = "DEFAULT"
+
+// PartitionedTableInfo describes a declaratively partitioned table.
+type PartitionedTableInfo struct {
+	Schema        string   `json:"schema"`
+	Name          string   `json:"name"`
+	KeyDefinition string   `json:"keyDefinition"` // pg_get_partkeydef, e.g. RANGE (created_at)
+	KeyColumns    []string `json:"keyColumns"`    // plain key columns; expressions are omitted
+}
+
+// PartitionInfo describes one partition of a partitioned table.
+type PartitionInfo struct {
+	Schema        string `json:"schema"`
+	Name          string `json:"name"`
+	ParentSchema  string `json:"parentSchema"`
+	Parent        string `json:"parent"`
+	Bound         string `json:"bound"` // e.g. FOR VALUES FROM (...) TO (...)
+	IsDefault     bool   `json:"isDefault"`
+	IsPartitioned bool   `json:"isPartitioned"` // sub-partitioned; holds no rows itself
 }
 
 // Snapshot holds the complete catalog metadata for a database.
 type Snapshot struct {
-	Tables      []TableInfo      `json:"tables"`
-	Columns     []ColumnInfo     `json:"columns"`
-	Indexes     []IndexInfo      `json:"indexes"`
-	Stats       []TableStats     `json:"stats"`
-	Constraints []ConstraintInfo `json:"constraints"`
+	Tables            []TableInfo            `json:"tables"`
+	Relations         []RelationInfo         `json:"relations,omitempty"` // every kind; Tables holds base tables only
+	Columns           []ColumnInfo           `json:"columns"`
+	Indexes           []IndexInfo            `json:"indexes"`
+	Stats             []TableStats           `json:"stats"`
+	Constraints       []ConstraintInfo       `json:"constraints"`
+	Sequences         []SequenceInfo         `json:"sequences,omitempty"`
+	Extensions        []ExtensionInfo        `json:"extensions,omitempty"`
+	ColumnStats       []ColumnStats          `json:"columnStats,omitempty"`
+	MatViews          []MatViewInfo          `json:"matViews,omitempty"`
+	Views             []ViewInfo             `json:"views,omitempty"`
+	Triggers          []TriggerInfo          `json:"triggers,omitempty"`
+	Functions         []FunctionInfo         `json:"functions,omitempty"`
+	PartitionedTables []PartitionedTableInfo `json:"partitionedTables,omitempty"`
+	Partitions        []PartitionInfo        `json:"partitions,omitempty"`
+	IO                []IOStats              `json:"io,omitempty"`         // empty before PostgreSQL 16
+	Statements        []StatStatement        `json:"statements,omitempty"` // empty without pg_stat_statements
+	Database          *DatabaseStats         `json:"database,omitempty"`
+	Instance          *InstanceStats         `json:"instance,omitempty"`
+	Grants            []GrantInfo            `json:"grants,omitempty"`
+	Roles             []RoleInfo             `json:"roles,omitempty"`
+	Policies          []PolicyInfo           `json:"policies,omitempty"`
+	Settings          []SettingInfo          `json:"settings,omitempty"`
+	// Activity is only gathered on request (audit --activity).
+	Activity []SessionInfo `json:"activity,omitempty"`
+	// Cluster is only gathered on request (audit --cluster-health).
+	Cluster *ClusterHealth `json:"cluster,omitempty"`
 }
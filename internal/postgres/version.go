@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MajorVersion extracts the major version from a server_version string such
+// as "16.2 (Debian 16.2-1)" or "9.6.24". Returns 0 if it cannot be parsed.
+func MajorVersion(version string) int {
+	version = strings.TrimSpace(version)
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(version)
+	}
+	major, err := strconv.Atoi(version[:end])
+	if err != nil {
+		return 0
+	}
+	return major
+}
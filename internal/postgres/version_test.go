@@ -0,0 +1,20 @@
+package postgres
+
+import "testing"
+
+func TestMajorVersion(t *testing.T) {
+	tests := map[string]int{
+		"16.2 (Debian 16.2-1.pgdg120+2)": 16,
+		"17beta1":                        17,
+		"9.6.24":                         9,
+		"10.23":                          10,
+		" 15 ":                           15,
+		"":                               0,
+		"unknown":                        0,
+	}
+	for in, want := range tests {
+		if got := MajorVersion(in); got != want {
+			t.Errorf("MajorVersion(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
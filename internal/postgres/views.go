@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATEs the planner raises when a view reads an object that no longer
+// exists. Direct table references are protected by dependency tracking,
+// but functions called by a view are not.
+var undefinedObjectCodes = map[string]bool{
+	"42P01": true, // undefined_table
+	"42703": true, // undefined_column
+	"42883": true, // undefined_function
+	"42704": true, // undefined_object
+}
+
+// GetViews fetches all user views with their definitions and the relations
+// each one reads.
+func (i *Inspector) GetViews(ctx context.Context) ([]ViewInfo, error) {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			COALESCE(pg_catalog.pg_get_viewdef(c.oid), '') AS definition,
+			COALESCE(
+				ARRAY(
+					SELECT DISTINCT rn.nspname || '.' || rc.relname
+					FROM pg_catalog.pg_rewrite r
+					JOIN pg_catalog.pg_depend d
+						ON d.classid = 'pg_catalog.pg_rewrite'::regclass
+						AND d.objid = r.oid
+						AND d.refclassid = 'pg_catalog.pg_class'::regclass
+					JOIN pg_catalog.pg_class rc ON rc.oid = d.refobjid
+					JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+					WHERE r.ev_class = c.oid AND rc.oid <> c.oid
+					ORDER BY 1
+				),
+				'{}'
+			) AS depends_on
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'v'
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND (cardinality($1::text[]) = 0 OR c.relname ILIKE ANY($1))
+		ORDER BY n.nspname, c.relname`
+
+	rows, err := i.pool.Query(ctx, query, i.tables)
+	if err != nil {
+		return nil, fmt.Errorf("get views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []ViewInfo
+	for rows.Next() {
+		var v ViewInfo
+		if err := rows.Scan(&v.Schema, &v.Name, &v.Definition, &v.DependsOn); err != nil {
+			return nil, fmt.Errorf("scan view: %w", err)
+		}
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
+
+// CheckViews plans a query against each candidate view and records planner
+// errors caused by missing objects in ViewInfo.Error. Other errors, such as
+// permission denied, leave the view unmarked.
+func (i *Inspector) CheckViews(ctx context.Context, views []ViewInfo) error {
+	candidates, err := i.viewCheckCandidates(ctx)
+	if err != nil {
+		return err
+	}
+	for idx := range views {
+		v := &views[idx]
+		if !candidates[v.Schema+"."+v.Name] {
+			continue
+		}
+		name := pgx.Identifier{v.Schema, v.Name}.Sanitize()
+		_, err := i.pool.Exec(ctx, "EXPLAIN SELECT * FROM "+name)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("check views: %w", ctx.Err())
+		}
+		if msg, ok := undefinedObjectError(err); ok {
+			v.Error = msg
+		}
+	}
+	return nil
+}
+
+// viewCheckCandidates returns the schema.name of views that can break
+// without PostgreSQL noticing: those calling a user-defined function,
+// whose body isn't covered by dependency tracking, directly or through
+// another such view. Every other view's references are protected by
+// pg_depend, so planning it cannot fail on a missing object.
+func (i *Inspector) viewCheckCandidates(ctx context.Context) (map[string]bool, error) {
+	query := `
+		WITH RECURSIVE view_deps AS (
+			SELECT r.ev_class AS view, d.refclassid, d.refobjid
+			FROM pg_catalog.pg_rewrite r
+			JOIN pg_catalog.pg_depend d
+				ON d.classid = 'pg_catalog.pg_rewrite'::regclass
+				AND d.objid = r.oid
+			WHERE d.refobjid <> r.ev_class
+		), candidates AS (
+			SELECT vd.view
+			FROM view_deps vd
+			JOIN pg_catalog.pg_proc p
+				ON vd.refclassid = 'pg_catalog.pg_proc'::regclass
+				AND p.oid = vd.refobjid
+			JOIN pg_catalog.pg_namespace pn ON pn.oid = p.pronamespace
+			WHERE pn.nspname NOT IN ('pg_catalog', 'information_schema')
+			UNION
+			SELECT vd.view
+			FROM view_deps vd
+			JOIN candidates c
+				ON vd.refclassid = 'pg_catalog.pg_class'::regclass
+				AND vd.refobjid = c.view
+		)
+		SELECT n.nspname, c.relname
+		FROM candidates
+		JOIN pg_catalog.pg_class c ON c.oid = candidates.view
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'v'`
+
+	rows, err := i.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get view check candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[string]bool)
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			return nil, fmt.Errorf("scan view check candidate: %w", err)
+		}
+		candidates[schema+"."+name] = true
+	}
+	return candidates, rows.Err()
+}
+
+// undefinedObjectError returns the server message if err is a missing
+// object error.
+func undefinedObjectError(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && undefinedObjectCodes[pgErr.Code] {
+		return pgErr.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUndefinedObjectError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantMsg string
+		wantOK  bool
+	}{
+		{"undefined table", &pgconn.PgError{Code: "42P01", Message: `relation "gone" does not exist`}, `relation "gone" does not exist`, true},
+		{"wrapped undefined function", fmt.Errorf("explain: %w", &pgconn.PgError{Code: "42883", Message: "function f() does not exist"}), "function f() does not exist", true},
+		{"permission denied", &pgconn.PgError{Code: "42501", Message: "permission denied"}, "", false},
+		{"not a postgres error", errors.New("connection reset"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := undefinedObjectError(tt.err)
+			if msg != tt.wantMsg || ok != tt.wantOK {
+				t.Errorf("got (%q, %v), want (%q, %v)", msg, ok, tt.wantMsg, tt.wantOK)
+			}
+		})
+	}
+}
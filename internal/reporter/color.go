@@ -16,13 +16,74 @@ const (
 	colorCyan   = "\033[36m"
 	colorGray   = "\033[37m"
 	colorBold   = "\033[1m"
+
+	colorBoldBrightRed    = "\033[1;91m"
+	colorBoldBrightYellow = "\033[1;93m"
+	colorBoldBrightCyan   = "\033[1;96m"
+	colorBoldBrightWhite  = "\033[1;97m"
+)
+
+// Theme selects the color palette used by the text writer.
+type Theme string
+
+const (
+	ThemeDefault      Theme = "default"
+	ThemeHighContrast Theme = "high-contrast"
+	ThemeMonochrome   Theme = "monochrome"
 )
 
-var severityColor = map[analyzer.Severity]string{
-	analyzer.SeverityHigh:   colorRed,
-	analyzer.SeverityMedium: colorYellow,
-	analyzer.SeverityLow:    colorCyan,
-	analyzer.SeverityInfo:   colorGray,
+// palette holds the escape codes for one theme. An empty code means the
+// text is written without decoration.
+type palette struct {
+	severity map[analyzer.Severity]string
+	header   string
+}
+
+var palettes = map[Theme]palette{
+	ThemeDefault: {
+		severity: map[analyzer.Severity]string{
+			analyzer.SeverityHigh:   colorRed,
+			analyzer.SeverityMedium: colorYellow,
+			analyzer.SeverityLow:    colorCyan,
+			analyzer.SeverityInfo:   colorGray,
+		},
+		header: colorBold,
+	},
+	ThemeHighContrast: {
+		severity: map[analyzer.Severity]string{
+			analyzer.SeverityHigh:   colorBoldBrightRed,
+			analyzer.SeverityMedium: colorBoldBrightYellow,
+			analyzer.SeverityLow:    colorBoldBrightCyan,
+			analyzer.SeverityInfo:   colorBoldBrightWhite,
+		},
+		header: colorBold,
+	},
+	// Monochrome avoids hues entirely and only emphasizes high severity.
+	ThemeMonochrome: {
+		severity: map[analyzer.Severity]string{
+			analyzer.SeverityHigh: colorBold,
+		},
+		header: colorBold,
+	},
+}
+
+// paletteFor returns the palette for a theme, falling back to the default
+// theme for empty or unknown names.
+func paletteFor(t Theme) *palette {
+	if p, ok := palettes[t]; ok {
+		return &p
+	}
+	p := palettes[ThemeDefault]
+	return &p
+}
+
+// paint wraps s in the given escape code. A nil palette or empty code
+// leaves s untouched.
+func (p *palette) paint(code, s string) string {
+	if p == nil || code == "" {
+		return s
+	}
+	return code + s + colorReset
 }
 
 var isTerminal = term.IsTerminal
@@ -34,3 +95,9 @@ func isTTY(w io.Writer) bool {
 	}
 	return isTerminal(int(f.Fd()))
 }
+
+// noColorEnv reports whether the NO_COLOR convention (https://no-color.org)
+// asks for uncolored output.
+func noColorEnv() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
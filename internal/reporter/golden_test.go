@@ -0,0 +1,110 @@
+package reporter
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden files with the current output")
+
+// goldenReport covers every part of the report layouts: all severities,
+// several schemas, effort estimates, confidence, details, and column and
+// index targets.
+func goldenReport() Report {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedTable, Severity: analyzer.SeverityHigh, Schema: "public", Table: "old_data", Message: "table has no sequential or index scans", Effort: analyzer.EffortLarge, Confidence: analyzer.ConfidenceHigh,
+			Detail: map[string]string{"live_tuples": "1200", "dead_tuples": "30"}},
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "public", Table: "users", Index: "idx_users_legacy", Message: "index has never been used", Effort: analyzer.EffortTrivial, Confidence: analyzer.ConfidenceLow,
+			Detail: map[string]string{"size": "120 MB"}},
+		{Type: analyzer.FindingMissingColumn, Severity: analyzer.SeverityHigh, Schema: "public", Table: "users", Column: "nickname", Message: "column referenced in code does not exist", Effort: analyzer.EffortSmall, Confidence: analyzer.ConfidenceMedium,
+			Detail: map[string]string{"files": "internal/store/users.go:42"}},
+		{Type: analyzer.FindingMissingVacuum, Severity: analyzer.SeverityLow, Schema: "billing", Table: "invoices", Message: "table has not been vacuumed in 45 days", Effort: analyzer.EffortTrivial},
+		{Type: analyzer.FindingWideTable, Severity: analyzer.SeverityInfo, Schema: "billing", Table: "invoices", Message: "table has 64 columns", Effort: analyzer.EffortLarge,
+			Detail: map[string]string{"columns": "64", "threshold": "50"}},
+	}
+	report := NewReport("audit", findings, "1.2.3")
+	report.Metadata.URIHash = "sha256:0123456789abcdef"
+	report.Metadata.Database = "app"
+	report.Scanned = ScanContext{
+		Tables:  12,
+		Indexes: 20,
+		Schemas: 2,
+		BySchema: map[string]SchemaCounts{
+			"public":  {Tables: 9, Indexes: 16},
+			"billing": {Tables: 3, Indexes: 4},
+		},
+	}
+	return report
+}
+
+// checkGolden compares got with testdata/<name>.golden, rewriting the file
+// instead when -update is set.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/reporter -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test ./internal/reporter -update and review the diff)\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// TestGolden renders the same report in every known format, so a new
+// format fails here until its golden file is created and reviewed.
+func TestGolden(t *testing.T) {
+	formats := make([]string, 0, len(knownFormats))
+	for f := range knownFormats {
+		formats = append(formats, string(f))
+	}
+	sort.Strings(formats)
+
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			report := goldenReport()
+			var buf bytes.Buffer
+			opts := WriteOptions{NoColor: true, Width: 160, Timestamp: "2024-01-01T00:00:00Z"}
+			if err := Write(&buf, &report, Format(format), opts); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "report."+format, buf.Bytes())
+		})
+	}
+}
+
+func TestGolden_TextLayouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		report Report
+		width  int
+	}{
+		{"report.text-condensed", goldenReport(), 80},
+		{"empty.text", NewReport("audit", nil, "1.2.3"), 160},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := WriteOptions{NoColor: true, Width: tt.width, Timestamp: "2024-01-01T00:00:00Z"}
+			if err := Write(&buf, &tt.report, FormatText, opts); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, buf.Bytes())
+		})
+	}
+}
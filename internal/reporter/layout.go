@@ -0,0 +1,175 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ppiankov/pgspectre/internal/analyzer"
+	"golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+const (
+	// condensedMaxWidth is the widest output that still gets the condensed
+	// table layout; anything wider uses the grouped verbose layout.
+	condensedMaxWidth = 100
+	// minMessageWidth keeps messages readable when other columns are wide.
+	minMessageWidth = 20
+	ellipsis        = "..."
+)
+
+var terminalSize = term.GetSize
+
+// outputWidth resolves the column budget for text output. An explicit width
+// wins over the terminal size. When output is not a terminal, as in CI
+// logs, the COLUMNS environment variable is used. Zero means unknown,
+// which keeps the verbose layout.
+func outputWidth(w io.Writer, explicit int) int {
+	if explicit > 0 {
+		return explicit
+	}
+	f, ok := w.(*os.File)
+	if !ok || !isTerminal(int(f.Fd())) {
+		return columnsEnv()
+	}
+	cols, _, err := terminalSize(int(f.Fd()))
+	if err != nil || cols <= 0 {
+		return 0
+	}
+	return cols
+}
+
+// columnsEnv returns the width set in COLUMNS, or 0 if it is unset or not
+// a positive number.
+func columnsEnv() int {
+	cols, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS")))
+	if err != nil || cols <= 0 {
+		return 0
+	}
+	return cols
+}
+
+// useCondensed reports whether the given width calls for the table layout.
+func useCondensed(width int) bool {
+	return width > 0 && width <= condensedMaxWidth
+}
+
+// writeCondensed renders one row per finding with no detail lines, truncating
+// messages so that every row fits within width columns. Correlated findings
+// are nested under their root cause as in the verbose layout, and the same
+// summary follows.
+func writeCondensed(w io.Writer, report *Report, pal *palette, width int) error {
+	findings, nested := clusterFindings(report.Findings)
+
+	typeWidth := len("TYPE")
+	objectWidth := len("OBJECT")
+	for i := range findings {
+		f := &findings[i]
+		if n := displayWidth(typeLabel(f.Type, nested[i])); n > typeWidth {
+			typeWidth = n
+		}
+		if n := displayWidth(findingObject(f)); n > objectWidth {
+			objectWidth = n
+		}
+	}
+
+	// Row layout: "SEV   " + type + "  " + object + "  " + message
+	msgWidth := width - severityPrefixWidth - typeWidth - objectWidth - 6
+	if msgWidth < minMessageWidth {
+		msgWidth = minMessageWidth
+	}
+
+	if _, err := fmt.Fprintf(w, "%-*s  %-*s  %-*s  %s\n",
+		severityPrefixWidth, "SEV", typeWidth, "TYPE", objectWidth, "OBJECT", "MESSAGE"); err != nil {
+		return err
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		if _, err := fmt.Fprintf(w, "%s  %s  %s  %s\n",
+			condensedSeverity(f.Severity, pal),
+			padRight(typeLabel(f.Type, nested[i]), typeWidth),
+			padRight(findingObject(f), objectWidth),
+			truncate(findingMessage(f), msgWidth)); err != nil {
+			return err
+		}
+	}
+
+	return writeSummary(w, report, pal)
+}
+
+// findingObject returns the fully qualified object a finding refers to.
+func findingObject(f *analyzer.Finding) string {
+	obj := tableGroupKey(f)
+	if target := findingTarget(f); target != "" {
+		obj += "." + target
+	}
+	return obj
+}
+
+func condensedSeverity(severity analyzer.Severity, pal *palette) string {
+	raw := severityLabel[severity]
+	padding := strings.Repeat(" ", severityPrefixWidth-len(raw))
+	if pal == nil {
+		return raw + padding
+	}
+	return pal.paint(pal.severity[severity], raw) + padding
+}
+
+// truncate shortens s to at most n display columns, marking the cut with
+// an ellipsis. It never splits a rune.
+func truncate(s string, n int) string {
+	if displayWidth(s) <= n {
+		return s
+	}
+	budget := n
+	if n > len(ellipsis) {
+		budget = n - len(ellipsis)
+	}
+	used := 0
+	for i, r := range s {
+		rw := runeWidth(r)
+		if used+rw > budget {
+			if n <= len(ellipsis) {
+				return s[:i]
+			}
+			return s[:i] + ellipsis
+		}
+		used += rw
+	}
+	return s
+}
+
+// padRight pads s with spaces to n display columns.
+func padRight(s string, n int) string {
+	if pad := n - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// displayWidth returns the number of terminal columns s takes up.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the terminal columns of r: two for East Asian wide
+// and fullwidth characters, none for combining marks, one otherwise.
+func runeWidth(r rune) int {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,60 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StdoutPath is the output path that stands for standard output.
+const StdoutPath = "-"
+
+// Output is one report destination: a format and the file it goes to.
+type Output struct {
+	Format Format
+	Path   string
+}
+
+var knownFormats = map[Format]bool{
+	FormatText:       true,
+	FormatJSON:       true,
+	FormatSARIF:      true,
+	FormatSpectreHub: true,
+}
+
+// ParseOutputs parses a --format value. A bare format such as "json" writes
+// to stdout; a comma-separated list of format=path pairs, e.g.
+// "json=report.json,sarif=report.sarif,text=-", writes each format to its
+// own file, with "-" meaning stdout. At most one output may use stdout.
+func ParseOutputs(spec string) ([]Output, error) {
+	var outputs []Output
+	stdout := false
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, path, hasPath := strings.Cut(part, "=")
+		format := Format(strings.ToLower(strings.TrimSpace(name)))
+		path = strings.TrimSpace(path)
+		if !knownFormats[format] {
+			return nil, fmt.Errorf("unknown format %q (available: text, json, sarif, spectrehub)", name)
+		}
+		if !hasPath || path == "" {
+			if hasPath {
+				return nil, fmt.Errorf("format %q: empty output path", name)
+			}
+			path = StdoutPath
+		}
+		if path == StdoutPath {
+			if stdout {
+				return nil, fmt.Errorf("format %q: only one format can be written to stdout", name)
+			}
+			stdout = true
+		}
+		outputs = append(outputs, Output{Format: format, Path: path})
+	}
+	if len(outputs) == 0 {
+		return []Output{{Format: FormatText, Path: StdoutPath}}, nil
+	}
+	return outputs, nil
+}
@@ -0,0 +1,48 @@
+package reporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []Output
+	}{
+		{"", []Output{{Format: FormatText, Path: StdoutPath}}},
+		{"json", []Output{{Format: FormatJSON, Path: StdoutPath}}},
+		{"json=report.json, sarif=out/report.sarif,text=-", []Output{
+			{Format: FormatJSON, Path: "report.json"},
+			{Format: FormatSARIF, Path: "out/report.sarif"},
+			{Format: FormatText, Path: StdoutPath},
+		}},
+		{"SARIF=a.sarif,spectrehub", []Output{
+			{Format: FormatSARIF, Path: "a.sarif"},
+			{Format: FormatSpectreHub, Path: StdoutPath},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseOutputs(tt.spec)
+		if err != nil {
+			t.Errorf("ParseOutputs(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseOutputs(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseOutputs_Errors(t *testing.T) {
+	for _, spec := range []string{
+		"xml",
+		"json=",
+		"json,text",
+		"json=-,sarif",
+	} {
+		if _, err := ParseOutputs(spec); err == nil {
+			t.Errorf("ParseOutputs(%q): expected error", spec)
+		}
+	}
+}
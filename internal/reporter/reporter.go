@@ -1,12 +1,14 @@
 package reporter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ppiankov/pgspectre/internal/analyzer"
 )
@@ -31,20 +33,43 @@ type Metadata struct {
 	Database  string `json:"database,omitempty"`
 }
 
-// Summary counts findings by severity.
+// Summary counts findings by severity, remediation effort, confidence,
+// and schema.
 type Summary struct {
-	Total  int `json:"total"`
-	High   int `json:"high"`
-	Medium int `json:"medium"`
-	Low    int `json:"low"`
-	Info   int `json:"info"`
+	Total        int                         `json:"total"`
+	High         int                         `json:"high"`
+	Medium       int                         `json:"medium"`
+	Low          int                         `json:"low"`
+	Info         int                         `json:"info"`
+	ByEffort     map[analyzer.Effort]int     `json:"byEffort,omitempty"`
+	ByConfidence map[analyzer.Confidence]int `json:"byConfidence,omitempty"`
+	BySchema     map[string]int              `json:"bySchema,omitempty"`
 }
 
 // ScanContext holds context about what was scanned.
 type ScanContext struct {
+	Tables   int                     `json:"tables"`
+	Indexes  int                     `json:"indexes"`
+	Schemas  int                     `json:"schemas"`
+	BySchema map[string]SchemaCounts `json:"bySchema,omitempty"`
+}
+
+// SchemaCounts holds the objects scanned in one schema.
+type SchemaCounts struct {
 	Tables  int `json:"tables"`
 	Indexes int `json:"indexes"`
-	Schemas int `json:"schemas"`
+}
+
+// ServiceSummary is one service of a workspace check: the repo checked
+// against a database's schemas, who owns it, and its finding counts.
+type ServiceSummary struct {
+	Name     string   `json:"name"`
+	Owner    string   `json:"owner,omitempty"`
+	Repo     string   `json:"repo"`
+	Database string   `json:"database"`
+	Schemas  []string `json:"schemas,omitempty"`
+	Findings int      `json:"findings"`
+	High     int      `json:"high"`
 }
 
 // Report is the top-level audit/check output.
@@ -54,10 +79,21 @@ type Report struct {
 	MaxSeverity analyzer.Severity  `json:"maxSeverity"`
 	Summary     Summary            `json:"summary"`
 	Scanned     ScanContext        `json:"scanned,omitempty"`
+	// Instance is server-wide checkpoint and WAL activity, for audits
+	// against a live server.
+	Instance *analyzer.InstanceSummary `json:"instance,omitempty"`
+	// Tuning suggests thresholds to raise when many findings sit just
+	// over them.
+	Tuning *analyzer.Tuning `json:"tuning,omitempty"`
+	// Services lists the services of a workspace check; each finding's
+	// service and owner details say which one it came from.
+	Services []ServiceSummary `json:"services,omitempty"`
 }
 
-// NewReport builds a report from findings.
+// NewReport builds a report from findings, sorting them into a stable order.
 func NewReport(command string, findings []analyzer.Finding, version string) Report {
+	analyzer.SortFindings(findings)
+
 	var summary Summary
 	for _, f := range findings {
 		summary.Total++
@@ -71,6 +107,24 @@ func NewReport(command string, findings []analyzer.Finding, version string) Repo
 		case analyzer.SeverityInfo:
 			summary.Info++
 		}
+		if f.Effort != "" {
+			if summary.ByEffort == nil {
+				summary.ByEffort = make(map[analyzer.Effort]int)
+			}
+			summary.ByEffort[f.Effort]++
+		}
+		if f.Confidence != "" {
+			if summary.ByConfidence == nil {
+				summary.ByConfidence = make(map[analyzer.Confidence]int)
+			}
+			summary.ByConfidence[f.Confidence]++
+		}
+		if f.Schema != "" {
+			if summary.BySchema == nil {
+				summary.BySchema = make(map[string]int)
+			}
+			summary.BySchema[f.Schema]++
+		}
 	}
 
 	if findings == nil {
@@ -93,10 +147,35 @@ func NewReport(command string, findings []analyzer.Finding, version string) Repo
 // WriteOptions controls text output behavior.
 type WriteOptions struct {
 	NoColor bool
+	Theme   Theme
+	// Width overrides terminal width detection; 0 means auto-detect.
+	// Widths up to 100 columns switch to the condensed table layout.
+	Width int
+	// Canonical makes JSON-based formats byte-stable: object keys are
+	// sorted and the timestamp is Timestamp, or empty if unset.
+	Canonical bool
+	// Timestamp replaces the report's generation time, e.g. with a fixed
+	// value for reproducible output.
+	Timestamp string
 }
 
 // Write outputs the report in the given format.
 func Write(w io.Writer, report *Report, format Format, opts ...WriteOptions) error {
+	var opt WriteOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Timestamp != "" || opt.Canonical {
+		report.Metadata.Timestamp = opt.Timestamp
+	}
+	if opt.Canonical && format != FormatText && format != "" {
+		var buf bytes.Buffer
+		if err := Write(&buf, report, format); err != nil {
+			return err
+		}
+		return writeCanonicalJSON(w, buf.Bytes())
+	}
+
 	switch format {
 	case FormatJSON:
 		return writeJSON(w, report)
@@ -105,12 +184,14 @@ func Write(w io.Writer, report *Report, format Format, opts ...WriteOptions) err
 	case FormatSpectreHub:
 		return writeSpectreHub(w, report)
 	default:
-		var opt WriteOptions
-		if len(opts) > 0 {
-			opt = opts[0]
+		var pal *palette
+		if !opt.NoColor && !noColorEnv() && isTTY(w) {
+			pal = paletteFor(opt.Theme)
 		}
-		useColor := !opt.NoColor && isTTY(w)
-		return writeText(w, report, useColor)
+		if width := outputWidth(w, opt.Width); useCondensed(width) && report.Summary.Total > 0 {
+			return writeCondensed(w, report, pal, width)
+		}
+		return writeText(w, report, pal)
 	}
 }
 
@@ -120,6 +201,20 @@ func writeJSON(w io.Writer, report *Report) error {
 	return enc.Encode(report)
 }
 
+// writeCanonicalJSON re-encodes a JSON document with every object's keys
+// sorted. Numbers are kept verbatim.
+func writeCanonicalJSON(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("canonical json: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 var severityLabel = map[analyzer.Severity]string{
 	analyzer.SeverityHigh:   "HIGH",
 	analyzer.SeverityMedium: "MED",
@@ -139,7 +234,8 @@ type tableGroup struct {
 	findings []analyzer.Finding
 }
 
-func writeText(w io.Writer, report *Report, useColor bool) error {
+// writeText renders the human-readable report. A nil palette disables color.
+func writeText(w io.Writer, report *Report, pal *palette) error {
 	if report.Summary.Total == 0 {
 		if report.Scanned.Tables > 0 {
 			_, err := fmt.Fprintf(w, "No issues detected. %d tables, %d indexes scanned.\n",
@@ -165,25 +261,46 @@ func writeText(w io.Writer, report *Report, useColor bool) error {
 			}
 		}
 		header := g.key
-		if useColor {
-			header = colorBold + header + colorReset
+		if pal != nil {
+			header = pal.paint(pal.header, header)
 		}
 		if _, err := fmt.Fprintln(w, header); err != nil {
 			return err
 		}
 
-		if err := writeGroupFindings(w, g, useColor); err != nil {
+		if err := writeGroupFindings(w, g, pal); err != nil {
 			return err
 		}
 	}
 
+	return writeSummary(w, report, pal)
+}
+
+// writeSummary prints the summary sections that follow the findings in
+// both text layouts.
+func writeSummary(w io.Writer, report *Report, pal *palette) error {
 	if _, err := fmt.Fprintln(w, "\nSummary"); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintf(w, "  Total findings: %d\n", report.Summary.Total); err != nil {
 		return err
 	}
-	if err := writeSeveritySummary(w, report.Summary, useColor); err != nil {
+	if err := writeSeveritySummary(w, report.Summary, pal); err != nil {
+		return err
+	}
+	if err := writeEffortSummary(w, report.Summary); err != nil {
+		return err
+	}
+	if err := writeConfidenceSummary(w, report.Summary); err != nil {
+		return err
+	}
+	if err := writeSchemaSummary(w, report); err != nil {
+		return err
+	}
+	if err := writeServiceSummary(w, report.Services); err != nil {
+		return err
+	}
+	if err := writeInstanceSummary(w, report.Instance); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintln(w, "  Top types:"); err != nil {
@@ -194,6 +311,26 @@ func writeText(w io.Writer, report *Report, useColor bool) error {
 			return err
 		}
 	}
+	return writeTuning(w, report.Tuning)
+}
+
+// writeTuning prints the noise score and suggested threshold changes.
+func writeTuning(w io.Writer, t *analyzer.Tuning) error {
+	if t == nil {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "  Noise score: %d%% (%d of %d findings would go with the suggested thresholds)\n", t.NoiseScore, t.Noise, t.Total); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "  Suggested tuning:"); err != nil {
+		return err
+	}
+	for _, s := range t.Suggestions {
+		if _, err := fmt.Fprintf(w, "    raising %s from %s to %s would remove %d of %d %s findings\n",
+			s.Setting, s.Current, s.Suggested, s.Removes, s.Of, s.Finding); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -241,11 +378,13 @@ func writeTableOfContents(w io.Writer, groups []tableGroup) error {
 	return err
 }
 
-func writeGroupFindings(w io.Writer, group tableGroup, useColor bool) error {
+func writeGroupFindings(w io.Writer, group tableGroup, pal *palette) error {
+	findings, nested := clusterFindings(group.findings)
+
 	typeWidth := 0
 	targetWidth := 0
-	for _, f := range group.findings {
-		if n := len(string(f.Type)); n > typeWidth {
+	for i, f := range findings {
+		if n := utf8.RuneCountInString(typeLabel(f.Type, nested[i])); n > typeWidth {
 			typeWidth = n
 		}
 		if n := len(findingTarget(&f)); n > targetWidth {
@@ -253,13 +392,13 @@ func writeGroupFindings(w io.Writer, group tableGroup, useColor bool) error {
 		}
 	}
 
-	for _, f := range group.findings {
+	for i, f := range findings {
 		if _, err := fmt.Fprintf(
 			w,
 			"  %s  %-*s",
-			severityPrefix(f.Severity, useColor),
+			severityPrefix(f.Severity, pal),
 			typeWidth,
-			f.Type,
+			typeLabel(f.Type, nested[i]),
 		); err != nil {
 			return err
 		}
@@ -270,7 +409,7 @@ func writeGroupFindings(w io.Writer, group tableGroup, useColor bool) error {
 			}
 		}
 
-		if _, err := fmt.Fprintf(w, "  %s\n", f.Message); err != nil {
+		if _, err := fmt.Fprintf(w, "  %s\n", findingMessage(&f)); err != nil {
 			return err
 		}
 
@@ -282,6 +421,73 @@ func writeGroupFindings(w io.Writer, group tableGroup, useColor bool) error {
 	return nil
 }
 
+// findingMessage returns the message, marked when confidence is below high.
+func findingMessage(f *analyzer.Finding) string {
+	if f.Confidence == analyzer.ConfidenceMedium || f.Confidence == analyzer.ConfidenceLow {
+		return f.Message + fmt.Sprintf(" [%s confidence]", f.Confidence)
+	}
+	return f.Message
+}
+
+// clusterFindings orders a table's findings so that correlated ones (see
+// analyzer.Correlate) are adjacent. Each cluster is led by the finding
+// linked to the most others, its root cause; nested marks the rest.
+func clusterFindings(findings []analyzer.Finding) ([]analyzer.Finding, []bool) {
+	byRef := make(map[analyzer.FindingRef][]int)
+	for i := range findings {
+		ref := findings[i].Ref()
+		byRef[ref] = append(byRef[ref], i)
+	}
+
+	ordered := make([]analyzer.Finding, 0, len(findings))
+	nested := make([]bool, 0, len(findings))
+	placed := make([]bool, len(findings))
+	for start := range findings {
+		if placed[start] {
+			continue
+		}
+		// Collect the cluster, in report order.
+		cluster := []int{start}
+		placed[start] = true
+		for k := 0; k < len(cluster); k++ {
+			for _, ref := range findings[cluster[k]].Related {
+				for _, j := range byRef[ref] {
+					if !placed[j] {
+						placed[j] = true
+						cluster = append(cluster, j)
+					}
+				}
+			}
+		}
+		sort.Ints(cluster)
+
+		root := cluster[0]
+		for _, i := range cluster {
+			if len(findings[i].Related) > len(findings[root].Related) {
+				root = i
+			}
+		}
+		ordered = append(ordered, findings[root])
+		nested = append(nested, false)
+		for _, i := range cluster {
+			if i != root {
+				ordered = append(ordered, findings[i])
+				nested = append(nested, true)
+			}
+		}
+	}
+	return ordered, nested
+}
+
+// typeLabel is a finding's type as shown in text output, marked when it is
+// nested under the root cause it is correlated with.
+func typeLabel(ft analyzer.FindingType, nested bool) string {
+	if nested {
+		return "└ " + string(ft)
+	}
+	return string(ft)
+}
+
 func writeDetailLines(w io.Writer, detail map[string]string) error {
 	if len(detail) == 0 {
 		return nil
@@ -306,17 +512,24 @@ func writeDetailLines(w io.Writer, detail map[string]string) error {
 	return nil
 }
 
-func writeSeveritySummary(w io.Writer, summary Summary, useColor bool) error {
+func writeSeveritySummary(w io.Writer, summary Summary, pal *palette) error {
+	if _, err := fmt.Fprint(w, "  By severity: "); err != nil {
+		return err
+	}
+	return writeSeverityCounts(w, summary, pal)
+}
+
+func writeSeverityCounts(w io.Writer, summary Summary, pal *palette) error {
 	if _, err := fmt.Fprintf(
 		w,
-		"  By severity: %s %d  %s %d  %s %d  %s %d\n",
-		summarySeverityPrefix(analyzer.SeverityHigh, useColor),
+		"%s %d  %s %d  %s %d  %s %d\n",
+		summarySeverityPrefix(analyzer.SeverityHigh, pal),
 		summary.High,
-		summarySeverityPrefix(analyzer.SeverityMedium, useColor),
+		summarySeverityPrefix(analyzer.SeverityMedium, pal),
 		summary.Medium,
-		summarySeverityPrefix(analyzer.SeverityLow, useColor),
+		summarySeverityPrefix(analyzer.SeverityLow, pal),
 		summary.Low,
-		summarySeverityPrefix(analyzer.SeverityInfo, useColor),
+		summarySeverityPrefix(analyzer.SeverityInfo, pal),
 		summary.Info,
 	); err != nil {
 		return err
@@ -324,6 +537,114 @@ func writeSeveritySummary(w io.Writer, summary Summary, useColor bool) error {
 	return nil
 }
 
+func writeEffortSummary(w io.Writer, summary Summary) error {
+	if len(summary.ByEffort) == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(analyzer.Efforts))
+	for _, e := range analyzer.Efforts {
+		parts = append(parts, fmt.Sprintf("%s %d", e, summary.ByEffort[e]))
+	}
+	_, err := fmt.Fprintf(w, "  By effort:   %s\n", strings.Join(parts, "  "))
+	return err
+}
+
+func writeConfidenceSummary(w io.Writer, summary Summary) error {
+	if len(summary.ByConfidence) == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(analyzer.Confidences))
+	for _, c := range analyzer.Confidences {
+		parts = append(parts, fmt.Sprintf("%s %d", c, summary.ByConfidence[c]))
+	}
+	_, err := fmt.Fprintf(w, "  By confidence: %s\n", strings.Join(parts, "  "))
+	return err
+}
+
+// writeSchemaSummary prints tables scanned and findings per schema. It is
+// skipped when everything lives in a single schema.
+func writeSchemaSummary(w io.Writer, report *Report) error {
+	seen := make(map[string]bool)
+	for s := range report.Scanned.BySchema {
+		seen[s] = true
+	}
+	for s := range report.Summary.BySchema {
+		seen[s] = true
+	}
+	if len(seen) < 2 {
+		return nil
+	}
+	schemas := make([]string, 0, len(seen))
+	width := len("schema")
+	for s := range seen {
+		schemas = append(schemas, s)
+		if len(s) > width {
+			width = len(s)
+		}
+	}
+	sort.Strings(schemas)
+
+	if _, err := fmt.Fprintf(w, "  By schema:\n    %-*s  %6s  %8s\n", width, "schema", "tables", "findings"); err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		if _, err := fmt.Fprintf(w, "    %-*s  %6d  %8d\n",
+			width, s, report.Scanned.BySchema[s].Tables, report.Summary.BySchema[s]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeServiceSummary prints finding counts per workspace service.
+func writeServiceSummary(w io.Writer, services []ServiceSummary) error {
+	if len(services) == 0 {
+		return nil
+	}
+	nameWidth, ownerWidth := len("service"), len("owner")
+	for _, s := range services {
+		nameWidth = max(nameWidth, len(s.Name))
+		ownerWidth = max(ownerWidth, len(s.Owner))
+	}
+	if _, err := fmt.Fprintf(w, "  By service:\n    %-*s  %-*s  %8s  %4s  %s\n",
+		nameWidth, "service", ownerWidth, "owner", "findings", "high", "database"); err != nil {
+		return err
+	}
+	for _, s := range services {
+		target := s.Database
+		if len(s.Schemas) > 0 {
+			target += " (" + strings.Join(s.Schemas, ", ") + ")"
+		}
+		if _, err := fmt.Fprintf(w, "    %-*s  %-*s  %8d  %4d  %s\n",
+			nameWidth, s.Name, ownerWidth, s.Owner, s.Findings, s.High, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInstanceSummary prints checkpoint frequency, WAL volume, and the
+// share of buffers backends write themselves.
+func writeInstanceSummary(w io.Writer, s *analyzer.InstanceSummary) error {
+	if s == nil {
+		return nil
+	}
+	parts := []string{fmt.Sprintf("%d checkpoints (%d requested)",
+		s.CheckpointsTimed+s.CheckpointsRequested, s.CheckpointsRequested)}
+	if s.CheckpointInterval > 0 {
+		parts[0] += ", every " + (time.Duration(s.CheckpointInterval) * time.Second).String()
+	}
+	switch {
+	case s.WALBytesPerHour >= 1<<30:
+		parts = append(parts, fmt.Sprintf("WAL %.1f GB/h", float64(s.WALBytesPerHour)/(1<<30)))
+	case s.WALBytesPerHour > 0:
+		parts = append(parts, fmt.Sprintf("WAL %.1f MB/h", float64(s.WALBytesPerHour)/(1<<20)))
+	}
+	parts = append(parts, fmt.Sprintf("backends write %.0f%% of buffers", s.BackendWriteRatio*100))
+	_, err := fmt.Fprintf(w, "  Instance:    %s\n", strings.Join(parts, "; "))
+	return err
+}
+
 type findingTypeCount struct {
 	ft    analyzer.FindingType
 	count int
@@ -378,19 +699,19 @@ func findingTarget(f *analyzer.Finding) string {
 	}
 }
 
-func severityPrefix(severity analyzer.Severity, useColor bool) string {
+func severityPrefix(severity analyzer.Severity, pal *palette) string {
 	raw := "[" + severityLabel[severity] + "]"
 	padding := strings.Repeat(" ", severityPrefixWidth-len(raw))
-	if !useColor {
+	if pal == nil {
 		return raw + padding
 	}
-	return severityColor[severity] + raw + colorReset + padding
+	return pal.paint(pal.severity[severity], raw) + padding
 }
 
-func summarySeverityPrefix(severity analyzer.Severity, useColor bool) string {
+func summarySeverityPrefix(severity analyzer.Severity, pal *palette) string {
 	raw := "[" + severityLabel[severity] + "]"
-	if !useColor {
+	if pal == nil {
 		return raw
 	}
-	return severityColor[severity] + raw + colorReset
+	return pal.paint(pal.severity[severity], raw)
 }
@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ppiankov/pgspectre/internal/analyzer"
 )
@@ -42,6 +44,210 @@ func TestNewReport_Summary(t *testing.T) {
 	}
 }
 
+func TestNewReport_StableOrder(t *testing.T) {
+	forward := append([]analyzer.Finding(nil), testFindings...)
+	reversed := make([]analyzer.Finding, len(testFindings))
+	for i, f := range testFindings {
+		reversed[len(testFindings)-1-i] = f
+	}
+
+	var out [2]bytes.Buffer
+	for i, findings := range [][]analyzer.Finding{forward, reversed} {
+		r := NewReport("audit", findings, "test")
+		r.Metadata.Timestamp = ""
+		if err := Write(&out[i], &r, FormatJSON); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out[0].String() != out[1].String() {
+		t.Errorf("report depends on input order:\n%s\n---\n%s", out[0].String(), out[1].String())
+	}
+}
+
+func TestWrite_Canonical(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatSARIF, FormatSpectreHub} {
+		t.Run(string(format), func(t *testing.T) {
+			var out [2]bytes.Buffer
+			for i := range out {
+				r := NewReport("audit", append([]analyzer.Finding(nil), testFindings...), "test")
+				r.Metadata.Timestamp = time.Now().Add(time.Duration(i) * time.Hour).Format(time.RFC3339)
+				opts := WriteOptions{Canonical: true, Timestamp: "2024-01-01T00:00:00Z"}
+				if err := Write(&out[i], &r, format, opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if out[0].String() != out[1].String() {
+				t.Fatalf("canonical output differs between runs")
+			}
+			if format != FormatSARIF && !strings.Contains(out[0].String(), "2024-01-01T00:00:00Z") {
+				t.Errorf("timestamp not injected:\n%s", out[0].String())
+			}
+		})
+	}
+
+	r := NewReport("audit", testFindings, "test")
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatJSON, WriteOptions{Canonical: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Index(out, `"maxSeverity"`) > strings.Index(out, `"metadata"`) {
+		t.Errorf("top-level keys not sorted:\n%s", out)
+	}
+	if !strings.Contains(out, `"timestamp": ""`) {
+		t.Errorf("canonical output without --timestamp should have an empty timestamp:\n%s", out)
+	}
+}
+
+func TestNewReport_EffortSummary(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Effort: analyzer.EffortSmall},
+		{Type: analyzer.FindingBloatedIndex, Severity: analyzer.SeverityLow, Effort: analyzer.EffortSmall},
+		{Type: analyzer.FindingSequenceExhaustion, Severity: analyzer.SeverityHigh, Effort: analyzer.EffortLarge},
+		{Type: analyzer.FindingCodeMatch, Severity: analyzer.SeverityInfo},
+	}
+	r := NewReport("audit", findings, "test")
+
+	if r.Summary.ByEffort[analyzer.EffortSmall] != 2 {
+		t.Errorf("small = %d, want 2", r.Summary.ByEffort[analyzer.EffortSmall])
+	}
+	if r.Summary.ByEffort[analyzer.EffortLarge] != 1 {
+		t.Errorf("large = %d, want 1", r.Summary.ByEffort[analyzer.EffortLarge])
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "By effort:   trivial 0  small 2  medium 0  large 1") {
+		t.Errorf("expected effort summary line, got:\n%s", buf.String())
+	}
+}
+
+func TestNewReport_NoEffortOmitsSummary(t *testing.T) {
+	r := NewReport("audit", testFindings, "test")
+	if r.Summary.ByEffort != nil {
+		t.Errorf("expected nil ByEffort, got %v", r.Summary.ByEffort)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "By effort") {
+		t.Error("did not expect effort summary without estimates")
+	}
+}
+
+func TestNewReport_SchemaSummary(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedTable, Severity: analyzer.SeverityHigh, Schema: "billing", Table: "invoices"},
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "billing", Table: "invoices", Index: "idx_a"},
+		{Type: analyzer.FindingMissingVacuum, Severity: analyzer.SeverityLow, Schema: "public", Table: "logs"},
+		{Type: analyzer.FindingMissingTable, Severity: analyzer.SeverityHigh, Table: "ghost"},
+	}
+	r := NewReport("audit", findings, "test")
+	if r.Summary.BySchema["billing"] != 2 || r.Summary.BySchema["public"] != 1 || len(r.Summary.BySchema) != 2 {
+		t.Errorf("BySchema = %v", r.Summary.BySchema)
+	}
+
+	r.Scanned = ScanContext{Tables: 5, Schemas: 3, BySchema: map[string]SchemaCounts{
+		"billing": {Tables: 2},
+		"public":  {Tables: 2},
+		"audit":   {Tables: 1},
+	}}
+	for _, width := range []int{0, 80} {
+		var buf bytes.Buffer
+		if err := Write(&buf, &r, FormatText, WriteOptions{Width: width}); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			"  By schema:\n    schema   tables  findings\n",
+			"    audit         1         0\n    billing       2         2\n    public        2         1\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("width %d: expected %q in output, got:\n%s", width, want, out)
+			}
+		}
+	}
+
+	// A single schema needs no breakdown.
+	r = NewReport("audit", testFindings, "test")
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "By schema") {
+		t.Error("did not expect schema summary for a single schema")
+	}
+}
+
+func TestWriteText_InstanceSummary(t *testing.T) {
+	r := NewReport("audit", testFindings, "test")
+	r.Instance = &analyzer.InstanceSummary{
+		CheckpointsTimed: 30, CheckpointsRequested: 90, CheckpointInterval: 252,
+		WALBytesPerHour: 1536 * 1024 * 1024, BackendWriteRatio: 0.03,
+	}
+	want := "  Instance:    120 checkpoints (90 requested), every 4m12s; WAL 1.5 GB/h; backends write 3% of buffers\n"
+	for _, width := range []int{0, 80} {
+		var buf bytes.Buffer
+		if err := Write(&buf, &r, FormatText, WriteOptions{Width: width}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("width %d: expected %q in output, got:\n%s", width, want, buf.String())
+		}
+	}
+}
+
+func TestWriteText_ServiceSummary(t *testing.T) {
+	r := NewReport("workspace", testFindings, "test")
+	r.Services = []ServiceSummary{
+		{Name: "billing", Owner: "team-pay", Repo: "./billing", Database: "main", Schemas: []string{"billing"}, Findings: 3, High: 1},
+		{Name: "web", Repo: "./web", Database: "main", Findings: 1},
+	}
+	for _, width := range []int{0, 80} {
+		var buf bytes.Buffer
+		if err := Write(&buf, &r, FormatText, WriteOptions{Width: width}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"  By service:\n    service  owner     findings  high  database\n",
+			"    billing  team-pay         3     1  main (billing)\n",
+			"    web                       1     0  main\n",
+		} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("width %d: expected %q in output, got:\n%s", width, want, buf.String())
+			}
+		}
+	}
+}
+
+func TestWriteText_Tuning(t *testing.T) {
+	r := NewReport("audit", testFindings, "test")
+	r.Tuning = &analyzer.Tuning{
+		NoiseScore: 40, Noise: 240, Total: 600,
+		Suggestions: []analyzer.TuningSuggestion{{
+			Setting: "thresholds.unused_index_min_bytes", Finding: analyzer.FindingUnusedIndex,
+			Current: "104857600 (100.0 MB)", Suggested: "524288000 (500.0 MB)", Removes: 240, Of: 300,
+		}},
+	}
+	for _, width := range []int{0, 80} {
+		var buf bytes.Buffer
+		if err := Write(&buf, &r, FormatText, WriteOptions{Width: width}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"  Noise score: 40% (240 of 600 findings would go with the suggested thresholds)\n",
+			"    raising thresholds.unused_index_min_bytes from 104857600 (100.0 MB) to 524288000 (500.0 MB) would remove 240 of 300 UNUSED_INDEX findings\n",
+		} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("width %d: expected %q in output, got:\n%s", width, want, buf.String())
+			}
+		}
+	}
+}
+
 func TestNewReport_Empty(t *testing.T) {
 	r := NewReport("audit", nil, "test")
 
@@ -211,21 +417,85 @@ func TestWriteText_AlignsColumnsWithinGroup(t *testing.T) {
 		t.Fatalf("expected 3 finding lines, got %d in:\n%s", len(findingLines), buf.String())
 	}
 
-	positions := []int{
-		strings.Index(findingLines[0], "index never used"),
-		strings.Index(findingLines[1], "column missing"),
-		strings.Index(findingLines[2], "table has no primary key"),
-	}
-	for i, pos := range positions {
+	var positions []int
+	for _, msg := range []string{"index never used", "column missing", "table has no primary key"} {
+		pos := -1
+		for _, line := range findingLines {
+			if i := strings.Index(line, msg); i >= 0 {
+				pos = i
+			}
+		}
 		if pos < 0 {
-			t.Fatalf("message not found in line %d: %q", i, findingLines[i])
+			t.Fatalf("message %q not found in:\n%s", msg, buf.String())
 		}
+		positions = append(positions, pos)
 	}
 	if positions[0] != positions[1] || positions[1] != positions[2] {
 		t.Fatalf("message columns are not aligned: %v\n%s", positions, buf.String())
 	}
 }
 
+func TestWriteText_NestsCorrelatedFindings(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "public", Table: "legacy", Index: "legacy_a_idx", Message: "unused"},
+		{Type: analyzer.FindingNoPrimaryKey, Severity: analyzer.SeverityMedium, Schema: "public", Table: "legacy", Message: "no PK"},
+		{Type: analyzer.FindingUnreferencedTable, Severity: analyzer.SeverityLow, Schema: "public", Table: "legacy", Message: "not in code"},
+	}
+	analyzer.Correlate(findings)
+	r := NewReport("audit", findings, "test")
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "  [") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 finding lines in:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], " UNREFERENCED_TABLE ") ||
+		!strings.Contains(lines[1], "└ NO_PRIMARY_KEY") ||
+		!strings.Contains(lines[2], "└ UNUSED_INDEX") {
+		t.Errorf("expected the unreferenced table to lead its cluster:\n%s", buf.String())
+	}
+	column := func(line, msg string) int { return utf8.RuneCountInString(line[:strings.Index(line, msg)]) }
+	if column(lines[1], "no PK") != column(lines[0], "not in code") {
+		t.Errorf("nested finding messages are not aligned:\n%s", buf.String())
+	}
+}
+
+func TestWrite_CondensedNestsCorrelatedFindings(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "public", Table: "legacy", Index: "legacy_a_idx", Message: "unused"},
+		{Type: analyzer.FindingNoPrimaryKey, Severity: analyzer.SeverityMedium, Schema: "public", Table: "legacy", Message: "no PK"},
+		{Type: analyzer.FindingUnreferencedTable, Severity: analyzer.SeverityLow, Schema: "public", Table: "legacy", Message: "not in code"},
+	}
+	analyzer.Correlate(findings)
+	r := NewReport("audit", findings, "test")
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText, WriteOptions{Width: 80}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "SEV") {
+		t.Fatalf("expected condensed layout, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], " UNREFERENCED_TABLE ") ||
+		!strings.Contains(lines[2], "└ NO_PRIMARY_KEY") ||
+		!strings.Contains(lines[3], "└ UNUSED_INDEX") {
+		t.Errorf("expected the unreferenced table to lead its cluster:\n%s", buf.String())
+	}
+	column := func(line, msg string) int { return utf8.RuneCountInString(line[:strings.Index(line, msg)]) }
+	if column(lines[2], "no PK") != column(lines[1], "not in code") {
+		t.Errorf("nested finding messages are not aligned:\n%s", buf.String())
+	}
+}
+
 func TestWriteText_TOC(t *testing.T) {
 	var findings []analyzer.Finding
 	for i := 0; i < 25; i++ {
@@ -323,6 +593,170 @@ func TestWriteText_NoColorOptionDisablesANSI(t *testing.T) {
 	}
 }
 
+func TestWriteText_NoColorEnvDisablesANSI(t *testing.T) {
+	restore := stubTerminal(t, true)
+	defer restore()
+	t.Setenv("NO_COLOR", "1")
+
+	file, err := os.CreateTemp(t.TempDir(), "report-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+
+	r := NewReport("audit", testFindings, "test")
+	if err := Write(file, &r, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Fatalf("expected no ANSI escape codes with NO_COLOR set, got:\n%s", string(data))
+	}
+}
+
+func TestWriteText_Themes(t *testing.T) {
+	tests := []struct {
+		theme    Theme
+		want     []string
+		unwanted []string
+	}{
+		{ThemeDefault, []string{colorRed + "[HIGH]", colorBold}, nil},
+		{"", []string{colorRed + "[HIGH]"}, nil},
+		{"unknown", []string{colorRed + "[HIGH]"}, nil},
+		{ThemeHighContrast, []string{colorBoldBrightRed + "[HIGH]", colorBoldBrightYellow + "[MED]"}, []string{colorRed}},
+		{ThemeMonochrome, []string{colorBold + "[HIGH]"}, []string{colorRed, colorYellow, colorCyan, colorGray}},
+	}
+
+	r := NewReport("audit", testFindings, "test")
+	for _, tt := range tests {
+		t.Run(string(tt.theme), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeText(&buf, &r, paletteFor(tt.theme)); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %q in output:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("did not expect %q in output:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+func TestWrite_NarrowWidthUsesCondensedLayout(t *testing.T) {
+	long := analyzer.Finding{
+		Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "public", Table: "users", Index: "idx_old",
+		Message: strings.Repeat("very long message ", 10),
+		Detail:  map[string]string{"size": "8.0 KB"},
+	}
+	r := NewReport("audit", append([]analyzer.Finding{long}, testFindings...), "test")
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText, WriteOptions{Width: 80}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "SEV") {
+		t.Errorf("expected table header, got:\n%s", out)
+	}
+	if strings.Contains(out, "size:") {
+		t.Error("condensed layout should omit detail lines")
+	}
+	if !strings.Contains(out, "public.users.idx_old") {
+		t.Error("expected fully qualified object column")
+	}
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if len(line) > 80 {
+			t.Errorf("line exceeds 80 columns (%d): %q", len(line), line)
+		}
+	}
+	if !strings.Contains(out, "Total findings: 4") {
+		t.Errorf("expected totals line, got:\n%s", out)
+	}
+}
+
+func TestWrite_CondensedKeepsSummarySections(t *testing.T) {
+	findings := []analyzer.Finding{
+		{Type: analyzer.FindingUnusedIndex, Severity: analyzer.SeverityMedium, Schema: "public", Table: "users", Index: "idx_old",
+			Message: "unused", Effort: analyzer.EffortSmall, Confidence: analyzer.ConfidenceLow},
+		{Type: analyzer.FindingMissingVacuum, Severity: analyzer.SeverityLow, Schema: "billing", Table: "invoices",
+			Message: "not vacuumed", Effort: analyzer.EffortTrivial, Confidence: analyzer.ConfidenceHigh},
+	}
+	r := NewReport("audit", findings, "test")
+	r.Scanned = ScanContext{Tables: 3, Schemas: 2, BySchema: map[string]SchemaCounts{"billing": {Tables: 1}, "public": {Tables: 2}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText, WriteOptions{Width: 80}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "SEV") {
+		t.Fatalf("expected condensed layout, got:\n%s", out)
+	}
+	for _, want := range []string{"Summary", "By severity:", "By effort:", "By confidence:", "By schema:", "Top types:", "[low confidence]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("condensed output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWrite_WideWidthUsesVerboseLayout(t *testing.T) {
+	r := NewReport("audit", testFindings, "test")
+	var buf bytes.Buffer
+	if err := Write(&buf, &r, FormatText, WriteOptions{Width: 160}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "public.old_data\n") {
+		t.Errorf("expected grouped verbose layout, got:\n%s", buf.String())
+	}
+}
+
+func TestOutputWidth_Columns(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("COLUMNS", "80")
+	if got := outputWidth(&buf, 0); got != 80 {
+		t.Errorf("outputWidth with COLUMNS=80 = %d, want 80", got)
+	}
+	if got := outputWidth(&buf, 120); got != 120 {
+		t.Errorf("explicit width = %d, want 120", got)
+	}
+	t.Setenv("COLUMNS", "wide")
+	if got := outputWidth(&buf, 0); got != 0 {
+		t.Errorf("outputWidth with invalid COLUMNS = %d, want 0", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"abcdef", 2, "ab"},
+		{"größenänderung", 8, "größe..."},
+		{"表名表名表名", 7, "表名..."},
+		{"café", 4, "café"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestTopFindingTypes_LimitsAndSorts(t *testing.T) {
 	findings := []analyzer.Finding{
 		{Type: analyzer.FindingUnusedIndex},
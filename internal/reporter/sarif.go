@@ -48,10 +48,11 @@ type sarifMessage struct {
 }
 
 type sarifResult struct {
-	RuleID    string          `json:"ruleId"`
-	Level     string          `json:"level"`
-	Message   sarifMessage    `json:"message"`
-	Locations []sarifLocation `json:"locations,omitempty"`
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
 }
 
 type sarifLocation struct {
@@ -64,20 +65,6 @@ type sarifLogicalLocation struct {
 	Kind               string `json:"kind"`
 }
 
-var ruleDescriptions = map[analyzer.FindingType]string{
-	analyzer.FindingMissingTable:      "Table referenced in code does not exist in database",
-	analyzer.FindingMissingColumn:     "Column referenced in code does not exist in table",
-	analyzer.FindingUnusedTable:       "Table has no read activity (seq_scan=0, idx_scan=0)",
-	analyzer.FindingUnreferencedTable: "Table exists in database but not referenced in code",
-	analyzer.FindingUnusedIndex:       "Index has never been used for scans",
-	analyzer.FindingBloatedIndex:      "Index size exceeds table size",
-	analyzer.FindingMissingVacuum:     "Table has not been vacuumed recently",
-	analyzer.FindingNoPrimaryKey:      "Table has no primary key constraint",
-	analyzer.FindingDuplicateIndex:    "Multiple indexes with same definition on same table",
-	analyzer.FindingCodeMatch:         "Table reference in code matches database table",
-	analyzer.FindingOK:                "No issues detected",
-}
-
 var severityToLevel = map[analyzer.Severity]string{
 	analyzer.SeverityHigh:   "error",
 	analyzer.SeverityMedium: "warning",
@@ -92,9 +79,15 @@ func writeSARIF(w io.Writer, report *Report) error {
 		ruleSet[f.Type] = true
 	}
 
-	rules := make([]sarifRule, 0)
+	ruleTypes := make([]analyzer.FindingType, 0, len(ruleSet))
 	for ft := range ruleSet {
-		desc := ruleDescriptions[ft]
+		ruleTypes = append(ruleTypes, ft)
+	}
+	sort.Slice(ruleTypes, func(i, j int) bool { return ruleTypes[i] < ruleTypes[j] })
+
+	rules := make([]sarifRule, 0)
+	for _, ft := range ruleTypes {
+		desc := analyzer.FindingTypeDescription(ft)
 		if desc == "" {
 			desc = string(ft)
 		}
@@ -147,6 +140,9 @@ func writeSARIF(w io.Writer, report *Report) error {
 				},
 			},
 		}
+		if f.Confidence != "" {
+			r.Properties = map[string]string{"confidence": string(f.Confidence)}
+		}
 		results = append(results, r)
 	}
 
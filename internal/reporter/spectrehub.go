@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/url"
-	"time"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/postgres"
 )
 
 // SpectreHubEnvelope is the spectre/v1 cross-tool ingestion format.
@@ -45,25 +47,39 @@ type SpectreHubSummary struct {
 	Info   int `json:"info"`
 }
 
-// HashURI produces a sha256 hash of the URI with credentials stripped.
+// HashURI produces a sha256 hash of the URI with credentials stripped. A
+// single-host URL hashes as it always has, so existing targets keep their
+// hash. Multi-host and Unix socket URLs and keyword/value settings are
+// normalized first (see postgres.ConnTarget.String), so equivalent forms
+// hash the same.
 func HashURI(rawURI string) string {
-	u, err := url.Parse(rawURI)
+	if u, err := url.Parse(rawURI); err == nil && isSingleHostURL(u) {
+		u.User = nil
+		h := sha256.Sum256([]byte(u.String()))
+		return fmt.Sprintf("sha256:%x", h)
+	}
+	t, err := postgres.ParseConnString(rawURI)
 	if err != nil {
 		h := sha256.Sum256([]byte(rawURI))
 		return fmt.Sprintf("sha256:%x", h)
 	}
-	u.User = nil
-	safe := u.String()
-	h := sha256.Sum256([]byte(safe))
+	h := sha256.Sum256([]byte(t.String()))
 	return fmt.Sprintf("sha256:%x", h)
 }
 
+// isSingleHostURL reports whether u is a postgres:// URL naming one TCP
+// host.
+func isSingleHostURL(u *url.URL) bool {
+	return (u.Scheme == "postgres" || u.Scheme == "postgresql") &&
+		u.Host != "" && !strings.ContainsAny(u.Host, ",%") && !u.Query().Has("host")
+}
+
 func writeSpectreHub(w io.Writer, report *Report) error {
 	envelope := SpectreHubEnvelope{
 		Schema:    "spectre/v1",
 		Tool:      "pgspectre",
 		Version:   report.Metadata.Version,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: report.Metadata.Timestamp,
 		Target: SpectreHubTarget{
 			Type:     "postgresql",
 			URIHash:  report.Metadata.URIHash,
@@ -85,12 +101,16 @@ func writeSpectreHub(w io.Writer, report *Report) error {
 		} else if f.Column != "" {
 			loc += "." + f.Column
 		}
-		envelope.Findings = append(envelope.Findings, SpectreHubFinding{
+		hf := SpectreHubFinding{
 			ID:       string(f.Type),
 			Severity: string(f.Severity),
 			Location: loc,
 			Message:  f.Message,
-		})
+		}
+		if f.Confidence != "" {
+			hf.Metadata = map[string]any{"confidence": string(f.Confidence)}
+		}
+		envelope.Findings = append(envelope.Findings, hf)
 	}
 
 	if envelope.Findings == nil {
@@ -2,7 +2,9 @@ package reporter
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"testing"
 
@@ -136,4 +138,23 @@ func TestHashURI(t *testing.T) {
 	if len(h1) < 10 || h1[:7] != "sha256:" {
 		t.Fatalf("hash should start with sha256:, got %q", h1)
 	}
+
+	// Equivalent multi-host and Unix socket forms hash the same
+	pairs := [][2]string{
+		{buildURI("alice", "a:5432,b:5432", "mydb") + "?target_session_attrs=read-only", "postgres://A,B/mydb?target_session_attrs=read-only"},
+		{"postgres://%2Fvar%2Frun%2Fpostgresql/mydb", "postgresql:///mydb?host=/var/run/postgresql"},
+		{buildURI("alice", "a:5432,b:5432", "mydb"), "host=a,b dbname=mydb user=bob"},
+	}
+	for _, p := range pairs {
+		if HashURI(p[0]) != HashURI(p[1]) {
+			t.Errorf("%q and %q should hash the same", p[0], p[1])
+		}
+	}
+	// Single-host URLs keep the hash of earlier releases.
+	if got, want := HashURI("postgres://u:p@db/app"), fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("postgres://db/app"))); got != want {
+		t.Errorf("single-host hash = %s, want %s", got, want)
+	}
+	if HashURI("postgres://a,b/mydb") == HashURI("postgres://b,a/mydb") {
+		t.Error("host order decides which server is tried first and should change the hash")
+	}
 }
@@ -0,0 +1,321 @@
+//go:build pgquery
+
+package scanner
+
+import (
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// astAvailable reports whether this build includes libpg_query.
+const astAvailable = true
+
+// jsonbOperators and jsonbFunctions mark a condition on a jsonb column,
+// as the JSONB_FILTER column patterns do.
+var (
+	jsonbOperators = map[string]bool{
+		"@>": true, "<@": true, "?": true, "?|": true, "?&": true,
+		"->": true, "->>": true, "#>": true, "#>>": true, "@?": true, "@@": true,
+	}
+	jsonbFunctions = map[string]bool{
+		"jsonb_path_exists": true, "jsonb_path_match": true, "jsonb_exists": true,
+		"jsonb_exists_any": true, "jsonb_exists_all": true, "jsonb_contains": true,
+	}
+)
+
+// parseSQL parses sql with libpg_query and returns the tables and columns
+// of every statement in it. ok is false when sql does not parse.
+func parseSQL(sql string) (tables []tableMatch, columns []columnMatch, ok bool) {
+	tree, err := pg_query.Parse(sql)
+	if err != nil || len(tree.Stmts) == 0 {
+		return nil, nil, false
+	}
+	for _, raw := range tree.Stmts {
+		w := newASTWalker()
+		w.walk(raw.Stmt.ProtoReflect(), ContextSelect)
+		t, c := w.resolve()
+		tables = append(tables, t...)
+		columns = append(columns, c...)
+	}
+	return tables, columns, true
+}
+
+// astColumn is a column reference before its qualifier is resolved.
+// table is set when the grammar ties the column to a relation, as the
+// column list of INSERT INTO orders (...) does.
+type astColumn struct {
+	qualifier string
+	table     *pg_query.RangeVar
+	column    string
+	context   Context
+}
+
+// astWalker collects the relations and column references of one
+// statement. Names are resolved once the whole tree has been seen, since
+// a CTE or alias can be used before the walk reaches its definition.
+type astWalker struct {
+	relations []tableMatch
+	columns   []astColumn
+	aliases   map[string]*pg_query.RangeVar // lowercased alias or name -> relation
+	derived   map[string]bool               // CTE, subquery, and function aliases
+	outputs   map[string]bool               // SELECT list aliases, usable in ORDER BY
+	target    *pg_query.RangeVar            // relation of the enclosing INSERT or UPDATE
+}
+
+func newASTWalker() *astWalker {
+	return &astWalker{
+		aliases: make(map[string]*pg_query.RangeVar),
+		derived: make(map[string]bool),
+		outputs: make(map[string]bool),
+	}
+}
+
+// walk visits m and its children. ctx is the context of relations and
+// columns found under m; statements and clauses set it for their fields.
+func (w *astWalker) walk(m protoreflect.Message, ctx Context) {
+	switch n := m.Interface().(type) {
+	case *pg_query.RangeVar:
+		w.relations = append(w.relations, tableMatch{Table: n.Relname, Schema: n.Schemaname, Pattern: PatternSQL, Context: ctx})
+		w.aliases[strings.ToLower(n.Relname)] = n
+		if n.Alias != nil {
+			w.aliases[strings.ToLower(n.Alias.Aliasname)] = n
+		}
+		return
+	case *pg_query.CommonTableExpr:
+		w.derived[strings.ToLower(n.Ctename)] = true
+	case *pg_query.RangeSubselect:
+		if n.Alias != nil {
+			w.derived[strings.ToLower(n.Alias.Aliasname)] = true
+		}
+	case *pg_query.RangeFunction:
+		if n.Alias != nil {
+			w.derived[strings.ToLower(n.Alias.Aliasname)] = true
+		}
+	case *pg_query.ColumnRef:
+		w.columnRef(n, ctx)
+		return
+	case *pg_query.ResTarget:
+		switch {
+		case n.Name == "":
+		case ctx == ContextInsert || ctx == ContextUpdate:
+			w.columns = append(w.columns, astColumn{table: w.target, column: n.Name, context: ctx})
+		default:
+			w.outputs[strings.ToLower(n.Name)] = true
+		}
+	case *pg_query.A_Expr:
+		if col := n.Lexpr.GetColumnRef(); col != nil && jsonbOperators[nodeName(n.Name)] {
+			w.columnRef(col, ContextJSONB)
+			if n.Rexpr != nil {
+				w.walk(n.Rexpr.ProtoReflect(), ctx)
+			}
+			return
+		}
+	case *pg_query.FuncCall:
+		if len(n.Args) > 0 && jsonbFunctions[nodeName(n.Funcname)] {
+			if col := n.Args[0].GetColumnRef(); col != nil {
+				w.columnRef(col, ContextJSONB)
+				for _, a := range n.Args[1:] {
+					w.walk(a.ProtoReflect(), ctx)
+				}
+				return
+			}
+		}
+	case *pg_query.IndexElem:
+		if ctx == ContextConflict && n.Name != "" {
+			w.columns = append(w.columns, astColumn{table: w.target, column: n.Name, context: ctx})
+		}
+	case *pg_query.JoinExpr:
+		for _, u := range n.UsingClause {
+			for _, side := range []*pg_query.Node{n.Larg, n.Rarg} {
+				if rel := side.GetRangeVar(); rel != nil && u.GetString_() != nil {
+					w.columns = append(w.columns, astColumn{table: rel, column: u.GetString_().Sval, context: ContextJoin})
+				}
+			}
+		}
+	case *pg_query.InsertStmt:
+		defer w.setTarget(n.Relation)()
+	case *pg_query.UpdateStmt:
+		defer w.setTarget(n.Relation)()
+	case *pg_query.CreateStmt, *pg_query.AlterTableStmt, *pg_query.IndexStmt,
+		*pg_query.TruncateStmt, *pg_query.RenameStmt, *pg_query.CreateTableAsStmt,
+		*pg_query.ViewStmt, *pg_query.CommentStmt, *pg_query.GrantStmt:
+		ctx = ContextDDL
+	}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil || fd.IsMap() {
+			return true
+		}
+		child := fieldContext(m, fd, ctx)
+		if fd.IsList() {
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				w.walk(l.Get(i).Message(), child)
+			}
+			return true
+		}
+		w.walk(v.Message(), child)
+		return true
+	})
+}
+
+// setTarget makes rel the relation bare INSERT and UPDATE columns belong
+// to, and returns a func that restores the previous one.
+func (w *astWalker) setTarget(rel *pg_query.RangeVar) func() {
+	prev := w.target
+	w.target = rel
+	return func() { w.target = prev }
+}
+
+// fieldContext is the context of what a field of m holds, given the
+// context of m itself.
+func fieldContext(m protoreflect.Message, fd protoreflect.FieldDescriptor, ctx Context) Context {
+	field := string(fd.Name())
+	switch m.Interface().(type) {
+	case *pg_query.SelectStmt:
+		switch field {
+		case "where_clause", "having_clause":
+			return ContextWhere
+		case "sort_clause", "group_clause":
+			return ContextOrderBy
+		}
+		return ContextSelect
+	case *pg_query.InsertStmt:
+		switch field {
+		case "relation", "cols":
+			return ContextInsert
+		case "returning_list":
+			return ContextReturning
+		}
+		return ContextSelect
+	case *pg_query.UpdateStmt:
+		switch field {
+		case "relation", "target_list":
+			return ContextUpdate
+		case "where_clause":
+			return ContextWhere
+		case "returning_list":
+			return ContextReturning
+		}
+		return ContextSelect
+	case *pg_query.DeleteStmt:
+		switch field {
+		case "relation":
+			return ContextDelete
+		case "where_clause":
+			return ContextWhere
+		case "returning_list":
+			return ContextReturning
+		}
+		return ContextSelect
+	case *pg_query.OnConflictClause:
+		switch field {
+		case "infer":
+			return ContextConflict
+		case "target_list":
+			return ContextUpdate
+		case "where_clause":
+			return ContextWhere
+		}
+	case *pg_query.InferClause:
+		if field == "where_clause" {
+			return ContextWhere
+		}
+	case *pg_query.JoinExpr:
+		if field == "quals" {
+			return ContextJoin
+		}
+	case *pg_query.ViewStmt, *pg_query.CreateTableAsStmt:
+		if field == "query" {
+			return ContextSelect
+		}
+	}
+	return ctx
+}
+
+// columnRef records a column reference: col, t.col, or s.t.col.
+func (w *astWalker) columnRef(n *pg_query.ColumnRef, ctx Context) {
+	if ctx == ContextDDL {
+		return
+	}
+	var parts []string
+	for _, f := range n.Fields {
+		s := f.GetString_()
+		if s == nil {
+			return // t.* or *
+		}
+		parts = append(parts, s.Sval)
+	}
+	if len(parts) == 0 {
+		return
+	}
+	c := astColumn{column: parts[len(parts)-1], context: ctx}
+	if len(parts) > 1 {
+		c.qualifier = parts[len(parts)-2]
+	}
+	w.columns = append(w.columns, c)
+}
+
+// resolve returns the statement's tables, without CTE names, and its
+// columns with qualifiers resolved to the tables they alias. A bare
+// column belongs to the statement's only table, if it has just one.
+// Columns of CTEs and subqueries, and ORDER BY references to SELECT
+// list aliases, are dropped.
+func (w *astWalker) resolve() ([]tableMatch, []columnMatch) {
+	var tables []tableMatch
+	distinct := make(map[string]bool)
+	for _, t := range w.relations {
+		if t.Schema == "" && w.derived[strings.ToLower(t.Table)] {
+			continue
+		}
+		tables = append(tables, t)
+		distinct[strings.ToLower(t.Schema+"."+t.Table)] = true
+	}
+	var only *pg_query.RangeVar
+	if len(distinct) == 1 && len(w.derived) == 0 {
+		for _, rel := range w.aliases {
+			only = rel
+			break
+		}
+	}
+
+	var columns []columnMatch
+	for _, c := range w.columns {
+		rel := c.table
+		switch {
+		case rel != nil:
+		case c.qualifier != "":
+			q := strings.ToLower(c.qualifier)
+			if w.derived[q] {
+				continue
+			}
+			if rel = w.aliases[q]; rel == nil || (rel.Schemaname == "" && w.derived[strings.ToLower(rel.Relname)]) {
+				continue // a CTE, or NEW, OLD, EXCLUDED
+			}
+		case w.outputs[strings.ToLower(c.column)] && c.context == ContextOrderBy:
+			continue
+		default:
+			rel = only
+		}
+		m := columnMatch{Column: c.column, Context: c.context}
+		if rel != nil {
+			m.Table, m.Schema = rel.Relname, rel.Schemaname
+		}
+		columns = append(columns, m)
+	}
+	return tables, columns
+}
+
+// nodeName returns the last part of a qualified name list, as in
+// pg_catalog.jsonb_path_exists or OPERATOR(pg_catalog.@>).
+func nodeName(nodes []*pg_query.Node) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	if s := nodes[len(nodes)-1].GetString_(); s != nil {
+		return strings.ToLower(s.Sval)
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build !pgquery
+
+package scanner
+
+// astAvailable reports whether this build includes libpg_query.
+const astAvailable = false
+
+// parseSQL is unavailable without libpg_query: nothing parses.
+func parseSQL(string) ([]tableMatch, []columnMatch, bool) {
+	return nil, nil, false
+}
@@ -0,0 +1,139 @@
+//go:build pgquery
+
+package scanner
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// matchStrings renders matches as sorted "schema.table:CONTEXT" and
+// "table.column:CONTEXT" strings for comparison.
+func matchStrings(tables []tableMatch, columns []columnMatch) (ts, cs []string) {
+	for _, t := range tables {
+		name := t.Table
+		if t.Schema != "" {
+			name = t.Schema + "." + name
+		}
+		ts = append(ts, name+":"+string(t.Context))
+	}
+	for _, c := range columns {
+		cs = append(cs, c.Table+"."+c.Column+":"+string(c.Context))
+	}
+	sort.Strings(ts)
+	sort.Strings(cs)
+	return ts, cs
+}
+
+func TestParseSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		tables  string
+		columns string
+	}{
+		{
+			name:    "aliases resolve to tables",
+			sql:     "SELECT u.email, o.total FROM users u JOIN orders o ON o.user_id = u.id WHERE o.status = 'paid' ORDER BY o.created_at",
+			tables:  "orders:SELECT users:SELECT",
+			columns: "orders.created_at:ORDER_BY orders.status:WHERE orders.total:SELECT orders.user_id:JOIN users.email:SELECT users.id:JOIN",
+		},
+		{
+			name:    "bare columns of the only table",
+			sql:     "SELECT name, count(*) AS n FROM public.accounts WHERE active GROUP BY name ORDER BY n",
+			tables:  "public.accounts:SELECT",
+			columns: "accounts.active:WHERE accounts.name:ORDER_BY accounts.name:SELECT",
+		},
+		{
+			name:    "CTE is not a table",
+			sql:     "WITH recent AS (SELECT user_id FROM orders WHERE created_at > now()) SELECT r.user_id FROM recent r JOIN users ON users.id = r.user_id",
+			tables:  "orders:SELECT users:SELECT",
+			columns: ".created_at:WHERE .user_id:SELECT users.id:JOIN",
+		},
+		{
+			name:    "join using",
+			sql:     "SELECT * FROM orders JOIN order_items USING (order_id)",
+			tables:  "order_items:SELECT orders:SELECT",
+			columns: "order_items.order_id:JOIN orders.order_id:JOIN",
+		},
+		{
+			name:    "upsert",
+			sql:     "INSERT INTO counters (key, hits) VALUES ($1, 1) ON CONFLICT (key) DO UPDATE SET hits = counters.hits + EXCLUDED.hits RETURNING hits",
+			tables:  "counters:INSERT",
+			columns: "counters.hits:INSERT counters.hits:RETURNING counters.hits:UPDATE counters.hits:UPDATE counters.key:INSERT counters.key:ON_CONFLICT",
+		},
+		{
+			name:    "update and delete",
+			sql:     "UPDATE accounts SET balance = 0 WHERE id = $1; DELETE FROM sessions WHERE expires_at < now()",
+			tables:  "accounts:UPDATE sessions:DELETE",
+			columns: "accounts.balance:UPDATE accounts.id:WHERE sessions.expires_at:WHERE",
+		},
+		{
+			name:    "lateral subquery",
+			sql:     "SELECT u.id, l.total FROM users u CROSS JOIN LATERAL (SELECT sum(total) AS total FROM orders WHERE orders.user_id = u.id) l",
+			tables:  "orders:SELECT users:SELECT",
+			columns: ".total:SELECT orders.user_id:WHERE users.id:SELECT users.id:WHERE",
+		},
+		{
+			name:    "jsonb condition",
+			sql:     "SELECT id FROM events WHERE payload @> '{}' AND jsonb_path_exists(meta, '$.a')",
+			tables:  "events:SELECT",
+			columns: "events.id:SELECT events.meta:JSONB_FILTER events.payload:JSONB_FILTER",
+		},
+		{
+			name:    "ddl",
+			sql:     "CREATE INDEX idx_orders_status ON orders (status)",
+			tables:  "orders:DDL",
+			columns: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, columns, ok := parseSQL(tt.sql)
+			if !ok {
+				t.Fatalf("%q did not parse", tt.sql)
+			}
+			ts, cs := matchStrings(tables, columns)
+			if got := strings.Join(ts, " "); got != tt.tables {
+				t.Errorf("tables = %q, want %q", got, tt.tables)
+			}
+			if got := strings.Join(cs, " "); got != tt.columns {
+				t.Errorf("columns = %q, want %q", got, tt.columns)
+			}
+		})
+	}
+}
+
+func TestParseSQL_Invalid(t *testing.T) {
+	for _, sql := range []string{"FROM users WHERE", "SELECT * FROM", ""} {
+		if _, _, ok := parseSQL(sql); ok {
+			t.Errorf("%q should not parse", sql)
+		}
+	}
+}
+
+func TestScan_ASTParser(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "repo.go", "package main\n"+
+		"var q = `WITH recent AS (SELECT * FROM orders)\nSELECT r.id FROM recent r`\n"+
+		"var u = \"UPDATE accounts SET balance = ? WHERE id = ?\"\n"+
+		"var part = \"SELECT * FROM payments WHERE \" + cond\n")
+
+	result, err := ScanWithOptions(dir, ScanOptions{Workers: 1, Parser: ParserAST})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Tables, ","); got != "accounts,orders,payments" {
+		t.Errorf("tables = %s, want accounts,orders,payments", got)
+	}
+	var cols []string
+	for _, c := range result.ColumnRefs {
+		cols = append(cols, c.Table+"."+c.Column)
+	}
+	sort.Strings(cols)
+	if got := strings.Join(cols, ","); got != "accounts.balance,accounts.id" {
+		t.Errorf("columns = %s, want accounts.balance,accounts.id", got)
+	}
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchSource is a file mixing raw SQL, ORM calls, and code with no
+// references, so every pattern family is exercised.
+const benchSource = `package store
+
+import "database/sql"
+
+func loadUser%[1]d(db *sql.DB, id int) error {
+	row := db.QueryRow("SELECT id, name, email FROM users WHERE id = $1", id)
+	_, err := db.Exec("UPDATE orders_%[1]d SET status = 'shipped' WHERE user_id = $1", id)
+	_, err = db.Exec("INSERT INTO audit_log (user_id, action) VALUES ($1, $2)", id, "load")
+	db.Table("payments").Where("user_id = ?", id)
+	for i := 0; i < 10; i++ {
+		total += i * 2 // plain code without table references
+	}
+	return row.Err()
+}
+`
+
+// benchRepo writes synthetic source files and returns the repo path
+// and its total size.
+func benchRepo(b *testing.B, files int) (string, int64) {
+	b.Helper()
+	dir := b.TempDir()
+	var size int64
+	for i := 0; i < files; i++ {
+		content := strings.Repeat(fmt.Sprintf(benchSource, i), 20)
+		writeFile(b, dir, fmt.Sprintf("pkg%d/file%d.go", i%10, i), content)
+		size += int64(len(content))
+	}
+	return dir, size
+}
+
+func BenchmarkScan(b *testing.B) {
+	dir, size := benchRepo(b, 50)
+	b.SetBytes(size)
+	for b.Loop() {
+		if _, err := Scan(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanParallel(b *testing.B) {
+	dir, size := benchRepo(b, 50)
+	b.SetBytes(size)
+	for b.Loop() {
+		if _, err := ScanParallel(dir, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanFile(b *testing.B) {
+	dir, size := benchRepo(b, 1)
+	path := dir + "/pkg0/file0.go"
+	b.SetBytes(size)
+	for b.Loop() {
+		if fr := scanFile(path, "pkg0/file0.go", ScanOptions{}); fr.err != nil {
+			b.Fatal(fr.err)
+		}
+	}
+}
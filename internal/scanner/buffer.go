@@ -18,8 +18,17 @@ type sqlBuffer struct {
 	kind      blockKind
 	lines     []string
 	startLine int
+
+	// ctes are the CTE names in scope for code lines outside a buffered
+	// string, defined last on cteLine; see lineCTEs.
+	ctes    map[string]bool
+	cteLine int
 }
 
+// cteScopeLines is how many lines after its definition a CTE name stays in
+// scope for code lines, when no semicolon ends the statement first.
+const cteScopeLines = 50
+
 // bufferedStatement is a completed multi-line SQL string with its origin line.
 type bufferedStatement struct {
 	text    string
@@ -33,7 +42,7 @@ var backtickExts = map[string]bool{
 
 // tripleQuoteExts are file extensions that use triple-quote multi-line strings.
 var tripleQuoteExts = map[string]bool{
-	".py": true, ".java": true,
+	".py": true, ".java": true, ".kt": true, ".cs": true, ".ex": true, ".exs": true,
 }
 
 func newSQLBuffer() *sqlBuffer {
@@ -54,6 +63,9 @@ func (b *sqlBuffer) reset() {
 // when semicolons are encountered.
 func (b *sqlBuffer) feedSQL(lineNum int, line string) []bufferedStatement {
 	if len(b.lines) == 0 {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
 		b.startLine = lineNum
 		b.kind = blockSQL
 	}
@@ -71,7 +83,7 @@ func (b *sqlBuffer) feedSQL(lineNum int, line string) []bufferedStatement {
 		if i < len(parts)-1 {
 			// Part before a semicolon — complete the statement
 			b.lines = append(b.lines, part)
-			text := normalize(b.lines)
+			text := b.text()
 			if text != "" {
 				results = append(results, bufferedStatement{
 					text:    text,
@@ -106,17 +118,15 @@ func (b *sqlBuffer) feedCode(lineNum int, line, ext string) (*bufferedStatement,
 		switch b.kind {
 		case blockBacktick:
 			if containsBacktick(line) {
-				text := normalize(b.lines)
-				text = trimAtBacktick(text)
-				result := &bufferedStatement{text: text, lineNum: b.startLine}
+				b.lines[len(b.lines)-1] = trimAtBacktick(line)
+				result := &bufferedStatement{text: b.text(), lineNum: b.startLine}
 				b.reset()
 				return result, true
 			}
 		case blockTripleQuote:
 			if containsTripleQuote(line) {
-				text := normalize(b.lines)
-				text = trimAtTripleQuote(text)
-				result := &bufferedStatement{text: text, lineNum: b.startLine}
+				b.lines[len(b.lines)-1] = trimAtTripleQuote(line)
+				result := &bufferedStatement{text: b.text(), lineNum: b.startLine}
 				b.reset()
 				return result, true
 			}
@@ -142,12 +152,35 @@ func (b *sqlBuffer) feedCode(lineNum int, line, ext string) (*bufferedStatement,
 	return nil, false
 }
 
+// lineCTEs returns the CTE names in scope for a code line that is not part
+// of a buffered string, as when a query is built by concatenating strings:
+// those the line defines, and those defined on earlier lines of the same
+// statement. Names go out of scope at a semicolon, or cteScopeLines lines
+// after the last definition.
+func (b *sqlBuffer) lineCTEs(lineNum int, line string) map[string]bool {
+	if b.ctes != nil && lineNum-b.cteLine > cteScopeLines {
+		b.ctes = nil
+	}
+	for name := range cteNames(line, b.ctes != nil) {
+		if b.ctes == nil {
+			b.ctes = make(map[string]bool)
+		}
+		b.ctes[name] = true
+		b.cteLine = lineNum
+	}
+	scope := b.ctes
+	if len(splitOnSemicolons(line)) > 1 {
+		b.ctes = nil
+	}
+	return scope
+}
+
 // flush returns a statement from any remaining buffered content.
 func (b *sqlBuffer) flush() *bufferedStatement {
 	if len(b.lines) == 0 {
 		return nil
 	}
-	text := normalize(b.lines)
+	text := b.text()
 	lineNum := b.startLine
 	b.reset()
 	if text == "" {
@@ -156,6 +189,15 @@ func (b *sqlBuffer) flush() *bufferedStatement {
 	return &bufferedStatement{text: text, lineNum: lineNum}
 }
 
+// text returns the buffered lines as one statement, without SQL comments.
+// .sql lines arrive already stripped, along with the file's other lines.
+func (b *sqlBuffer) text() string {
+	if b.kind == blockSQL {
+		return normalize(b.lines)
+	}
+	return normalize(stripSQLComments(b.lines))
+}
+
 // normalize joins lines and collapses whitespace to a single space.
 func normalize(lines []string) string {
 	joined := strings.Join(lines, " ")
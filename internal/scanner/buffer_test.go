@@ -171,6 +171,35 @@ func TestFeedCode_BacktickMultiLine(t *testing.T) {
 	}
 }
 
+func TestFeedCode_StripsSQLComments(t *testing.T) {
+	buf := newSQLBuffer()
+	lines := []string{
+		"query := `SELECT id -- FROM legacy",
+		"  /* JOIN audit",
+		"     ON audit.id = id */",
+		"FROM users -- hot path`",
+	}
+	var stmt *bufferedStatement
+	for i, line := range lines {
+		stmt, _ = buf.feedCode(i+1, line, ".go")
+	}
+	if stmt == nil {
+		t.Fatal("expected statement on close")
+	}
+	if want := "SELECT id FROM users"; stmt.text != want {
+		t.Errorf("text = %q, want %q", stmt.text, want)
+	}
+}
+
+func TestFeedSQL_SkipsLeadingBlankLines(t *testing.T) {
+	buf := newSQLBuffer()
+	buf.feedSQL(1, "")
+	stmts := buf.feedSQL(2, "SELECT * FROM users;")
+	if len(stmts) != 1 || stmts[0].lineNum != 2 {
+		t.Errorf("statements = %+v, want one starting on line 2", stmts)
+	}
+}
+
 func TestFeedCode_BacktickJS(t *testing.T) {
 	buf := newSQLBuffer()
 
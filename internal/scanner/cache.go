@@ -0,0 +1,309 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+// CacheDirEnv names the environment variable that overrides where scan
+// caches are kept, for example to persist them between CI runs.
+const CacheDirEnv = "PGSPECTRE_CACHE_DIR"
+
+// CacheDir returns the directory that holds scan caches: $PGSPECTRE_CACHE_DIR,
+// or pgspectre in the user's cache directory.
+func CacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pgspectre"), nil
+}
+
+// scanCachePath returns the cache file of a repository, named by a hash of
+// its absolute path.
+func scanCachePath(repoPath string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, "scan-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+var (
+	scannerVersionOnce sync.Once
+	scannerVersionKey  string
+)
+
+// scannerVersion identifies the build doing the scan: a hash of the
+// running executable, so any change to the patterns, model trackers, or
+// parsers discards caches written before. It falls back to the module
+// build info when the executable can't be read.
+func scannerVersion() string {
+	scannerVersionOnce.Do(func() {
+		h := sha256.New()
+		exe, err := os.Executable()
+		if err == nil {
+			var f *os.File
+			if f, err = os.Open(exe); err == nil {
+				_, err = io.Copy(h, f)
+				_ = f.Close()
+			}
+		}
+		if err != nil {
+			h.Reset()
+			if info, ok := debug.ReadBuildInfo(); ok {
+				h.Write([]byte(info.String()))
+			}
+		}
+		scannerVersionKey = hex.EncodeToString(h.Sum(nil))
+	})
+	return scannerVersionKey
+}
+
+// scanCache holds the references of every file of a repository, keyed by
+// path, with a hash of the content they were read from. Commit and Dirty
+// say which working copy they match, for ScanChanged: the commit plus
+// the dirty files, which differed from it or were untracked.
+type scanCache struct {
+	Version string                `json:"version"` // scannerVersion of the scan
+	Options string                `json:"options"` // optionsKey of the scan
+	Commit  string                `json:"commit,omitempty"`
+	Dirty   []string              `json:"dirty,omitempty"`
+	Files   map[string]cachedFile `json:"files"`
+}
+
+// cachedFile is the scan result of one file.
+type cachedFile struct {
+	Hash        string          `json:"hash,omitempty"` // sha256 of the content; empty if unsupported
+	Refs        []TableRef      `json:"refs,omitempty"`
+	ColumnRefs  []ColumnRef     `json:"columnRefs,omitempty"`
+	Queries     []QueryRef      `json:"queries,omitempty"`
+	DynamicRefs []DynamicRef    `json:"dynamicRefs,omitempty"`
+	Migration   *migration.File `json:"migration,omitempty"`
+	Skipped     bool            `json:"skipped,omitempty"`
+}
+
+// scanCached walks a repository as ScanWithOptions does, reading again
+// only the files whose content differs from the scan cache, and updates
+// the cache.
+func scanCached(repoPath string, opts ScanOptions) (ScanResult, error) {
+	c := loadScanCache(repoPath, opts)
+	if err := c.rescanAll(repoPath, opts); err != nil {
+		return ScanResult{RepoPath: repoPath}, err
+	}
+	c.Commit, c.Dirty = "", nil
+	if head, err := gitCommit(repoPath, "HEAD"); err == nil {
+		if dirty, err := gitChangedFiles(repoPath, head); err == nil {
+			c.Commit, c.Dirty = head, dirty
+		}
+	}
+	c.save(repoPath)
+	return c.result(repoPath, opts.MaxRefBytes)
+}
+
+// loadScanCache returns the repository's scan cache, or an empty one if
+// it has none that can be read, or one written by another build or with
+// other options.
+func loadScanCache(repoPath string, opts ScanOptions) *scanCache {
+	key := optionsKey(opts)
+	empty := &scanCache{Version: scannerVersion(), Options: key, Files: make(map[string]cachedFile)}
+	path, err := scanCachePath(repoPath)
+	if err != nil {
+		return empty
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty
+	}
+	var c scanCache
+	if err := json.Unmarshal(data, &c); err != nil || c.Version != empty.Version || c.Options != key || c.Files == nil {
+		return empty
+	}
+	return &c
+}
+
+// rescanAll walks the repository and replaces the cached files with the
+// files it holds now.
+func (c *scanCache) rescanAll(repoPath string, opts ScanOptions) error {
+	old := c.Files
+	c.Files = make(map[string]cachedFile, len(old))
+	var read []string
+	err := walkRepo(repoPath, opts, func(path string, d fs.DirEntry, supported bool) error {
+		relPath, _ := filepath.Rel(repoPath, path)
+		relPath = filepath.ToSlash(relPath)
+		if supported {
+			read = append(read, relPath)
+		} else {
+			c.Files[relPath] = cachedFile{Skipped: true}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk %s: %w", repoPath, err)
+	}
+	return c.rescan(repoPath, read, old, opts)
+}
+
+// rescan scans the files at relPaths, slash paths relative to repoPath,
+// with opts.Workers goroutines and stores their results. A file whose
+// content hashes as its entry in old does is not read again.
+func (c *scanCache) rescan(repoPath string, relPaths []string, old map[string]cachedFile, opts ScanOptions) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]cachedFile, len(relPaths))
+	errs := make([]error, len(relPaths))
+	next := make(chan int, len(relPaths))
+	for i := range relPaths {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = scanCachedFile(repoPath, relPaths[i], old, opts)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, relPath := range relPaths {
+		if errs[i] != nil {
+			return fmt.Errorf("scan %s: %w", relPath, errs[i])
+		}
+		c.Files[relPath] = results[i]
+	}
+	return nil
+}
+
+// scanCachedFile scans one file unless its entry in old has the same
+// content hash.
+func scanCachedFile(repoPath, slashPath string, old map[string]cachedFile, opts ScanOptions) (cachedFile, error) {
+	relPath := filepath.FromSlash(slashPath)
+	path := filepath.Join(repoPath, relPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if f, ok := old[slashPath]; ok && f.Hash == hash {
+		return f, nil
+	}
+
+	fr := withMigration(scanContent(bytes.NewReader(data), relPath, opts), path, relPath, opts)
+	if fr.err != nil {
+		return cachedFile{}, fr.err
+	}
+	return cachedFile{
+		Hash:        hash,
+		Refs:        fr.refs,
+		ColumnRefs:  fr.colRefs,
+		Queries:     fr.queries,
+		DynamicRefs: fr.dynamic,
+		Migration:   fr.migration,
+		Skipped:     fr.skipped,
+	}, nil
+}
+
+// result merges the cached files into a scan result, in path order.
+func (c *scanCache) result(repoPath string, maxRefBytes int64) (ScanResult, error) {
+	paths := make([]string, 0, len(c.Files))
+	for p := range c.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	col := newCollector(repoPath, maxRefBytes)
+	for _, p := range paths {
+		f := c.Files[p]
+		fr := fileResult{refs: f.Refs, colRefs: f.ColumnRefs, queries: f.Queries, dynamic: f.DynamicRefs, skipped: f.Skipped}
+		if f.Migration != nil {
+			mf := *f.Migration
+			mf.Path = filepath.Join(repoPath, filepath.FromSlash(p))
+			fr.migration = &mf
+		}
+		if err := col.add(fr); err != nil {
+			return col.finish(err)
+		}
+	}
+	return col.finish(nil)
+}
+
+// save writes the cache, warning rather than failing the scan when it
+// can't, as on a read-only home directory.
+func (c *scanCache) save(repoPath string) {
+	if err := c.write(repoPath); err != nil {
+		slog.Warn("scan cache not saved", "error", err)
+	}
+}
+
+// write saves the cache, replacing the file atomically.
+func (c *scanCache) write(repoPath string) error {
+	path, err := scanCachePath(repoPath)
+	if err != nil {
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write scan cache: %w", err)
+	}
+	return nil
+}
+
+// optionsKey identifies the options that change what a scan finds, so a
+// cache written with others isn't used.
+func optionsKey(opts ScanOptions) string {
+	opts.Workers, opts.MaxRefBytes, opts.Cache = 0, 0, false
+	data, _ := json.Marshal(opts)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
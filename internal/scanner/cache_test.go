@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTempCacheDir keeps the scan caches of a test in a temp directory.
+func useTempCacheDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(CacheDirEnv, dir)
+	return dir
+}
+
+func TestScanWithOptions_Cache(t *testing.T) {
+	useTempCacheDir(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "orders.sql", "SELECT id FROM orders;")
+	writeFile(t, dir, "stock.sql", "SELECT id FROM stock;")
+	opts := ScanOptions{Workers: 2, Cache: true}
+
+	result, err := ScanWithOptions(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders", "stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Fatalf("tables = %v, want %v", result.Tables, want)
+	}
+
+	// A file whose content is unchanged is taken from the cache.
+	c := loadScanCache(dir, opts)
+	f := c.Files["stock.sql"]
+	if f.Hash == "" {
+		t.Fatalf("expected stock.sql in the cache, got %+v", c.Files)
+	}
+	f.Refs = append(f.Refs, TableRef{Table: "cached_only", File: "stock.sql", Line: 1, Pattern: PatternSQL, Context: ContextSelect})
+	c.Files["stock.sql"] = f
+	if err := c.write(dir); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "orders.sql", "SELECT id FROM invoices;")
+
+	result, err = ScanWithOptions(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cached_only", "invoices", "stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+
+	// Other options discard the cache.
+	result, err = ScanWithOptions(dir, ScanOptions{Workers: 1, Cache: true, ExcludePaths: []string{"none/**"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"invoices", "stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables with other options = %v, want %v", result.Tables, want)
+	}
+}
+
+func TestLoadScanCache_OtherBuild(t *testing.T) {
+	useTempCacheDir(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "stock.sql", "SELECT id FROM stock;")
+	opts := ScanOptions{Cache: true}
+	if _, err := ScanWithOptions(dir, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	c := loadScanCache(dir, opts)
+	if len(c.Files) == 0 {
+		t.Fatal("expected a scan cache")
+	}
+	c.Version = "other build"
+	if err := c.write(dir); err != nil {
+		t.Fatal(err)
+	}
+	if c := loadScanCache(dir, opts); len(c.Files) != 0 || c.Version != scannerVersion() {
+		t.Errorf("cache of another build was used: %+v", c)
+	}
+}
+
+func TestScanWithOptions_CacheOutsideRepo(t *testing.T) {
+	cacheDir := useTempCacheDir(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "stock.sql", "SELECT id FROM stock;")
+	if _, err := ScanWithOptions(dir, ScanOptions{Cache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("scan wrote into the repository: %v", entries)
+	}
+	path, err := scanCachePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != cacheDir {
+		t.Errorf("cache path = %s, want it in %s", path, cacheDir)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected a scan cache: %v", err)
+	}
+}
+
+func TestScanWithOptions_UnwritableCache(t *testing.T) {
+	// A file in the cache directory's place keeps the cache from being
+	// saved, even for root.
+	blocker := filepath.Join(t.TempDir(), "cache")
+	writeFile(t, filepath.Dir(blocker), "cache", "")
+	t.Setenv(CacheDirEnv, blocker)
+	dir := t.TempDir()
+	writeFile(t, dir, "stock.sql", "SELECT id FROM stock;")
+
+	result, err := ScanWithOptions(dir, ScanOptions{Cache: true})
+	if err != nil {
+		t.Fatalf("scan with an unwritable cache failed: %v", err)
+	}
+	if want := []string{"stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ScanChanged scans a git repository as ScanWithOptions does, reading
+// only the files changed since the merge-base of ref and HEAD, including
+// uncommitted and untracked ones. The references of the other files come
+// from the repository's scan cache, which ScanChanged keeps up to date;
+// files that changed since the cache was written are read as well.
+// Without a cache of a commit in the history, every file is hashed and
+// those whose content the cache doesn't hold are read. With
+// opts.MaxRefBytes set every file is read, as the cache can't be held
+// within the cap.
+func ScanChanged(repoPath, ref string, opts ScanOptions) (ScanResult, error) {
+	fail := func(err error) (ScanResult, error) {
+		return ScanResult{RepoPath: repoPath}, err
+	}
+	if _, err := git(repoPath, "rev-parse", "--git-dir"); err != nil {
+		return fail(err)
+	}
+	refCommit, err := gitCommit(repoPath, ref)
+	if err != nil {
+		return fail(err)
+	}
+	base, err := git(repoPath, "merge-base", refCommit, "HEAD")
+	if err != nil {
+		return fail(err)
+	}
+	base = strings.TrimSpace(base)
+	head, err := gitCommit(repoPath, "HEAD")
+	if err != nil {
+		return fail(err)
+	}
+	if opts.MaxRefBytes > 0 {
+		opts.Cache = false
+		return ScanWithOptions(repoPath, opts)
+	}
+
+	c := loadScanCache(repoPath, opts)
+	if !gitHasCommit(repoPath, c.Commit) {
+		if err := c.rescanAll(repoPath, opts); err != nil {
+			return fail(err)
+		}
+	} else {
+		changed, err := gitChangedFiles(repoPath, base)
+		if err != nil {
+			return fail(err)
+		}
+		sinceCache, err := gitChangedFiles(repoPath, c.Commit)
+		if err != nil {
+			return fail(err)
+		}
+		filter := newFileFilter(repoPath, opts)
+		seen := make(map[string]bool)
+		var read []string
+		for _, relPath := range append(append(changed, sinceCache...), c.Dirty...) {
+			if seen[relPath] {
+				continue
+			}
+			seen[relPath] = true
+			info, err := os.Stat(filepath.Join(repoPath, filepath.FromSlash(relPath)))
+			switch {
+			case err != nil || info.IsDir() || filter.skipFile(relPath):
+				delete(c.Files, relPath) // deleted, or left out of the scan
+			case filter.supported(relPath):
+				read = append(read, relPath)
+			default:
+				c.Files[relPath] = cachedFile{Skipped: true}
+			}
+		}
+		if err := c.rescan(repoPath, read, c.Files, opts); err != nil {
+			return fail(err)
+		}
+	}
+
+	// Every file that differed from the cache has been read again, so the
+	// cache now holds the working copy: HEAD plus its dirty files.
+	dirty, err := gitChangedFiles(repoPath, head)
+	if err != nil {
+		return fail(err)
+	}
+	c.Commit, c.Dirty = head, dirty
+	c.save(repoPath)
+	return c.result(repoPath, opts.MaxRefBytes)
+}
+
+// gitHasCommit reports whether the repository has the commit, which a
+// shallow clone or a rewritten history may not.
+func gitHasCommit(repoPath, commit string) bool {
+	if commit == "" {
+		return false
+	}
+	_, err := git(repoPath, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestScanChanged(t *testing.T) {
+	useTempCacheDir(t)
+	dir := gitRepo(t)
+	writeFile(t, dir, "app.go", `package main
+func main() { db.Query("SELECT * FROM users") }`)
+	writeFile(t, dir, "db/orders.sql", "SELECT id FROM orders;")
+	writeFile(t, dir, "db/stock.sql", "SELECT id FROM stock;")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-qm", "initial")
+
+	// Without a cache, every file is read.
+	result, err := ScanChanged(dir, "HEAD", ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders", "stock", "users"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Fatalf("tables = %v, want %v", result.Tables, want)
+	}
+
+	// Unchanged files come from the cache: a table only it holds shows up.
+	cache := loadScanCache(dir, ScanOptions{})
+	if cache.Commit == "" || len(cache.Files) == 0 {
+		t.Fatalf("expected a scan cache of a commit, got %+v", cache)
+	}
+	f := cache.Files["db/stock.sql"]
+	f.Refs = append(f.Refs, TableRef{Table: "cached_only", File: "db/stock.sql", Line: 1, Pattern: PatternSQL, Context: ContextSelect})
+	cache.Files["db/stock.sql"] = f
+	if err := cache.write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "db/orders.sql", "SELECT id FROM invoices;")
+	writeFile(t, dir, "jobs.py", `cur.execute("SELECT * FROM payments")`)
+	if err := os.Remove(filepath.Join(dir, "app.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = ScanChanged(dir, "HEAD", ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cached_only", "invoices", "payments", "stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+	if result.FilesScanned != 3 {
+		t.Errorf("files scanned = %d, want 3", result.FilesScanned)
+	}
+
+	// Committing the changes and reverting one is seen through the cache.
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-qm", "change")
+	writeFile(t, dir, "db/orders.sql", "SELECT id FROM orders;")
+	result, err = ScanChanged(dir, "HEAD", ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cached_only", "orders", "payments", "stock"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+}
+
+func TestScanChanged_Errors(t *testing.T) {
+	useTempCacheDir(t)
+	dir := gitRepo(t)
+	writeFile(t, dir, "query.sql", "SELECT id FROM orders;")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-qm", "initial")
+
+	if _, err := ScanChanged(dir, "no-such-branch", ScanOptions{}); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+	if _, err := ScanChanged(t.TempDir(), "HEAD", ScanOptions{}); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import "strings"
+
+// commentSyntax describes how a language writes comments, and the string
+// literals comment markers are not looked for in.
+type commentSyntax struct {
+	line       []string // markers that comment out the rest of the line
+	blockOpen  string   // empty when the language has no block comments
+	blockClose string
+	quotes     string // characters that open and close a string literal
+	escapes    bool   // backslash escapes the next character in a literal
+}
+
+var (
+	sqlComments   = commentSyntax{line: []string{"--"}, blockOpen: "/*", blockClose: "*/", quotes: `'"`}
+	cComments     = commentSyntax{line: []string{"//"}, blockOpen: "/*", blockClose: "*/", quotes: "\"'`", escapes: true}
+	hashComments  = commentSyntax{line: []string{"#"}, quotes: `"'`, escapes: true}
+	slashComments = commentSyntax{line: []string{"//"}, quotes: `"`, escapes: true}
+)
+
+// commentSyntaxes maps file extensions to their comment syntax. Rust uses
+// single quotes for lifetimes, so only double quotes delimit its strings;
+// C# has no backtick strings. PHP's # comments are left alone, since
+// #[...] opens an attribute.
+var commentSyntaxes = map[string]commentSyntax{
+	".sql":    sqlComments,
+	".go":     cComments,
+	".js":     cComments,
+	".ts":     cComments,
+	".jsx":    cComments,
+	".tsx":    cComments,
+	".java":   cComments,
+	".kt":     cComments,
+	".cs":     {line: []string{"//"}, blockOpen: "/*", blockClose: "*/", quotes: `"'`, escapes: true},
+	".php":    {line: []string{"//"}, blockOpen: "/*", blockClose: "*/", quotes: `"'`, escapes: true},
+	".rs":     {line: []string{"//"}, blockOpen: "/*", blockClose: "*/", quotes: `"`, escapes: true},
+	".py":     hashComments,
+	".rb":     hashComments,
+	".ex":     hashComments,
+	".exs":    hashComments,
+	".prisma": slashComments,
+}
+
+// commentStripper removes comments from successive lines of one file or
+// string, tracking block comments that span lines. A comment is replaced
+// by a space so the tokens around it stay apart.
+type commentStripper struct {
+	syntax  commentSyntax
+	inBlock bool
+}
+
+func newCommentStripper(syntax commentSyntax) *commentStripper {
+	return &commentStripper{syntax: syntax}
+}
+
+// strip returns line without its comments. A string literal left open at
+// the end of the line is assumed to end there; multi-line strings are the
+// sqlBuffer's to track.
+func (c *commentStripper) strip(line string) string {
+	syn := c.syntax
+	if !c.inBlock && (syn.blockOpen == "" || !strings.Contains(line, syn.blockOpen)) && !containsAny(line, syn.line) {
+		return line
+	}
+
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case c.inBlock:
+			if strings.HasPrefix(line[i:], syn.blockClose) {
+				c.inBlock = false
+				i += len(syn.blockClose) - 1
+				sb.WriteByte(' ')
+			}
+		case quote != 0:
+			sb.WriteByte(ch)
+			if syn.escapes && ch == '\\' && i+1 < len(line) {
+				i++
+				sb.WriteByte(line[i])
+			} else if ch == quote {
+				quote = 0
+			}
+		case strings.IndexByte(syn.quotes, ch) >= 0:
+			quote = ch
+			sb.WriteByte(ch)
+		case syn.blockOpen != "" && strings.HasPrefix(line[i:], syn.blockOpen):
+			c.inBlock = true
+			i += len(syn.blockOpen) - 1
+		case hasPrefixAny(line[i:], syn.line):
+			return strings.TrimRight(sb.String(), " \t")
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+// stripSQLComments removes SQL comments from the lines of one statement.
+func stripSQLComments(lines []string) []string {
+	c := newCommentStripper(sqlComments)
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = c.strip(l)
+	}
+	return out
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefixAny(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
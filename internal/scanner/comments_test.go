@@ -0,0 +1,62 @@
+package scanner
+
+import "testing"
+
+func TestCommentStripper_Line(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  string
+		line string
+		want string
+	}{
+		{"sql line comment", ".sql", "SELECT 1 FROM users -- from orders", "SELECT 1 FROM users"},
+		{"sql whole line", ".sql", "-- DROP TABLE legacy;", ""},
+		{"sql inline block", ".sql", "SELECT /* FROM audit */ id FROM users", "SELECT   id FROM users"},
+		{"sql marker in string", ".sql", "SELECT '--' FROM users", "SELECT '--' FROM users"},
+		{"sql doubled quote", ".sql", "SELECT 'it''s' -- FROM orders", "SELECT 'it''s'"},
+		{"go line comment", ".go", `rows, err := db.Query(q) // FROM orders`, `rows, err := db.Query(q)`},
+		{"go marker in string", ".go", `u := "http://host/users"`, `u := "http://host/users"`},
+		{"go escaped quote", ".go", `s := "a \" // b" // FROM orders`, `s := "a \" // b"`},
+		{"python hash", ".py", `cur.execute(q)  # SELECT * FROM orders`, `cur.execute(q)`},
+		{"python hash in string", ".py", `q = "SELECT * FROM users WHERE tag = '#1'"`, `q = "SELECT * FROM users WHERE tag = '#1'"`},
+		{"ruby hash", ".rb", `User.where(active: true) # joins(:orders)`, `User.where(active: true)`},
+		{"rust lifetime", ".rs", `fn get<'a>(c: &'a Conn) // FROM orders`, `fn get<'a>(c: &'a Conn)`},
+		{"no comment", ".java", `String q = "SELECT * FROM users";`, `String q = "SELECT * FROM users";`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newCommentStripper(commentSyntaxes[tt.ext]).strip(tt.line)
+			if got != tt.want {
+				t.Errorf("strip(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentStripper_BlockSpansLines(t *testing.T) {
+	c := newCommentStripper(sqlComments)
+	lines := []string{
+		"/*",
+		" * Migration 0042: replaces legacy_orders",
+		" * SELECT * FROM legacy_orders;",
+		" */ CREATE TABLE orders (id INT); /* trailing",
+		"still comment */ SELECT 1;",
+	}
+	want := []string{"", "", "", "  CREATE TABLE orders (id INT); ", "  SELECT 1;"}
+	for i, line := range lines {
+		if got := c.strip(line); got != want[i] {
+			t.Errorf("line %d: strip(%q) = %q, want %q", i+1, line, got, want[i])
+		}
+	}
+}
+
+func TestStripSQLComments(t *testing.T) {
+	got := stripSQLComments([]string{"SELECT id -- the key", "/* FROM audit", "*/ FROM users"})
+	want := []string{"SELECT id", "", "  FROM users"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
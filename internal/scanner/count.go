@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// FileCount summarizes the files a scan would read.
+type FileCount struct {
+	Files   int   `json:"files"`
+	Skipped int   `json:"skipped"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// CountFiles walks a repository the same way a scan with opts does but
+// only counts supported files and their total size, without reading them.
+func CountFiles(repoPath string, opts ScanOptions) (FileCount, error) {
+	var count FileCount
+
+	err := walkRepo(repoPath, opts, func(path string, d fs.DirEntry, supported bool) error {
+		if !supported {
+			count.Skipped++
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		count.Files++
+		count.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("walk %s: %w", repoPath, err)
+	}
+	return count, nil
+}
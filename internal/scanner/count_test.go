@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":                 "package main\n",
+		"schema.sql":              "CREATE TABLE users (id int);\n",
+		"README.md":               "# readme\n",
+		"node_modules/lib/x.js":   "SELECT * FROM hidden",
+		"internal/db/queries.sql": "SELECT 1;",
+	}
+	var wantBytes int64
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "README.md" && name != "node_modules/lib/x.js" {
+			wantBytes += int64(len(content))
+		}
+	}
+
+	count, err := CountFiles(dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Files != 3 {
+		t.Errorf("Files = %d, want 3", count.Files)
+	}
+	if count.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", count.Skipped)
+	}
+	if count.Bytes != wantBytes {
+		t.Errorf("Bytes = %d, want %d", count.Bytes, wantBytes)
+	}
+}
+
+func TestCountFiles_MissingDir(t *testing.T) {
+	if _, err := CountFiles(filepath.Join(t.TempDir(), "missing"), ScanOptions{}); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+}
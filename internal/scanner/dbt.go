@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dbt Jinja in model SQL. ref('model') and ref('package', 'model') name
+// another model's relation; source('source', 'table') a table loaded
+// outside dbt, in the schema named after the source unless its YAML says
+// otherwise.
+var (
+	dbtRefRe     = regexp.MustCompile(`\{\{\s*ref\s*\(\s*(?:['"]\w+['"]\s*,\s*)?['"](\w+)['"]\s*(?:,\s*v(?:ersion)?\s*=\s*\w+\s*)?\)\s*\}\}`)
+	dbtSourceRe  = regexp.MustCompile(`\{\{\s*source\s*\(\s*['"](\w+)['"]\s*,\s*['"](\w+)['"]\s*\)\s*\}\}`)
+	dbtConfigRe  = regexp.MustCompile(`\{\{\s*config\s*\(`)
+	dbtAliasRe   = regexp.MustCompile(`\balias\s*=\s*['"](\w+)['"]`)
+	dbtSchemaRe  = regexp.MustCompile(`\bschema\s*=\s*['"](\w+)['"]`)
+	dbtEphemeral = regexp.MustCompile(`\bmaterialized\s*=\s*['"]ephemeral['"]`)
+)
+
+// supportedFiles are scanned by name, whatever their extension.
+var supportedFiles = map[string]bool{
+	"manifest.json": true, // dbt
+}
+
+// dbtModel tracks the dbt Jinja of one SQL file. A file that calls ref(),
+// source(), or config() is a dbt model, which builds a relation named
+// after the file unless its config sets an alias; ephemeral models build
+// none.
+type dbtModel struct {
+	model     bool
+	alias     string
+	schema    string
+	ephemeral bool
+}
+
+// resolve replaces the ref() and source() calls in a line of model SQL
+// with the relations they name, so the SQL patterns see plain table
+// names.
+func (d *dbtModel) resolve(line string) string {
+	if !strings.Contains(line, "{{") {
+		return line
+	}
+	if dbtConfigRe.MatchString(line) {
+		d.model = true
+		if m := dbtAliasRe.FindStringSubmatch(line); m != nil {
+			d.alias = m[1]
+		}
+		if m := dbtSchemaRe.FindStringSubmatch(line); m != nil {
+			d.schema = m[1]
+		}
+		d.ephemeral = d.ephemeral || dbtEphemeral.MatchString(line)
+	}
+	resolved := dbtRefRe.ReplaceAllString(line, "$1")
+	resolved = dbtSourceRe.ReplaceAllString(resolved, "$1.$2")
+	if resolved != line {
+		d.model = true
+	}
+	return resolved
+}
+
+// refs returns the reference a model makes to the relation it builds.
+func (d *dbtModel) refs(relPath string) []TableRef {
+	if !d.model || d.ephemeral {
+		return nil
+	}
+	name := d.alias
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+	}
+	if !isValidTableName(name) {
+		return nil
+	}
+	return []TableRef{{Table: name, Schema: d.schema, File: relPath, Line: 1, Pattern: PatternORM, Context: ContextDDL}}
+}
+
+// dbtManifest is the part of a dbt manifest.json the scanner reads.
+type dbtManifest struct {
+	Metadata struct {
+		SchemaVersion string `json:"dbt_schema_version"`
+	} `json:"metadata"`
+	Nodes   map[string]dbtNode `json:"nodes"`
+	Sources map[string]dbtNode `json:"sources"`
+}
+
+type dbtNode struct {
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+	Alias        string `json:"alias"`
+	Identifier   string `json:"identifier"`
+	Schema       string `json:"schema"`
+	Path         string `json:"original_file_path"`
+	Config       struct {
+		Materialized string `json:"materialized"`
+	} `json:"config"`
+	Columns map[string]struct {
+		Name string `json:"name"`
+	} `json:"columns"`
+}
+
+// dbtRelationTypes are the dbt resources that build or read a relation.
+var dbtRelationTypes = map[string]bool{
+	"model":    true,
+	"seed":     true,
+	"snapshot": true,
+	"source":   true,
+}
+
+// scanManifest reads the relations and documented columns of a dbt
+// manifest.json: the models, seeds, and snapshots the project builds and
+// the sources it reads. References point at the file that defines each,
+// relative to the project, which holds the manifest in target/. Other
+// manifest.json files are skipped.
+func scanManifest(r io.Reader, relPath string) fileResult {
+	var m dbtManifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil || m.Metadata.SchemaVersion == "" {
+		return fileResult{skipped: true, filePath: relPath}
+	}
+	project := filepath.Dir(filepath.Dir(relPath))
+
+	keys := make([]string, 0, len(m.Nodes)+len(m.Sources))
+	nodes := make(map[string]dbtNode, cap(keys))
+	for k, n := range m.Nodes {
+		keys, nodes[k] = append(keys, k), n
+	}
+	for k, n := range m.Sources {
+		keys, nodes[k] = append(keys, k), n
+	}
+	sort.Strings(keys)
+
+	fr := fileResult{filePath: relPath}
+	for _, k := range keys {
+		n := nodes[k]
+		if !dbtRelationTypes[n.ResourceType] || n.Config.Materialized == "ephemeral" {
+			continue
+		}
+		name := n.Name
+		ctx := ContextDDL
+		switch {
+		case n.ResourceType == "source":
+			ctx = ContextSelect
+			if n.Identifier != "" {
+				name = n.Identifier
+			}
+		case n.Alias != "":
+			name = n.Alias
+		}
+		if !isValidTableName(name) {
+			continue
+		}
+		file := relPath
+		if n.Path != "" {
+			file = filepath.Join(project, filepath.FromSlash(n.Path))
+		}
+		fr.refs = append(fr.refs, TableRef{Table: name, Schema: n.Schema, File: file, Line: 1, Pattern: PatternORM, Context: ctx})
+
+		columns := make([]string, 0, len(n.Columns))
+		for key, c := range n.Columns {
+			if c.Name == "" {
+				c.Name = key
+			}
+			columns = append(columns, c.Name)
+		}
+		sort.Strings(columns)
+		for _, c := range columns {
+			fr.colRefs = append(fr.colRefs, ColumnRef{Table: name, Column: c, Schema: n.Schema, File: file, Line: 1, Context: ContextUnknown})
+		}
+	}
+	return fr
+}
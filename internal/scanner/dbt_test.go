@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDBTModelResolve(t *testing.T) {
+	var d dbtModel
+	tests := []struct{ in, want string }{
+		{"select * from {{ ref('stg_orders') }} o", "select * from stg_orders o"},
+		{`join {{ref("jaffle", "customers")}} c using (id)`, "join customers c using (id)"},
+		{"from {{ ref('dim_dates', v=2) }}", "from dim_dates"},
+		{"from {{ source('raw', 'payments') }}", "from raw.payments"},
+		{"from {{ var('events_table') }}", "from {{ var('events_table') }}"},
+		{"select 1", "select 1"},
+	}
+	for _, tt := range tests {
+		if got := d.resolve(tt.in); got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if !d.model {
+		t.Error("a file calling ref() is a model")
+	}
+}
+
+func TestScan_DBT(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "analytics/models/staging/stg_orders.sql", "select id, customer_id\nfrom {{ source('raw', 'orders') }}\n")
+	writeFile(t, dir, "analytics/models/marts/fct_orders.sql", "{{ config(materialized='table', alias='orders_fact', schema='marts') }}\nselect o.id\nfrom {{ ref('stg_orders') }} o\n")
+	writeFile(t, dir, "analytics/models/staging/int_tmp.sql", "{{ config(materialized='ephemeral') }}\nselect 1\n")
+	writeFile(t, dir, "analytics/target/manifest.json", `{
+  "metadata": {"dbt_schema_version": "https://schemas.getdbt.com/dbt/manifest/v12.json"},
+  "nodes": {
+    "model.jaffle.stg_orders": {"resource_type": "model", "name": "stg_orders", "alias": "stg_orders", "schema": "analytics",
+      "original_file_path": "models/staging/stg_orders.sql", "config": {"materialized": "view"},
+      "columns": {"id": {"name": "id"}, "customer_id": {"name": "customer_id"}}},
+    "model.jaffle.int_tmp": {"resource_type": "model", "name": "int_tmp", "schema": "analytics", "config": {"materialized": "ephemeral"}},
+    "test.jaffle.not_null_id": {"resource_type": "test", "name": "not_null_id", "schema": "analytics"}
+  },
+  "sources": {
+    "source.jaffle.raw.orders": {"resource_type": "source", "name": "orders", "identifier": "raw_orders", "schema": "raw",
+      "original_file_path": "models/sources.yml", "columns": {}}
+  }
+}`)
+	writeFile(t, dir, "web/manifest.json", `{"name": "app", "icons": []}`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	var got []string
+	for _, r := range result.Refs {
+		if r.Table == "raw" {
+			continue // the schema of raw.orders, as for any qualified name
+		}
+		got = append(got, r.Schema+"."+r.Table+"@"+filepath.ToSlash(r.File)+":"+string(r.Context))
+	}
+	sort.Strings(got)
+	want := []string{
+		".stg_orders@analytics/models/marts/fct_orders.sql:SELECT",
+		".stg_orders@analytics/models/staging/stg_orders.sql:DDL",
+		"analytics.stg_orders@analytics/models/staging/stg_orders.sql:DDL",
+		"marts.orders_fact@analytics/models/marts/fct_orders.sql:DDL",
+		"raw.orders@analytics/models/staging/stg_orders.sql:SELECT",
+		"raw.raw_orders@analytics/models/sources.yml:SELECT",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refs =\n%v\nwant\n%v", got, want)
+	}
+	if result.FilesScanned != 4 || result.FilesSkipped != 1 {
+		t.Errorf("scanned %d, skipped %d; want 4 and 1", result.FilesScanned, result.FilesSkipped)
+	}
+
+	var columns []string
+	for _, c := range result.ColumnRefs {
+		if c.Table == "stg_orders" {
+			columns = append(columns, c.Table+"."+c.Column)
+		}
+	}
+	if want := []string{"stg_orders.customer_id", "stg_orders.id"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("manifest columns = %v, want %v", columns, want)
+	}
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Django model classes and the Meta options that decide their table.
+var (
+	djangoClassRe     = regexp.MustCompile(`^(\s*)class\s+(\w+)\s*\(([^)]*)\)\s*:`)
+	djangoModelBaseRe = regexp.MustCompile(`\w*Model\b`)
+	djangoMetaRe      = regexp.MustCompile(`^\s*class\s+Meta\b`)
+	djangoOwnTableRe  = regexp.MustCompile(`^\s*(?:db_table\s*=|abstract\s*=\s*True\b|proxy\s*=\s*True\b)`)
+	djangoAppLabelRe  = regexp.MustCompile(`^\s*app_label\s*=\s*["'](\w+)["']`)
+	djangoModelsDirRe = regexp.MustCompile(`(?:^|/)models(?:\.py$|/)`)
+)
+
+// djangoModel is a model class whose table may come from its name.
+type djangoModel struct {
+	name       string
+	line       int
+	indent     int
+	suppressed bool
+	appLabel   string // from Meta, if set
+	ownTable   bool   // Meta names its table, or it has none
+}
+
+// djangoModels tracks the model classes of a Django app's models module,
+// models.py or a models/ package. A model without db_table maps to
+// <app_label>_<model name in lowercase>, where the app label is the name
+// of the app's directory unless labels, keyed by that name or the
+// directory's path in the repository, maps it to another, or Meta sets
+// app_label. Abstract and proxy models have no table of their own.
+type djangoModels struct {
+	labels  map[string]string
+	models  []*djangoModel
+	current *djangoModel // model whose body is being read
+}
+
+func (d *djangoModels) line(lineNum int, text string, suppressed bool) {
+	if m := djangoClassRe.FindStringSubmatch(text); m != nil && d.isModel(m[3]) {
+		d.current = &djangoModel{name: m[2], line: lineNum, indent: len(m[1]), suppressed: suppressed}
+		d.models = append(d.models, d.current)
+		return
+	}
+	m := d.current
+	ind := pyIndentRe.FindStringSubmatch(text)
+	if m == nil || ind == nil || djangoMetaRe.MatchString(text) {
+		return
+	}
+	if len(ind[1]) <= m.indent {
+		d.current = nil
+		return
+	}
+	if djangoOwnTableRe.MatchString(text) {
+		m.ownTable = true
+	}
+	if l := djangoAppLabelRe.FindStringSubmatch(text); l != nil {
+		m.appLabel = l[1]
+	}
+}
+
+// isModel reports whether a class with these bases is a model: one
+// derives from models.Model, or another class whose name ends in Model,
+// or a model declared earlier in the file.
+func (d *djangoModels) isModel(bases string) bool {
+	if djangoModelBaseRe.MatchString(bases) {
+		return true
+	}
+	for _, b := range strings.Split(bases, ",") {
+		b = strings.TrimSpace(b)
+		for _, m := range d.models {
+			if m.name == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// refs returns the implicit table references of the file's models.
+func (d *djangoModels) refs(relPath string) ([]TableRef, []ColumnRef) {
+	slashed := filepath.ToSlash(relPath)
+	loc := djangoModelsDirRe.FindStringIndex(slashed)
+	if loc == nil {
+		return nil, nil
+	}
+	appDir := strings.TrimSuffix(slashed[:loc[0]], "/")
+	label := appDir[strings.LastIndexByte(appDir, '/')+1:]
+	if l, ok := d.labels[appDir]; ok {
+		label = l
+	} else if l, ok := d.labels[label]; ok {
+		label = l
+	}
+
+	var refs []TableRef
+	for _, m := range d.models {
+		appLabel := label
+		if m.appLabel != "" {
+			appLabel = m.appLabel
+		}
+		table := strings.ToLower(appLabel + "_" + m.name)
+		if m.ownTable || appLabel == "" || !isValidTableName(table) {
+			continue
+		}
+		refs = append(refs, TableRef{Table: table, File: relPath, Line: m.line, Pattern: PatternORM, Context: ContextUnknown, Suppressed: m.suppressed})
+	}
+	return refs, nil
+}
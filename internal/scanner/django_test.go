@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan_DjangoTables(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "shop/models.py", `class TimeStamped(models.Model):
+    created = models.DateTimeField()
+
+    class Meta:
+        abstract = True
+
+class Product(TimeStamped):
+    name = models.CharField(max_length=100)
+
+class OrderLine(models.Model):
+    class Meta:
+        db_table = "order_lines"
+
+class Special(Product):
+    class Meta:
+        proxy = True
+
+def helper():
+    proxy = True
+`)
+	writeFile(t, dir, "apps/accounts/models/user.py", `class Profile(models.Model):
+    bio = models.TextField()
+
+class Badge(models.Model):
+    class Meta:
+        app_label = "rewards"
+`)
+
+	plain, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"order_lines"}; !reflect.DeepEqual(plain.Tables, want) {
+		t.Errorf("without inference, tables = %v, want %v", plain.Tables, want)
+	}
+
+	result, err := ScanWithOptions(dir, ScanOptions{Workers: 1, DjangoTables: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"accounts_profile", "order_lines", "rewards_badge", "shop_product"}
+	if !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+
+	labeled, err := ScanWithOptions(dir, ScanOptions{Workers: 1, DjangoTables: true, DjangoAppLabels: map[string]string{"apps/accounts": "users", "shop": "store"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"order_lines", "rewards_badge", "store_product", "users_profile"}
+	if !reflect.DeepEqual(labeled.Tables, want) {
+		t.Errorf("with app labels, tables = %v, want %v", labeled.Tables, want)
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import "regexp"
+
+// Ecto schemas: schema "name" do ... end, and the fields declared in it.
+// A field's column is its name unless source: names another; an embed is
+// stored in a column of its name, and a belongs_to association adds its
+// <name>_id foreign key column.
+var (
+	ectoSchemaRe     = regexp.MustCompile(`\bschema\s*\(?\s*"(\w+)"\s*\)?\s*do\b`)
+	ectoFieldRe      = regexp.MustCompile(`^\s*(?:field|embeds_one|embeds_many)\(?\s*:(\w+)(.*)`)
+	ectoBelongsToRe  = regexp.MustCompile(`^\s*belongs_to\(?\s*:(\w+)(.*)`)
+	ectoSourceRe     = regexp.MustCompile(`\bsource:\s*:(\w+)`)
+	ectoForeignKeyRe = regexp.MustCompile(`\bforeign_key:\s*:(\w+)`)
+	ectoVirtualRe    = regexp.MustCompile(`\bvirtual:\s*true\b`)
+	ectoDoRe         = regexp.MustCompile(`\bdo\s*$`)
+	ectoEndRe        = regexp.MustCompile(`^\s*end\b`)
+)
+
+// ectoSchemas tracks the schema blocks of one Elixir file. Blocks nested
+// in a schema, such as an embed's, are counted so that the schema ends
+// at its own end, and their fields are not the table's.
+type ectoSchemas struct {
+	table   string // table of the schema block being read
+	depth   int    // blocks open inside it
+	columns []ColumnRef
+}
+
+func (e *ectoSchemas) line(lineNum int, text string, suppressed bool) {
+	if m := ectoSchemaRe.FindStringSubmatch(text); m != nil {
+		e.table, e.depth = m[1], 0
+		return
+	}
+	if e.table == "" {
+		return
+	}
+	if ectoEndRe.MatchString(text) {
+		if e.depth == 0 {
+			e.table = ""
+		} else {
+			e.depth--
+		}
+		return
+	}
+	inner := e.depth > 0
+	if ectoDoRe.MatchString(text) {
+		e.depth++
+	}
+	if inner {
+		return
+	}
+
+	var column string
+	if m := ectoFieldRe.FindStringSubmatch(text); m != nil {
+		if ectoVirtualRe.MatchString(m[2]) {
+			return
+		}
+		column = m[1]
+		if s := ectoSourceRe.FindStringSubmatch(m[2]); s != nil {
+			column = s[1]
+		}
+	} else if m := ectoBelongsToRe.FindStringSubmatch(text); m != nil {
+		column = m[1] + "_id"
+		if fk := ectoForeignKeyRe.FindStringSubmatch(m[2]); fk != nil {
+			column = fk[1]
+		}
+	}
+	if column != "" {
+		e.columns = append(e.columns, ColumnRef{Table: e.table, Column: column, Line: lineNum, Context: ContextUnknown, Suppressed: suppressed})
+	}
+}
+
+func (e *ectoSchemas) refs(relPath string) ([]TableRef, []ColumnRef) {
+	for i := range e.columns {
+		e.columns[i].File = relPath
+	}
+	return nil, e.columns
+}
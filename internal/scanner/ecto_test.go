@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan_EctoSchemas(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "lib/app/accounts/user.ex", `defmodule App.Accounts.User do
+  use Ecto.Schema
+
+  schema "users" do
+    field :email, :string
+    field :name, :string, source: :full_name
+    field :password, :string, virtual: true
+    belongs_to :team, App.Team
+    belongs_to :owner, App.User, foreign_key: :owned_by
+
+    embeds_one :settings, Settings do
+      field :theme, :string
+    end
+
+    timestamps()
+  end
+
+  def changeset(user, attrs) do
+    field(:ignored, :string)
+  end
+end
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Tables, []string{"users"}) {
+		t.Errorf("tables = %v, want [users]", result.Tables)
+	}
+	var got []string
+	for _, c := range result.ColumnRefs {
+		got = append(got, c.Table+"."+c.Column)
+	}
+	want := []string{"users.email", "users.full_name", "users.team_id", "users.owned_by", "users.settings"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// QueryRef is a SQL statement found in code, identified by its fingerprint
+// so that it can be matched against pg_stat_statements.
+type QueryRef struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	// StarTables are the tables the statement reads with SELECT *; empty
+	// when it names its columns.
+	StarTables []string `json:"starTables,omitempty"`
+}
+
+var (
+	fpCommentRe     = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+	fpStringRe      = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fpPlaceholderRe = regexp.MustCompile(`\$\d+|%\(\w+\)s|%s|\?|@\w+|:[a-z_]\w*`)
+	fpNumberRe      = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	fpListRe        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fpSpaceRe       = regexp.MustCompile(`\s+`)
+	fpPunctRe       = regexp.MustCompile(`\s*([(),=<>])\s*`)
+
+	// selectStarRe matches SELECT * and SELECT t.*, but not count(*).
+	selectStarRe = regexp.MustCompile(`(?i)\bSELECT\s+(?:DISTINCT\s+)?(?:\w+\.)?\*\s*(?:,|\bFROM\b)`)
+	// existsStarRe matches EXISTS (SELECT * ...), whose columns are never read.
+	existsStarRe = regexp.MustCompile(`(?i)\bEXISTS\s*\(\s*SELECT\s+\*`)
+
+	// statementStartRe finds where a SQL statement begins in a line of code.
+	statementStartRe = regexp.MustCompile(`(?i)\b(?:SELECT|INSERT|UPDATE|DELETE|WITH)\b`)
+)
+
+// Fingerprint identifies a SQL statement regardless of its literals,
+// placeholder style, case, comments, and whitespace, so a query in code
+// and its pg_stat_statements normalization ($1, $2, ...) match.
+func Fingerprint(sql string) string {
+	s := fpCommentRe.ReplaceAllString(sql, " ")
+	s = fpStringRe.ReplaceAllString(s, "?")
+	s = strings.ToLower(s)
+	// Keep ::type casts apart from :name parameters.
+	s = strings.ReplaceAll(s, "::", "\x00")
+	s = fpPlaceholderRe.ReplaceAllString(s, "?")
+	s = strings.ReplaceAll(s, "\x00", "::")
+	s = fpNumberRe.ReplaceAllString(s, "?")
+	s = fpListRe.ReplaceAllString(s, "(?)")
+	s = fpSpaceRe.ReplaceAllString(s, " ")
+	s = fpPunctRe.ReplaceAllString(s, "$1")
+	s = strings.TrimRight(strings.TrimSpace(s), "; ")
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// selectsStar reports whether stmt reads a table with SELECT *, whose
+// result changes shape when the table's columns do.
+func selectsStar(stmt string) bool {
+	return selectStarRe.MatchString(existsStarRe.ReplaceAllString(stmt, "EXISTS (SELECT 1"))
+}
+
+// extractStatement returns the SQL statement in a line of code: from the
+// first statement keyword to the quote that closes the string literal it
+// starts in, or to the end of the line.
+func extractStatement(text string) (string, bool) {
+	loc := statementStartRe.FindStringIndex(text)
+	if loc == nil {
+		return "", false
+	}
+	stmt := text[loc[0]:]
+	before := strings.TrimRight(text[:loc[0]], " \t")
+	if before != "" {
+		if q := before[len(before)-1]; q == '"' || q == '`' || q == '\'' {
+			if end := strings.IndexByte(stmt, q); end >= 0 {
+				stmt = stmt[:end]
+			}
+		}
+	}
+	return stmt, true
+}
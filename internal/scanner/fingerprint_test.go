@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	pgss := Fingerprint("SELECT * FROM orders WHERE id = $1 AND status IN ($2, $3) AND total > $4::numeric LIMIT $5")
+	same := []string{
+		"select *\n  from orders\n where id = ? and status in (?, ?, ?) and total > ?::numeric limit 10;",
+		"SELECT * FROM orders WHERE id = :id AND status IN (:s) AND total > 9.5::numeric LIMIT :n",
+		"SELECT * FROM orders WHERE id = %s AND status IN ('a', 'it''s') AND total>%(t)s::numeric LIMIT 1 -- hot path",
+		"SELECT /* api */ * FROM orders WHERE id=@id AND status IN (@a,@b) AND total > @t::numeric LIMIT @n",
+	}
+	for _, q := range same {
+		if got := Fingerprint(q); got != pgss {
+			t.Errorf("Fingerprint(%q) = %s, want %s", q, got, pgss)
+		}
+	}
+
+	for _, q := range []string{
+		"SELECT * FROM orders WHERE id = $1",
+		"SELECT * FROM orders WHERE user_id = $1 AND status IN ($2) AND total > $3::numeric LIMIT $4",
+	} {
+		if Fingerprint(q) == pgss {
+			t.Errorf("Fingerprint(%q) matches a different query", q)
+		}
+	}
+}
+
+func TestExtractStatement(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{`rows, err := db.Query("SELECT id FROM users WHERE email = $1", email)`, "SELECT id FROM users WHERE email = $1", true},
+		{`cur.execute('DELETE FROM sessions WHERE expires_at < now()')`, "DELETE FROM sessions WHERE expires_at < now()", true},
+		{"UPDATE users SET name = $1 WHERE id = $2", "UPDATE users SET name = $1 WHERE id = $2", true},
+		{`log.Printf("starting")`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := extractStatement(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("extractStatement(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSelectsStar(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"SELECT * FROM users", true},
+		{"select distinct o.* from orders o join users u on u.id = o.user_id", true},
+		{"SELECT *, now() AS at FROM events", true},
+		{"SELECT count(*) FROM users", false},
+		{"SELECT id FROM users WHERE EXISTS (SELECT * FROM orders WHERE orders.user_id = users.id)", false},
+		{"SELECT id, email FROM users", false},
+	}
+	for _, tt := range tests {
+		if got := selectsStar(tt.stmt); got != tt.want {
+			t.Errorf("selectsStar(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestScan_StarTables(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app.py", "cur.execute(\"SELECT * FROM users u JOIN orders o ON o.user_id = u.id\")\ncur.execute(\"SELECT id FROM accounts\")\n")
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Queries) != 2 {
+		t.Fatalf("got %d queries, want 2: %+v", len(result.Queries), result.Queries)
+	}
+	for _, q := range result.Queries {
+		want := ""
+		if q.Line == 1 {
+			want = "users,orders"
+		}
+		if got := strings.Join(q.StarTables, ","); got != want {
+			t.Errorf("line %d: StarTables = %q, want %q", q.Line, got, want)
+		}
+	}
+}
+
+func TestScan_Queries(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app.go", "package main\n\nvar q = `\n  SELECT id\n  FROM users\n  WHERE email = $1`\n\nfunc f() { db.Exec(\"DELETE FROM users WHERE id = $1\") } // pgspectre:ignore\n")
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Queries) != 1 {
+		t.Fatalf("got %d queries, want 1: %+v", len(result.Queries), result.Queries)
+	}
+	q := result.Queries[0]
+	if q.File != "app.go" || q.Line != 3 || q.Fingerprint != Fingerprint("SELECT id FROM users WHERE email = ?") {
+		t.Errorf("query = %+v", q)
+	}
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are inputs that have tripped the scanner before or exercise
+// its edge cases: quotes, backticks, long lines, and non-ASCII text.
+var fuzzSeeds = []string{
+	"SELECT id, name FROM users WHERE id = $1",
+	`db.Exec("INSERT INTO orders (user_id, amount) VALUES ($1, $2)")`,
+	"UPDATE public.accounts SET balance = 0; DELETE FROM logs;",
+	"q := `SELECT *",
+	`sql = """SELECT a.b FROM "Mixed"."Case" a`,
+	"SELECT 'it''s; fine' FROM t; SELECT 1",
+	"FROM " + strings.Repeat("x", 200),
+	"SELECT ünïcödé FROM tàble WHERE 名前 = '値'",
+	"JOIN \x00\xff ON a.b = c.d",
+	strings.Repeat("SELECT a FROM b; ", 50),
+}
+
+func FuzzScanLine(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, m := range ScanLine(line) {
+			if !isValidTableName(m.Table) {
+				t.Errorf("ScanLine(%q) returned invalid table %q", line, m.Table)
+			}
+		}
+	})
+}
+
+func FuzzScanLineColumns(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, m := range ScanLineColumns(line) {
+			if m.Column == "" {
+				t.Errorf("ScanLineColumns(%q) returned an empty column", line)
+			}
+		}
+	})
+}
+
+func FuzzSplitOnSemicolons(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		parts := splitOnSemicolons(line)
+		if len(parts) == 0 {
+			t.Fatalf("splitOnSemicolons(%q) returned no parts", line)
+		}
+		if got := strings.Join(parts, ";"); got != line {
+			t.Errorf("splitOnSemicolons(%q) lost text: rejoined %q", line, got)
+		}
+	})
+}
+
+func FuzzSQLBuffer(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, ".go")
+	}
+	f.Add("x = '''\nSELECT * FROM users\n'''", ".py")
+	f.Add("CREATE TABLE t (\n  id int\n);\nDROP TABLE t", ".sql")
+	f.Fuzz(func(t *testing.T, text, ext string) {
+		buf := newSQLBuffer()
+		for i, line := range strings.Split(text, "\n") {
+			if ext == ".sql" {
+				buf.feedSQL(i+1, line)
+				continue
+			}
+			if stmt, _ := buf.feedCode(i+1, line, ext); stmt != nil && stmt.lineNum > i+1 {
+				t.Errorf("statement starts at line %d after current line %d", stmt.lineNum, i+1)
+			}
+		}
+		if stmt := buf.flush(); stmt != nil && stmt.text == "" {
+			t.Error("flush returned an empty statement")
+		}
+		if buf.active() {
+			t.Error("buffer still active after flush")
+		}
+	})
+}
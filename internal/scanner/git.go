@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/gitrepo"
+)
+
+// git runs git in dir and returns its output. It never prompts for
+// credentials.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// gitCommit resolves rev in the repository at dir to a commit hash.
+func gitCommit(dir, rev string) (string, error) {
+	out, err := git(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// gitChangedFiles returns the files of the repository at dir whose
+// working copy differs from commit, deleted ones included, and the
+// untracked files that .gitignore doesn't ignore, as slash paths
+// relative to dir.
+func gitChangedFiles(dir, commit string) ([]string, error) {
+	diff, err := git(dir, "diff", "--name-only", "--no-renames", "--relative", "-z", commit, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(diff+untracked, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// CloneRepo fetches ref, or the default branch if ref is empty, of the
+// git repository at repoURL into a new temp dir, without its history.
+// ref can be a branch, a tag, or a commit the server lets clients fetch.
+// Call cleanup to remove the clone.
+func CloneRepo(repoURL, ref string) (dir string, cleanup func(), err error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", nil, fmt.Errorf("invalid ref %q", ref)
+	}
+	dir, err = os.MkdirTemp("", "pgspectre-repo-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("clone %s: %w", gitrepo.RedactURL(repoURL), err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", repoURL, ref},
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("clone %s: %w", gitrepo.RedactURL(repoURL), err)
+		}
+	}
+	return dir, cleanup, nil
+}
@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneRepo(t *testing.T) {
+	src := gitRepo(t)
+	writeFile(t, src, "query.sql", "SELECT id FROM orders;")
+	runGit(t, src, "add", "-A")
+	runGit(t, src, "commit", "-qm", "initial")
+	runGit(t, src, "tag", "v1")
+	writeFile(t, src, "query.sql", "SELECT id FROM invoices;")
+	runGit(t, src, "commit", "-qam", "change")
+
+	for ref, want := range map[string]string{"": "invoices", "v1": "orders"} {
+		dir, cleanup, err := CloneRepo("file://"+src, ref)
+		if err != nil {
+			t.Fatalf("ref %q: %v", ref, err)
+		}
+		result, err := ScanWithOptions(dir, ScanOptions{Workers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Tables) != 1 || result.Tables[0] != want {
+			t.Errorf("ref %q: tables = %v, want [%s]", ref, result.Tables, want)
+		}
+		cleanup()
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("ref %q: clone not removed", ref)
+		}
+	}
+
+	if _, _, err := CloneRepo("file://"+filepath.Join(src, "missing"), ""); err == nil {
+		t.Error("expected error for missing repository")
+	}
+}
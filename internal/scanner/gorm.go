@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Go model structs and the struct tags that map their fields to columns:
+// gorm:"column:name", bun:"name", and sqlx db:"name". A TableName()
+// method names a struct's table, and bun:"table:name" on an embedded
+// bun.BaseModel does for bun.
+var (
+	goStructRe    = regexp.MustCompile(`^\s*(?:type\s+)?(\w+)\s+struct\s*\{`)
+	goFieldRe     = regexp.MustCompile("^\\s*(\\*?[\\w.]+)(?:\\s+([^`]+?))?\\s*(?:`([^`]*)`)?\\s*$")
+	goTableNameRe = regexp.MustCompile(`^\s*func\s*\(\s*(?:\w+\s+)?\*?(\w+)\s*\)\s*TableName\s*\(\s*\)\s*string\b`)
+	goReturnRe    = regexp.MustCompile(`\breturn\s+"(\w+)"`)
+)
+
+// gormRelationKeys are gorm tag keys that mark an association, whose
+// field has no column of its own.
+var gormRelationKeys = map[string]bool{
+	"foreignkey":     true,
+	"references":     true,
+	"many2many":      true,
+	"polymorphic":    true,
+	"joinforeignkey": true,
+	"embedded":       true,
+}
+
+// goStruct is a struct whose fields carry column tags.
+type goStruct struct {
+	name       string
+	line       int
+	suppressed bool
+	orm        bool // gorm or bun, which derive a table from the name
+	table      string
+	schema     string
+	tableLine  int // of the bun.BaseModel naming the table
+	columns    []ColumnRef
+}
+
+// goModels tracks the structs of one Go file. A struct's table is only
+// known once the file is read, since its TableName() method can follow
+// it. Without one, GORM and bun models map to the pluralized snake_case
+// of their name; structs with only db tags are query results of no
+// table of their own, and their columns are left out.
+type goModels struct {
+	structs    []*goStruct
+	current    *goStruct
+	depth      int               // braces open in the current struct
+	tableNames map[string]string // struct name to TableName() result
+	pending    string            // struct whose TableName() body is being read
+	tables     []TableRef
+}
+
+func (g *goModels) line(lineNum int, text string, suppressed bool) {
+	if g.current != nil {
+		if g.depth == 1 {
+			g.field(lineNum, text, suppressed)
+		}
+		g.depth += strings.Count(text, "{") - strings.Count(text, "}")
+		if g.depth <= 0 {
+			g.current = nil
+		}
+		return
+	}
+
+	if g.pending != "" {
+		if m := goReturnRe.FindStringSubmatch(text); m != nil {
+			g.setTableName(g.pending, m[1])
+			// The TableName() pattern only finds a return on the func line.
+			if isValidTableName(m[1]) {
+				g.tables = append(g.tables, TableRef{Table: m[1], Line: lineNum, Pattern: PatternORM, Context: ContextUnknown, Suppressed: suppressed})
+			}
+			g.pending = ""
+		} else if strings.HasPrefix(strings.TrimSpace(text), "}") {
+			g.pending = ""
+		}
+		return
+	}
+	if m := goTableNameRe.FindStringSubmatch(text); m != nil {
+		if r := goReturnRe.FindStringSubmatch(text); r != nil {
+			g.setTableName(m[1], r[1])
+		} else {
+			g.pending = m[1]
+		}
+		return
+	}
+	if m := goStructRe.FindStringSubmatch(text); m != nil {
+		depth := strings.Count(text, "{") - strings.Count(text, "}")
+		if depth > 0 {
+			g.current = &goStruct{name: m[1], line: lineNum, suppressed: suppressed}
+			g.structs = append(g.structs, g.current)
+			g.depth = depth
+		}
+	}
+}
+
+func (g *goModels) setTableName(structName, table string) {
+	if g.tableNames == nil {
+		g.tableNames = make(map[string]string)
+	}
+	g.tableNames[structName] = table
+}
+
+// field reads a line of the current struct's body.
+func (g *goModels) field(lineNum int, text string, suppressed bool) {
+	m := goFieldRe.FindStringSubmatch(text)
+	if m == nil || m[3] == "" {
+		return
+	}
+	s := g.current
+	name, typ, tag := m[1], m[2], reflect.StructTag(m[3])
+
+	if typ == "" { // embedded
+		if strings.TrimPrefix(name, "*") == "bun.BaseModel" {
+			s.orm = true
+			if v, ok := tag.Lookup("bun"); ok {
+				for _, opt := range strings.Split(v, ",") {
+					if t, found := strings.CutPrefix(strings.TrimSpace(opt), "table:"); found {
+						s.schema, s.table = splitQualified(t)
+						s.tableLine = lineNum
+					}
+				}
+			}
+		}
+		return
+	}
+
+	var column string
+	if v, ok := tag.Lookup("gorm"); ok {
+		if v == "-" {
+			return
+		}
+		s.orm = true
+		column = snakeCase(name)
+		for _, opt := range strings.Split(v, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(opt), ":")
+			switch key = strings.ToLower(key); {
+			case key == "column":
+				column = value
+			case key == "-" || gormRelationKeys[key]:
+				return
+			}
+		}
+	} else if v, ok := tag.Lookup("bun"); ok {
+		if v == "-" {
+			return
+		}
+		s.orm = true
+		opts := strings.Split(v, ",")
+		for _, opt := range opts {
+			if strings.HasPrefix(opt, "rel:") || strings.HasPrefix(opt, "m2m:") {
+				return
+			}
+		}
+		column = opts[0]
+		if column == "" || strings.Contains(column, ":") {
+			column = snakeCase(name)
+		}
+	} else if v, ok := tag.Lookup("db"); ok {
+		column, _, _ = strings.Cut(v, ",")
+		if column == "-" {
+			return
+		}
+	}
+	if column != "" && isValidColumnName(column) {
+		s.columns = append(s.columns, ColumnRef{Column: column, Line: lineNum, Context: ContextUnknown, Suppressed: suppressed})
+	}
+}
+
+// splitQualified splits a schema-qualified name.
+func splitQualified(name string) (schema, table string) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// refs returns the tables of the file's models that the patterns don't
+// find, and the columns of each model with a table.
+func (g *goModels) refs(relPath string) ([]TableRef, []ColumnRef) {
+	refs := g.tables
+	var colRefs []ColumnRef
+	for _, s := range g.structs {
+		table, schema, line := s.table, s.schema, s.tableLine
+		switch {
+		case table != "":
+		case g.tableNames[s.name] != "":
+			table, line = g.tableNames[s.name], 0 // the TableName() pattern reports it
+		case s.orm:
+			table, line = tableize(s.name), s.line
+		}
+		if !isValidTableName(table) {
+			continue
+		}
+		if line > 0 {
+			refs = append(refs, TableRef{Table: table, Schema: schema, Line: line, Pattern: PatternORM, Context: ContextUnknown, Suppressed: s.suppressed})
+		}
+		for _, c := range s.columns {
+			c.Table, c.Schema = table, schema
+			colRefs = append(colRefs, c)
+		}
+	}
+
+	for i := range refs {
+		refs[i].File = relPath
+	}
+	for i := range colRefs {
+		colRefs[i].File = relPath
+	}
+	return refs, colRefs
+}
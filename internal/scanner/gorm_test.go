@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan_GoStructTags(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "models/models.go", `package models
+
+// UserProfile is a GORM model with the default table.
+type UserProfile struct {
+	ID        uint   `+"`gorm:\"primaryKey\"`"+`
+	FullName  string `+"`gorm:\"column:display_name;size:255\"`"+`
+	AccountID uint
+	Account   Account `+"`gorm:\"foreignKey:AccountID\"`"+`
+	Secret    string  `+"`gorm:\"-\"`"+`
+}
+
+type Account struct {
+	ID   uint   `+"`gorm:\"column:id\"`"+`
+	Name string `+"`gorm:\"column:name\"`"+`
+}
+
+func (a *Account) TableName() string {
+	return "billing_accounts"
+}
+
+type Story struct {
+	bun.BaseModel `+"`bun:\"table:app.stories,alias:s\"`"+`
+
+	ID       int64  `+"`bun:\",pk,autoincrement\"`"+`
+	Title    string `+"`bun:\"headline\"`"+`
+	AuthorID int64
+	Author   *User  `+"`bun:\"rel:belongs-to,join:author_id=id\"`"+`
+}
+
+// OrderRow is a query result; it has no table.
+type OrderRow struct {
+	Total int `+"`db:\"total\"`"+`
+}
+
+type Invoice struct {
+	Number string `+"`db:\"invoice_number\"`"+`
+	Draft  bool   `+"`db:\"-\"`"+`
+}
+
+func (Invoice) TableName() string { return "invoices" }
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTables := []string{"billing_accounts", "invoices", "stories", "user_profiles"}
+	if !reflect.DeepEqual(result.Tables, wantTables) {
+		t.Errorf("tables = %v, want %v", result.Tables, wantTables)
+	}
+	for _, ref := range result.Refs {
+		if ref.Table == "billing_accounts" && ref.Line != 18 {
+			t.Errorf("billing_accounts at line %d, want 18", ref.Line)
+		}
+		if ref.Table == "stories" && ref.Schema != "app" {
+			t.Errorf("stories schema = %q, want app", ref.Schema)
+		}
+	}
+
+	var got []string
+	for _, c := range result.ColumnRefs {
+		if c.Table != "app" { // the bun tag's app.stories, read as a column
+			got = append(got, c.Table+"."+c.Column)
+		}
+	}
+	want := []string{
+		"user_profiles.id", "user_profiles.display_name",
+		"billing_accounts.id", "billing_accounts.name",
+		"stories.id", "stories.headline",
+		"invoices.invoice_number",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v\nwant %v", got, want)
+	}
+}
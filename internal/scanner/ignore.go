@@ -0,0 +1,232 @@
+package scanner
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern of a .gitignore file.
+type ignoreRule struct {
+	dir     string // slash path of the .gitignore's directory, "" at the root
+	pattern string
+	negate  bool // !pattern re-includes
+	dirOnly bool // pattern/ matches only directories
+}
+
+// matches reports whether the rule matches rel, a slash path from the
+// repository root in the rule's directory or below it.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.dir != "" {
+		if !strings.HasPrefix(rel, r.dir+"/") {
+			return false
+		}
+		rel = rel[len(r.dir)+1:]
+	}
+	return matchPathGlob(r.pattern, rel)
+}
+
+// readGitignore returns the rules of the .gitignore file in dir, if any.
+func readGitignore(dir, relDir string) []ignoreRule {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{dir: relDir}
+		if strings.HasPrefix(line, "!") {
+			r.negate, line = true, line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`) // \# and \! are literal
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		// A pattern without a slash matches at any depth; one with a
+		// slash is relative to the .gitignore's directory.
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		r.pattern = strings.TrimPrefix(line, "/")
+		if r.pattern != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// fileFilter decides which paths of a repository a scan leaves out: the
+// directories in skipDirs or opts.SkipDirs, paths that .gitignore files
+// ignore, and paths matching the exclude globs. Directories must be
+// checked before their contents, as filepath.WalkDir visits them, so that
+// their .gitignore rules are loaded in time.
+type fileFilter struct {
+	root       string
+	exclude    []string
+	skipDirs   map[string]bool // names
+	skipPaths  map[string]bool // slash paths from the root
+	extensions map[string]bool // lowercase, with the dot
+	rules      []ignoreRule
+	dirs       map[string]bool // skipFile's decisions, by slash path
+}
+
+func newFileFilter(root string, opts ScanOptions) *fileFilter {
+	f := &fileFilter{
+		root:       root,
+		exclude:    opts.ExcludePaths,
+		skipDirs:   make(map[string]bool),
+		skipPaths:  make(map[string]bool),
+		extensions: make(map[string]bool),
+	}
+	for _, d := range opts.SkipDirs {
+		d = strings.Trim(strings.TrimPrefix(filepath.ToSlash(d), "./"), "/")
+		if strings.Contains(d, "/") {
+			f.skipPaths[d] = true
+		} else if d != "" {
+			f.skipDirs[d] = true
+		}
+	}
+	for _, ext := range opts.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		f.extensions[ext] = true
+	}
+	return f
+}
+
+// supported reports whether the scan reads the file at path.
+func (f *fileFilter) supported(path string) bool {
+	return isSupported(path) || f.extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// skip reports whether the scan leaves out path.
+func (f *fileFilter) skip(p string, isDir bool) bool {
+	rel, err := filepath.Rel(f.root, p)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		f.rules = append(f.rules, readGitignore(p, "")...)
+		return false
+	}
+	if isDir && (skipDirs[path.Base(rel)] || f.skipDirs[path.Base(rel)] || f.skipPaths[rel]) {
+		return true
+	}
+	for _, g := range f.exclude {
+		if matchExcludeGlob(g, rel) {
+			return true
+		}
+	}
+	ignored := false
+	for _, r := range f.rules { // the last match wins
+		if r.matches(rel, isDir) {
+			ignored = !r.negate
+		}
+	}
+	if ignored {
+		return true
+	}
+	if isDir {
+		f.rules = append(f.rules, readGitignore(p, rel)...)
+	}
+	return false
+}
+
+// skipFile reports whether a walk would leave out the file at rel, a slash
+// path from the root, checking its directories first as the walk does.
+func (f *fileFilter) skipFile(rel string) bool {
+	if f.dirs == nil {
+		f.dirs = make(map[string]bool)
+		f.skip(f.root, true)
+	}
+	parts := strings.Split(rel, "/")
+	dir := ""
+	for _, part := range parts[:len(parts)-1] {
+		dir = path.Join(dir, part)
+		skipped, ok := f.dirs[dir]
+		if !ok {
+			skipped = f.skip(filepath.Join(f.root, filepath.FromSlash(dir)), true)
+			f.dirs[dir] = skipped
+		}
+		if skipped {
+			return true
+		}
+	}
+	return f.skip(filepath.Join(f.root, filepath.FromSlash(rel)), false)
+}
+
+// walkRepo walks a repository as a scan does, calling fn for each file the
+// scan doesn't leave out, with whether it reads the file.
+func walkRepo(repoPath string, opts ScanOptions, fn func(path string, d fs.DirEntry, supported bool) error) error {
+	filter := newFileFilter(repoPath, opts)
+	return filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filter.skip(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		return fn(path, d, filter.supported(path))
+	})
+}
+
+// matchExcludeGlob reports whether an exclude glob matches rel, a slash
+// path from the repository root. A glob without a slash, such as
+// *.pb.go, matches the base name at any depth.
+func matchExcludeGlob(glob, rel string) bool {
+	glob = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(glob), "./"), "/")
+	if !strings.Contains(glob, "/") {
+		rel = path.Base(rel)
+	}
+	return matchPathGlob(glob, rel)
+}
+
+// matchPathGlob matches a slash path against a glob whose ** segments
+// match any number of path segments, including none, and whose other
+// segments are path.Match patterns.
+func matchPathGlob(glob, rel string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
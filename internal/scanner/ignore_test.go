@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"**/testdata/**", "testdata/users.sql", true},
+		{"**/testdata/**", "pkg/scanner/testdata/x/users.sql", true},
+		{"**/testdata/**", "pkg/testdata.go", false},
+		{"generated/**", "generated", true},
+		{"generated/**", "generated/db/models.go", true},
+		{"generated/**", "src/generated/models.go", false},
+		{"db/*.sql", "db/schema.sql", true},
+		{"db/*.sql", "db/seeds/users.sql", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+	}
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScan_ExtraExtensionsAndSkipDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Views/Orders.cshtml", `@{ var rows = db.Query("SELECT * FROM orders"); }`)
+	writeFile(t, dir, "app.go", `db.Query("SELECT * FROM users")`)
+	writeFile(t, dir, "third_party/lib.go", `db.Query("SELECT * FROM vendored")`)
+	writeFile(t, dir, "services/legacy/app.go", `db.Query("SELECT * FROM legacy")`)
+	writeFile(t, dir, "services/billing/app.go", `db.Query("SELECT * FROM invoices")`)
+	writeFile(t, dir, "services/billing/legacy/app.go", `db.Query("SELECT * FROM billing_legacy")`)
+
+	plain, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"billing_legacy", "invoices", "legacy", "users", "vendored"}; !reflect.DeepEqual(plain.Tables, want) {
+		t.Errorf("default tables = %v, want %v", plain.Tables, want)
+	}
+
+	for _, workers := range []int{1, 4} {
+		opts := ScanOptions{Workers: workers, Extensions: []string{"CSHTML"}, SkipDirs: []string{"third_party", "./services/legacy/"}}
+		result, err := ScanWithOptions(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"billing_legacy", "invoices", "orders", "users"}
+		if !reflect.DeepEqual(result.Tables, want) {
+			t.Errorf("workers=%d: tables = %v, want %v", workers, result.Tables, want)
+		}
+		if result.FilesSkipped != 0 {
+			t.Errorf("workers=%d: skipped %d files, want 0", workers, result.FilesSkipped)
+		}
+	}
+}
+
+func TestScan_IgnoresAndExcludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".gitignore", "# build output\n/out/\n*.gen.go\n!keep.gen.go\n")
+	writeFile(t, dir, "app.go", `db.Query("SELECT * FROM users")`)
+	writeFile(t, dir, "out/app.go", `db.Query("SELECT * FROM out_table")`)
+	writeFile(t, dir, "pkg/out/app.go", `db.Query("SELECT * FROM nested_out")`)
+	writeFile(t, dir, "pkg/models.gen.go", `db.Query("SELECT * FROM generated_table")`)
+	writeFile(t, dir, "pkg/keep.gen.go", `db.Query("SELECT * FROM kept")`)
+	writeFile(t, dir, "pkg/.gitignore", "fixtures.sql\n")
+	writeFile(t, dir, "pkg/fixtures.sql", "SELECT * FROM pkg_fixture;")
+	writeFile(t, dir, "fixtures.sql", "SELECT * FROM root_fixture;")
+	writeFile(t, dir, "internal/scanner/testdata/seed.sql", "SELECT * FROM test_seed;")
+	writeFile(t, dir, "schema/api.pb.go", `db.Query("SELECT * FROM proto_table")`)
+
+	for _, workers := range []int{1, 4} {
+		opts := ScanOptions{Workers: workers, ExcludePaths: []string{"**/testdata/**", "*.pb.go"}}
+		result, err := ScanWithOptions(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"kept", "nested_out", "root_fixture", "users"}
+		if !reflect.DeepEqual(result.Tables, want) {
+			t.Errorf("workers=%d: tables = %v, want %v", workers, result.Tables, want)
+		}
+
+		count, err := CountFiles(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count.Files != 4 {
+			t.Errorf("workers=%d: counted %d files, want 4", workers, count.Files)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// JPA and Hibernate annotations, in Java or Kotlin. Kotlin use-site
+// targets such as @field:Column are accepted.
+var (
+	jpaEntityRe    = regexp.MustCompile(`@(?:\w+:)?(?:\w+\.)*Entity\b(?:\s*\(\s*(?:name\s*=\s*)?"(\w+)"\s*\))?`)
+	jpaTableRe     = regexp.MustCompile(`@(?:\w+:)?(?:\w+\.)*Table\s*\(\s*(?:[^)@]*?\b(?:name|value)\s*=\s*)?"(\w+)"`)
+	jpaClassRe     = regexp.MustCompile(`\bclass\s+(\w+)`)
+	jpaColumnRe    = regexp.MustCompile(`@(?:\w+:)?(?:\w+\.)*(?:Column|JoinColumn)\s*\([^)@]*?\bname\s*=\s*"(\w+)"`)
+	jpaJoinTableRe = regexp.MustCompile(`@(?:\w+:)?(?:\w+\.)*JoinTable\b`)
+	// jpaJoinNameRe finds the join table's own name, not that of an
+	// @JoinColumn nested in it.
+	jpaJoinNameRe = regexp.MustCompile(`(?:JoinTable\s*\(|^|,)\s*name\s*=\s*"(\w+)"`)
+)
+
+// jpaEntities tracks the entity classes of one Java or Kotlin file. An
+// entity without @Table maps to its entity or class name in snake_case,
+// as Spring Boot's default naming strategy does. Columns named by
+// @Column and @JoinColumn belong to the entity they are declared in;
+// those of an @JoinTable belong to the join table.
+type jpaEntities struct {
+	entity     bool   // an @Entity annotation awaits its class
+	entityName string // its name = "...", if any
+	table      string // an @Table name awaiting its class
+	current    string // table of the entity class being read
+	joinDepth  int    // open parentheses of an @JoinTable annotation
+	tables     []TableRef
+	columns    []ColumnRef
+}
+
+func (j *jpaEntities) line(lineNum int, text string, suppressed bool) {
+	if j.joinDepth > 0 || jpaJoinTableRe.MatchString(text) {
+		if m := jpaJoinNameRe.FindStringSubmatch(strings.TrimSpace(text)); m != nil && isValidTableName(m[1]) {
+			j.tables = append(j.tables, TableRef{Table: m[1], Line: lineNum, Pattern: PatternORM, Context: ContextUnknown, Suppressed: suppressed})
+		}
+		j.joinDepth = max(j.joinDepth+strings.Count(text, "(")-strings.Count(text, ")"), 0)
+		return
+	}
+
+	if m := jpaEntityRe.FindStringSubmatch(text); m != nil {
+		j.entity, j.entityName = true, m[1]
+	}
+	if m := jpaTableRe.FindStringSubmatch(text); m != nil {
+		j.table = m[1]
+	}
+	if m := jpaClassRe.FindStringSubmatch(text); m != nil {
+		j.current = ""
+		switch {
+		case j.table != "":
+			j.current = j.table // the @Table pattern reports it
+		case j.entity:
+			name := j.entityName
+			if name == "" {
+				name = m[1]
+			}
+			j.current = snakeCase(name)
+			if isValidTableName(j.current) {
+				j.tables = append(j.tables, TableRef{Table: j.current, Line: lineNum, Pattern: PatternORM, Context: ContextUnknown, Suppressed: suppressed})
+			}
+		}
+		j.entity, j.entityName, j.table = false, "", ""
+	}
+	if j.current == "" {
+		return
+	}
+	for _, m := range jpaColumnRe.FindAllStringSubmatch(text, -1) {
+		j.columns = append(j.columns, ColumnRef{Table: j.current, Column: m[1], Line: lineNum, Context: ContextUnknown, Suppressed: suppressed})
+	}
+}
+
+func (j *jpaEntities) refs(relPath string) ([]TableRef, []ColumnRef) {
+	for i := range j.tables {
+		j.tables[i].File = relPath
+	}
+	for i := range j.columns {
+		j.columns[i].File = relPath
+	}
+	return j.tables, j.columns
+}
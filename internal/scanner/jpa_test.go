@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScan_JPAEntities(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "User.java", `package app;
+
+@Entity
+@Table(name = "users", schema = "app")
+public class User {
+    @Id
+    @Column(name = "id")
+    private Long id;
+
+    @Column(nullable = false, name = "email_address")
+    private String email;
+
+    @ManyToMany
+    @JoinTable(
+        name = "user_roles",
+        joinColumns = @JoinColumn(name = "user_id"),
+        inverseJoinColumns = @JoinColumn(name = "role_id"))
+    private Set<Role> roles;
+
+    @ManyToOne
+    @JoinColumn(name = "team_id")
+    private Team team;
+}
+`)
+	writeFile(t, dir, "Order.kt", `@Entity
+data class PurchaseOrder(
+    @Id val id: Long,
+    @field:Column(name = "total_cents") val total: Long,
+)
+
+@Embeddable
+class Money(@Column(name = "amount") val amount: Long)
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := make(map[string]bool)
+	for _, r := range result.Refs {
+		tables[r.Table] = true
+	}
+	for _, want := range []string{"users", "user_roles", "purchase_order"} {
+		if !tables[want] {
+			t.Errorf("missing table %q in %v", want, result.Tables)
+		}
+	}
+	if tables["money"] {
+		t.Error("embeddable class should not be a table")
+	}
+
+	var got []string
+	for _, c := range result.ColumnRefs {
+		got = append(got, c.Table+"."+c.Column)
+	}
+	want := []string{"purchase_order.total_cents", "users.id", "users.email_address", "users.team_id"}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+}
+
+func TestScanLine_JPATable(t *testing.T) {
+	tests := []struct {
+		line  string
+		table string
+	}{
+		{`@Table(name = "users")`, "users"},
+		{`@Table(schema = "app", name = "orders", indexes = @Index(name = "idx", columnList = "x"))`, "orders"},
+		{`@javax.persistence.Table(name="accounts")`, "accounts"},
+		{`@Table("invoices")`, "invoices"},
+	}
+	for _, tt := range tests {
+		matches := ScanLine(tt.line)
+		if len(matches) != 1 || matches[0].Table != tt.table || matches[0].Pattern != PatternORM {
+			t.Errorf("ScanLine(%q) = %v, want ORM table %q", tt.line, matches, tt.table)
+		}
+	}
+}
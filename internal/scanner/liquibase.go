@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// liquibaseExts are the changelog formats read for Liquibase change
+// types. Other XML and YAML files are skipped.
+var liquibaseExts = map[string]bool{
+	".xml":  true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// liquibaseChanges are the change types whose table and columns are
+// referenced.
+var liquibaseChanges = map[string]bool{
+	"createTable": true,
+	"addColumn":   true,
+	"createIndex": true,
+}
+
+var (
+	xmlTagRe     = regexp.MustCompile(`<(/?)(?:[\w-]+:)?([\w-]+)((?:\s[^>]*?)?)(/?)>`)
+	xmlAttrRe    = regexp.MustCompile(`([\w:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	yamlChangeRe = regexp.MustCompile(`^(\s*)-\s*(createTable|addColumn|createIndex)\s*:\s*$`)
+	yamlKeyRe    = regexp.MustCompile(`^(\s*)(?:-\s+)?(\w+)\s*:\s*["']?([^"'#]*?)["']?\s*(?:#.*)?$`)
+	yamlIndentRe = regexp.MustCompile(`^(\s*)\S`)
+	xmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// liquibaseChange is a change type being read: its table, and the
+// columns it names.
+type liquibaseChange struct {
+	table, schema string
+	line          int
+	suppressed    bool
+	columns       []ColumnRef
+}
+
+func (c *liquibaseChange) refs(relPath string) ([]TableRef, []ColumnRef) {
+	if !isValidTableName(c.table) {
+		return nil, nil
+	}
+	ref := TableRef{Table: c.table, Schema: c.schema, File: relPath, Line: c.line, Pattern: PatternMigration, Context: ContextDDL, Suppressed: c.suppressed}
+	for i := range c.columns {
+		c.columns[i].Table, c.columns[i].Schema, c.columns[i].File = c.table, c.schema, relPath
+	}
+	return []TableRef{ref}, c.columns
+}
+
+// scanLiquibase scans an XML or YAML file. Files that aren't Liquibase
+// changelogs are skipped.
+func scanLiquibase(r io.Reader, relPath, ext string) fileResult {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fileResult{err: err, filePath: relPath}
+	}
+	src := string(data)
+	if !strings.Contains(src, "databaseChangeLog") {
+		return fileResult{skipped: true, filePath: relPath}
+	}
+	var fr fileResult
+	if ext == ".xml" {
+		fr.refs, fr.colRefs = scanLiquibaseXML(src, relPath)
+	} else {
+		fr.refs, fr.colRefs = scanLiquibaseYAML(src, relPath)
+	}
+	fr.filePath = relPath
+	return fr
+}
+
+// scanLiquibaseXML returns the table and column references of the
+// createTable, addColumn, and createIndex changes of an XML changelog.
+func scanLiquibaseXML(src, relPath string) ([]TableRef, []ColumnRef) {
+	lines := strings.Split(src, "\n")
+	lineAt := func(offset int) int { return strings.Count(src[:offset], "\n") + 1 }
+	suppressedAt := func(line int) bool { return hasInlineIgnore(lines[line-1]) }
+	// Blank comments, keeping offsets, so commented-out changes don't count.
+	src = xmlCommentRe.ReplaceAllStringFunc(src, func(c string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '\n' {
+				return r
+			}
+			return ' '
+		}, c)
+	})
+
+	var refs []TableRef
+	var colRefs []ColumnRef
+	var change *liquibaseChange
+	for _, m := range xmlTagRe.FindAllStringSubmatchIndex(src, -1) {
+		closing := m[3] > m[2]
+		name := src[m[4]:m[5]]
+		selfClosing := m[9] > m[8]
+		attrs := xmlAttrs(src[m[6]:m[7]])
+		line := lineAt(m[0])
+
+		switch {
+		case liquibaseChanges[name] && closing:
+			if change != nil {
+				r, c := change.refs(relPath)
+				refs, colRefs = append(refs, r...), append(colRefs, c...)
+			}
+			change = nil
+		case liquibaseChanges[name]:
+			change = &liquibaseChange{table: attrs["tableName"], schema: attrs["schemaName"], line: line, suppressed: suppressedAt(line)}
+			if selfClosing {
+				r, c := change.refs(relPath)
+				refs, colRefs = append(refs, r...), append(colRefs, c...)
+				change = nil
+			}
+		case name == "column" && !closing && change != nil && attrs["name"] != "":
+			change.columns = append(change.columns, ColumnRef{Column: attrs["name"], Line: line, Context: ContextDDL, Suppressed: suppressedAt(line)})
+		}
+	}
+	return refs, colRefs
+}
+
+func xmlAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range xmlAttrRe.FindAllStringSubmatch(s, -1) {
+		attrs[m[1]] = m[2] + m[3]
+	}
+	return attrs
+}
+
+// scanLiquibaseYAML returns the table and column references of the
+// createTable, addColumn, and createIndex changes of a YAML changelog.
+// A change's keys may come in any order, so it is reported once its
+// block ends.
+func scanLiquibaseYAML(src, relPath string) ([]TableRef, []ColumnRef) {
+	var refs []TableRef
+	var colRefs []ColumnRef
+	var change *liquibaseChange
+	changeIndent, columnIndent := 0, -1
+	finish := func() {
+		if change != nil {
+			r, c := change.refs(relPath)
+			refs, colRefs = append(refs, r...), append(colRefs, c...)
+		}
+		change = nil
+	}
+
+	for i, text := range strings.Split(src, "\n") {
+		lineNum := i + 1
+		ind := yamlIndentRe.FindStringSubmatch(text)
+		if ind == nil || strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+		indent := len(ind[1])
+		if change != nil && indent <= changeIndent {
+			finish()
+		}
+		if m := yamlChangeRe.FindStringSubmatch(text); m != nil {
+			change = &liquibaseChange{line: lineNum, suppressed: hasInlineIgnore(text)}
+			changeIndent, columnIndent = len(m[1]), -1
+			continue
+		}
+		if change == nil {
+			continue
+		}
+		m := yamlKeyRe.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		keyIndent := len(m[1])
+		if columnIndent >= 0 && keyIndent <= columnIndent {
+			columnIndent = -1
+		}
+		switch key, value := m[2], strings.TrimSpace(m[3]); {
+		case key == "column" && value == "":
+			columnIndent = keyIndent
+		case key == "name" && columnIndent >= 0 && value != "":
+			change.columns = append(change.columns, ColumnRef{Column: value, Line: lineNum, Context: ContextDDL, Suppressed: hasInlineIgnore(text)})
+		case key == "tableName" && columnIndent < 0:
+			change.table = value
+		case key == "schemaName" && columnIndent < 0:
+			change.schema = value
+		}
+	}
+	finish()
+	return refs, colRefs
+}
@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanLiquibaseXML(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<databaseChangeLog xmlns="http://www.liquibase.org/xml/ns/dbchangelog">
+  <changeSet id="1" author="dev">
+    <createTable tableName="users" schemaName="app">
+      <column name="id" type="bigint">
+        <constraints primaryKey="true"/>
+      </column>
+      <column name="email" type="varchar(255)"/>
+    </createTable>
+  </changeSet>
+  <changeSet id="2" author="dev">
+    <addColumn
+        tableName='users'>
+      <column name="nickname" type="text"/>
+    </addColumn>
+    <createIndex indexName="idx_orders_user" tableName="orders">
+      <column name="user_id"/>
+    </createIndex>
+    <!-- <createTable tableName="commented_out"/> -->
+    <dropTable tableName="legacy"/>
+    <createTable tableName="audit_log"/> <!-- pgspectre:ignore -->
+  </changeSet>
+</databaseChangeLog>
+`
+	refs, cols := scanLiquibaseXML(src, "db/changelog.xml")
+	want := []TableRef{
+		{Table: "users", Schema: "app", File: "db/changelog.xml", Line: 4, Pattern: PatternMigration, Context: ContextDDL},
+		{Table: "users", File: "db/changelog.xml", Line: 12, Pattern: PatternMigration, Context: ContextDDL},
+		{Table: "orders", File: "db/changelog.xml", Line: 16, Pattern: PatternMigration, Context: ContextDDL},
+		{Table: "audit_log", File: "db/changelog.xml", Line: 21, Pattern: PatternMigration, Context: ContextDDL, Suppressed: true},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("got %d table refs, want %d: %+v", len(refs), len(want), refs)
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("ref %d = %+v, want %+v", i, refs[i], want[i])
+		}
+	}
+
+	wantCols := []ColumnRef{
+		{Table: "users", Column: "id", Schema: "app", File: "db/changelog.xml", Line: 5, Context: ContextDDL},
+		{Table: "users", Column: "email", Schema: "app", File: "db/changelog.xml", Line: 8, Context: ContextDDL},
+		{Table: "users", Column: "nickname", File: "db/changelog.xml", Line: 14, Context: ContextDDL},
+		{Table: "orders", Column: "user_id", File: "db/changelog.xml", Line: 17, Context: ContextDDL},
+	}
+	if len(cols) != len(wantCols) {
+		t.Fatalf("got %d column refs, want %d: %+v", len(cols), len(wantCols), cols)
+	}
+	for i := range wantCols {
+		if cols[i] != wantCols[i] {
+			t.Errorf("column ref %d = %+v, want %+v", i, cols[i], wantCols[i])
+		}
+	}
+}
+
+func TestScanLiquibaseYAML(t *testing.T) {
+	src := `databaseChangeLog:
+  - changeSet:
+      id: 1
+      author: dev
+      changes:
+        - createTable:
+            columns:
+              - column:
+                  name: id
+                  type: bigint
+                  constraints:
+                    primaryKey: true
+              - column:
+                  name: email
+            tableName: users
+        - createIndex:
+            indexName: idx_users_email
+            tableName: "users"
+            columns:
+              - column:
+                  name: email
+        # - addColumn:
+        #     tableName: commented_out
+  - changeSet:
+      id: 2
+      changes:
+        - addColumn:
+            schemaName: app
+            tableName: orders
+            columns:
+              - column:
+                  name: total
+                  type: numeric
+        - dropTable:
+            tableName: legacy
+`
+	refs, cols := scanLiquibaseYAML(src, "changelog.yaml")
+	var tables []string
+	for _, r := range refs {
+		tables = append(tables, r.Schema+"."+r.Table)
+	}
+	if got, want := tables, []string{".users", ".users", "app.orders"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+	if refs[0].Line != 6 {
+		t.Errorf("createTable line = %d, want 6", refs[0].Line)
+	}
+
+	var columns []string
+	for _, c := range cols {
+		columns = append(columns, c.Table+"."+c.Column)
+	}
+	if got, want := columns, []string{"users.id", "users.email", "users.email", "orders.total"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+}
+
+func TestScan_Liquibase(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "src/main/resources/db/changelog.yml", "databaseChangeLog:\n  - changeSet:\n      changes:\n        - createTable:\n            tableName: users\n")
+	writeFile(t, dir, "docker-compose.yml", "services:\n  db:\n    image: postgres\n")
+	writeFile(t, dir, "pom.xml", "<project><artifactId>app</artifactId></project>\n")
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !reflect.DeepEqual(result.Tables, []string{"users"}) {
+		t.Errorf("tables = %v, want users", result.Tables)
+	}
+	if result.FilesScanned != 1 || result.FilesSkipped != 2 {
+		t.Errorf("scanned %d, skipped %d; want 1 and 2", result.FilesScanned, result.FilesSkipped)
+	}
+}
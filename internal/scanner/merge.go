@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// mergeChunk is how many table references Merge adds at a time, so that
+// spilling can keep up with large inputs.
+const mergeChunk = 4096
+
+// Merge combines the scans of several repositories, as the scan of one
+// workspace would: each reference's file is prefixed with the name of its
+// repository, as name/path/to/file. Table references past maxRefBytes
+// spill to a temp file as in a scan. The results are left as they are;
+// close them when done.
+func Merge(results []ScanResult, names []string, maxRefBytes int64) (ScanResult, error) {
+	repoPaths := make([]string, len(results))
+	for i, r := range results {
+		repoPaths[i] = r.RepoPath
+	}
+	c := newCollector(strings.Join(repoPaths, ","), maxRefBytes)
+	scanned, skipped := 0, 0
+	for i, r := range results {
+		name := names[i]
+		var chunk []TableRef
+		var addErr error
+		err := r.EachRef(func(ref TableRef) {
+			if addErr != nil {
+				return
+			}
+			ref.File = filepath.Join(name, ref.File)
+			chunk = append(chunk, ref)
+			if len(chunk) == mergeChunk {
+				addErr = c.add(fileResult{refs: chunk})
+				chunk = nil
+			}
+		})
+		if err == nil {
+			err = addErr
+		}
+		if err != nil {
+			return c.finish(err)
+		}
+
+		fr := fileResult{refs: chunk}
+		for _, ref := range r.ColumnRefs {
+			ref.File = filepath.Join(name, ref.File)
+			fr.colRefs = append(fr.colRefs, ref)
+		}
+		for _, q := range r.Queries {
+			q.File = filepath.Join(name, q.File)
+			fr.queries = append(fr.queries, q)
+		}
+		for _, d := range r.DynamicRefs {
+			d.File = filepath.Join(name, d.File)
+			fr.dynamic = append(fr.dynamic, d)
+		}
+		if err := c.add(fr); err != nil {
+			return c.finish(err)
+		}
+		scanned += r.FilesScanned
+		skipped += r.FilesSkipped
+	}
+	c.result.FilesScanned, c.result.FilesSkipped = scanned, skipped
+	return c.finish(nil)
+}
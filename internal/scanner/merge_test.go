@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	web, worker := t.TempDir(), t.TempDir()
+	writeFile(t, web, "store.go", `package store
+func q() { db.Query("SELECT email FROM users") }`)
+	writeFile(t, worker, "jobs.sql", "SELECT id FROM orders;\nSELECT id FROM users;")
+
+	var results []ScanResult
+	for _, dir := range []string{web, worker} {
+		r, err := ScanWithOptions(dir, ScanOptions{Workers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+
+	for _, maxRefBytes := range []int64{0, 1} {
+		merged, err := Merge(results, []string{"web", "worker"}, maxRefBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"orders", "users"}; !reflect.DeepEqual(merged.Tables, want) {
+			t.Errorf("tables = %v, want %v", merged.Tables, want)
+		}
+		if merged.FilesScanned != 2 || merged.RefCount() != 3 {
+			t.Errorf("files = %d, refs = %d; want 2, 3", merged.FilesScanned, merged.RefCount())
+		}
+		if maxRefBytes > 0 && merged.Spilled() == 0 {
+			t.Error("expected spilled refs")
+		}
+		files := make(map[string]bool)
+		if err := merged.EachRef(func(r TableRef) { files[r.File] = true }); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]bool{filepath.Join("web", "store.go"): true, filepath.Join("worker", "jobs.sql"): true}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("files = %v, want %v", files, want)
+		}
+		_ = merged.Close()
+	}
+}
@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+// scanPath scans one file as opts configure. When migrations are
+// replayed, the DDL references of a versioned migration file are
+// dropped; the collector replays the file instead.
+func scanPath(path, relPath string, opts ScanOptions) fileResult {
+	return withMigration(scanFile(path, relPath, opts), path, relPath, opts)
+}
+
+// withMigration sets up the replay of fr, the result of the file at path,
+// if it is a versioned migration file and migrations are replayed.
+func withMigration(fr fileResult, path, relPath string, opts ScanOptions) fileResult {
+	if !opts.ReplayMigrations || fr.err != nil {
+		return fr
+	}
+	mf, ok := migration.VersionedFile(path)
+	if !ok {
+		return fr
+	}
+	mf.Name = relPath
+	fr.migration = &mf
+
+	kept := fr.refs[:0]
+	for _, r := range fr.refs {
+		if r.Pattern != PatternMigration {
+			kept = append(kept, r)
+		}
+	}
+	fr.refs = kept
+	return fr
+}
+
+// replay replays the versioned migrations and references each table
+// they leave at the statement that created it.
+func (c *collector) replay() error {
+	s, err := migration.Replay(c.migrations)
+	if err != nil {
+		return fmt.Errorf("replay migrations: %w", err)
+	}
+	for _, t := range s.Tables() {
+		ref := TableRef{Table: t.Name, File: t.File, Line: t.Line, Pattern: PatternMigration, Context: ContextDDL}
+		if !strings.EqualFold(t.Schema, "public") {
+			ref.Schema = t.Schema
+		}
+		c.tables[strings.ToLower(t.Name)] = true
+		c.refBytes += refSize(ref)
+		c.result.Refs = append(c.result.Refs, ref)
+	}
+	return nil
+}
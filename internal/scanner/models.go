@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// modelTracker follows the model classes of one file, whose tables and
+// columns depend on more than one line: a class's table can be set after
+// the class line, and its columns are declared in its body.
+type modelTracker interface {
+	// line reads one line of code, without comments.
+	line(lineNum int, text string, suppressed bool)
+	// refs returns the references the file's models make that the line
+	// patterns don't find.
+	refs(relPath string) ([]TableRef, []ColumnRef)
+}
+
+// modelTrackers makes the model tracker for a file extension.
+var modelTrackers = map[string]func() modelTracker{
+	".go":     func() modelTracker { return &goModels{} },
+	".rb":     func() modelTracker { return &rubyModels{} },
+	".java":   func() modelTracker { return &jpaEntities{} },
+	".kt":     func() modelTracker { return &jpaEntities{} },
+	".ex":     func() modelTracker { return &ectoSchemas{} },
+	".exs":    func() modelTracker { return &ectoSchemas{} },
+	".prisma": func() modelTracker { return &prismaModels{} },
+	".py":     func() modelTracker { return &sqlalchemyTables{} },
+}
+
+// modelTrackerSet runs several model trackers over one file.
+type modelTrackerSet []modelTracker
+
+func (s modelTrackerSet) line(lineNum int, text string, suppressed bool) {
+	for _, t := range s {
+		t.line(lineNum, text, suppressed)
+	}
+}
+
+func (s modelTrackerSet) refs(relPath string) ([]TableRef, []ColumnRef) {
+	var refs []TableRef
+	var colRefs []ColumnRef
+	for _, t := range s {
+		r, c := t.refs(relPath)
+		refs, colRefs = append(refs, r...), append(colRefs, c...)
+	}
+	return refs, colRefs
+}
+
+var (
+	camelBoundaryRe = regexp.MustCompile(`([A-Z\d]+)([A-Z][a-z])`)
+	camelLowerUpper = regexp.MustCompile(`([a-z\d])([A-Z])`)
+)
+
+// snakeCase turns a CamelCase class name into snake_case: HTMLPage
+// becomes html_page.
+func snakeCase(name string) string {
+	s := camelBoundaryRe.ReplaceAllString(name, "${1}_${2}")
+	s = camelLowerUpper.ReplaceAllString(s, "${1}_${2}")
+	return strings.ToLower(s)
+}
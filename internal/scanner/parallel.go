@@ -5,44 +5,94 @@ import (
 	"io/fs"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
 )
 
 // fileResult holds the scan result for a single file.
 type fileResult struct {
-	refs     []TableRef
-	colRefs  []ColumnRef
-	err      error
-	filePath string
+	refs    []TableRef
+	colRefs []ColumnRef
+	queries []QueryRef
+	dynamic []DynamicRef
+	// migration is set for a versioned migration file when migrations
+	// are replayed.
+	migration *migration.File
+	skipped   bool // read, but not a kind of file references are taken from
+	err       error
+	filePath  string
+}
+
+// ScanOptions tune a repository scan.
+type ScanOptions struct {
+	// Workers is the number of scanner goroutines: 0 means
+	// runtime.NumCPU(), 1 is sequential.
+	Workers int
+	// MaxRefBytes caps the memory held by table references. Past it they
+	// are spilled to a temp file; call Close on the result to remove it.
+	// 0 means no cap.
+	MaxRefBytes int64
+	// Parser turns SQL into references; empty means ParserRegex.
+	Parser Parser
+	// ReplayMigrations replays versioned migration directories (Flyway,
+	// golang-migrate, Alembic) in version order and references the tables
+	// they leave, instead of every table their DDL names: a table a later
+	// migration drops is no longer referenced.
+	ReplayMigrations bool
+	// DjangoTables references the implicit <app_label>_<model> table of
+	// each Django model without db_table. An app's label is the name of
+	// its directory unless DjangoAppLabels maps that name, or the
+	// directory's path in the repository, to another.
+	DjangoTables    bool
+	DjangoAppLabels map[string]string
+	// ExcludePaths are globs of paths to leave out, relative to the
+	// repository, such as **/testdata/** or generated/**; ** matches any
+	// number of directories. A glob without a slash matches file and
+	// directory names at any depth. Paths that .gitignore files ignore
+	// are always left out.
+	ExcludePaths []string
+	// Extensions are file extensions to scan on top of the built-in ones,
+	// such as .cshtml; their files are read as code.
+	Extensions []string
+	// SkipDirs are directories to skip on top of the built-in ones, such
+	// as node_modules: a name skips directories of that name at any
+	// depth, and a path relative to the repository skips that subtree.
+	SkipDirs []string
+	// Cache keeps the references of each file in the repository's cache
+	// file in CacheDir, keyed by its path and a hash of its content, so
+	// that a later scan only reads the files that changed. ScanChanged
+	// always uses the cache. A scan
+	// with MaxRefBytes set doesn't, since the cache holds every file's
+	// references in memory.
+	Cache bool
 }
 
 // ScanParallel walks a code repository using N goroutines.
 // workers=0 means runtime.NumCPU(). workers=1 is sequential.
 func ScanParallel(repoPath string, workers int) (ScanResult, error) {
+	return ScanWithOptions(repoPath, ScanOptions{Workers: workers})
+}
+
+// ScanWithOptions walks a code repository as configured by opts.
+func ScanWithOptions(repoPath string, opts ScanOptions) (ScanResult, error) {
+	if opts.Cache && opts.MaxRefBytes == 0 {
+		return scanCached(repoPath, opts)
+	}
+	workers := opts.Workers
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
 	if workers == 1 {
-		return Scan(repoPath)
+		return scanSequential(repoPath, opts)
 	}
 
 	// Phase 1: collect file paths
 	var paths []string
 	skipped := 0
 
-	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			if skipDirs[d.Name()] {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if !supportedExtensions[ext] {
+	err := walkRepo(repoPath, opts, func(path string, d fs.DirEntry, supported bool) error {
+		if !supported {
 			skipped++
 			return nil
 		}
@@ -69,13 +119,7 @@ func ScanParallel(repoPath string, workers int) (ScanResult, error) {
 			defer wg.Done()
 			for path := range pathCh {
 				relPath, _ := filepath.Rel(repoPath, path)
-				refs, colRefs, err := scanFile(path, relPath)
-				resultCh <- fileResult{
-					refs:     refs,
-					colRefs:  colRefs,
-					err:      err,
-					filePath: relPath,
-				}
+				resultCh <- scanPath(path, relPath, opts)
 			}
 		}()
 	}
@@ -84,21 +128,16 @@ func ScanParallel(repoPath string, workers int) (ScanResult, error) {
 	close(resultCh)
 
 	// Phase 3: merge results
-	result := ScanResult{
-		RepoPath:     repoPath,
-		FilesSkipped: skipped,
-	}
+	c := newCollector(repoPath, opts.MaxRefBytes)
+	c.result.FilesSkipped = skipped
 
 	for fr := range resultCh {
 		if fr.err != nil {
-			return result, fmt.Errorf("scan %s: %w", fr.filePath, fr.err)
+			return c.finish(fmt.Errorf("scan %s: %w", fr.filePath, fr.err))
+		}
+		if err := c.add(fr); err != nil {
+			return c.finish(err)
 		}
-		result.Refs = append(result.Refs, fr.refs...)
-		result.ColumnRefs = append(result.ColumnRefs, fr.colRefs...)
-		result.FilesScanned++
 	}
-
-	result.Tables = uniqueTables(result.Refs)
-	result.Columns = uniqueColumns(result.ColumnRefs)
-	return result, nil
+	return c.finish(nil)
 }
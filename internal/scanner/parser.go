@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Parser selects how SQL found in code is turned into table and column
+// references.
+type Parser string
+
+const (
+	// ParserRegex matches SQL with line patterns. It needs no complete
+	// statement and works on fragments of concatenated strings.
+	ParserRegex Parser = "regex"
+	// ParserAST parses complete statements with libpg_query, the parser
+	// PostgreSQL itself uses, and falls back to the patterns for anything
+	// that does not parse. It needs a build with -tags pgquery (and cgo).
+	ParserAST Parser = "ast"
+)
+
+// ParseParser converts a parser name to a Parser; empty means ParserRegex.
+// ParserAST is an error in builds without libpg_query.
+func ParseParser(s string) (Parser, error) {
+	switch p := Parser(strings.ToLower(strings.TrimSpace(s))); p {
+	case "", ParserRegex:
+		return ParserRegex, nil
+	case ParserAST:
+		if !astAvailable {
+			return "", fmt.Errorf("parser %q is not in this build; rebuild with cgo and -tags pgquery", p)
+		}
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown parser %q (valid: regex, ast)", s)
+	}
+}
+
+// astPlaceholderRe matches the placeholder styles drivers use, which
+// PostgreSQL's grammar only accepts as $n.
+var astPlaceholderRe = regexp.MustCompile(`%\(\w+\)s|%s|\?|@\w+|:[a-z_]\w*`)
+
+// astSQL prepares SQL from code for parsing: string literals emptied, so
+// placeholders in them are left alone, and placeholders rewritten to $1.
+func astSQL(sql string) string {
+	s := fpStringRe.ReplaceAllString(sql, "''")
+	// Keep ::type casts apart from :name parameters.
+	s = strings.ReplaceAll(s, "::", "\x00")
+	s = astPlaceholderRe.ReplaceAllString(s, "$$1")
+	return strings.ReplaceAll(s, "\x00", "::")
+}
+
+// scanStatementAST extracts the references in text with the AST parser.
+// text is a complete statement, or a line of code holding one in a string
+// literal. ok is false when it does not parse; the caller falls back to
+// the patterns.
+func scanStatementAST(text string, whole bool) (tables []tableMatch, columns []columnMatch, ok bool) {
+	sql := text
+	if !whole {
+		stmt, found := extractStatement(text)
+		if !found {
+			return nil, nil, false
+		}
+		sql = stmt
+	}
+	return parseSQL(astSQL(sql))
+}
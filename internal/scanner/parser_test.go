@@ -0,0 +1,38 @@
+package scanner
+
+import "testing"
+
+func TestParseParser(t *testing.T) {
+	for _, s := range []string{"", "regex", " Regex "} {
+		if p, err := ParseParser(s); err != nil || p != ParserRegex {
+			t.Errorf("ParseParser(%q) = %q, %v; want regex", s, p, err)
+		}
+	}
+	if _, err := ParseParser("yacc"); err == nil {
+		t.Error("unknown parser should be an error")
+	}
+	p, err := ParseParser("ast")
+	if astAvailable && (err != nil || p != ParserAST) {
+		t.Errorf("ParseParser(ast) = %q, %v; want ast", p, err)
+	}
+	if !astAvailable && err == nil {
+		t.Error("ast parser should be an error in a build without libpg_query")
+	}
+}
+
+func TestASTSQL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"SELECT * FROM users WHERE id = ?", "SELECT * FROM users WHERE id = $1"},
+		{"SELECT * FROM users WHERE id = %s AND org = %(org)s", "SELECT * FROM users WHERE id = $1 AND org = $1"},
+		{"SELECT * FROM users WHERE id = :id AND name = @name", "SELECT * FROM users WHERE id = $1 AND name = $1"},
+		{"SELECT id::text FROM users WHERE tag = 'a?b'", "SELECT id::text FROM users WHERE tag = ''"},
+		{"SELECT * FROM users WHERE id = $2", "SELECT * FROM users WHERE id = $2"},
+	}
+	for _, tt := range tests {
+		if got := astSQL(tt.in); got != tt.want {
+			t.Errorf("astSQL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -69,10 +69,74 @@ var patterns = []pattern{
 	{re: regexp.MustCompile(`\.Table\(["'](\w+)["']\)`),
 		tableGroup: 1, patType: PatternORM, context: ContextUnknown},
 
-	// ORM: Prisma @@map("name")
-	{re: regexp.MustCompile(`@@map\(["'](\w+)["']\)`),
+	// ORM: ActiveRecord self.table_name = "name" or :name
+	{re: regexp.MustCompile(`\bself\.table_name\s*=\s*(?:["']|:)(\w+)`),
 		tableGroup: 1, patType: PatternORM, context: ContextUnknown},
 
+	// ORM: Sequel DB[:name], DB.from(:name), Sequel::Model(:name), set_dataset :name
+	{re: regexp.MustCompile(`\b(?:\w*DB|db)(?:\[\s*|\.from\(\s*):(\w+)`),
+		tableGroup: 1, patType: PatternORM, context: ContextUnknown},
+	{re: regexp.MustCompile(`\b(?:Sequel::Model\(\s*|set_dataset[\s(]\s*):(\w+)`),
+		tableGroup: 1, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: JPA/Hibernate @Table(name = "name"), Spring Data @Table("name")
+	{re: jpaTableRe, tableGroup: 1, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Entity Framework [Table("name")] or [Table("name", Schema = "schema")]
+	{re: regexp.MustCompile(`\[\s*(?:\w+\.)*Table(?:Attribute)?\s*\(\s*"(\w+)"(?:\s*,\s*Schema\s*=\s*"(\w+)")?`),
+		tableGroup: 1, schemaGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Entity Framework fluent .ToTable("name") or .ToTable("name", "schema")
+	{re: regexp.MustCompile(`\.ToTable\(\s*"(\w+)"(?:\s*,\s*(?:schema:\s*)?"(\w+)")?`),
+		tableGroup: 1, schemaGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Laravel Eloquent protected $table = 'name', query builder DB::table('name')
+	{re: regexp.MustCompile(`\b(?:protected|public|private|var)\s+(?:static\s+)?(?:\??string\s+)?\$table\s*=\s*['"](?:(\w+)\.)?(\w+)['"]`),
+		schemaGroup: 1, tableGroup: 2, patType: PatternORM, context: ContextUnknown},
+	{re: regexp.MustCompile(`\bDB::table\(\s*['"](?:(\w+)\.)?(\w+)['"]`),
+		schemaGroup: 1, tableGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Doctrine #[ORM\Table(name: 'name', schema: 'schema')]
+	{re: regexp.MustCompile(`#\[\s*(?:\\?\w+\\)*Table\s*\(\s*(?:name\s*:\s*)?['"](\w+)['"](?:\s*,\s*schema\s*:\s*['"](\w+)['"])?`),
+		tableGroup: 1, schemaGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Ecto schema "name" do, from(x in "name")
+	{re: ectoSchemaRe, tableGroup: 1, patType: PatternORM, context: ContextUnknown},
+	{re: regexp.MustCompile(`\bfrom\(?\s*\w+\s+in\s+\{?\s*"(\w+)"`),
+		tableGroup: 1, patType: PatternORM, context: ContextSelect},
+
+	// ORM: TypeORM @Entity("name") or @Entity({ name: "name", schema: "schema" })
+	{re: regexp.MustCompile(`@Entity\(\s*(?:\{[^}]*?\bname\s*:\s*)?['"](\w+)['"](?:[^})]*?\bschema\s*:\s*['"](\w+)['"])?`),
+		tableGroup: 1, schemaGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Sequelize { tableName: 'name' }, Objection static tableName = 'name'
+	{re: regexp.MustCompile(`\btableName\s*[:=]\s*['"](\w+)['"]`),
+		tableGroup: 1, patType: PatternORM, context: ContextUnknown},
+
+	// ORM: Knex knex('name'), db('name'), knex.from('name'), .select(...).from('name')
+	{re: regexp.MustCompile(`\b(?:knex|db|trx)\(\s*['"](?:(\w+)\.)?(\w+)(?:\s+as\s+\w+)?['"]\s*\)`),
+		schemaGroup: 1, tableGroup: 2, patType: PatternORM, context: ContextUnknown},
+	{re: regexp.MustCompile(`(?:\b(?:knex|db|trx)|\))\s*\.(?:from|into|table)\(\s*['"](?:(\w+)\.)?(\w+)(?:\s+as\s+\w+)?['"]\s*\)`),
+		schemaGroup: 1, tableGroup: 2, patType: PatternORM, context: ContextUnknown},
+
+	// Migration: Knex schema.createTable('name'), Sequelize queryInterface.createTable('name')
+	{re: regexp.MustCompile(`\.schema(?:\.withSchema\([^)]*\))?\.(?:createTable|createTableIfNotExists|alterTable|table)\(\s*['"](\w+)['"]`),
+		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
+	{re: regexp.MustCompile(`\bqueryInterface\.(?:createTable|addColumn|changeColumn|renameColumn|removeColumn|addIndex|addConstraint)\(\s*['"](\w+)['"]`),
+		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
+
+	// Migration: Ecto create table(:name), alter table(:name), create index(:name, ...)
+	{re: regexp.MustCompile(`\b(?:create|create_if_not_exists|alter)\s+(?:table|(?:unique_)?index)\(\s*:(\w+)`),
+		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
+
+	// Migration: Laravel Schema::create('name'), Schema::table('name')
+	{re: regexp.MustCompile(`\bSchema::(?:create|table)\(\s*['"](\w+)['"]`),
+		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
+
+	// Migration: Rails and Sequel create_table :name, change_table, add_column :name, ...
+	{re: regexp.MustCompile(`\b(?:create_table|change_table|add_column|add_index|add_reference)[\s(]\s*(?::|["'])(\w+)`),
+		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
+
 	// Migration: CREATE TABLE [IF NOT EXISTS] table
 	{re: regexp.MustCompile(`(?i)\bCREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)\.(\w+)`),
 		schemaGroup: 1, tableGroup: 2, patType: PatternMigration, context: ContextDDL},
@@ -94,6 +158,60 @@ var patterns = []pattern{
 		tableGroup: 1, patType: PatternMigration, context: ContextDDL},
 }
 
+// CTE definitions: the first after WITH, and any further ones after a
+// comma. Both allow a column list and [NOT] MATERIALIZED.
+var (
+	firstCTERe = regexp.MustCompile(`(?i)\bWITH\s+(?:RECURSIVE\s+)?(\w+)\s*(?:\([^)]*\)\s*)?AS\s*(?:NOT\s+)?(?:MATERIALIZED\s+)?\(`)
+	nextCTERe  = regexp.MustCompile(`(?i),\s*(\w+)\s*(?:\([^)]*\)\s*)?AS\s*(?:NOT\s+)?(?:MATERIALIZED\s+)?\(`)
+)
+
+// cteNames returns the lowercased names of the common table expressions
+// text defines. Names after a comma only count once a WITH has been seen,
+// in text or, when inWith is set, on an earlier line of the statement.
+func cteNames(text string, inWith bool) map[string]bool {
+	first := firstCTERe.FindAllStringSubmatch(text, -1)
+	if len(first) == 0 && !inWith {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, m := range first {
+		names[strings.ToLower(m[1])] = true
+	}
+	for _, m := range nextCTERe.FindAllStringSubmatch(text, -1) {
+		names[strings.ToLower(m[1])] = true
+	}
+	return names
+}
+
+// dynamicTableRe matches a table name built at runtime: a string that
+// ends right after the keyword and is concatenated with something, or an
+// interpolation or format verb in the table name's place, as in
+// "FROM " + table, f"FROM {table}", `FROM ${table}`, "FROM #{table}",
+// and Sprintf("FROM %s").
+var (
+	dynamicTableRe = regexp.MustCompile(`(?i)\b(FROM|JOIN|INSERT\s+INTO|UPDATE)(?:\s*["'` + "`" + `]\s*\+|\s+(?:\$\{|#\{|\{\w*\}|%[sv]\b|%\(\w+\)s))`)
+	setRe          = regexp.MustCompile(`(?i)\bSET\b`)
+)
+
+// dynamicTable reports whether a statement in text takes its table name
+// from a runtime value, and the keyword before it. The match must follow
+// a statement keyword, and an UPDATE must be followed by SET, so that
+// prose such as "read from " + path is not taken for SQL.
+func dynamicTable(text string) (string, bool) {
+	start := statementStartRe.FindStringIndex(text)
+	if start == nil {
+		return "", false
+	}
+	for _, loc := range dynamicTableRe.FindAllStringSubmatchIndex(text[start[0]:], -1) {
+		keyword := strings.ToUpper(strings.Join(strings.Fields(text[start[0]+loc[2]:start[0]+loc[3]]), " "))
+		if keyword == "UPDATE" && !setRe.MatchString(text[start[0]+loc[1]:]) {
+			continue
+		}
+		return keyword, true
+	}
+	return "", false
+}
+
 // SQL keywords that should not be treated as table names.
 var sqlKeywords = map[string]bool{
 	"select": true, "from": true, "where": true, "and": true, "or": true,
@@ -106,14 +224,22 @@ var sqlKeywords = map[string]bool{
 	"exists": true, "between": true, "like": true, "true": true, "false": true,
 	"table": true, "index": true, "create": true, "alter": true, "drop": true,
 	"insert": true, "update": true, "delete": true, "begin": true, "commit": true,
-	"rollback": true, "if": true, "with": true, "returning": true,
+	"rollback": true, "if": true, "with": true, "returning": true, "lateral": true,
 	// Common false positives from import statements
 	"sqlalchemy": true, "django": true, "gorm": true, "prisma": true,
 	"import": true, "package": true, "require": true, "include": true,
 }
 
-// ScanLine extracts table references from a single line of code.
+// ScanLine extracts table references from a single line of code. Names of
+// common table expressions the line defines (WITH recent AS (...)) are not
+// table references.
 func ScanLine(line string) []tableMatch {
+	return scanLine(line, cteNames(line, false))
+}
+
+// scanLine extracts table references from line, skipping unqualified
+// names in ctes.
+func scanLine(line string, ctes map[string]bool) []tableMatch {
 	var matches []tableMatch
 	seen := make(map[string]bool)
 
@@ -128,6 +254,9 @@ func ScanLine(line string) []tableMatch {
 			if p.schemaGroup > 0 && p.schemaGroup < len(m) {
 				schema = m[p.schemaGroup]
 			}
+			if schema == "" && ctes[strings.ToLower(table)] {
+				continue
+			}
 
 			key := schema + "." + table + string(p.context)
 			if seen[key] {
@@ -159,6 +288,35 @@ var columnPatterns = []struct {
 	re      *regexp.Regexp
 	extract func([]string) []columnMatch
 }{
+	// WHERE/AND/OR col @> / col ->> / col ?| ..., optionally qualified.
+	// Listed before the plain condition pattern so <@ isn't taken for <.
+	{re: regexp.MustCompile(`(?i)\b(?:WHERE|AND|OR)\s+(?:(\w+)\.)?(\w+)\s*(?:@>|<@|\?\||\?&|->>|->|#>>|#>|@\?|@@)`),
+		extract: extractJSONBColumn},
+
+	// WHERE/AND/OR jsonb_path_exists(col, ...) and similar jsonb predicates
+	{re: regexp.MustCompile(`(?i)\b(?:WHERE|AND|OR)\s+(?:NOT\s+)?jsonb_(?:path_exists|path_match|exists|exists_any|exists_all|contains)\s*\(\s*(?:(\w+)\.)?(\w+)`),
+		extract: extractJSONBColumn},
+
+	// INSERT INTO table ... ON CONFLICT (col1, col2)
+	{re: regexp.MustCompile(`(?i)\bINSERT\s+INTO\s+(?:(\w+)\.)?(\w+)\b.*?\bON\s+CONFLICT\s*\(([^)]+)\)`),
+		extract: extractConflictColumns},
+
+	// INSERT INTO table ... ON CONFLICT ... DO UPDATE SET col = ..., col2 = ...
+	{re: regexp.MustCompile(`(?i)\bINSERT\s+INTO\s+(?:(\w+)\.)?(\w+)\b.*?\bDO\s+UPDATE\s+SET\s+(.+?)(?:\s+WHERE\b|\s+RETURNING\b|;|$)`),
+		extract: extractConflictUpdateColumns},
+
+	// INSERT INTO / UPDATE / DELETE FROM table ... RETURNING col1, col2
+	{re: regexp.MustCompile(`(?i)\b(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+(?:(\w+)\.)?(\w+)\b.*?\bRETURNING\s+([\w\s.,*]+)`),
+		extract: extractReturningColumns},
+
+	// JOIN ... ON a.col = b.col, and AND a.col = b.col conditions after it
+	{re: regexp.MustCompile(`(?i)\b(?:ON|AND)\s+(?:(\w+)\.)?(\w+)\s*=\s*(?:(\w+)\.)?(\w+)\b`),
+		extract: extractJoinColumns},
+
+	// JOIN table USING (col1, col2)
+	{re: regexp.MustCompile(`(?i)\bJOIN\s+(?:(\w+)\.)?(\w+)(?:\s+(?:AS\s+)?\w+)?\s+USING\s*\(([^)]+)\)`),
+		extract: extractJoinUsingColumns},
+
 	// table.column dotted reference (e.g., users.email, u.name)
 	{re: regexp.MustCompile(`(?i)\b(\w+)\.(\w+)\b`), extract: extractDottedColumn},
 
@@ -252,6 +410,14 @@ func extractConditionColumn(m []string) []columnMatch {
 	return []columnMatch{{Column: col, Context: ContextWhere}}
 }
 
+func extractJSONBColumn(m []string) []columnMatch {
+	table, col := m[1], m[2]
+	if !isValidColumnName(col) {
+		return nil
+	}
+	return []columnMatch{{Table: table, Column: col, Context: ContextJSONB}}
+}
+
 func extractByColumn(m []string) []columnMatch {
 	col := m[1]
 	if !isValidColumnName(col) {
@@ -272,6 +438,116 @@ func extractInsertColumns(m []string) []columnMatch {
 	return matches
 }
 
+// extractConflictColumns returns the conflict target of an upsert. Index
+// expressions such as lower(email) are skipped.
+func extractConflictColumns(m []string) []columnMatch {
+	schema, table := m[1], m[2]
+	var matches []columnMatch
+	for _, part := range strings.Split(m[3], ",") {
+		col := strings.TrimSpace(part)
+		if isValidColumnName(col) && !strings.ContainsAny(col, "( ") {
+			matches = append(matches, columnMatch{Table: table, Schema: schema, Column: col, Context: ContextConflict})
+		}
+	}
+	return matches
+}
+
+// conflictSetRe matches the column of one assignment in a SET list.
+var conflictSetRe = regexp.MustCompile(`(?:^|,)\s*(\w+)\s*=`)
+
+// extractConflictUpdateColumns returns the columns an upsert's DO UPDATE
+// SET assigns.
+func extractConflictUpdateColumns(m []string) []columnMatch {
+	schema, table := m[1], m[2]
+	var matches []columnMatch
+	for _, a := range conflictSetRe.FindAllStringSubmatch(m[3], -1) {
+		if isValidColumnName(a[1]) {
+			matches = append(matches, columnMatch{Table: table, Schema: schema, Column: a[1], Context: ContextUpdate})
+		}
+	}
+	return matches
+}
+
+// extractReturningColumns returns the columns of a RETURNING list, of the
+// table the statement writes.
+func extractReturningColumns(m []string) []columnMatch {
+	schema, table := m[1], m[2]
+	if !isValidTableName(table) {
+		return nil // DO UPDATE SET of an upsert
+	}
+	var matches []columnMatch
+	for _, part := range strings.Split(m[3], ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		col := fields[0]
+		if dot := strings.LastIndexByte(col, '.'); dot >= 0 {
+			col = col[dot+1:]
+		}
+		if isValidColumnName(col) {
+			matches = append(matches, columnMatch{Table: table, Schema: schema, Column: col, Context: ContextReturning})
+		}
+	}
+	return matches
+}
+
+// extractJoinColumns returns both sides of an equality between two
+// qualified columns, as in a join condition. Conditions with a literal or
+// placeholder on one side are WHERE conditions.
+func extractJoinColumns(m []string) []columnMatch {
+	if m[1] == "" || m[3] == "" {
+		return nil
+	}
+	var matches []columnMatch
+	for _, side := range [][2]string{{m[1], m[2]}, {m[3], m[4]}} {
+		if !sqlKeywords[strings.ToLower(side[0])] && isValidColumnName(side[1]) {
+			matches = append(matches, columnMatch{Table: side[0], Column: side[1], Context: ContextJoin})
+		}
+	}
+	return matches
+}
+
+// extractJoinUsingColumns returns the columns of a JOIN ... USING list,
+// of the joined table.
+func extractJoinUsingColumns(m []string) []columnMatch {
+	schema, table := m[1], m[2]
+	if !isValidTableName(table) {
+		return nil
+	}
+	var matches []columnMatch
+	for _, part := range strings.Split(m[3], ",") {
+		col := strings.TrimSpace(part)
+		if isValidColumnName(col) {
+			matches = append(matches, columnMatch{Table: table, Schema: schema, Column: col, Context: ContextJoin})
+		}
+	}
+	return matches
+}
+
+// tableAliasRe matches a FROM or JOIN table and its alias.
+var tableAliasRe = regexp.MustCompile(`(?i)\b(?:FROM|JOIN|UPDATE|INTO)\s+(?:(\w+)\.)?(\w+)(?:\s+(?:AS\s+)?(\w+))?`)
+
+// resolveJoinAliases replaces the aliases that qualify join columns with
+// the tables line gives them to, so the index advisor can find the table.
+func resolveJoinAliases(line string, matches []columnMatch) {
+	aliases := make(map[string][2]string)
+	for _, m := range tableAliasRe.FindAllStringSubmatch(line, -1) {
+		if m[3] != "" && !sqlKeywords[strings.ToLower(m[3])] {
+			aliases[strings.ToLower(m[3])] = [2]string{m[1], m[2]}
+		}
+	}
+	for i := range matches {
+		cm := &matches[i]
+		if cm.Context != ContextJoin || cm.Schema != "" {
+			continue
+		}
+		if t, ok := aliases[strings.ToLower(cm.Table)]; ok {
+			cm.Schema, cm.Table = t[0], t[1]
+		}
+	}
+}
+
 // ScanLineColumns extracts column references from a single line of code.
 func ScanLineColumns(line string) []columnMatch {
 	var matches []columnMatch
@@ -289,6 +565,7 @@ func ScanLineColumns(line string) []columnMatch {
 			}
 		}
 	}
+	resolveJoinAliases(line, matches)
 
 	return matches
 }
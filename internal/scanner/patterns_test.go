@@ -126,7 +126,30 @@ func TestScanLine_ORM(t *testing.T) {
 		{"django", `        db_table = "orders"`, "orders"},
 		{"gorm tablename", `func (User) TableName() string { return "users" }`, "users"},
 		{"gorm table", `db.Table("orders").Find(&results)`, "orders"},
-		{"prisma", `  @@map("user_accounts")`, "user_accounts"},
+		{"activerecord table_name", `  self.table_name = "legacy_users"`, "legacy_users"},
+		{"activerecord symbol", `  self.table_name = :legacy_orders`, "legacy_orders"},
+		{"sequel dataset", `DB[:accounts].where(id: 1).first`, "accounts"},
+		{"sequel from", `db.from(:invoices).count`, "invoices"},
+		{"sequel model", `class Post < Sequel::Model(:blog_posts)`, "blog_posts"},
+		{"sequel set_dataset", `  set_dataset :archived_posts`, "archived_posts"},
+		{"ef table attribute", `[Table("customers")]`, "customers"},
+		{"ef fluent", `modelBuilder.Entity<Invoice>().ToTable("invoices");`, "invoices"},
+		{"eloquent table", `    protected $table = 'flights';`, "flights"},
+		{"eloquent typed table", `    protected ?string $table = "airports";`, "airports"},
+		{"laravel query builder", `$users = DB::table('members')->where('active', 1)->get();`, "members"},
+		{"doctrine attribute", `#[ORM\Table(name: 'products')]`, "products"},
+		{"doctrine attribute positional", `#[Table('carts')]`, "carts"},
+		{"ecto schema", `  schema "accounts" do`, "accounts"},
+		{"ecto query", `query = from(u in "users", where: u.age > 18, select: u.name)`, "users"},
+		{"ecto query source tuple", `from p in {"legacy_posts", Post}, select: p.id`, "legacy_posts"},
+		{"typeorm entity", `@Entity("photos")`, "photos"},
+		{"typeorm entity options", `@Entity({ name: "albums", schema: "media" })`, "albums"},
+		{"sequelize tableName", `  }, { sequelize, tableName: 'customers', timestamps: false });`, "customers"},
+		{"objection tableName", `  static tableName = 'persons';`, "persons"},
+		{"knex call", `const rows = await knex('accounts').where({ id });`, "accounts"},
+		{"knex alias", `db("orders as o").join("users as u", "u.id", "o.user_id")`, "orders"},
+		{"knex from", `knex.select('id').from('payments')`, "payments"},
+		{"knex insert into", `await trx.insert(rows).into('audit_log')`, "audit_log"},
 	}
 
 	for _, tt := range tests {
@@ -158,6 +181,18 @@ func TestScanLine_Migration(t *testing.T) {
 		{"create index", `CREATE INDEX idx_users_email ON users (email)`, "users"},
 		{"create unique index", `CREATE UNIQUE INDEX idx_orders_id ON orders (id)`, "orders"},
 		{"schema qualified", `CREATE TABLE public.users (`, "users"},
+		{"rails create_table", `    create_table :users do |t|`, "users"},
+		{"rails create_table string", `  create_table "orders", force: :cascade do |t|`, "orders"},
+		{"rails add_column", `    add_column :users, :email, :string`, "users"},
+		{"sequel create_table", `    create_table(:sessions) do`, "sessions"},
+		{"laravel schema create", `        Schema::create('password_resets', function (Blueprint $table) {`, "password_resets"},
+		{"laravel schema table", `        Schema::table('users', function (Blueprint $table) {`, "users"},
+		{"ecto create table", `    create table(:comments) do`, "comments"},
+		{"ecto alter table", `    alter table(:users) do`, "users"},
+		{"ecto create index", `    create unique_index(:users, [:email])`, "users"},
+		{"knex createTable", `  return knex.schema.createTable('tags', (table) => {`, "tags"},
+		{"knex withSchema", `  await knex.schema.withSchema('app').alterTable('tags', (t) => {`, "tags"},
+		{"sequelize createTable", `    await queryInterface.createTable('Users', {`, "Users"},
 	}
 
 	for _, tt := range tests {
@@ -202,6 +237,42 @@ func TestScanLine_RejectsKeywords(t *testing.T) {
 	}
 }
 
+func TestScanLine_CTENames(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		tables []string
+	}{
+		{"single", `WITH recent AS (SELECT * FROM orders) SELECT * FROM recent`, []string{"orders"}},
+		{"several", `WITH a AS (SELECT id FROM users), b AS (SELECT id FROM accounts) SELECT * FROM a JOIN b ON a.id = b.id`, []string{"users", "accounts"}},
+		{"recursive", `WITH RECURSIVE tree(id, parent) AS (SELECT id, parent FROM nodes UNION ALL SELECT n.id, n.parent FROM nodes n JOIN tree t ON n.parent = t.id) SELECT * FROM tree`, []string{"nodes"}},
+		{"materialized", `WITH totals AS MATERIALIZED (SELECT sum(x) FROM ledger) SELECT * FROM totals`, []string{"ledger"}},
+		{"case insensitive", `with Recent as (select * from orders) select * from RECENT`, []string{"orders"}},
+		{"schema qualified is a table", `WITH recent AS (SELECT 1) SELECT * FROM archive.recent`, []string{"recent", "archive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range ScanLine(tt.line) {
+				got = append(got, m.Table)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.tables, ",") {
+				t.Errorf("tables = %v, want %v", got, tt.tables)
+			}
+		})
+	}
+}
+
+func TestCTENames_CommaNeedsWith(t *testing.T) {
+	if got := cteNames(`foo(x, bar AS (1))`, false); got != nil {
+		t.Errorf("without WITH: got %v, want none", got)
+	}
+	if got := cteNames(`, totals AS (SELECT 1)`, true); !got["totals"] {
+		t.Errorf("continuing a WITH: got %v, want totals", got)
+	}
+}
+
 func TestScanLineColumns_Select(t *testing.T) {
 	matches := ScanLineColumns(`SELECT name, email FROM users`)
 	found := make(map[string]bool)
@@ -243,6 +314,115 @@ func TestScanLineColumns_Where(t *testing.T) {
 	}
 }
 
+func TestScanLineColumns_Join(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"aliases", `SELECT * FROM users u JOIN orders o ON o.user_id = u.id`, []string{"orders.user_id", "users.id"}},
+		{"table names", `LEFT JOIN payments ON orders.id = payments.order_id`, []string{"orders.id", "payments.order_id"}},
+		{"schema qualified", `FROM app.users AS u INNER JOIN app.orders o ON u.id = o.user_id AND o.region = u.region`, []string{"app.users.id", "app.orders.user_id", "app.orders.region", "app.users.region"}},
+		{"using", `SELECT * FROM orders JOIN order_items i USING (order_id, tenant_id)`, []string{"order_items.order_id", "order_items.tenant_id"}},
+		{"literal is not a join", `SELECT * FROM users u WHERE u.id = $1 AND u.status = 'active'`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range ScanLineColumns(tt.line) {
+				if m.Context != ContextJoin {
+					continue
+				}
+				name := m.Table + "." + m.Column
+				if m.Schema != "" {
+					name = m.Schema + "." + name
+				}
+				got = append(got, name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("JOIN columns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanLineColumns_Upsert(t *testing.T) {
+	line := `INSERT INTO app.counters (key, hits) VALUES ($1, 1) ON CONFLICT (key, lower(name)) DO UPDATE SET hits = counters.hits + 1, updated_at = now() RETURNING hits, id AS counter_id`
+	got := make(map[string]bool)
+	for _, m := range ScanLineColumns(line) {
+		got[m.Schema+"."+m.Table+"."+m.Column+":"+string(m.Context)] = true
+	}
+	for _, want := range []string{
+		"app.counters.key:ON_CONFLICT",
+		"app.counters.hits:UPDATE",
+		"app.counters.updated_at:UPDATE",
+		"app.counters.id:RETURNING",
+	} {
+		if !got[want] {
+			t.Errorf("missing %s in %v", want, got)
+		}
+	}
+	for k := range got {
+		if strings.Contains(k, "lower") || strings.Contains(k, "counter_id") {
+			t.Errorf("unexpected column %s", k)
+		}
+	}
+}
+
+func TestScanLineColumns_Returning(t *testing.T) {
+	tests := []struct {
+		line, table string
+		columns     []string
+	}{
+		{`UPDATE accounts SET balance = 0 WHERE id = $1 RETURNING id, balance`, "accounts", []string{"id", "balance"}},
+		{`DELETE FROM sessions WHERE expires_at < now() RETURNING s.user_id`, "sessions", []string{"user_id"}},
+		{`INSERT INTO users (email) VALUES ($1) RETURNING *`, "users", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range ScanLineColumns(tt.line) {
+			if m.Context == ContextReturning {
+				if m.Table != tt.table {
+					t.Errorf("%s: table = %q, want %q", tt.line, m.Table, tt.table)
+				}
+				got = append(got, m.Column)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.columns, ",") {
+			t.Errorf("%s: RETURNING columns = %v, want %v", tt.line, got, tt.columns)
+		}
+	}
+}
+
+func TestScanLineColumns_JSONB(t *testing.T) {
+	tests := []struct {
+		line, table, column string
+	}{
+		{`SELECT id FROM events WHERE payload @> '{"kind":"click"}'`, "", "payload"},
+		{`WHERE e.payload->>'kind' = $1`, "e", "payload"},
+		{`AND tags ?| array['a','b']`, "", "tags"},
+		{`WHERE attrs <@ $1::jsonb`, "", "attrs"},
+		{`WHERE NOT jsonb_path_exists(events.payload, '$.user')`, "events", "payload"},
+	}
+	for _, tt := range tests {
+		var got *columnMatch
+		for _, m := range ScanLineColumns(tt.line) {
+			if m.Context == ContextJSONB {
+				got = &m
+			}
+		}
+		if got == nil || got.Table != tt.table || got.Column != tt.column {
+			t.Errorf("%s: got %+v, want %s.%s", tt.line, got, tt.table, tt.column)
+		}
+	}
+
+	for _, m := range ScanLineColumns(`WHERE status = ? AND age > 18`) {
+		if m.Context == ContextJSONB {
+			t.Errorf("plain condition matched as jsonb: %+v", m)
+		}
+	}
+}
+
 func TestScanLineColumns_Insert(t *testing.T) {
 	matches := ScanLineColumns(`INSERT INTO users (name, email, status) VALUES ('a', 'b', 'c')`)
 	found := make(map[string]bool)
@@ -356,3 +536,61 @@ func TestIsValidTableName(t *testing.T) {
 		})
 	}
 }
+
+func TestDynamicTable(t *testing.T) {
+	tests := []struct {
+		text    string
+		keyword string
+	}{
+		{`db.Query("SELECT id FROM " + table + " WHERE id = $1")`, "FROM"},
+		{`cur.execute(f"SELECT * FROM {table} WHERE id = %s", (id,))`, "FROM"},
+		{"db.query(`DELETE FROM ${table} WHERE id = $1`)", "FROM"},
+		{`conn.exec("SELECT a.id FROM accounts a JOIN #{other} o ON o.id = a.id")`, "JOIN"},
+		{`fmt.Sprintf("INSERT  INTO %s (id) VALUES ($1)", table)`, "INSERT INTO"},
+		{`cur.execute("SELECT * FROM %(table)s" % params)`, "FROM"},
+		{`"SELECT * FROM {}".format(table)`, "FROM"},
+		{`"UPDATE " + table + " SET name = $1"`, "UPDATE"},
+		{`log.Printf("failed to update " + name)`, ""},
+		{`errors.New("read from " + path)`, ""},
+		{`db.Query("SELECT id FROM users WHERE name = '" + name + "'")`, ""},
+		{`cur.execute("SELECT * FROM users WHERE id = %s", (id,))`, ""},
+	}
+	for _, tt := range tests {
+		keyword, ok := dynamicTable(tt.text)
+		if ok != (tt.keyword != "") || keyword != tt.keyword {
+			t.Errorf("dynamicTable(%q) = %q, %v; want %q", tt.text, keyword, ok, tt.keyword)
+		}
+	}
+}
+
+func TestScanLine_EntityFrameworkSchema(t *testing.T) {
+	tests := []struct {
+		line   string
+		schema string
+		table  string
+	}{
+		{`[Table("customers", Schema = "sales")]`, "sales", "customers"},
+		{`builder.ToTable("invoices", "billing");`, "billing", "invoices"},
+		{`builder.ToTable("invoices", schema: "billing");`, "billing", "invoices"},
+		{`builder.ToTable("invoices");`, "", "invoices"},
+	}
+	for _, tt := range tests {
+		matches := ScanLine(tt.line)
+		if len(matches) != 1 || matches[0].Table != tt.table || matches[0].Schema != tt.schema {
+			t.Errorf("ScanLine(%q) = %v, want %s.%s", tt.line, matches, tt.schema, tt.table)
+		}
+	}
+}
+
+func TestScanLine_NodeORMNonMatches(t *testing.T) {
+	for _, line := range []string{
+		`const buf = Buffer.from('hello')`,
+		`const chars = Array.from("letters")`,
+		`@Entity(name = "AppUser")`,
+		`db(tableVar).where({ id })`,
+	} {
+		if matches := ScanLine(line); len(matches) != 0 {
+			t.Errorf("ScanLine(%q) = %v, want none", line, matches)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+// prismaModels collects the lines of a Prisma schema, whose models are
+// parsed once the file is read: a model's fields decide its columns only
+// when the file's enums are known.
+type prismaModels struct {
+	lines      []string
+	suppressed map[int]bool
+}
+
+func (p *prismaModels) line(lineNum int, text string, suppressed bool) {
+	for len(p.lines) < lineNum-1 {
+		p.lines = append(p.lines, "")
+	}
+	p.lines = append(p.lines, text)
+	if suppressed {
+		if p.suppressed == nil {
+			p.suppressed = make(map[int]bool)
+		}
+		p.suppressed[lineNum] = true
+	}
+}
+
+// refs returns a reference to each model's table, and to each of its
+// columns, at the line that declares it.
+func (p *prismaModels) refs(relPath string) ([]TableRef, []ColumnRef) {
+	s := migration.NewSchema()
+	s.ApplyPrisma(relPath, strings.Join(p.lines, "\n"))
+
+	var refs []TableRef
+	var colRefs []ColumnRef
+	for _, t := range s.Tables() {
+		schema := t.Schema
+		if strings.EqualFold(schema, "public") {
+			schema = ""
+		}
+		if !isValidTableName(t.Name) {
+			continue
+		}
+		refs = append(refs, TableRef{Table: t.Name, Schema: schema, File: relPath, Line: t.Line, Pattern: PatternORM, Context: ContextUnknown, Suppressed: p.suppressed[t.Line]})
+		for _, c := range t.Columns {
+			colRefs = append(colRefs, ColumnRef{Table: t.Name, Column: c.Name, Schema: schema, File: relPath, Line: c.Line, Context: ContextUnknown, Suppressed: p.suppressed[c.Line]})
+		}
+	}
+	return refs, colRefs
+}
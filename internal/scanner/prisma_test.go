@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan_PrismaModels(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "prisma/schema.prisma", `model User {
+  id       Int     @id
+  email    String  @unique
+  fullName String? @map("full_name")
+  posts    Post[]
+
+  @@map("users")
+}
+
+model Post {
+  id       Int  @id
+  authorId Int
+  author   User @relation(fields: [authorId], references: [id])
+}
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Tables, []string{"post", "users"}) {
+		t.Errorf("tables = %v, want [post users]", result.Tables)
+	}
+	for _, ref := range result.Refs {
+		if ref.Table == "users" && ref.Line != 1 {
+			t.Errorf("users at line %d, want 1", ref.Line)
+		}
+	}
+	var got []string
+	for _, c := range result.ColumnRefs {
+		got = append(got, c.Table+"."+c.Column)
+	}
+	want := []string{"Post.id", "Post.authorId", "users.id", "users.email", "users.full_name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Ruby model classes. ActiveRecord and Sequel derive a model's table from
+// its class name unless the class sets one; abstract classes have none.
+var (
+	rubyModelRe    = regexp.MustCompile(`^\s*class\s+(?:\w+::)*(\w+)\s*<\s*(?:::)?(?:ApplicationRecord|ActiveRecord::Base|Sequel::Model)\s*(?:;|$)`)
+	rubyOwnTableRe = regexp.MustCompile(`^\s*(?:self\.table_name\s*=|set_dataset\b|self\.abstract_class\s*=\s*true\b|primary_abstract_class\b)`)
+)
+
+// uncountables are nouns whose plural is the word itself.
+var uncountables = map[string]bool{
+	"equipment": true, "information": true, "rice": true, "money": true, "species": true,
+	"series": true, "fish": true, "sheep": true, "jeans": true, "police": true,
+}
+
+// irregularPlurals are the nouns whose plural the suffix rules get wrong.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"ox":     "oxen",
+}
+
+// pluralRules are tried in order; the first whose suffix matches wins.
+// They follow the default ActiveSupport inflections.
+var pluralRules = []struct {
+	re  *regexp.Regexp
+	rep string
+}{
+	{regexp.MustCompile(`(quiz)$`), "${1}zes"},
+	{regexp.MustCompile(`(matr|vert|ind)(?:ix|ex)$`), "${1}ices"},
+	{regexp.MustCompile(`(octop|vir)(?:us|i)$`), "${1}i"},
+	{regexp.MustCompile(`(alias|status)$`), "${1}es"},
+	{regexp.MustCompile(`(bu)s$`), "${1}ses"},
+	{regexp.MustCompile(`(buffal|tomat)o$`), "${1}oes"},
+	{regexp.MustCompile(`([ti])um$`), "${1}a"},
+	{regexp.MustCompile(`([ti])a$`), "${1}a"},
+	{regexp.MustCompile(`sis$`), "ses"},
+	{regexp.MustCompile(`(?:([^f])fe|([lr])f)$`), "${1}${2}ves"},
+	{regexp.MustCompile(`(hive)$`), "${1}s"},
+	{regexp.MustCompile(`([^aeiouy]|qu)y$`), "${1}ies"},
+	{regexp.MustCompile(`(x|ch|ss|sh)$`), "${1}es"},
+	{regexp.MustCompile(`(ax|test)is$`), "${1}es"},
+	{regexp.MustCompile(`s$`), "s"},
+}
+
+// rubyModel is a model class whose table comes from its name.
+type rubyModel struct {
+	table      string
+	line       int
+	suppressed bool
+	ownTable   bool // the class names its table, or has none
+}
+
+// rubyModels tracks the model classes of one Ruby file. A class's
+// implicit table is only known once its body has been read, since
+// self.table_name or self.abstract_class can follow the class line.
+type rubyModels struct {
+	models []*rubyModel
+}
+
+func (r *rubyModels) line(lineNum int, text string, suppressed bool) {
+	if m := rubyModelRe.FindStringSubmatch(text); m != nil {
+		r.models = append(r.models, &rubyModel{
+			table:      tableize(m[1]),
+			line:       lineNum,
+			suppressed: suppressed,
+			ownTable:   m[1] == "ApplicationRecord",
+		})
+		return
+	}
+	if len(r.models) > 0 && rubyOwnTableRe.MatchString(text) {
+		r.models[len(r.models)-1].ownTable = true
+	}
+}
+
+// refs returns the implicit table references of the file's models.
+func (r *rubyModels) refs(relPath string) ([]TableRef, []ColumnRef) {
+	var refs []TableRef
+	for _, m := range r.models {
+		if m.ownTable || !isValidTableName(m.table) {
+			continue
+		}
+		refs = append(refs, TableRef{
+			Table:      m.table,
+			File:       relPath,
+			Line:       m.line,
+			Pattern:    PatternORM,
+			Context:    ContextUnknown,
+			Suppressed: m.suppressed,
+		})
+	}
+	return refs, nil
+}
+
+// tableize turns a model class name into its default table name, as
+// ActiveSupport does: UserProfile becomes user_profiles.
+func tableize(class string) string {
+	s := snakeCase(class)
+	head, word := "", s
+	if i := strings.LastIndexByte(s, '_'); i >= 0 {
+		head, word = s[:i+1], s[i+1:]
+	}
+	return head + pluralize(word)
+}
+
+// pluralize returns the plural of a lowercase English noun.
+func pluralize(word string) string {
+	if p, ok := irregularPlurals[word]; ok {
+		return p
+	}
+	if uncountables[word] {
+		return word
+	}
+	for _, r := range pluralRules {
+		if r.re.MatchString(word) {
+			return r.re.ReplaceAllString(word, r.rep)
+		}
+	}
+	return word + "s"
+}
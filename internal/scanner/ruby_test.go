@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableize(t *testing.T) {
+	tests := map[string]string{
+		"User":          "users",
+		"UserProfile":   "user_profiles",
+		"Category":      "categories",
+		"Address":       "addresses",
+		"Person":        "people",
+		"SalesPerson":   "sales_people",
+		"Status":        "statuses",
+		"Datum":         "data",
+		"Analysis":      "analyses",
+		"Knife":         "knives",
+		"Day":           "days",
+		"Box":           "boxes",
+		"Sheep":         "sheep",
+		"HTMLPage":      "html_pages",
+		"OAuth2Token":   "o_auth2_tokens",
+		"Price":         "prices",
+		"Matrix":        "matrices",
+		"Quiz":          "quizzes",
+		"News":          "news",
+		"Bus":           "buses",
+		"ShippingIndex": "shipping_indices",
+	}
+	for class, want := range tests {
+		if got := tableize(class); got != want {
+			t.Errorf("tableize(%q) = %q, want %q", class, got, want)
+		}
+	}
+}
+
+func TestScan_RubyModels(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app/models/application_record.rb", `class ApplicationRecord < ActiveRecord::Base
+  primary_abstract_class
+end
+`)
+	writeFile(t, dir, "app/models/models.rb", `class LineItem < ApplicationRecord
+  belongs_to :order
+end
+
+module Admin
+  class User < ::ActiveRecord::Base
+    self.table_name = "admin_accounts"
+  end
+end
+
+class Base < ApplicationRecord
+  self.abstract_class = true
+end
+
+class Post < Sequel::Model
+end
+
+class Legacy < ApplicationRecord # pgspectre:ignore
+end
+
+class Service < BaseService
+end
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []TableRef
+	for _, r := range result.Refs {
+		if r.Pattern == PatternORM {
+			got = append(got, r)
+		}
+	}
+	want := []TableRef{
+		{Table: "admin_accounts", File: "app/models/models.rb", Line: 7, Pattern: PatternORM, Context: ContextUnknown},
+		{Table: "line_items", File: "app/models/models.rb", Line: 1, Pattern: PatternORM, Context: ContextUnknown},
+		{Table: "posts", File: "app/models/models.rb", Line: 15, Pattern: PatternORM, Context: ContextUnknown},
+		{Table: "legacies", File: "app/models/models.rb", Line: 18, Pattern: PatternORM, Context: ContextUnknown, Suppressed: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ORM refs = %+v, want %+v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package scanner
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -18,10 +19,18 @@ var supportedExtensions = map[string]bool{
 	".jsx":    true,
 	".tsx":    true,
 	".java":   true,
+	".kt":     true,
+	".cs":     true,
+	".php":    true,
+	".ex":     true,
+	".exs":    true,
 	".rb":     true,
 	".sql":    true,
 	".rs":     true,
 	".prisma": true,
+	".xml":    true, // Liquibase changelogs
+	".yaml":   true,
+	".yml":    true,
 }
 
 var skipDirs = map[string]bool{
@@ -36,63 +45,134 @@ var skipDirs = map[string]bool{
 	"bin":          true,
 }
 
+// isSupported reports whether the scanner reads the file at path.
+func isSupported(path string) bool {
+	return supportedExtensions[strings.ToLower(filepath.Ext(path))] || supportedFiles[filepath.Base(path)]
+}
+
 // Scan walks a code repository and extracts SQL table references.
 func Scan(repoPath string) (ScanResult, error) {
-	result := ScanResult{RepoPath: repoPath}
+	return scanSequential(repoPath, ScanOptions{})
+}
 
-	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+func scanSequential(repoPath string, opts ScanOptions) (ScanResult, error) {
+	c := newCollector(repoPath, opts.MaxRefBytes)
 
-		if d.IsDir() {
-			if skipDirs[d.Name()] {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if !supportedExtensions[ext] {
-			result.FilesSkipped++
+	err := walkRepo(repoPath, opts, func(path string, d fs.DirEntry, supported bool) error {
+		if !supported {
+			c.result.FilesSkipped++
 			return nil
 		}
 
 		relPath, _ := filepath.Rel(repoPath, path)
-		refs, colRefs, err := scanFile(path, relPath)
-		if err != nil {
-			return fmt.Errorf("scan %s: %w", relPath, err)
+		fr := scanPath(path, relPath, opts)
+		if fr.err != nil {
+			return fmt.Errorf("scan %s: %w", relPath, fr.err)
 		}
-
-		result.Refs = append(result.Refs, refs...)
-		result.ColumnRefs = append(result.ColumnRefs, colRefs...)
-		result.FilesScanned++
-		return nil
+		return c.add(fr)
 	})
 	if err != nil {
-		return result, fmt.Errorf("walk %s: %w", repoPath, err)
+		err = fmt.Errorf("walk %s: %w", repoPath, err)
+	}
+	return c.finish(err)
+}
+
+// ScanFiles scans the given files, as opts configure, instead of walking a
+// repository. Files of unsupported types are counted as skipped; the
+// others are reported under their paths as given, whether or not a
+// .gitignore or opts.ExcludePaths would leave them out of a walk.
+func ScanFiles(paths []string, opts ScanOptions) (ScanResult, error) {
+	c := newCollector("", opts.MaxRefBytes)
+	filter := newFileFilter("", opts)
+	for _, path := range paths {
+		if !filter.supported(path) {
+			c.result.FilesSkipped++
+			continue
+		}
+		fr := scanPath(path, path, opts)
+		if fr.err != nil {
+			return c.finish(fmt.Errorf("scan %s: %w", path, fr.err))
+		}
+		if err := c.add(fr); err != nil {
+			return c.finish(err)
+		}
 	}
+	return c.finish(nil)
+}
 
-	result.Tables = uniqueTables(result.Refs)
-	result.Columns = uniqueColumns(result.ColumnRefs)
-	return result, nil
+// ScanReader scans r as the contents of a file at path, whose extension
+// decides its language, as with a file piped from an editor. A path of
+// an unsupported type is counted as skipped.
+func ScanReader(r io.Reader, path string, opts ScanOptions) (ScanResult, error) {
+	c := newCollector("", opts.MaxRefBytes)
+	if !newFileFilter("", opts).supported(path) {
+		c.result.FilesSkipped++
+		return c.finish(nil)
+	}
+	fr := scanContent(r, path, opts)
+	if fr.err != nil {
+		return c.finish(fmt.Errorf("scan %s: %w", path, fr.err))
+	}
+	return c.finish(c.add(fr))
 }
 
-func scanFile(path, relPath string) ([]TableRef, []ColumnRef, error) {
+// scanFile scans one file as opts configure; relPath is its path in the
+// repository.
+func scanFile(path, relPath string, opts ScanOptions) fileResult {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, nil, err
+		return fileResult{err: err, filePath: relPath}
 	}
 	defer func() { _ = f.Close() }()
+	return scanContent(f, relPath, opts)
+}
 
-	ext := strings.ToLower(filepath.Ext(path))
+// scanContent scans the contents of a file; its language is that of
+// relPath.
+func scanContent(r io.Reader, relPath string, opts ScanOptions) fileResult {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if liquibaseExts[ext] {
+		return scanLiquibase(r, relPath, ext)
+	}
+	if filepath.Base(relPath) == "manifest.json" {
+		return scanManifest(r, relPath)
+	}
 	buf := newSQLBuffer()
+	comments := newCommentStripper(commentSyntaxes[ext])
+	var models modelTracker
+	if newTracker, ok := modelTrackers[ext]; ok {
+		models = newTracker()
+	}
+	if ext == ".py" && opts.DjangoTables {
+		models = modelTrackerSet{models, &djangoModels{labels: opts.DjangoAppLabels}}
+	}
 
 	var refs []TableRef
 	var colRefs []ColumnRef
+	var queries []QueryRef
+	var dynamic []DynamicRef
 
-	scanText := func(text string, line int, suppressed bool) {
-		for _, m := range ScanLine(text) {
+	// scanText scans a complete statement, or, when code is set, a line of
+	// code with the CTE names in scope for it. With the AST parser, SQL
+	// that parses replaces what the SQL patterns find; ORM and migration
+	// patterns still apply.
+	scanText := func(text string, line int, suppressed, code bool, ctes map[string]bool) {
+		if !code {
+			ctes = cteNames(text, false)
+		}
+		matches := scanLine(text, ctes)
+		columns := ScanLineColumns(text)
+		if opts.Parser == ParserAST {
+			if tables, cols, ok := scanStatementAST(text, !code); ok {
+				matches = withoutSQLMatches(matches, tables)
+				columns = cols
+			}
+		}
+		dml := false
+		for _, m := range matches {
+			if m.Pattern == PatternSQL {
+				dml = true
+			}
 			refs = append(refs, TableRef{
 				Table:      m.Table,
 				Schema:     m.Schema,
@@ -103,7 +183,7 @@ func scanFile(path, relPath string) ([]TableRef, []ColumnRef, error) {
 				Suppressed: suppressed,
 			})
 		}
-		for _, cm := range ScanLineColumns(text) {
+		for _, cm := range columns {
 			colRefs = append(colRefs, ColumnRef{
 				Table:      cm.Table,
 				Column:     cm.Column,
@@ -114,48 +194,115 @@ func scanFile(path, relPath string) ([]TableRef, []ColumnRef, error) {
 				Suppressed: suppressed,
 			})
 		}
+		if stmt, ok := extractStatement(text); ok && dml && !suppressed {
+			q := QueryRef{Fingerprint: Fingerprint(stmt), File: relPath, Line: line}
+			if selectsStar(stmt) {
+				for _, m := range matches {
+					if m.Pattern == PatternSQL && m.Context == ContextSelect {
+						q.StarTables = append(q.StarTables, m.Table)
+					}
+				}
+			}
+			queries = append(queries, q)
+		}
+		if keyword, ok := dynamicTable(text); ok && !suppressed {
+			dynamic = append(dynamic, DynamicRef{File: relPath, Line: line, Keyword: keyword})
+		}
 	}
 
-	sc := bufio.NewScanner(f)
+	sc := bufio.NewScanner(r)
 	lineNum := 0
 
 	if ext == ".sql" {
+		var dbt dbtModel
 		for sc.Scan() {
 			lineNum++
-			rawLine := sc.Text()
+			rawLine := dbt.resolve(sc.Text())
 			ignored := hasInlineIgnore(rawLine)
-			for _, s := range buf.feedSQL(lineNum, rawLine) {
-				scanText(s.text, s.lineNum, ignored)
+			for _, s := range buf.feedSQL(lineNum, comments.strip(rawLine)) {
+				scanText(s.text, s.lineNum, ignored, false, nil)
 			}
 		}
+		refs = append(refs, dbt.refs(relPath)...)
 	} else {
 		for sc.Scan() {
 			lineNum++
 			line := sc.Text()
 			ignored := hasInlineIgnore(line)
+			// Lines of a multi-line string are SQL, not code; the buffer
+			// strips their SQL comments when the string closes.
+			if !buf.active() {
+				line = comments.strip(line)
+			}
 
 			stmt, buffered := buf.feedCode(lineNum, line, ext)
 			if stmt != nil {
-				scanText(stmt.text, stmt.lineNum, ignored)
+				scanText(stmt.text, stmt.lineNum, ignored, false, nil)
 			}
 			if !buffered {
-				scanText(line, lineNum, ignored)
+				scanText(line, lineNum, ignored, true, buf.lineCTEs(lineNum, line))
+				if models != nil {
+					models.line(lineNum, line, ignored)
+				}
 			}
 		}
 	}
 
 	// Flush any remaining buffered content
 	if s := buf.flush(); s != nil {
-		scanText(s.text, s.lineNum, false)
+		scanText(s.text, s.lineNum, false, false, nil)
 	}
+	if models != nil {
+		modelRefs, modelColRefs := models.refs(relPath)
+		refs = append(refs, modelRefs...)
+		colRefs = append(colRefs, modelColRefs...)
+	}
+
+	return fileResult{refs: refs, colRefs: colRefs, queries: queries, dynamic: dynamic, err: sc.Err(), filePath: relPath}
+}
 
-	return refs, colRefs, sc.Err()
+// withoutSQLMatches replaces the SQL pattern matches in matches with
+// parsed ones.
+func withoutSQLMatches(matches, parsed []tableMatch) []tableMatch {
+	out := parsed
+	for _, m := range matches {
+		if m.Pattern != PatternSQL {
+			out = append(out, m)
+		}
+	}
+	return out
 }
 
 func hasInlineIgnore(line string) bool {
 	return strings.Contains(line, "pgspectre:ignore")
 }
 
+// Restrict returns a copy of the result keeping only references to tables
+// for which keep returns true. Spilled references that match are loaded
+// into memory.
+func (r ScanResult) Restrict(keep func(table string) bool) (ScanResult, error) {
+	out := r
+	out.Refs = nil
+	out.ColumnRefs = nil
+	out.spill = nil
+	err := r.EachRef(func(ref TableRef) {
+		if keep(ref.Table) {
+			out.Refs = append(out.Refs, ref)
+		}
+	})
+	if err != nil {
+		return r, err
+	}
+	for _, ref := range r.ColumnRefs {
+		if keep(ref.Table) {
+			out.ColumnRefs = append(out.ColumnRefs, ref)
+		}
+	}
+	out.Tables = uniqueTables(out.Refs)
+	out.Columns = uniqueColumns(out.ColumnRefs)
+	return out, nil
+}
+
 func uniqueColumns(refs []ColumnRef) []string {
 	seen := make(map[string]bool)
 	for _, r := range refs {
@@ -3,10 +3,12 @@ package scanner
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
-func writeFile(t *testing.T, dir, name, content string) {
+func writeFile(t testing.TB, dir, name, content string) {
 	t.Helper()
 	path := filepath.Join(dir, name)
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -202,6 +204,95 @@ VALUES (1, 2);`)
 	}
 }
 
+func TestScan_CTENames(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, dir, "report.sql", `WITH recent AS (
+    SELECT * FROM orders WHERE created_at > now() - interval '1 day'
+),
+totals AS (
+    SELECT user_id, count(*) AS n FROM recent GROUP BY user_id
+)
+SELECT * FROM totals JOIN users ON users.id = totals.user_id;
+
+SELECT * FROM recent_events;`)
+
+	// Go backtick multi-line string
+	writeFile(t, dir, "repo.go", "package main\nvar q = `WITH active AS (\n  SELECT id FROM accounts\n)\nSELECT * FROM active`\n")
+
+	// Query built by concatenating strings, one line at a time
+	writeFile(t, dir, "app.js", "const q = 'WITH paid AS (SELECT * FROM invoices) ' +\n  'SELECT * FROM paid';\nconst r = 'SELECT * FROM paid';\n")
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableSet := make(map[string]bool)
+	for _, tbl := range result.Tables {
+		tableSet[tbl] = true
+	}
+	for _, want := range []string{"orders", "users", "recent_events", "accounts", "invoices"} {
+		if !tableSet[want] {
+			t.Errorf("expected table %q, got %v", want, result.Tables)
+		}
+	}
+	for _, cte := range []string{"recent", "totals", "active"} {
+		if tableSet[cte] {
+			t.Errorf("CTE %q reported as a table: %v", cte, result.Tables)
+		}
+	}
+	var paidLines []int
+	for _, r := range result.Refs {
+		if r.Table == "paid" {
+			paidLines = append(paidLines, r.Line)
+		}
+	}
+	if len(paidLines) != 1 || paidLines[0] != 3 {
+		t.Errorf("paid refs on lines %v, want only line 3 (after the statement ended)", paidLines)
+	}
+}
+
+func TestScan_IgnoresComments(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, dir, "0042_orders.sql", `/*
+ * Replaces legacy_orders. Rollback:
+ *   INSERT INTO legacy_orders SELECT * FROM orders;
+ */
+-- DROP TABLE legacy_archive;
+CREATE TABLE orders (id INT); -- was FROM old_orders`)
+
+	writeFile(t, dir, "repo.go", "package main\n\n/*\nSELECT * FROM go_block_comment\n*/\n\n// SELECT * FROM go_line_comment\nvar q = \"SELECT * FROM accounts\" // JOIN go_trailing_comment\n")
+
+	writeFile(t, dir, "app.py", "# SELECT * FROM py_comment\ncur.execute(\"SELECT * FROM payments\")\n")
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableSet := make(map[string]bool)
+	for _, tbl := range result.Tables {
+		tableSet[tbl] = true
+	}
+	for _, want := range []string{"orders", "accounts", "payments"} {
+		if !tableSet[want] {
+			t.Errorf("expected table %q, got %v", want, result.Tables)
+		}
+	}
+	for _, commented := range []string{"legacy_orders", "legacy_archive", "old_orders", "go_block_comment", "go_line_comment", "go_trailing_comment", "py_comment"} {
+		if tableSet[commented] {
+			t.Errorf("table %q from a comment reported: %v", commented, result.Tables)
+		}
+	}
+	for _, r := range result.Refs {
+		if r.Table == "orders" && r.Line != 6 {
+			t.Errorf("orders ref on line %d, want 6", r.Line)
+		}
+	}
+}
+
 func TestUniqueTables_Sorted(t *testing.T) {
 	refs := []TableRef{
 		{Table: "Zebra"},
@@ -220,3 +311,211 @@ func TestUniqueTables_Sorted(t *testing.T) {
 		t.Errorf("expected [apple banana zebra], got %v", tables)
 	}
 }
+
+func TestScanResult_Restrict(t *testing.T) {
+	result := ScanResult{
+		Refs: []TableRef{
+			{Table: "orders", File: "a.sql"},
+			{Table: "order_items", File: "a.sql"},
+			{Table: "users", File: "b.sql"},
+		},
+		ColumnRefs: []ColumnRef{
+			{Table: "orders", Column: "status"},
+			{Table: "users", Column: "email"},
+		},
+		FilesScanned: 2,
+	}
+	result.Tables = uniqueTables(result.Refs)
+	result.Columns = uniqueColumns(result.ColumnRefs)
+
+	got, err := result.Restrict(func(table string) bool { return strings.HasPrefix(table, "order") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Refs) != 2 || len(got.ColumnRefs) != 1 {
+		t.Fatalf("got %d refs, %d column refs; want 2, 1", len(got.Refs), len(got.ColumnRefs))
+	}
+	if len(got.Tables) != 2 || got.Tables[0] != "order_items" || got.Tables[1] != "orders" {
+		t.Errorf("Tables = %v", got.Tables)
+	}
+	if len(got.Columns) != 1 || got.Columns[0] != "orders.status" {
+		t.Errorf("Columns = %v", got.Columns)
+	}
+	if got.FilesScanned != 2 {
+		t.Errorf("FilesScanned = %d, want 2", got.FilesScanned)
+	}
+	if len(result.Refs) != 3 {
+		t.Error("Restrict modified the original result")
+	}
+}
+
+func TestScan_DynamicRefs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app.py", `cur.execute(f"SELECT * FROM {table}")
+cur.execute("SELECT id FROM users")
+cur.execute("DELETE FROM " + table)  # pgspectre:ignore
+query = f"""
+    SELECT id
+    FROM {table}
+"""
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DynamicRef{
+		{File: "app.py", Line: 1, Keyword: "FROM"},
+		{File: "app.py", Line: 4, Keyword: "FROM"},
+	}
+	if !reflect.DeepEqual(result.DynamicRefs, want) {
+		t.Errorf("dynamicRefs = %+v, want %+v", result.DynamicRefs, want)
+	}
+}
+
+func TestScan_CSharp(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Data/Repo.cs", `[Table("customers")]
+public class Customer { }
+
+public class Repo
+{
+    // SELECT * FROM commented_out
+    public Task<Order> Get(int id) =>
+        conn.QuerySingleAsync<Order>("SELECT id, total FROM orders WHERE id = @id", new { id });
+
+    const string Report = """
+        SELECT sum(total)
+        FROM invoices
+        """;
+}
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"customers", "invoices", "orders"}
+	if !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+}
+
+func TestScan_PHP(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "src/Entity/Product.php", `<?php
+#[ORM\Entity]
+#[ORM\Table(name: 'products', schema: 'shop')]
+class Product
+{
+    // DB::table('commented_out')
+    public function stock(): int
+    {
+        return DB::table('inventory')->where('product_id', $this->id)->sum('qty');
+    }
+}
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"inventory", "products"}
+	if !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+	for _, r := range result.Refs {
+		if r.Table == "products" && r.Schema != "shop" {
+			t.Errorf("products schema = %q, want shop", r.Schema)
+		}
+	}
+}
+
+func TestScan_ReplayMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "db/migrations/000001_init.up.sql", "CREATE TABLE users (id int);\nCREATE TABLE legacy (id int);\n")
+	writeFile(t, dir, "db/migrations/000001_init.down.sql", "DROP TABLE legacy;\nDROP TABLE users;\n")
+	writeFile(t, dir, "db/migrations/000002_drop_legacy.up.sql", "DROP TABLE legacy;\nUPDATE users SET id = id;\n")
+	writeFile(t, dir, "app.go", `db.Query("SELECT id FROM orders")`)
+
+	for _, workers := range []int{1, 4} {
+		plain, err := ScanWithOptions(dir, ScanOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if want := []string{"legacy", "orders", "users"}; !reflect.DeepEqual(plain.Tables, want) {
+			t.Errorf("without replay, tables = %v, want %v", plain.Tables, want)
+		}
+
+		result, err := ScanWithOptions(dir, ScanOptions{Workers: workers, ReplayMigrations: true})
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if want := []string{"orders", "users"}; !reflect.DeepEqual(result.Tables, want) {
+			t.Errorf("with replay, tables = %v, want %v", result.Tables, want)
+		}
+		var created, updated bool
+		for _, r := range result.Refs {
+			if r.Table != "users" {
+				continue
+			}
+			switch {
+			case r.Pattern == PatternMigration && r.File == filepath.Join("db", "migrations", "000001_init.up.sql") && r.Line == 1:
+				created = true
+			case r.Pattern == PatternSQL && r.Context == ContextUpdate:
+				updated = true
+			}
+		}
+		if !created || !updated {
+			t.Errorf("users refs: created=%v updated=%v, want both: %+v", created, updated, result.Refs)
+		}
+	}
+}
+
+func TestScanFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app.go", `package main
+func main() { db.Query("SELECT * FROM users") }`)
+	writeFile(t, dir, "query.sql", "SELECT name FROM accounts;")
+	writeFile(t, dir, "README.md", "SELECT * FROM docs")
+
+	paths := []string{filepath.Join(dir, "app.go"), filepath.Join(dir, "query.sql"), filepath.Join(dir, "README.md")}
+	result, err := ScanFiles(paths, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Tables; !reflect.DeepEqual(got, []string{"accounts", "users"}) {
+		t.Errorf("tables = %v, want [accounts users]", got)
+	}
+	if result.FilesScanned != 2 || result.FilesSkipped != 1 {
+		t.Errorf("scanned %d, skipped %d; want 2, 1", result.FilesScanned, result.FilesSkipped)
+	}
+	if f := result.Refs[0].File; f != paths[0] && f != paths[1] {
+		t.Errorf("ref file = %q, want a path as given", f)
+	}
+
+	if _, err := ScanFiles([]string{filepath.Join(dir, "missing.sql")}, ScanOptions{}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestScanReader(t *testing.T) {
+	src := `package main
+func main() { db.Query("SELECT id FROM sessions") }`
+	result, err := ScanReader(strings.NewReader(src), "internal/app.go", ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Tables, []string{"sessions"}) || result.Refs[0].File != "internal/app.go" {
+		t.Errorf("tables = %v, refs = %+v; want sessions in internal/app.go", result.Tables, result.Refs)
+	}
+
+	result, err = ScanReader(strings.NewReader(src), "notes.txt", ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Refs) != 0 || result.FilesSkipped != 1 {
+		t.Errorf("unsupported input: refs = %d, skipped = %d", len(result.Refs), result.FilesSkipped)
+	}
+}
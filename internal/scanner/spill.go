@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/ppiankov/pgspectre/internal/migration"
+)
+
+// refSpill holds table references moved out of memory into a temp file,
+// one JSON object per line.
+type refSpill struct {
+	path string
+	n    int
+}
+
+// collector merges per-file results into a ScanResult, moving table
+// references to a temp file whenever the in-memory ones would exceed
+// maxRefBytes. maxRefBytes <= 0 keeps everything in memory.
+type collector struct {
+	result      ScanResult
+	maxRefBytes int64
+	refBytes    int64
+	tables      map[string]bool
+	migrations  []migration.File // versioned migrations to replay
+	file        *os.File
+	w           *bufio.Writer
+}
+
+func newCollector(repoPath string, maxRefBytes int64) *collector {
+	return &collector{
+		result:      ScanResult{RepoPath: repoPath},
+		maxRefBytes: maxRefBytes,
+		tables:      make(map[string]bool),
+	}
+}
+
+func (c *collector) add(fr fileResult) error {
+	if fr.skipped {
+		c.result.FilesSkipped++
+		return nil
+	}
+	for _, r := range fr.refs {
+		c.tables[strings.ToLower(r.Table)] = true
+		c.refBytes += refSize(r)
+	}
+	c.result.Refs = append(c.result.Refs, fr.refs...)
+	c.result.ColumnRefs = append(c.result.ColumnRefs, fr.colRefs...)
+	c.result.Queries = append(c.result.Queries, fr.queries...)
+	c.result.DynamicRefs = append(c.result.DynamicRefs, fr.dynamic...)
+	if fr.migration != nil {
+		c.migrations = append(c.migrations, *fr.migration)
+	}
+	c.result.FilesScanned++
+	if c.maxRefBytes > 0 && c.refBytes > c.maxRefBytes {
+		return c.spill()
+	}
+	return nil
+}
+
+// spill appends the in-memory table references to the temp file.
+func (c *collector) spill() error {
+	if c.file == nil {
+		f, err := os.CreateTemp("", "pgspectre-refs-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("create spill file: %w", err)
+		}
+		c.file = f
+		c.w = bufio.NewWriter(f)
+		c.result.spill = &refSpill{path: f.Name()}
+	}
+	enc := json.NewEncoder(c.w)
+	for _, r := range c.result.Refs {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("write spill file: %w", err)
+		}
+	}
+	c.result.spill.n += len(c.result.Refs)
+	c.result.Refs = nil
+	c.refBytes = 0
+	return nil
+}
+
+// finish closes the spill file and fills in the unique table and column
+// lists. On error the spill file is removed.
+func (c *collector) finish(err error) (ScanResult, error) {
+	if err == nil && len(c.migrations) > 0 {
+		err = c.replay()
+	}
+	if c.file != nil {
+		if ferr := c.w.Flush(); ferr != nil && err == nil {
+			err = fmt.Errorf("write spill file: %w", ferr)
+		}
+		if cerr := c.file.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("close spill file: %w", cerr)
+		}
+	}
+	if err != nil {
+		_ = c.result.Close()
+		c.result.spill = nil
+		return c.result, err
+	}
+	c.result.Tables = sortedKeys(c.tables)
+	c.result.Columns = uniqueColumns(c.result.ColumnRefs)
+	return c.result, nil
+}
+
+// refSize estimates the heap a table reference occupies.
+func refSize(r TableRef) int64 {
+	return int64(unsafe.Sizeof(r)) + int64(len(r.Table)+len(r.Schema)+len(r.File)+len(r.Pattern)+len(r.Context))
+}
+
+// Spilled reports how many table references are held in a temp file
+// rather than in Refs.
+func (r ScanResult) Spilled() int {
+	if r.spill == nil {
+		return 0
+	}
+	return r.spill.n
+}
+
+// RefCount returns the number of table references, including spilled ones.
+func (r ScanResult) RefCount() int {
+	return len(r.Refs) + r.Spilled()
+}
+
+// EachRef calls fn for every table reference, reading spilled ones back
+// from the temp file first.
+func (r ScanResult) EachRef(fn func(TableRef)) error {
+	if r.spill != nil {
+		f, err := os.Open(r.spill.path)
+		if err != nil {
+			return fmt.Errorf("open spill file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		dec := json.NewDecoder(bufio.NewReader(f))
+		for range r.spill.n {
+			var ref TableRef
+			if err := dec.Decode(&ref); err != nil {
+				return fmt.Errorf("read spill file: %w", err)
+			}
+			fn(ref)
+		}
+	}
+	for _, ref := range r.Refs {
+		fn(ref)
+	}
+	return nil
+}
+
+// LoadRefs reads spilled table references back into Refs and removes the
+// temp file.
+func (r *ScanResult) LoadRefs() error {
+	if r.spill == nil {
+		return nil
+	}
+	refs := make([]TableRef, 0, r.RefCount())
+	if err := r.EachRef(func(ref TableRef) { refs = append(refs, ref) }); err != nil {
+		return err
+	}
+	if err := r.Close(); err != nil {
+		return err
+	}
+	r.Refs = refs
+	r.spill = nil
+	return nil
+}
+
+// Close removes the spill file, if any. Spilled references are no longer
+// readable afterwards.
+func (r ScanResult) Close() error {
+	if r.spill == nil {
+		return nil
+	}
+	if err := os.Remove(r.spill.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove spill file: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestScanWithOptions_SpillsRefs(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 20 {
+		writeFile(t, dir, fmt.Sprintf("q%02d.sql", i), fmt.Sprintf("SELECT * FROM t%02d;\nSELECT * FROM users;\n", i))
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			want, err := ScanParallel(dir, workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ScanWithOptions(dir, ScanOptions{Workers: workers, MaxRefBytes: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Spilled() == 0 {
+				t.Fatal("expected refs to spill")
+			}
+			path := got.spill.path
+
+			if got.RefCount() != len(want.Refs) {
+				t.Errorf("RefCount = %d, want %d", got.RefCount(), len(want.Refs))
+			}
+			if fmt.Sprint(got.Tables) != fmt.Sprint(want.Tables) {
+				t.Errorf("Tables = %v, want %v", got.Tables, want.Tables)
+			}
+			users := 0
+			if err := got.EachRef(func(r TableRef) {
+				if r.Table == "users" {
+					users++
+				}
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if users != 20 {
+				t.Errorf("EachRef saw %d users refs, want 20", users)
+			}
+
+			restricted, err := got.Restrict(func(table string) bool { return table == "users" })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restricted.Spilled() != 0 || len(restricted.Refs) != 20 {
+				t.Errorf("Restrict: %d in memory, %d spilled; want 20, 0", len(restricted.Refs), restricted.Spilled())
+			}
+
+			if err := got.LoadRefs(); err != nil {
+				t.Fatal(err)
+			}
+			if got.Spilled() != 0 || len(got.Refs) != len(want.Refs) {
+				t.Errorf("LoadRefs: %d in memory, %d spilled", len(got.Refs), got.Spilled())
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("spill file %s not removed", path)
+			}
+		})
+	}
+}
+
+func TestScanWithOptions_CacheSpillsRefs(t *testing.T) {
+	useTempCacheDir(t)
+	dir := t.TempDir()
+	for i := range 20 {
+		writeFile(t, dir, fmt.Sprintf("q%02d.sql", i), fmt.Sprintf("SELECT * FROM t%02d;\n", i))
+	}
+
+	got, err := ScanWithOptions(dir, ScanOptions{Workers: 1, MaxRefBytes: 1, Cache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = got.Close() }()
+	if got.Spilled() == 0 {
+		t.Fatal("expected refs to spill")
+	}
+	if got.RefCount() != 20 {
+		t.Errorf("RefCount = %d, want 20", got.RefCount())
+	}
+	path, err := scanCachePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("scan under MaxRefBytes wrote a cache: %v", err)
+	}
+}
+
+func TestScanWithOptions_NoCapKeepsRefsInMemory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "q.sql", "SELECT * FROM users;")
+
+	result, err := ScanWithOptions(dir, ScanOptions{Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Spilled() != 0 || len(result.Refs) != 1 {
+		t.Errorf("got %d in memory, %d spilled; want 1, 0", len(result.Refs), result.Spilled())
+	}
+	if err := result.Close(); err != nil {
+		t.Errorf("Close without spill: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SQLAlchemy tables: Core Table("name", metadata, Column("col", ...)),
+// and declarative classes whose __tablename__ names the table and whose
+// Column() and mapped_column() attributes are its columns, named by the
+// attribute unless the call names one.
+var (
+	saTableRe      = regexp.MustCompile(`(?:^|[^\w.])(?:sa\.|sqlalchemy\.|db\.)?Table\s*\(`)
+	saNameRe       = regexp.MustCompile(`^\s*["'](\w+)["']`)
+	saCoreColumnRe = regexp.MustCompile(`\bColumn\s*\(\s*["'](\w+)["']`)
+	saSchemaRe     = regexp.MustCompile(`\bschema\s*=\s*["'](\w+)["']`)
+	saClassRe      = regexp.MustCompile(`^(\s*)class\s+\w+\s*[(:]`)
+	saTablenameRe  = regexp.MustCompile(`^\s*__tablename__\s*=\s*["'](\w+)["']`)
+	saTableArgsRe  = regexp.MustCompile(`^\s*__table_args__\s*=`)
+	saSchemaKeyRe  = regexp.MustCompile(`["']schema["']\s*:\s*["'](\w+)["']`)
+	saAttrColumnRe = regexp.MustCompile(`^\s*(\w+)\s*(?::[^=]*)?=\s*(?:sa\.|sqlalchemy\.|db\.|orm\.)?(?:Column|mapped_column)\s*\(\s*(?:["'](\w+)["'])?`)
+	pyIndentRe     = regexp.MustCompile(`^(\s*)\S`)
+)
+
+// saTable is a Table() call or declarative class being read.
+type saTable struct {
+	name, schema string
+	line         int
+	suppressed   bool
+	columns      []ColumnRef
+}
+
+// sqlalchemyTables tracks the tables of one Python file. A Table() call
+// spans lines until its parentheses close; a declarative class until a
+// line is indented no deeper than the class, and its __tablename__ can
+// follow its columns.
+type sqlalchemyTables struct {
+	call        *saTable
+	depth       int // parentheses open in the Table() call
+	class       *saTable
+	classIndent int // indentation of the class line
+	tables      []*saTable
+	declared    []*saTable // classes, whose tables the patterns report
+}
+
+func (s *sqlalchemyTables) line(lineNum int, text string, suppressed bool) {
+	if ind := pyIndentRe.FindStringSubmatch(text); ind != nil && s.class != nil && len(ind[1]) <= s.classIndent {
+		s.class = nil
+	}
+
+	if s.call == nil {
+		if loc := saTableRe.FindStringIndex(text); loc != nil {
+			s.call = &saTable{line: lineNum, suppressed: suppressed}
+			s.tables = append(s.tables, s.call)
+			s.depth = 0
+			text = text[loc[1]-1:]
+		}
+	}
+	if s.call != nil {
+		s.tableLine(lineNum, text, suppressed)
+		return
+	}
+
+	if m := saClassRe.FindStringSubmatch(text); m != nil {
+		s.class = &saTable{line: lineNum, suppressed: suppressed}
+		s.classIndent = len(m[1])
+		s.declared = append(s.declared, s.class)
+		return
+	}
+	if s.class == nil {
+		return
+	}
+	switch {
+	case saTablenameRe.MatchString(text):
+		s.class.name = saTablenameRe.FindStringSubmatch(text)[1]
+	case saTableArgsRe.MatchString(text):
+		if m := saSchemaKeyRe.FindStringSubmatch(text); m != nil {
+			s.class.schema = m[1]
+		}
+	default:
+		if m := saAttrColumnRe.FindStringSubmatch(text); m != nil {
+			column := m[1]
+			if m[2] != "" {
+				column = m[2]
+			}
+			s.class.columns = append(s.class.columns, ColumnRef{Column: column, Line: lineNum, Context: ContextUnknown, Suppressed: suppressed})
+		}
+	}
+}
+
+// tableLine reads a line of a Table() call, from its opening parenthesis
+// on the first line.
+func (s *sqlalchemyTables) tableLine(lineNum int, text string, suppressed bool) {
+	t := s.call
+	if t.name == "" {
+		rest := text
+		if s.depth == 0 {
+			rest = strings.TrimPrefix(text, "(")
+		}
+		if m := saNameRe.FindStringSubmatch(rest); m != nil {
+			t.name = m[1]
+		}
+	}
+	if m := saSchemaRe.FindStringSubmatch(text); m != nil {
+		t.schema = m[1]
+	}
+	for _, m := range saCoreColumnRe.FindAllStringSubmatch(text, -1) {
+		t.columns = append(t.columns, ColumnRef{Column: m[1], Line: lineNum, Context: ContextUnknown, Suppressed: suppressed})
+	}
+	s.depth += strings.Count(text, "(") - strings.Count(text, ")")
+	if s.depth <= 0 {
+		s.call = nil
+	}
+}
+
+// refs returns the tables of the file's Table() calls, and the columns
+// of those and of its declarative classes.
+func (s *sqlalchemyTables) refs(relPath string) ([]TableRef, []ColumnRef) {
+	var refs []TableRef
+	var colRefs []ColumnRef
+	for _, t := range s.tables {
+		if !isValidTableName(t.name) {
+			continue
+		}
+		refs = append(refs, TableRef{Table: t.name, Schema: t.schema, File: relPath, Line: t.line, Pattern: PatternORM, Context: ContextUnknown, Suppressed: t.suppressed})
+		colRefs = t.appendColumns(colRefs, relPath)
+	}
+	for _, t := range s.declared {
+		if isValidTableName(t.name) {
+			colRefs = t.appendColumns(colRefs, relPath)
+		}
+	}
+	return refs, colRefs
+}
+
+func (t *saTable) appendColumns(colRefs []ColumnRef, relPath string) []ColumnRef {
+	for _, c := range t.columns {
+		c.Table, c.Schema, c.File = t.name, t.schema, relPath
+		colRefs = append(colRefs, c)
+	}
+	return colRefs
+}
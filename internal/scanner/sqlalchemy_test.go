@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan_SQLAlchemyTables(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app/tables.py", `import sqlalchemy as sa
+from sqlalchemy import Column, Integer, String, Table
+
+users = sa.Table(
+    "users",
+    metadata,
+    sa.Column("id", sa.Integer, primary_key=True),
+    sa.Column("email", sa.String(255), nullable=False),
+    schema="auth",
+)
+
+tags = Table("tags", metadata, Column("name", String), Column("slug", String))
+
+# orders = Table("orders", metadata)
+
+class Order(Base):
+    __tablename__ = "orders"
+
+    id = Column(Integer, primary_key=True)
+    total: Mapped[int] = mapped_column("amount_cents")
+    user = relationship("User")
+
+    def label(self):
+        return Column("ignored")
+
+class Mixin:
+    created = Column(Integer)
+`)
+
+	result, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders", "tags", "users"}; !reflect.DeepEqual(result.Tables, want) {
+		t.Errorf("tables = %v, want %v", result.Tables, want)
+	}
+	for _, ref := range result.Refs {
+		if ref.Table == "users" && (ref.Schema != "auth" || ref.Line != 4) {
+			t.Errorf("users ref = %s at line %d, want auth at 4", ref.Schema, ref.Line)
+		}
+	}
+
+	var got []string
+	for _, c := range result.ColumnRefs {
+		got = append(got, c.Table+"."+c.Column)
+	}
+	want := []string{"users.id", "users.email", "tags.name", "tags.slug", "orders.id", "orders.amount_cents"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v\nwant %v", got, want)
+	}
+}
@@ -13,14 +13,18 @@ const (
 type Context string
 
 const (
-	ContextSelect  Context = "SELECT"
-	ContextInsert  Context = "INSERT"
-	ContextUpdate  Context = "UPDATE"
-	ContextDelete  Context = "DELETE"
-	ContextDDL     Context = "DDL"
-	ContextWhere   Context = "WHERE"
-	ContextOrderBy Context = "ORDER_BY"
-	ContextUnknown Context = "UNKNOWN"
+	ContextSelect    Context = "SELECT"
+	ContextInsert    Context = "INSERT"
+	ContextUpdate    Context = "UPDATE"
+	ContextDelete    Context = "DELETE"
+	ContextDDL       Context = "DDL"
+	ContextWhere     Context = "WHERE"
+	ContextOrderBy   Context = "ORDER_BY"
+	ContextJSONB     Context = "JSONB_FILTER" // jsonb operator or function in a condition
+	ContextJoin      Context = "JOIN"         // JOIN ... ON or USING condition
+	ContextConflict  Context = "ON_CONFLICT"  // INSERT ... ON CONFLICT (col) target
+	ContextReturning Context = "RETURNING"    // INSERT/UPDATE/DELETE ... RETURNING list
+	ContextUnknown   Context = "UNKNOWN"
 )
 
 // TableRef is a single reference to a database table found in code.
@@ -45,13 +49,26 @@ type ColumnRef struct {
 	Suppressed bool    `json:"suppressed,omitempty"`
 }
 
+// DynamicRef is a statement whose table name is built at runtime, by
+// concatenation, interpolation, or a format verb, so the table it
+// references can't be read from the code.
+type DynamicRef struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Keyword string `json:"keyword"` // FROM, JOIN, INSERT INTO, or UPDATE
+}
+
 // ScanResult holds all table and column references found in a code repository.
 type ScanResult struct {
-	RepoPath     string      `json:"repoPath"`
-	Refs         []TableRef  `json:"refs"`
-	ColumnRefs   []ColumnRef `json:"columnRefs,omitempty"`
-	Tables       []string    `json:"tables"`
-	Columns      []string    `json:"columns,omitempty"`
-	FilesScanned int         `json:"filesScanned"`
-	FilesSkipped int         `json:"filesSkipped,omitempty"`
+	RepoPath     string       `json:"repoPath"`
+	Refs         []TableRef   `json:"refs"`
+	ColumnRefs   []ColumnRef  `json:"columnRefs,omitempty"`
+	Tables       []string     `json:"tables"`
+	Columns      []string     `json:"columns,omitempty"`
+	Queries      []QueryRef   `json:"queries,omitempty"` // DML statements, for matching pg_stat_statements
+	DynamicRefs  []DynamicRef `json:"dynamicRefs,omitempty"`
+	FilesScanned int          `json:"filesScanned"`
+	FilesSkipped int          `json:"filesSkipped,omitempty"`
+
+	spill *refSpill // table references moved out of Refs, if any
 }
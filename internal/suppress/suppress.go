@@ -56,7 +56,7 @@ func (r *Rules) WithConfigFindings(findings []string) {
 func (r *Rules) IsSuppressed(f *analyzer.Finding) bool {
 	// Check config-level finding type suppressions
 	for _, ft := range r.configFindings {
-		if strings.EqualFold(string(f.Type), ft) {
+		if analyzer.CanonicalFindingType(ft) == f.Type {
 			return true
 		}
 	}
@@ -64,7 +64,7 @@ func (r *Rules) IsSuppressed(f *analyzer.Finding) bool {
 	// Check ignore file suppressions
 	for _, s := range r.ignoreFile.Suppressions {
 		if matchTable(s.Table, f.Table) {
-			if s.Type == "" || strings.EqualFold(s.Type, string(f.Type)) {
+			if s.Type == "" || analyzer.CanonicalFindingType(s.Type) == f.Type {
 				return true
 			}
 		}
@@ -143,6 +143,17 @@ func TestIsSuppressed_ConfigFindings(t *testing.T) {
 	}
 }
 
+func TestIsSuppressed_TypeAlias(t *testing.T) {
+	rules := &Rules{
+		ignoreFile: IgnoreFile{Suppressions: []Suppression{{Table: "*", Type: "schema_drift"}}},
+	}
+
+	f := analyzer.Finding{Type: analyzer.FindingMissingColumn}
+	if !rules.IsSuppressed(&f) {
+		t.Error("renamed finding type should match its old name")
+	}
+}
+
 func TestFilter(t *testing.T) {
 	rules := &Rules{
 		ignoreFile: IgnoreFile{
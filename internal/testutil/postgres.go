@@ -50,6 +50,24 @@ const postSeedSQL = `
 SELECT pg_stat_reset();
 CREATE TABLE empty_table (id INTEGER, data TEXT);
 ALTER TABLE empty_table SET (autovacuum_enabled = false);
+CREATE MATERIALIZED VIEW order_totals AS
+	SELECT user_id, sum(amount) AS total FROM orders GROUP BY user_id
+	WITH NO DATA;
+CREATE VIEW active_users AS SELECT id, name FROM users WHERE status = 'active';
+CREATE FUNCTION touch_order() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+	NEW.created_at := now();
+	RETURN NEW;
+END
+$$;
+CREATE FUNCTION user_order_count(uid INTEGER) RETURNS bigint LANGUAGE sql AS $$
+	SELECT count(*) FROM orders WHERE user_id = uid
+$$;
+CREATE TRIGGER orders_touch BEFORE UPDATE ON orders FOR EACH ROW EXECUTE FUNCTION touch_order();
+ALTER TABLE orders DISABLE TRIGGER orders_touch;
+CREATE TABLE events (id BIGINT NOT NULL, created_at DATE NOT NULL) PARTITION BY RANGE (created_at);
+CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+CREATE TABLE events_default PARTITION OF events DEFAULT;
 `
 
 const testDBEnv = "PGSPECTRE_TEST_DB_URL"